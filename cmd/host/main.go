@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"example.com/pluginhost/internal/host"
 )
@@ -15,21 +20,237 @@ func getenv(key, fallback string) string {
 	return fallback
 }
 
+// splitList 解析逗号分隔的环境变量，忽略空项
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// parseKeyMap 解析 "pluginId=key,..." 格式的按插件配置的密钥，name 用于日志
+func parseKeyMap(name, v string) map[string]string {
+	keys := make(map[string]string)
+	for _, item := range splitList(v) {
+		id, key, ok := strings.Cut(item, "=")
+		if !ok || id == "" || key == "" {
+			log.Printf("ignoring invalid %s entry: %q", name, item)
+			continue
+		}
+		keys[id] = key
+	}
+	return keys
+}
+
 func main() {
 	root := getenv("HOST_ROOT", ".")
 	pluginsDir := getenv("HOST_PLUGINS_DIR", filepath.Join(root, "plugins"))
 	vaultDir := getenv("HOST_VAULT_DIR", filepath.Join(root, "vault"))
 	addr := getenv("HOST_ADDR", ":8080")
 
-	cfg := host.Config{RootDir: root, PluginsDir: pluginsDir, VaultDir: vaultDir}
+	vaultQuota, err := strconv.ParseInt(getenv("HOST_VAULT_QUOTA_BYTES", "0"), 10, 64)
+	if err != nil {
+		log.Fatalf("invalid HOST_VAULT_QUOTA_BYTES: %v", err)
+	}
+
+	security := host.DefaultSecurityConfig()
+	security.AllowedPluginIDs = splitList(os.Getenv("HOST_ALLOWED_PLUGIN_IDS"))
+	security.BlockedPluginIDs = splitList(os.Getenv("HOST_BLOCKED_PLUGIN_IDS"))
+	if security.RequireMarketSHA256, err = strconv.ParseBool(getenv("HOST_REQUIRE_MARKET_SHA256", "false")); err != nil {
+		log.Fatalf("invalid HOST_REQUIRE_MARKET_SHA256: %v", err)
+	}
+	if security.MaxPlugins, err = strconv.Atoi(getenv("HOST_MAX_PLUGINS", "0")); err != nil {
+		log.Fatalf("invalid HOST_MAX_PLUGINS: %v", err)
+	}
+	if security.AllowPrivateNetwork, err = strconv.ParseBool(getenv("HOST_ALLOW_PRIVATE_NETWORK", "false")); err != nil {
+		log.Fatalf("invalid HOST_ALLOW_PRIVATE_NETWORK: %v", err)
+	}
+
+	probeTimeout, err := time.ParseDuration(getenv("HOST_HEALTH_PROBE_TIMEOUT", "5s"))
+	if err != nil {
+		log.Fatalf("invalid HOST_HEALTH_PROBE_TIMEOUT: %v", err)
+	}
+
+	backupMode := getenv("HOST_BACKUP_MODE", host.BackupModeFull)
+	if backupMode != host.BackupModeFull && backupMode != host.BackupModeDiff {
+		log.Fatalf("invalid HOST_BACKUP_MODE: %q", backupMode)
+	}
+
+	var webhooks []host.WebhookConfig
+	if v := os.Getenv("HOST_WEBHOOKS"); v != "" {
+		if err := json.Unmarshal([]byte(v), &webhooks); err != nil {
+			log.Fatalf("invalid HOST_WEBHOOKS: %v", err)
+		}
+		for _, wh := range webhooks {
+			if wh.URL == "" {
+				log.Fatalf("invalid HOST_WEBHOOKS: missing url")
+			}
+		}
+	}
+
+	eventRetention, err := time.ParseDuration(getenv("HOST_EVENT_RETENTION", host.DefaultEventRetention.String()))
+	if err != nil {
+		log.Fatalf("invalid HOST_EVENT_RETENTION: %v", err)
+	}
+	sseKeepAlive, err := time.ParseDuration(getenv("HOST_SSE_KEEPALIVE", "15s"))
+	if err != nil {
+		log.Fatalf("invalid HOST_SSE_KEEPALIVE: %v", err)
+	}
+	maxSSEClients, err := strconv.Atoi(getenv("HOST_MAX_SSE_CLIENTS", "0"))
+	if err != nil {
+		log.Fatalf("invalid HOST_MAX_SSE_CLIENTS: %v", err)
+	}
+	vaultWatchInterval, err := time.ParseDuration(getenv("HOST_VAULT_WATCH_INTERVAL", "2s"))
+	if err != nil {
+		log.Fatalf("invalid HOST_VAULT_WATCH_INTERVAL: %v", err)
+	}
+	// 权限按八进制解析，如 640、750
+	fileMode, err := strconv.ParseUint(getenv("HOST_FILE_MODE", "0"), 8, 32)
+	if err != nil {
+		log.Fatalf("invalid HOST_FILE_MODE: %v", err)
+	}
+	dirMode, err := strconv.ParseUint(getenv("HOST_DIR_MODE", "0"), 8, 32)
+	if err != nil {
+		log.Fatalf("invalid HOST_DIR_MODE: %v", err)
+	}
+
+	rpcTimeout, err := time.ParseDuration(getenv("HOST_RPC_TIMEOUT", host.DefaultRPCTimeout.String()))
+	if err != nil {
+		log.Fatalf("invalid HOST_RPC_TIMEOUT: %v", err)
+	}
+	slowRPCThreshold, err := time.ParseDuration(getenv("HOST_SLOW_RPC_THRESHOLD", host.DefaultSlowRPCThreshold.String()))
+	if err != nil {
+		log.Fatalf("invalid HOST_SLOW_RPC_THRESHOLD: %v", err)
+	}
+	maxRPCRequestBytes, err := strconv.ParseInt(getenv("HOST_RPC_MAX_REQUEST_BYTES", "0"), 10, 64)
+	if err != nil {
+		log.Fatalf("invalid HOST_RPC_MAX_REQUEST_BYTES: %v", err)
+	}
+	maxRPCBatchSize, err := strconv.Atoi(getenv("HOST_RPC_MAX_BATCH_SIZE", "0"))
+	if err != nil {
+		log.Fatalf("invalid HOST_RPC_MAX_BATCH_SIZE: %v", err)
+	}
+	maxRPCParamsDepth, err := strconv.Atoi(getenv("HOST_RPC_MAX_PARAMS_DEPTH", "0"))
+	if err != nil {
+		log.Fatalf("invalid HOST_RPC_MAX_PARAMS_DEPTH: %v", err)
+	}
+	maxManifestBytes, err := strconv.ParseInt(getenv("HOST_MAX_MANIFEST_BYTES", "0"), 10, 64)
+	if err != nil {
+		log.Fatalf("invalid HOST_MAX_MANIFEST_BYTES: %v", err)
+	}
+	maxManifestDepth, err := strconv.Atoi(getenv("HOST_MAX_MANIFEST_DEPTH", "0"))
+	if err != nil {
+		log.Fatalf("invalid HOST_MAX_MANIFEST_DEPTH: %v", err)
+	}
+	pluginRPCRateLimit, err := strconv.Atoi(getenv("HOST_PLUGIN_RPC_RATE_LIMIT", "0"))
+	if err != nil {
+		log.Fatalf("invalid HOST_PLUGIN_RPC_RATE_LIMIT: %v", err)
+	}
+
+	enableOnInstall, err := strconv.ParseBool(getenv("HOST_ENABLE_ON_INSTALL", "true"))
+	if err != nil {
+		log.Fatalf("invalid HOST_ENABLE_ON_INSTALL: %v", err)
+	}
+	readOnly, err := strconv.ParseBool(getenv("HOST_READ_ONLY", "false"))
+	if err != nil {
+		log.Fatalf("invalid HOST_READ_ONLY: %v", err)
+	}
+	allowBackendProcesses, err := strconv.ParseBool(getenv("HOST_ALLOW_BACKEND_PROCESSES", "false"))
+	if err != nil {
+		log.Fatalf("invalid HOST_ALLOW_BACKEND_PROCESSES: %v", err)
+	}
+	verifyGitHubDigest, err := strconv.ParseBool(getenv("HOST_VERIFY_GITHUB_DIGEST", "false"))
+	if err != nil {
+		log.Fatalf("invalid HOST_VERIFY_GITHUB_DIGEST: %v", err)
+	}
+	insecureSkipTLSVerify, err := strconv.ParseBool(getenv("HOST_INSECURE_SKIP_TLS_VERIFY", "false"))
+	if err != nil {
+		log.Fatalf("invalid HOST_INSECURE_SKIP_TLS_VERIFY: %v", err)
+	}
+
+	cfg := host.Config{
+		RootDir:                root,
+		PluginsDir:             pluginsDir,
+		VaultDir:               vaultDir,
+		VaultQuotaBytes:        vaultQuota,
+		AdminToken:             os.Getenv("HOST_ADMIN_TOKEN"),
+		Security:               &security,
+		PinnedKeys:             parseKeyMap("pinned key", os.Getenv("HOST_PINNED_KEYS")),
+		PluginKeys:             parseKeyMap("plugin key", os.Getenv("HOST_PLUGIN_KEYS")),
+		ProbeTimeout:           probeTimeout,
+		TempDir:                os.Getenv("HOST_TEMP_DIR"),
+		StagingDir:             os.Getenv("HOST_STAGING_DIR"),
+		ManifestNames:          splitList(os.Getenv("HOST_MANIFEST_NAMES")),
+		MaxManifestBytes:       maxManifestBytes,
+		MaxManifestDepth:       maxManifestDepth,
+		BackupMode:             backupMode,
+		GitHubAPIURL:           os.Getenv("HOST_GITHUB_API_URL"),
+		VerifyGitHubDigest:     verifyGitHubDigest,
+		Webhooks:               webhooks,
+		EventRetention:         eventRetention,
+		SSEKeepAlive:           sseKeepAlive,
+		MaxSSEClients:          maxSSEClients,
+		HTTPProxy:              os.Getenv("HOST_HTTP_PROXY"),
+		CACertFile:             os.Getenv("HOST_CA_CERT_FILE"),
+		InsecureSkipTLSVerify:  insecureSkipTLSVerify,
+		VaultWatchInterval:     vaultWatchInterval,
+		FileMode:               os.FileMode(fileMode),
+		DirMode:                os.FileMode(dirMode),
+		EnableOnInstall:        &enableOnInstall,
+		TrustedPlugins:         splitList(os.Getenv("HOST_TRUSTED_PLUGINS")),
+		DefaultPermissions:     splitList(os.Getenv("HOST_DEFAULT_PERMISSIONS")),
+		DisabledMethods:        splitList(os.Getenv("HOST_DISABLED_METHODS")),
+		AllowedVaultExtensions: splitList(os.Getenv("HOST_VAULT_ALLOWED_EXTENSIONS")),
+		BlockedVaultExtensions: splitList(os.Getenv("HOST_VAULT_BLOCKED_EXTENSIONS")),
+		RPCTimeout:             rpcTimeout,
+		SlowRPCThreshold:       slowRPCThreshold,
+		MaxRPCRequestBytes:     maxRPCRequestBytes,
+		MaxRPCBatchSize:        maxRPCBatchSize,
+		MaxRPCParamsDepth:      maxRPCParamsDepth,
+		PluginRPCRateLimit:     pluginRPCRateLimit,
+		ReadOnly:               readOnly,
+		AllowBackendProcesses:  allowBackendProcesses,
+	}
 	h := host.NewPluginHost(cfg)
+	if err := h.EnsureDirs(); err != nil {
+		log.Fatalf("prepare directories: %v", err)
+	}
+	if n, err := h.SweepStaleDownloads(host.StaleDownloadAge); err != nil {
+		log.Printf("sweep stale downloads: %v", err)
+	} else if n > 0 {
+		log.Printf("Removed %d stale download files", n)
+	}
+	if n, err := h.PruneEventHistory(); err != nil {
+		log.Printf("prune event history: %v", err)
+	} else if n > 0 {
+		log.Printf("Removed %d expired events", n)
+	}
 	if err := h.LoadPlugins(); err != nil {
 		log.Fatalf("load plugins: %v", err)
 	}
 	log.Printf("Loaded %d plugins from %s", h.CountPlugins(), pluginsDir)
+	h.StartPluginProcesses()
+
+	updateInterval, err := time.ParseDuration(getenv("HOST_UPDATE_CHECK_INTERVAL", "1h"))
+	if err != nil {
+		log.Fatalf("invalid HOST_UPDATE_CHECK_INTERVAL: %v", err)
+	}
+	if updateInterval > 0 {
+		h.StartUpdateChecker(context.Background(), updateInterval)
+	}
+
+	probeInterval, err := time.ParseDuration(getenv("HOST_HEALTH_PROBE_INTERVAL", "30s"))
+	if err != nil {
+		log.Fatalf("invalid HOST_HEALTH_PROBE_INTERVAL: %v", err)
+	}
+	if probeInterval > 0 {
+		h.StartHealthProber(context.Background(), probeInterval)
+	}
 
 	if err := h.StartHTTPServer(addr); err != nil {
 		log.Fatal(err)
 	}
 }
-
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// CreateUserQuotasTable 创建用户存储库配额表
+func CreateUserQuotasTable() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000008_create_user_quotas_table",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				CREATE TABLE IF NOT EXISTS user_quotas (
+					id SERIAL PRIMARY KEY,
+					user_id INTEGER UNIQUE NOT NULL,
+					quota_bytes BIGINT NOT NULL DEFAULT 0,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					deleted_at TIMESTAMP NULL
+				)
+			`).Error; err != nil {
+				return err
+			}
+
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS user_quotas CASCADE").Error
+		},
+	}
+}
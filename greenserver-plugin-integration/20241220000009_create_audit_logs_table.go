@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// CreateAuditLogsTable 创建审计日志表
+func CreateAuditLogsTable() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000009_create_audit_logs_table",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				CREATE TABLE IF NOT EXISTS audit_logs (
+					id SERIAL PRIMARY KEY,
+					action VARCHAR(255) NOT NULL,
+					actor VARCHAR(255),
+					target TEXT,
+					meta TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action)`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at)`).Error; err != nil {
+				return err
+			}
+
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS audit_logs CASCADE").Error
+		},
+	}
+}
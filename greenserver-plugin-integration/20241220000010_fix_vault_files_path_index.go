@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// FixVaultFilesPathIndex 删除 path 单列唯一索引，改为 (user_id, path) 复合唯一索引
+//
+// 旧模型在 path 上声明了 uniqueIndex，经 AutoMigrate 建出的同名唯一索引会让
+// 不同用户无法拥有相同路径的文件。
+func FixVaultFilesPathIndex() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000010_fix_vault_files_path_index",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`DROP INDEX IF EXISTS idx_vault_files_path`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_vault_files_path ON vault_files(path)`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_vault_files_user_path ON vault_files(user_id, path)`).Error; err != nil {
+				return err
+			}
+
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`DROP INDEX IF EXISTS idx_vault_files_user_path`).Error
+		},
+	}
+}
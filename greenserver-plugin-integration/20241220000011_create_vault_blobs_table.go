@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// CreateVaultBlobsTable 创建按内容寻址的数据块表，并为存储库文件增加内容哈希列
+func CreateVaultBlobsTable() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000011_create_vault_blobs_table",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				CREATE TABLE IF NOT EXISTS vault_blobs (
+					hash VARCHAR(64) PRIMARY KEY,
+					content BYTEA,
+					size BIGINT DEFAULT 0,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`ALTER TABLE vault_files ADD COLUMN IF NOT EXISTS blob_hash VARCHAR(64)`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_vault_files_blob_hash ON vault_files(blob_hash)`).Error; err != nil {
+				return err
+			}
+
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Exec(`ALTER TABLE vault_files DROP COLUMN IF EXISTS blob_hash`).Error; err != nil {
+				return err
+			}
+			return tx.Exec("DROP TABLE IF EXISTS vault_blobs CASCADE").Error
+		},
+	}
+}
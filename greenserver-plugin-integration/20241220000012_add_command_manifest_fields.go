@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// AddCommandManifestFields 为命令表增加分类、快捷键和来源列，支持清单声明的命令
+func AddCommandManifestFields() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000012_add_command_manifest_fields",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				ALTER TABLE commands
+					ADD COLUMN IF NOT EXISTS category VARCHAR(255),
+					ADD COLUMN IF NOT EXISTS hotkey VARCHAR(255),
+					ADD COLUMN IF NOT EXISTS source VARCHAR(32) DEFAULT 'runtime'
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_commands_plugin_source ON commands(plugin_id, source)`).Error; err != nil {
+				return err
+			}
+
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			if err := tx.Exec(`DROP INDEX IF EXISTS idx_commands_plugin_source`).Error; err != nil {
+				return err
+			}
+			return tx.Exec(`
+				ALTER TABLE commands
+					DROP COLUMN IF EXISTS category,
+					DROP COLUMN IF EXISTS hotkey,
+					DROP COLUMN IF EXISTS source
+			`).Error
+		},
+	}
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// AddEventsPublishPermission 增加允许插件发布自定义事件的 events.publish 权限
+func AddEventsPublishPermission() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000013_add_events_publish_permission",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Exec(`
+				INSERT INTO permissions (name, description)
+				VALUES (?, ?)
+				ON CONFLICT (name) DO NOTHING
+			`, "events.publish", "Default permission: events.publish").Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`DELETE FROM permissions WHERE name = ?`, "events.publish").Error
+		},
+	}
+}
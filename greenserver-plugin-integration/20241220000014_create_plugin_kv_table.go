@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// CreatePluginKVTable 创建插件键值存储表
+func CreatePluginKVTable() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000014_create_plugin_kv_table",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				CREATE TABLE IF NOT EXISTS plugin_kv (
+					id SERIAL PRIMARY KEY,
+					plugin_id VARCHAR(100) NOT NULL,
+					key VARCHAR(128) NOT NULL,
+					value TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+					updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)
+			`).Error; err != nil {
+				return err
+			}
+
+			return tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_plugin_kv_plugin_key ON plugin_kv(plugin_id, key)`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS plugin_kv CASCADE").Error
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// CreateEventsTable 创建事件历史表
+func CreateEventsTable() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000015_create_events_table",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				CREATE TABLE IF NOT EXISTS events (
+					id SERIAL PRIMARY KEY,
+					type VARCHAR(100) NOT NULL,
+					data TEXT,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_events_type ON events(type)`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_events_created_at ON events(created_at)`).Error; err != nil {
+				return err
+			}
+
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS events CASCADE").Error
+		},
+	}
+}
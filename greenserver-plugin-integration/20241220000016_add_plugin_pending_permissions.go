@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// AddPluginPendingPermissions 为插件表增加待批准权限列，升级新增的权限批准前保存在此
+func AddPluginPendingPermissions() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000016_add_plugin_pending_permissions",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE plugins ADD COLUMN IF NOT EXISTS pending_permissions TEXT DEFAULT ''`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE plugins DROP COLUMN IF EXISTS pending_permissions`).Error
+		},
+	}
+}
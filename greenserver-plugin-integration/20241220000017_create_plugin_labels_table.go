@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// CreatePluginLabelsTable 创建插件标签表，保存运维人员为插件设置的标签
+func CreatePluginLabelsTable() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000017_create_plugin_labels_table",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.Exec(`
+				CREATE TABLE IF NOT EXISTS plugin_labels (
+					id SERIAL PRIMARY KEY,
+					plugin_id VARCHAR(100) NOT NULL,
+					label VARCHAR(64) NOT NULL,
+					created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				)
+			`).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_plugin_labels_plugin_label ON plugin_labels(plugin_id, label)`).Error; err != nil {
+				return err
+			}
+			return tx.Exec(`CREATE INDEX IF NOT EXISTS idx_plugin_labels_label ON plugin_labels(label)`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE IF EXISTS plugin_labels CASCADE").Error
+		},
+	}
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// AddPluginI18n 为插件表增加本地化文本列，保存清单中按语言提供的名称和描述
+func AddPluginI18n() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000018_add_plugin_i18n",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE plugins ADD COLUMN IF NOT EXISTS i18n TEXT DEFAULT ''`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE plugins DROP COLUMN IF EXISTS i18n`).Error
+		},
+	}
+}
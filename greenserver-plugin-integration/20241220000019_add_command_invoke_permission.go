@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// AddCommandInvokePermission 为命令表增加 invoke_permission 列，其他插件调用该命令时需要此权限
+func AddCommandInvokePermission() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000019_add_command_invoke_permission",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE commands ADD COLUMN IF NOT EXISTS invoke_permission VARCHAR(255) DEFAULT ''`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE commands DROP COLUMN IF EXISTS invoke_permission`).Error
+		},
+	}
+}
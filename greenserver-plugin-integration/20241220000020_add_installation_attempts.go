@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// AddInstallationAttempts 为安装记录表增加 attempts 列，记录失败安装的重试次数
+func AddInstallationAttempts() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000020_add_installation_attempts",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE plugin_installations ADD COLUMN IF NOT EXISTS attempts INTEGER DEFAULT 0`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE plugin_installations DROP COLUMN IF EXISTS attempts`).Error
+		},
+	}
+}
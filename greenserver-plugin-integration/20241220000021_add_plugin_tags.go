@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// AddPluginTags 为插件表增加标签列，保存清单中声明的 tags
+func AddPluginTags() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000021_add_plugin_tags",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE plugins ADD COLUMN IF NOT EXISTS tags TEXT DEFAULT ''`).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`ALTER TABLE plugins DROP COLUMN IF EXISTS tags`).Error
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// AddKVPermissions 增加插件读写自身键值存储的 kv.read、kv.write 权限
+func AddKVPermissions() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "20241220000022_add_kv_permissions",
+		Migrate: func(tx *gorm.DB) error {
+			for _, perm := range []string{"kv.read", "kv.write"} {
+				if err := tx.Exec(`
+					INSERT INTO permissions (name, description)
+					VALUES (?, ?)
+					ON CONFLICT (name) DO NOTHING
+				`, perm, "Default permission: "+perm).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Exec(`DELETE FROM permissions WHERE name IN (?, ?)`, "kv.read", "kv.write").Error
+		},
+	}
+}
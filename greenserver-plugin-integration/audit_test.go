@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// acceptingInstallService 接受安装请求但不实际下载
+type acceptingInstallService struct {
+	*ServiceImpl
+}
+
+func (s acceptingInstallService) InstallPlugin(req *PluginInstallRequest) error {
+	return nil
+}
+
+func TestInstallPluginHandlerWritesAuditLog(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	h := &Handler{service: acceptingInstallService{s}}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", uint(7))
+	c.Request = httptest.NewRequest("POST", "/plugins/install", strings.NewReader(`{"id":"demo","url":"https://example.com/demo.zip"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.InstallPlugin(c)
+
+	logs, err := s.GetAuditLogs(&AuditQuery{Action: "plugin.install"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("got %d plugin.install logs, want 1", len(logs))
+	}
+	if logs[0].Actor != "user:7" || logs[0].Target != "demo" {
+		t.Errorf("log = %+v", logs[0])
+	}
+}
+
+func TestLoadPluginFromManifestAuditsPermissionGrants(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	manifest := `{"id":"demo","name":"Demo","version":"1.0.0","permissions":["vault.read","events.publish"]}`
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadPluginFromManifest(manifestPath, true); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := repo.GetAuditLogs(&AuditQuery{Action: "permission.grant"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("got %d permission.grant logs, want 2", len(logs))
+	}
+	for _, l := range logs {
+		if l.Target != "demo" {
+			t.Errorf("log target = %q, want demo", l.Target)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package plugin
+
+// GetCommandPalette 返回命令面板需要的全部命令及所属插件的名称和启用状态，
+// includeDisabled 为 false 时不含已禁用插件的命令；所属插件已不存在的命令总是跳过
+func (s *ServiceImpl) GetCommandPalette(includeDisabled bool) ([]*CommandPaletteItem, error) {
+	plugins, err := s.repo.GetAllPlugins(nil)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Plugin, len(plugins))
+	for _, p := range plugins {
+		byID[p.PluginID] = p
+	}
+
+	commands, err := s.repo.GetAllCommands()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*CommandPaletteItem, 0, len(commands))
+	for _, cmd := range commands {
+		p, ok := byID[cmd.PluginID]
+		if !ok || (!p.Enabled && !includeDisabled) {
+			continue
+		}
+		items = append(items, &CommandPaletteItem{
+			CommandID:     cmd.CommandID,
+			PluginID:      cmd.PluginID,
+			Title:         cmd.Title,
+			PluginName:    p.Name,
+			PluginEnabled: p.Enabled,
+			Category:      cmd.Category,
+			Hotkey:        cmd.Hotkey,
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// setupPalette 添加启用的 notes、禁用的 draft 两个插件及其命令，另有一条所属插件已不存在的命令
+func setupPalette(t *testing.T, repo Repository) {
+	t.Helper()
+	for _, p := range []*Plugin{
+		{PluginID: "notes", Name: "Notes", Version: "1.0.0", Enabled: true},
+		{PluginID: "draft", Name: "Draft", Version: "1.0.0"},
+	} {
+		if err := repo.CreatePlugin(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, cmd := range []*Command{
+		{CommandID: "notes.open", PluginID: "notes", Title: "Open", Category: "File", Hotkey: "Mod+O"},
+		{CommandID: "draft.publish", PluginID: "draft", Title: "Publish", Category: "Draft"},
+		{CommandID: "gone.ghost", PluginID: "gone", Title: "Ghost"},
+	} {
+		if err := repo.CreateCommand(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetCommandPalette(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	setupPalette(t, repo)
+
+	open := &CommandPaletteItem{CommandID: "notes.open", PluginID: "notes", Title: "Open", PluginName: "Notes", PluginEnabled: true, Category: "File", Hotkey: "Mod+O"}
+	items, err := s.GetCommandPalette(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []*CommandPaletteItem{open}) {
+		t.Fatalf("palette = %+v", items)
+	}
+
+	// includeDisabled 时包含禁用插件的命令，所属插件不存在的命令仍然跳过
+	items, err = s.GetCommandPalette(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publish := &CommandPaletteItem{CommandID: "draft.publish", PluginID: "draft", Title: "Publish", PluginName: "Draft", Category: "Draft"}
+	if len(items) != 2 {
+		t.Fatalf("palette with disabled = %+v", items)
+	}
+	got := map[string]*CommandPaletteItem{items[0].CommandID: items[0], items[1].CommandID: items[1]}
+	if !reflect.DeepEqual(got, map[string]*CommandPaletteItem{"notes.open": open, "draft.publish": publish}) {
+		t.Fatalf("palette with disabled = %+v", got)
+	}
+}
+
+func TestGetCommandPaletteRPC(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	setupPalette(t, repo)
+	h := &Handler{service: s}
+
+	decode := func(result interface{}) []CommandPaletteItem {
+		t.Helper()
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var items []CommandPaletteItem
+		if err := json.Unmarshal(data, &items); err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+
+	_, resp := callTestRPC(t, h, "notes", "host.getCommandPalette", nil)
+	if resp.Error != nil {
+		t.Fatalf("getCommandPalette: %+v", resp.Error)
+	}
+	if items := decode(resp.Result); len(items) != 1 || items[0].PluginName != "Notes" || !items[0].PluginEnabled || items[0].Hotkey != "Mod+O" {
+		t.Fatalf("items = %+v", items)
+	}
+
+	_, resp = callTestRPC(t, h, "notes", "host.getCommandPalette", map[string]bool{"includeDisabled": true})
+	if items := decode(resp.Result); len(items) != 2 {
+		t.Fatalf("items with disabled = %+v", items)
+	}
+
+	if _, resp := callTestRPC(t, h, "notes", "host.getCommandPalette", map[string]string{"includeDisabled": "yes"}); resp.Error == nil || resp.Error.Code != 400 {
+		t.Fatalf("invalid params: %+v", resp.Error)
+	}
+}
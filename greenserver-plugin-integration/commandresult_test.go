@@ -0,0 +1,161 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInvokeCommandWaitReturnsResult(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+
+	type invokeResult struct {
+		id     string
+		result json.RawMessage
+		err    error
+	}
+	done := make(chan invokeResult, 1)
+	go func() {
+		id, result, err := s.InvokeCommandWait(ctx, "worker", "worker.sum", 5*time.Second)
+		done <- invokeResult{id, result, err}
+	}()
+
+	var invocationID string
+	select {
+	case ev := <-events:
+		if ev.Type != "command.invoked" {
+			t.Fatalf("event type = %q", ev.Type)
+		}
+		invocationID, _ = ev.Data.(map[string]interface{})["invocationId"].(string)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no command.invoked event")
+	}
+
+	// 其他插件不能替处理命令的插件提交结果
+	if err := s.PostCommandResult("other", &CommandResultRequest{InvocationID: invocationID, Result: json.RawMessage(`1`)}); !errors.Is(err, ErrUnknownInvocation) {
+		t.Errorf("result from another plugin: err = %v, want ErrUnknownInvocation", err)
+	}
+	if err := s.PostCommandResult("worker", &CommandResultRequest{InvocationID: invocationID, Result: json.RawMessage(`{"sum":42}`)}); err != nil {
+		t.Fatalf("PostCommandResult: %v", err)
+	}
+
+	got := <-done
+	if got.err != nil || got.id != invocationID || string(got.result) != `{"sum":42}` {
+		t.Fatalf("InvokeCommandWait = %q, %s, %v", got.id, got.result, got.err)
+	}
+	// 每个调用只接受一次结果
+	if err := s.PostCommandResult("worker", &CommandResultRequest{InvocationID: invocationID}); !errors.Is(err, ErrUnknownInvocation) {
+		t.Errorf("second result: err = %v, want ErrUnknownInvocation", err)
+	}
+}
+
+func TestInvokeCommandWaitTimesOut(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	start := time.Now()
+	_, _, err := s.InvokeCommandWait(context.Background(), "worker", "worker.slow", 50*time.Millisecond)
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("err = %v, want ErrCommandTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timeout took %s", elapsed)
+	}
+	if !strings.Contains(err.Error(), "50ms") {
+		t.Errorf("timeout error %q does not mention the timeout", err)
+	}
+}
+
+func TestCommandInvokePermission(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "owner", "granted", "plain")
+	for id, perm := range map[string]string{"owner": "commands.register", "granted": "files.export"} {
+		if err := repo.AddPluginPermission(id, perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := &Handler{service: s}
+	for _, params := range []map[string]interface{}{
+		{"id": "owner.export", "title": "Export", "invokePermission": "files.export"},
+		{"id": "owner.open", "title": "Open"},
+	} {
+		if code, resp := callTestRPC(t, h, "owner", "commands.register", params); code != 200 || resp.Error != nil {
+			t.Fatalf("commands.register: got %d %+v", code, resp.Error)
+		}
+	}
+	if perm, err := s.CommandInvokePermission("owner", "owner.export"); err != nil || perm != "files.export" {
+		t.Fatalf("CommandInvokePermission = %q, %v", perm, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+	cases := []struct {
+		caller, command string
+		want            int
+	}{
+		{"plain", "owner.export", 403},
+		{"granted", "owner.export", 200},
+		// 命令所属插件调用自己的命令不需要该权限
+		{"owner", "owner.export", 200},
+		// 未声明 invokePermission 的命令任何插件都可调用
+		{"plain", "owner.open", 200},
+	}
+	for _, c := range cases {
+		code, resp := callTestRPC(t, h, c.caller, "commands.invoke", map[string]interface{}{"id": c.command, "pluginId": "owner"})
+		if code != c.want {
+			t.Errorf("%s invoking %s: got %d %+v, want %d", c.caller, c.command, code, resp.Error, c.want)
+		}
+		if c.want == 403 {
+			if resp.Error == nil || resp.Error.Message != "missing permission: files.export" {
+				t.Errorf("%s invoking %s: error = %+v", c.caller, c.command, resp.Error)
+			}
+			continue
+		}
+		// 调用的是命令所属插件的命令
+		select {
+		case ev := <-events:
+			data, _ := ev.Data.(map[string]interface{})
+			if ev.Type != "command.invoked" || data["pluginId"] != "owner" || data["commandId"] != c.command {
+				t.Errorf("%s invoking %s: event = %+v", c.caller, c.command, ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s invoking %s: no command.invoked event", c.caller, c.command)
+		}
+	}
+}
+
+func TestManifestCommandInvokePermission(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	installManifest(t, s, "owner", `{"id":"owner","name":"Owner","version":"1.0.0",
+		"commands":[{"id":"owner.purge","title":"Purge","invokePermission":"vault.write"},{"id":"owner.open","title":"Open"}]}`)
+
+	for command, want := range map[string]string{"owner.purge": "vault.write", "owner.open": "", "owner.missing": ""} {
+		if perm, err := s.CommandInvokePermission("owner", command); err != nil || perm != want {
+			t.Errorf("CommandInvokePermission(%s) = %q, %v, want %q", command, perm, err, want)
+		}
+	}
+	commands, err := s.GetAllCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, cmd := range commands {
+		if cmd.CommandID == "owner.purge" {
+			found = true
+			if cmd.InvokePermission != "vault.write" {
+				t.Errorf("command response = %+v", cmd)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("owner.purge not registered: %+v", commands)
+	}
+}
@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// commandIDs 返回所有命令的 pluginId/commandId，已排序
+func commandIDs(t *testing.T, s *ServiceImpl) []string {
+	t.Helper()
+	cmds, err := s.GetAllCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0, len(cmds))
+	for _, c := range cmds {
+		ids = append(ids, c.PluginID+"/"+c.CommandID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// writeManifestFile 在临时目录写入清单文件并返回路径
+func writeManifestFile(t *testing.T, manifest string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestManifestCommandsRegisteredOnInstall(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	v1 := writeManifestFile(t, `{"id":"demo","name":"Demo","version":"1.0.0",
+		"commands":[{"id":"demo.old","title":"Old"},{"id":"demo.run","title":"Run","hotkey":"Ctrl+R"},{"id":"","title":"Skipped"}]}`)
+	if err := s.loadPluginFromManifest(v1, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(commandIDs(t, s), ","); got != "demo/demo.old,demo/demo.run" {
+		t.Fatalf("commands after install = %s", got)
+	}
+
+	// 升级时替换清单命令，运行时注册的命令保留
+	if err := s.RegisterCommand("demo", &CommandRegisterRequest{ID: "demo.dynamic", Title: "Dynamic"}); err != nil {
+		t.Fatal(err)
+	}
+	v2 := writeManifestFile(t, `{"id":"demo","name":"Demo","version":"2.0.0","commands":[{"id":"demo.run","title":"Run"}]}`)
+	if err := s.loadPluginFromManifest(v2, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(commandIDs(t, s), ","); got != "demo/demo.dynamic,demo/demo.run" {
+		t.Fatalf("commands after upgrade = %s", got)
+	}
+
+	if err := s.UninstallPlugin("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := commandIDs(t, s); len(ids) != 0 {
+		t.Errorf("commands after uninstall = %v", ids)
+	}
+}
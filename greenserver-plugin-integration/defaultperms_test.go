@@ -0,0 +1,34 @@
+package plugin
+
+import "testing"
+
+func TestDefaultPermissions(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{
+		DefaultPermissions: []string{"events.publish"},
+	}).(*ServiceImpl)
+	createTestPlugins(t, repo, "plain")
+	h := &Handler{service: s}
+
+	if !s.HasPermission("plain", "events.publish") {
+		t.Error("default permission not granted to a plugin that does not declare it")
+	}
+	if s.HasPermission("plain", "vault.write") {
+		t.Error("permission outside the defaults was granted")
+	}
+	if s.HasPermission("ghost", "events.publish") {
+		t.Error("default permission granted to an uninstalled plugin")
+	}
+
+	if code, resp := callTestRPC(t, h, "plain", "events.publish", map[string]interface{}{"name": "ping"}); code != 200 {
+		t.Errorf("events.publish with a default permission: got %d %+v", code, resp.Error)
+	}
+
+	// 未配置默认权限时仍须声明
+	repo = NewInMemoryRepository()
+	s = NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "plain")
+	if code, _ := callTestRPC(t, &Handler{service: s}, "plain", "events.publish", map[string]interface{}{"name": "ping"}); code != 403 {
+		t.Errorf("events.publish without defaults: status = %d, want 403", code)
+	}
+}
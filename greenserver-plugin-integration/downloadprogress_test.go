@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProgressReaderStats(t *testing.T) {
+	start := time.Now()
+	p := &progressReader{total: 1000, read: 250, started: start}
+	if got, want := p.stats(start.Add(time.Second)), (InstallProgress{BytesDownloaded: 250, TotalBytes: 1000, BytesPerSecond: 250, ETASeconds: 3}); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+	p.read = 1000
+	if got := p.stats(start.Add(2 * time.Second)); got.ETASeconds != 0 || got.BytesPerSecond != 500 {
+		t.Errorf("finished stats = %+v", got)
+	}
+	// 未知总长度时省略总字节数和剩余时间
+	p = &progressReader{total: -1, read: 250, started: start}
+	if got, want := p.stats(start.Add(time.Second)), (InstallProgress{BytesDownloaded: 250, BytesPerSecond: 250}); got != want {
+		t.Errorf("unknown total stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestProgressReaderReportsAtEOF(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 4096)
+	var reports []InstallProgress
+	r := newProgressReader(bytes.NewReader(data), int64(len(data)), func(p InstallProgress) {
+		reports = append(reports, p)
+	})
+	if n, err := io.Copy(io.Discard, r); err != nil || n != int64(len(data)) {
+		t.Fatalf("copy = %d, %v", n, err)
+	}
+	if len(reports) != 1 || reports[0].BytesDownloaded != 4096 || reports[0].TotalBytes != 4096 {
+		t.Fatalf("reports = %+v", reports)
+	}
+}
+
+// installDownloadEvents 从 handler 提供的服务安装插件，返回下载阶段带有字节数的进度
+func installDownloadEvents(t *testing.T, handler http.HandlerFunc) []InstallProgress {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+	if err := s.InstallPlugin(&PluginInstallRequest{ID: "demo", URL: srv.URL + "/plugin.zip"}); err != nil {
+		t.Fatal(err)
+	}
+	var out []InstallProgress
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Type {
+			case "plugin.installation.failed":
+				t.Fatalf("install failed: %+v", ev.Data)
+			case "plugin.installation.done":
+				if len(out) == 0 {
+					t.Fatal("no download progress events")
+				}
+				return out
+			case "plugin.installation.progress":
+				if p, ok := ev.Data.(InstallProgress); ok && p.BytesDownloaded > 0 {
+					out = append(out, p)
+				}
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the installation to finish")
+		}
+	}
+}
+
+func TestInstallReportsDownloadBytes(t *testing.T) {
+	zipPath := writeTestZip(t, t.TempDir(), []string{"manifest.json"}, [][]byte{[]byte(`{"id":"demo","name":"Demo","version":"1.0.0"}`)})
+	info, err := os.Stat(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := installDownloadEvents(t, func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, zipPath)
+	})
+	last := events[len(events)-1]
+	if last.PluginID != "demo" || last.Phase != "downloading" || last.BytesDownloaded != info.Size() || last.TotalBytes != info.Size() {
+		t.Fatalf("last download event = %+v", last)
+	}
+	// 下载完成时进度推进到 30
+	if last.Progress != 30 || last.ETASeconds != 0 {
+		t.Errorf("last download event = %+v", last)
+	}
+}
+
+func TestInstallReportsDownloadBytesWithoutContentLength(t *testing.T) {
+	zipPath := writeTestZip(t, t.TempDir(), []string{"manifest.json"}, [][]byte{[]byte(`{"id":"demo","name":"Demo","version":"1.0.0"}`)})
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := installDownloadEvents(t, func(w http.ResponseWriter, r *http.Request) {
+		// 先刷新响应头，使用分块编码而不发送 Content-Length
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(data)
+	})
+	last := events[len(events)-1]
+	if last.BytesDownloaded != int64(len(data)) || last.TotalBytes != 0 || last.ETASeconds != 0 || last.Progress != 10 {
+		t.Fatalf("last download event = %+v", last)
+	}
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPluginsFromDiskSweepsStaleDownloads(t *testing.T) {
+	tmp := t.TempDir()
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{TempDir: tmp}).(*ServiceImpl)
+
+	write := func(name string, age time.Duration) string {
+		p := filepath.Join(tmp, name)
+		if err := os.WriteFile(p, []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		old := time.Now().Add(-age)
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	stale := write("plugin-123.zip", 2*StaleDownloadAge)
+	fresh := write("plugin-456.zip", time.Minute)
+	other := write("unrelated.zip", 2*StaleDownloadAge)
+
+	// 启动加载插件时清理残留的下载暂存文件
+	if err := s.LoadPluginsFromDisk(); err != nil {
+		t.Fatalf("LoadPluginsFromDisk: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale download was not removed")
+	}
+	for _, p := range []string{fresh, other} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(p), err)
+		}
+	}
+
+	if n, err := s.SweepStaleDownloads(0); err != nil || n != 1 {
+		t.Errorf("SweepStaleDownloads(0) = %d, %v, want 1 (the fresh download)", n, err)
+	}
+}
@@ -1,6 +1,9 @@
 package plugin
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // PluginResponse 插件响应
 type PluginResponse struct {
@@ -11,12 +14,21 @@ type PluginResponse struct {
 	Author      string               `json:"author"`
 	Description string               `json:"description"`
 	Enabled     bool                 `json:"enabled"`
+	Trusted     bool                 `json:"trusted,omitempty"`
 	BackupPath  string               `json:"backup_path"`
 	Entrypoints *EntrypointsResponse `json:"entrypoints,omitempty"`
 	Permissions []string             `json:"permissions"`
-	Commands    []CommandResponse    `json:"commands"`
-	CreatedAt   time.Time            `json:"created_at"`
-	UpdatedAt   time.Time            `json:"updated_at"`
+	// PendingPermissions 升级新增、等待通过 host.approvePermissions 批准的权限
+	PendingPermissions []string          `json:"pending_permissions,omitempty"`
+	Commands           []CommandResponse `json:"commands"`
+	// Tags 清单中声明的标签，已转为小写并去重
+	Tags []string `json:"tags,omitempty"`
+	// Labels 运维人员设置的标签，与清单中的 tags 无关
+	Labels []string `json:"labels"`
+	// I18n 清单中按语言提供的名称和描述，Name、Description 已按 Accept-Language 选择
+	I18n      *PluginI18n `json:"i18n,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
 }
 
 // EntrypointsResponse 插件入口点响应
@@ -32,13 +44,75 @@ type CommandResponse struct {
 	PluginID    string `json:"plugin_id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
+	Category    string `json:"category,omitempty"`
+	Hotkey      string `json:"hotkey,omitempty"`
+	Source      string `json:"source"`
+	// InvokePermission 其他插件调用该命令需要的权限
+	InvokePermission string `json:"invoke_permission,omitempty"`
+}
+
+// CommandPaletteItem 命令面板中的一条命令，附带所属插件的名称和启用状态
+type CommandPaletteItem struct {
+	CommandID     string `json:"command_id"`
+	PluginID      string `json:"plugin_id"`
+	Title         string `json:"title"`
+	PluginName    string `json:"plugin_name"`
+	PluginEnabled bool   `json:"plugin_enabled"`
+	Category      string `json:"category,omitempty"`
+	Hotkey        string `json:"hotkey,omitempty"`
+}
+
+// PluginQuery 插件列表查询参数
+type PluginQuery struct {
+	Enabled *bool  `form:"enabled"` // 按启用状态过滤（可选）
+	Author  string `form:"author"`  // 按作者过滤（可选）
+	Label   string `form:"label"`   // 按运维标签过滤（可选）
+	Sort    string `form:"sort"`    // 排序字段：plugin_id（默认）或 name（可选）
+}
+
+// 插件列表排序字段
+const (
+	PluginSortID   = "plugin_id"
+	PluginSortName = "name"
+)
+
+// AuditQuery 审计日志查询参数
+type AuditQuery struct {
+	Action string     `form:"action"`                                        // 按操作类型过滤（可选）
+	Since  *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"` // 起始时间（可选）
+	Until  *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"` // 结束时间（可选）
+}
+
+// EventHistoryQuery 事件历史查询参数
+type EventHistoryQuery struct {
+	Type  string     `form:"type"`                                          // 按事件类型过滤（可选）
+	Since *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"` // 起始时间（可选）
+	Limit int        `form:"limit"`                                         // 返回的最大条数（可选）
 }
 
 // PluginInstallRequest 插件安装请求
 type PluginInstallRequest struct {
-	ID     string `json:"id" binding:"required"`  // 插件ID
-	URL    string `json:"url" binding:"required"` // 下载URL
-	SHA256 string `json:"sha256"`                 // 文件校验和（可选）
+	ID     string `json:"id"`     // 插件ID
+	URL    string `json:"url"`    // 下载URL
+	SHA256 string `json:"sha256"` // 文件校验和（可选）
+	Source string `json:"source"` // 安装来源，git 表示从 Repo 的发布版本安装
+	Repo   string `json:"repo"`   // GitHub 仓库，格式为 owner/name
+	Ref    string `json:"ref"`    // 发布标签，为空时使用最新发布
+	// AutoEnable 安装后是否立即启用，为空时使用服务配置
+	AutoEnable *bool `json:"autoEnable"`
+}
+
+// ValidationError 请求字段校验错误
+type ValidationError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Args    []any  `json:"-"` // 消息模板参数，用于按语言重新生成消息
+}
+
+// ValidationErrorResponse 校验失败时的响应体
+type ValidationErrorResponse struct {
+	Errors []ValidationError `json:"errors"`
 }
 
 // PluginToggleRequest 插件启用/禁用请求
@@ -46,6 +120,44 @@ type PluginToggleRequest struct {
 	PluginID string `json:"plugin_id" binding:"required"`
 }
 
+// PluginLabelRequest 添加插件标签请求
+type PluginLabelRequest struct {
+	Label string `json:"label" binding:"required"` // 标签，如 env:prod
+}
+
+// PluginBatchRequest 批量启用/禁用/卸载请求
+type PluginBatchRequest struct {
+	PluginIDs []string `json:"plugin_ids" binding:"required"`
+	Enabled   *bool    `json:"enabled"` // 仅批量启用/禁用时使用
+}
+
+// BatchResult 批量操作中单个插件的处理结果
+type BatchResult struct {
+	PluginID string `json:"plugin_id"`
+	Ok       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReconcileResult 按磁盘内容校正插件记录的结果，各项为插件ID
+type ReconcileResult struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+}
+
+// UninstallPreviewResponse 卸载插件前的预览
+type UninstallPreviewResponse struct {
+	PluginID string `json:"pluginId"`
+	// DirectorySize 插件目录的大小（字节）
+	DirectorySize int64 `json:"directorySize"`
+	// CommandCount 将被移除的命令数
+	CommandCount int `json:"commandCount"`
+	// BackupWillBeCreated 卸载前是否会先备份，本服务卸载时不备份，始终为 false
+	BackupWillBeCreated bool `json:"backupWillBeCreated"`
+	// SettingsSize 插件键值存储的大小，卸载后保留
+	SettingsSize int64 `json:"settingsSize"`
+}
+
 // PluginBackupRequest 插件备份请求
 type PluginBackupRequest struct {
 	PluginID string `json:"plugin_id" binding:"required"`
@@ -53,13 +165,35 @@ type PluginBackupRequest struct {
 
 // CommandRegisterRequest 命令注册请求
 type CommandRegisterRequest struct {
-	ID    string `json:"id" binding:"required"`
-	Title string `json:"title" binding:"required"`
+	ID       string `json:"id" binding:"required"`
+	Title    string `json:"title" binding:"required"`
+	Category string `json:"category"`
+	Hotkey   string `json:"hotkey"`
+	// InvokePermission 其他插件调用该命令需要的权限，为空时不限制
+	InvokePermission string `json:"invokePermission"`
 }
 
 // CommandInvokeRequest 命令调用请求
 type CommandInvokeRequest struct {
 	ID string `json:"id" binding:"required"`
+	// PluginID 命令所属的插件，为空时为调用方自己的命令
+	PluginID  string `json:"pluginId"`
+	Wait      bool   `json:"wait"`      // 为 true 时等待处理命令的插件返回结果
+	TimeoutMs int    `json:"timeoutMs"` // 等待结果的超时（毫秒），0 表示默认值
+}
+
+// CommandResultRequest 插件提交命令执行结果的请求
+type CommandResultRequest struct {
+	InvocationID string          `json:"invocationId"`
+	Result       json.RawMessage `json:"result"`
+	Error        string          `json:"error"`
+}
+
+// CommandInvokeResponse 等待结果的命令调用响应
+type CommandInvokeResponse struct {
+	Ok           bool            `json:"ok"`
+	InvocationID string          `json:"invocationId"`
+	Result       json.RawMessage `json:"result,omitempty"`
 }
 
 // VaultListResponse 存储库文件列表响应
@@ -84,17 +218,40 @@ type VaultWriteRequest struct {
 	Content string `json:"content" binding:"required"`
 }
 
+// VaultCopyRequest 存储库文件复制请求
+type VaultCopyRequest struct {
+	From      string `json:"from" binding:"required"`
+	To        string `json:"to" binding:"required"`
+	Overwrite bool   `json:"overwrite"`
+}
+
 // VaultWriteResponse 存储库文件写入响应
 type VaultWriteResponse struct {
 	Ok bool `json:"ok"`
 }
 
+// VaultImportSkip 导入时被跳过的条目
+type VaultImportSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// VaultImportResponse 存储库批量导入结果
+type VaultImportResponse struct {
+	Created []string          `json:"created"`
+	Updated []string          `json:"updated"`
+	Skipped []VaultImportSkip `json:"skipped"`
+}
+
 // RPCRequest JSON-RPC请求结构
 type RPCRequest struct {
 	ID       string      `json:"id,omitempty"`
 	Method   string      `json:"method" binding:"required"`
 	Params   interface{} `json:"params,omitempty"`
 	PluginID string      `json:"pluginId,omitempty"`
+
+	// authenticated 插件身份是否由 X-Plugin-Key 凭据确认，仅声明 pluginId 时为 false
+	authenticated bool
 }
 
 // RPCResponse JSON-RPC响应结构
@@ -116,24 +273,86 @@ type InstallationStatusResponse struct {
 	Status      string     `json:"status"`
 	Progress    int        `json:"progress"`
 	Message     string     `json:"message"`
+	Attempts    int        `json:"attempts"`
 	InstalledAt *time.Time `json:"installed_at"`
 }
 
 // MarketItem 市场插件项目
 type MarketItem struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Version     string  `json:"version"`
-	Author      string  `json:"author"`
-	Description string  `json:"description"`
-	URL         string  `json:"url"`
-	SHA256      string  `json:"sha256"`
-	Downloads   int     `json:"downloads"`
-	Rating      float64 `json:"rating"`
-}
-
-// EventData 事件数据
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Author      string   `json:"author"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	SHA256      string   `json:"sha256"`
+	Downloads   int      `json:"downloads"`
+	Rating      float64  `json:"rating"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// MarketStatsResponse 市场索引的汇总统计
+type MarketStatsResponse struct {
+	Total     int            `json:"total"`
+	ByTag     map[string]int `json:"by_tag"`
+	ByAuthor  map[string]int `json:"by_author"`
+	Downloads int64          `json:"downloads"`
+}
+
+// AuditLogResponse 审计日志响应
+type AuditLogResponse struct {
+	ID        uint                   `json:"id"`
+	Action    string                 `json:"action"`
+	Actor     string                 `json:"actor"`
+	Target    string                 `json:"target"`
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// PluginFileResponse 插件包中的一个文件
+type PluginFileResponse struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Mode string `json:"mode"`
+}
+
+// EventHistoryResponse 事件历史记录响应
+type EventHistoryResponse struct {
+	Seq       uint                   `json:"seq"`
+	Type      string                 `json:"type"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// EventData 事件数据，安装相关事件的 Data 为 InstallProgress
 type EventData struct {
-	Type string                 `json:"type"`
-	Data map[string]interface{} `json:"data"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// InstallProgress 插件安装进度，用于 plugin.installation.progress、plugin.installation.failed
+// 和 plugin.installation.done 事件，字段与独立宿主一致
+type InstallProgress struct {
+	PluginID string `json:"pluginId"`
+	// Status 下载、校验等阶段时同 Phase，结束时为 failed 或 installed
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Message  string `json:"message,omitempty"`
+	// Phase 当前或失败时所处的阶段：downloading、verifying、extracting、configuring
+	Phase string `json:"phase,omitempty"`
+	// BytesDownloaded 下载阶段已下载的字节数
+	BytesDownloaded int64 `json:"bytesDownloaded,omitempty"`
+	// TotalBytes 安装包总字节数，服务端未返回 Content-Length 时省略
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+	// BytesPerSecond 从开始下载到现在的平均速率
+	BytesPerSecond int64 `json:"bytesPerSecond,omitempty"`
+	// ETASeconds 按平均速率估算的剩余秒数，总字节数未知时省略
+	ETASeconds int64 `json:"etaSeconds,omitempty"`
+	// Code 失败时的错误码，本服务不区分错误类型，始终为空
+	Code string `json:"code,omitempty"`
+	// Error 失败时的原始错误
+	Error    string `json:"error,omitempty"`
+	Terminal bool   `json:"terminal"`
+	// RequiresApproval 升级新增了权限，需批准后才生效
+	RequiresApproval bool `json:"requiresApproval,omitempty"`
 }
@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// servePluginZip 在本地服务器上提供只含清单的插件包，返回下载地址
+func servePluginZip(t *testing.T, manifest string) string {
+	t.Helper()
+	zipPath := writeTestZip(t, t.TempDir(), []string{"manifest.json"}, [][]byte{[]byte(manifest)})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, zipPath)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/plugin.zip"
+}
+
+// installAndWait 从本地测试服务安装插件，等待 plugin.installed 事件并返回其中的 enabled
+func installAndWait(t *testing.T, s *ServiceImpl, req *PluginInstallRequest) interface{} {
+	t.Helper()
+	req.URL = servePluginZip(t, `{"id":"`+req.ID+`","name":"Demo","version":"1.0.0"}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+	if err := s.InstallPlugin(req); err != nil {
+		t.Fatalf("InstallPlugin: %v", err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == "plugin.installation.failed" {
+				t.Fatalf("install failed: %+v", ev.Data)
+			}
+			if ev.Type == "plugin.installed" {
+				return ev.Data.(map[string]interface{})["enabled"]
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for plugin.installed")
+		}
+	}
+}
+
+func TestEnableOnInstall(t *testing.T) {
+	off, on := false, true
+	cases := []struct {
+		name       string
+		option     *bool
+		autoEnable *bool
+		want       bool
+	}{
+		{"default", nil, nil, true},
+		{"option disabled", &off, nil, false},
+		{"request enables", &off, &on, true},
+		{"request disables", nil, &off, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "",
+				ServiceOptions{EnableOnInstall: tc.option}).(*ServiceImpl)
+
+			if got := installAndWait(t, s, &PluginInstallRequest{ID: "demo", AutoEnable: tc.autoEnable}); got != tc.want {
+				t.Errorf("plugin.installed enabled = %v, want %v", got, tc.want)
+			}
+			plugin, err := s.repo.GetPluginByID("demo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plugin.Enabled != tc.want {
+				t.Errorf("Enabled = %v, want %v", plugin.Enabled, tc.want)
+			}
+		})
+	}
+}
+
+func TestReinstallKeepsEnabledState(t *testing.T) {
+	off := false
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	installAndWait(t, s, &PluginInstallRequest{ID: "demo", AutoEnable: &off})
+	// 安装记录在广播之后才释放，等待其释放后再重新安装
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, busy := s.getInstallation("demo"); !busy {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first installation never finished")
+		}
+	}
+
+	// 重新安装不改变已有插件的启用状态
+	if got := installAndWait(t, s, &PluginInstallRequest{ID: "demo"}); got != false {
+		t.Errorf("reinstall: plugin.installed enabled = %v, want false", got)
+	}
+	plugin, err := s.repo.GetPluginByID("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plugin.Enabled {
+		t.Error("reinstall enabled a disabled plugin")
+	}
+}
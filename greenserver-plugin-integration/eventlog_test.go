@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventHistoryQueryByType(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	s.Broadcast(&EventData{Type: "plugin.installed", Data: map[string]interface{}{"pluginId": "a"}})
+	s.Broadcast(&EventData{Type: "vault.changed"})
+	s.Broadcast(&EventData{Type: "plugin.installed", Data: map[string]interface{}{"pluginId": "b"}})
+
+	all, err := s.GetEventHistory(&EventHistoryQuery{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("history = %d events, %v, want 3", len(all), err)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Seq <= all[i-1].Seq {
+			t.Errorf("history not in sequence order: %d after %d", all[i].Seq, all[i-1].Seq)
+		}
+	}
+
+	installed, err := s.GetEventHistory(&EventHistoryQuery{Type: "plugin.installed"})
+	if err != nil || len(installed) != 2 {
+		t.Fatalf("type filter = %d events, %v, want 2", len(installed), err)
+	}
+	if installed[0].Data["pluginId"] != "a" || installed[1].Data["pluginId"] != "b" {
+		t.Errorf("type filter data = %v, %v", installed[0].Data, installed[1].Data)
+	}
+	// limit 保留最近的事件
+	latest, err := s.GetEventHistory(&EventHistoryQuery{Limit: 1})
+	if err != nil || len(latest) != 1 || latest[0].Seq != all[2].Seq {
+		t.Errorf("limit 1 = %+v, %v, want the latest event", latest, err)
+	}
+}
+
+func TestEventHistoryQueryByTimeAndPrune(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{EventRetention: 24 * time.Hour}).(*ServiceImpl)
+	now := time.Now()
+	for _, e := range []*EventLog{
+		{Type: "plugin.installed", CreatedAt: now.Add(-48 * time.Hour)},
+		{Type: "plugin.installed", CreatedAt: now.Add(-2 * time.Hour)},
+		{Type: "vault.changed", CreatedAt: now.Add(-time.Minute)},
+	} {
+		if err := repo.CreateEventLog(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 超出保留时长的事件不会返回
+	if got, err := s.GetEventHistory(&EventHistoryQuery{}); err != nil || len(got) != 2 {
+		t.Errorf("history = %d events, %v, want 2", len(got), err)
+	}
+	since := now.Add(-time.Hour)
+	if got, err := s.GetEventHistory(&EventHistoryQuery{Since: &since}); err != nil || len(got) != 1 || got[0].Type != "vault.changed" {
+		t.Errorf("since an hour ago = %+v, %v", got, err)
+	}
+
+	removed, err := s.PruneEventHistory()
+	if err != nil || removed != 1 {
+		t.Fatalf("PruneEventHistory = %d, %v, want 1", removed, err)
+	}
+	old := now.Add(-72 * time.Hour)
+	if logs, _ := repo.GetEventLogs(&EventHistoryQuery{Since: &old, Limit: 10}); len(logs) != 2 {
+		t.Errorf("%d event logs left after pruning, want 2", len(logs))
+	}
+}
@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPluginIncompatible 插件依赖服务未实现的特性，不能启用
+var ErrPluginIncompatible = errors.New("plugin requires unsupported host features")
+
+// hostFeatures 服务实现的特性，包括全部RPC方法名，插件清单 requiresFeatures 据此检查
+var hostFeatures = []string{
+	"audit",
+	"commands.invoke",
+	"commands.list",
+	"commands.register",
+	"commands.result",
+	"events.history",
+	"events.publish",
+	"host.approvePermissions",
+	"host.backupPlugin",
+	"host.batchSetEnabled",
+	"host.batchUninstall",
+	"host.checkPermission",
+	"host.disablePlugin",
+	"host.enablePlugin",
+	"host.getAllPermissions",
+	"host.getCommandPalette",
+	"host.getInstallationStatus",
+	"host.getPluginStats",
+	"host.getPlugins",
+	"host.listPluginFiles",
+	"host.previewUninstall",
+	"host.resetPluginStats",
+	"host.retryInstallation",
+	"host.setReadOnly",
+	"kv.delete",
+	"kv.get",
+	"kv.list",
+	"kv.set",
+	"sse",
+	"vault.copy",
+	"vault.delete",
+	"vault.export",
+	"vault.import",
+	"vault.list",
+	"vault.read",
+	"vault.write",
+	"webhooks",
+}
+
+// missingFeatures 返回清单 requiresFeatures 中服务未实现的特性
+func missingFeatures(manifest map[string]interface{}) []string {
+	required, ok := manifest["requiresFeatures"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var missing []string
+	for _, f := range required {
+		name, ok := f.(string)
+		if ok && !containsString(hostFeatures, name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// checkCompatible 插件缺少依赖的特性时广播 plugin.incompatible 并返回 ErrPluginIncompatible
+func (s *ServiceImpl) checkCompatible(pluginID string, manifest map[string]interface{}) error {
+	missing := missingFeatures(manifest)
+	if len(missing) == 0 {
+		return nil
+	}
+	s.Broadcast(&EventData{
+		Type: "plugin.incompatible",
+		Data: map[string]interface{}{
+			"pluginId": pluginID,
+			"missing":  missing,
+		},
+	})
+	return fmt.Errorf("%w: %s needs %s", ErrPluginIncompatible, pluginID, strings.Join(missing, ", "))
+}
+
+// checkInstalledCompatible 按插件目录中的清单检查兼容性，清单不可读时不做限制
+func (s *ServiceImpl) checkInstalledCompatible(pluginID string) error {
+	manifest, err := readManifestFile(filepath.Join(s.pluginsDir, pluginID, "manifest.json"))
+	if err != nil {
+		return nil
+	}
+	return s.checkCompatible(pluginID, manifest)
+}
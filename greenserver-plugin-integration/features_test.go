@@ -0,0 +1,89 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// installManifest 把清单写入插件目录并按清单创建插件记录
+func installManifest(t *testing.T, s *ServiceImpl, pluginID, manifest string) {
+	t.Helper()
+	dir := filepath.Join(s.pluginsDir, pluginID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadPluginFromManifest(path, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMissingFeatures(t *testing.T) {
+	cases := []struct {
+		manifest map[string]interface{}
+		want     int
+	}{
+		{map[string]interface{}{}, 0},
+		{map[string]interface{}{"requiresFeatures": []interface{}{"vault.read", "sse"}}, 0},
+		{map[string]interface{}{"requiresFeatures": []interface{}{"vault.read", "websocket", "gpu"}}, 2},
+	}
+	for _, tc := range cases {
+		if got := missingFeatures(tc.manifest); len(got) != tc.want {
+			t.Errorf("missingFeatures(%v) = %v, want %d missing", tc.manifest, got, tc.want)
+		}
+	}
+}
+
+func TestSatisfiedFeaturesInstallEnabled(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+
+	installManifest(t, s, "demo", `{"id":"demo","name":"Demo","version":"1.0.0","requiresFeatures":["vault.read","sse"]}`)
+	if plugin, _ := repo.GetPluginByID("demo"); !plugin.Enabled {
+		t.Error("plugin with satisfied requirements is disabled")
+	}
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == "plugin.incompatible" {
+			t.Errorf("unexpected plugin.incompatible: %+v", ev.Data)
+		}
+	}
+}
+
+func TestMissingFeaturesKeepPluginDisabled(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+
+	// 缺少特性的插件照常安装，但保持禁用，也不能手动启用
+	installManifest(t, s, "demo", `{"id":"demo","name":"Demo","version":"1.0.0","requiresFeatures":["websocket"]}`)
+	if plugin, _ := repo.GetPluginByID("demo"); plugin.Enabled {
+		t.Error("incompatible plugin was enabled")
+	}
+	var missing []string
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == "plugin.incompatible" {
+			missing, _ = ev.Data.(map[string]interface{})["missing"].([]string)
+		}
+	}
+	if len(missing) != 1 || missing[0] != "websocket" {
+		t.Errorf("plugin.incompatible missing = %v, want [websocket]", missing)
+	}
+
+	if err := s.EnablePlugin("demo"); !errors.Is(err, ErrPluginIncompatible) {
+		t.Errorf("EnablePlugin: err = %v, want ErrPluginIncompatible", err)
+	}
+	if plugin, _ := repo.GetPluginByID("demo"); plugin.Enabled {
+		t.Error("EnablePlugin enabled an incompatible plugin")
+	}
+}
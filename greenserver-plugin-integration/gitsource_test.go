@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveGitHubAPI 模拟 GitHub 发布版本接口，releases 的键为标签，"latest" 表示最新发布
+func serveGitHubAPI(t *testing.T, repo string, releases map[string]string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tag, ok := strings.CutPrefix(r.URL.Path, "/repos/"+repo+"/releases/tags/")
+		if !ok && r.URL.Path == "/repos/"+repo+"/releases/latest" {
+			tag, ok = "latest", true
+		}
+		assetURL, found := releases[tag]
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tag_name": tag,
+			"assets": []map[string]string{
+				{"name": "checksums.txt", "browser_download_url": "https://github.com/checksums.txt"},
+				{"name": "plugin.zip", "browser_download_url": assetURL},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestResolveGitRelease(t *testing.T) {
+	api := serveGitHubAPI(t, "acme/demo", map[string]string{
+		"v1.0.0": "https://github.com/acme/demo/releases/download/v1.0.0/plugin.zip",
+		"latest": "https://github.com/acme/demo/releases/download/v2.0.0/plugin.zip",
+	})
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{GitHubAPIURL: api}).(*ServiceImpl)
+
+	if got, err := s.resolveGitRelease("acme/demo", "v1.0.0"); err != nil || !strings.HasSuffix(got, "/v1.0.0/plugin.zip") {
+		t.Errorf("tagged release = %q, %v", got, err)
+	}
+	if got, err := s.resolveGitRelease("acme/demo", ""); err != nil || !strings.HasSuffix(got, "/v2.0.0/plugin.zip") {
+		t.Errorf("latest release = %q, %v", got, err)
+	}
+	if _, err := s.resolveGitRelease("acme/demo", "v9.9.9"); err == nil || !strings.Contains(err.Error(), "release not found") {
+		t.Errorf("missing tag: err = %v", err)
+	}
+	for _, repo := range []string{"acme", "acme/demo/extra", "../etc/passwd", "acme/.."} {
+		if _, err := s.resolveGitRelease(repo, ""); err == nil {
+			t.Errorf("repo %q: expected error", repo)
+		}
+	}
+}
+
+func TestInstallPluginGitSourceValidation(t *testing.T) {
+	api := serveGitHubAPI(t, "acme/demo", map[string]string{"v1.0.0": "ftp://github.com/acme/demo/plugin.zip"})
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{GitHubAPIURL: api}).(*ServiceImpl)
+
+	codeOf := func(req *PluginInstallRequest) string {
+		t.Helper()
+		var vf *ValidationFailedError
+		if err := s.InstallPlugin(req); !errors.As(err, &vf) || len(vf.Errors) != 1 {
+			t.Fatalf("InstallPlugin(%+v) = %v, want one validation error", req, err)
+		}
+		return vf.Errors[0].Code
+	}
+
+	if got := codeOf(&PluginInstallRequest{ID: "demo", Source: "svn"}); got != "INVALID_SOURCE" {
+		t.Errorf("unknown source: code = %s", got)
+	}
+	if got := codeOf(&PluginInstallRequest{ID: "demo", Source: InstallSourceGit, Repo: "acme/demo", Ref: "v9.9.9"}); got != "GIT_RESOLVE_FAILED" {
+		t.Errorf("unknown tag: code = %s", got)
+	}
+	// 解析出的地址仍要经过常规的安装校验
+	if got := codeOf(&PluginInstallRequest{ID: "demo", Source: InstallSourceGit, Repo: "acme/demo", Ref: "v1.0.0"}); got != "INSECURE_PROTOCOL" {
+		t.Errorf("resolved ftp URL: code = %s", got)
+	}
+}
@@ -1,12 +1,16 @@
 package plugin
 
 import (
+	"archive/zip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lgnixai/wmcms/pkg/logger"
@@ -17,37 +21,79 @@ import (
 type Handler struct {
 	service    Service
 	pluginsDir string
+	options    HandlerOptions
+	rpcStatsMu sync.Mutex
+	rpcStats   map[string]*pluginRPCCounter
+}
+
+// HandlerOptions 插件处理器可选配置
+type HandlerOptions struct {
+	// RPCTimeout 单个RPC请求的默认超时，0 表示使用 DefaultRPCTimeout
+	RPCTimeout time.Duration
+	// RPCMethodTimeouts 按方法名覆盖RPC超时
+	RPCMethodTimeouts map[string]time.Duration
+	// SlowRPCThreshold 耗时超过该值的RPC请求写入日志，0 表示使用 DefaultSlowRPCThreshold
+	SlowRPCThreshold time.Duration
+	// MaxRPCBatchSize RPC参数中单个数组的最大元素数，0 表示使用 DefaultMaxRPCBatchSize
+	MaxRPCBatchSize int
+	// MaxRPCParamsDepth RPC参数的最大嵌套层数，0 表示使用 DefaultMaxRPCParamsDepth
+	MaxRPCParamsDepth int
+	// PluginRPCRateLimit 每个插件每分钟最多的RPC调用数，超出时返回 429，0 表示不限制
+	PluginRPCRateLimit int
 }
 
 // NewHandler 创建插件处理器实例
 func NewHandler(service Service, pluginsDir string) *Handler {
+	return NewHandlerWithOptions(service, pluginsDir, HandlerOptions{})
+}
+
+// NewHandlerWithOptions 使用可选配置创建插件处理器实例
+func NewHandlerWithOptions(service Service, pluginsDir string, options HandlerOptions) *Handler {
 	return &Handler{
 		service:    service,
 		pluginsDir: pluginsDir,
+		options:    options,
 	}
 }
 
 // GetPlugins 获取所有插件
 // @Summary 获取所有插件
-// @Description 获取系统中所有插件的列表
+// @Description 获取系统中所有插件的列表，支持按启用状态、作者和运维标签过滤，名称和描述按 Accept-Language 本地化
 // @Tags 插件
 // @Accept json
 // @Produce json
+// @Param enabled query bool false "是否启用"
+// @Param author query string false "插件作者"
+// @Param label query string false "运维标签"
+// @Param sort query string false "排序字段：plugin_id（默认）或 name"
 // @Success 200 {array} PluginResponse
 // @Router /plugins [get]
 func (h *Handler) GetPlugins(c *gin.Context) {
-	plugins, err := h.service.GetAllPlugins()
+	var query PluginQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, http.StatusBadRequest, "查询参数错误")
+		return
+	}
+	if !validPluginSort(query.Sort) {
+		response.Error(c, http.StatusBadRequest, "不支持的排序字段")
+		return
+	}
+
+	plugins, err := h.service.GetAllPlugins(&query)
 	if err != nil {
 		response.Error(c, http.StatusInternalServerError, "获取插件列表失败")
 		return
 	}
+	for _, p := range plugins {
+		localizePlugin(p, c.GetHeader("Accept-Language"))
+	}
 
 	response.Success(c, plugins)
 }
 
 // GetPlugin 获取单个插件
 // @Summary 获取单个插件
-// @Description 根据插件ID获取插件详细信息
+// @Description 根据插件ID获取插件详细信息，名称和描述按 Accept-Language 本地化
 // @Tags 插件
 // @Accept json
 // @Produce json
@@ -66,6 +112,7 @@ func (h *Handler) GetPlugin(c *gin.Context) {
 		response.Error(c, http.StatusNotFound, "插件不存在")
 		return
 	}
+	localizePlugin(plugin, c.GetHeader("Accept-Language"))
 
 	response.Success(c, plugin)
 }
@@ -80,6 +127,9 @@ func (h *Handler) GetPlugin(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /plugins/enable [post]
 func (h *Handler) EnablePlugin(c *gin.Context) {
+	if h.rejectReadOnly(c) {
+		return
+	}
 	var req PluginToggleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, "请求参数错误")
@@ -87,9 +137,14 @@ func (h *Handler) EnablePlugin(c *gin.Context) {
 	}
 
 	if err := h.service.EnablePlugin(req.PluginID); err != nil {
+		if errors.Is(err, ErrPluginIncompatible) {
+			response.Error(c, http.StatusConflict, err.Error())
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "启用插件失败")
 		return
 	}
+	h.service.Audit("plugin.enable", h.actor(c, ""), req.PluginID, nil)
 
 	response.Success(c, gin.H{"message": "插件已启用"})
 }
@@ -104,6 +159,9 @@ func (h *Handler) EnablePlugin(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /plugins/disable [post]
 func (h *Handler) DisablePlugin(c *gin.Context) {
+	if h.rejectReadOnly(c) {
+		return
+	}
 	var req PluginToggleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, "请求参数错误")
@@ -114,6 +172,7 @@ func (h *Handler) DisablePlugin(c *gin.Context) {
 		response.Error(c, http.StatusInternalServerError, "禁用插件失败")
 		return
 	}
+	h.service.Audit("plugin.disable", h.actor(c, ""), req.PluginID, nil)
 
 	response.Success(c, gin.H{"message": "插件已禁用"})
 }
@@ -146,6 +205,71 @@ func (h *Handler) BackupPlugin(c *gin.Context) {
 	})
 }
 
+// DownloadPluginBackup 下载插件备份
+// @Summary 下载插件备份
+// @Description 把插件目录打包为ZIP直接流式返回，不在服务器上保存备份文件
+// @Tags 插件
+// @Produce application/zip
+// @Param id path string true "插件ID"
+// @Success 200 {file} binary
+// @Router /plugins/{id}/_host/backup [get]
+func (h *Handler) DownloadPluginBackup(c *gin.Context) {
+	pluginID := c.Param("id")
+	plugin, err := h.service.GetPlugin(pluginID)
+	if err != nil {
+		response.Error(c, http.StatusNotFound, "插件不存在")
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", BackupFileName(pluginID, plugin.Version)))
+	if err := h.service.WritePluginBackup(pluginID, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			response.Error(c, http.StatusInternalServerError, "备份插件失败")
+			return
+		}
+		// 响应头已发出，只能中断流并记录
+		logger.Error("Failed to stream plugin backup", err)
+		return
+	}
+
+	h.service.Audit("plugin.backup.download", h.actor(c, ""), pluginID, nil)
+}
+
+// ReconcilePlugins 按磁盘内容校正插件记录
+// @Summary 校正插件记录
+// @Description 重新扫描插件目录，添加新插件、更新清单有变化的插件并删除目录已不存在的插件记录（仅管理员）
+// @Tags 插件
+// @Accept json
+// @Produce json
+// @Success 200 {object} ReconcileResult
+// @Router /plugins/reconcile [post]
+func (h *Handler) ReconcilePlugins(c *gin.Context) {
+	if !h.isAdmin(c) {
+		response.Error(c, http.StatusForbidden, "需要管理员权限")
+		return
+	}
+	if h.rejectReadOnly(c) {
+		return
+	}
+
+	result, err := h.service.ReconcilePlugins()
+	if err != nil {
+		logger.Error("Failed to reconcile plugins", err)
+		response.Error(c, http.StatusInternalServerError, "校正插件记录失败")
+		return
+	}
+	h.service.Audit("plugin.reconcile", h.actor(c, ""), "", map[string]interface{}{
+		"added":   result.Added,
+		"updated": result.Updated,
+		"removed": result.Removed,
+	})
+
+	response.Success(c, result)
+}
+
 // InstallPlugin 安装插件
 // @Summary 安装插件
 // @Description 从URL安装插件
@@ -156,6 +280,9 @@ func (h *Handler) BackupPlugin(c *gin.Context) {
 // @Success 200 {object} response.Response
 // @Router /plugins/install [post]
 func (h *Handler) InstallPlugin(c *gin.Context) {
+	if h.rejectReadOnly(c) {
+		return
+	}
 	var req PluginInstallRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, "请求参数错误")
@@ -163,9 +290,31 @@ func (h *Handler) InstallPlugin(c *gin.Context) {
 	}
 
 	if err := h.service.InstallPlugin(&req); err != nil {
+		var vf *ValidationFailedError
+		if errors.As(err, &vf) {
+			status := http.StatusBadRequest
+			if errors.Is(err, ErrPluginNotAllowed) {
+				status = http.StatusForbidden
+			}
+			lang := negotiateLanguage(c.GetHeader("Accept-Language"))
+			c.JSON(status, ValidationErrorResponse{Errors: localizeErrors(vf.Errors, lang)})
+			return
+		}
+		if errors.Is(err, ErrMaxPluginsReached) {
+			response.Error(c, http.StatusConflict, "已安装插件数量达到上限")
+			return
+		}
+		if errors.Is(err, ErrInstallInProgress) {
+			response.Error(c, http.StatusConflict, "插件正在安装中")
+			return
+		}
 		response.Error(c, http.StatusInternalServerError, "安装插件失败")
 		return
 	}
+	h.service.Audit("plugin.install", h.actor(c, ""), req.ID, map[string]interface{}{
+		"url":    req.URL,
+		"sha256": req.SHA256,
+	})
 
 	response.Success(c, gin.H{"message": "插件安装已开始"})
 }
@@ -185,15 +334,94 @@ func (h *Handler) UninstallPlugin(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "插件ID不能为空")
 		return
 	}
+	if h.rejectReadOnly(c) {
+		return
+	}
 
 	if err := h.service.UninstallPlugin(pluginID); err != nil {
 		response.Error(c, http.StatusInternalServerError, "卸载插件失败")
 		return
 	}
+	h.service.Audit("plugin.uninstall", h.actor(c, ""), pluginID, nil)
 
 	response.Success(c, gin.H{"message": "插件已卸载"})
 }
 
+// AddPluginLabel 添加插件标签
+// @Summary 添加插件标签
+// @Description 为已安装插件添加运维标签（如 env:prod），与清单中的 tags 相互独立
+// @Tags 插件
+// @Accept json
+// @Produce json
+// @Param id path string true "插件ID"
+// @Param body body PluginLabelRequest true "标签"
+// @Success 200 {object} response.Response
+// @Router /plugins/{id}/labels [post]
+func (h *Handler) AddPluginLabel(c *gin.Context) {
+	pluginID := c.Param("id")
+	if h.rejectReadOnly(c) {
+		return
+	}
+	var req PluginLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.service.AddPluginLabel(pluginID, req.Label); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidLabel):
+			response.Error(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrPluginNotFound):
+			response.Error(c, http.StatusNotFound, "插件不存在")
+		default:
+			response.Error(c, http.StatusInternalServerError, "添加标签失败")
+		}
+		return
+	}
+	h.service.Audit("plugin.label.add", h.actor(c, ""), pluginID, map[string]interface{}{"label": req.Label})
+
+	response.Success(c, gin.H{"message": "标签已添加"})
+}
+
+// RemovePluginLabel 删除插件标签
+// @Summary 删除插件标签
+// @Description 删除插件的运维标签
+// @Tags 插件
+// @Accept json
+// @Produce json
+// @Param id path string true "插件ID"
+// @Param label path string true "标签"
+// @Success 200 {object} response.Response
+// @Router /plugins/{id}/labels/{label} [delete]
+func (h *Handler) RemovePluginLabel(c *gin.Context) {
+	pluginID := c.Param("id")
+	label := c.Param("label")
+	if h.rejectReadOnly(c) {
+		return
+	}
+
+	removed, err := h.service.RemovePluginLabel(pluginID, label)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidLabel):
+			response.Error(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrPluginNotFound):
+			response.Error(c, http.StatusNotFound, "插件不存在")
+		default:
+			response.Error(c, http.StatusInternalServerError, "删除标签失败")
+		}
+		return
+	}
+	if !removed {
+		response.Error(c, http.StatusNotFound, "标签不存在")
+		return
+	}
+	h.service.Audit("plugin.label.remove", h.actor(c, ""), pluginID, map[string]interface{}{"label": label})
+
+	response.Success(c, gin.H{"message": "标签已删除"})
+}
+
 // GetInstallationStatus 获取安装状态
 // @Summary 获取安装状态
 // @Description 获取插件的安装状态
@@ -239,22 +467,208 @@ func (h *Handler) GetCommands(c *gin.Context) {
 
 // GetMarketItems 获取市场插件
 // @Summary 获取市场插件
-// @Description 获取插件市场中的所有插件
+// @Description 获取插件市场中的所有插件，支持按标签过滤；Accept 为 text/html 时返回 HTML 页面
 // @Tags 插件
 // @Accept json
-// @Produce json
+// @Produce json,html
+// @Param tag query string false "标签"
 // @Success 200 {array} MarketItem
 // @Router /plugins/market [get]
 func (h *Handler) GetMarketItems(c *gin.Context) {
-	items, err := h.service.GetMarketItems()
+	items, err := h.service.GetMarketItems(c.Query("tag"))
 	if err != nil {
 		response.Error(c, http.StatusBadGateway, "获取市场插件失败")
 		return
 	}
 
+	c.Writer.Header().Add("Vary", "Accept")
+	if wantsHTML(c.GetHeader("Accept")) {
+		writeMarketHTML(c, items)
+		return
+	}
 	response.Success(c, items)
 }
 
+// GetMarketStats 获取市场统计
+// @Summary 获取市场统计
+// @Description 返回市场插件总数、按标签和作者的插件数以及下载量总和
+// @Tags 插件
+// @Produce json
+// @Success 200 {object} MarketStatsResponse
+// @Router /plugins/market/stats [get]
+func (h *Handler) GetMarketStats(c *gin.Context) {
+	stats, err := h.service.GetMarketStats()
+	if err != nil {
+		response.Error(c, http.StatusBadGateway, "获取市场统计失败")
+		return
+	}
+	response.Success(c, stats)
+}
+
+// ImportVault 批量导入笔记
+// @Summary 批量导入笔记
+// @Description 上传 zip 压缩包，将其中的文件导入当前用户存储库的 prefix 目录下
+// @Tags 插件
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "zip 压缩包"
+// @Param prefix formData string false "目标目录"
+// @Success 200 {object} VaultImportResponse
+// @Router /plugins/vault/import [post]
+func (h *Handler) ImportVault(c *gin.Context) {
+	userID := h.getUserID(c)
+	if userID == 0 {
+		response.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+	if h.rejectReadOnly(c) {
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "缺少上传文件")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "读取上传文件失败")
+		return
+	}
+	defer file.Close()
+
+	zr, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的 zip 文件")
+		return
+	}
+
+	prefix := c.PostForm("prefix")
+	result, err := h.service.ImportVault(userID, prefix, zr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "导入失败: "+err.Error())
+		return
+	}
+
+	h.service.Audit("vault.import", h.actor(c, ""), prefix, map[string]interface{}{
+		"created": len(result.Created),
+		"updated": len(result.Updated),
+		"skipped": len(result.Skipped),
+	})
+	response.Success(c, result)
+}
+
+// ExportVault 导出存储库
+// @Summary 导出存储库
+// @Description 以 zip 流的形式导出当前用户存储库中的文件，需要插件具有 vault.read 权限
+// @Tags 插件
+// @Produce application/zip
+// @Param pluginId query string false "插件ID"
+// @Param prefix query string false "只导出该目录下的文件"
+// @Success 200 {file} file
+// @Router /plugins/vault/export [get]
+func (h *Handler) ExportVault(c *gin.Context) {
+	userID := h.getUserID(c)
+	if userID == 0 {
+		response.Error(c, http.StatusUnauthorized, "未登录")
+		return
+	}
+
+	pluginID, err := h.service.ResolvePluginID(c.GetHeader("X-Plugin-Key"), c.Query("pluginId"))
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, ErrPluginIDMismatch) {
+			status = http.StatusForbidden
+		}
+		response.Error(c, status, err.Error())
+		return
+	}
+	if !h.hasPermission(pluginID, "vault.read") {
+		response.Error(c, http.StatusForbidden, "missing permission: vault.read")
+		return
+	}
+
+	prefix := c.Query("prefix")
+	if _, ok := vaultImportPath(prefix, "x"); !ok {
+		response.Error(c, http.StatusBadRequest, "invalid prefix")
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="vault.zip"`)
+	if err := h.service.ExportVault(c.Request.Context(), userID, prefix, c.Writer); err != nil {
+		// 响应头已发出，只能中断流并记录
+		logger.Error("Failed to export vault", err)
+		return
+	}
+
+	h.service.Audit("vault.export", h.actor(c, pluginID), prefix, nil)
+}
+
+// GetAuditLogs 查询审计日志
+// @Summary 查询审计日志
+// @Description 按操作类型和时间范围查询特权操作审计日志（仅管理员）
+// @Tags 插件
+// @Accept json
+// @Produce json
+// @Param action query string false "操作类型"
+// @Param since query string false "起始时间（RFC3339）"
+// @Param until query string false "结束时间（RFC3339）"
+// @Success 200 {array} AuditLogResponse
+// @Router /plugins/audit [get]
+func (h *Handler) GetAuditLogs(c *gin.Context) {
+	if !h.isAdmin(c) {
+		response.Error(c, http.StatusForbidden, "需要管理员权限")
+		return
+	}
+
+	var query AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, http.StatusBadRequest, "查询参数错误")
+		return
+	}
+
+	logs, err := h.service.GetAuditLogs(&query)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取审计日志失败")
+		return
+	}
+
+	response.Success(c, logs)
+}
+
+// GetEventHistory 查询事件历史
+// @Summary 查询事件历史
+// @Description 按事件类型和时间查询已广播的事件（仅管理员），按序号升序返回最近的记录
+// @Tags 插件
+// @Accept json
+// @Produce json
+// @Param type query string false "事件类型"
+// @Param since query string false "起始时间（RFC3339）"
+// @Param limit query int false "最大条数，默认100，最多1000"
+// @Success 200 {array} EventHistoryResponse
+// @Router /plugins/events/history [get]
+func (h *Handler) GetEventHistory(c *gin.Context) {
+	if !h.isAdmin(c) {
+		response.Error(c, http.StatusForbidden, "需要管理员权限")
+		return
+	}
+
+	var query EventHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil || query.Limit < 0 {
+		response.Error(c, http.StatusBadRequest, "查询参数错误")
+		return
+	}
+
+	events, err := h.service.GetEventHistory(&query)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "获取事件历史失败")
+		return
+	}
+
+	response.Success(c, events)
+}
+
 // HandleRPC 处理JSON-RPC请求
 // @Summary 处理JSON-RPC请求
 // @Description 处理插件的JSON-RPC API调用
@@ -265,19 +679,72 @@ func (h *Handler) GetMarketItems(c *gin.Context) {
 // @Success 200 {object} RPCResponse
 // @Router /plugins/rpc [post]
 func (h *Handler) HandleRPC(c *gin.Context) {
+	body := &rpcStatsReader{ReadCloser: c.Request.Body}
+	c.Request.Body = body
 	var req RPCRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.writeRPCError(c, req.ID, 400, "invalid json")
 		return
 	}
 
+	// 插件身份以凭据为准，不信任请求体中声明的 pluginId
+	key := c.GetHeader("X-Plugin-Key")
+	pluginID, err := h.service.ResolvePluginID(key, req.PluginID)
+	if err != nil {
+		code := 401
+		if errors.Is(err, ErrPluginIDMismatch) {
+			code = 403
+		}
+		h.writeRPCError(c, req.ID, code, err.Error())
+		return
+	}
+	req.PluginID = pluginID
+	req.authenticated = key != "" && pluginID != ""
+
+	defer func() { h.recordPluginRPC(c, pluginID, body.n) }()
+	if !h.allowPluginRPC(pluginID) {
+		h.writeRPCError(c, req.ID, 429, "rate limit exceeded")
+		return
+	}
+
+	if err := checkRPCParams(req.Params, 0, h.maxRPCBatchSize(), h.maxRPCParamsDepth()); err != nil {
+		h.writeRPCError(c, req.ID, 400, err.Error())
+		return
+	}
+
+	// 方法执行超过超时后取消请求上下文，未完成的查询随之中止
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.rpcTimeout(req.Method))
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	defer h.logSlowRPC(req.Method, req.PluginID, start)
+
+	if mutatingRPCMethods[req.Method] && h.service.IsReadOnly() {
+		h.writeRPCError(c, req.ID, 503, readOnlyMessage)
+		return
+	}
+
 	switch req.Method {
 	case "host.getPlugins":
-		plugins, err := h.service.GetAllPlugins()
+		var query PluginQuery
+		if req.Params != nil {
+			if err := h.parseParams(req.Params, &query); err != nil {
+				h.writeRPCError(c, req.ID, 400, "invalid params")
+				return
+			}
+		}
+		if !validPluginSort(query.Sort) {
+			h.writeRPCError(c, req.ID, 400, "invalid sort: "+query.Sort)
+			return
+		}
+		plugins, err := h.service.GetAllPlugins(&query)
 		if err != nil {
 			h.writeRPCError(c, req.ID, 500, err.Error())
 			return
 		}
+		for _, p := range plugins {
+			localizePlugin(p, c.GetHeader("Accept-Language"))
+		}
 		h.writeRPCResult(c, req.ID, plugins)
 
 	case "vault.list":
@@ -292,12 +759,12 @@ func (h *Handler) HandleRPC(c *gin.Context) {
 			return
 		}
 
-		paths, err := h.service.ListVaultFiles(userID)
+		paths, err := h.service.ListVaultFiles(c.Request.Context(), userID)
 		if err != nil {
 			h.writeRPCError(c, req.ID, 500, err.Error())
 			return
 		}
-		h.writeRPCResult(c, req.ID, paths)
+		h.writeRPCResult(c, req.ID, h.filterVaultSandbox(req.PluginID, paths))
 
 	case "vault.read":
 		if !h.hasPermission(req.PluginID, "vault.read") {
@@ -318,10 +785,13 @@ func (h *Handler) HandleRPC(c *gin.Context) {
 			h.writeRPCError(c, req.ID, 400, "missing path")
 			return
 		}
+		if !h.checkVaultSandbox(c, req, params.Path) {
+			return
+		}
 
 		result, err := h.service.ReadVaultFile(userID, params.Path)
 		if err != nil {
-			h.writeRPCError(c, req.ID, 404, err.Error())
+			h.writeRPCError(c, req.ID, vaultErrorCode(err), err.Error())
 			return
 		}
 		h.writeRPCResult(c, req.ID, result)
@@ -343,11 +813,104 @@ func (h *Handler) HandleRPC(c *gin.Context) {
 			h.writeRPCError(c, req.ID, 400, "missing params")
 			return
 		}
+		if !h.checkVaultSandbox(c, req, params.Path) {
+			return
+		}
 
 		if err := h.service.WriteVaultFile(userID, &params); err != nil {
+			if errors.Is(err, ErrInvalidVaultPath) {
+				h.writeRPCError(c, req.ID, 400, err.Error())
+				return
+			}
+			if errors.Is(err, ErrVaultQuotaExceeded) {
+				h.writeRPCError(c, req.ID, 413, err.Error())
+				return
+			}
+			if errors.Is(err, ErrVaultWriteRejected) {
+				h.writeRPCError(c, req.ID, 403, err.Error())
+				return
+			}
 			h.writeRPCError(c, req.ID, 500, err.Error())
 			return
 		}
+		h.service.Audit("vault.write", h.actor(c, req.PluginID), params.Path, map[string]interface{}{
+			"size": len(params.Content),
+		})
+		h.writeRPCResult(c, req.ID, VaultWriteResponse{Ok: true})
+
+	case "vault.copy":
+		if !h.hasPermission(req.PluginID, "vault.read") {
+			h.writeRPCError(c, req.ID, 403, "missing permission: vault.read")
+			return
+		}
+		if !h.hasPermission(req.PluginID, "vault.write") {
+			h.writeRPCError(c, req.ID, 403, "missing permission: vault.write")
+			return
+		}
+
+		userID := h.getUserID(c)
+		if userID == 0 {
+			h.writeRPCError(c, req.ID, 401, "unauthorized")
+			return
+		}
+
+		var params VaultCopyRequest
+		if err := h.parseParams(req.Params, &params); err != nil || params.From == "" || params.To == "" {
+			h.writeRPCError(c, req.ID, 400, "missing params")
+			return
+		}
+		if !h.checkVaultSandbox(c, req, params.From) || !h.checkVaultSandbox(c, req, params.To) {
+			return
+		}
+
+		if err := h.service.CopyVaultFile(userID, &params); err != nil {
+			switch {
+			case errors.Is(err, ErrVaultFileNotFound):
+				h.writeRPCError(c, req.ID, 404, err.Error())
+			case errors.Is(err, ErrVaultFileExists):
+				h.writeRPCError(c, req.ID, 409, err.Error())
+			case errors.Is(err, ErrInvalidVaultPath):
+				h.writeRPCError(c, req.ID, 400, err.Error())
+			case errors.Is(err, ErrVaultQuotaExceeded):
+				h.writeRPCError(c, req.ID, 413, err.Error())
+			case errors.Is(err, ErrVaultWriteRejected):
+				h.writeRPCError(c, req.ID, 403, err.Error())
+			default:
+				h.writeRPCError(c, req.ID, 500, err.Error())
+			}
+			return
+		}
+		h.service.Audit("vault.copy", h.actor(c, req.PluginID), params.To, map[string]interface{}{
+			"from": params.From,
+		})
+		h.writeRPCResult(c, req.ID, VaultWriteResponse{Ok: true})
+
+	case "vault.delete":
+		if !h.hasPermission(req.PluginID, "vault.write") {
+			h.writeRPCError(c, req.ID, 403, "missing permission: vault.write")
+			return
+		}
+
+		userID := h.getUserID(c)
+		if userID == 0 {
+			h.writeRPCError(c, req.ID, 401, "unauthorized")
+			return
+		}
+
+		var params VaultReadRequest
+		if err := h.parseParams(req.Params, &params); err != nil || params.Path == "" {
+			h.writeRPCError(c, req.ID, 400, "missing params")
+			return
+		}
+		if !h.checkVaultSandbox(c, req, params.Path) {
+			return
+		}
+
+		if err := h.service.DeleteVaultFile(userID, params.Path); err != nil {
+			h.writeRPCError(c, req.ID, vaultErrorCode(err), err.Error())
+			return
+		}
+		h.service.Audit("vault.delete", h.actor(c, req.PluginID), params.Path, nil)
 		h.writeRPCResult(c, req.ID, VaultWriteResponse{Ok: true})
 
 	case "commands.register":
@@ -376,19 +939,327 @@ func (h *Handler) HandleRPC(c *gin.Context) {
 		}
 		h.writeRPCResult(c, req.ID, commands)
 
+	case "host.getCommandPalette":
+		var params struct {
+			IncludeDisabled bool `json:"includeDisabled"` // 为 true 时同时返回已禁用插件的命令
+		}
+		if req.Params != nil {
+			if err := h.parseParams(req.Params, &params); err != nil {
+				h.writeRPCError(c, req.ID, 400, "invalid params")
+				return
+			}
+		}
+		items, err := h.service.GetCommandPalette(params.IncludeDisabled)
+		if err != nil {
+			h.writeRPCError(c, req.ID, 500, err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, items)
+
 	case "commands.invoke":
 		var params CommandInvokeRequest
 		if err := h.parseParams(req.Params, &params); err != nil || params.ID == "" || req.PluginID == "" {
 			h.writeRPCError(c, req.ID, 400, "missing params")
 			return
 		}
+		// 调用其他插件的命令时，命令声明的 invokePermission 须由调用方持有
+		owner := req.PluginID
+		if params.PluginID != "" {
+			owner = params.PluginID
+		}
+		if owner != req.PluginID {
+			perm, err := h.service.CommandInvokePermission(owner, params.ID)
+			if err != nil {
+				h.writeRPCError(c, req.ID, 500, err.Error())
+				return
+			}
+			if perm != "" && !h.hasPermission(req.PluginID, perm) {
+				h.writeRPCError(c, req.ID, 403, "missing permission: "+perm)
+				return
+			}
+		}
+
+		if params.Wait {
+			timeout := commandDefaultTimeout
+			if params.TimeoutMs > 0 {
+				timeout = time.Duration(params.TimeoutMs) * time.Millisecond
+				if timeout > commandMaxTimeout {
+					timeout = commandMaxTimeout
+				}
+			}
+			invocationID, result, err := h.service.InvokeCommandWait(c.Request.Context(), owner, params.ID, timeout)
+			if err != nil {
+				code := 500
+				if errors.Is(err, ErrCommandTimeout) {
+					code = 504
+				}
+				h.writeRPCError(c, req.ID, code, err.Error())
+				return
+			}
+			h.writeRPCResult(c, req.ID, CommandInvokeResponse{Ok: true, InvocationID: invocationID, Result: result})
+			return
+		}
 
-		if err := h.service.InvokeCommand(req.PluginID, params.ID); err != nil {
+		if err := h.service.InvokeCommand(owner, params.ID); err != nil {
 			h.writeRPCError(c, req.ID, 500, err.Error())
 			return
 		}
 		h.writeRPCResult(c, req.ID, gin.H{"ok": true})
 
+	case "commands.result":
+		var params CommandResultRequest
+		if err := h.parseParams(req.Params, &params); err != nil || params.InvocationID == "" || req.PluginID == "" {
+			h.writeRPCError(c, req.ID, 400, "missing params")
+			return
+		}
+		if err := h.service.PostCommandResult(req.PluginID, &params); err != nil {
+			h.writeRPCError(c, req.ID, 404, err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, gin.H{"ok": true})
+
+	case "kv.get":
+		var params struct {
+			Key string `json:"key"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil {
+			h.writeRPCError(c, req.ID, 400, "missing params")
+			return
+		}
+		if !h.checkKVAccess(c, req, "kv.read") {
+			return
+		}
+		value, err := h.service.KVGet(req.PluginID, params.Key)
+		if err != nil {
+			h.writeRPCError(c, req.ID, kvErrorCode(err), err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, gin.H{"key": params.Key, "value": value})
+
+	case "kv.set":
+		var params struct {
+			Key   string          `json:"key"`
+			Value json.RawMessage `json:"value"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil || len(params.Value) == 0 {
+			h.writeRPCError(c, req.ID, 400, "missing params")
+			return
+		}
+		if !h.checkKVAccess(c, req, "kv.write") {
+			return
+		}
+		if err := h.service.KVSet(req.PluginID, params.Key, params.Value); err != nil {
+			h.writeRPCError(c, req.ID, kvErrorCode(err), err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, gin.H{"ok": true})
+
+	case "kv.delete":
+		var params struct {
+			Key string `json:"key"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil {
+			h.writeRPCError(c, req.ID, 400, "missing params")
+			return
+		}
+		if !h.checkKVAccess(c, req, "kv.write") {
+			return
+		}
+		deleted, err := h.service.KVDelete(req.PluginID, params.Key)
+		if err != nil {
+			h.writeRPCError(c, req.ID, kvErrorCode(err), err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, gin.H{"deleted": deleted})
+
+	case "kv.list":
+		var params struct {
+			Prefix string `json:"prefix"`
+		}
+		if req.Params != nil {
+			if err := h.parseParams(req.Params, &params); err != nil {
+				h.writeRPCError(c, req.ID, 400, "invalid params")
+				return
+			}
+		}
+		if !h.checkKVAccess(c, req, "kv.read") {
+			return
+		}
+		keys, err := h.service.KVList(req.PluginID, params.Prefix)
+		if err != nil {
+			h.writeRPCError(c, req.ID, kvErrorCode(err), err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, keys)
+
+	case "events.publish":
+		if !h.hasPermission(req.PluginID, "events.publish") {
+			h.writeRPCError(c, req.ID, 403, "missing permission: events.publish")
+			return
+		}
+
+		var params struct {
+			Name    string          `json:"name"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil || params.Name == "" {
+			h.writeRPCError(c, req.ID, 400, "missing name")
+			return
+		}
+
+		eventType, err := h.service.PublishEvent(req.PluginID, params.Name, params.Payload)
+		if err != nil {
+			if errors.Is(err, ErrEventPayloadTooLarge) {
+				h.writeRPCError(c, req.ID, 413, err.Error())
+				return
+			}
+			h.writeRPCError(c, req.ID, 400, err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, gin.H{"type": eventType})
+
+	case "host.checkPermission":
+		var params struct {
+			PluginID   string `json:"pluginId"`
+			Permission string `json:"permission"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil || params.PluginID == "" || params.Permission == "" {
+			h.writeRPCError(c, req.ID, 400, "missing params")
+			return
+		}
+		h.writeRPCResult(c, req.ID, gin.H{"granted": h.service.HasPermission(params.PluginID, params.Permission)})
+
+	case "host.getAllPermissions":
+		permissions, err := h.service.GetAllPluginPermissions()
+		if err != nil {
+			h.writeRPCError(c, req.ID, 500, err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, permissions)
+
+	case "host.listPluginFiles":
+		if !h.isAdmin(c) {
+			h.writeRPCError(c, req.ID, 403, "admin required")
+			return
+		}
+		var params struct {
+			PluginID string `json:"pluginId"`
+			Glob     string `json:"glob"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil || params.PluginID == "" {
+			h.writeRPCError(c, req.ID, 400, "missing pluginId")
+			return
+		}
+		files, err := h.service.ListPluginFiles(params.PluginID, params.Glob)
+		if err != nil {
+			if errors.Is(err, ErrPluginNotFound) {
+				h.writeRPCError(c, req.ID, 404, "plugin not found")
+				return
+			}
+			h.writeRPCError(c, req.ID, 400, err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, files)
+
+	case "host.previewUninstall":
+		if !h.isAdmin(c) {
+			h.writeRPCError(c, req.ID, 403, "admin required")
+			return
+		}
+		var params struct {
+			PluginID string `json:"pluginId"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil || params.PluginID == "" {
+			h.writeRPCError(c, req.ID, 400, "missing pluginId")
+			return
+		}
+		preview, err := h.service.PreviewUninstall(params.PluginID)
+		if err != nil {
+			if errors.Is(err, ErrPluginNotFound) {
+				h.writeRPCError(c, req.ID, 404, "plugin not found")
+				return
+			}
+			h.writeRPCError(c, req.ID, 500, err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, preview)
+
+	case "host.getPluginStats":
+		var params struct {
+			PluginID string `json:"pluginId"`
+		}
+		if req.Params != nil {
+			if err := h.parseParams(req.Params, &params); err != nil {
+				h.writeRPCError(c, req.ID, 400, "invalid params")
+				return
+			}
+		}
+		// 以凭据认证的插件只能查看自己的统计，管理员可查看全部
+		target, ok := h.authorizeTargetPlugin(c, req, params.PluginID)
+		if !ok {
+			h.writeRPCError(c, req.ID, 403, "admin required")
+			return
+		}
+		params.PluginID = target
+		h.writeRPCResult(c, req.ID, h.pluginRPCStats(params.PluginID))
+
+	case "host.resetPluginStats":
+		if !h.isAdmin(c) {
+			h.writeRPCError(c, req.ID, 403, "admin required")
+			return
+		}
+		var params struct {
+			PluginID string `json:"pluginId"`
+		}
+		if req.Params != nil {
+			if err := h.parseParams(req.Params, &params); err != nil {
+				h.writeRPCError(c, req.ID, 400, "invalid params")
+				return
+			}
+		}
+		h.resetPluginRPCStats(params.PluginID)
+		h.service.Audit("host.resetPluginStats", h.actor(c, req.PluginID), params.PluginID, nil)
+		h.writeRPCResult(c, req.ID, gin.H{"ok": true})
+
+	case "host.approvePermissions":
+		if !h.isAdmin(c) {
+			h.writeRPCError(c, req.ID, 403, "admin required")
+			return
+		}
+		var params struct {
+			PluginID string `json:"pluginId"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil || params.PluginID == "" {
+			h.writeRPCError(c, req.ID, 400, "missing pluginId")
+			return
+		}
+		approved, err := h.service.ApprovePermissions(params.PluginID, h.actor(c, req.PluginID))
+		if err != nil {
+			if errors.Is(err, ErrPluginNotFound) {
+				h.writeRPCError(c, req.ID, 404, "plugin not found")
+				return
+			}
+			h.writeRPCError(c, req.ID, 500, err.Error())
+			return
+		}
+		h.writeRPCResult(c, req.ID, gin.H{"pluginId": params.PluginID, "approved": approved})
+
+	case "host.setReadOnly":
+		if !h.isAdmin(c) {
+			h.writeRPCError(c, req.ID, 403, "admin required")
+			return
+		}
+		var params struct {
+			ReadOnly *bool `json:"readOnly"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil || params.ReadOnly == nil {
+			h.writeRPCError(c, req.ID, 400, "missing readOnly")
+			return
+		}
+		h.service.SetReadOnly(*params.ReadOnly)
+		h.service.Audit("host.setReadOnly", h.actor(c, req.PluginID), "", map[string]interface{}{"readOnly": *params.ReadOnly})
+		h.writeRPCResult(c, req.ID, gin.H{"readOnly": *params.ReadOnly})
+
 	case "host.getInstallationStatus":
 		var params struct {
 			PluginID string `json:"pluginId"`
@@ -405,6 +1276,36 @@ func (h *Handler) HandleRPC(c *gin.Context) {
 		}
 		h.writeRPCResult(c, req.ID, status)
 
+	case "host.retryInstallation":
+		var params struct {
+			PluginID string `json:"pluginId"`
+		}
+		if err := h.parseParams(req.Params, &params); err != nil || params.PluginID == "" {
+			h.writeRPCError(c, req.ID, 400, "missing pluginId")
+			return
+		}
+
+		status, err := h.service.RetryInstallation(params.PluginID)
+		if err != nil {
+			var vf *ValidationFailedError
+			switch {
+			case errors.Is(err, ErrInstallationNotFound):
+				h.writeRPCError(c, req.ID, 404, err.Error())
+			case errors.Is(err, ErrInstallationNotRetryable), errors.Is(err, ErrInstallationDead),
+				errors.Is(err, ErrInstallInProgress), errors.Is(err, ErrMaxPluginsReached):
+				h.writeRPCError(c, req.ID, 409, err.Error())
+			case errors.Is(err, ErrPluginNotAllowed):
+				h.writeRPCError(c, req.ID, 403, err.Error())
+			case errors.As(err, &vf):
+				h.writeRPCError(c, req.ID, 400, err.Error())
+			default:
+				h.writeRPCError(c, req.ID, 500, err.Error())
+			}
+			return
+		}
+		h.service.Audit("plugin.installation.retry", h.actor(c, req.PluginID), params.PluginID, map[string]interface{}{"attempt": status.Attempts})
+		h.writeRPCResult(c, req.ID, status)
+
 	case "host.enablePlugin":
 		var params PluginToggleRequest
 		if err := h.parseParams(req.Params, &params); err != nil || params.PluginID == "" {
@@ -413,9 +1314,14 @@ func (h *Handler) HandleRPC(c *gin.Context) {
 		}
 
 		if err := h.service.EnablePlugin(params.PluginID); err != nil {
+			if errors.Is(err, ErrPluginIncompatible) {
+				h.writeRPCError(c, req.ID, 409, err.Error())
+				return
+			}
 			h.writeRPCError(c, req.ID, 404, err.Error())
 			return
 		}
+		h.service.Audit("plugin.enable", h.actor(c, req.PluginID), params.PluginID, nil)
 		h.writeRPCResult(c, req.ID, gin.H{"ok": true})
 
 	case "host.disablePlugin":
@@ -429,8 +1335,43 @@ func (h *Handler) HandleRPC(c *gin.Context) {
 			h.writeRPCError(c, req.ID, 404, err.Error())
 			return
 		}
+		h.service.Audit("plugin.disable", h.actor(c, req.PluginID), params.PluginID, nil)
 		h.writeRPCResult(c, req.ID, gin.H{"ok": true})
 
+	case "host.batchSetEnabled":
+		var params PluginBatchRequest
+		if err := h.parseParams(req.Params, &params); err != nil || len(params.PluginIDs) == 0 || params.Enabled == nil {
+			h.writeRPCError(c, req.ID, 400, "missing params")
+			return
+		}
+
+		results := h.service.BatchSetEnabled(params.PluginIDs, *params.Enabled)
+		action := "plugin.disable"
+		if *params.Enabled {
+			action = "plugin.enable"
+		}
+		for _, result := range results {
+			if result.Ok {
+				h.service.Audit(action, h.actor(c, req.PluginID), result.PluginID, nil)
+			}
+		}
+		h.writeRPCResult(c, req.ID, results)
+
+	case "host.batchUninstall":
+		var params PluginBatchRequest
+		if err := h.parseParams(req.Params, &params); err != nil || len(params.PluginIDs) == 0 {
+			h.writeRPCError(c, req.ID, 400, "missing plugin_ids")
+			return
+		}
+
+		results := h.service.BatchUninstall(params.PluginIDs)
+		for _, result := range results {
+			if result.Ok {
+				h.service.Audit("plugin.uninstall", h.actor(c, req.PluginID), result.PluginID, nil)
+			}
+		}
+		h.writeRPCResult(c, req.ID, results)
+
 	case "host.backupPlugin":
 		var params PluginBackupRequest
 		if err := h.parseParams(req.Params, &params); err != nil || params.PluginID == "" {
@@ -522,6 +1463,23 @@ func (h *Handler) hasPermission(pluginID, permission string) bool {
 	return h.service.HasPermission(pluginID, permission)
 }
 
+// checkKVAccess 键值存储只对已安装且声明了 perm 的插件开放，读写的总是调用方（已解析的插件ID）自己的存储
+func (h *Handler) checkKVAccess(c *gin.Context, req RPCRequest, perm string) bool {
+	if req.PluginID == "" {
+		h.writeRPCError(c, req.ID, 400, "missing pluginId")
+		return false
+	}
+	if _, err := h.service.GetPlugin(req.PluginID); err != nil {
+		h.writeRPCError(c, req.ID, 404, "plugin not found")
+		return false
+	}
+	if !h.hasPermission(req.PluginID, perm) {
+		h.writeRPCError(c, req.ID, 403, "missing permission: "+perm)
+		return false
+	}
+	return true
+}
+
 func (h *Handler) getUserID(c *gin.Context) uint {
 	if userID, exists := c.Get("userID"); exists {
 		if id, ok := userID.(uint); ok {
@@ -536,6 +1494,38 @@ func (h *Handler) getUserID(c *gin.Context) uint {
 	return 0
 }
 
+func (h *Handler) isAdmin(c *gin.Context) bool {
+	if role, exists := c.Get("role"); exists {
+		if roleStr, ok := role.(string); ok {
+			return roleStr == "admin"
+		}
+	}
+	return false
+}
+
+// authorizeTargetPlugin 判断请求能否操作 target 插件并返回实际操作的插件ID：管理员可操作任意插件；
+// 其他调用方须以凭据认证，只能操作自己，target 为空时取调用方自身
+func (h *Handler) authorizeTargetPlugin(c *gin.Context, req RPCRequest, target string) (string, bool) {
+	if h.isAdmin(c) {
+		return target, true
+	}
+	if !req.authenticated || req.PluginID == "" || (target != "" && target != req.PluginID) {
+		return "", false
+	}
+	return req.PluginID, true
+}
+
+// actor 返回审计日志中的操作者标识
+func (h *Handler) actor(c *gin.Context, pluginID string) string {
+	if userID := h.getUserID(c); userID != 0 {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	if pluginID != "" {
+		return "plugin:" + pluginID
+	}
+	return "remote:" + c.ClientIP()
+}
+
 func (h *Handler) parseParams(params interface{}, target interface{}) error {
 	if params == nil {
 		return fmt.Errorf("params is nil")
@@ -551,11 +1541,43 @@ func (h *Handler) parseParams(params interface{}, target interface{}) error {
 	return json.Unmarshal(jsonBytes, target)
 }
 
+// validPluginSort 判断排序字段是否受支持，空表示默认排序
+func validPluginSort(sort string) bool {
+	return sort == "" || sort == PluginSortID || sort == PluginSortName
+}
+
 func (h *Handler) writeRPCResult(c *gin.Context, id string, result interface{}) {
 	c.JSON(http.StatusOK, RPCResponse{ID: id, Result: result})
 }
 
+// kvErrorCode 把键值存储错误映射为 RPC 错误码
+func kvErrorCode(err error) int {
+	switch {
+	case errors.Is(err, ErrKVKeyNotFound):
+		return 404
+	case errors.Is(err, ErrKVQuotaExceeded):
+		return 413
+	case errors.Is(err, ErrInvalidKVKey):
+		return 400
+	}
+	return 500
+}
+
+// vaultErrorCode 把存储库读取错误映射为 RPC 错误码：文件不存在为 404，路径是目录为 400
+func vaultErrorCode(err error) int {
+	switch {
+	case errors.Is(err, ErrVaultFileNotFound):
+		return 404
+	case errors.Is(err, ErrVaultIsDir):
+		return 400
+	}
+	return 500
+}
+
 func (h *Handler) writeRPCError(c *gin.Context, id string, code int, message string) {
+	if code == 500 && errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		code, message = 504, "request timed out"
+	}
 	httpStatus := h.httpStatusForCode(code)
 	c.JSON(httpStatus, RPCResponse{
 		ID: id,
@@ -576,6 +1598,16 @@ func (h *Handler) httpStatusForCode(code int) int {
 		return http.StatusForbidden
 	case 404:
 		return http.StatusNotFound
+	case 409:
+		return http.StatusConflict
+	case 413:
+		return http.StatusRequestEntityTooLarge
+	case 429:
+		return http.StatusTooManyRequests
+	case 503:
+		return http.StatusServiceUnavailable
+	case 504:
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
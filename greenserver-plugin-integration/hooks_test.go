@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// installUntilTerminal 安装插件并返回终态事件（done 或 failed）
+func installUntilTerminal(t *testing.T, s *ServiceImpl, req *PluginInstallRequest) *EventData {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+	if err := s.InstallPlugin(req); err != nil {
+		t.Fatalf("InstallPlugin: %v", err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == "plugin.installation.done" || ev.Type == "plugin.installation.failed" {
+				return ev
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the installation to finish")
+		}
+	}
+}
+
+func TestPreInstallHookVetoesInstall(t *testing.T) {
+	repo := NewInMemoryRepository()
+	pluginsDir := t.TempDir()
+	var seen map[string]interface{}
+	s := NewServiceWithOptions(repo, pluginsDir, t.TempDir(), "", ServiceOptions{
+		PreInstall: func(pluginID string, manifest map[string]interface{}) error {
+			seen = manifest
+			return errors.New("not on the approved list")
+		},
+	}).(*ServiceImpl)
+	url := servePluginZip(t, `{"id":"demo","name":"Demo","version":"1.0.0"}`)
+
+	ev := installUntilTerminal(t, s, &PluginInstallRequest{ID: "demo", URL: url})
+	if ev.Type != "plugin.installation.failed" {
+		t.Fatalf("terminal event = %s, want plugin.installation.failed", ev.Type)
+	}
+	if p, ok := ev.Data.(InstallProgress); !ok || !strings.Contains(p.Error, "not on the approved list") {
+		t.Errorf("failure = %+v", ev.Data)
+	}
+	if seen["id"] != "demo" || seen["version"] != "1.0.0" {
+		t.Errorf("pre-install hook saw %v", seen)
+	}
+	if _, err := repo.GetPluginByID("demo"); err == nil {
+		t.Error("vetoed plugin was registered")
+	}
+	if _, err := os.Stat(filepath.Join(pluginsDir, "demo")); !os.IsNotExist(err) {
+		t.Errorf("vetoed plugin was extracted: %v", err)
+	}
+}
+
+func TestInstallAndUninstallHooksObserveManifest(t *testing.T) {
+	repo := NewInMemoryRepository()
+	var installed, uninstalled []map[string]interface{}
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{
+		OnInstall: func(pluginID string, manifest map[string]interface{}) error {
+			installed = append(installed, manifest)
+			// 安装后回调的错误只记录日志
+			return errors.New("indexer offline")
+		},
+		OnUninstall: func(pluginID string, manifest map[string]interface{}) error {
+			uninstalled = append(uninstalled, manifest)
+			return nil
+		},
+	}).(*ServiceImpl)
+	url := servePluginZip(t, `{"id":"demo","name":"Demo","version":"1.2.0"}`)
+
+	if ev := installUntilTerminal(t, s, &PluginInstallRequest{ID: "demo", URL: url}); ev.Type != "plugin.installation.done" {
+		t.Fatalf("install hook error failed the install: %+v", ev.Data)
+	}
+	if len(installed) != 1 || installed[0]["id"] != "demo" || installed[0]["version"] != "1.2.0" {
+		t.Fatalf("install hook saw %v", installed)
+	}
+
+	if err := s.UninstallPlugin("demo"); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	if len(uninstalled) != 1 || uninstalled[0]["id"] != "demo" || uninstalled[0]["version"] != "1.2.0" {
+		t.Errorf("uninstall hook saw %v", uninstalled)
+	}
+}
@@ -0,0 +1,205 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultLanguage 请求未指定可用语言时使用的语言，与本服务其余中文响应保持一致
+const defaultLanguage = "zh"
+
+// messageCatalog 按错误码和语言索引的校验消息模板，错误码与独立宿主的消息目录一致，
+// 模板参数与 ValidationError.Args 对应
+var messageCatalog = map[string]map[string]string{
+	"EMPTY_ID": {
+		"en": "plugin ID must not be empty",
+		"zh": "插件ID不能为空",
+	},
+	"INVALID_ID_FORMAT": {
+		"en": "plugin ID may only contain letters, digits, hyphens and underscores",
+		"zh": "插件ID只能包含字母、数字、连字符和下划线",
+	},
+	"ID_TOO_LONG": {
+		"en": "plugin ID must not exceed 50 characters",
+		"zh": "插件ID长度不能超过50个字符",
+	},
+	"PLUGIN_NOT_ALLOWED": {
+		"en": "plugin %s is not allowed to be installed",
+		"zh": "插件 %s 不允许安装",
+	},
+	"EMPTY_URL": {
+		"en": "download URL must not be empty",
+		"zh": "下载URL不能为空",
+	},
+	"INVALID_URL": {
+		"en": "invalid URL",
+		"zh": "无效的URL格式",
+	},
+	"INSECURE_PROTOCOL": {
+		"en": "only HTTP or HTTPS download URLs are allowed",
+		"zh": "只允许HTTP或HTTPS协议的下载链接",
+	},
+	"INVALID_HASH_FORMAT": {
+		"en": "invalid SHA256 hash format",
+		"zh": "无效的SHA256哈希格式",
+	},
+	"INTEGRITY_REQUIRED": {
+		"en": "a SHA256 checksum is required for installs from this source",
+		"zh": "该来源的安装必须提供SHA256校验和",
+	},
+	"INVALID_SOURCE": {
+		"en": "unsupported install source",
+		"zh": "不支持的安装来源",
+	},
+	"GIT_RESOLVE_FAILED": {
+		"en": "the git release could not be resolved to a download URL: %v",
+		"zh": "无法从 Git 发布版本解析出下载地址: %v",
+	},
+}
+
+// localize 返回错误码在指定语言下的消息，缺少该语言时回退到 defaultLanguage，未收录的错误码返回空串
+func localize(code, lang string, args ...any) string {
+	msgs, ok := messageCatalog[code]
+	if !ok {
+		return ""
+	}
+	tmpl, ok := msgs[lang]
+	if !ok {
+		tmpl = msgs[defaultLanguage]
+	}
+	if len(args) == 0 {
+		return tmpl
+	}
+	return fmt.Sprintf(tmpl, args...)
+}
+
+// negotiateLanguage 从 Accept-Language 中选出第一个有翻译的语言，按主语言标签匹配（zh-CN 匹配 zh）
+func negotiateLanguage(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(tag, "-")
+		primary = strings.ToLower(primary)
+		if primary == "en" || primary == "zh" {
+			return primary
+		}
+	}
+	return defaultLanguage
+}
+
+// newValidationError 按错误码生成默认语言的校验错误，保留参数以便按请求语言重新生成消息
+func newValidationError(field, code string, args ...any) ValidationError {
+	return ValidationError{Field: field, Code: code, Message: localize(code, defaultLanguage, args...), Args: args}
+}
+
+// Localized 返回消息按指定语言重新生成的副本，未收录的错误码保留原消息
+func (e ValidationError) Localized(lang string) ValidationError {
+	if msg := localize(e.Code, lang, e.Args...); msg != "" {
+		e.Message = msg
+	}
+	return e
+}
+
+// localizeErrors 按语言生成一组校验错误的本地化副本
+func localizeErrors(errs []ValidationError, lang string) []ValidationError {
+	out := make([]ValidationError, len(errs))
+	for i, e := range errs {
+		out[i] = e.Localized(lang)
+	}
+	return out
+}
+
+// localeTagPattern 清单本地化文本接受的语言标签：主语言加可选的地区、文字等子标签，如 zh、zh-Hant-TW
+var localeTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// PluginI18n 清单中按语言标签提供的名称和描述，与独立宿主的 i18n 字段一致
+type PluginI18n struct {
+	Name        map[string]string `json:"name,omitempty"`
+	Description map[string]string `json:"description,omitempty"`
+}
+
+// manifestI18n 读取清单的 i18n 字段并校验语言标签，返回保存到插件记录的 JSON，未声明时返回空串
+func manifestI18n(manifest map[string]interface{}) (string, error) {
+	raw, ok := manifest["i18n"]
+	if !ok || raw == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return "", err
+	}
+	var i18n PluginI18n
+	if err := json.Unmarshal(data, &i18n); err != nil {
+		return "", fmt.Errorf("invalid i18n: %w", err)
+	}
+	for _, values := range []map[string]string{i18n.Name, i18n.Description} {
+		for key := range values {
+			if !localeTagPattern.MatchString(key) {
+				return "", fmt.Errorf("invalid i18n: %q is not a valid language tag", key)
+			}
+		}
+	}
+	if len(i18n.Name) == 0 && len(i18n.Description) == 0 {
+		return "", nil
+	}
+	data, err = json.Marshal(i18n)
+	return string(data), err
+}
+
+// parsePluginI18n 解析插件记录中保存的本地化文本，为空或损坏时返回 nil
+func parsePluginI18n(data string) *PluginI18n {
+	if data == "" {
+		return nil
+	}
+	var i18n PluginI18n
+	if err := json.Unmarshal([]byte(data), &i18n); err != nil {
+		return nil
+	}
+	return &i18n
+}
+
+// localizedText 按 Accept-Language 中的顺序选出 values 里最匹配的文本：先找完全相同的标签，
+// 再按主语言匹配（请求 zh-CN 时可用 zh，请求 zh 时可用 zh-TW），都没有时返回 base
+func localizedText(base string, values map[string]string, acceptLanguage string) string {
+	if len(values) == 0 {
+		return base
+	}
+	// 同一主语言有多个键时按键排序取第一个，结果不随 map 遍历顺序变化
+	keys := make([]string, 0, len(values))
+	for key, text := range values {
+		if text != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		for _, key := range keys {
+			if strings.EqualFold(key, tag) {
+				return values[key]
+			}
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		for _, key := range keys {
+			keyPrimary, _, _ := strings.Cut(key, "-")
+			if strings.EqualFold(keyPrimary, primary) {
+				return values[key]
+			}
+		}
+	}
+	return base
+}
+
+// localizePlugin 按 Accept-Language 替换响应中的名称和描述，原始的本地化文本保留在 I18n 中
+func localizePlugin(p *PluginResponse, acceptLanguage string) {
+	if p == nil || p.I18n == nil || acceptLanguage == "" {
+		return
+	}
+	p.Name = localizedText(p.Name, p.I18n.Name, acceptLanguage)
+	p.Description = localizedText(p.Description, p.I18n.Description, acceptLanguage)
+}
@@ -0,0 +1,189 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInstallValidationLocalized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{service: &ServiceImpl{}}
+
+	cases := map[string]string{
+		"en-US,en;q=0.9": "plugin ID must not be empty",
+		"zh-CN":          "插件ID不能为空",
+		"":               "插件ID不能为空",
+		"fr":             "插件ID不能为空",
+	}
+	for lang, want := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/plugins/install", strings.NewReader(`{"url":"https://example.com/p.zip"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if lang != "" {
+			c.Request.Header.Set("Accept-Language", lang)
+		}
+		h.InstallPlugin(c)
+
+		if w.Code != 400 {
+			t.Fatalf("Accept-Language %q: status = %d, want 400", lang, w.Code)
+		}
+		var resp ValidationErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Errors) != 1 || resp.Errors[0].Code != "EMPTY_ID" || resp.Errors[0].Message != want {
+			t.Errorf("Accept-Language %q: errors = %+v, want EMPTY_ID %q", lang, resp.Errors, want)
+		}
+	}
+}
+
+func TestValidationErrorLocalizedArgs(t *testing.T) {
+	e := newValidationError("id", "PLUGIN_NOT_ALLOWED", "evil")
+	if e.Message != "插件 evil 不允许安装" {
+		t.Errorf("default message = %q", e.Message)
+	}
+	if got := e.Localized("en").Message; got != "plugin evil is not allowed to be installed" {
+		t.Errorf("en message = %q", got)
+	}
+	unknown := ValidationError{Code: "UNKNOWN", Message: "原消息"}
+	if got := unknown.Localized("en").Message; got != "原消息" {
+		t.Errorf("unknown code message = %q, want original", got)
+	}
+}
+
+func TestLocalizedText(t *testing.T) {
+	values := map[string]string{"zh": "演示", "zh-TW": "演示繁體", "ja": "デモ", "fr": ""}
+	cases := map[string]string{
+		"zh":                  "演示",
+		"zh-TW":               "演示繁體",
+		"ZH-tw":               "演示繁體",
+		"zh-CN,zh;q=0.9":      "演示",
+		"de, ja;q=0.8":        "デモ",
+		"ja-JP":               "デモ",
+		"fr":                  "Demo",
+		"de,*":                "Demo",
+		"":                    "Demo",
+		"ko;q=0.9, zh-HK":     "演示",
+		"pt-BR,en-US;q=0.9,*": "Demo",
+	}
+	for lang, want := range cases {
+		if got := localizedText("Demo", values, lang); got != want {
+			t.Errorf("Accept-Language %q: got %q, want %q", lang, got, want)
+		}
+	}
+	// 同一主语言有多个键时按键排序选择
+	if got := localizedText("Demo", map[string]string{"zh-TW": "繁體", "zh-CN": "简体"}, "zh"); got != "简体" {
+		t.Errorf("primary language match = %q, want 简体", got)
+	}
+}
+
+func TestManifestI18n(t *testing.T) {
+	data, err := manifestI18n(map[string]interface{}{"i18n": map[string]interface{}{
+		"name": map[string]interface{}{"zh": "演示"}, "description": map[string]interface{}{"zh-Hant-TW": "說明"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i18n := parsePluginI18n(data)
+	if i18n == nil || i18n.Name["zh"] != "演示" || i18n.Description["zh-Hant-TW"] != "說明" {
+		t.Fatalf("i18n = %q", data)
+	}
+	for _, manifest := range []map[string]interface{}{{}, {"i18n": nil}, {"i18n": map[string]interface{}{}}} {
+		if data, err := manifestI18n(manifest); err != nil || data != "" {
+			t.Errorf("manifest %v: %q, %v", manifest, data, err)
+		}
+	}
+	for _, key := range []string{"chinese", "z", "zh_CN", "zh-", ""} {
+		manifest := map[string]interface{}{"i18n": map[string]interface{}{"name": map[string]interface{}{key: "x"}}}
+		if _, err := manifestI18n(manifest); err == nil {
+			t.Errorf("locale key %q accepted", key)
+		}
+	}
+	if _, err := manifestI18n(map[string]interface{}{"i18n": map[string]interface{}{"name": "演示"}}); err == nil {
+		t.Error("non-map i18n.name accepted")
+	}
+	if parsePluginI18n("") != nil || parsePluginI18n("{") != nil {
+		t.Error("empty or corrupt i18n should parse to nil")
+	}
+}
+
+// loadI18nPlugin 从带有本地化文本的清单加载插件
+func loadI18nPlugin(t *testing.T, s *ServiceImpl, manifest string) error {
+	t.Helper()
+	dir := filepath.Join(s.pluginsDir, "demo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return s.loadPluginFromManifest(path, true)
+}
+
+func TestGetPluginsLocalized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	if err := loadI18nPlugin(t, s, `{"id":"demo","name":"Demo","version":"1.0.0","description":"A demo",
+		"i18n":{"name":{"zh":"演示","ja":"デモ"},"description":{"zh":"演示插件"}}}`); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{service: s}
+
+	for lang, want := range map[string][2]string{
+		"zh-CN":       {"演示", "演示插件"},
+		"ja":          {"デモ", "A demo"},
+		"fr,en;q=0.5": {"Demo", "A demo"},
+		"":            {"Demo", "A demo"},
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/rpc", strings.NewReader(`{"id":"1","method":"host.getPlugins"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if lang != "" {
+			c.Request.Header.Set("Accept-Language", lang)
+		}
+		h.HandleRPC(c)
+		var resp RPCResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error != nil {
+			t.Fatalf("Accept-Language %q: %s, %v", lang, w.Body.String(), err)
+		}
+		if got := rpcResultField(t, resp.Result, "name"); got != want[0] {
+			t.Errorf("Accept-Language %q: name = %q, want %q", lang, got, want[0])
+		}
+		if got := rpcResultField(t, resp.Result, "description"); got != want[1] {
+			t.Errorf("Accept-Language %q: description = %q, want %q", lang, got, want[1])
+		}
+	}
+
+	// 存储的记录保留原始名称，本地化文本随响应返回
+	p, err := s.GetPlugin("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Demo" || p.I18n == nil || p.I18n.Name["ja"] != "デモ" {
+		t.Fatalf("plugin = %+v", p)
+	}
+	localizePlugin(p, "ja")
+	if p.Name != "デモ" || p.Description != "A demo" {
+		t.Errorf("localized plugin = %q %q", p.Name, p.Description)
+	}
+}
+
+func TestLoadPluginRejectsInvalidLocale(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	err := loadI18nPlugin(t, s, `{"id":"demo","name":"Demo","version":"1.0.0","i18n":{"name":{"zh_CN":"演示"}}}`)
+	if err == nil || !strings.Contains(err.Error(), "zh_CN") {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := s.GetPlugin("demo"); err == nil {
+		t.Error("plugin with an invalid locale key was registered")
+	}
+}
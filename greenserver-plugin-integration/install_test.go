@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// failingPermissionRepo 授予指定权限时返回错误，事务内的存储库同样包装
+type failingPermissionRepo struct {
+	Repository
+	perm string
+}
+
+func (r *failingPermissionRepo) Transaction(fn func(repo Repository) error) error {
+	return r.Repository.Transaction(func(repo Repository) error {
+		return fn(&failingPermissionRepo{Repository: repo, perm: r.perm})
+	})
+}
+
+func (r *failingPermissionRepo) AddPluginPermission(pluginID, permissionName string) error {
+	if permissionName == r.perm {
+		return errors.New("permission write failed")
+	}
+	return r.Repository.AddPluginPermission(pluginID, permissionName)
+}
+
+func TestLoadPluginFromManifestRollsBackOnFailure(t *testing.T) {
+	repo := &failingPermissionRepo{Repository: NewInMemoryRepository(), perm: "events.publish"}
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	manifest := `{"id":"demo","name":"Demo","version":"1.0.0",
+		"permissions":["vault.read","events.publish"],
+		"commands":[{"id":"demo.run","title":"Run"}]}`
+	if err := os.WriteFile(manifestPath, []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.loadPluginFromManifest(manifestPath, true); err == nil {
+		t.Fatal("expected the permission failure to abort the install")
+	}
+	if _, err := repo.GetPluginByID("demo"); err == nil {
+		t.Error("plugin record should be rolled back")
+	}
+	if perms, _ := repo.GetPluginPermissions("demo"); len(perms) != 0 {
+		t.Errorf("permissions should be rolled back, got %v", perms)
+	}
+	if cmds, _ := repo.GetCommandsByPluginID("demo"); len(cmds) != 0 {
+		t.Errorf("manifest commands should be rolled back, got %d", len(cmds))
+	}
+}
+
+func TestInstallPluginRejectsDuplicateInProgress(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	running := &PluginInstallation{PluginID: "demo", Status: "downloading"}
+	s.installations["demo"] = running
+
+	err := s.InstallPlugin(&PluginInstallRequest{ID: "demo", URL: "https://example.com/demo.zip"})
+	if !errors.Is(err, ErrInstallInProgress) {
+		t.Fatalf("InstallPlugin err = %v, want ErrInstallInProgress", err)
+	}
+	if s.installations["demo"] != running {
+		t.Error("in-progress installation was replaced")
+	}
+	if _, err := repo.GetInstallationByPluginID("demo"); err == nil {
+		t.Error("rejected install should not write an installation record")
+	}
+}
+
+func TestRetryInstallationRejectsInProgress(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	failed := &PluginInstallation{PluginID: "demo", Status: "failed", SourceURL: "https://example.com/demo.zip"}
+	if err := repo.CreateInstallation(failed); err != nil {
+		t.Fatal(err)
+	}
+	s.installations["demo"] = &PluginInstallation{PluginID: "demo", Status: "installing"}
+
+	if _, err := s.RetryInstallation("demo"); !errors.Is(err, ErrInstallInProgress) {
+		t.Fatalf("RetryInstallation err = %v, want ErrInstallInProgress", err)
+	}
+	if got, _ := repo.GetInstallationByPluginID("demo"); got.Attempts != 0 || got.Status != "failed" {
+		t.Errorf("installation record changed: %+v", got)
+	}
+}
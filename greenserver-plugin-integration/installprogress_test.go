@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestInstallProgressJSONShape(t *testing.T) {
+	// 与独立宿主的测试使用相同的期望，保证两端序列化结果一致
+	cases := []struct {
+		progress InstallProgress
+		want     string
+	}{
+		{
+			InstallProgress{PluginID: "demo", Status: "downloading", Progress: 10, Phase: "downloading"},
+			`{"pluginId":"demo","status":"downloading","progress":10,"phase":"downloading","terminal":false}`,
+		},
+		{
+			InstallProgress{PluginID: "demo", Status: "failed", Progress: 30, Message: "bad checksum", Phase: "verifying", Code: "X", Error: "mismatch", Terminal: true},
+			`{"pluginId":"demo","status":"failed","progress":30,"message":"bad checksum","phase":"verifying","code":"X","error":"mismatch","terminal":true}`,
+		},
+		{
+			InstallProgress{PluginID: "demo", Status: "downloading", Progress: 50, BytesDownloaded: 512, TotalBytes: 1024, BytesPerSecond: 256, ETASeconds: 2},
+			`{"pluginId":"demo","status":"downloading","progress":50,"bytesDownloaded":512,"totalBytes":1024,"bytesPerSecond":256,"etaSeconds":2,"terminal":false}`,
+		},
+		{
+			InstallProgress{PluginID: "demo", Status: "completed", Progress: 100, Terminal: true, RequiresApproval: true},
+			`{"pluginId":"demo","status":"completed","progress":100,"terminal":true,"requiresApproval":true}`,
+		},
+	}
+	for _, tc := range cases {
+		data, err := json.Marshal(tc.progress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.want {
+			t.Errorf("json = %s\nwant   %s", data, tc.want)
+		}
+	}
+}
+
+func TestInstallEventsCarryInstallProgress(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+	url := servePluginZip(t, `{"id":"demo","name":"Demo","version":"1.0.0"}`)
+	if err := s.InstallPlugin(&PluginInstallRequest{ID: "demo", URL: url}); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress int
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Type {
+			case "plugin.installation.progress", "plugin.installation.failed", "plugin.installation.done":
+			default:
+				continue
+			}
+			p, ok := ev.Data.(InstallProgress)
+			if !ok {
+				t.Fatalf("%s data = %T, want InstallProgress", ev.Type, ev.Data)
+			}
+			if p.PluginID != "demo" || p.Status == "" {
+				t.Errorf("%s data = %+v", ev.Type, p)
+			}
+			if ev.Type == "plugin.installation.progress" {
+				progress++
+				continue
+			}
+			if ev.Type != "plugin.installation.done" || !p.Terminal || p.Progress != 100 {
+				t.Errorf("terminal event = %s %+v, want a completed install", ev.Type, p)
+			}
+			if progress == 0 {
+				t.Error("no plugin.installation.progress events before the terminal event")
+			}
+			return
+		case <-timeout:
+			t.Fatal("timed out waiting for the installation to finish")
+		}
+	}
+}
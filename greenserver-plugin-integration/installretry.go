@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DefaultMaxInstallRetries 未配置 MaxInstallRetries 时一次安装最多重试的次数
+const DefaultMaxInstallRetries = 3
+
+var (
+	// ErrInstallationNotFound 插件没有安装记录
+	ErrInstallationNotFound = errors.New("installation not found")
+	// ErrInstallationNotRetryable 安装记录不处于失败状态，不能重试
+	ErrInstallationNotRetryable = errors.New("installation is not in failed state")
+	// ErrInstallationDead 安装重试次数已用尽
+	ErrInstallationDead = errors.New("installation retries exhausted")
+)
+
+// maxInstallRetries 返回生效的重试次数上限
+func (s *ServiceImpl) maxInstallRetries() int {
+	if s.options.MaxInstallRetries > 0 {
+		return s.options.MaxInstallRetries
+	}
+	return DefaultMaxInstallRetries
+}
+
+// RetryInstallation 以安装记录中保存的下载地址和校验和重新执行失败的安装，广播
+// plugin.installation.retry。本次重试仍失败且次数达到 MaxInstallRetries 时记录转为 dead，
+// 不能再重试。安装后是否启用按服务配置决定
+func (s *ServiceImpl) RetryInstallation(pluginID string) (*InstallationStatusResponse, error) {
+	installation, err := s.repo.GetInstallationByPluginID(pluginID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInstallationNotFound
+		}
+		return nil, err
+	}
+	switch installation.Status {
+	case "failed":
+	case "dead":
+		return nil, fmt.Errorf("%w: %s", ErrInstallationDead, pluginID)
+	default:
+		return nil, fmt.Errorf("%w: %s is %s", ErrInstallationNotRetryable, pluginID, installation.Status)
+	}
+
+	req := &PluginInstallRequest{ID: pluginID, URL: installation.SourceURL, SHA256: installation.SHA256}
+	if err := s.validateInstallRequest(req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPluginLimit(pluginID); err != nil {
+		return nil, err
+	}
+
+	s.installMutex.Lock()
+	if _, ok := s.installations[pluginID]; ok {
+		s.installMutex.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrInstallInProgress, pluginID)
+	}
+	installation.Attempts++
+	installation.Status = "installing"
+	installation.Progress = 0
+	installation.Message = "重新开始下载插件"
+	s.installations[pluginID] = installation
+	s.installMutex.Unlock()
+	if err := s.repo.UpdateInstallation(installation); err != nil {
+		return nil, err
+	}
+
+	s.Broadcast(&EventData{
+		Type: "plugin.installation.retry",
+		Data: map[string]interface{}{
+			"pluginId":   pluginID,
+			"attempt":    installation.Attempts,
+			"maxRetries": s.maxInstallRetries(),
+		},
+	})
+	// 安装协程会修改记录，先取出本次重试的状态再启动
+	status := &InstallationStatusResponse{
+		PluginID: installation.PluginID,
+		Status:   installation.Status,
+		Progress: installation.Progress,
+		Message:  installation.Message,
+		Attempts: installation.Attempts,
+	}
+	go s.performInstallation(req)
+
+	return status, nil
+}
@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyPluginServer 在 ok 为 false 时返回 500，否则返回插件包
+func flakyPluginServer(t *testing.T, ok *atomic.Bool) string {
+	t.Helper()
+	zipPath := writeTestZip(t, t.TempDir(), []string{"manifest.json"}, [][]byte{[]byte(`{"id":"demo","name":"Demo","version":"1.0.0"}`)})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ok.Load() {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		http.ServeFile(w, r, zipPath)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/plugin.zip"
+}
+
+// retryUntilTerminal 重试安装并收集事件，直到安装成功、失败或转为 dead
+func retryUntilTerminal(t *testing.T, s *ServiceImpl, pluginID string) []*EventData {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+	if _, err := s.RetryInstallation(pluginID); err != nil {
+		t.Fatalf("RetryInstallation: %v", err)
+	}
+	var got []*EventData
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			got = append(got, ev)
+			if ev.Type == "plugin.installation.done" || ev.Type == "plugin.installation.failed" {
+				// dead 事件紧随 failed 发出
+				select {
+				case next := <-events:
+					got = append(got, next)
+				case <-time.After(100 * time.Millisecond):
+				}
+				return got
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the retry to finish")
+		}
+	}
+}
+
+// eventOfType 返回第一个指定类型的事件
+func eventOfType(events []*EventData, typ string) *EventData {
+	for _, ev := range events {
+		if ev.Type == typ {
+			return ev
+		}
+	}
+	return nil
+}
+
+func TestRetryInstallationSucceeds(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	var ok atomic.Bool
+	url := flakyPluginServer(t, &ok)
+
+	if ev := installUntilTerminal(t, s, &PluginInstallRequest{ID: "demo", URL: url}); ev.Type != "plugin.installation.failed" {
+		t.Fatalf("first install: %+v", ev)
+	}
+	ok.Store(true)
+	events := retryUntilTerminal(t, s, "demo")
+
+	retry := eventOfType(events, "plugin.installation.retry")
+	if retry == nil {
+		t.Fatalf("no retry event: %+v", events)
+	}
+	if data := retry.Data.(map[string]interface{}); data["attempt"] != 1 || data["maxRetries"] != DefaultMaxInstallRetries {
+		t.Errorf("retry event = %+v", data)
+	}
+	if eventOfType(events, "plugin.installation.done") == nil {
+		t.Fatalf("retry did not complete: %+v", events)
+	}
+	status, err := s.GetInstallationStatus("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != "installed" || status.Attempts != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	if _, err := repo.GetPluginByID("demo"); err != nil {
+		t.Errorf("plugin not registered: %v", err)
+	}
+	// 安装成功后不能再重试
+	if _, err := s.RetryInstallation("demo"); !errors.Is(err, ErrInstallationNotRetryable) {
+		t.Errorf("retry after success: %v", err)
+	}
+}
+
+func TestRetryInstallationExhaustsRetries(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{MaxInstallRetries: 2}).(*ServiceImpl)
+	var ok atomic.Bool
+	url := flakyPluginServer(t, &ok)
+	installUntilTerminal(t, s, &PluginInstallRequest{ID: "demo", URL: url})
+
+	events := retryUntilTerminal(t, s, "demo")
+	if eventOfType(events, "plugin.installation.dead") != nil {
+		t.Fatalf("dead after the first retry: %+v", events)
+	}
+	if status, _ := s.GetInstallationStatus("demo"); status.Status != "failed" || status.Attempts != 1 {
+		t.Fatalf("status after one retry = %+v", status)
+	}
+
+	events = retryUntilTerminal(t, s, "demo")
+	if eventOfType(events, "plugin.installation.failed") == nil || eventOfType(events, "plugin.installation.dead") == nil {
+		t.Fatalf("last retry events = %+v", events)
+	}
+	status, err := s.GetInstallationStatus("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != "dead" || status.Attempts != 2 {
+		t.Errorf("status = %+v, want dead after 2 attempts", status)
+	}
+	// 转为 dead 后即使下载恢复也不再重试
+	ok.Store(true)
+	if _, err := s.RetryInstallation("demo"); !errors.Is(err, ErrInstallationDead) {
+		t.Errorf("retry of a dead installation: %v", err)
+	}
+}
+
+func TestRetryInstallationRPC(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{MaxInstallRetries: 1}).(*ServiceImpl)
+	h := &Handler{service: s}
+	var ok atomic.Bool
+	installUntilTerminal(t, s, &PluginInstallRequest{ID: "demo", URL: flakyPluginServer(t, &ok)})
+
+	if code, _ := callTestRPC(t, h, "", "host.retryInstallation", map[string]string{}); code != 400 {
+		t.Errorf("missing pluginId: got %d, want 400", code)
+	}
+	if code, _ := callTestRPC(t, h, "", "host.retryInstallation", map[string]string{"pluginId": "missing"}); code != 404 {
+		t.Errorf("unknown installation: got %d, want 404", code)
+	}
+	retryUntilTerminal(t, s, "demo")
+	if code, resp := callTestRPC(t, h, "", "host.retryInstallation", map[string]string{"pluginId": "demo"}); code != 409 {
+		t.Errorf("dead installation: got %d %+v, want 409", code, resp.Error)
+	}
+}
@@ -0,0 +1,33 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInstallRequiresSHA256ForMarketSource(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{RequireMarketSHA256: true}).(*ServiceImpl)
+
+	// 市场来源缺少哈希时拒绝安装
+	err := s.InstallPlugin(&PluginInstallRequest{ID: "demo", URL: "https://github.com/acme/demo/releases/download/v1.0.0/plugin.zip"})
+	var vf *ValidationFailedError
+	if !errors.As(err, &vf) || len(vf.Errors) != 1 || vf.Errors[0].Field != "sha256" || vf.Errors[0].Code != "INTEGRITY_REQUIRED" {
+		t.Fatalf("market install without sha256: err = %v, want INTEGRITY_REQUIRED", err)
+	}
+	if _, exists := s.getInstallation("demo"); exists {
+		t.Error("rejected install left an installation record")
+	}
+
+	// 本地开发安装不要求哈希
+	for _, url := range []string{"http://localhost:8080/plugin.zip", "http://127.0.0.1:8080/plugin.zip", "http://[::1]:8080/plugin.zip"} {
+		if err := s.validateInstallRequest(&PluginInstallRequest{ID: "local", URL: url}); err != nil {
+			t.Errorf("local install %s without sha256: %v", url, err)
+		}
+	}
+
+	// 未开启要求时市场来源也可以不提供哈希
+	relaxed := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	if err := relaxed.validateInstallRequest(&PluginInstallRequest{ID: "demo", URL: "https://github.com/acme/demo/plugin.zip"}); err != nil {
+		t.Errorf("install without sha256 when not required: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	k := newKeyedMutex()
+	unlockA := k.Lock("a")
+
+	// 不同键互不阻塞
+	done := make(chan struct{})
+	go func() {
+		k.Lock("b")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lock on another key blocked")
+	}
+
+	// 相同键须等待释放
+	acquired := make(chan struct{})
+	go func() {
+		unlock := k.Lock("a")
+		close(acquired)
+		unlock()
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second lock on the same key acquired while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second lock not acquired after release")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.locks) != 0 {
+		t.Errorf("unused locks not reclaimed: %d left", len(k.locks))
+	}
+}
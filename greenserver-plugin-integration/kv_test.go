@@ -0,0 +1,99 @@
+package plugin
+
+import (
+	"testing"
+)
+
+// newKVTestHandler 创建带有指定插件和权限的处理器，perms 的键为插件ID
+func newKVTestHandler(t *testing.T, perms map[string][]string) (*Handler, *ServiceImpl) {
+	t.Helper()
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	for id, list := range perms {
+		createTestPlugins(t, repo, id)
+		for _, perm := range list {
+			if err := repo.AddPluginPermission(id, perm); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return &Handler{service: s}, s
+}
+
+func TestKVRejectsUnknownPlugin(t *testing.T) {
+	h, s := newKVTestHandler(t, nil)
+
+	for method, params := range map[string]interface{}{
+		"kv.get":    map[string]string{"key": "k"},
+		"kv.set":    map[string]interface{}{"key": "k", "value": 1},
+		"kv.delete": map[string]string{"key": "k"},
+		"kv.list":   map[string]string{},
+	} {
+		if code, resp := callTestRPC(t, h, "missing", method, params); code != 404 {
+			t.Errorf("%s for an unknown plugin: got %d %+v, want 404", method, code, resp.Error)
+		}
+	}
+	if code, _ := callTestRPC(t, h, "", "kv.get", map[string]string{"key": "k"}); code != 400 {
+		t.Errorf("kv.get without pluginId: got %d, want 400", code)
+	}
+	// 不存在的插件不会创建命名空间
+	if keys, err := s.KVList("missing", ""); err != nil || len(keys) != 0 {
+		t.Fatalf("keys for an unknown plugin = %v, %v", keys, err)
+	}
+}
+
+func TestKVRequiresPermission(t *testing.T) {
+	h, s := newKVTestHandler(t, map[string][]string{
+		"reader": {"kv.read"},
+		"writer": {"kv.write"},
+		"none":   nil,
+	})
+
+	for _, tc := range []struct {
+		pluginID, method string
+		params           interface{}
+		want             int
+	}{
+		{"none", "kv.get", map[string]string{"key": "k"}, 403},
+		{"none", "kv.list", nil, 403},
+		{"none", "kv.set", map[string]interface{}{"key": "k", "value": 1}, 403},
+		{"reader", "kv.set", map[string]interface{}{"key": "k", "value": 1}, 403},
+		{"reader", "kv.delete", map[string]string{"key": "k"}, 403},
+		{"reader", "kv.list", nil, 200},
+		{"writer", "kv.get", map[string]string{"key": "k"}, 403},
+		{"writer", "kv.list", nil, 403},
+		{"writer", "kv.set", map[string]interface{}{"key": "k", "value": 1}, 200},
+		{"writer", "kv.delete", map[string]string{"key": "k"}, 200},
+	} {
+		if code, resp := callTestRPC(t, h, tc.pluginID, tc.method, tc.params); code != tc.want {
+			t.Errorf("%s as %s: got %d %+v, want %d", tc.method, tc.pluginID, code, resp.Error, tc.want)
+		}
+	}
+	for _, id := range []string{"none", "reader"} {
+		if keys, _ := s.KVList(id, ""); len(keys) != 0 {
+			t.Errorf("%s wrote keys without kv.write: %v", id, keys)
+		}
+	}
+}
+
+func TestKVReadWriteOwnNamespace(t *testing.T) {
+	h, s := newKVTestHandler(t, map[string][]string{
+		"notes": {"kv.read", "kv.write"},
+		"other": {"kv.read", "kv.write"},
+	})
+
+	if code, resp := callTestRPC(t, h, "notes", "kv.set", map[string]interface{}{"key": "theme", "value": "dark"}); code != 200 {
+		t.Fatalf("kv.set: %d %+v", code, resp.Error)
+	}
+	code, resp := callTestRPC(t, h, "notes", "kv.get", map[string]string{"key": "theme"})
+	if result, _ := resp.Result.(map[string]interface{}); code != 200 || result["value"] != "dark" {
+		t.Fatalf("kv.get: %d %+v", code, resp)
+	}
+	// 其他插件看不到该键
+	if code, _ := callTestRPC(t, h, "other", "kv.get", map[string]string{"key": "theme"}); code != 404 {
+		t.Errorf("kv.get from another plugin: got %d, want 404", code)
+	}
+	if deleted, err := s.KVDelete("other", "theme"); err != nil || deleted {
+		t.Errorf("another plugin's delete = %v, %v", deleted, err)
+	}
+}
@@ -0,0 +1,52 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// labelPattern 标签形如 env:prod、team:docs，不含空白、逗号和斜杠，可以直接放在路径中
+var labelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._:-]{0,63}$`)
+
+// normalizeLabel 去掉首尾空白并转为小写，不合法时返回 ErrInvalidLabel
+func normalizeLabel(label string) (string, error) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if !labelPattern.MatchString(label) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+	}
+	return label, nil
+}
+
+// AddPluginLabel 为插件添加运维标签，标签已存在时不做任何事，插件未安装时返回 ErrPluginNotFound
+func (s *ServiceImpl) AddPluginLabel(pluginID, label string) error {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.AddLabel(pluginID, label); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPluginNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RemovePluginLabel 删除插件的运维标签，返回标签是否存在
+func (s *ServiceImpl) RemovePluginLabel(pluginID, label string) (bool, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return false, err
+	}
+	if _, err := s.repo.GetPluginByID(pluginID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrPluginNotFound
+		}
+		return false, err
+	}
+	return s.repo.RemoveLabel(pluginID, label)
+}
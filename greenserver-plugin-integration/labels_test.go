@@ -0,0 +1,237 @@
+package plugin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestNormalizeLabel(t *testing.T) {
+	valid := map[string]string{
+		"env:prod":                    "env:prod",
+		" Team:Docs ":                 "team:docs",
+		"a":                           "a",
+		"v1.2_beta-rc":                "v1.2_beta-rc",
+		"0" + strings.Repeat("x", 63): "0" + strings.Repeat("x", 63),
+	}
+	for in, want := range valid {
+		if got, err := normalizeLabel(in); err != nil || got != want {
+			t.Errorf("normalizeLabel(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "  ", "env prod", "a,b", "a/b", ":env", "-x", strings.Repeat("x", 65)} {
+		if _, err := normalizeLabel(in); !errors.Is(err, ErrInvalidLabel) {
+			t.Errorf("normalizeLabel(%q) error = %v, want ErrInvalidLabel", in, err)
+		}
+	}
+}
+
+// listPluginIDs 返回 GetAllPlugins 结果中的插件ID，以逗号连接
+func listPluginIDs(t *testing.T, s *ServiceImpl, query *PluginQuery) string {
+	t.Helper()
+	plugins, err := s.GetAllPlugins(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(plugins))
+	for i, p := range plugins {
+		ids[i] = p.PluginID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestPluginLabels(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "a", "b", "c")
+
+	for _, l := range []struct{ id, label string }{
+		{"a", "env:prod"}, {"b", "ENV:PROD"}, {"b", "team:docs"}, {"c", "env:dev"},
+		// 重复添加不报错也不产生第二条
+		{"a", " env:prod "},
+	} {
+		if err := s.AddPluginLabel(l.id, l.label); err != nil {
+			t.Fatalf("AddPluginLabel(%s, %s): %v", l.id, l.label, err)
+		}
+	}
+	if err := s.AddPluginLabel("missing", "env:prod"); !errors.Is(err, ErrPluginNotFound) {
+		t.Errorf("missing plugin: %v", err)
+	}
+	if err := s.AddPluginLabel("a", "bad label"); !errors.Is(err, ErrInvalidLabel) {
+		t.Errorf("invalid label: %v", err)
+	}
+
+	if got := listPluginIDs(t, s, &PluginQuery{Label: "env:prod"}); got != "a,b" {
+		t.Errorf("label env:prod = %q", got)
+	}
+	// 过滤条件与添加时一样规范化
+	if got := listPluginIDs(t, s, &PluginQuery{Label: " Team:Docs"}); got != "b" {
+		t.Errorf("label team:docs = %q", got)
+	}
+	enabled := true
+	if got := listPluginIDs(t, s, &PluginQuery{Label: "env:dev", Enabled: &enabled}); got != "c" {
+		t.Errorf("label with enabled = %q", got)
+	}
+	if got := listPluginIDs(t, s, &PluginQuery{Label: "none"}); got != "" {
+		t.Errorf("unknown label = %q", got)
+	}
+	if ids, err := repo.GetByLabel("env:prod"); err != nil || strings.Join(ids, ",") != "a,b" {
+		t.Errorf("GetByLabel = %v, %v", ids, err)
+	}
+
+	p, err := s.GetPlugin("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(p.Labels, ",") != "env:prod,team:docs" {
+		t.Errorf("labels of b = %v", p.Labels)
+	}
+	// 运维标签不影响清单中的 tags
+	if len(p.Tags) != 0 {
+		t.Errorf("tags of b = %v", p.Tags)
+	}
+
+	removed, err := s.RemovePluginLabel("b", "Env:Prod")
+	if err != nil || !removed {
+		t.Fatalf("RemovePluginLabel = %v, %v", removed, err)
+	}
+	if removed, err := s.RemovePluginLabel("b", "env:prod"); err != nil || removed {
+		t.Errorf("second remove = %v, %v", removed, err)
+	}
+	if _, err := s.RemovePluginLabel("missing", "env:prod"); !errors.Is(err, ErrPluginNotFound) {
+		t.Errorf("remove from missing plugin: %v", err)
+	}
+	if got := listPluginIDs(t, s, &PluginQuery{Label: "env:prod"}); got != "a" {
+		t.Errorf("after remove = %q", got)
+	}
+}
+
+func TestUninstallAndReconcileDropLabels(t *testing.T) {
+	repo := NewInMemoryRepository()
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(repo, pluginsDir, t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	setupInstalledPlugin(t, repo, pluginsDir, "a")
+	// stale 只在数据库中，对账时会被删除
+	createTestPlugins(t, repo, "stale")
+	for _, id := range []string{"a", "stale"} {
+		if err := s.AddPluginLabel(id, "env:prod"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.UninstallPlugin("a"); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := repo.GetByLabel("env:prod"); strings.Join(ids, ",") != "stale" {
+		t.Fatalf("labels after uninstall = %v", ids)
+	}
+	if _, err := s.ReconcilePlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := repo.GetByLabel("env:prod"); len(ids) != 0 {
+		t.Fatalf("labels after reconcile = %v", ids)
+	}
+	// 重新安装同名插件不会继承旧标签
+	createTestPlugins(t, repo, "stale")
+	if p, err := s.GetPlugin("stale"); err != nil || len(p.Labels) != 0 {
+		t.Fatalf("reinstalled plugin labels = %+v, %v", p, err)
+	}
+}
+
+func TestMemoryTransactionRollsBackLabels(t *testing.T) {
+	repo := NewInMemoryRepository()
+	createTestPlugins(t, repo, "a")
+	err := repo.Transaction(func(tx Repository) error {
+		if err := tx.AddLabel("a", "env:prod"); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	if err == nil {
+		t.Fatal("expected transaction error")
+	}
+	if ids, _ := repo.GetByLabel("env:prod"); len(ids) != 0 {
+		t.Fatalf("label kept after rollback: %v", ids)
+	}
+}
+
+func TestGetAllPluginsFiltersLabelInSQL(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRepository(db).GetAllPlugins(&PluginQuery{Label: "env:prod"}); err != nil {
+		t.Fatal(err)
+	}
+	// 标签过滤以子查询的形式落在插件查询的 WHERE 子句中
+	var found bool
+	for _, q := range queries {
+		if strings.Contains(q, "FROM `plugins`") && strings.Contains(q, "plugin_id IN (SELECT `plugin_id` FROM `plugin_labels` WHERE label = ") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("no plugin query filters by label: %q", queries)
+	}
+}
+
+// callLabelHandler 以管理员身份调用标签处理函数
+func callLabelHandler(h *Handler, handle gin.HandlerFunc, method, target, body string, params gin.Params) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("role", "admin")
+	handle(c)
+}
+
+func TestPluginLabelHandlers(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	h := &Handler{service: s}
+	createTestPlugins(t, repo, "a")
+	id := gin.Params{{Key: "id", Value: "a"}}
+
+	callLabelHandler(h, h.AddPluginLabel, http.MethodPost, "/plugins/a/labels", `{"label":"env:prod"}`, id)
+	if ids, _ := repo.GetByLabel("env:prod"); strings.Join(ids, ",") != "a" {
+		t.Fatalf("labels after POST = %v", ids)
+	}
+	logs, err := s.GetAuditLogs(&AuditQuery{Action: "plugin.label.add"})
+	if err != nil || len(logs) != 1 || logs[0].Target != "a" || logs[0].Meta["label"] != "env:prod" {
+		t.Fatalf("add audit = %+v, %v", logs, err)
+	}
+
+	// 不合法的标签不写入也不记审计
+	callLabelHandler(h, h.AddPluginLabel, http.MethodPost, "/plugins/a/labels", `{"label":"a b"}`, id)
+	if logs, _ := s.GetAuditLogs(&AuditQuery{Action: "plugin.label.add"}); len(logs) != 1 {
+		t.Fatalf("invalid label audited: %+v", logs)
+	}
+
+	callLabelHandler(h, h.RemovePluginLabel, http.MethodDelete, "/plugins/a/labels/env:prod", "", append(id, gin.Param{Key: "label", Value: "env:prod"}))
+	if ids, _ := repo.GetByLabel("env:prod"); len(ids) != 0 {
+		t.Fatalf("labels after DELETE = %v", ids)
+	}
+	if logs, _ := s.GetAuditLogs(&AuditQuery{Action: "plugin.label.remove"}); len(logs) != 1 {
+		t.Fatalf("remove audit = %+v", logs)
+	}
+
+	// 只读模式下不修改标签
+	s.SetReadOnly(true)
+	callLabelHandler(h, h.AddPluginLabel, http.MethodPost, "/plugins/a/labels", `{"label":"env:dev"}`, id)
+	if ids, _ := repo.GetByLabel("env:dev"); len(ids) != 0 {
+		t.Fatalf("label added in read-only mode: %v", ids)
+	}
+}
@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// countdownContext 在 Err 被调用 remaining 次之后报告已取消，用于模拟查询途中断开
+type countdownContext struct {
+	context.Context
+	remaining int
+	calls     int
+}
+
+func (c *countdownContext) Err() error {
+	c.calls++
+	if c.calls > c.remaining {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestListVaultFilesCancelled(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	for i := 0; i < 100; i++ {
+		if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: fmt.Sprintf("notes/%03d.md", i), Content: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := s.ListVaultFiles(context.Background(), 1)
+	if err != nil || len(all) != 100 {
+		t.Fatalf("uncancelled list = %d files, %v", len(all), err)
+	}
+
+	ctx := &countdownContext{Context: context.Background(), remaining: 5}
+	if _, err := repo.GetVaultFilesByUserID(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("repository query: err = %v, want context.Canceled", err)
+	}
+	if ctx.calls != ctx.remaining+1 {
+		t.Errorf("query continued after cancellation: %d context checks", ctx.calls)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if paths, err := s.ListVaultFiles(cancelled, 1); !errors.Is(err, context.Canceled) || len(paths) != 0 {
+		t.Errorf("ListVaultFiles with cancelled context = %v, %v", paths, err)
+	}
+}
+
+func TestExportVaultCancelled(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	for i := 0; i < 20; i++ {
+		if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: fmt.Sprintf("notes/%03d.md", i), Content: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 统计完整导出时检查 ctx 的次数，其中每个文件检查一次
+	full := &countdownContext{Context: context.Background(), remaining: 1 << 30}
+	if err := s.ExportVault(full, 1, "", &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	listCalls := full.calls - 20
+
+	// 查询途中断开时不再写出 zip
+	var buf bytes.Buffer
+	ctx := &countdownContext{Context: context.Background(), remaining: listCalls / 2}
+	if err := s.ExportVault(ctx, 1, "", &buf); !errors.Is(err, context.Canceled) || buf.Len() != 0 {
+		t.Fatalf("cancelled during the query: %v, %d bytes written", err, buf.Len())
+	}
+
+	// 导出途中断开时不再读取剩余文件
+	ctx = &countdownContext{Context: context.Background(), remaining: listCalls + 3}
+	if err := s.ExportVault(ctx, 1, "", &bytes.Buffer{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled during the export: %v, want context.Canceled", err)
+	}
+	if ctx.calls != ctx.remaining+1 {
+		t.Errorf("export continued after cancellation: %d context checks", ctx.calls)
+	}
+}
@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// marketPage 市场插件的简易 HTML 页面，安装按钮以 JSON 请求 POST install（需已登录）
+var marketPage = template.Must(template.New("market").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>插件市场</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: .5em; text-align: left; vertical-align: top; }
+.tag { background: #eee; border-radius: 3px; padding: 0 .3em; margin-right: .3em; font-size: .85em; }
+</style>
+</head>
+<body>
+<h1>插件市场</h1>
+{{if .}}
+<table>
+<thead><tr><th>名称</th><th>版本</th><th>作者</th><th>描述</th><th>标签</th><th></th></tr></thead>
+<tbody>
+{{range .}}<tr>
+<td>{{.Name}}<br><small>{{.ID}}</small></td>
+<td>{{.Version}}</td>
+<td>{{.Author}}</td>
+<td>{{.Description}}</td>
+<td>{{range .Tags}}<span class="tag">{{.}}</span>{{end}}</td>
+<td><button class="install" data-id="{{.ID}}" data-url="{{.URL}}" data-sha256="{{.SHA256}}">安装</button> <a href="{{.URL}}">下载</a></td>
+</tr>
+{{end}}</tbody>
+</table>
+{{else}}
+<p>暂无可用插件</p>
+{{end}}
+<script>
+document.querySelectorAll("button.install").forEach(function (b) {
+  b.addEventListener("click", function () {
+    b.disabled = true;
+    fetch("install", {
+      method: "POST",
+      credentials: "same-origin",
+      headers: {"Content-Type": "application/json"},
+      body: JSON.stringify({id: b.dataset.id, url: b.dataset.url, sha256: b.dataset.sha256})
+    }).then(function (r) {
+      b.textContent = r.ok ? "安装中" : "失败 (" + r.status + ")";
+    }, function () {
+      b.textContent = "失败";
+      b.disabled = false;
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// wantsHTML 按 Accept 请求头中的顺序，text/html 先于 application/json 出现时返回 true
+func wantsHTML(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		switch strings.ToLower(mediaType) {
+		case "text/html":
+			return true
+		case "application/json":
+			return false
+		}
+	}
+	return false
+}
+
+// writeMarketHTML 以 HTML 表格输出市场插件
+func writeMarketHTML(c *gin.Context, items []*MarketItem) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := marketPage.Execute(c.Writer, items); err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
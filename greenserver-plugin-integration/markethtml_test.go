@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWantsHTML(t *testing.T) {
+	cases := map[string]bool{
+		"":                                 false,
+		"*/*":                              false,
+		"application/json":                 false,
+		"text/html":                        true,
+		"TEXT/HTML; charset=utf-8":         true,
+		"text/html,application/json;q=0.9": true,
+		"application/json, text/html":      false,
+	}
+	for accept, want := range cases {
+		if got := wantsHTML(accept); got != want {
+			t.Errorf("wantsHTML(%q) = %v, want %v", accept, got, want)
+		}
+	}
+}
+
+// getMarketItems 以指定的 Accept 请求头调用 GetMarketItems
+func getMarketItems(h *Handler, accept string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/plugins/market", nil)
+	if accept != "" {
+		c.Request.Header.Set("Accept", accept)
+	}
+	h.GetMarketItems(c)
+	return w
+}
+
+func TestGetMarketItemsHTML(t *testing.T) {
+	market := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]*MarketItem{
+			{ID: "demo", Name: "<b>Demo</b>", Version: "1.2.0", Author: "alice", URL: "https://example.com/demo.zip", Tags: []string{"Notes"}},
+		})
+	}))
+	defer market.Close()
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), market.URL, ServiceOptions{}).(*ServiceImpl)
+	h := &Handler{service: s}
+
+	w := getMarketItems(h, "text/html,application/xhtml+xml,*/*;q=0.8")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("browser Accept: got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("Vary") != "Accept" {
+		t.Errorf("Vary = %q, want Accept", w.Header().Get("Vary"))
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<table>", `data-id="demo"`, "1.2.0", "alice", `<span class="tag">notes</span>`, "&lt;b&gt;Demo&lt;/b&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("HTML page missing %q", want)
+		}
+	}
+
+	// 未要求 HTML 时仍走 JSON 响应
+	for _, accept := range []string{"", "application/json", "application/json, text/html"} {
+		w := getMarketItems(h, accept)
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || strings.Contains(w.Body.String(), "<table>") {
+			t.Errorf("Accept %q: got an HTML page", accept)
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: Vary = %q, want Accept", accept, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestGetMarketItemsHTMLEmpty(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	w := getMarketItems(&Handler{service: s}, "text/html")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "暂无可用插件") {
+		t.Errorf("empty market page = %d %q", w.Code, w.Body.String())
+	}
+}
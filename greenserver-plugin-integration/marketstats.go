@@ -0,0 +1,30 @@
+package plugin
+
+// GetMarketStats 汇总市场索引的条目数、各标签和作者的条目数以及下载量总和，
+// 与 GetMarketItems 使用同一份索引数据
+func (s *ServiceImpl) GetMarketStats() (*MarketStatsResponse, error) {
+	items, err := s.GetMarketItems("")
+	if err != nil {
+		return nil, err
+	}
+	return marketStats(items), nil
+}
+
+// marketStats 统计市场条目，未填写作者的条目不计入 ByAuthor
+func marketStats(items []*MarketItem) *MarketStatsResponse {
+	stats := &MarketStatsResponse{
+		Total:    len(items),
+		ByTag:    make(map[string]int),
+		ByAuthor: make(map[string]int),
+	}
+	for _, item := range items {
+		for _, tag := range item.Tags {
+			stats.ByTag[tag]++
+		}
+		if item.Author != "" {
+			stats.ByAuthor[item.Author]++
+		}
+		stats.Downloads += int64(item.Downloads)
+	}
+	return stats
+}
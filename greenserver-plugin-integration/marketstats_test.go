@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// serveMarketIndex 启动返回 items 的市场索引服务
+func serveMarketIndex(t *testing.T, items []*MarketItem) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestGetMarketStats(t *testing.T) {
+	url := serveMarketIndex(t, []*MarketItem{
+		{ID: "a", Author: "alice", Downloads: 10, Tags: []string{"Notes", "sync"}},
+		{ID: "b", Author: "alice", Downloads: 5, Tags: []string{"notes", " NOTES "}},
+		{ID: "c", Author: "bob", Downloads: 1},
+		{ID: "d", Downloads: 4, Tags: []string{"sync"}},
+	})
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), url, ServiceOptions{}).(*ServiceImpl)
+
+	stats, err := s.GetMarketStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &MarketStatsResponse{
+		Total: 4,
+		// 标签与 GetMarketItems 一样规范化，同一条目内重复的标签只计一次
+		ByTag: map[string]int{"notes": 2, "sync": 2},
+		// 未填写作者的条目不计入
+		ByAuthor:  map[string]int{"alice": 2, "bob": 1},
+		Downloads: 20,
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func TestGetMarketStatsWithoutMarket(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	stats, err := s.GetMarketStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total != 0 || stats.Downloads != 0 || stats.ByTag == nil || stats.ByAuthor == nil {
+		t.Fatalf("stats = %+v", stats)
+	}
+	// 空统计编码为空对象而不是 null，便于前端直接使用
+	data, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"total":0,"by_tag":{},"by_author":{},"downloads":0}` {
+		t.Fatalf("json = %s", data)
+	}
+}
+
+func TestGetMarketStatsIndexError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer srv.Close()
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), srv.URL, ServiceOptions{}).(*ServiceImpl)
+	if _, err := s.GetMarketStats(); err == nil {
+		t.Fatal("expected error for an unreadable market index")
+	}
+}
@@ -0,0 +1,62 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMaxPluginsRejectsNewInstallsPastLimit(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{MaxPlugins: 2}).(*ServiceImpl)
+	createTestPlugins(t, repo, "a", "b")
+
+	// 达到上限后新安装在创建安装记录前被拒绝
+	if err := s.InstallPlugin(&PluginInstallRequest{ID: "c", URL: "https://example.com/c.zip"}); !errors.Is(err, ErrMaxPluginsReached) {
+		t.Fatalf("InstallPlugin past limit: err = %v, want ErrMaxPluginsReached", err)
+	}
+	if _, busy := s.getInstallation("c"); busy {
+		t.Error("rejected install left an installation record")
+	}
+	c := writeManifestFile(t, `{"id":"c","name":"C","version":"1.0.0"}`)
+	if err := s.loadPluginFromManifest(c, true); !errors.Is(err, ErrMaxPluginsReached) {
+		t.Fatalf("loadPluginFromManifest past limit: err = %v, want ErrMaxPluginsReached", err)
+	}
+	if count, _ := repo.CountPlugins(); count != 2 {
+		t.Errorf("plugin count = %d, want 2", count)
+	}
+
+	// 升级已安装的插件不受上限影响
+	a := writeManifestFile(t, `{"id":"a","name":"A","version":"2.0.0"}`)
+	if err := s.loadPluginFromManifest(a, true); err != nil {
+		t.Fatalf("upgrade at limit: %v", err)
+	}
+	if plugin, _ := repo.GetPluginByID("a"); plugin.Version != "2.0.0" {
+		t.Errorf("version after upgrade = %s", plugin.Version)
+	}
+}
+
+func TestMaxPluginsConcurrentInstalls(t *testing.T) {
+	const limit = 3
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{MaxPlugins: limit}).(*ServiceImpl)
+	manifests := make([]string, 8)
+	for i := range manifests {
+		manifests[i] = writeManifestFile(t, fmt.Sprintf(`{"id":"p%d","name":"P","version":"1.0.0"}`, i))
+	}
+
+	var wg sync.WaitGroup
+	for _, path := range manifests {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			_ = s.loadPluginFromManifest(path, true)
+		}(path)
+	}
+	wg.Wait()
+
+	if count, _ := repo.CountPlugins(); count != limit {
+		t.Errorf("plugin count = %d, want exactly %d", count, limit)
+	}
+}
@@ -0,0 +1,973 @@
+package plugin
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryRepository 基于内存的插件存储库实现，用于测试和无数据库的嵌入式模式
+//
+// 语义与 RepositoryImpl 保持一致：查询不到记录时返回 gorm.ErrRecordNotFound，
+// 违反唯一约束时返回 gorm.ErrDuplicatedKey，查询插件时预加载权限和命令。
+type MemoryRepository struct {
+	mu sync.RWMutex
+
+	nextID        uint
+	plugins       map[string]*Plugin // plugin_id -> 插件（不含关联）
+	permissions   map[string]*Permission
+	pluginPerms   map[string][]string // plugin_id -> 权限名称
+	labels        []*PluginLabel
+	commands      []*Command
+	installations map[string]*PluginInstallation
+	vaultFiles    map[vaultKey]*VaultFile
+	vaultBlobs    map[string]*VaultBlob
+	quotas        map[uint]*UserQuota
+	auditLogs     []*AuditLog
+	eventLogs     []*EventLog
+	kv            map[kvKey]*PluginKV
+}
+
+// kvKey 键值存储的唯一键 (plugin_id, key)
+type kvKey struct {
+	pluginID string
+	key      string
+}
+
+// vaultKey 存储库文件的唯一键 (user_id, path)
+type vaultKey struct {
+	userID uint
+	path   string
+}
+
+// NewInMemoryRepository 创建内存插件存储库实例
+func NewInMemoryRepository() Repository {
+	return &MemoryRepository{
+		plugins:       make(map[string]*Plugin),
+		permissions:   make(map[string]*Permission),
+		pluginPerms:   make(map[string][]string),
+		installations: make(map[string]*PluginInstallation),
+		vaultFiles:    make(map[vaultKey]*VaultFile),
+		vaultBlobs:    make(map[string]*VaultBlob),
+		quotas:        make(map[uint]*UserQuota),
+		kv:            make(map[kvKey]*PluginKV),
+	}
+}
+
+// newID 生成自增主键，调用方须持有写锁
+func (r *MemoryRepository) newID() uint {
+	r.nextID++
+	return r.nextID
+}
+
+// loadPlugin 返回带关联的插件副本，调用方须持有读锁
+func (r *MemoryRepository) loadPlugin(p *Plugin) *Plugin {
+	out := *p
+	out.Permissions = make([]Permission, 0, len(r.pluginPerms[p.PluginID]))
+	for _, name := range r.pluginPerms[p.PluginID] {
+		if perm, ok := r.permissions[name]; ok {
+			out.Permissions = append(out.Permissions, *perm)
+		}
+	}
+	out.Commands = make([]Command, 0)
+	for _, cmd := range r.commands {
+		if cmd.PluginID == p.PluginID {
+			out.Commands = append(out.Commands, *cmd)
+		}
+	}
+	out.Labels = make([]PluginLabel, 0)
+	for _, label := range r.labels {
+		if label.PluginID == p.PluginID {
+			out.Labels = append(out.Labels, *label)
+		}
+	}
+	return &out
+}
+
+// Transaction 执行 fn，fn 返回错误时把数据恢复到执行前的快照
+//
+// 内存实现只保证回滚，不提供事务隔离：fn 执行期间其他调用方可以看到中间状态。
+func (r *MemoryRepository) Transaction(fn func(repo Repository) error) error {
+	r.mu.RLock()
+	snapshot := r.snapshot()
+	r.mu.RUnlock()
+
+	if err := fn(r); err != nil {
+		r.mu.Lock()
+		r.restore(snapshot)
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// memorySnapshot 内存存储库的数据快照
+type memorySnapshot struct {
+	nextID        uint
+	plugins       map[string]*Plugin
+	permissions   map[string]*Permission
+	pluginPerms   map[string][]string
+	labels        []*PluginLabel
+	commands      []*Command
+	installations map[string]*PluginInstallation
+	vaultFiles    map[vaultKey]*VaultFile
+	vaultBlobs    map[string]*VaultBlob
+	quotas        map[uint]*UserQuota
+	auditLogs     []*AuditLog
+	eventLogs     []*EventLog
+	kv            map[kvKey]*PluginKV
+}
+
+// snapshot 复制当前数据，调用方须持有读锁
+func (r *MemoryRepository) snapshot() *memorySnapshot {
+	s := &memorySnapshot{
+		nextID:        r.nextID,
+		plugins:       make(map[string]*Plugin, len(r.plugins)),
+		permissions:   make(map[string]*Permission, len(r.permissions)),
+		pluginPerms:   make(map[string][]string, len(r.pluginPerms)),
+		labels:        append([]*PluginLabel(nil), r.labels...), // 标签写入后不再修改，可以共享
+		commands:      make([]*Command, 0, len(r.commands)),
+		installations: make(map[string]*PluginInstallation, len(r.installations)),
+		vaultFiles:    make(map[vaultKey]*VaultFile, len(r.vaultFiles)),
+		vaultBlobs:    make(map[string]*VaultBlob, len(r.vaultBlobs)),
+		quotas:        make(map[uint]*UserQuota, len(r.quotas)),
+		auditLogs:     make([]*AuditLog, 0, len(r.auditLogs)),
+		eventLogs:     append([]*EventLog(nil), r.eventLogs...), // 事件记录写入后不再修改，可以共享
+		kv:            make(map[kvKey]*PluginKV, len(r.kv)),
+	}
+	for k, v := range r.plugins {
+		c := *v
+		s.plugins[k] = &c
+	}
+	for k, v := range r.permissions {
+		c := *v
+		s.permissions[k] = &c
+	}
+	for k, v := range r.pluginPerms {
+		s.pluginPerms[k] = append([]string(nil), v...)
+	}
+	for _, v := range r.commands {
+		c := *v
+		s.commands = append(s.commands, &c)
+	}
+	for k, v := range r.installations {
+		c := *v
+		s.installations[k] = &c
+	}
+	for k, v := range r.vaultFiles {
+		c := *v
+		s.vaultFiles[k] = &c
+	}
+	for k, v := range r.vaultBlobs {
+		s.vaultBlobs[k] = v // 数据块写入后不再修改，可以共享
+	}
+	for k, v := range r.quotas {
+		c := *v
+		s.quotas[k] = &c
+	}
+	for _, v := range r.auditLogs {
+		c := *v
+		s.auditLogs = append(s.auditLogs, &c)
+	}
+	for k, v := range r.kv {
+		c := *v
+		s.kv[k] = &c
+	}
+	return s
+}
+
+// restore 恢复快照，调用方须持有写锁
+func (r *MemoryRepository) restore(s *memorySnapshot) {
+	r.nextID = s.nextID
+	r.plugins = s.plugins
+	r.permissions = s.permissions
+	r.pluginPerms = s.pluginPerms
+	r.labels = s.labels
+	r.commands = s.commands
+	r.installations = s.installations
+	r.vaultFiles = s.vaultFiles
+	r.vaultBlobs = s.vaultBlobs
+	r.quotas = s.quotas
+	r.auditLogs = s.auditLogs
+	r.eventLogs = s.eventLogs
+	r.kv = s.kv
+}
+
+// Plugin operations
+func (r *MemoryRepository) CreatePlugin(plugin *Plugin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.plugins[plugin.PluginID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	now := time.Now()
+	plugin.ID = r.newID()
+	plugin.CreatedAt = now
+	plugin.UpdatedAt = now
+
+	stored := *plugin
+	stored.Permissions = nil
+	stored.Commands = nil
+	stored.Labels = nil
+	r.plugins[plugin.PluginID] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) GetPluginByID(pluginID string) (*Plugin, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.plugins[pluginID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.loadPlugin(p), nil
+}
+
+func (r *MemoryRepository) CountPlugins() (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(r.plugins)), nil
+}
+
+func (r *MemoryRepository) GetAllPlugins(query *PluginQuery) ([]*Plugin, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plugins := make([]*Plugin, 0, len(r.plugins))
+	for _, p := range r.plugins {
+		if query != nil {
+			if query.Enabled != nil && p.Enabled != *query.Enabled {
+				continue
+			}
+			if query.Author != "" && p.Author != query.Author {
+				continue
+			}
+			if query.Label != "" && !r.hasLabel(p.PluginID, query.Label) {
+				continue
+			}
+		}
+		plugins = append(plugins, r.loadPlugin(p))
+	}
+	sortByName := query != nil && query.Sort == PluginSortName
+	sort.Slice(plugins, func(i, j int) bool {
+		if sortByName && plugins[i].Name != plugins[j].Name {
+			return plugins[i].Name < plugins[j].Name
+		}
+		return plugins[i].PluginID < plugins[j].PluginID
+	})
+	return plugins, nil
+}
+
+func (r *MemoryRepository) UpdatePlugin(plugin *Plugin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.plugins[plugin.PluginID]
+	if !ok {
+		// 与 Save 一致：不存在时插入
+		if plugin.ID == 0 {
+			plugin.ID = r.newID()
+		}
+		plugin.CreatedAt = time.Now()
+	} else {
+		plugin.ID = existing.ID
+		plugin.CreatedAt = existing.CreatedAt
+	}
+	plugin.UpdatedAt = time.Now()
+
+	stored := *plugin
+	stored.Permissions = nil
+	stored.Commands = nil
+	stored.Labels = nil
+	r.plugins[plugin.PluginID] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) DeletePlugin(pluginID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.plugins, pluginID)
+	delete(r.pluginPerms, pluginID)
+	return nil
+}
+
+func (r *MemoryRepository) EnablePlugin(pluginID string) error {
+	return r.setEnabled(pluginID, true)
+}
+
+func (r *MemoryRepository) DisablePlugin(pluginID string) error {
+	return r.setEnabled(pluginID, false)
+}
+
+func (r *MemoryRepository) setEnabled(pluginID string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 与按条件 Update 一致：没有匹配的行时不报错
+	if p, ok := r.plugins[pluginID]; ok {
+		p.Enabled = enabled
+		p.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// Permission operations
+func (r *MemoryRepository) CreatePermission(permission *Permission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.createPermission(permission)
+}
+
+// createPermission 调用方须持有写锁
+func (r *MemoryRepository) createPermission(permission *Permission) error {
+	if _, exists := r.permissions[permission.Name]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	now := time.Now()
+	permission.ID = r.newID()
+	permission.CreatedAt = now
+	permission.UpdatedAt = now
+
+	stored := *permission
+	r.permissions[permission.Name] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) GetPermissionByName(name string) (*Permission, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	perm, ok := r.permissions[name]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	out := *perm
+	return &out, nil
+}
+
+func (r *MemoryRepository) GetAllPermissions() ([]*Permission, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	permissions := make([]*Permission, 0, len(r.permissions))
+	for _, perm := range r.permissions {
+		out := *perm
+		permissions = append(permissions, &out)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i].ID < permissions[j].ID })
+	return permissions, nil
+}
+
+func (r *MemoryRepository) GetPluginPermissions(pluginID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.plugins[pluginID]; !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return append([]string{}, r.pluginPerms[pluginID]...), nil
+}
+
+func (r *MemoryRepository) GetAllPluginPermissions() (map[string][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string][]string, len(r.plugins))
+	for pluginID := range r.plugins {
+		perms := append([]string{}, r.pluginPerms[pluginID]...)
+		sort.Strings(perms)
+		result[pluginID] = perms
+	}
+	return result, nil
+}
+
+func (r *MemoryRepository) AddPluginPermission(pluginID string, permissionName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.plugins[pluginID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if _, ok := r.permissions[permissionName]; !ok {
+		// 如果权限不存在，创建它
+		if err := r.createPermission(&Permission{Name: permissionName}); err != nil {
+			return err
+		}
+	}
+	for _, name := range r.pluginPerms[pluginID] {
+		if name == permissionName {
+			return nil
+		}
+	}
+	r.pluginPerms[pluginID] = append(r.pluginPerms[pluginID], permissionName)
+	return nil
+}
+
+func (r *MemoryRepository) RemovePluginPermission(pluginID string, permissionName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.plugins[pluginID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if _, ok := r.permissions[permissionName]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	names := r.pluginPerms[pluginID]
+	for i, name := range names {
+		if name == permissionName {
+			r.pluginPerms[pluginID] = append(names[:i:i], names[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Label operations
+func (r *MemoryRepository) AddLabel(pluginID, label string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.plugins[pluginID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if r.hasLabel(pluginID, label) {
+		return nil
+	}
+	r.labels = append(r.labels, &PluginLabel{ID: r.newID(), PluginID: pluginID, Label: label, CreatedAt: time.Now()})
+	return nil
+}
+
+func (r *MemoryRepository) RemoveLabel(pluginID, label string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, l := range r.labels {
+		if l.PluginID == pluginID && l.Label == label {
+			r.labels = append(r.labels[:i:i], r.labels[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) GetByLabel(label string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pluginIDs := make([]string, 0)
+	for _, l := range r.labels {
+		if l.Label == label {
+			pluginIDs = append(pluginIDs, l.PluginID)
+		}
+	}
+	sort.Strings(pluginIDs)
+	return pluginIDs, nil
+}
+
+func (r *MemoryRepository) DeleteLabelsByPluginID(pluginID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := make([]*PluginLabel, 0, len(r.labels))
+	for _, l := range r.labels {
+		if l.PluginID != pluginID {
+			kept = append(kept, l)
+		}
+	}
+	r.labels = kept
+	return nil
+}
+
+// hasLabel 调用方须持有读锁
+func (r *MemoryRepository) hasLabel(pluginID, label string) bool {
+	for _, l := range r.labels {
+		if l.PluginID == pluginID && l.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+// Command operations
+func (r *MemoryRepository) CreateCommand(command *Command) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	command.ID = r.newID()
+	command.CreatedAt = now
+	command.UpdatedAt = now
+
+	stored := *command
+	r.commands = append(r.commands, &stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetCommandsByPluginID(pluginID string) ([]*Command, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	commands := make([]*Command, 0)
+	for _, cmd := range r.commands {
+		if cmd.PluginID == pluginID {
+			out := *cmd
+			commands = append(commands, &out)
+		}
+	}
+	return commands, nil
+}
+
+func (r *MemoryRepository) GetAllCommands() ([]*Command, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	commands := make([]*Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		out := *cmd
+		commands = append(commands, &out)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		if commands[i].PluginID != commands[j].PluginID {
+			return commands[i].PluginID < commands[j].PluginID
+		}
+		return commands[i].CommandID < commands[j].CommandID
+	})
+	return commands, nil
+}
+
+func (r *MemoryRepository) DeleteCommandsByPluginID(pluginID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.commands[:0]
+	for _, cmd := range r.commands {
+		if cmd.PluginID != pluginID {
+			kept = append(kept, cmd)
+		}
+	}
+	r.commands = kept
+	return nil
+}
+
+func (r *MemoryRepository) DeleteCommandsBySource(pluginID, source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.commands[:0]
+	for _, cmd := range r.commands {
+		if cmd.PluginID != pluginID || cmd.Source != source {
+			kept = append(kept, cmd)
+		}
+	}
+	r.commands = kept
+	return nil
+}
+
+// Installation operations
+func (r *MemoryRepository) CreateInstallation(installation *PluginInstallation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	installation.ID = r.newID()
+	installation.CreatedAt = now
+	installation.UpdatedAt = now
+
+	stored := *installation
+	r.installations[installation.PluginID] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) GetInstallationByPluginID(pluginID string) (*PluginInstallation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	installation, ok := r.installations[pluginID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	out := *installation
+	return &out, nil
+}
+
+func (r *MemoryRepository) UpdateInstallation(installation *PluginInstallation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if installation.ID == 0 {
+		installation.ID = r.newID()
+		installation.CreatedAt = time.Now()
+	}
+	installation.UpdatedAt = time.Now()
+
+	stored := *installation
+	r.installations[installation.PluginID] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) DeleteInstallation(pluginID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.installations, pluginID)
+	return nil
+}
+
+// Vault operations
+func (r *MemoryRepository) CreateVaultFile(file *VaultFile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := vaultKey{userID: file.UserID, path: filepath.Clean(file.Path)}
+	if _, exists := r.vaultFiles[key]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	now := time.Now()
+	file.ID = r.newID()
+	file.CreatedAt = now
+	file.UpdatedAt = now
+
+	stored := *file
+	stored.Content = append([]byte(nil), file.Content...)
+	r.vaultFiles[key] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) GetVaultFileByPath(userID uint, path string) (*VaultFile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	file, ok := r.vaultFiles[vaultKey{userID: userID, path: filepath.Clean(path)}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	out := *file
+	out.Content = append([]byte(nil), file.Content...)
+	return &out, nil
+}
+
+func (r *MemoryRepository) GetVaultFilesByUserID(ctx context.Context, userID uint) ([]*VaultFile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	files := make([]*VaultFile, 0)
+	for key, file := range r.vaultFiles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if key.userID == userID {
+			out := *file
+			out.Content = append([]byte(nil), file.Content...)
+			files = append(files, &out)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+	return files, nil
+}
+
+func (r *MemoryRepository) UpdateVaultFile(file *VaultFile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := vaultKey{userID: file.UserID, path: filepath.Clean(file.Path)}
+	if existing, ok := r.vaultFiles[key]; ok && existing.ID != file.ID {
+		return gorm.ErrDuplicatedKey
+	}
+	// 路径或所属用户变化时移除旧键
+	for k, existing := range r.vaultFiles {
+		if file.ID != 0 && existing.ID == file.ID && k != key {
+			delete(r.vaultFiles, k)
+		}
+	}
+	if file.ID == 0 {
+		file.ID = r.newID()
+		file.CreatedAt = time.Now()
+	}
+	file.UpdatedAt = time.Now()
+
+	stored := *file
+	stored.Content = append([]byte(nil), file.Content...)
+	r.vaultFiles[key] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) DeleteVaultFile(userID uint, path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.vaultFiles, vaultKey{userID: userID, path: filepath.Clean(path)})
+	return nil
+}
+
+func (r *MemoryRepository) GetVaultUsage(userID uint) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var total int64
+	for key, file := range r.vaultFiles {
+		if key.userID == userID {
+			total += file.Size
+		}
+	}
+	return total, nil
+}
+
+// Vault blob operations
+
+// CreateVaultBlob 保存数据块，相同哈希已存在时忽略
+func (r *MemoryRepository) CreateVaultBlob(blob *VaultBlob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.vaultBlobs[blob.Hash]; exists {
+		return nil
+	}
+	if blob.CreatedAt.IsZero() {
+		blob.CreatedAt = time.Now()
+	}
+	stored := *blob
+	stored.Content = append([]byte(nil), blob.Content...)
+	r.vaultBlobs[blob.Hash] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) GetVaultBlob(hash string) (*VaultBlob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	blob, ok := r.vaultBlobs[hash]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	out := *blob
+	out.Content = append([]byte(nil), blob.Content...)
+	return &out, nil
+}
+
+func (r *MemoryRepository) CountVaultBlobRefs(hash string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, file := range r.vaultFiles {
+		if file.BlobHash == hash {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryRepository) DeleteVaultBlob(hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.vaultBlobs, hash)
+	return nil
+}
+
+// Quota operations
+func (r *MemoryRepository) GetUserQuota(userID uint) (*UserQuota, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quota, ok := r.quotas[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	out := *quota
+	return &out, nil
+}
+
+func (r *MemoryRepository) SetUserQuota(userID uint, quotaBytes int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if quota, ok := r.quotas[userID]; ok {
+		quota.QuotaBytes = quotaBytes
+		quota.UpdatedAt = now
+		return nil
+	}
+	r.quotas[userID] = &UserQuota{
+		ID:         r.newID(),
+		UserID:     userID,
+		QuotaBytes: quotaBytes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	return nil
+}
+
+// KV operations
+func (r *MemoryRepository) GetKV(pluginID, key string) (*PluginKV, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kv, ok := r.kv[kvKey{pluginID, key}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	out := *kv
+	return &out, nil
+}
+
+func (r *MemoryRepository) SetKV(kv *PluginKV) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	k := kvKey{kv.PluginID, kv.Key}
+	if existing, ok := r.kv[k]; ok {
+		existing.Value = kv.Value
+		existing.UpdatedAt = now
+		return nil
+	}
+	kv.ID = r.newID()
+	kv.CreatedAt = now
+	kv.UpdatedAt = now
+	stored := *kv
+	r.kv[k] = &stored
+	return nil
+}
+
+func (r *MemoryRepository) DeleteKV(pluginID, key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := kvKey{pluginID, key}
+	if _, ok := r.kv[k]; !ok {
+		return false, nil
+	}
+	delete(r.kv, k)
+	return true, nil
+}
+
+func (r *MemoryRepository) ListKVKeys(pluginID, prefix string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]string, 0)
+	for k := range r.kv {
+		if k.pluginID == pluginID && strings.HasPrefix(k.key, prefix) {
+			keys = append(keys, k.key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (r *MemoryRepository) GetKVUsage(pluginID string) (int64, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count, size int64
+	for k, v := range r.kv {
+		if k.pluginID == pluginID {
+			count++
+			size += int64(len(k.key) + len(v.Value))
+		}
+	}
+	return count, size, nil
+}
+
+// Event history operations
+func (r *MemoryRepository) CreateEventLog(event *EventLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ID = r.newID()
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	stored := *event
+	r.eventLogs = append(r.eventLogs, &stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetEventLogs(query *EventHistoryQuery) ([]*EventLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]*EventLog, 0)
+	for i := len(r.eventLogs) - 1; i >= 0 && len(events) < query.Limit; i-- {
+		e := r.eventLogs[i]
+		if query.Type != "" && e.Type != query.Type {
+			continue
+		}
+		if query.Since != nil && e.CreatedAt.Before(*query.Since) {
+			continue
+		}
+		out := *e
+		events = append(events, &out)
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+func (r *MemoryRepository) DeleteEventLogsBefore(t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := make([]*EventLog, 0, len(r.eventLogs))
+	for _, e := range r.eventLogs {
+		if e.CreatedAt.Before(t) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	removed := int64(len(r.eventLogs) - len(kept))
+	r.eventLogs = kept
+	return removed, nil
+}
+
+// Audit operations
+func (r *MemoryRepository) CreateAuditLog(log *AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	log.ID = r.newID()
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	stored := *log
+	r.auditLogs = append(r.auditLogs, &stored)
+	return nil
+}
+
+func (r *MemoryRepository) GetAuditLogs(query *AuditQuery) ([]*AuditLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	logs := make([]*AuditLog, 0)
+	for _, log := range r.auditLogs {
+		if query != nil {
+			if query.Action != "" && log.Action != query.Action {
+				continue
+			}
+			if query.Since != nil && log.CreatedAt.Before(*query.Since) {
+				continue
+			}
+			if query.Until != nil && log.CreatedAt.After(*query.Until) {
+				continue
+			}
+		}
+		out := *log
+		logs = append(logs, &out)
+	}
+	// 与 ORDER BY created_at DESC 一致
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].CreatedAt.After(logs[j].CreatedAt) })
+	return logs, nil
+}
@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestMemoryRepositoryPlugins(t *testing.T) {
+	repo := NewInMemoryRepository()
+	if _, err := repo.GetPluginByID("demo"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("missing plugin err = %v, want ErrRecordNotFound", err)
+	}
+	if err := repo.CreatePlugin(&Plugin{PluginID: "demo", Name: "Demo", Version: "1.0.0", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreatePlugin(&Plugin{PluginID: "demo", Name: "Again", Version: "1.0.0"}); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("duplicate plugin err = %v, want ErrDuplicatedKey", err)
+	}
+	if err := repo.AddPluginPermission("missing", "vault.read"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("permission for missing plugin err = %v", err)
+	}
+	for _, perm := range []string{"vault.read", "vault.read", "vault.write"} {
+		if err := repo.AddPluginPermission("demo", perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.CreateCommand(&Command{CommandID: "demo.run", PluginID: "demo", Title: "Run"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 与 gorm 实现一样预加载权限和命令
+	p, err := repo.GetPluginByID("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Permissions) != 2 || len(p.Commands) != 1 {
+		t.Fatalf("preloaded %d permissions and %d commands, want 2 and 1", len(p.Permissions), len(p.Commands))
+	}
+
+	// 返回的是副本，修改不影响存储
+	p.Name = "Changed"
+	if stored, _ := repo.GetPluginByID("demo"); stored.Name != "Demo" {
+		t.Errorf("stored name = %q after mutating a returned copy", stored.Name)
+	}
+
+	if err := repo.DisablePlugin("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := repo.GetPluginByID("demo"); stored.Enabled {
+		t.Error("plugin still enabled")
+	}
+	if n, _ := repo.CountPlugins(); n != 1 {
+		t.Errorf("CountPlugins = %d, want 1", n)
+	}
+}
+
+func TestMemoryRepositoryTransactionRollback(t *testing.T) {
+	repo := NewInMemoryRepository()
+	if err := repo.CreatePlugin(&Plugin{PluginID: "keep", Name: "Keep", Version: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	boom := errors.New("boom")
+	err := repo.Transaction(func(tx Repository) error {
+		if err := tx.CreatePlugin(&Plugin{PluginID: "temp", Name: "Temp", Version: "1.0.0"}); err != nil {
+			return err
+		}
+		if err := tx.DeletePlugin("keep"); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("Transaction err = %v, want boom", err)
+	}
+	if _, err := repo.GetPluginByID("temp"); err == nil {
+		t.Error("plugin created in a failed transaction should be rolled back")
+	}
+	if _, err := repo.GetPluginByID("keep"); err != nil {
+		t.Errorf("plugin deleted in a failed transaction should be restored: %v", err)
+	}
+}
+
+func TestMemoryRepositoryVaultFiles(t *testing.T) {
+	repo := NewInMemoryRepository()
+	if err := repo.CreateVaultFile(&VaultFile{UserID: 1, Path: "notes/a.md", Content: []byte("abc"), Size: 3}); err != nil {
+		t.Fatal(err)
+	}
+	// 唯一约束针对 (user_id, path)，路径按规范化后比较
+	if err := repo.CreateVaultFile(&VaultFile{UserID: 1, Path: "notes/./a.md"}); !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("duplicate path err = %v, want ErrDuplicatedKey", err)
+	}
+	if err := repo.CreateVaultFile(&VaultFile{UserID: 2, Path: "notes/a.md"}); err != nil {
+		t.Errorf("same path for another user: %v", err)
+	}
+
+	f, err := repo.GetVaultFileByPath(1, "notes/a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Content[0] = 'x'
+	if stored, _ := repo.GetVaultFileByPath(1, "notes/a.md"); string(stored.Content) != "abc" {
+		t.Errorf("stored content = %q after mutating a returned copy", stored.Content)
+	}
+
+	if err := repo.DeleteVaultFile(1, "notes/a.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetVaultFileByPath(1, "notes/a.md"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("deleted file err = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := repo.GetVaultFileByPath(2, "notes/a.md"); err != nil {
+		t.Errorf("other user's file affected by delete: %v", err)
+	}
+}
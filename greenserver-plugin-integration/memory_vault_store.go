@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryVaultStore 把存储库保存在内存中，用于测试和无数据库的嵌入式模式，重启后内容丢失
+type MemoryVaultStore struct {
+	mu    sync.RWMutex
+	files map[vaultKey]memoryVaultFile
+}
+
+type memoryVaultFile struct {
+	content []byte
+	modTime time.Time
+}
+
+// NewMemoryVaultStore 创建空的内存存储库
+func NewMemoryVaultStore() *MemoryVaultStore {
+	return &MemoryVaultStore{files: make(map[vaultKey]memoryVaultFile)}
+}
+
+func (v *MemoryVaultStore) List(ctx context.Context, userID uint) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	v.mu.RLock()
+	paths := make([]string, 0)
+	for key := range v.files {
+		if key.userID == userID {
+			paths = append(paths, key.path)
+		}
+	}
+	v.mu.RUnlock()
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (v *MemoryVaultStore) Read(userID uint, path string) ([]byte, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	file, ok := v.files[vaultKey{userID: userID, path: filepath.Clean(path)}]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVaultFileNotFound, path)
+	}
+	return append([]byte(nil), file.content...), nil
+}
+
+func (v *MemoryVaultStore) Write(userID uint, path string, content []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.files[vaultKey{userID: userID, path: filepath.Clean(path)}] = memoryVaultFile{
+		content: append([]byte(nil), content...),
+		modTime: time.Now(),
+	}
+	return nil
+}
+
+func (v *MemoryVaultStore) Delete(userID uint, path string) error {
+	key := vaultKey{userID: userID, path: filepath.Clean(path)}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.files[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrVaultFileNotFound, path)
+	}
+	delete(v.files, key)
+	return nil
+}
+
+func (v *MemoryVaultStore) Stat(userID uint, path string) (*VaultFileInfo, error) {
+	key := vaultKey{userID: userID, path: filepath.Clean(path)}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	file, ok := v.files[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrVaultFileNotFound, path)
+	}
+	return &VaultFileInfo{Path: key.path, Size: int64(len(file.content)), ModTime: file.modTime}, nil
+}
@@ -8,19 +8,23 @@ import (
 
 // Plugin 插件模型
 type Plugin struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	PluginID    string         `json:"plugin_id" gorm:"uniqueIndex;not null"`                   // 插件唯一标识
-	Name        string         `json:"name" gorm:"not null"`                                    // 插件名称
-	Version     string         `json:"version" gorm:"not null"`                                 // 插件版本
-	Author      string         `json:"author"`                                                  // 插件作者
-	Description string         `json:"description"`                                             // 插件描述
-	Enabled     bool           `json:"enabled" gorm:"default:true"`                             // 是否启用
-	BackupPath  string         `json:"backup_path"`                                             // 备份路径
-	Permissions []Permission   `json:"permissions" gorm:"many2many:plugin_permissions;"`        // 插件权限
-	Commands    []Command      `json:"commands" gorm:"foreignKey:PluginID;references:PluginID"` // 插件命令
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	PluginID           string         `json:"plugin_id" gorm:"uniqueIndex;not null"`                   // 插件唯一标识
+	Name               string         `json:"name" gorm:"not null"`                                    // 插件名称
+	Version            string         `json:"version" gorm:"not null"`                                 // 插件版本
+	Author             string         `json:"author"`                                                  // 插件作者
+	Description        string         `json:"description"`                                             // 插件描述
+	Enabled            bool           `json:"enabled" gorm:"default:true"`                             // 是否启用
+	BackupPath         string         `json:"backup_path"`                                             // 备份路径
+	PendingPermissions string         `json:"pending_permissions" gorm:"type:text"`                    // 升级新增、尚未批准的权限，逗号分隔
+	I18n               string         `json:"i18n" gorm:"column:i18n;type:text"`                       // 清单中的本地化名称和描述，JSON
+	Tags               string         `json:"tags" gorm:"type:text"`                                   // 清单中规整后的标签，逗号分隔
+	Permissions        []Permission   `json:"permissions" gorm:"many2many:plugin_permissions;"`        // 插件权限
+	Commands           []Command      `json:"commands" gorm:"foreignKey:PluginID;references:PluginID"` // 插件命令
+	Labels             []PluginLabel  `json:"labels" gorm:"foreignKey:PluginID;references:PluginID"`   // 运维人员设置的标签
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 // Permission 权限模型
@@ -35,25 +39,36 @@ type Permission struct {
 
 // Command 命令模型
 type Command struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	CommandID   string         `json:"command_id" gorm:"not null"` // 命令标识
-	PluginID    string         `json:"plugin_id" gorm:"not null"`  // 所属插件ID
-	Title       string         `json:"title" gorm:"not null"`      // 命令标题
-	Description string         `json:"description"`                // 命令描述
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	CommandID        string         `json:"command_id" gorm:"not null"`      // 命令标识
+	PluginID         string         `json:"plugin_id" gorm:"not null"`       // 所属插件ID
+	Title            string         `json:"title" gorm:"not null"`           // 命令标题
+	Description      string         `json:"description"`                     // 命令描述
+	Category         string         `json:"category"`                        // 命令分类
+	Hotkey           string         `json:"hotkey"`                          // 快捷键
+	Source           string         `json:"source" gorm:"default:'runtime'"` // 命令来源：manifest 或 runtime
+	InvokePermission string         `json:"invoke_permission"`               // 其他插件调用该命令需要的权限，为空时不限制
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
+// 命令来源
+const (
+	CommandSourceManifest = "manifest"
+	CommandSourceRuntime  = "runtime"
+)
+
 // PluginInstallation 插件安装记录
 type PluginInstallation struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	PluginID    string         `json:"plugin_id" gorm:"not null"`       // 插件ID
-	Status      string         `json:"status" gorm:"default:'pending'"` // 安装状态：pending, installing, installed, failed
+	Status      string         `json:"status" gorm:"default:'pending'"` // 安装状态：pending, installing, installed, failed, dead
 	Progress    int            `json:"progress" gorm:"default:0"`       // 安装进度 0-100
 	Message     string         `json:"message"`                         // 状态消息
 	SourceURL   string         `json:"source_url"`                      // 安装源URL
 	SHA256      string         `json:"sha256"`                          // 文件校验和
+	Attempts    int            `json:"attempts" gorm:"default:0"`       // 重试次数
 	InstalledAt *time.Time     `json:"installed_at"`                    // 安装完成时间
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
@@ -63,16 +78,71 @@ type PluginInstallation struct {
 // VaultFile 存储库文件模型（用于插件访问用户文件）
 type VaultFile struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	Path      string         `json:"path" gorm:"uniqueIndex;not null"` // 文件相对路径
-	Content   []byte         `json:"content"`                          // 文件内容
-	MimeType  string         `json:"mime_type"`                        // 文件类型
-	Size      int64          `json:"size"`                             // 文件大小
-	UserID    uint           `json:"user_id" gorm:"not null"`          // 所属用户ID
+	Path      string         `json:"path" gorm:"uniqueIndex:idx_vault_files_user_path,priority:2;not null"`    // 文件相对路径，同一用户内唯一
+	Content   []byte         `json:"content"`                                                                  // 文件内容（旧数据，新写入存放在 vault_blobs）
+	BlobHash  string         `json:"blob_hash" gorm:"index"`                                                   // 内容 SHA256，引用 vault_blobs
+	MimeType  string         `json:"mime_type"`                                                                // 文件类型
+	Size      int64          `json:"size"`                                                                     // 文件大小
+	UserID    uint           `json:"user_id" gorm:"uniqueIndex:idx_vault_files_user_path,priority:1;not null"` // 所属用户ID
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
+// VaultBlob 按内容寻址的存储库数据块，相同内容只存一份
+type VaultBlob struct {
+	Hash      string    `json:"hash" gorm:"primaryKey;size:64"` // 内容 SHA256（十六进制）
+	Content   []byte    `json:"content"`                        // 数据内容
+	Size      int64     `json:"size"`                           // 数据大小
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserQuota 用户存储库配额
+type UserQuota struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"uniqueIndex;not null"` // 用户ID
+	QuotaBytes int64          `json:"quota_bytes" gorm:"not null"`         // 配额（字节），0表示不限制
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// AuditLog 特权操作审计日志（只追加）
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Action    string    `json:"action" gorm:"index;not null"` // 操作类型，如 plugin.install
+	Actor     string    `json:"actor"`                        // 操作者
+	Target    string    `json:"target"`                       // 操作对象
+	Meta      string    `json:"meta" gorm:"type:text"`        // 附加信息（JSON）
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// EventLog 已广播事件的历史记录，ID 即事件序号
+type EventLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"index;size:100;not null"` // 事件类型
+	Data      string    `json:"data" gorm:"type:text"`               // 事件数据（JSON）
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// PluginKV 插件的键值存储，按插件ID隔离
+type PluginKV struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PluginID  string    `json:"plugin_id" gorm:"uniqueIndex:idx_plugin_kv_plugin_key,priority:1;size:100;not null"` // 所属插件
+	Key       string    `json:"key" gorm:"uniqueIndex:idx_plugin_kv_plugin_key,priority:2;size:128;not null"`       // 键
+	Value     string    `json:"value" gorm:"type:text"`                                                             // 值（JSON）
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PluginLabel 运维人员为已安装插件设置的标签，如 env:prod，与作者在清单中声明的 tags 相互独立
+type PluginLabel struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PluginID  string    `json:"plugin_id" gorm:"uniqueIndex:idx_plugin_labels_plugin_label,priority:1;size:100;not null"`  // 所属插件
+	Label     string    `json:"label" gorm:"uniqueIndex:idx_plugin_labels_plugin_label,priority:2;index;size:64;not null"` // 标签
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // TableName 设置表名
 func (Plugin) TableName() string {
 	return "plugins"
@@ -93,3 +163,27 @@ func (PluginInstallation) TableName() string {
 func (VaultFile) TableName() string {
 	return "vault_files"
 }
+
+func (VaultBlob) TableName() string {
+	return "vault_blobs"
+}
+
+func (UserQuota) TableName() string {
+	return "user_quotas"
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+func (EventLog) TableName() string {
+	return "events"
+}
+
+func (PluginKV) TableName() string {
+	return "plugin_kv"
+}
+
+func (PluginLabel) TableName() string {
+	return "plugin_labels"
+}
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// rpcResultField 依次取出 RPC 结果数组中每一项的 field 字段，以逗号连接
+func rpcResultField(t *testing.T, result interface{}, field string) string {
+	t.Helper()
+	items, ok := result.([]interface{})
+	if !ok {
+		t.Fatalf("result = %#v, want array", result)
+	}
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = fmt.Sprint(item.(map[string]interface{})[field])
+	}
+	return strings.Join(values, ",")
+}
+
+// setupOrdering 以打乱的顺序创建插件和命令
+func setupOrdering(t *testing.T, repo Repository) {
+	t.Helper()
+	for _, p := range []*Plugin{
+		{PluginID: "delta", Name: "Alpha"}, {PluginID: "bravo", Name: "Zulu"}, {PluginID: "alpha", Name: "Mike"},
+		{PluginID: "charlie", Name: "Alpha"}, {PluginID: "echo", Name: "Kilo"},
+	} {
+		p.Version = "1.0.0"
+		if err := repo.CreatePlugin(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, cmd := range []*Command{
+		{CommandID: "bravo.zoom", PluginID: "bravo"}, {CommandID: "alpha.open", PluginID: "alpha"},
+		{CommandID: "bravo.close", PluginID: "bravo"}, {CommandID: "alpha.copy", PluginID: "alpha"},
+	} {
+		if err := repo.CreateCommand(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetPluginsStableOrder(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	setupOrdering(t, repo)
+	h := &Handler{service: s}
+
+	for i := 0; i < 20; i++ {
+		_, resp := callTestRPC(t, h, "alpha", "host.getPlugins", nil)
+		if got := rpcResultField(t, resp.Result, "plugin_id"); got != "alpha,bravo,charlie,delta,echo" {
+			t.Fatalf("call %d: order = %s", i, got)
+		}
+		// 名称相同时按插件ID排序
+		_, resp = callTestRPC(t, h, "alpha", "host.getPlugins", map[string]string{"sort": PluginSortName})
+		if got := rpcResultField(t, resp.Result, "plugin_id"); got != "charlie,delta,echo,alpha,bravo" {
+			t.Fatalf("call %d: name order = %s", i, got)
+		}
+	}
+	_, resp := callTestRPC(t, h, "alpha", "host.getPlugins", map[string]string{"sort": PluginSortID})
+	if got := rpcResultField(t, resp.Result, "plugin_id"); got != "alpha,bravo,charlie,delta,echo" {
+		t.Fatalf("sort=plugin_id order = %s", got)
+	}
+	if _, resp := callTestRPC(t, h, "alpha", "host.getPlugins", map[string]string{"sort": "version"}); resp.Error == nil || resp.Error.Code != 400 {
+		t.Fatalf("invalid sort: %+v", resp.Error)
+	}
+}
+
+func TestGetAllCommandsStableOrder(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	setupOrdering(t, repo)
+	h := &Handler{service: s}
+
+	for i := 0; i < 20; i++ {
+		_, resp := callTestRPC(t, h, "alpha", "commands.list", nil)
+		if got := rpcResultField(t, resp.Result, "command_id"); got != "alpha.copy,alpha.open,bravo.close,bravo.zoom" {
+			t.Fatalf("call %d: order = %s", i, got)
+		}
+	}
+}
+
+func TestRepositoryOrdersInSQL(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRepository(db)
+	if _, err := repo.GetAllPlugins(&PluginQuery{Sort: PluginSortName}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetAllCommands(); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) < 2 {
+		t.Fatalf("queries = %q", queries)
+	}
+	// 名称排序以插件ID作为第二排序键
+	if !strings.HasSuffix(queries[0], "ORDER BY name,plugin_id") {
+		t.Errorf("GetAllPlugins SQL = %q", queries[0])
+	}
+	if !strings.HasSuffix(queries[len(queries)-1], "ORDER BY plugin_id,command_id") {
+		t.Errorf("GetAllCommands SQL = %q", queries[len(queries)-1])
+	}
+}
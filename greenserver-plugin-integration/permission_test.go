@@ -0,0 +1,121 @@
+package plugin
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestCheckPermissionRPC(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "reader", "admin", "none")
+	if err := repo.AddPluginPermission("reader", "vault.read"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddPluginPermission("admin", "*"); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{service: s}
+
+	cases := []struct {
+		pluginID, permission string
+		granted              bool
+	}{
+		{"reader", "vault.read", true},
+		{"reader", "vault.write", false},
+		{"admin", "vault.write", true},
+		{"none", "vault.read", false},
+		{"missing", "vault.read", false},
+	}
+	for _, tc := range cases {
+		code, resp := callTestRPC(t, h, "none", "host.checkPermission", map[string]interface{}{
+			"pluginId":   tc.pluginID,
+			"permission": tc.permission,
+		})
+		if code != 200 || resp.Error != nil {
+			t.Fatalf("%s/%s: got %d %+v", tc.pluginID, tc.permission, code, resp.Error)
+		}
+		if got := resp.Result.(map[string]interface{})["granted"]; got != tc.granted {
+			t.Errorf("%s/%s: granted = %v, want %v", tc.pluginID, tc.permission, got, tc.granted)
+		}
+	}
+
+	if code, _ := callTestRPC(t, h, "none", "host.checkPermission", map[string]interface{}{"pluginId": "reader"}); code != 400 {
+		t.Errorf("missing permission param: got %d, want 400", code)
+	}
+}
+
+func TestGetAllPermissionsRPC(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "writer", "reader", "none")
+	for _, grant := range [][2]string{{"writer", "vault.write"}, {"writer", "commands.register"}, {"reader", "vault.read"}} {
+		if err := repo.AddPluginPermission(grant[0], grant[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := &Handler{service: s}
+
+	code, resp := callTestRPC(t, h, "", "host.getAllPermissions", nil)
+	if code != 200 || resp.Error != nil {
+		t.Fatalf("host.getAllPermissions: got %d %+v", code, resp.Error)
+	}
+	all := resp.Result.(map[string]interface{})
+	if len(all) != 3 {
+		t.Fatalf("result = %v, want every plugin", all)
+	}
+	// 与逐个插件查询的结果一致（按名称排序），没有权限的插件为空列表
+	for _, id := range []string{"writer", "reader", "none"} {
+		perms, err := s.GetPluginPermissions(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(perms)
+		items, ok := all[id].([]interface{})
+		if !ok {
+			t.Fatalf("%s permissions = %#v, want a list", id, all[id])
+		}
+		got := make([]string, len(items))
+		for i, item := range items {
+			got[i] = item.(string)
+		}
+		if strings.Join(got, ",") != strings.Join(perms, ",") {
+			t.Errorf("%s permissions = %v, want %v", id, got, perms)
+		}
+	}
+}
+
+func TestGetAllPluginPermissionsSingleQuery(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	capture := func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	// DryRun 不支持读取结果行，语句已经生成
+	if _, err := NewRepository(db).GetAllPluginPermissions(); err != nil && !errors.Is(err, gorm.ErrDryRunModeUnsupported) {
+		t.Fatal(err)
+	}
+	// 一次关联查询取出全部插件的权限，而不是每个插件各自预加载
+	if len(queries) != 1 {
+		t.Fatalf("queries = %q, want exactly one", queries)
+	}
+	for _, part := range []string{"FROM `plugins`", "LEFT JOIN plugin_permissions", "LEFT JOIN permissions", "ORDER BY plugins.plugin_id, permissions.name"} {
+		if !strings.Contains(queries[0], part) {
+			t.Errorf("query %q does not contain %q", queries[0], part)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// manifestPermissions 返回清单声明的权限
+func manifestPermissions(manifest map[string]interface{}) []string {
+	var perms []string
+	if permissions, ok := manifest["permissions"].([]interface{}); ok {
+		for _, perm := range permissions {
+			if permStr, ok := perm.(string); ok {
+				perms = append(perms, permStr)
+			}
+		}
+	}
+	return perms
+}
+
+// addedPermissions 返回 next 中不在 granted 里的权限，已拥有 * 时不视为新增
+func addedPermissions(granted, next []string) []string {
+	has := make(map[string]bool, len(granted))
+	for _, perm := range granted {
+		has[perm] = true
+	}
+	if has["*"] {
+		return nil
+	}
+	var added []string
+	for _, perm := range next {
+		if !has[perm] {
+			has[perm] = true
+			added = append(added, perm)
+		}
+	}
+	return added
+}
+
+// splitPendingPermissions 解析插件记录中逗号分隔的待批准权限
+func splitPendingPermissions(pending string) []string {
+	if pending == "" {
+		return nil
+	}
+	return strings.Split(pending, ",")
+}
+
+// ApprovePermissions 批准插件升级时新增的权限，逐项授予并写入审计日志，返回本次批准的权限。
+// 插件未安装时返回 ErrPluginNotFound
+func (s *ServiceImpl) ApprovePermissions(pluginID, actor string) ([]string, error) {
+	plugin, err := s.repo.GetPluginByID(pluginID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPluginNotFound
+		}
+		return nil, err
+	}
+	approved := splitPendingPermissions(plugin.PendingPermissions)
+	if len(approved) == 0 {
+		return []string{}, nil
+	}
+
+	for _, perm := range approved {
+		if err := s.repo.AddPluginPermission(pluginID, perm); err != nil {
+			return nil, err
+		}
+		s.Audit("permission.grant", actor, pluginID, map[string]interface{}{"permission": perm})
+	}
+	plugin.PendingPermissions = ""
+	if err := s.repo.UpdatePlugin(plugin); err != nil {
+		return nil, err
+	}
+
+	s.Broadcast(&EventData{
+		Type: "plugin.permissions.approved",
+		Data: map[string]interface{}{
+			"pluginId":    pluginID,
+			"permissions": approved,
+		},
+	})
+	return approved, nil
+}
@@ -0,0 +1,123 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAddedPermissions(t *testing.T) {
+	cases := []struct {
+		granted, next []string
+		want          string
+	}{
+		{nil, []string{"vault.read"}, "vault.read"},
+		{[]string{"vault.read"}, []string{"vault.read", "vault.write", "vault.write"}, "vault.write"},
+		{[]string{"vault.read", "vault.write"}, []string{"vault.read"}, ""},
+		{[]string{"*"}, []string{"vault.write"}, ""},
+	}
+	for _, tc := range cases {
+		if got := strings.Join(addedPermissions(tc.granted, tc.next), ","); got != tc.want {
+			t.Errorf("addedPermissions(%v, %v) = %s, want %s", tc.granted, tc.next, got, tc.want)
+		}
+	}
+}
+
+func TestUpgradeWithholdsAddedPermissions(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	installManifest(t, s, "demo", `{"id":"demo","name":"Demo","version":"1.0.0","permissions":["vault.read"]}`)
+	if p, _ := s.GetPlugin("demo"); len(p.PendingPermissions) != 0 {
+		t.Fatalf("fresh install pending = %v, want none", p.PendingPermissions)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+	installManifest(t, s, "demo", `{"id":"demo","name":"Demo","version":"2.0.0","permissions":["vault.read","vault.write"]}`)
+
+	if !s.HasPermission("demo", "vault.read") {
+		t.Error("permission granted before the upgrade was withdrawn")
+	}
+	if s.HasPermission("demo", "vault.write") {
+		t.Error("permission added by the upgrade was granted before approval")
+	}
+	if p, _ := s.GetPlugin("demo"); strings.Join(p.PendingPermissions, ",") != "vault.write" || p.Version != "2.0.0" {
+		t.Errorf("plugin = %s pending %v, want 2.0.0 pending [vault.write]", p.Version, p.PendingPermissions)
+	}
+	var changed map[string]interface{}
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == "plugin.permissions.changed" {
+			changed = ev.Data.(map[string]interface{})
+		}
+	}
+	if added, _ := changed["added"].([]string); changed["requiresApproval"] != true || strings.Join(added, ",") != "vault.write" {
+		t.Errorf("plugin.permissions.changed = %v, want added [vault.write]", changed)
+	}
+
+	// 升级没有新增权限时清空待批准列表
+	installManifest(t, s, "demo", `{"id":"demo","name":"Demo","version":"2.0.1","permissions":["vault.read"]}`)
+	if p, _ := s.GetPlugin("demo"); len(p.PendingPermissions) != 0 {
+		t.Errorf("pending after an upgrade without new permissions = %v", p.PendingPermissions)
+	}
+}
+
+func TestApprovePermissions(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	installManifest(t, s, "demo", `{"id":"demo","name":"Demo","version":"1.0.0","permissions":["vault.read"]}`)
+	installManifest(t, s, "demo", `{"id":"demo","name":"Demo","version":"2.0.0","permissions":["vault.read","vault.write"]}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+
+	if code, _ := callTestRPC(t, &Handler{service: s}, "demo", "host.approvePermissions", map[string]string{"pluginId": "demo"}); code != 403 {
+		t.Errorf("non-admin host.approvePermissions: status = %d, want 403", code)
+	}
+	if _, err := s.ApprovePermissions("missing", "user:1"); !errors.Is(err, ErrPluginNotFound) {
+		t.Errorf("approve unknown plugin: err = %v, want ErrPluginNotFound", err)
+	}
+
+	approved, err := s.ApprovePermissions("demo", "user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(approved, ",") != "vault.write" {
+		t.Errorf("approved = %v, want [vault.write]", approved)
+	}
+	if !s.HasPermission("demo", "vault.write") {
+		t.Error("approved permission is not granted")
+	}
+	if p, _ := s.GetPlugin("demo"); len(p.PendingPermissions) != 0 {
+		t.Errorf("pending after approval = %v", p.PendingPermissions)
+	}
+	logs, err := s.GetAuditLogs(&AuditQuery{Action: "permission.grant"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var granted int
+	for _, log := range logs {
+		if log.Actor == "user:1" && log.Target == "demo" {
+			granted++
+			if log.Meta["permission"] != "vault.write" {
+				t.Errorf("permission.grant meta = %v, want vault.write", log.Meta)
+			}
+		}
+	}
+	if granted != 1 {
+		t.Errorf("got %d permission.grant audit entries by user:1, want 1", granted)
+	}
+	var approvedEvent bool
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == "plugin.permissions.approved" {
+			approvedEvent = true
+		}
+	}
+	if !approvedEvent {
+		t.Error("no plugin.permissions.approved event")
+	}
+
+	// 没有待批准的权限时返回空列表
+	if approved, err := s.ApprovePermissions("demo", "user:1"); err != nil || len(approved) != 0 {
+		t.Errorf("second approve = %v, %v, want nothing approved", approved, err)
+	}
+}
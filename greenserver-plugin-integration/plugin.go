@@ -12,10 +12,11 @@ func RegisterPluginRoutes(v1 *gin.RouterGroup, pluginHandler *plugin.Handler, pl
 	pluginGroup := v1.Group("/plugins")
 
 	// 公共路由（不需要认证）
-	pluginGroup.GET("", pluginHandler.GetPlugins)            // 获取所有插件
-	pluginGroup.GET("/:id", pluginHandler.GetPlugin)         // 获取单个插件
-	pluginGroup.GET("/market", pluginHandler.GetMarketItems) // 获取市场插件
-	pluginGroup.GET("/commands", pluginHandler.GetCommands)  // 获取所有命令
+	pluginGroup.GET("", pluginHandler.GetPlugins)                  // 获取所有插件
+	pluginGroup.GET("/:id", pluginHandler.GetPlugin)               // 获取单个插件
+	pluginGroup.GET("/market", pluginHandler.GetMarketItems)       // 获取市场插件
+	pluginGroup.GET("/market/stats", pluginHandler.GetMarketStats) // 获取市场统计
+	pluginGroup.GET("/commands", pluginHandler.GetCommands)        // 获取所有命令
 
 	// RPC和事件路由（支持跨域）
 	pluginGroup.POST("/rpc", pluginHandler.HandleRPC)   // JSON-RPC API
@@ -23,19 +24,36 @@ func RegisterPluginRoutes(v1 *gin.RouterGroup, pluginHandler *plugin.Handler, pl
 
 	// 静态资源服务
 	pluginGroup.GET("/assets/:pluginID/*filepath", pluginHandler.ServePluginAssets)
+	pluginGroup.GET("/:id/_host/icon", pluginHandler.ServePluginIcon) // 插件图标
 
 	// 需要认证的路由
 	authGroup := pluginGroup.Group("")
 	authGroup.Use(middleware.CombinedAuth(nil)) // 支持JWT和API Key认证
 	{
 		// 插件管理
-		authGroup.POST("/install", pluginHandler.InstallPlugin) // 安装插件
-		authGroup.DELETE("/:id", pluginHandler.UninstallPlugin) // 卸载插件
-		authGroup.POST("/enable", pluginHandler.EnablePlugin)   // 启用插件
-		authGroup.POST("/disable", pluginHandler.DisablePlugin) // 禁用插件
-		authGroup.POST("/backup", pluginHandler.BackupPlugin)   // 备份插件
+		authGroup.POST("/install", pluginHandler.InstallPlugin)      // 安装插件
+		authGroup.DELETE("/:id", pluginHandler.UninstallPlugin)      // 卸载插件
+		authGroup.POST("/enable", pluginHandler.EnablePlugin)        // 启用插件
+		authGroup.POST("/disable", pluginHandler.DisablePlugin)      // 禁用插件
+		authGroup.POST("/backup", pluginHandler.BackupPlugin)        // 备份插件
+		authGroup.POST("/reconcile", pluginHandler.ReconcilePlugins) // 按磁盘内容校正插件记录（仅管理员）
+
+		// 运维标签
+		authGroup.POST("/:id/labels", pluginHandler.AddPluginLabel)             // 添加标签
+		authGroup.DELETE("/:id/labels/:label", pluginHandler.RemovePluginLabel) // 删除标签
 
 		// 安装状态
 		authGroup.GET("/:id/installation-status", pluginHandler.GetInstallationStatus) // 获取安装状态
+
+		// 备份下载
+		authGroup.GET("/:id/_host/backup", pluginHandler.DownloadPluginBackup) // 流式下载插件备份
+
+		// 存储库导入
+		authGroup.POST("/vault/import", pluginHandler.ImportVault) // 批量导入笔记
+		authGroup.GET("/vault/export", pluginHandler.ExportVault)  // 导出存储库
+
+		// 审计日志与事件历史（仅管理员）
+		authGroup.GET("/audit", pluginHandler.GetAuditLogs)             // 查询审计日志
+		authGroup.GET("/events/history", pluginHandler.GetEventHistory) // 查询事件历史
 	}
 }
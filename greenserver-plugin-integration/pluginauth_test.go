@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthorizeTargetPlugin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	cases := []struct {
+		name   string
+		admin  bool
+		req    RPCRequest
+		target string
+		want   string
+		ok     bool
+	}{
+		{"admin any plugin", true, RPCRequest{}, "beta", "beta", true},
+		{"authenticated self", false, RPCRequest{PluginID: "alpha", authenticated: true}, "alpha", "alpha", true},
+		{"authenticated default self", false, RPCRequest{PluginID: "alpha", authenticated: true}, "", "alpha", true},
+		{"authenticated other", false, RPCRequest{PluginID: "alpha", authenticated: true}, "beta", "", false},
+		{"claimed only", false, RPCRequest{PluginID: "alpha"}, "alpha", "", false},
+		{"anonymous", false, RPCRequest{}, "", "", false},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		if tc.admin {
+			c.Set("role", "admin")
+		}
+		got, ok := h.authorizeTargetPlugin(c, tc.req, tc.target)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestResolvePluginIDWithoutKeys(t *testing.T) {
+	s := &ServiceImpl{}
+	// 未配置凭据时沿用声明的ID，但携带凭据的请求无法对应任何插件
+	if id, err := s.ResolvePluginID("", "alpha"); err != nil || id != "alpha" {
+		t.Errorf("claimed id = (%q, %v), want alpha", id, err)
+	}
+	if _, err := s.ResolvePluginID("forged", "alpha"); err != ErrInvalidPluginKey {
+		t.Errorf("unknown key err = %v, want ErrInvalidPluginKey", err)
+	}
+}
+
+func TestCannotRegisterCommandsAsAnotherPlugin(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{
+		PluginKeys: map[string]string{"alpha": "key-a", "beta": "key-b"},
+	}).(*ServiceImpl)
+	createTestPlugins(t, repo, "alpha", "beta")
+	for _, id := range []string{"alpha", "beta"} {
+		if err := repo.AddPluginPermission(id, "commands.register"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := &Handler{service: s}
+
+	register := func(key, claimed string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"id":"1","method":"commands.register","pluginId":"` + claimed + `","params":{"id":"beta.hijack","title":"Hijack"}}`
+		c.Request = httptest.NewRequest("POST", "/rpc", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			c.Request.Header.Set("X-Plugin-Key", key)
+		}
+		h.HandleRPC(c)
+		return w.Code
+	}
+
+	if code := register("key-a", "beta"); code != 403 {
+		t.Errorf("claiming another plugin's ID with a credential: got %d, want 403", code)
+	}
+	if code := register("", "beta"); code != 401 {
+		t.Errorf("claiming a plugin ID without a credential: got %d, want 401", code)
+	}
+	if cmds, _ := repo.GetCommandsByPluginID("beta"); len(cmds) != 0 {
+		t.Fatalf("command registered under beta: %+v", cmds)
+	}
+
+	// 不声明 pluginId 时以凭据确定身份
+	if code := register("key-a", ""); code != 200 {
+		t.Fatalf("register with credential: got %d", code)
+	}
+	if cmds, _ := repo.GetCommandsByPluginID("alpha"); len(cmds) != 1 {
+		t.Errorf("alpha has %d commands, want 1", len(cmds))
+	}
+}
@@ -0,0 +1,91 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePluginFixture 在插件目录下写入测试文件
+func writePluginFixture(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// filePaths 返回文件列表中的路径，以逗号连接
+func filePaths(files []*PluginFileResponse) string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return strings.Join(paths, ",")
+}
+
+func TestListPluginFiles(t *testing.T) {
+	repo := NewInMemoryRepository()
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(repo, pluginsDir, t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "demo")
+	dir := filepath.Join(pluginsDir, "demo")
+	writePluginFixture(t, dir, map[string]string{
+		"manifest.json":     `{"id":"demo"}`,
+		"dist/main.js":      "console.log(1)",
+		"dist/lib/util.js":  "export {}",
+		"assets/icon.png":   "png",
+		"assets/styles.css": "body{}",
+	})
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	writePluginFixture(t, outside, map[string]string{"secret.txt": "top secret"})
+
+	files, err := s.ListPluginFiles("demo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 符号链接只列出自身，不进入其指向的目录
+	if got := filePaths(files); got != "assets/icon.png,assets/styles.css,dist/lib/util.js,dist/main.js,link,manifest.json" {
+		t.Fatalf("paths = %s", got)
+	}
+	for _, f := range files {
+		if f.Path == "dist/main.js" && (f.Size != int64(len("console.log(1)")) || !strings.HasPrefix(f.Mode, "-rw")) {
+			t.Errorf("main.js = %+v", f)
+		}
+	}
+
+	if files, _ = s.ListPluginFiles("demo", "*.js"); filePaths(files) != "dist/lib/util.js,dist/main.js" {
+		t.Errorf("glob *.js = %s", filePaths(files))
+	}
+	if files, _ = s.ListPluginFiles("demo", "assets/*"); filePaths(files) != "assets/icon.png,assets/styles.css" {
+		t.Errorf("glob assets/* = %s", filePaths(files))
+	}
+}
+
+func TestListPluginFilesErrors(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "demo")
+
+	if _, err := s.ListPluginFiles("missing", ""); !errors.Is(err, ErrPluginNotFound) {
+		t.Errorf("missing plugin: err = %v, want ErrPluginNotFound", err)
+	}
+	if _, err := s.ListPluginFiles("demo", "["); err == nil {
+		t.Error("invalid glob was accepted")
+	}
+
+	h := &Handler{service: s}
+	if code, _ := callTestRPC(t, h, "demo", "host.listPluginFiles", map[string]string{"pluginId": "demo"}); code != 403 {
+		t.Errorf("non-admin: status = %d, want 403", code)
+	}
+}
@@ -0,0 +1,86 @@
+package plugin
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPluginIcon 插件未声明图标或图标文件缺失时返回的默认图标
+const defaultPluginIcon = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 64 64"><rect width="64" height="64" rx="12" fill="#8c8c8c"/><path d="M26 14h12v8a4 4 0 0 0 8 0v-2h4v12h-2a4 4 0 0 0 0 8h2v12H38v-2a4 4 0 0 0-8 0v2H14V38h2a4 4 0 0 0 0-8h-2V20h12z" fill="#fff"/></svg>`
+
+// iconContentTypes 允许作为插件图标的文件扩展名及其内容类型
+var iconContentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+}
+
+// resolvePluginIcon 返回清单 icon 指向的图标文件，解析符号链接后仍须位于插件目录内
+func resolvePluginIcon(pluginDir string, manifest map[string]interface{}) (string, bool) {
+	icon, _ := manifest["icon"].(string)
+	p := filepath.ToSlash(icon)
+	if p == "" || strings.HasPrefix(p, "/") {
+		return "", false
+	}
+	if _, ok := iconContentTypes[strings.ToLower(path.Ext(p))]; !ok {
+		return "", false
+	}
+	dir, err := filepath.EvalSymlinks(pluginDir)
+	if err != nil {
+		return "", false
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(dir, filepath.FromSlash(path.Clean("/"+p))))
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return target, true
+}
+
+// ServePluginIcon 获取插件图标
+// @Summary 获取插件图标
+// @Description 返回插件清单 icon 声明的图标，未声明或文件缺失时返回默认图标
+// @Tags 插件
+// @Produce image/png,image/svg+xml
+// @Param id path string true "插件ID"
+// @Success 200 {file} file
+// @Router /plugins/{id}/_host/icon [get]
+func (h *Handler) ServePluginIcon(c *gin.Context) {
+	pluginID := c.Param("id")
+	if _, err := h.service.GetPlugin(pluginID); err != nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	// 图标可能是 SVG，禁止浏览器嗅探类型和执行其中的脚本
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+
+	pluginDir := filepath.Join(h.pluginsDir, pluginID)
+	if manifest, err := readManifestFile(filepath.Join(pluginDir, "manifest.json")); err == nil {
+		if target, ok := resolvePluginIcon(pluginDir, manifest); ok {
+			if f, err := os.Open(target); err == nil {
+				defer f.Close()
+				if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+					c.Header("Content-Type", iconContentTypes[strings.ToLower(filepath.Ext(target))])
+					http.ServeContent(c.Writer, c.Request, "", info.ModTime(), f)
+					return
+				}
+			}
+		}
+	}
+	c.Header("Content-Type", iconContentTypes[".svg"])
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, strings.NewReader(defaultPluginIcon))
+}
@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveTestIcon 调用 ServePluginIcon 并返回响应
+func serveTestIcon(h *Handler, method, pluginID string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: pluginID}}
+	c.Request = httptest.NewRequest(method, "/plugins/"+pluginID+"/_host/icon", nil)
+	h.ServePluginIcon(c)
+	return w
+}
+
+func TestResolvePluginIcon(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "assets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "assets", "icon.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "secret.png")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "escape.png")); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		icon interface{}
+		ok   bool
+	}{
+		{"assets/icon.png", true},
+		{"./assets/icon.png", true},
+		{nil, false},
+		{"", false},
+		{"/assets/icon.png", false},
+		{"assets/icon.txt", false},
+		{"missing.png", false},
+		// 越界路径被限制在插件目录内，解析到不存在的文件
+		{"../secret.png", false},
+		{"escape.png", false},
+	}
+	for _, tc := range cases {
+		target, ok := resolvePluginIcon(dir, map[string]interface{}{"icon": tc.icon})
+		if ok != tc.ok {
+			t.Errorf("resolvePluginIcon(%v) = %q %v, want ok=%v", tc.icon, target, ok, tc.ok)
+		}
+	}
+}
+
+func TestServePluginIcon(t *testing.T) {
+	repo := NewInMemoryRepository()
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(repo, pluginsDir, t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	h := &Handler{service: s, pluginsDir: pluginsDir}
+	createTestPlugins(t, repo, "demo", "plain")
+	writeTestManifest(t, pluginsDir, "demo", `{"id":"demo","icon":"icon.png"}`)
+	writeTestManifest(t, pluginsDir, "plain", `{"id":"plain"}`)
+	png := "\x89PNG\r\n\x1a\nfake"
+	if err := os.WriteFile(filepath.Join(pluginsDir, "demo", "icon.png"), []byte(png), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serveTestIcon(h, http.MethodGet, "demo")
+	if w.Code != http.StatusOK || w.Body.String() != png {
+		t.Fatalf("declared icon = %d %q, want the png", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", ct)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("icon response missing nosniff header")
+	}
+
+	w = serveTestIcon(h, http.MethodGet, "plain")
+	if w.Code != http.StatusOK || w.Body.String() != defaultPluginIcon || w.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Errorf("undeclared icon = %d %q, want default svg", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	if w := serveTestIcon(h, http.MethodGet, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown plugin: status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrVaultWriteRejected 写入被存储库写入策略拒绝
+var ErrVaultWriteRejected = errors.New("vault write rejected")
+
+// VaultWritePolicy 存储库写入策略，在每次写入前调用，返回错误时拒绝写入
+type VaultWritePolicy interface {
+	Allow(path string, content []byte) error
+}
+
+// AllowAllPolicy 默认策略，允许所有写入
+type AllowAllPolicy struct{}
+
+func (AllowAllPolicy) Allow(string, []byte) error { return nil }
+
+// ExtensionBlocklistPolicy 拒绝写入指定扩展名的文件，扩展名不区分大小写，如 ".exe"
+type ExtensionBlocklistPolicy struct {
+	Extensions []string
+}
+
+func (p ExtensionBlocklistPolicy) Allow(path string, _ []byte) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return nil
+	}
+	for _, blocked := range p.Extensions {
+		if strings.ToLower(blocked) == ext {
+			return fmt.Errorf("%w: files with extension %s are not allowed", ErrVaultWriteRejected, ext)
+		}
+	}
+	return nil
+}
+
+// normalizeExtension 把 "md"、".MD" 这样的配置项规整为 ".md"
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// checkVaultExtension 按扩展名列表检查写入路径，禁止列表优先；两个列表都为空时允许所有扩展名。
+// 配置了允许列表时没有扩展名的文件同样被拒绝
+func checkVaultExtension(path string, allowed, blocked []string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != "" {
+		for _, b := range blocked {
+			if normalizeExtension(b) == ext {
+				return fmt.Errorf("%w: files with extension %s are not allowed", ErrVaultWriteRejected, ext)
+			}
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, a := range allowed {
+		if normalizeExtension(a) == ext {
+			return nil
+		}
+	}
+	if ext == "" {
+		return fmt.Errorf("%w: files without an extension are not allowed", ErrVaultWriteRejected)
+	}
+	return fmt.Errorf("%w: extension %s is not in the allowed list", ErrVaultWriteRejected, ext)
+}
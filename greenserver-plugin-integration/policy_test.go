@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtensionBlocklistPolicyBlocksExe(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{
+		VaultWritePolicy: ExtensionBlocklistPolicy{Extensions: []string{".exe"}},
+	}).(*ServiceImpl)
+
+	err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "tools/setup.EXE", Content: "MZ"})
+	if !errors.Is(err, ErrVaultWriteRejected) {
+		t.Fatalf("write .exe: err = %v, want ErrVaultWriteRejected", err)
+	}
+	if _, err := s.ReadVaultFile(1, "tools/setup.EXE"); err == nil {
+		t.Error("rejected file was written")
+	}
+
+	if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "notes/a.md", Content: "ok"}); err != nil {
+		t.Fatalf("write .md: %v", err)
+	}
+	// 复制同样经过策略检查
+	if err := s.CopyVaultFile(1, &VaultCopyRequest{From: "notes/a.md", To: "notes/a.exe"}); !errors.Is(err, ErrVaultWriteRejected) {
+		t.Errorf("copy to .exe: err = %v, want ErrVaultWriteRejected", err)
+	}
+}
+
+func TestCheckVaultExtensionLists(t *testing.T) {
+	cases := []struct {
+		path             string
+		allowed, blocked []string
+		ok               bool
+	}{
+		// 两个列表都为空时不限制
+		{"a.exe", nil, nil, true},
+		{"README", nil, nil, true},
+		{"a.md", []string{"md", ".PNG"}, nil, true},
+		{"img/a.png", []string{"md", ".PNG"}, nil, true},
+		{"a.pdf", []string{"md"}, nil, false},
+		{"README", []string{"md"}, nil, false},
+		// 禁止列表优先于允许列表
+		{"a.md", []string{".md"}, []string{".md"}, false},
+		{"a.sh", nil, []string{" .SH "}, false},
+	}
+	for _, c := range cases {
+		err := checkVaultExtension(c.path, c.allowed, c.blocked)
+		if (err == nil) != c.ok {
+			t.Errorf("checkVaultExtension(%q, %v, %v) = %v, want ok=%v", c.path, c.allowed, c.blocked, err, c.ok)
+		}
+		if err != nil && !errors.Is(err, ErrVaultWriteRejected) {
+			t.Errorf("%q: %v is not ErrVaultWriteRejected", c.path, err)
+		}
+	}
+}
+
+func TestVaultExtensionOptions(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{
+		AllowedVaultExtensions: []string{".md", ".png"},
+		BlockedVaultExtensions: []string{".png"},
+	}).(*ServiceImpl)
+
+	if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "notes/a.md", Content: "ok"}); err != nil {
+		t.Fatalf("write .md: %v", err)
+	}
+	for _, p := range []string{"bin/tool.exe", "img/a.png"} {
+		err := s.WriteVaultFile(1, &VaultWriteRequest{Path: p, Content: "x"})
+		if !errors.Is(err, ErrVaultWriteRejected) || !strings.Contains(err.Error(), filepath.Ext(p)) {
+			t.Errorf("write %s: err = %v", p, err)
+		}
+	}
+	if err := s.CopyVaultFile(1, &VaultCopyRequest{From: "notes/a.md", To: "notes/a.exe"}); !errors.Is(err, ErrVaultWriteRejected) {
+		t.Errorf("copy to .exe: err = %v", err)
+	}
+
+	// 导入时跳过不允许的扩展名
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"b.md", "c.exe"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.ImportVault(1, "", zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != "c.exe" {
+		t.Errorf("skipped = %+v, want c.exe", result.Skipped)
+	}
+	if _, err := s.ReadVaultFile(1, "b.md"); err != nil {
+		t.Errorf("allowed entry not imported: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// callTestRPC 以插件身份调用 HandleRPC，返回状态码和解码后的响应
+func callTestRPC(t *testing.T, h *Handler, pluginID, method string, params interface{}) (int, RPCResponse) {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"id": "1", "method": method, "pluginId": pluginID, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/rpc", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.HandleRPC(c)
+	var resp RPCResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: decode response %q: %v", method, w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func TestPublishEventReachesSubscriber(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "pub", "quiet")
+	if err := repo.AddPluginPermission("pub", "events.publish"); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{service: s}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+
+	if code, _ := callTestRPC(t, h, "quiet", "events.publish", map[string]interface{}{"name": "x"}); code != 403 {
+		t.Errorf("publish without permission: got %d, want 403", code)
+	}
+	big := strings.Repeat("x", maxEventPayloadBytes)
+	if code, _ := callTestRPC(t, h, "pub", "events.publish", map[string]interface{}{"name": "big", "payload": big}); code != 413 {
+		t.Errorf("oversized payload: got %d, want 413", code)
+	}
+	if code, _ := callTestRPC(t, h, "pub", "events.publish", map[string]interface{}{
+		"name":    "note.synced",
+		"payload": map[string]interface{}{"count": 3},
+	}); code != 200 {
+		t.Fatalf("events.publish: got %d", code)
+	}
+
+	// 被拒绝的发布不会广播，订阅者收到的第一条即为成功发布的事件
+	select {
+	case ev := <-events:
+		if ev.Type != "plugin:pub:note.synced" {
+			t.Fatalf("event type = %q", ev.Type)
+		}
+		data := ev.Data.(map[string]interface{})
+		if data["pluginId"] != "pub" {
+			t.Errorf("pluginId = %v", data["pluginId"])
+		}
+		if payload, _ := json.Marshal(data["payload"]); string(payload) != `{"count":3}` {
+			t.Errorf("payload = %s", payload)
+		}
+	default:
+		t.Fatal("subscriber received no event")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected extra event %q", ev.Type)
+	default:
+	}
+}
@@ -0,0 +1,36 @@
+package plugin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVaultQuota(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	if err := repo.SetUserQuota(1, 100); err != nil {
+		t.Fatal(err)
+	}
+	write := func(user uint, path string, size int) error {
+		return s.WriteVaultFile(user, &VaultWriteRequest{Path: path, Content: strings.Repeat("x", size)})
+	}
+
+	if err := write(1, "a.md", 60); err != nil {
+		t.Fatalf("write within quota: %v", err)
+	}
+	if err := write(1, "b.md", 50); !errors.Is(err, ErrVaultQuotaExceeded) {
+		t.Fatalf("write exceeding quota: err = %v, want ErrVaultQuotaExceeded", err)
+	}
+	// 覆盖已有文件只计算增量
+	if err := write(1, "a.md", 90); err != nil {
+		t.Fatalf("overwrite within quota: %v", err)
+	}
+	if err := write(1, "c.md", 20); !errors.Is(err, ErrVaultQuotaExceeded) {
+		t.Fatalf("write after overwrite: err = %v, want ErrVaultQuotaExceeded", err)
+	}
+	// 配额按用户计算，没有配额记录的用户不受限制
+	if err := write(2, "big.md", 1000); err != nil {
+		t.Fatalf("user without quota: %v", err)
+	}
+}
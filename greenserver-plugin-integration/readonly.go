@@ -0,0 +1,51 @@
+package plugin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lgnixai/wmcms/pkg/response"
+)
+
+// readOnlyMessage 只读模式下拒绝写操作时返回的错误信息
+const readOnlyMessage = "host is read-only"
+
+// mutatingRPCMethods 会修改插件、存储库或持久化数据的RPC方法，只读模式下拒绝
+var mutatingRPCMethods = map[string]bool{
+	"vault.write":             true,
+	"vault.copy":              true,
+	"vault.delete":            true,
+	"kv.set":                  true,
+	"kv.delete":               true,
+	"host.enablePlugin":       true,
+	"host.disablePlugin":      true,
+	"host.batchSetEnabled":    true,
+	"host.batchUninstall":     true,
+	"host.approvePermissions": true,
+	"host.retryInstallation":  true,
+}
+
+// IsReadOnly 返回服务当前是否处于只读模式
+func (s *ServiceImpl) IsReadOnly() bool {
+	return s.readOnly.Load()
+}
+
+// SetReadOnly 切换只读模式，状态变化时广播 host.readonly.changed
+func (s *ServiceImpl) SetReadOnly(readOnly bool) {
+	if s.readOnly.Swap(readOnly) == readOnly {
+		return
+	}
+	s.Broadcast(&EventData{
+		Type: "host.readonly.changed",
+		Data: map[string]interface{}{"readOnly": readOnly},
+	})
+}
+
+// rejectReadOnly 只读模式下对写操作返回 503，返回 true 表示请求已被拒绝
+func (h *Handler) rejectReadOnly(c *gin.Context) bool {
+	if !h.service.IsReadOnly() {
+		return false
+	}
+	response.Error(c, http.StatusServiceUnavailable, "系统处于只读模式")
+	return true
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReadOnlyRejectsWriteRPCs(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{ReadOnly: true}).(*ServiceImpl)
+	createTestPlugins(t, repo, "rw")
+	for _, perm := range []string{"vault.read", "vault.write"} {
+		if err := repo.AddPluginPermission("rw", perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := &Handler{service: s}
+
+	for method, params := range map[string]interface{}{
+		"vault.write":        map[string]string{"path": "a.md", "content": "x"},
+		"vault.delete":       map[string]string{"path": "a.md"},
+		"host.enablePlugin":  map[string]string{"pluginId": "rw"},
+		"host.disablePlugin": map[string]string{"pluginId": "rw"},
+	} {
+		code, resp := callTestRPC(t, h, "rw", method, params)
+		if code != 503 || resp.Error == nil || resp.Error.Message != readOnlyMessage {
+			t.Errorf("%s in read-only mode: got %d %+v, want 503", method, code, resp.Error)
+		}
+	}
+	if _, err := s.ReadVaultFile(1, "a.md"); err == nil {
+		t.Error("read-only mode did not prevent the write")
+	}
+
+	// 读操作照常可用
+	if code, resp := callTestRPC(t, h, "rw", "host.checkPermission", map[string]string{"pluginId": "rw", "permission": "vault.read"}); code != 200 {
+		t.Errorf("host.checkPermission in read-only mode: got %d %+v", code, resp.Error)
+	}
+}
+
+func TestSetReadOnly(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Subscribe(ctx)
+
+	s.SetReadOnly(true)
+	s.SetReadOnly(true)
+	if !s.IsReadOnly() {
+		t.Fatal("SetReadOnly(true) did not enable read-only mode")
+	}
+	s.SetReadOnly(false)
+
+	// 状态没有变化时不广播
+	var changes []interface{}
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == "host.readonly.changed" {
+			changes = append(changes, ev.Data.(map[string]interface{})["readOnly"])
+		}
+	}
+	if len(changes) != 2 || changes[0] != true || changes[1] != false {
+		t.Errorf("host.readonly.changed values = %v, want [true false]", changes)
+	}
+
+	h := &Handler{service: s}
+	if code, _ := callTestRPC(t, h, "", "host.setReadOnly", map[string]bool{"readOnly": true}); code != 403 {
+		t.Errorf("non-admin host.setReadOnly: status = %d, want 403", code)
+	}
+	if s.IsReadOnly() {
+		t.Error("non-admin host.setReadOnly changed the mode")
+	}
+}
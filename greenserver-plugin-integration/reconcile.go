@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lgnixai/wmcms/pkg/logger"
+)
+
+// ReconcilePlugins 重新扫描插件目录并校正插件记录：为新出现的插件创建记录，
+// 按清单更新名称、版本等信息，删除目录已不存在的插件记录。
+// 正在安装的插件不会被删除，清单无效的插件视为不存在
+func (s *ServiceImpl) ReconcilePlugins() (*ReconcileResult, error) {
+	result := &ReconcileResult{Added: []string{}, Updated: []string{}, Removed: []string{}}
+
+	// 插件目录不可读时不做任何修改，避免误删全部记录
+	entries, err := os.ReadDir(s.pluginsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	onDisk := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), uninstallingSuffix) {
+			continue
+		}
+		manifest, err := readManifestFile(filepath.Join(s.pluginsDir, entry.Name(), "manifest.json"))
+		if err != nil {
+			continue
+		}
+		pluginID := getStringFromMap(manifest, "id")
+		if pluginID == "" || getStringFromMap(manifest, "name") == "" || getStringFromMap(manifest, "version") == "" {
+			continue
+		}
+		onDisk[pluginID] = true
+
+		if err := s.syncManifestCommands(pluginID, manifest); err != nil {
+			logger.Error("Failed to sync manifest commands for "+pluginID, err)
+		}
+		compatible := s.checkCompatible(pluginID, manifest) == nil
+
+		existing, err := s.repo.GetPluginByID(pluginID)
+		if err != nil || existing == nil {
+			if err := s.createDiskPlugin(manifest, compatible); err != nil {
+				return nil, fmt.Errorf("failed to create plugin %s: %w", pluginID, err)
+			}
+			result.Added = append(result.Added, pluginID)
+			continue
+		}
+
+		name := getStringFromMap(manifest, "name")
+		version := getStringFromMap(manifest, "version")
+		author := getStringFromMap(manifest, "author")
+		description := getStringFromMap(manifest, "description")
+		changed := existing.Name != name || existing.Version != version ||
+			existing.Author != author || existing.Description != description
+		existing.Name = name
+		existing.Version = version
+		existing.Author = author
+		existing.Description = description
+		if existing.Enabled && !compatible {
+			existing.Enabled = false
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		if err := s.repo.UpdatePlugin(existing); err != nil {
+			return nil, fmt.Errorf("failed to update plugin %s: %w", pluginID, err)
+		}
+		result.Updated = append(result.Updated, pluginID)
+	}
+
+	plugins, err := s.repo.GetAllPlugins(&PluginQuery{})
+	if err != nil {
+		return nil, err
+	}
+	for _, plugin := range plugins {
+		if onDisk[plugin.PluginID] || s.installing(plugin.PluginID) {
+			continue
+		}
+		err := s.repo.Transaction(func(repo Repository) error {
+			if err := repo.DeleteCommandsByPluginID(plugin.PluginID); err != nil {
+				return err
+			}
+			if err := repo.DeleteLabelsByPluginID(plugin.PluginID); err != nil {
+				return err
+			}
+			if err := repo.DeletePlugin(plugin.PluginID); err != nil {
+				return err
+			}
+			return repo.DeleteInstallation(plugin.PluginID)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove plugin %s: %w", plugin.PluginID, err)
+		}
+		result.Removed = append(result.Removed, plugin.PluginID)
+		s.Broadcast(&EventData{
+			Type: "plugin.uninstalled",
+			Data: map[string]interface{}{"pluginId": plugin.PluginID},
+		})
+	}
+
+	return result, nil
+}
+
+// installing 判断插件是否有进行中的安装，安装完成前插件目录可能尚未创建
+func (s *ServiceImpl) installing(pluginID string) bool {
+	installation, err := s.repo.GetInstallationByPluginID(pluginID)
+	if err != nil {
+		return false
+	}
+	return installation.Status != "installed" && installation.Status != "failed" && installation.Status != "dead"
+}
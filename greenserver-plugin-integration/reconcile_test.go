@@ -0,0 +1,118 @@
+package plugin
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReconcilePlugins(t *testing.T) {
+	repo := NewInMemoryRepository()
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(repo, pluginsDir, t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	// 数据库与磁盘不一致：stale 的目录已删除，changed 的清单版本已更新，
+	// fresh 只存在于磁盘，pending 正在安装，目录尚未创建
+	createTestPlugins(t, repo, "same", "changed", "stale", "pending")
+	if err := repo.CreateInstallation(&PluginInstallation{PluginID: "pending", Status: "installing"}); err != nil {
+		t.Fatal(err)
+	}
+	writeTestManifest(t, pluginsDir, "same", `{"id":"same","name":"same","version":"1.0.0"}`)
+	writeTestManifest(t, pluginsDir, "changed", `{"id":"changed","name":"Changed","version":"2.0.0","author":"alice"}`)
+	writeTestManifest(t, pluginsDir, "fresh", `{"id":"fresh","name":"Fresh","version":"1.0.0","commands":[{"id":"fresh.run","title":"Run"}]}`)
+	writeTestManifest(t, pluginsDir, "broken", `{"id":"broken"`)
+	writeTestManifest(t, pluginsDir, "gone"+uninstallingSuffix, `{"id":"gone","name":"Gone","version":"1.0.0"}`)
+
+	result, err := s.ReconcilePlugins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(result.Added, ","); got != "fresh" {
+		t.Errorf("added = %s, want fresh", got)
+	}
+	if got := strings.Join(result.Updated, ","); got != "changed" {
+		t.Errorf("updated = %s, want changed", got)
+	}
+	if got := strings.Join(result.Removed, ","); got != "stale" {
+		t.Errorf("removed = %s, want stale", got)
+	}
+
+	if p, err := repo.GetPluginByID("changed"); err != nil || p.Version != "2.0.0" || p.Name != "Changed" || p.Author != "alice" {
+		t.Errorf("changed plugin = %+v, %v", p, err)
+	}
+	if p, err := repo.GetPluginByID("fresh"); err != nil || p.Version != "1.0.0" {
+		t.Errorf("fresh plugin = %+v, %v", p, err)
+	}
+	if cmds, _ := repo.GetCommandsByPluginID("fresh"); len(cmds) != 1 || cmds[0].CommandID != "fresh.run" {
+		t.Errorf("fresh commands = %+v, want fresh.run", cmds)
+	}
+	if _, err := repo.GetPluginByID("stale"); err == nil {
+		t.Error("plugin without a directory was kept")
+	}
+	if _, err := repo.GetPluginByID("pending"); err != nil {
+		t.Error("plugin with an install in progress was removed")
+	}
+	for _, id := range []string{"broken", "gone"} {
+		if _, err := repo.GetPluginByID(id); err == nil {
+			t.Errorf("%s was added", id)
+		}
+	}
+
+	// 再次校正时没有变化
+	result, err = s.ReconcilePlugins()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Added)+len(result.Updated)+len(result.Removed) != 0 {
+		t.Errorf("second reconcile = %+v, want no changes", result)
+	}
+}
+
+func TestReconcilePluginsUnreadableDir(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, filepath.Join(t.TempDir(), "missing"), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "demo")
+
+	// 插件目录不可读时不删除任何记录
+	if _, err := s.ReconcilePlugins(); err == nil {
+		t.Fatal("ReconcilePlugins with a missing plugins dir: expected error")
+	}
+	if _, err := repo.GetPluginByID("demo"); err != nil {
+		t.Error("plugin record removed although the plugins dir could not be read")
+	}
+}
+
+func TestReconcilePluginsHandler(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "stale")
+	h := &Handler{service: s}
+
+	reconcile := func(role string) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		if role != "" {
+			c.Set("role", role)
+		}
+		c.Request = httptest.NewRequest("POST", "/plugins/reconcile", nil)
+		h.ReconcilePlugins(c)
+	}
+
+	reconcile("user")
+	if _, err := repo.GetPluginByID("stale"); err != nil {
+		t.Fatal("non-admin reconcile changed the registry")
+	}
+	reconcile("admin")
+	if _, err := repo.GetPluginByID("stale"); err == nil {
+		t.Error("admin reconcile kept the stale plugin")
+	}
+	logs, err := s.GetAuditLogs(&AuditQuery{Action: "plugin.reconcile"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 {
+		t.Errorf("got %d plugin.reconcile audit entries, want 1", len(logs))
+	}
+}
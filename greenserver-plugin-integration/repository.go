@@ -1,9 +1,12 @@
 package plugin
 
 import (
+	"context"
 	"path/filepath"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository 插件存储库接口
@@ -11,7 +14,9 @@ type Repository interface {
 	// Plugin operations
 	CreatePlugin(plugin *Plugin) error
 	GetPluginByID(pluginID string) (*Plugin, error)
-	GetAllPlugins() ([]*Plugin, error)
+	// GetAllPlugins 按 query.Sort 排序，默认按插件ID
+	GetAllPlugins(query *PluginQuery) ([]*Plugin, error)
+	CountPlugins() (int64, error)
 	UpdatePlugin(plugin *Plugin) error
 	DeletePlugin(pluginID string) error
 	EnablePlugin(pluginID string) error
@@ -22,14 +27,26 @@ type Repository interface {
 	GetPermissionByName(name string) (*Permission, error)
 	GetAllPermissions() ([]*Permission, error)
 	GetPluginPermissions(pluginID string) ([]string, error)
+	// GetAllPluginPermissions 返回插件ID到权限名称的映射，没有权限的插件对应空列表
+	GetAllPluginPermissions() (map[string][]string, error)
 	AddPluginPermission(pluginID string, permissionName string) error
 	RemovePluginPermission(pluginID string, permissionName string) error
 
 	// Command operations
 	CreateCommand(command *Command) error
 	GetCommandsByPluginID(pluginID string) ([]*Command, error)
+	// GetAllCommands 按 (plugin_id, command_id) 排序
 	GetAllCommands() ([]*Command, error)
 	DeleteCommandsByPluginID(pluginID string) error
+	DeleteCommandsBySource(pluginID, source string) error
+
+	// Label operations
+	// AddLabel 为插件添加标签，标签已存在时不做任何事，插件不存在时返回 gorm.ErrRecordNotFound
+	AddLabel(pluginID, label string) error
+	RemoveLabel(pluginID, label string) (bool, error)
+	// GetByLabel 返回带有该标签的插件ID，按字典序排列
+	GetByLabel(label string) ([]string, error)
+	DeleteLabelsByPluginID(pluginID string) error
 
 	// Installation operations
 	CreateInstallation(installation *PluginInstallation) error
@@ -40,9 +57,40 @@ type Repository interface {
 	// Vault operations
 	CreateVaultFile(file *VaultFile) error
 	GetVaultFileByPath(userID uint, path string) (*VaultFile, error)
-	GetVaultFilesByUserID(userID uint) ([]*VaultFile, error)
+	GetVaultFilesByUserID(ctx context.Context, userID uint) ([]*VaultFile, error)
 	UpdateVaultFile(file *VaultFile) error
 	DeleteVaultFile(userID uint, path string) error
+	GetVaultUsage(userID uint) (int64, error)
+
+	// Vault blob operations
+	CreateVaultBlob(blob *VaultBlob) error
+	GetVaultBlob(hash string) (*VaultBlob, error)
+	CountVaultBlobRefs(hash string) (int64, error)
+	DeleteVaultBlob(hash string) error
+
+	// Quota operations
+	GetUserQuota(userID uint) (*UserQuota, error)
+	SetUserQuota(userID uint, quotaBytes int64) error
+
+	// KV operations
+	GetKV(pluginID, key string) (*PluginKV, error)
+	SetKV(kv *PluginKV) error
+	DeleteKV(pluginID, key string) (bool, error)
+	ListKVKeys(pluginID, prefix string) ([]string, error)
+	GetKVUsage(pluginID string) (count int64, size int64, err error)
+
+	// Audit operations
+	CreateAuditLog(log *AuditLog) error
+	GetAuditLogs(query *AuditQuery) ([]*AuditLog, error)
+
+	// Event history operations
+	CreateEventLog(event *EventLog) error
+	// GetEventLogs 返回符合条件的最近 query.Limit 条事件，按序号升序
+	GetEventLogs(query *EventHistoryQuery) ([]*EventLog, error)
+	DeleteEventLogsBefore(t time.Time) (int64, error)
+
+	// Transaction 在单个事务中执行 fn，fn 返回错误时回滚
+	Transaction(fn func(repo Repository) error) error
 }
 
 // RepositoryImpl 插件存储库实现
@@ -55,6 +103,13 @@ func NewRepository(db *gorm.DB) Repository {
 	return &RepositoryImpl{db: db}
 }
 
+// Transaction 在单个数据库事务中执行 fn
+func (r *RepositoryImpl) Transaction(fn func(repo Repository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&RepositoryImpl{db: tx})
+	})
+}
+
 // Plugin operations
 func (r *RepositoryImpl) CreatePlugin(plugin *Plugin) error {
 	return r.db.Create(plugin).Error
@@ -62,7 +117,7 @@ func (r *RepositoryImpl) CreatePlugin(plugin *Plugin) error {
 
 func (r *RepositoryImpl) GetPluginByID(pluginID string) (*Plugin, error) {
 	var plugin Plugin
-	err := r.db.Preload("Permissions").Preload("Commands").
+	err := r.db.Preload("Permissions").Preload("Commands").Preload("Labels").
 		Where("plugin_id = ?", pluginID).First(&plugin).Error
 	if err != nil {
 		return nil, err
@@ -70,9 +125,30 @@ func (r *RepositoryImpl) GetPluginByID(pluginID string) (*Plugin, error) {
 	return &plugin, nil
 }
 
-func (r *RepositoryImpl) GetAllPlugins() ([]*Plugin, error) {
+func (r *RepositoryImpl) CountPlugins() (int64, error) {
+	var count int64
+	err := r.db.Model(&Plugin{}).Count(&count).Error
+	return count, err
+}
+
+func (r *RepositoryImpl) GetAllPlugins(query *PluginQuery) ([]*Plugin, error) {
 	var plugins []*Plugin
-	err := r.db.Preload("Permissions").Preload("Commands").Find(&plugins).Error
+	db := r.db.Preload("Permissions").Preload("Commands").Preload("Labels")
+	if query != nil {
+		if query.Enabled != nil {
+			db = db.Where("enabled = ?", *query.Enabled)
+		}
+		if query.Author != "" {
+			db = db.Where("author = ?", query.Author)
+		}
+		if query.Label != "" {
+			db = db.Where("plugin_id IN (?)", r.db.Model(&PluginLabel{}).Select("plugin_id").Where("label = ?", query.Label))
+		}
+		if query.Sort == PluginSortName {
+			db = db.Order("name")
+		}
+	}
+	err := db.Order("plugin_id").Find(&plugins).Error
 	return plugins, err
 }
 
@@ -128,6 +204,35 @@ func (r *RepositoryImpl) GetPluginPermissions(pluginID string) ([]string, error)
 	return permissions, nil
 }
 
+// GetAllPluginPermissions 以一次关联查询取出全部插件的权限，避免逐个插件预加载
+func (r *RepositoryImpl) GetAllPluginPermissions() (map[string][]string, error) {
+	var rows []struct {
+		PluginID string
+		Name     *string
+	}
+	err := r.db.Table("plugins").
+		Select("plugins.plugin_id, permissions.name").
+		Joins("LEFT JOIN plugin_permissions ON plugin_permissions.plugin_id = plugins.id").
+		Joins("LEFT JOIN permissions ON permissions.id = plugin_permissions.permission_id AND permissions.deleted_at IS NULL").
+		Where("plugins.deleted_at IS NULL").
+		Order("plugins.plugin_id, permissions.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]string)
+	for _, row := range rows {
+		if _, ok := result[row.PluginID]; !ok {
+			result[row.PluginID] = []string{}
+		}
+		if row.Name != nil {
+			result[row.PluginID] = append(result[row.PluginID], *row.Name)
+		}
+	}
+	return result, nil
+}
+
 func (r *RepositoryImpl) AddPluginPermission(pluginID string, permissionName string) error {
 	var plugin Plugin
 	if err := r.db.Where("plugin_id = ?", pluginID).First(&plugin).Error; err != nil {
@@ -160,6 +265,31 @@ func (r *RepositoryImpl) RemovePluginPermission(pluginID string, permissionName
 	return r.db.Model(&plugin).Association("Permissions").Delete(&permission)
 }
 
+// Label operations
+func (r *RepositoryImpl) AddLabel(pluginID, label string) error {
+	if err := r.db.Where("plugin_id = ?", pluginID).First(&Plugin{}).Error; err != nil {
+		return err
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&PluginLabel{PluginID: pluginID, Label: label}).Error
+}
+
+func (r *RepositoryImpl) RemoveLabel(pluginID, label string) (bool, error) {
+	result := r.db.Where("plugin_id = ? AND label = ?", pluginID, label).Delete(&PluginLabel{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *RepositoryImpl) GetByLabel(label string) ([]string, error) {
+	pluginIDs := make([]string, 0)
+	err := r.db.Model(&PluginLabel{}).Where("label = ?", label).
+		Order("plugin_id").Pluck("plugin_id", &pluginIDs).Error
+	return pluginIDs, err
+}
+
+func (r *RepositoryImpl) DeleteLabelsByPluginID(pluginID string) error {
+	return r.db.Where("plugin_id = ?", pluginID).Delete(&PluginLabel{}).Error
+}
+
 // Command operations
 func (r *RepositoryImpl) CreateCommand(command *Command) error {
 	return r.db.Create(command).Error
@@ -173,7 +303,7 @@ func (r *RepositoryImpl) GetCommandsByPluginID(pluginID string) ([]*Command, err
 
 func (r *RepositoryImpl) GetAllCommands() ([]*Command, error) {
 	var commands []*Command
-	err := r.db.Find(&commands).Error
+	err := r.db.Order("plugin_id").Order("command_id").Find(&commands).Error
 	return commands, err
 }
 
@@ -181,6 +311,10 @@ func (r *RepositoryImpl) DeleteCommandsByPluginID(pluginID string) error {
 	return r.db.Where("plugin_id = ?", pluginID).Delete(&Command{}).Error
 }
 
+func (r *RepositoryImpl) DeleteCommandsBySource(pluginID, source string) error {
+	return r.db.Where("plugin_id = ? AND source = ?", pluginID, source).Delete(&Command{}).Error
+}
+
 // Installation operations
 func (r *RepositoryImpl) CreateInstallation(installation *PluginInstallation) error {
 	return r.db.Create(installation).Error
@@ -217,9 +351,9 @@ func (r *RepositoryImpl) GetVaultFileByPath(userID uint, path string) (*VaultFil
 	return &file, nil
 }
 
-func (r *RepositoryImpl) GetVaultFilesByUserID(userID uint) ([]*VaultFile, error) {
+func (r *RepositoryImpl) GetVaultFilesByUserID(ctx context.Context, userID uint) ([]*VaultFile, error) {
 	var files []*VaultFile
-	err := r.db.Where("user_id = ?", userID).Find(&files).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&files).Error
 	return files, err
 }
 
@@ -228,5 +362,150 @@ func (r *RepositoryImpl) UpdateVaultFile(file *VaultFile) error {
 }
 
 func (r *RepositoryImpl) DeleteVaultFile(userID uint, path string) error {
-	return r.db.Where("user_id = ? AND path = ?", userID, filepath.Clean(path)).Delete(&VaultFile{}).Error
+	return r.db.Unscoped().Where("user_id = ? AND path = ?", userID, filepath.Clean(path)).Delete(&VaultFile{}).Error
+}
+
+func (r *RepositoryImpl) GetVaultUsage(userID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&VaultFile{}).Where("user_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").Scan(&total).Error
+	return total, err
+}
+
+// Vault blob operations
+
+// CreateVaultBlob 保存数据块，相同哈希已存在时忽略
+func (r *RepositoryImpl) CreateVaultBlob(blob *VaultBlob) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(blob).Error
+}
+
+func (r *RepositoryImpl) GetVaultBlob(hash string) (*VaultBlob, error) {
+	var blob VaultBlob
+	err := r.db.Where("hash = ?", hash).First(&blob).Error
+	if err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+func (r *RepositoryImpl) CountVaultBlobRefs(hash string) (int64, error) {
+	var count int64
+	err := r.db.Model(&VaultFile{}).Where("blob_hash = ?", hash).Count(&count).Error
+	return count, err
+}
+
+func (r *RepositoryImpl) DeleteVaultBlob(hash string) error {
+	return r.db.Where("hash = ?", hash).Delete(&VaultBlob{}).Error
+}
+
+// Quota operations
+func (r *RepositoryImpl) GetUserQuota(userID uint) (*UserQuota, error) {
+	var quota UserQuota
+	err := r.db.Where("user_id = ?", userID).First(&quota).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (r *RepositoryImpl) SetUserQuota(userID uint, quotaBytes int64) error {
+	quota := &UserQuota{UserID: userID, QuotaBytes: quotaBytes}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quota_bytes", "updated_at"}),
+	}).Create(quota).Error
+}
+
+// KV operations
+func (r *RepositoryImpl) GetKV(pluginID, key string) (*PluginKV, error) {
+	var kv PluginKV
+	err := r.db.Where("plugin_id = ? AND key = ?", pluginID, key).First(&kv).Error
+	if err != nil {
+		return nil, err
+	}
+	return &kv, nil
+}
+
+// SetKV 写入键值，键已存在时覆盖
+func (r *RepositoryImpl) SetKV(kv *PluginKV) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "plugin_id"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(kv).Error
+}
+
+func (r *RepositoryImpl) DeleteKV(pluginID, key string) (bool, error) {
+	result := r.db.Where("plugin_id = ? AND key = ?", pluginID, key).Delete(&PluginKV{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListKVKeys 按字典序列出以 prefix 开头的键，prefix 不能包含 LIKE 通配符
+func (r *RepositoryImpl) ListKVKeys(pluginID, prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	err := r.db.Model(&PluginKV{}).Where("plugin_id = ? AND key LIKE ?", pluginID, prefix+"%").
+		Order("key").Pluck("key", &keys).Error
+	return keys, err
+}
+
+// GetKVUsage 返回插件的键数量和键值总字节数
+func (r *RepositoryImpl) GetKVUsage(pluginID string) (int64, int64, error) {
+	var usage struct {
+		Count int64
+		Size  int64
+	}
+	err := r.db.Model(&PluginKV{}).Where("plugin_id = ?", pluginID).
+		Select("COUNT(*) AS count, COALESCE(SUM(OCTET_LENGTH(key) + OCTET_LENGTH(value)), 0) AS size").
+		Scan(&usage).Error
+	return usage.Count, usage.Size, err
+}
+
+// Event history operations
+func (r *RepositoryImpl) CreateEventLog(event *EventLog) error {
+	return r.db.Create(event).Error
+}
+
+func (r *RepositoryImpl) GetEventLogs(query *EventHistoryQuery) ([]*EventLog, error) {
+	var events []*EventLog
+	db := r.db.Order("id DESC").Limit(query.Limit)
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.Since != nil {
+		db = db.Where("created_at >= ?", *query.Since)
+	}
+	if err := db.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+func (r *RepositoryImpl) DeleteEventLogsBefore(t time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", t).Delete(&EventLog{})
+	return result.RowsAffected, result.Error
+}
+
+// Audit operations
+func (r *RepositoryImpl) CreateAuditLog(log *AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *RepositoryImpl) GetAuditLogs(query *AuditQuery) ([]*AuditLog, error) {
+	var logs []*AuditLog
+	db := r.db.Order("created_at DESC")
+	if query != nil {
+		if query.Action != "" {
+			db = db.Where("action = ?", query.Action)
+		}
+		if query.Since != nil {
+			db = db.Where("created_at >= ?", *query.Since)
+		}
+		if query.Until != nil {
+			db = db.Where("created_at <= ?", *query.Until)
+		}
+	}
+	err := db.Find(&logs).Error
+	return logs, err
 }
@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func TestDeleteVaultFileIsHardDelete(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sql string
+	if err := db.Callback().Delete().After("gorm:delete").Register("test:capture", func(tx *gorm.DB) {
+		sql = tx.Statement.SQL.String()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewRepository(db).DeleteVaultFile(1, "notes/a.md"); err != nil {
+		t.Fatal(err)
+	}
+	// 软删除会生成 UPDATE ... SET deleted_at，路径唯一索引下无法重新创建同名文件
+	if !strings.HasPrefix(sql, "DELETE FROM") {
+		t.Fatalf("DeleteVaultFile SQL = %q, want hard DELETE", sql)
+	}
+}
+
+func TestGetAllPluginsFiltersInSQL(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	}); err != nil {
+		t.Fatal(err)
+	}
+	enabled := true
+	if _, err := NewRepository(db).GetAllPlugins(&PluginQuery{Enabled: &enabled, Author: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) == 0 {
+		t.Fatal("no query captured")
+	}
+	// 过滤条件须落在 WHERE 子句中，而不是查出全部后在内存中过滤
+	for _, clause := range []string{"WHERE", "enabled = ", "author = "} {
+		if !strings.Contains(queries[0], clause) {
+			t.Errorf("GetAllPlugins SQL %q missing %q", queries[0], clause)
+		}
+	}
+}
+
+func TestGetAllPluginsFilters(t *testing.T) {
+	repo := NewInMemoryRepository()
+	for _, p := range []*Plugin{
+		{PluginID: "a", Name: "A", Author: "alice", Enabled: true},
+		{PluginID: "b", Name: "B", Author: "alice", Enabled: false},
+		{PluginID: "c", Name: "C", Author: "bob", Enabled: true},
+	} {
+		if err := repo.CreatePlugin(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	yes, no := true, false
+	cases := []struct {
+		name  string
+		query *PluginQuery
+		want  []string
+	}{
+		{"none", nil, []string{"a", "b", "c"}},
+		{"enabled", &PluginQuery{Enabled: &yes}, []string{"a", "c"}},
+		{"disabled", &PluginQuery{Enabled: &no}, []string{"b"}},
+		{"author", &PluginQuery{Author: "alice"}, []string{"a", "b"}},
+		{"combined", &PluginQuery{Enabled: &yes, Author: "alice"}, []string{"a"}},
+		{"no match", &PluginQuery{Enabled: &no, Author: "bob"}, nil},
+	}
+	for _, c := range cases {
+		plugins, err := repo.GetAllPlugins(c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range plugins {
+			got = append(got, p.PluginID)
+		}
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
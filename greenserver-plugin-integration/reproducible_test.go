@@ -0,0 +1,82 @@
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeBackupFixture 在 dir 下按给定顺序写入文件，并把修改时间设为 mtime
+func writeBackupFixture(t *testing.T, dir string, mtime time.Time, names []string) {
+	t.Helper()
+	for _, name := range names {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		mode := os.FileMode(0644)
+		if filepath.Ext(name) == ".sh" {
+			mode = 0755
+		}
+		if err := os.WriteFile(p, []byte("content of "+name), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(p, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackupZipIsReproducible(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	writeBackupFixture(t, a, time.Now().Add(-48*time.Hour), []string{"manifest.json", "assets/app.js", "bin/run.sh", "z.txt"})
+	writeBackupFixture(t, b, time.Now(), []string{"z.txt", "bin/run.sh", "assets/app.js", "manifest.json"})
+
+	var zipA, zipB bytes.Buffer
+	if err := writeDirZip(&zipA, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDirZip(&zipB, b); err != nil {
+		t.Fatal(err)
+	}
+	if sha256.Sum256(zipA.Bytes()) != sha256.Sum256(zipB.Bytes()) {
+		t.Fatal("backups of identical content have different SHA256")
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(zipA.Bytes()), int64(zipA.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		if f.Method != zip.Deflate {
+			t.Errorf("%s: method = %d, want Deflate", f.Name, f.Method)
+		}
+	}
+	want := []string{"assets/app.js", "bin/run.sh", "manifest.json", "z.txt"}
+	if len(names) != len(want) {
+		t.Fatalf("entries = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("entries = %v, want sorted %v", names, want)
+		}
+	}
+	for _, f := range zr.File {
+		wantMode := os.FileMode(0644)
+		if f.Name == "bin/run.sh" {
+			wantMode = 0755
+		}
+		if got := f.Mode().Perm(); got != wantMode {
+			t.Errorf("%s: mode = %v, want %v", f.Name, got, wantMode)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package plugin
+
+import "errors"
+
+const (
+	// DefaultMaxRPCBatchSize 未配置 MaxRPCBatchSize 时参数中单个数组的最大元素数
+	DefaultMaxRPCBatchSize = 1000
+	// DefaultMaxRPCParamsDepth 未配置 MaxRPCParamsDepth 时参数的最大嵌套层数
+	DefaultMaxRPCParamsDepth = 32
+)
+
+var (
+	errRPCBatchTooLarge = errors.New("batch too large")
+	errRPCParamsTooDeep = errors.New("params nested too deeply")
+)
+
+// maxRPCBatchSize 返回生效的数组元素数上限
+func (h *Handler) maxRPCBatchSize() int {
+	if h.options.MaxRPCBatchSize > 0 {
+		return h.options.MaxRPCBatchSize
+	}
+	return DefaultMaxRPCBatchSize
+}
+
+// maxRPCParamsDepth 返回生效的嵌套层数上限
+func (h *Handler) maxRPCParamsDepth() int {
+	if h.options.MaxRPCParamsDepth > 0 {
+		return h.options.MaxRPCParamsDepth
+	}
+	return DefaultMaxRPCParamsDepth
+}
+
+// checkRPCParams 递归检查参数，数组元素数或嵌套层数超过上限时返回错误。
+// 与请求体字节数限制互补：小请求体也可能包含大量元素或极深的嵌套
+func checkRPCParams(v interface{}, depth, maxLen, maxDepth int) error {
+	switch val := v.(type) {
+	case []interface{}:
+		if depth+1 > maxDepth {
+			return errRPCParamsTooDeep
+		}
+		if len(val) > maxLen {
+			return errRPCBatchTooLarge
+		}
+		for _, item := range val {
+			if err := checkRPCParams(item, depth+1, maxLen, maxDepth); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if depth+1 > maxDepth {
+			return errRPCParamsTooDeep
+		}
+		for _, item := range val {
+			if err := checkRPCParams(item, depth+1, maxLen, maxDepth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
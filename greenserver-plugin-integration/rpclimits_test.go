@@ -0,0 +1,68 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCheckRPCParams(t *testing.T) {
+	cases := []struct {
+		name   string
+		params string
+		want   error
+	}{
+		{"flat", `{"pluginIds":["a","b","c"]}`, nil},
+		{"batch too large", `{"pluginIds":["a","b","c","d"]}`, errRPCBatchTooLarge},
+		{"nested array too large", `{"a":[[1],[1,2,3,4]]}`, errRPCBatchTooLarge},
+		{"at depth limit", `{"a":{"b":[1]}}`, nil},
+		{"too deep", `{"a":{"b":{"c":{}}}}`, errRPCParamsTooDeep},
+	}
+	for _, tc := range cases {
+		var params interface{}
+		if err := json.Unmarshal([]byte(tc.params), &params); err != nil {
+			t.Fatal(err)
+		}
+		if got := checkRPCParams(params, 0, 3, 3); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRPCBatchSizeLimit(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	h := &Handler{service: s, options: HandlerOptions{MaxRPCBatchSize: 3}}
+	ids := make([]string, 4)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("p%d", i)
+	}
+	createTestPlugins(t, repo, ids...)
+
+	code, resp := callTestRPC(t, h, "", "host.batchSetEnabled", map[string]interface{}{"pluginIds": ids, "enabled": false})
+	if code != 400 || resp.Error == nil || resp.Error.Message != "batch too large" {
+		t.Fatalf("over-long batch: got %d %+v, want 400 batch too large", code, resp.Error)
+	}
+	for _, id := range ids {
+		if plugin, _ := repo.GetPluginByID(id); !plugin.Enabled {
+			t.Errorf("rejected batch disabled %s", id)
+		}
+	}
+
+	// 未超过上限的批量请求通过检查，交给方法自身处理
+	if code, resp := callTestRPC(t, h, "", "host.batchSetEnabled", map[string]interface{}{"pluginIds": ids[:3], "enabled": false}); code == 400 && resp.Error != nil && resp.Error.Message == "batch too large" {
+		t.Errorf("batch at the limit rejected: %+v", resp.Error)
+	}
+}
+
+func TestRPCParamsDepthLimit(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	h := &Handler{service: s}
+
+	deep := strings.Repeat(`{"a":`, DefaultMaxRPCParamsDepth) + "1" + strings.Repeat("}", DefaultMaxRPCParamsDepth)
+	code, resp := callTestRPC(t, h, "demo", "kv.set", map[string]interface{}{"key": "k", "value": json.RawMessage(deep)})
+	if code != 400 || resp.Error == nil || resp.Error.Message != "params nested too deeply" {
+		t.Errorf("deeply nested params: got %d %+v, want 400 params nested too deeply", code, resp.Error)
+	}
+}
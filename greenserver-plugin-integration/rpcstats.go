@@ -0,0 +1,138 @@
+package plugin
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rpcRateWindow 插件RPC限流的统计窗口
+const rpcRateWindow = time.Minute
+
+// PluginRPCStats 插件的RPC调用统计，保存在内存中，服务重启或调用 host.resetPluginStats 后清零
+type PluginRPCStats struct {
+	PluginID string `json:"pluginId"`
+	Calls    int64  `json:"calls"`
+	// Errors 返回错误的调用数，包括被限流拒绝的调用
+	Errors int64 `json:"errors"`
+	// Limited 被限流拒绝的调用数
+	Limited  int64     `json:"limited"`
+	BytesIn  int64     `json:"bytesIn"`
+	BytesOut int64     `json:"bytesOut"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// pluginRPCCounter 单个插件的统计和当前限流窗口
+type pluginRPCCounter struct {
+	stats       PluginRPCStats
+	windowStart time.Time
+	windowCalls int
+}
+
+// trackedPlugin 判断是否统计该插件，只统计已安装的插件，避免任意声明的插件ID占用内存
+func (h *Handler) trackedPlugin(pluginID string) bool {
+	if pluginID == "" {
+		return false
+	}
+	h.rpcStatsMu.Lock()
+	_, ok := h.rpcStats[pluginID]
+	h.rpcStatsMu.Unlock()
+	if ok {
+		return true
+	}
+	_, err := h.service.GetPlugin(pluginID)
+	return err == nil
+}
+
+// allowPluginRPC 按 HandlerOptions.PluginRPCRateLimit 检查插件在当前窗口内能否再发起调用
+func (h *Handler) allowPluginRPC(pluginID string) bool {
+	limit := h.options.PluginRPCRateLimit
+	if limit <= 0 || !h.trackedPlugin(pluginID) {
+		return true
+	}
+	h.rpcStatsMu.Lock()
+	defer h.rpcStatsMu.Unlock()
+	counter := h.rpcCounterLocked(pluginID)
+	now := time.Now()
+	if now.Sub(counter.windowStart) >= rpcRateWindow {
+		counter.windowStart = now
+		counter.windowCalls = 0
+	}
+	if counter.windowCalls >= limit {
+		counter.stats.Limited++
+		return false
+	}
+	counter.windowCalls++
+	return true
+}
+
+// recordPluginRPC 记录一次调用的流量和结果
+func (h *Handler) recordPluginRPC(c *gin.Context, pluginID string, bytesIn int64) {
+	if !h.trackedPlugin(pluginID) {
+		return
+	}
+	h.rpcStatsMu.Lock()
+	defer h.rpcStatsMu.Unlock()
+	counter := h.rpcCounterLocked(pluginID)
+	counter.stats.Calls++
+	if c.Writer.Status() >= 400 {
+		counter.stats.Errors++
+	}
+	counter.stats.BytesIn += bytesIn
+	if size := c.Writer.Size(); size > 0 {
+		counter.stats.BytesOut += int64(size)
+	}
+	counter.stats.LastSeen = time.Now()
+}
+
+// rpcCounterLocked 返回插件的计数器，不存在时创建，调用方须持有 rpcStatsMu
+func (h *Handler) rpcCounterLocked(pluginID string) *pluginRPCCounter {
+	if h.rpcStats == nil {
+		h.rpcStats = make(map[string]*pluginRPCCounter)
+	}
+	counter, ok := h.rpcStats[pluginID]
+	if !ok {
+		counter = &pluginRPCCounter{stats: PluginRPCStats{PluginID: pluginID}}
+		h.rpcStats[pluginID] = counter
+	}
+	return counter
+}
+
+// pluginRPCStats 返回插件的调用统计，pluginID 为空时返回全部插件，按插件ID排序
+func (h *Handler) pluginRPCStats(pluginID string) []PluginRPCStats {
+	h.rpcStatsMu.Lock()
+	defer h.rpcStatsMu.Unlock()
+	out := make([]PluginRPCStats, 0, len(h.rpcStats))
+	for id, counter := range h.rpcStats {
+		if pluginID == "" || id == pluginID {
+			out = append(out, counter.stats)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PluginID < out[j].PluginID })
+	return out
+}
+
+// resetPluginRPCStats 清零插件的调用统计，pluginID 为空时清零全部
+func (h *Handler) resetPluginRPCStats(pluginID string) {
+	h.rpcStatsMu.Lock()
+	defer h.rpcStatsMu.Unlock()
+	if pluginID == "" {
+		h.rpcStats = nil
+		return
+	}
+	delete(h.rpcStats, pluginID)
+}
+
+// rpcStatsReader 统计读取的请求体字节数
+type rpcStatsReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *rpcStatsReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
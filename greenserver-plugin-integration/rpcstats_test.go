@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPluginRPCStatsCountCalls(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "a")
+	h := &Handler{service: s}
+
+	for i := 0; i < 3; i++ {
+		if code, resp := callTestRPC(t, h, "a", "host.checkPermission", map[string]string{"pluginId": "a", "permission": "vault.read"}); code != 200 {
+			t.Fatalf("host.checkPermission: got %d %+v", code, resp.Error)
+		}
+	}
+	if code, _ := callTestRPC(t, h, "a", "vault.read", map[string]string{"path": "a.md"}); code < 400 {
+		t.Fatalf("vault.read without permission: status = %d, want an error", code)
+	}
+	// 未安装的插件不统计
+	callTestRPC(t, h, "ghost", "host.checkPermission", map[string]string{"pluginId": "a", "permission": "vault.read"})
+
+	stats := h.pluginRPCStats("")
+	if len(stats) != 1 || stats[0].PluginID != "a" {
+		t.Fatalf("stats = %+v, want only plugin a", stats)
+	}
+	st := stats[0]
+	if st.Calls != 4 || st.Errors != 1 || st.Limited != 0 {
+		t.Errorf("calls/errors/limited = %d/%d/%d, want 4/1/0", st.Calls, st.Errors, st.Limited)
+	}
+	if st.BytesIn == 0 || st.BytesOut == 0 || st.LastSeen.IsZero() {
+		t.Errorf("traffic not recorded: %+v", st)
+	}
+
+	h.resetPluginRPCStats("a")
+	if stats := h.pluginRPCStats(""); len(stats) != 0 {
+		t.Errorf("stats after reset = %+v, want none", stats)
+	}
+}
+
+func TestPluginRPCRateLimit(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "a", "b")
+	h := &Handler{service: s, options: HandlerOptions{PluginRPCRateLimit: 2}}
+	params := map[string]string{"pluginId": "a", "permission": "vault.read"}
+
+	for i := 0; i < 2; i++ {
+		if code, resp := callTestRPC(t, h, "a", "host.checkPermission", params); code != 200 {
+			t.Fatalf("call %d under the limit: got %d %+v", i, code, resp.Error)
+		}
+	}
+	code, resp := callTestRPC(t, h, "a", "host.checkPermission", params)
+	if code != 429 || resp.Error == nil || resp.Error.Message != "rate limit exceeded" {
+		t.Fatalf("call over the limit: got %d %+v, want 429", code, resp.Error)
+	}
+	// 限流按插件计算
+	if code, resp := callTestRPC(t, h, "b", "host.checkPermission", params); code != 200 {
+		t.Errorf("other plugin was limited: got %d %+v", code, resp.Error)
+	}
+
+	stats := h.pluginRPCStats("a")
+	if len(stats) != 1 || stats[0].Calls != 3 || stats[0].Errors != 1 || stats[0].Limited != 1 {
+		t.Errorf("stats = %+v, want 3 calls, 1 error, 1 limited", stats)
+	}
+}
+
+func TestPluginStatsAccess(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{
+		PluginKeys: map[string]string{"a": "key-a", "b": "key-b"},
+	}).(*ServiceImpl)
+	createTestPlugins(t, repo, "a", "b")
+	h := &Handler{service: s}
+
+	call := func(key, pluginID, method, params string) (int, RPCResponse) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		body := `{"id":"1","method":"` + method + `","pluginId":"` + pluginID + `","params":` + params + `}`
+		c.Request = httptest.NewRequest("POST", "/rpc", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Request.Header.Set("X-Plugin-Key", key)
+		h.HandleRPC(c)
+		var resp RPCResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode response %q: %v", method, w.Body.String(), err)
+		}
+		return w.Code, resp
+	}
+	call("key-a", "a", "host.checkPermission", `{"pluginId":"a","permission":"vault.read"}`)
+	call("key-b", "b", "host.checkPermission", `{"pluginId":"b","permission":"vault.read"}`)
+
+	// 以凭据认证的插件只能查看自己的统计
+	code, resp := call("key-a", "a", "host.getPluginStats", `{}`)
+	if code != 200 {
+		t.Fatalf("own stats: got %d %+v", code, resp.Error)
+	}
+	if own, _ := resp.Result.([]interface{}); len(own) != 1 || own[0].(map[string]interface{})["pluginId"] != "a" {
+		t.Errorf("own stats = %v, want only plugin a", resp.Result)
+	}
+	if code, _ := call("key-a", "a", "host.getPluginStats", `{"pluginId":"b"}`); code != 403 {
+		t.Errorf("another plugin's stats: status = %d, want 403", code)
+	}
+	if code, _ := call("key-a", "a", "host.resetPluginStats", `{}`); code != 403 {
+		t.Errorf("non-admin reset: status = %d, want 403", code)
+	}
+	if len(h.pluginRPCStats("")) != 2 {
+		t.Error("rejected reset cleared the stats")
+	}
+}
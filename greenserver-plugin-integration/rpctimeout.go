@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lgnixai/wmcms/pkg/logger"
+)
+
+const (
+	// DefaultRPCTimeout 未配置 RPCTimeout 时单个RPC请求的超时
+	DefaultRPCTimeout = 30 * time.Second
+	// DefaultSlowRPCThreshold 未配置 SlowRPCThreshold 时记录慢请求的耗时阈值
+	DefaultSlowRPCThreshold = 2 * time.Second
+)
+
+// defaultRPCMethodTimeouts 耗时可能较长的方法的内置超时，可被 HandlerOptions.RPCMethodTimeouts 覆盖
+var defaultRPCMethodTimeouts = map[string]time.Duration{
+	"vault.list":      2 * time.Minute,
+	"commands.invoke": commandMaxTimeout + 5*time.Second,
+}
+
+// rpcTimeout 返回方法生效的超时
+func (h *Handler) rpcTimeout(method string) time.Duration {
+	if d, ok := h.options.RPCMethodTimeouts[method]; ok && d > 0 {
+		return d
+	}
+	if d, ok := defaultRPCMethodTimeouts[method]; ok {
+		return d
+	}
+	if h.options.RPCTimeout > 0 {
+		return h.options.RPCTimeout
+	}
+	return DefaultRPCTimeout
+}
+
+// logSlowRPC 记录耗时超过阈值的RPC请求
+func (h *Handler) logSlowRPC(method, pluginID string, start time.Time) {
+	threshold := h.options.SlowRPCThreshold
+	if threshold <= 0 {
+		threshold = DefaultSlowRPCThreshold
+	}
+	if d := time.Since(start); d >= threshold {
+		logger.Error(fmt.Sprintf("Slow RPC request %s (plugin %q)", method, pluginID), fmt.Errorf("took %s", d))
+	}
+}
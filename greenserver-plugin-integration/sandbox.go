@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrOutsideSandbox 访问的存储库路径不在插件沙箱目录内
+var ErrOutsideSandbox = errors.New("path outside plugin sandbox")
+
+// cleanVaultPath 把存储库相对路径规整为不带前导 / 的 slash 形式，存储库根目录为 "."
+func cleanVaultPath(p string) string {
+	cleaned := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(p)), "/")
+	if cleaned == "" {
+		return "."
+	}
+	return cleaned
+}
+
+// inVaultRoot 判断规整后的路径是否位于 root 之内
+func inVaultRoot(root, p string) bool {
+	return root == "." || p == root || strings.HasPrefix(p, root+"/")
+}
+
+// VaultSandboxRoot 返回插件可访问的存储库子目录，清单未声明 sandbox 时返回 false
+func (s *ServiceImpl) VaultSandboxRoot(pluginID string) (string, bool) {
+	if pluginID == "" {
+		return "", false
+	}
+	manifest, err := readManifestFile(filepath.Join(s.pluginsDir, pluginID, "manifest.json"))
+	if err != nil {
+		return "", false
+	}
+	sandbox, ok := manifest["sandbox"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	root, _ := sandbox["vaultRoot"].(string)
+	if root == "" {
+		root = "plugins/" + pluginID + "/data"
+	}
+	return cleanVaultPath(root), true
+}
+
+// checkVaultSandbox 检查插件能否访问该存储库路径，不能访问时写入 403 错误
+func (h *Handler) checkVaultSandbox(c *gin.Context, req RPCRequest, relPath string) bool {
+	root, ok := h.service.VaultSandboxRoot(req.PluginID)
+	if !ok || inVaultRoot(root, cleanVaultPath(relPath)) {
+		return true
+	}
+	h.writeRPCError(c, req.ID, 403, fmt.Errorf("%w: %s is outside %s", ErrOutsideSandbox, relPath, root).Error())
+	return false
+}
+
+// filterVaultSandbox 只保留插件沙箱目录内的路径
+func (h *Handler) filterVaultSandbox(pluginID string, paths []string) []string {
+	root, ok := h.service.VaultSandboxRoot(pluginID)
+	if !ok {
+		return paths
+	}
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if inVaultRoot(root, cleanVaultPath(p)) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
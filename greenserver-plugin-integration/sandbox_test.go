@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeTestManifest 在插件目录中写入清单
+func writeTestManifest(t *testing.T, pluginsDir, pluginID, manifest string) {
+	t.Helper()
+	dir := filepath.Join(pluginsDir, pluginID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVaultSandboxRoot(t *testing.T) {
+	dir := t.TempDir()
+	s := &ServiceImpl{pluginsDir: dir}
+	writeTestManifest(t, dir, "free", `{"id":"free"}`)
+	writeTestManifest(t, dir, "boxed", `{"id":"boxed","sandbox":{}}`)
+	writeTestManifest(t, dir, "custom", `{"id":"custom","sandbox":{"vaultRoot":"../notes/"}}`)
+
+	if _, ok := s.VaultSandboxRoot("free"); ok {
+		t.Error("plugin without sandbox should not be confined")
+	}
+	if root, _ := s.VaultSandboxRoot("boxed"); root != "plugins/boxed/data" {
+		t.Errorf("default root = %q", root)
+	}
+	if root, _ := s.VaultSandboxRoot("custom"); root != "notes" {
+		t.Errorf("custom root = %q, want notes", root)
+	}
+}
+
+func TestCheckVaultSandbox(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	writeTestManifest(t, dir, "boxed", `{"id":"boxed","sandbox":{"vaultRoot":"notes"}}`)
+	h := &Handler{service: &ServiceImpl{pluginsDir: dir}}
+	req := RPCRequest{ID: "1", PluginID: "boxed"}
+
+	cases := map[string]bool{
+		"notes/a.md":         true,
+		"notes":              true,
+		"/notes/sub/b.md":    true,
+		"notesx/a.md":        false,
+		"notes/../secret.md": false,
+		"other.md":           false,
+	}
+	for p, want := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/rpc", nil)
+		if got := h.checkVaultSandbox(c, req, p); got != want {
+			t.Errorf("checkVaultSandbox(%q) = %v, want %v", p, got, want)
+		}
+		if !want && w.Code != 403 {
+			t.Errorf("checkVaultSandbox(%q) status = %d, want 403", p, w.Code)
+		}
+	}
+
+	got := h.filterVaultSandbox("boxed", []string{"notes/a.md", "other.md", "notes/b/c.md"})
+	if want := []string{"notes/a.md", "notes/b/c.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterVaultSandbox = %v, want %v", got, want)
+	}
+}
@@ -3,66 +3,299 @@ package plugin
 import (
 	"archive/zip"
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lgnixai/wmcms/pkg/logger"
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrVaultQuotaExceeded 写入会超出用户存储库配额
+	ErrVaultQuotaExceeded = errors.New("vault quota exceeded")
+	// ErrPluginNotAllowed 插件ID被禁止或不在允许列表中（PLUGIN_NOT_ALLOWED）
+	ErrPluginNotAllowed = errors.New("PLUGIN_NOT_ALLOWED")
+	// ErrMaxPluginsReached 已安装插件数量达到上限（MAX_PLUGINS_REACHED）
+	ErrMaxPluginsReached = errors.New("MAX_PLUGINS_REACHED")
+	// ErrInvalidPluginKey 插件凭据不对应任何插件
+	ErrInvalidPluginKey = errors.New("invalid plugin key")
+	// ErrPluginKeyRequired 已配置插件凭据时，声明 pluginId 的请求必须携带凭据
+	ErrPluginKeyRequired = errors.New("plugin key required")
+	// ErrPluginIDMismatch 请求声明的 pluginId 与凭据不符
+	ErrPluginIDMismatch = errors.New("pluginId does not match credential")
+	// ErrInvalidEventName 事件名称不合法
+	ErrInvalidEventName = errors.New("invalid event name")
+	// ErrEventPayloadTooLarge 事件载荷超过大小上限
+	ErrEventPayloadTooLarge = errors.New("event payload too large")
+	// ErrInvalidKVKey 键为空、过长或包含不允许的字符
+	ErrInvalidKVKey = errors.New("invalid key")
+	// ErrInvalidLabel 标签为空、过长或包含不允许的字符
+	ErrInvalidLabel = errors.New("invalid label")
+	// ErrInstallInProgress 同一插件已有进行中的安装
+	ErrInstallInProgress = errors.New("already installing")
+	// ErrPluginNotFound 插件未安装
+	ErrPluginNotFound = errors.New("plugin not found")
+	// ErrKVKeyNotFound 键不存在
+	ErrKVKeyNotFound = errors.New("key not found")
+	// ErrKVQuotaExceeded 写入会超出插件键值存储的键数量或大小上限
+	ErrKVQuotaExceeded = errors.New("kv quota exceeded")
+	// ErrCommandTimeout 处理命令的插件未在超时前返回结果
+	ErrCommandTimeout = errors.New("command result timed out")
+	// ErrUnknownInvocation 调用ID不存在、已完成或不属于该插件
+	ErrUnknownInvocation = errors.New("unknown invocation")
+	// ErrVaultFileNotFound 存储库中不存在该文件
+	ErrVaultFileNotFound = errors.New("vault file not found")
+	// ErrVaultIsDir 读取的路径是存储库中的目录而不是文件
+	ErrVaultIsDir = errors.New("vault path is a directory")
+	// ErrVaultFileExists 目标文件已存在且未要求覆盖
+	ErrVaultFileExists = errors.New("vault file already exists")
+)
+
+// ValidationFailedError 安装请求未通过校验，包含逐字段的错误
+type ValidationFailedError struct {
+	Errors []ValidationError
+}
+
+func (e *ValidationFailedError) Error() string {
+	return fmt.Sprintf("validation failed: %v", e.Errors)
+}
+
+// Is 包含 PLUGIN_NOT_ALLOWED 时与 ErrPluginNotAllowed 匹配
+func (e *ValidationFailedError) Is(target error) bool {
+	if target != ErrPluginNotAllowed {
+		return false
+	}
+	for _, v := range e.Errors {
+		if v.Code == "PLUGIN_NOT_ALLOWED" {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	pluginIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	sha256Pattern   = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+	gitRepoPattern  = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	kvKeyPattern    = regexp.MustCompile(`^[A-Za-z0-9.:/-]{1,128}$`)
+)
+
+// DefaultMaxPluginSize 未配置 MaxPluginSize 时的插件包大小上限
+const DefaultMaxPluginSize = 10 << 20
+
+const (
+	// maxKVKeys 每个插件键值存储的键数量上限
+	maxKVKeys = 1000
+	// maxKVBytes 每个插件键值存储的总大小上限（键与值的字节数之和）
+	maxKVBytes = 1 << 20
+)
+
+const (
+	// InstallSourceGit 从 GitHub 仓库的发布版本安装
+	InstallSourceGit = "git"
+	// defaultGitHubAPIURL 未配置 GitHubAPIURL 时使用的 GitHub API 地址
+	defaultGitHubAPIURL = "https://api.github.com"
+)
+
+const (
+	// downloadTempPattern 下载暂存文件的命名模式
+	downloadTempPattern = "plugin-*.zip"
+	// StaleDownloadAge 启动清理时视为残留的暂存文件最短存在时间
+	StaleDownloadAge = time.Hour
+	// downloadProgressInterval 下载进度事件的最短间隔
+	downloadProgressInterval = 250 * time.Millisecond
+)
+
+const (
+	// commandDefaultTimeout 等待命令结果的默认超时
+	commandDefaultTimeout = 10 * time.Second
+	// commandMaxTimeout 调用方可指定的最长等待时间
+	commandMaxTimeout = 60 * time.Second
+)
+
+// maxEventPayloadBytes 插件发布事件的载荷大小上限
+const maxEventPayloadBytes = 64 << 10
+
+var eventNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
 // Service 插件服务接口
 type Service interface {
 	// Plugin management
-	GetAllPlugins() ([]*PluginResponse, error)
+	GetAllPlugins(query *PluginQuery) ([]*PluginResponse, error)
 	GetPlugin(pluginID string) (*PluginResponse, error)
 	EnablePlugin(pluginID string) error
 	DisablePlugin(pluginID string) error
 	BackupPlugin(pluginID string) (string, error)
+	WritePluginBackup(pluginID string, w io.Writer) error
 	LoadPluginsFromDisk() error
+	ReconcilePlugins() (*ReconcileResult, error)
+	BatchSetEnabled(pluginIDs []string, enabled bool) []*BatchResult
+	ListPluginFiles(pluginID, glob string) ([]*PluginFileResponse, error)
+	BatchUninstall(pluginIDs []string) []*BatchResult
+
+	// Label management
+	AddPluginLabel(pluginID, label string) error
+	RemovePluginLabel(pluginID, label string) (bool, error)
 
 	// Installation management
 	InstallPlugin(req *PluginInstallRequest) error
 	UninstallPlugin(pluginID string) error
+	PreviewUninstall(pluginID string) (*UninstallPreviewResponse, error)
 	GetInstallationStatus(pluginID string) (*InstallationStatusResponse, error)
+	RetryInstallation(pluginID string) (*InstallationStatusResponse, error)
+	SweepStaleDownloads(olderThan time.Duration) (int, error)
 
 	// Permission management
 	HasPermission(pluginID, permission string) bool
+	ResolvePluginID(key, claimed string) (string, error)
 	GetPluginPermissions(pluginID string) ([]string, error)
+	GetAllPluginPermissions() (map[string][]string, error)
+	ApprovePermissions(pluginID, actor string) ([]string, error)
+
+	// Read-only mode
+	IsReadOnly() bool
+	SetReadOnly(readOnly bool)
 
 	// Command management
 	RegisterCommand(pluginID string, req *CommandRegisterRequest) error
 	GetAllCommands() ([]*CommandResponse, error)
+	GetCommandPalette(includeDisabled bool) ([]*CommandPaletteItem, error)
+	// CommandInvokePermission 返回调用插件命令需要的权限，命令不存在或不限制时返回空字符串
+	CommandInvokePermission(pluginID, commandID string) (string, error)
 	InvokeCommand(pluginID, commandID string) error
+	InvokeCommandWait(ctx context.Context, pluginID, commandID string, timeout time.Duration) (string, json.RawMessage, error)
+	PostCommandResult(pluginID string, req *CommandResultRequest) error
+	PublishEvent(pluginID, name string, payload json.RawMessage) (string, error)
+
+	// KV operations
+	KVGet(pluginID, key string) (json.RawMessage, error)
+	KVSet(pluginID, key string, value json.RawMessage) error
+	KVDelete(pluginID, key string) (bool, error)
+	KVList(pluginID, prefix string) ([]string, error)
 
 	// Vault operations
-	ListVaultFiles(userID uint) ([]string, error)
+	ListVaultFiles(ctx context.Context, userID uint) ([]string, error)
 	ReadVaultFile(userID uint, path string) (*VaultReadResponse, error)
 	WriteVaultFile(userID uint, req *VaultWriteRequest) error
+	DeleteVaultFile(userID uint, path string) error
+	CopyVaultFile(userID uint, req *VaultCopyRequest) error
+	ImportVault(userID uint, prefix string, zr *zip.Reader) (*VaultImportResponse, error)
+	ExportVault(ctx context.Context, userID uint, prefix string, w io.Writer) error
+	// VaultSandboxRoot 返回清单 sandbox 限定的存储库子目录，未声明时返回 false
+	VaultSandboxRoot(pluginID string) (string, bool)
 
 	// Market operations
-	GetMarketItems() ([]*MarketItem, error)
+	GetMarketItems(tag string) ([]*MarketItem, error)
+	GetMarketStats() (*MarketStatsResponse, error)
+
+	// Audit
+	Audit(action, actor, target string, meta map[string]interface{})
+	GetAuditLogs(query *AuditQuery) ([]*AuditLogResponse, error)
 
 	// Event management
 	Broadcast(event *EventData)
+	GetEventHistory(query *EventHistoryQuery) ([]*EventHistoryResponse, error)
+	PruneEventHistory() (int64, error)
 	Subscribe(ctx context.Context) <-chan *EventData
 }
 
+// ServiceOptions 插件服务的可选配置
+type ServiceOptions struct {
+	AllowedPluginIDs []string // 允许安装的插件ID，为空表示不限制
+	BlockedPluginIDs []string // 禁止安装的插件ID，优先于允许列表
+	// PluginKeys 插件ID到访问凭据的映射，配置后RPC以 X-Plugin-Key 请求头确定插件身份
+	PluginKeys map[string]string
+	// VaultWritePolicy 存储库写入前调用的策略，为 nil 时允许所有写入
+	VaultWritePolicy VaultWritePolicy
+	// AllowedVaultExtensions 允许写入存储库的扩展名，如 ".md"，为空时不限制；导入同样适用
+	AllowedVaultExtensions []string
+	// BlockedVaultExtensions 禁止写入存储库的扩展名，优先于允许列表
+	BlockedVaultExtensions []string
+	// TempDir 插件下载的暂存目录，为空时使用系统临时目录
+	TempDir string
+	// GitHubAPIURL 从 Git 源安装时使用的 GitHub API 地址，为空时使用 https://api.github.com
+	GitHubAPIURL string
+	// RequireMarketSHA256 为 true 时，除本机地址外的安装请求必须提供 sha256
+	RequireMarketSHA256 bool
+	// Webhooks 接收事件通知的外部地址
+	Webhooks []WebhookConfig
+	// EventRetention 事件历史的保留时长，0 表示使用 DefaultEventRetention
+	EventRetention time.Duration
+	// EnableOnInstall 新安装的插件是否立即启用，为 nil 时启用；安装请求的 autoEnable 可覆盖
+	EnableOnInstall *bool
+	// MaxPlugins 最多可安装的插件数，0 表示不限制，升级已安装的插件不受影响
+	MaxPlugins int
+	// MaxPluginSize 插件包大小上限（字节），解压后的总大小不得超过其 maxExtractExpansion 倍，
+	// 0 表示使用 DefaultMaxPluginSize
+	MaxPluginSize int64
+	// MaxInstallRetries 失败的安装最多可通过 host.retryInstallation 重试的次数，用尽后记录转为 dead，
+	// 0 表示使用 DefaultMaxInstallRetries
+	MaxInstallRetries int
+	// StagingDir 安装时解压插件的暂存目录，完成后原子地移入插件目录，须与插件目录位于同一文件系统，
+	// 为空时使用插件目录旁的 plugin-staging
+	StagingDir string
+	// TrustedPlugins 受信任的插件ID，这些插件无需声明即拥有全部权限，其操作仍照常写入审计日志
+	TrustedPlugins []string
+	// DefaultPermissions 所有已安装插件无需声明即拥有的权限，如 ui.show、notifications.send
+	DefaultPermissions []string
+	// PreInstall 插件包校验通过、解压之前同步调用，返回错误时中止安装
+	PreInstall PluginHook
+	// OnInstall 插件安装完成后同步调用，返回的错误只记录日志
+	OnInstall PluginHook
+	// OnUninstall 插件卸载完成后同步调用，返回的错误只记录日志
+	OnUninstall PluginHook
+	// VaultStore 存储库的存储后端，为 nil 时使用基于 repo 的 RepositoryVaultStore
+	VaultStore VaultStore
+	// ReadOnly 为 true 时服务以只读模式启动，安装、卸载及写存储库等操作返回 503
+	ReadOnly bool
+}
+
+// PluginHook 服务在插件安装、卸载流程中调用的回调，manifest 为插件清单解析后的内容
+type PluginHook func(pluginID string, manifest map[string]interface{}) error
+
 // ServiceImpl 插件服务实现
 type ServiceImpl struct {
 	repo          Repository
 	pluginsDir    string
 	vaultDir      string
 	marketURL     string
+	options       ServiceOptions
 	eventHub      *EventHub
-	installations map[string]*PluginInstallation
+	installations map[string]*PluginInstallation // 进行中的安装，结束（成功或失败）后移除
 	installMutex  sync.RWMutex
+	// pluginLocks 串行化同一插件的安装与卸载
+	pluginLocks   *keyedMutex
+	invocations   map[string]*pendingInvocation
+	invocationsMu sync.Mutex
+	webhooks      []*webhook
+	// pluginLimitMu 串行化插件数量检查与插件记录创建
+	pluginLimitMu sync.Mutex
+	readOnly      atomic.Bool
+	vault         VaultStore
+}
+
+// pendingInvocation 等待结果的命令调用
+type pendingInvocation struct {
+	pluginID string
+	ch       chan *CommandResultRequest
 }
 
 // EventHub 事件中心
@@ -118,19 +351,42 @@ func (h *EventHub) Broadcast(event *EventData) {
 
 // NewService 创建插件服务实例
 func NewService(repo Repository, pluginsDir, vaultDir, marketURL string) Service {
-	return &ServiceImpl{
+	return NewServiceWithOptions(repo, pluginsDir, vaultDir, marketURL, ServiceOptions{})
+}
+
+// NewServiceWithOptions 使用可选配置创建插件服务实例
+func NewServiceWithOptions(repo Repository, pluginsDir, vaultDir, marketURL string, options ServiceOptions) Service {
+	s := &ServiceImpl{
 		repo:          repo,
 		pluginsDir:    pluginsDir,
 		vaultDir:      vaultDir,
 		marketURL:     marketURL,
+		options:       options,
 		eventHub:      NewEventHub(),
 		installations: make(map[string]*PluginInstallation),
+		pluginLocks:   newKeyedMutex(),
+		invocations:   make(map[string]*pendingInvocation),
+		webhooks:      newWebhooks(options.Webhooks),
+	}
+	s.readOnly.Store(options.ReadOnly)
+	if len(options.PluginKeys) == 0 {
+		logger.Warn("no plugin keys configured; plugins are identified only by the pluginId they claim and cannot authenticate, so RPC operations targeting a plugin require an admin")
+	}
+	s.vault = options.VaultStore
+	if s.vault == nil {
+		s.vault = NewRepositoryVaultStore(repo)
 	}
+	return s
 }
 
 // Plugin management
-func (s *ServiceImpl) GetAllPlugins() ([]*PluginResponse, error) {
-	plugins, err := s.repo.GetAllPlugins()
+func (s *ServiceImpl) GetAllPlugins(query *PluginQuery) ([]*PluginResponse, error) {
+	if query != nil && query.Label != "" {
+		q := *query
+		q.Label = strings.ToLower(strings.TrimSpace(q.Label))
+		query = &q
+	}
+	plugins, err := s.repo.GetAllPlugins(query)
 	if err != nil {
 		return nil, err
 	}
@@ -144,6 +400,58 @@ func (s *ServiceImpl) GetAllPlugins() ([]*PluginResponse, error) {
 	return responses, nil
 }
 
+// ListPluginFiles 列出已安装插件目录下的文件，路径以 / 分隔并相对于插件目录。
+// glob 不含 / 时匹配文件名，否则匹配完整相对路径；符号链接只列出自身，不会跟随。
+// 插件未安装时返回 ErrPluginNotFound
+func (s *ServiceImpl) ListPluginFiles(pluginID, glob string) ([]*PluginFileResponse, error) {
+	if _, err := s.repo.GetPluginByID(pluginID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPluginNotFound
+		}
+		return nil, err
+	}
+	if !filepath.IsLocal(pluginID) || strings.ContainsAny(pluginID, `/\`) {
+		return nil, fmt.Errorf("invalid plugin id: %s", pluginID)
+	}
+	if glob != "" {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+	}
+
+	dir := filepath.Join(s.pluginsDir, pluginID)
+	files := make([]*PluginFileResponse, 0)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if glob != "" {
+			name := rel
+			if !strings.Contains(glob, "/") {
+				name = path.Base(rel)
+			}
+			if ok, _ := path.Match(glob, name); !ok {
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, &PluginFileResponse{Path: rel, Size: info.Size(), Mode: info.Mode().String()})
+		return nil
+	})
+	return files, err
+}
+
 func (s *ServiceImpl) GetPlugin(pluginID string) (*PluginResponse, error) {
 	plugin, err := s.repo.GetPluginByID(pluginID)
 	if err != nil {
@@ -154,6 +462,10 @@ func (s *ServiceImpl) GetPlugin(pluginID string) (*PluginResponse, error) {
 }
 
 func (s *ServiceImpl) EnablePlugin(pluginID string) error {
+	if err := s.checkInstalledCompatible(pluginID); err != nil {
+		return err
+	}
+
 	err := s.repo.EnablePlugin(pluginID)
 	if err != nil {
 		return err
@@ -181,6 +493,46 @@ func (s *ServiceImpl) DisablePlugin(pluginID string) error {
 	return nil
 }
 
+// BatchSetEnabled 批量启用或禁用插件，单个失败不影响其余插件
+func (s *ServiceImpl) BatchSetEnabled(pluginIDs []string, enabled bool) []*BatchResult {
+	results := make([]*BatchResult, 0, len(pluginIDs))
+	for _, pluginID := range pluginIDs {
+		if _, err := s.repo.GetPluginByID(pluginID); err != nil {
+			results = append(results, newBatchResult(pluginID, err))
+			continue
+		}
+
+		var err error
+		if enabled {
+			err = s.EnablePlugin(pluginID)
+		} else {
+			err = s.DisablePlugin(pluginID)
+		}
+		results = append(results, newBatchResult(pluginID, err))
+	}
+	return results
+}
+
+// BatchUninstall 批量卸载插件，单个失败不影响其余插件
+func (s *ServiceImpl) BatchUninstall(pluginIDs []string) []*BatchResult {
+	results := make([]*BatchResult, 0, len(pluginIDs))
+	for _, pluginID := range pluginIDs {
+		if _, err := s.repo.GetPluginByID(pluginID); err != nil {
+			results = append(results, newBatchResult(pluginID, err))
+			continue
+		}
+		results = append(results, newBatchResult(pluginID, s.UninstallPlugin(pluginID)))
+	}
+	return results
+}
+
+func newBatchResult(pluginID string, err error) *BatchResult {
+	if err != nil {
+		return &BatchResult{PluginID: pluginID, Error: err.Error()}
+	}
+	return &BatchResult{PluginID: pluginID, Ok: true}
+}
+
 func (s *ServiceImpl) BackupPlugin(pluginID string) (string, error) {
 	plugin, err := s.repo.GetPluginByID(pluginID)
 	if err != nil {
@@ -193,53 +545,20 @@ func (s *ServiceImpl) BackupPlugin(pluginID string) (string, error) {
 		return "", fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// 生成备份文件名
-	timestamp := time.Now().Format("20060102-150405")
-	backupFileName := fmt.Sprintf("%s-v%s-%s.zip", pluginID, plugin.Version, timestamp)
-	backupPath := filepath.Join(backupDir, backupFileName)
+	backupPath := filepath.Join(backupDir, BackupFileName(pluginID, plugin.Version))
 
 	// 创建zip文件
 	zipFile, err := os.Create(backupPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to create backup file: %w", err)
 	}
-	defer zipFile.Close()
-
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
 
 	// 添加插件目录中的所有文件到zip
 	pluginDir := filepath.Join(s.pluginsDir, pluginID)
-	err = filepath.WalkDir(pluginDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if d.IsDir() {
-			return nil
-		}
-
-		// 获取相对路径
-		relPath, err := filepath.Rel(pluginDir, path)
-		if err != nil {
-			return err
-		}
-
-		// 读取文件内容
-		fileData, err := os.ReadFile(path)
-		if err != nil {
-			return err
-		}
-
-		// 添加到zip
-		zipFileWriter, err := zipWriter.Create(relPath)
-		if err != nil {
-			return err
-		}
-
-		_, err = zipFileWriter.Write(fileData)
-		return err
-	})
+	err = writeDirZip(zipFile, pluginDir)
+	if cerr := zipFile.Close(); err == nil {
+		err = cerr
+	}
 
 	if err != nil {
 		os.Remove(backupPath) // 清理失败的备份文件
@@ -263,7 +582,76 @@ func (s *ServiceImpl) BackupPlugin(pluginID string) (string, error) {
 	return backupPath, nil
 }
 
+// WritePluginBackup 把插件目录打包为 zip 直接写入 w，不在磁盘上暂存，插件未安装时返回 ErrPluginNotFound
+func (s *ServiceImpl) WritePluginBackup(pluginID string, w io.Writer) error {
+	if _, err := s.repo.GetPluginByID(pluginID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPluginNotFound
+		}
+		return err
+	}
+	pluginDir := filepath.Join(s.pluginsDir, pluginID)
+	if _, err := os.Stat(pluginDir); err != nil {
+		return fmt.Errorf("plugin directory: %w", err)
+	}
+	return writeDirZip(w, pluginDir)
+}
+
+// BackupFileName 返回插件备份的文件名，含版本号和当前时间
+func BackupFileName(pluginID, version string) string {
+	return fmt.Sprintf("%s-v%s-%s.zip", pluginID, version, time.Now().Format("20060102-150405"))
+}
+
+// writeDirZip 把目录下的所有文件按路径顺序写入 zip，文件内容逐个流式写入，
+// 不会整体读入内存；输出只取决于文件路径、内容和权限
+func writeDirZip(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		// 固定压缩方式、不写修改时间，相同内容生成的压缩包逐字节一致
+		hdr := &zip.FileHeader{Name: filepath.ToSlash(rel), Method: zip.Deflate}
+		hdr.SetMode(info.Mode())
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
 func (s *ServiceImpl) LoadPluginsFromDisk() error {
+	// 启动时清理进程异常退出遗留的下载暂存文件
+	if _, err := s.SweepStaleDownloads(StaleDownloadAge); err != nil {
+		logger.Error("Failed to sweep stale downloads", err)
+	}
+	if _, err := s.PruneEventHistory(); err != nil {
+		logger.Error("Failed to prune event history", err)
+	}
+
 	entries, err := os.ReadDir(s.pluginsDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -273,7 +661,8 @@ func (s *ServiceImpl) LoadPluginsFromDisk() error {
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		// 跳过卸载中断后遗留的目录
+		if !entry.IsDir() || strings.HasSuffix(entry.Name(), uninstallingSuffix) {
 			continue
 		}
 
@@ -291,51 +680,89 @@ func (s *ServiceImpl) LoadPluginsFromDisk() error {
 		pluginID := getStringFromMap(manifest, "id")
 		name := getStringFromMap(manifest, "name")
 		version := getStringFromMap(manifest, "version")
-		author := getStringFromMap(manifest, "author")
-		description := getStringFromMap(manifest, "description")
 
 		if pluginID == "" || name == "" || version == "" {
 			continue
 		}
 
+		// 同步清单声明的命令
+		if err := s.syncManifestCommands(pluginID, manifest); err != nil {
+			logger.Error("Failed to sync manifest commands for "+pluginID, err)
+		}
+
+		// 依赖服务未实现特性的插件保持禁用
+		compatible := s.checkCompatible(pluginID, manifest) == nil
+
 		// 检查插件是否已存在
 		existingPlugin, err := s.repo.GetPluginByID(pluginID)
 		if err == nil && existingPlugin != nil {
+			if existingPlugin.Enabled && !compatible {
+				if err := s.repo.DisablePlugin(pluginID); err != nil {
+					logger.Error("Failed to disable incompatible plugin: "+pluginID, err)
+				}
+			}
 			continue // 插件已存在，跳过
 		}
 
-		// 创建新插件记录
-		plugin := &Plugin{
-			PluginID:    pluginID,
-			Name:        name,
-			Version:     version,
-			Author:      author,
-			Description: description,
-			Enabled:     true,
-		}
-
-		if err := s.repo.CreatePlugin(plugin); err != nil {
+		if err := s.createDiskPlugin(manifest, compatible); err != nil {
 			logger.Error("Failed to create plugin from disk: "+pluginID, err)
-			continue
 		}
+	}
 
-		// 处理权限
-		if permissions, ok := manifest["permissions"].([]interface{}); ok {
-			for _, perm := range permissions {
-				if permStr, ok := perm.(string); ok {
-					if err := s.repo.AddPluginPermission(pluginID, permStr); err != nil {
-						logger.Error("Failed to add plugin permission for "+pluginID+":"+permStr, err)
-					}
+	return nil
+}
+
+// createDiskPlugin 为磁盘上已有的插件创建记录并授予清单声明的权限
+func (s *ServiceImpl) createDiskPlugin(manifest map[string]interface{}, enabled bool) error {
+	pluginID := getStringFromMap(manifest, "id")
+	plugin := &Plugin{
+		PluginID:    pluginID,
+		Name:        getStringFromMap(manifest, "name"),
+		Version:     getStringFromMap(manifest, "version"),
+		Author:      getStringFromMap(manifest, "author"),
+		Description: getStringFromMap(manifest, "description"),
+		Enabled:     enabled,
+	}
+
+	if err := s.repo.CreatePlugin(plugin); err != nil {
+		return err
+	}
+
+	// 处理权限
+	if permissions, ok := manifest["permissions"].([]interface{}); ok {
+		for _, perm := range permissions {
+			if permStr, ok := perm.(string); ok {
+				if err := s.repo.AddPluginPermission(pluginID, permStr); err != nil {
+					logger.Error("Failed to add plugin permission for "+pluginID+":"+permStr, err)
+					continue
 				}
+				s.Audit("permission.grant", "system", pluginID, map[string]interface{}{"permission": permStr})
 			}
 		}
 	}
-
 	return nil
 }
 
 // Installation management
 func (s *ServiceImpl) InstallPlugin(req *PluginInstallRequest) error {
+	if req.Source != "" && req.Source != InstallSourceGit {
+		return &ValidationFailedError{Errors: []ValidationError{newValidationError("source", "INVALID_SOURCE")}}
+	}
+	if req.Source == InstallSourceGit {
+		assetURL, err := s.resolveGitRelease(req.Repo, req.Ref)
+		if err != nil {
+			return &ValidationFailedError{Errors: []ValidationError{newValidationError("repo", "GIT_RESOLVE_FAILED", err)}}
+		}
+		req.URL = assetURL
+	}
+
+	if err := s.validateInstallRequest(req); err != nil {
+		return err
+	}
+	if err := s.checkPluginLimit(req.ID); err != nil {
+		return err
+	}
+
 	// 创建安装记录
 	installation := &PluginInstallation{
 		PluginID:  req.ID,
@@ -346,114 +773,425 @@ func (s *ServiceImpl) InstallPlugin(req *PluginInstallRequest) error {
 		SHA256:    req.SHA256,
 	}
 
-	if err := s.repo.CreateInstallation(installation); err != nil {
-		return err
-	}
-
+	// 同一插件同时只允许一个进行中的安装，先占位再写入安装记录
 	s.installMutex.Lock()
+	if _, ok := s.installations[req.ID]; ok {
+		s.installMutex.Unlock()
+		return fmt.Errorf("%w: %s", ErrInstallInProgress, req.ID)
+	}
 	s.installations[req.ID] = installation
 	s.installMutex.Unlock()
 
+	if err := s.repo.CreateInstallation(installation); err != nil {
+		s.installMutex.Lock()
+		delete(s.installations, req.ID)
+		s.installMutex.Unlock()
+		return err
+	}
+
 	// 异步执行安装
 	go s.performInstallation(req)
 
 	return nil
 }
 
-func (s *ServiceImpl) performInstallation(req *PluginInstallRequest) {
-	installation, exists := s.getInstallation(req.ID)
-	if !exists {
-		return
+// validateInstallRequest 逐字段校验安装请求，失败时返回 *ValidationFailedError
+func (s *ServiceImpl) validateInstallRequest(req *PluginInstallRequest) error {
+	var errs []ValidationError
+
+	switch {
+	case req.ID == "":
+		errs = append(errs, newValidationError("id", "EMPTY_ID"))
+	case !pluginIDPattern.MatchString(req.ID):
+		errs = append(errs, newValidationError("id", "INVALID_ID_FORMAT"))
+	case len(req.ID) > 50:
+		errs = append(errs, newValidationError("id", "ID_TOO_LONG"))
+	default:
+		if err := s.checkPluginAllowed(req.ID); err != nil {
+			errs = append(errs, newValidationError("id", "PLUGIN_NOT_ALLOWED", req.ID))
+		}
 	}
 
-	// 更新状态
-	updateStatus := func(status string, progress int, message string) {
-		installation.Status = status
-		installation.Progress = progress
-		installation.Message = message
-		s.repo.UpdateInstallation(installation)
+	if req.URL == "" {
+		errs = append(errs, newValidationError("url", "EMPTY_URL"))
+	} else if u, err := url.Parse(req.URL); err != nil || u.Host == "" {
+		errs = append(errs, newValidationError("url", "INVALID_URL"))
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		errs = append(errs, newValidationError("url", "INSECURE_PROTOCOL"))
+	}
 
-		s.Broadcast(&EventData{
-			Type: "plugin.installation.progress",
-			Data: map[string]interface{}{
-				"pluginId": req.ID,
-				"status":   status,
-				"progress": progress,
-				"message":  message,
-			},
-		})
+	if req.SHA256 != "" && !sha256Pattern.MatchString(req.SHA256) {
+		errs = append(errs, newValidationError("sha256", "INVALID_HASH_FORMAT"))
+	} else if req.SHA256 == "" && s.options.RequireMarketSHA256 && !isLocalURL(req.URL) {
+		errs = append(errs, newValidationError("sha256", "INTEGRITY_REQUIRED"))
 	}
 
-	// 下载文件
-	updateStatus("downloading", 10, "正在下载插件文件")
-	tempFile, err := s.downloadFile(req.URL)
+	if len(errs) > 0 {
+		return &ValidationFailedError{Errors: errs}
+	}
+	return nil
+}
+
+// resolveGitRelease 通过 GitHub API 把 owner/repo 与 ref（发布标签，为空时取最新发布）
+// 解析为发布版本中第一个 zip 资源的下载地址，解析结果仍需经过常规的安装校验
+func (s *ServiceImpl) resolveGitRelease(repo, ref string) (string, error) {
+	if !gitRepoPattern.MatchString(repo) || strings.Contains(repo, "..") {
+		return "", fmt.Errorf("invalid repo %q, expected owner/name", repo)
+	}
+	api := strings.TrimRight(s.options.GitHubAPIURL, "/")
+	if api == "" {
+		api = defaultGitHubAPIURL
+	}
+	endpoint := api + "/repos/" + repo + "/releases/latest"
+	if ref != "" {
+		endpoint = api + "/repos/" + repo + "/releases/tags/" + url.PathEscape(ref)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
-		updateStatus("failed", 0, fmt.Sprintf("下载失败: %v", err))
-		return
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("query release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("release not found: %s@%s", repo, ref)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("query release failed with status: %d", resp.StatusCode)
 	}
-	defer os.Remove(tempFile)
 
-	// 校验文件
-	if req.SHA256 != "" {
-		updateStatus("verifying", 30, "正在校验文件")
-		if err := s.verifyFile(tempFile, req.SHA256); err != nil {
-			updateStatus("failed", 0, fmt.Sprintf("文件校验失败: %v", err))
-			return
+	var release struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("invalid release response: %w", err)
+	}
+	for _, a := range release.Assets {
+		if strings.HasSuffix(a.Name, ".zip") && a.URL != "" {
+			return a.URL, nil
 		}
 	}
+	return "", fmt.Errorf("release %s of %s has no zip asset", release.TagName, repo)
+}
 
-	// 解压文件
-	updateStatus("extracting", 50, "正在解压插件文件")
-	pluginDir := filepath.Join(s.pluginsDir, req.ID)
-	if err := s.extractZip(tempFile, pluginDir); err != nil {
-		updateStatus("failed", 0, fmt.Sprintf("解压失败: %v", err))
-		return
+// isLocalURL 判断下载地址是否指向本机，本地开发安装不要求 sha256
+func isLocalURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
 	}
-
-	// 读取manifest文件
-	updateStatus("configuring", 80, "正在配置插件")
-	manifestPath := filepath.Join(pluginDir, "manifest.json")
-	if err := s.loadPluginFromManifest(manifestPath); err != nil {
-		updateStatus("failed", 0, fmt.Sprintf("配置插件失败: %v", err))
-		return
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
 	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
 
-	// 完成安装
-	now := time.Now()
-	installation.Status = "installed"
-	installation.Progress = 100
+// checkPluginLimit 安装新插件会超出 MaxPlugins 时返回 ErrMaxPluginsReached，已安装的插件不受限制
+func (s *ServiceImpl) checkPluginLimit(pluginID string) error {
+	if s.options.MaxPlugins <= 0 {
+		return nil
+	}
+	if _, err := s.repo.GetPluginByID(pluginID); err == nil {
+		return nil
+	}
+	count, err := s.repo.CountPlugins()
+	if err != nil {
+		return err
+	}
+	if count >= int64(s.options.MaxPlugins) {
+		return fmt.Errorf("%w: maximum of %d plugins", ErrMaxPluginsReached, s.options.MaxPlugins)
+	}
+	return nil
+}
+
+// checkPluginAllowed 检查插件ID的允许/禁止列表，禁止列表优先
+func (s *ServiceImpl) checkPluginAllowed(pluginID string) error {
+	for _, blocked := range s.options.BlockedPluginIDs {
+		if blocked == pluginID {
+			return fmt.Errorf("%w: plugin %s is blocked", ErrPluginNotAllowed, pluginID)
+		}
+	}
+
+	if len(s.options.AllowedPluginIDs) > 0 {
+		for _, allowed := range s.options.AllowedPluginIDs {
+			if allowed == pluginID {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: plugin %s is not in the allowlist", ErrPluginNotAllowed, pluginID)
+	}
+
+	return nil
+}
+
+func (s *ServiceImpl) performInstallation(req *PluginInstallRequest) {
+	unlock := s.pluginLocks.Lock(req.ID)
+	defer unlock()
+
+	installation, exists := s.getInstallation(req.ID)
+	if !exists {
+		return
+	}
+	defer func() {
+		s.installMutex.Lock()
+		delete(s.installations, req.ID)
+		s.installMutex.Unlock()
+	}()
+
+	// 更新状态并广播进度
+	phase := ""
+	updateStatus := func(status string, progress int, message string) {
+		phase = status
+		installation.Status = status
+		installation.Progress = progress
+		installation.Message = message
+		s.repo.UpdateInstallation(installation)
+
+		s.Broadcast(&EventData{
+			Type: "plugin.installation.progress",
+			Data: InstallProgress{
+				PluginID: req.ID,
+				Status:   status,
+				Progress: progress,
+				Message:  message,
+				Phase:    phase,
+			},
+		})
+	}
+	// fail 记录失败状态，失败是终态，额外发出带 terminal 标记的 plugin.installation.failed；
+	// 重试次数已达上限时记录转为 dead 并发出 plugin.installation.dead
+	fail := func(message string, err error) {
+		installation.Status = "failed"
+		if installation.Attempts >= s.maxInstallRetries() {
+			installation.Status = "dead"
+		}
+		installation.Progress = 0
+		installation.Message = fmt.Sprintf("%s: %v", message, err)
+		s.repo.UpdateInstallation(installation)
+
+		progress := InstallProgress{
+			PluginID: req.ID,
+			Status:   installation.Status,
+			Message:  installation.Message,
+			Phase:    phase,
+			Error:    err.Error(),
+			Terminal: true,
+		}
+		s.Broadcast(&EventData{Type: "plugin.installation.progress", Data: progress})
+		s.Broadcast(&EventData{Type: "plugin.installation.failed", Data: progress})
+		if installation.Status == "dead" {
+			s.Broadcast(&EventData{Type: "plugin.installation.dead", Data: progress})
+		}
+	}
+
+	// 下载文件
+	updateStatus("downloading", 10, "正在下载插件文件")
+	// 下载过程中广播字节数、速率和剩余时间，总长度已知时进度在 10 到 30 之间推进
+	tempFile, err := s.downloadFile(req.URL, func(p InstallProgress) {
+		p.PluginID = req.ID
+		p.Status = phase
+		p.Phase = phase
+		p.Progress = 10
+		if p.TotalBytes > 0 {
+			p.Progress += int(min(p.BytesDownloaded, p.TotalBytes) * 20 / p.TotalBytes)
+		}
+		p.Message = installation.Message
+		s.Broadcast(&EventData{Type: "plugin.installation.progress", Data: p})
+	})
+	if err != nil {
+		fail("下载失败", err)
+		return
+	}
+	defer os.Remove(tempFile)
+
+	// 校验文件
+	if req.SHA256 != "" {
+		updateStatus("verifying", 30, "正在校验文件")
+		if err := s.verifyFile(tempFile, req.SHA256); err != nil {
+			fail("文件校验失败", err)
+			return
+		}
+	}
+
+	// 安装前回调可以否决本次安装
+	if hook := s.options.PreInstall; hook != nil {
+		manifest, err := readZipManifest(tempFile)
+		if err != nil {
+			fail("读取插件清单失败", err)
+			return
+		}
+		if err := hook(req.ID, manifest); err != nil {
+			fail("安装被拒绝", err)
+			return
+		}
+	}
+
+	// 解压到暂存目录，校验清单后再整体移入插件目录，加载插件时不会看到解压了一半的文件
+	updateStatus("extracting", 50, "正在解压插件文件")
+	stage, err := s.newStagingDir(req.ID)
+	if err != nil {
+		fail("创建暂存目录失败", err)
+		return
+	}
+	defer os.RemoveAll(stage)
+	if err := s.extractZip(tempFile, stage); err != nil {
+		fail("解压失败", err)
+		return
+	}
+	if _, err := readManifestFile(filepath.Join(stage, "manifest.json")); err != nil {
+		fail("读取插件清单失败", err)
+		return
+	}
+
+	// 读取manifest文件
+	updateStatus("configuring", 80, "正在配置插件")
+	pluginDir := filepath.Join(s.pluginsDir, req.ID)
+	previous, err := s.swapPluginDir(stage, pluginDir)
+	if err != nil {
+		fail("安装插件文件失败", err)
+		return
+	}
+	manifestPath := filepath.Join(pluginDir, "manifest.json")
+	enabled := s.options.EnableOnInstall == nil || *s.options.EnableOnInstall
+	if req.AutoEnable != nil {
+		enabled = *req.AutoEnable
+	}
+	if err := s.loadPluginFromManifest(manifestPath, enabled); err != nil {
+		s.restorePluginDir(pluginDir, previous)
+		fail("配置插件失败", err)
+		return
+	}
+	if previous != "" {
+		if err := os.RemoveAll(previous); err != nil {
+			logger.Error("Failed to remove previous plugin directory: "+pluginDir, err)
+		}
+	}
+	// 重新安装已有插件时保持原有的启用状态
+	requiresApproval := false
+	if plugin, err := s.repo.GetPluginByID(req.ID); err == nil {
+		enabled = plugin.Enabled
+		requiresApproval = plugin.PendingPermissions != ""
+	}
+
+	// 完成安装
+	now := time.Now()
+	installation.Status = "installed"
+	installation.Progress = 100
 	installation.Message = "安装完成"
 	installation.InstalledAt = &now
 	s.repo.UpdateInstallation(installation)
 
+	if hook := s.options.OnInstall; hook != nil {
+		manifest, err := readManifestFile(manifestPath)
+		if err == nil {
+			err = hook(req.ID, manifest)
+		}
+		if err != nil {
+			logger.Error("Install hook failed for plugin: "+req.ID, err)
+		}
+	}
+
 	s.Broadcast(&EventData{
 		Type: "plugin.installed",
 		Data: map[string]interface{}{
 			"pluginId": req.ID,
+			"enabled":  enabled,
+		},
+	})
+	s.Broadcast(&EventData{
+		Type: "plugin.installation.done",
+		Data: InstallProgress{
+			PluginID:         req.ID,
+			Status:           installation.Status,
+			Progress:         installation.Progress,
+			Terminal:         true,
+			RequiresApproval: requiresApproval,
 		},
 	})
 }
 
+// uninstallingSuffix 卸载过程中插件目录临时改名使用的后缀
+const uninstallingSuffix = ".uninstalling"
+
+// UninstallPlugin 卸载插件：先把插件目录移到一旁，在同一事务中删除插件、命令和安装记录，
+// 事务提交后再删除目录，事务失败时把目录移回原处
 func (s *ServiceImpl) UninstallPlugin(pluginID string) error {
-	// 删除插件目录
+	unlock := s.pluginLocks.Lock(pluginID)
+	defer unlock()
+
 	pluginDir := filepath.Join(s.pluginsDir, pluginID)
-	if err := os.RemoveAll(pluginDir); err != nil {
-		return fmt.Errorf("failed to remove plugin directory: %w", err)
+	trashDir := pluginDir + uninstallingSuffix
+
+	var manifest map[string]interface{}
+	if s.options.OnUninstall != nil {
+		var err error
+		if manifest, err = readManifestFile(filepath.Join(pluginDir, "manifest.json")); err != nil {
+			manifest = map[string]interface{}{"id": pluginID}
+		}
 	}
 
-	// 删除数据库记录
-	if err := s.repo.DeletePlugin(pluginID); err != nil {
-		return err
+	moved := false
+	if _, err := os.Stat(pluginDir); err == nil {
+		if err := os.RemoveAll(trashDir); err != nil {
+			return fmt.Errorf("failed to clear stale plugin directory: %w", err)
+		}
+		if err := os.Rename(pluginDir, trashDir); err != nil {
+			return fmt.Errorf("failed to move plugin directory: %w", err)
+		}
+		moved = true
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat plugin directory: %w", err)
 	}
 
-	// 删除安装记录
-	s.repo.DeleteInstallation(pluginID)
+	err := s.repo.Transaction(func(repo Repository) error {
+		if err := repo.DeleteCommandsByPluginID(pluginID); err != nil {
+			return err
+		}
+		if err := repo.DeleteLabelsByPluginID(pluginID); err != nil {
+			return err
+		}
+		if err := repo.DeletePlugin(pluginID); err != nil {
+			return err
+		}
+		return repo.DeleteInstallation(pluginID)
+	})
+	if err != nil {
+		if moved {
+			if restoreErr := os.Rename(trashDir, pluginDir); restoreErr != nil {
+				logger.Error("Failed to restore plugin directory: "+pluginID, restoreErr)
+			}
+		}
+		return fmt.Errorf("failed to delete plugin records: %w", err)
+	}
+
+	if moved {
+		if err := os.RemoveAll(trashDir); err != nil {
+			logger.Error("Failed to remove plugin directory: "+pluginID, err)
+		}
+	}
 
 	s.Broadcast(&EventData{
 		Type: "plugin.uninstalled",
 		Data: map[string]interface{}{"pluginId": pluginID},
 	})
 
+	if hook := s.options.OnUninstall; hook != nil {
+		if err := hook(pluginID, manifest); err != nil {
+			logger.Error("Uninstall hook failed for plugin: "+pluginID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -468,141 +1206,806 @@ func (s *ServiceImpl) GetInstallationStatus(pluginID string) (*InstallationStatu
 		Status:      installation.Status,
 		Progress:    installation.Progress,
 		Message:     installation.Message,
+		Attempts:    installation.Attempts,
 		InstalledAt: installation.InstalledAt,
 	}, nil
 }
 
-// Permission management
-func (s *ServiceImpl) HasPermission(pluginID, permission string) bool {
-	permissions, err := s.repo.GetPluginPermissions(pluginID)
+// Permission management
+// isTrustedPlugin 判断插件是否在 ServiceOptions.TrustedPlugins 中
+func (s *ServiceImpl) isTrustedPlugin(pluginID string) bool {
+	for _, id := range s.options.TrustedPlugins {
+		if id == pluginID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ServiceImpl) HasPermission(pluginID, permission string) bool {
+	permissions, err := s.repo.GetPluginPermissions(pluginID)
+	if err != nil {
+		return false
+	}
+	if s.isTrustedPlugin(pluginID) {
+		return true
+	}
+	for _, perm := range s.options.DefaultPermissions {
+		if perm == permission {
+			return true
+		}
+	}
+
+	for _, perm := range permissions {
+		if perm == permission || perm == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvePluginID 根据凭据确定生效的插件ID：携带凭据时以凭据为准并拒绝不一致的声明；
+// 未配置任何插件凭据时沿用请求中声明的 pluginId，这样得到的ID只用于权限检查，不能证明调用方身份
+func (s *ServiceImpl) ResolvePluginID(key, claimed string) (string, error) {
+	if key == "" {
+		if len(s.options.PluginKeys) > 0 && claimed != "" {
+			return "", ErrPluginKeyRequired
+		}
+		return claimed, nil
+	}
+	for id, want := range s.options.PluginKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(want)) == 1 {
+			if claimed != "" && claimed != id {
+				return "", ErrPluginIDMismatch
+			}
+			return id, nil
+		}
+	}
+	return "", ErrInvalidPluginKey
+}
+
+func (s *ServiceImpl) GetPluginPermissions(pluginID string) ([]string, error) {
+	return s.repo.GetPluginPermissions(pluginID)
+}
+
+// GetAllPluginPermissions 返回全部插件已授予的权限，供权限矩阵一次取得
+func (s *ServiceImpl) GetAllPluginPermissions() (map[string][]string, error) {
+	return s.repo.GetAllPluginPermissions()
+}
+
+// Command management
+func (s *ServiceImpl) RegisterCommand(pluginID string, req *CommandRegisterRequest) error {
+	command := &Command{
+		CommandID: req.ID,
+		PluginID:  pluginID,
+		Title:     req.Title,
+		Category:  req.Category,
+		Hotkey:    req.Hotkey,
+		Source:    CommandSourceRuntime,
+
+		InvokePermission: req.InvokePermission,
+	}
+
+	return s.repo.CreateCommand(command)
+}
+
+// syncManifestCommands 用清单 commands 数组替换插件之前由清单注册的命令，运行时注册的命令保持不变
+func (s *ServiceImpl) syncManifestCommands(pluginID string, manifest map[string]interface{}) error {
+	return s.repo.Transaction(func(repo Repository) error {
+		return syncManifestCommandsIn(repo, pluginID, manifest)
+	})
+}
+
+// syncManifestCommandsIn 在调用方的事务中用清单声明替换插件的清单命令
+func syncManifestCommandsIn(repo Repository, pluginID string, manifest map[string]interface{}) error {
+	declared, _ := manifest["commands"].([]interface{})
+
+	if err := repo.DeleteCommandsBySource(pluginID, CommandSourceManifest); err != nil {
+		return err
+	}
+	for _, item := range declared {
+		cmd, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		commandID := getStringFromMap(cmd, "id")
+		title := getStringFromMap(cmd, "title")
+		if commandID == "" || title == "" {
+			continue
+		}
+		if err := repo.CreateCommand(&Command{
+			CommandID: commandID,
+			PluginID:  pluginID,
+			Title:     title,
+			Category:  getStringFromMap(cmd, "category"),
+			Hotkey:    getStringFromMap(cmd, "hotkey"),
+			Source:    CommandSourceManifest,
+
+			InvokePermission: getStringFromMap(cmd, "invokePermission"),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ServiceImpl) GetAllCommands() ([]*CommandResponse, error) {
+	commands, err := s.repo.GetAllCommands()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*CommandResponse, 0, len(commands))
+	for _, cmd := range commands {
+		responses = append(responses, &CommandResponse{
+			ID:          cmd.ID,
+			CommandID:   cmd.CommandID,
+			PluginID:    cmd.PluginID,
+			Title:       cmd.Title,
+			Description: cmd.Description,
+			Category:    cmd.Category,
+			Hotkey:      cmd.Hotkey,
+			Source:      cmd.Source,
+
+			InvokePermission: cmd.InvokePermission,
+		})
+	}
+
+	return responses, nil
+}
+
+// PublishEvent 以 plugin:<id>:<name> 为类型广播插件自定义事件
+func (s *ServiceImpl) PublishEvent(pluginID, name string, payload json.RawMessage) (string, error) {
+	if !eventNamePattern.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidEventName, name)
+	}
+	if len(payload) > maxEventPayloadBytes {
+		return "", fmt.Errorf("%w: %d bytes exceeds %d", ErrEventPayloadTooLarge, len(payload), maxEventPayloadBytes)
+	}
+	eventType := "plugin:" + pluginID + ":" + name
+	s.Broadcast(&EventData{
+		Type: eventType,
+		Data: map[string]interface{}{
+			"pluginId": pluginID,
+			"payload":  payload,
+		},
+	})
+	return eventType, nil
+}
+
+// CommandInvokePermission 返回插件命令的 invokePermission，同一命令注册多次时以最后一次为准
+func (s *ServiceImpl) CommandInvokePermission(pluginID, commandID string) (string, error) {
+	commands, err := s.repo.GetCommandsByPluginID(pluginID)
+	if err != nil {
+		return "", err
+	}
+	perm := ""
+	for _, cmd := range commands {
+		if cmd.CommandID == commandID {
+			perm = cmd.InvokePermission
+		}
+	}
+	return perm, nil
+}
+
+func (s *ServiceImpl) InvokeCommand(pluginID, commandID string) error {
+	s.Broadcast(&EventData{
+		Type: "command.invoked",
+		Data: map[string]interface{}{
+			"pluginId":  pluginID,
+			"commandId": commandID,
+		},
+	})
+	return nil
+}
+
+// InvokeCommandWait 广播带调用ID的 command.invoked 事件，并等待处理命令的插件提交结果
+func (s *ServiceImpl) InvokeCommandWait(ctx context.Context, pluginID, commandID string, timeout time.Duration) (string, json.RawMessage, error) {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	id := hex.EncodeToString(b)
+
+	inv := &pendingInvocation{pluginID: pluginID, ch: make(chan *CommandResultRequest, 1)}
+	s.invocationsMu.Lock()
+	s.invocations[id] = inv
+	s.invocationsMu.Unlock()
+	defer func() {
+		s.invocationsMu.Lock()
+		delete(s.invocations, id)
+		s.invocationsMu.Unlock()
+	}()
+
+	s.Broadcast(&EventData{
+		Type: "command.invoked",
+		Data: map[string]interface{}{
+			"pluginId":     pluginID,
+			"commandId":    commandID,
+			"invocationId": id,
+		},
+	})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-inv.ch:
+		if res.Error != "" {
+			return id, nil, fmt.Errorf("command failed: %s", res.Error)
+		}
+		return id, res.Result, nil
+	case <-timer.C:
+		return id, nil, fmt.Errorf("%w after %s", ErrCommandTimeout, timeout)
+	case <-ctx.Done():
+		return id, nil, ctx.Err()
+	}
+}
+
+// PostCommandResult 把插件提交的结果交给等待中的调用方，每个调用只接受一次结果
+func (s *ServiceImpl) PostCommandResult(pluginID string, req *CommandResultRequest) error {
+	s.invocationsMu.Lock()
+	inv, ok := s.invocations[req.InvocationID]
+	if ok && inv.pluginID == pluginID {
+		delete(s.invocations, req.InvocationID)
+	}
+	s.invocationsMu.Unlock()
+	if !ok || inv.pluginID != pluginID {
+		return ErrUnknownInvocation
+	}
+	inv.ch <- req
+	return nil
+}
+
+// KV operations
+// KVGet 读取插件键值存储中的值，键不存在时返回 ErrKVKeyNotFound
+func (s *ServiceImpl) KVGet(pluginID, key string) (json.RawMessage, error) {
+	if !kvKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidKVKey, key)
+	}
+	kv, err := s.repo.GetKV(pluginID, key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKVKeyNotFound
+		}
+		return nil, err
+	}
+	return json.RawMessage(kv.Value), nil
+}
+
+// KVSet 写入键值，超出键数量或总大小上限时返回 ErrKVQuotaExceeded
+func (s *ServiceImpl) KVSet(pluginID, key string, value json.RawMessage) error {
+	if !kvKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKVKey, key)
+	}
+	return s.repo.Transaction(func(repo Repository) error {
+		count, size, err := repo.GetKVUsage(pluginID)
+		if err != nil {
+			return err
+		}
+		existing, err := repo.GetKV(pluginID, key)
+		switch {
+		case err == nil:
+			size -= int64(len(existing.Key) + len(existing.Value))
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if count >= maxKVKeys {
+				return fmt.Errorf("%w: at most %d keys", ErrKVQuotaExceeded, maxKVKeys)
+			}
+		default:
+			return err
+		}
+		if size+int64(len(key)+len(value)) > maxKVBytes {
+			return fmt.Errorf("%w: at most %d bytes", ErrKVQuotaExceeded, maxKVBytes)
+		}
+		return repo.SetKV(&PluginKV{PluginID: pluginID, Key: key, Value: string(value)})
+	})
+}
+
+// KVDelete 删除键，返回键是否存在
+func (s *ServiceImpl) KVDelete(pluginID, key string) (bool, error) {
+	if !kvKeyPattern.MatchString(key) {
+		return false, fmt.Errorf("%w: %q", ErrInvalidKVKey, key)
+	}
+	return s.repo.DeleteKV(pluginID, key)
+}
+
+// KVList 按字典序列出以 prefix 开头的键
+func (s *ServiceImpl) KVList(pluginID, prefix string) ([]string, error) {
+	if prefix != "" && !kvKeyPattern.MatchString(prefix) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidKVKey, prefix)
+	}
+	return s.repo.ListKVKeys(pluginID, prefix)
+}
+
+// Vault operations
+// ListVaultFiles 列出用户存储库中的文件路径，ctx 取消时中止查询
+func (s *ServiceImpl) ListVaultFiles(ctx context.Context, userID uint) ([]string, error) {
+	return s.vault.List(ctx, userID)
+}
+
+func (s *ServiceImpl) ReadVaultFile(userID uint, path string) (*VaultReadResponse, error) {
+	content, err := s.vault.Read(userID, path)
+	if errors.Is(err, ErrVaultFileNotFound) && s.isVaultDir(userID, path) {
+		return nil, fmt.Errorf("%w: %s", ErrVaultIsDir, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &VaultReadResponse{
+		Path:    filepath.Clean(path),
+		Content: string(content),
+	}, nil
+}
+
+// isVaultDir 判断 path 下是否有文件。存储库只保存文件，目录由文件路径隐含
+func (s *ServiceImpl) isVaultDir(userID uint, path string) bool {
+	paths, err := s.vault.List(context.Background(), userID)
+	if err != nil {
+		return false
+	}
+	prefix := filepath.Clean(path) + "/"
+	for _, p := range paths {
+		if strings.HasPrefix(filepath.ToSlash(p), filepath.ToSlash(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ServiceImpl) WriteVaultFile(userID uint, req *VaultWriteRequest) error {
+	if err := s.checkVaultWrite(req.Path, []byte(req.Content)); err != nil {
+		return err
+	}
+
+	// 检查配额，覆盖已有文件时只计算增量
+	var existingSize int64
+	if existing, err := s.vault.Stat(userID, req.Path); err == nil {
+		existingSize = existing.Size
+	} else if !errors.Is(err, ErrVaultFileNotFound) {
+		return err
+	}
+	if err := s.checkVaultQuota(userID, int64(len(req.Content))-existingSize); err != nil {
+		return err
+	}
+
+	return s.vault.Write(userID, req.Path, []byte(req.Content))
+}
+
+// CopyVaultFile 复制存储库文件，后端支持时新文件与源文件共用同一数据块。
+// 目标已存在且未设置 Overwrite 时返回 ErrVaultFileExists
+func (s *ServiceImpl) CopyVaultFile(userID uint, req *VaultCopyRequest) error {
+	src, err := s.vault.Stat(userID, req.From)
+	if err != nil {
+		return err
+	}
+	content, err := s.vault.Read(userID, req.From)
+	if err != nil {
+		return err
+	}
+	if err := s.checkVaultWrite(req.To, content); err != nil {
+		return err
+	}
+
+	var existingSize int64
+	dst, err := s.vault.Stat(userID, req.To)
+	switch {
+	case err == nil:
+		if !req.Overwrite {
+			return fmt.Errorf("%w: %s", ErrVaultFileExists, req.To)
+		}
+		existingSize = dst.Size
+	case !errors.Is(err, ErrVaultFileNotFound):
+		return err
+	}
+	if err := s.checkVaultQuota(userID, src.Size-existingSize); err != nil {
+		return err
+	}
+
+	if copier, ok := s.vault.(vaultCopier); ok {
+		return copier.Copy(userID, req.From, req.To)
+	}
+	return s.vault.Write(userID, req.To, content)
+}
+
+// maxVaultImportBytes 单次导入解压后内容的总大小上限
+const maxVaultImportBytes = 100 << 20
+
+// vaultImportPath 把压缩包条目名映射到 prefix 下的存储库相对路径，
+// 绝对路径、包含 .. 或反斜杠的条目返回 false
+func vaultImportPath(prefix, name string) (string, bool) {
+	clean := func(p string) (string, bool) {
+		if strings.Contains(p, `\`) || path.IsAbs(p) {
+			return "", false
+		}
+		p = path.Clean(p)
+		if p == ".." || strings.HasPrefix(p, "../") {
+			return "", false
+		}
+		return p, true
+	}
+	pre, ok := clean(prefix)
+	if !ok {
+		return "", false
+	}
+	rel, ok := clean(name)
+	if !ok || rel == "." {
+		return "", false
+	}
+	return path.Join(pre, rel), true
+}
+
+// ImportVault 把压缩包中的文件写入用户存储库 prefix 目录下，逐个检查配额和写入策略，
+// 不符合要求的条目跳过并记录原因，完成后广播一次 vault.imported；
+// 疑似压缩炸弹时中止导入并返回 ErrZipBomb
+func (s *ServiceImpl) ImportVault(userID uint, prefix string, zr *zip.Reader) (*VaultImportResponse, error) {
+	if _, ok := vaultImportPath(prefix, "x"); !ok {
+		return nil, fmt.Errorf("invalid prefix: %s", prefix)
+	}
+
+	result := &VaultImportResponse{Created: []string{}, Updated: []string{}, Skipped: []VaultImportSkip{}}
+	skip := func(name, reason string) {
+		result.Skipped = append(result.Skipped, VaultImportSkip{Path: name, Reason: reason})
+	}
+	if err := checkZipEntries(zr); err != nil {
+		return nil, err
+	}
+	guard := &zipGuard{}
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rel, ok := vaultImportPath(prefix, f.Name)
+		if !ok {
+			skip(f.Name, "invalid path")
+			continue
+		}
+		if err := checkZipEntryType(f); err != nil {
+			skip(f.Name, "unsupported entry type")
+			continue
+		}
+
+		rc, err := guard.open(f)
+		if err != nil {
+			skip(f.Name, err.Error())
+			continue
+		}
+		// 不信任条目头中声明的大小
+		data, err := io.ReadAll(io.LimitReader(rc, maxVaultImportBytes-total+1))
+		rc.Close()
+		if errors.Is(err, ErrZipBomb) {
+			return result, err
+		}
+		if err != nil {
+			skip(f.Name, err.Error())
+			continue
+		}
+		if total+int64(len(data)) > maxVaultImportBytes {
+			skip(f.Name, "import size limit exceeded")
+			continue
+		}
+
+		_, lookupErr := s.vault.Stat(userID, rel)
+		if err := s.WriteVaultFile(userID, &VaultWriteRequest{Path: rel, Content: string(data)}); err != nil {
+			if errors.Is(err, ErrVaultQuotaExceeded) || errors.Is(err, ErrVaultWriteRejected) || errors.Is(err, ErrInvalidVaultPath) {
+				skip(f.Name, err.Error())
+				continue
+			}
+			return result, err
+		}
+		total += int64(len(data))
+
+		if lookupErr == nil {
+			result.Updated = append(result.Updated, rel)
+		} else {
+			result.Created = append(result.Created, rel)
+		}
+	}
+
+	s.Broadcast(&EventData{
+		Type: "vault.imported",
+		Data: map[string]interface{}{
+			"userId":  userID,
+			"prefix":  prefix,
+			"created": len(result.Created),
+			"updated": len(result.Updated),
+			"skipped": len(result.Skipped),
+		},
+	})
+	return result, nil
+}
+
+// ExportVault 把用户存储库 prefix 目录下的文件逐个写入 zip 流，prefix 为空时导出全部，ctx 取消时中止导出
+func (s *ServiceImpl) ExportVault(ctx context.Context, userID uint, prefix string, w io.Writer) error {
+	if prefix != "" {
+		cleaned, ok := vaultImportPath(prefix, "x")
+		if !ok {
+			return fmt.Errorf("invalid prefix: %s", prefix)
+		}
+		prefix = path.Dir(cleaned)
+	}
+
+	paths, err := s.vault.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := filepath.ToSlash(p)
+		if prefix != "" && prefix != "." && name != prefix && !strings.HasPrefix(name, prefix+"/") {
+			continue
+		}
+
+		info, err := s.vault.Stat(userID, p)
+		if err != nil {
+			return err
+		}
+		content, err := s.vault.Read(userID, p)
+		if err != nil {
+			return err
+		}
+
+		dst, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: info.ModTime,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := dst.Write(content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// checkVaultWrite 先校验路径和扩展名，再按配置的策略检查写入，策略返回的错误统一包装为 ErrVaultWriteRejected
+func (s *ServiceImpl) checkVaultWrite(path string, content []byte) error {
+	if err := validateVaultPath(path); err != nil {
+		return err
+	}
+	if err := checkVaultExtension(path, s.options.AllowedVaultExtensions, s.options.BlockedVaultExtensions); err != nil {
+		return err
+	}
+	policy := s.options.VaultWritePolicy
+	if policy == nil {
+		return nil
+	}
+	if err := policy.Allow(filepath.Clean(path), content); err != nil {
+		if errors.Is(err, ErrVaultWriteRejected) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrVaultWriteRejected, err)
+	}
+	return nil
+}
+
+// DeleteVaultFile 删除存储库文件
+func (s *ServiceImpl) DeleteVaultFile(userID uint, path string) error {
+	return s.vault.Delete(userID, path)
+}
+
+// vaultUsage 返回用户存储库已用空间，后端未实现 Usage 时逐个统计文件大小
+func (s *ServiceImpl) vaultUsage(userID uint) (int64, error) {
+	if u, ok := s.vault.(vaultUsager); ok {
+		return u.Usage(userID)
+	}
+	paths, err := s.vault.List(context.Background(), userID)
+	if err != nil {
+		return 0, err
+	}
+	var used int64
+	for _, p := range paths {
+		info, err := s.vault.Stat(userID, p)
+		if err != nil {
+			continue
+		}
+		used += info.Size
+	}
+	return used, nil
+}
+
+func (s *ServiceImpl) checkVaultQuota(userID uint, delta int64) error {
+	if delta <= 0 {
+		return nil
+	}
+
+	quota, err := s.repo.GetUserQuota(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if quota.QuotaBytes <= 0 {
+		return nil
+	}
+
+	used, err := s.vaultUsage(userID)
+	if err != nil {
+		return err
+	}
+	if used+delta > quota.QuotaBytes {
+		s.Broadcast(&EventData{
+			Type: "vault.quota.exceeded",
+			Data: map[string]interface{}{
+				"userId": userID,
+				"used":   used,
+				"delta":  delta,
+				"quota":  quota.QuotaBytes,
+			},
+		})
+		return fmt.Errorf("%w: %d + %d bytes exceeds %d", ErrVaultQuotaExceeded, used, delta, quota.QuotaBytes)
+	}
+	return nil
+}
+
+// Market operations
+// GetMarketItems 获取市场插件，tag 不为空时只返回带有该标签的插件
+func (s *ServiceImpl) GetMarketItems(tag string) ([]*MarketItem, error) {
+	if s.marketURL == "" {
+		return []*MarketItem{}, nil
+	}
+
+	resp, err := http.Get(s.marketURL)
 	if err != nil {
-		return false
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	for _, perm := range permissions {
-		if perm == permission || perm == "*" {
-			return true
+	var items []*MarketItem
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, err
+	}
+
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	filtered := make([]*MarketItem, 0, len(items))
+	for _, item := range items {
+		item.Tags = normalizeTags(item.Tags)
+		if tag != "" && !containsString(item.Tags, tag) {
+			continue
 		}
+		filtered = append(filtered, item)
 	}
-	return false
-}
 
-func (s *ServiceImpl) GetPluginPermissions(pluginID string) ([]string, error) {
-	return s.repo.GetPluginPermissions(pluginID)
+	return filtered, nil
 }
 
-// Command management
-func (s *ServiceImpl) RegisterCommand(pluginID string, req *CommandRegisterRequest) error {
-	command := &Command{
-		CommandID: req.ID,
-		PluginID:  pluginID,
-		Title:     req.Title,
+// Audit 记录一条特权操作审计日志，写入失败只记录日志不影响操作本身
+func (s *ServiceImpl) Audit(action, actor, target string, meta map[string]interface{}) {
+	entry := &AuditLog{
+		Action: action,
+		Actor:  actor,
+		Target: target,
+	}
+	if id, ok := strings.CutPrefix(actor, "plugin:"); ok && s.isTrustedPlugin(id) {
+		// 受信任插件绕过了权限检查，在记录中标注以便追查
+		m := map[string]interface{}{"trusted": true}
+		for k, v := range meta {
+			m[k] = v
+		}
+		meta = m
+	}
+	if len(meta) > 0 {
+		metaJSON, err := json.Marshal(meta)
+		if err != nil {
+			logger.Error("Failed to marshal audit meta for "+action, err)
+		} else {
+			entry.Meta = string(metaJSON)
+		}
 	}
 
-	return s.repo.CreateCommand(command)
+	if err := s.repo.CreateAuditLog(entry); err != nil {
+		logger.Error("Failed to write audit log for "+action, err)
+	}
 }
 
-func (s *ServiceImpl) GetAllCommands() ([]*CommandResponse, error) {
-	commands, err := s.repo.GetAllCommands()
+func (s *ServiceImpl) GetAuditLogs(query *AuditQuery) ([]*AuditLogResponse, error) {
+	logs, err := s.repo.GetAuditLogs(query)
 	if err != nil {
 		return nil, err
 	}
 
-	responses := make([]*CommandResponse, 0, len(commands))
-	for _, cmd := range commands {
-		responses = append(responses, &CommandResponse{
-			ID:        cmd.ID,
-			CommandID: cmd.CommandID,
-			PluginID:  cmd.PluginID,
-			Title:     cmd.Title,
-		})
+	responses := make([]*AuditLogResponse, 0, len(logs))
+	for _, l := range logs {
+		response := &AuditLogResponse{
+			ID:        l.ID,
+			Action:    l.Action,
+			Actor:     l.Actor,
+			Target:    l.Target,
+			CreatedAt: l.CreatedAt,
+		}
+		if l.Meta != "" {
+			if err := json.Unmarshal([]byte(l.Meta), &response.Meta); err != nil {
+				logger.Error("Failed to parse audit meta", err)
+			}
+		}
+		responses = append(responses, response)
 	}
 
 	return responses, nil
 }
 
-func (s *ServiceImpl) InvokeCommand(pluginID, commandID string) error {
-	s.Broadcast(&EventData{
-		Type: "command.invoked",
-		Data: map[string]interface{}{
-			"pluginId":  pluginID,
-			"commandId": commandID,
-		},
-	})
-	return nil
+// Event management
+func (s *ServiceImpl) Broadcast(event *EventData) {
+	s.recordEvent(event)
+	s.eventHub.Broadcast(event)
+	s.dispatchWebhooks(event)
 }
 
-// Vault operations
-func (s *ServiceImpl) ListVaultFiles(userID uint) ([]string, error) {
-	files, err := s.repo.GetVaultFilesByUserID(userID)
-	if err != nil {
-		return nil, err
-	}
+// 事件历史参数
+const (
+	// DefaultEventRetention 未配置 EventRetention 时事件历史的保留时长
+	DefaultEventRetention = 7 * 24 * time.Hour
+	// defaultEventHistoryLimit 查询未指定 limit 时返回的条数
+	defaultEventHistoryLimit = 100
+	// maxEventHistoryLimit 单次查询最多返回的条数
+	maxEventHistoryLimit = 1000
+)
 
-	paths := make([]string, 0, len(files))
-	for _, file := range files {
-		paths = append(paths, file.Path)
+// recordEvent 保存事件历史，写入失败只记录日志不影响广播
+func (s *ServiceImpl) recordEvent(event *EventData) {
+	entry := &EventLog{Type: event.Type}
+	if event.Data != nil {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			logger.Error("Failed to marshal event data for "+event.Type, err)
+		} else {
+			entry.Data = string(data)
+		}
 	}
-
-	return paths, nil
-}
-
-func (s *ServiceImpl) ReadVaultFile(userID uint, path string) (*VaultReadResponse, error) {
-	file, err := s.repo.GetVaultFileByPath(userID, path)
-	if err != nil {
-		return nil, err
+	if err := s.repo.CreateEventLog(entry); err != nil {
+		logger.Error("Failed to record event "+event.Type, err)
 	}
-
-	return &VaultReadResponse{
-		Path:    file.Path,
-		Content: string(file.Content),
-	}, nil
 }
 
-func (s *ServiceImpl) WriteVaultFile(userID uint, req *VaultWriteRequest) error {
-	// 检查文件是否存在
-	existingFile, err := s.repo.GetVaultFileByPath(userID, req.Path)
-	if err == nil {
-		// 更新现有文件
-		existingFile.Content = []byte(req.Content)
-		existingFile.Size = int64(len(req.Content))
-		return s.repo.UpdateVaultFile(existingFile)
-	}
-
-	// 创建新文件
-	file := &VaultFile{
-		Path:    filepath.Clean(req.Path),
-		Content: []byte(req.Content),
-		Size:    int64(len(req.Content)),
-		UserID:  userID,
+// eventRetention 返回生效的事件保留时长
+func (s *ServiceImpl) eventRetention() time.Duration {
+	if s.options.EventRetention > 0 {
+		return s.options.EventRetention
 	}
-
-	return s.repo.CreateVaultFile(file)
+	return DefaultEventRetention
 }
 
-// Market operations
-func (s *ServiceImpl) GetMarketItems() ([]*MarketItem, error) {
-	if s.marketURL == "" {
-		return []*MarketItem{}, nil
+// GetEventHistory 返回符合条件的最近事件，按序号升序，超出保留时长的事件不会返回
+func (s *ServiceImpl) GetEventHistory(query *EventHistoryQuery) ([]*EventHistoryResponse, error) {
+	q := *query
+	if q.Limit <= 0 {
+		q.Limit = defaultEventHistoryLimit
+	} else if q.Limit > maxEventHistoryLimit {
+		q.Limit = maxEventHistoryLimit
+	}
+	if cutoff := time.Now().Add(-s.eventRetention()); q.Since == nil || q.Since.Before(cutoff) {
+		q.Since = &cutoff
 	}
 
-	resp, err := http.Get(s.marketURL)
+	events, err := s.repo.GetEventLogs(&q)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var items []*MarketItem
-	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
-		return nil, err
+	responses := make([]*EventHistoryResponse, 0, len(events))
+	for _, e := range events {
+		response := &EventHistoryResponse{
+			Seq:       e.ID,
+			Type:      e.Type,
+			CreatedAt: e.CreatedAt,
+		}
+		if e.Data != "" {
+			if err := json.Unmarshal([]byte(e.Data), &response.Data); err != nil {
+				logger.Error("Failed to parse event data", err)
+			}
+		}
+		responses = append(responses, response)
 	}
-
-	return items, nil
+	return responses, nil
 }
 
-// Event management
-func (s *ServiceImpl) Broadcast(event *EventData) {
-	s.eventHub.Broadcast(event)
+// PruneEventHistory 删除超出保留时长的事件记录，返回删除的条数
+func (s *ServiceImpl) PruneEventHistory() (int64, error) {
+	return s.repo.DeleteEventLogsBefore(time.Now().Add(-s.eventRetention()))
 }
 
 func (s *ServiceImpl) Subscribe(ctx context.Context) <-chan *EventData {
@@ -626,19 +2029,29 @@ func (s *ServiceImpl) convertToPluginResponse(plugin *Plugin) *PluginResponse {
 		}
 	}
 
+	labels := make([]string, len(plugin.Labels))
+	for i, label := range plugin.Labels {
+		labels[i] = label.Label
+	}
+
 	return &PluginResponse{
-		ID:          plugin.ID,
-		PluginID:    plugin.PluginID,
-		Name:        plugin.Name,
-		Version:     plugin.Version,
-		Author:      plugin.Author,
-		Description: plugin.Description,
-		Enabled:     plugin.Enabled,
-		BackupPath:  plugin.BackupPath,
-		Permissions: permissions,
-		Commands:    commands,
-		CreatedAt:   plugin.CreatedAt,
-		UpdatedAt:   plugin.UpdatedAt,
+		ID:                 plugin.ID,
+		PluginID:           plugin.PluginID,
+		Name:               plugin.Name,
+		Version:            plugin.Version,
+		Author:             plugin.Author,
+		Description:        plugin.Description,
+		Enabled:            plugin.Enabled,
+		Trusted:            s.isTrustedPlugin(plugin.PluginID),
+		BackupPath:         plugin.BackupPath,
+		Permissions:        permissions,
+		PendingPermissions: splitPendingPermissions(plugin.PendingPermissions),
+		Commands:           commands,
+		Tags:               splitTags(plugin.Tags),
+		Labels:             labels,
+		I18n:               parsePluginI18n(plugin.I18n),
+		CreatedAt:          plugin.CreatedAt,
+		UpdatedAt:          plugin.UpdatedAt,
 	}
 }
 
@@ -649,20 +2062,94 @@ func (s *ServiceImpl) getInstallation(pluginID string) (*PluginInstallation, boo
 	return installation, exists
 }
 
-func (s *ServiceImpl) downloadFile(url string) (string, error) {
+// tempDir 返回下载暂存目录，未配置时使用系统临时目录
+func (s *ServiceImpl) tempDir() string {
+	if s.options.TempDir != "" {
+		return s.options.TempDir
+	}
+	return os.TempDir()
+}
+
+// SweepStaleDownloads 删除暂存目录中修改时间早于 olderThan 的下载残留文件，返回删除的文件数
+func (s *ServiceImpl) SweepStaleDownloads(olderThan time.Duration) (int, error) {
+	matches, err := filepath.Glob(filepath.Join(s.tempDir(), downloadTempPattern))
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	for _, p := range matches {
+		info, err := os.Lstat(p)
+		if err != nil || !info.Mode().IsRegular() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(p); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// progressReader 包装下载流，按 downloadProgressInterval 回报已下载字节数、平均速率和预计剩余时间，
+// 读到结尾时再回报一次。total 为 Content-Length，未知时为 -1，此时不回报总字节数和剩余时间
+type progressReader struct {
+	r       io.Reader
+	total   int64
+	read    int64
+	started time.Time
+	last    time.Time
+	report  func(InstallProgress)
+}
+
+func newProgressReader(r io.Reader, total int64, report func(InstallProgress)) *progressReader {
+	now := time.Now()
+	return &progressReader{r: r, total: total, started: now, last: now, report: report}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	now := time.Now()
+	if err == io.EOF || now.Sub(p.last) >= downloadProgressInterval {
+		p.last = now
+		p.report(p.stats(now))
+	}
+	return n, err
+}
+
+// stats 返回只填写了字节数、速率和剩余时间的进度
+func (p *progressReader) stats(now time.Time) InstallProgress {
+	progress := InstallProgress{BytesDownloaded: p.read}
+	if elapsed := now.Sub(p.started).Seconds(); elapsed > 0 {
+		progress.BytesPerSecond = int64(float64(p.read) / elapsed)
+	}
+	if p.total > 0 {
+		progress.TotalBytes = p.total
+		if progress.BytesPerSecond > 0 && p.read < p.total {
+			progress.ETASeconds = (p.total - p.read + progress.BytesPerSecond - 1) / progress.BytesPerSecond
+		}
+	}
+	return progress
+}
+
+// downloadFile 下载安装包到暂存文件，下载过程中通过 report 回报进度
+func (s *ServiceImpl) downloadFile(url string, report func(InstallProgress)) (string, error) {
 	resp, err := http.Get(url)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	tempFile, err := os.CreateTemp("", "plugin-*.zip")
+	if err := os.MkdirAll(s.tempDir(), 0755); err != nil {
+		return "", err
+	}
+	tempFile, err := os.CreateTemp(s.tempDir(), downloadTempPattern)
 	if err != nil {
 		return "", err
 	}
 	defer tempFile.Close()
 
-	_, err = io.Copy(tempFile, resp.Body)
+	_, err = io.Copy(tempFile, newProgressReader(resp.Body, resp.ContentLength, report))
 	if err != nil {
 		os.Remove(tempFile.Name())
 		return "", err
@@ -691,6 +2178,17 @@ func (s *ServiceImpl) verifyFile(filePath, expectedSHA256 string) error {
 	return nil
 }
 
+// maxPluginSize 返回生效的插件包大小上限
+func (s *ServiceImpl) maxPluginSize() int64 {
+	if s.options.MaxPluginSize > 0 {
+		return s.options.MaxPluginSize
+	}
+	return DefaultMaxPluginSize
+}
+
+// extractZip 解压插件包，遇到疑似压缩炸弹、符号链接等非普通文件条目
+// 或经过符号链接的目标路径时中止。内容先解压到 dest 旁的临时目录，全部成功后才移入 dest，
+// 失败时 dest 中已有的文件保持原样
 func (s *ServiceImpl) extractZip(src, dest string) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
@@ -698,49 +2196,139 @@ func (s *ServiceImpl) extractZip(src, dest string) error {
 	}
 	defer r.Close()
 
-	os.MkdirAll(dest, 0755)
+	if err := checkZipEntries(&r.Reader); err != nil {
+		return err
+	}
+
+	dest = filepath.Clean(dest)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".extract-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
 
+	guard := &zipGuard{maxTotal: s.maxPluginSize() * maxExtractExpansion}
 	for _, f := range r.File {
-		path := filepath.Join(dest, f.Name)
+		path := filepath.Join(tmp, f.Name)
 
 		// 安全检查，防止路径遍历攻击
-		if !strings.HasPrefix(path, filepath.Clean(dest)+string(os.PathSeparator)) {
+		if !strings.HasPrefix(path, tmp+string(os.PathSeparator)) {
 			continue
 		}
 
+		if err := checkZipEntryType(f); err != nil {
+			return err
+		}
+
 		if f.FileInfo().IsDir() {
-			os.MkdirAll(path, 0755)
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
 			continue
 		}
 
 		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 			return err
 		}
-
-		rc, err := f.Open()
-		if err != nil {
+		if err := extractZipEntry(guard, f, path); err != nil {
 			return err
 		}
+	}
 
-		outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			rc.Close()
-			return err
-		}
+	return moveExtracted(tmp, dest)
+}
 
-		_, err = io.Copy(outFile, rc)
+// extractZipEntry 把单个条目写入新文件
+func extractZipEntry(guard *zipGuard, f *zip.File, path string) error {
+	rc, err := guard.open(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, extractedFileMode(f.Mode()))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(outFile, rc); err != nil {
 		outFile.Close()
-		rc.Close()
+		return err
+	}
+	return outFile.Close()
+}
 
+// moveExtracted 把临时目录中解压好的内容移入 dest：dest 不存在时整体重命名，
+// 否则逐个文件重命名覆盖，每个文件要么是旧内容要么是完整的新内容
+func moveExtracted(tmp, dest string) error {
+	if _, err := os.Lstat(dest); os.IsNotExist(err) {
+		return os.Rename(tmp, dest)
+	}
+	return filepath.WalkDir(tmp, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		rel, err := filepath.Rel(tmp, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if err := checkNoSymlink(dest, target); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return os.Rename(path, target)
+	})
+}
+
+// maxManifestBytes 读取插件清单的大小上限
+const maxManifestBytes = 1 << 20
+
+// readManifestFile 读取并解析插件目录中的清单文件
+func readManifestFile(path string) (map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
+	var manifest map[string]interface{}
+	if err := json.NewDecoder(io.LimitReader(f, maxManifestBytes)).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
 
-	return nil
+// readZipManifest 不解压整个插件包，只读取并解析根目录下的 manifest.json
+func readZipManifest(src string) (map[string]interface{}, error) {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name != "manifest.json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		var manifest map[string]interface{}
+		if err := json.NewDecoder(io.LimitReader(rc, maxManifestBytes)).Decode(&manifest); err != nil {
+			return nil, err
+		}
+		return manifest, nil
+	}
+	return nil, fmt.Errorf("manifest.json not found in plugin package")
 }
 
-func (s *ServiceImpl) loadPluginFromManifest(manifestPath string) error {
+// loadPluginFromManifest 按清单创建或更新插件记录，enabled 只用于新创建的插件
+func (s *ServiceImpl) loadPluginFromManifest(manifestPath string, enabled bool) error {
 	manifestBytes, err := os.ReadFile(manifestPath)
 	if err != nil {
 		return err
@@ -760,42 +2348,153 @@ func (s *ServiceImpl) loadPluginFromManifest(manifestPath string) error {
 	if pluginID == "" || name == "" || version == "" {
 		return fmt.Errorf("invalid manifest: missing required fields")
 	}
+	i18n, err := manifestI18n(manifest)
+	if err != nil {
+		return fmt.Errorf("invalid manifest: %w", err)
+	}
+	tags := strings.Join(manifestTags(manifest), ",")
 
-	// 检查插件是否已存在
-	existingPlugin, err := s.repo.GetPluginByID(pluginID)
-	if err == nil && existingPlugin != nil {
-		// 更新现有插件
-		existingPlugin.Name = name
-		existingPlugin.Version = version
-		existingPlugin.Author = author
-		existingPlugin.Description = description
-		return s.repo.UpdatePlugin(existingPlugin)
+	// 数量检查与插件记录创建在同一把锁内完成，并发安装不会超出上限
+	s.pluginLimitMu.Lock()
+	defer s.pluginLimitMu.Unlock()
+	if err := s.checkPluginLimit(pluginID); err != nil {
+		return err
 	}
 
-	// 创建新插件记录
-	plugin := &Plugin{
-		PluginID:    pluginID,
-		Name:        name,
-		Version:     version,
-		Author:      author,
-		Description: description,
-		Enabled:     true,
+	// 依赖服务未实现特性的插件照常安装，但保持禁用
+	compatible := s.checkCompatible(pluginID, manifest) == nil
+	if !compatible {
+		enabled = false
 	}
 
-	if err := s.repo.CreatePlugin(plugin); err != nil {
+	// 命令、插件记录和权限在同一事务中写入，任何一步失败都整体回滚，
+	// 事件和审计日志在提交后再发出
+	var pending, granted []string
+	err = s.repo.Transaction(func(repo Repository) error {
+		// 同步清单声明的命令
+		if err := syncManifestCommandsIn(repo, pluginID, manifest); err != nil {
+			return fmt.Errorf("failed to register manifest commands: %w", err)
+		}
+
+		// 检查插件是否已存在
+		existingPlugin, err := repo.GetPluginByID(pluginID)
+		if err == nil && existingPlugin != nil {
+			// 更新现有插件，新增的权限需批准后才生效
+			current, err := repo.GetPluginPermissions(pluginID)
+			if err != nil {
+				return err
+			}
+			pending = addedPermissions(current, manifestPermissions(manifest))
+			existingPlugin.Name = name
+			existingPlugin.Version = version
+			existingPlugin.Author = author
+			existingPlugin.Description = description
+			existingPlugin.I18n = i18n
+			existingPlugin.Tags = tags
+			existingPlugin.PendingPermissions = strings.Join(pending, ",")
+			if !compatible {
+				existingPlugin.Enabled = false
+			}
+			return repo.UpdatePlugin(existingPlugin)
+		}
+
+		// 创建新插件记录
+		plugin := &Plugin{
+			PluginID:    pluginID,
+			Name:        name,
+			Version:     version,
+			Author:      author,
+			Description: description,
+			I18n:        i18n,
+			Tags:        tags,
+			Enabled:     enabled,
+		}
+		if err := repo.CreatePlugin(plugin); err != nil {
+			return err
+		}
+
+		// 处理权限
+		for _, perm := range manifestPermissions(manifest) {
+			if err := repo.AddPluginPermission(pluginID, perm); err != nil {
+				return fmt.Errorf("failed to grant permission %s: %w", perm, err)
+			}
+			granted = append(granted, perm)
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
-	// 处理权限
-	if permissions, ok := manifest["permissions"].([]interface{}); ok {
-		for _, perm := range permissions {
-			if permStr, ok := perm.(string); ok {
-				s.repo.AddPluginPermission(pluginID, permStr)
+	for _, perm := range granted {
+		s.Audit("permission.grant", "system", pluginID, map[string]interface{}{"permission": perm})
+	}
+	if len(pending) > 0 {
+		s.Broadcast(&EventData{
+			Type: "plugin.permissions.changed",
+			Data: map[string]interface{}{
+				"pluginId":         pluginID,
+				"added":            pending,
+				"requiresApproval": true,
+			},
+		})
+	}
+	return nil
+}
+
+const (
+	maxTags      = 10
+	maxTagLength = 32
+)
+
+// manifestTags 返回清单中规整后的标签
+func manifestTags(manifest map[string]interface{}) []string {
+	var tags []string
+	if list, ok := manifest["tags"].([]interface{}); ok {
+		for _, t := range list {
+			// 标签以逗号分隔保存，含逗号的标签无法还原
+			if tag, ok := t.(string); ok && !strings.Contains(tag, ",") {
+				tags = append(tags, tag)
 			}
 		}
 	}
+	return normalizeTags(tags)
+}
 
-	return nil
+// splitTags 拆分插件记录中逗号分隔的标签
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
+// normalizeTags 将标签转为小写并去重，丢弃空标签和超长标签，最多保留 maxTags 个
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxTagLength || containsString(out, tag) {
+			continue
+		}
+		out = append(out, tag)
+		if len(out) == maxTags {
+			break
+		}
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 func getStringFromMap(m map[string]interface{}, key string) string {
@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckPluginAllowed(t *testing.T) {
+	cases := []struct {
+		name             string
+		allowed, blocked []string
+		ok               bool
+	}{
+		{"neutral", nil, nil, true},
+		{"allowlisted", []string{"demo"}, nil, true},
+		{"not allowlisted", []string{"other"}, nil, false},
+		{"blocked", nil, []string{"demo"}, false},
+		{"blocked wins over allowlist", []string{"demo"}, []string{"demo"}, false},
+	}
+	for _, tc := range cases {
+		s := &ServiceImpl{options: ServiceOptions{AllowedPluginIDs: tc.allowed, BlockedPluginIDs: tc.blocked}}
+		err := s.checkPluginAllowed("demo")
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: got %v, want ok=%v", tc.name, err, tc.ok)
+		}
+		if err != nil && !errors.Is(err, ErrPluginNotAllowed) {
+			t.Errorf("%s: err = %v, want ErrPluginNotAllowed", tc.name, err)
+		}
+	}
+}
+
+func TestInstallPluginRejectsBlockedID(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{BlockedPluginIDs: []string{"demo"}}).(*ServiceImpl)
+
+	err := s.InstallPlugin(&PluginInstallRequest{ID: "demo", URL: "https://example.com/demo.zip"})
+	var vf *ValidationFailedError
+	if !errors.As(err, &vf) || len(vf.Errors) != 1 || vf.Errors[0].Code != "PLUGIN_NOT_ALLOWED" {
+		t.Fatalf("InstallPlugin err = %v, want PLUGIN_NOT_ALLOWED", err)
+	}
+	if !errors.Is(err, ErrPluginNotAllowed) {
+		t.Error("validation error should match ErrPluginNotAllowed for the 403 mapping")
+	}
+	if _, err := repo.GetInstallationByPluginID("demo"); err == nil {
+		t.Error("blocked install should not write an installation record")
+	}
+}
+
+// createTestPlugins 在存储库中登记已启用的插件
+func createTestPlugins(t *testing.T, repo Repository, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := repo.CreatePlugin(&Plugin{PluginID: id, Name: id, Version: "1.0.0", Enabled: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBatchSetEnabled(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "a", "b")
+
+	results := s.BatchSetEnabled([]string{"a", "missing", "b"}, false)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for i, wantOk := range []bool{true, false, true} {
+		if results[i].Ok != wantOk || (results[i].Error == "") != wantOk {
+			t.Errorf("result %d = %+v, want ok=%v", i, results[i], wantOk)
+		}
+	}
+	for _, id := range []string{"a", "b"} {
+		if p, err := repo.GetPluginByID(id); err != nil || p.Enabled {
+			t.Errorf("%s should be disabled: %+v, %v", id, p, err)
+		}
+	}
+}
+
+func TestBatchUninstall(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "a", "b")
+
+	results := s.BatchUninstall([]string{"missing", "a"})
+	if len(results) != 2 || results[0].Ok || results[0].Error == "" || !results[1].Ok {
+		t.Fatalf("results = %+v", results)
+	}
+	if _, err := repo.GetPluginByID("a"); err == nil {
+		t.Error("a should be uninstalled")
+	}
+	if _, err := repo.GetPluginByID("b"); err != nil {
+		t.Errorf("b should be untouched: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lgnixai/wmcms/pkg/logger"
+)
+
+// stagingDir 返回安装时组装插件的暂存目录，未配置时使用插件目录旁的 plugin-staging
+func (s *ServiceImpl) stagingDir() string {
+	if s.options.StagingDir != "" {
+		return s.options.StagingDir
+	}
+	return filepath.Join(filepath.Dir(s.pluginsDir), "plugin-staging")
+}
+
+// newStagingDir 在暂存目录中创建用于组装插件的空目录，调用方负责删除
+func (s *ServiceImpl) newStagingDir(pluginID string) (string, error) {
+	if err := os.MkdirAll(s.stagingDir(), 0755); err != nil {
+		return "", err
+	}
+	stage, err := os.MkdirTemp(s.stagingDir(), pluginID+"-*")
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chmod(stage, 0755); err != nil {
+		os.RemoveAll(stage)
+		return "", err
+	}
+	return stage, nil
+}
+
+// swapPluginDir 把组装好的 stage 原子地移到 pluginDir。原有目录先移入暂存目录，
+// 返回其位置供失败时 restorePluginDir 恢复，没有原有目录时返回空串
+func (s *ServiceImpl) swapPluginDir(stage, pluginDir string) (string, error) {
+	previous := ""
+	if _, err := os.Stat(pluginDir); err == nil {
+		previous = stage + ".previous"
+		if err := os.Rename(pluginDir, previous); err != nil {
+			return "", fmt.Errorf("failed to move current plugin directory: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if err := os.Rename(stage, pluginDir); err != nil {
+		if previous != "" {
+			if restoreErr := os.Rename(previous, pluginDir); restoreErr != nil {
+				logger.Error("Failed to restore plugin directory: "+pluginDir, restoreErr)
+			}
+		}
+		return "", fmt.Errorf("failed to move plugin directory into place: %w", err)
+	}
+	return previous, nil
+}
+
+// restorePluginDir 撤销 swapPluginDir：删除新放入的目录，有原有目录时移回原处
+func (s *ServiceImpl) restorePluginDir(pluginDir, previous string) {
+	if err := os.RemoveAll(pluginDir); err != nil {
+		logger.Error("Failed to remove plugin directory: "+pluginDir, err)
+		return
+	}
+	if previous == "" {
+		return
+	}
+	if err := os.Rename(previous, pluginDir); err != nil {
+		logger.Error("Failed to restore plugin directory: "+pluginDir, err)
+	}
+}
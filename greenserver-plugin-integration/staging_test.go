@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stagingEntries 返回暂存目录中残留的条目
+func stagingEntries(t *testing.T, s *ServiceImpl) []string {
+	t.Helper()
+	entries, err := os.ReadDir(s.stagingDir())
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// installZipUntilTerminal 从本地测试服务安装给定内容的插件包并返回终态事件
+func installZipUntilTerminal(t *testing.T, s *ServiceImpl, pluginID string, names []string, contents [][]byte) *EventData {
+	t.Helper()
+	zipPath := writeTestZip(t, t.TempDir(), names, contents)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, zipPath)
+	}))
+	t.Cleanup(srv.Close)
+	return installUntilTerminal(t, s, &PluginInstallRequest{ID: pluginID, URL: srv.URL + "/plugin.zip"})
+}
+
+func TestSwapPluginDir(t *testing.T) {
+	root := t.TempDir()
+	s := &ServiceImpl{pluginsDir: filepath.Join(root, "plugins"), options: ServiceOptions{StagingDir: filepath.Join(root, "staging")}}
+	pluginDir := filepath.Join(s.pluginsDir, "demo")
+
+	stage := func(content string) string {
+		dir, err := s.newStagingDir("demo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		writePluginFixture(t, dir, map[string]string{"main.js": content})
+		return dir
+	}
+	readMain := func() string {
+		data, _ := os.ReadFile(filepath.Join(pluginDir, "main.js"))
+		return string(data)
+	}
+	if err := os.MkdirAll(s.pluginsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := s.swapPluginDir(stage("v1"), pluginDir)
+	if err != nil || previous != "" || readMain() != "v1" {
+		t.Fatalf("swap into empty dir: previous %q, err %v, main.js %q", previous, err, readMain())
+	}
+
+	previous, err = s.swapPluginDir(stage("v2"), pluginDir)
+	if err != nil || previous == "" || readMain() != "v2" {
+		t.Fatalf("swap over existing dir: previous %q, err %v, main.js %q", previous, err, readMain())
+	}
+	// 撤销时删除新目录并移回原有目录
+	s.restorePluginDir(pluginDir, previous)
+	if readMain() != "v1" {
+		t.Errorf("main.js after restore = %q, want v1", readMain())
+	}
+	if _, err := os.Stat(previous); !os.IsNotExist(err) {
+		t.Errorf("previous dir left in staging: %v", err)
+	}
+
+	s.restorePluginDir(pluginDir, "")
+	if _, err := os.Stat(pluginDir); !os.IsNotExist(err) {
+		t.Errorf("new plugin dir kept after restore: %v", err)
+	}
+}
+
+func TestInstallUsesStagingDir(t *testing.T) {
+	staging := filepath.Join(t.TempDir(), "stage")
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(NewInMemoryRepository(), pluginsDir, t.TempDir(), "", ServiceOptions{StagingDir: staging}).(*ServiceImpl)
+	if s.stagingDir() != staging {
+		t.Fatalf("stagingDir = %s, want %s", s.stagingDir(), staging)
+	}
+
+	ev := installZipUntilTerminal(t, s, "demo", []string{"manifest.json", "main.js"},
+		[][]byte{[]byte(`{"id":"demo","name":"Demo","version":"1.0.0"}`), []byte("v1")})
+	if ev.Type != "plugin.installation.done" {
+		t.Fatalf("install: %s %+v", ev.Type, ev.Data)
+	}
+	if data, err := os.ReadFile(filepath.Join(pluginsDir, "demo", "main.js")); err != nil || string(data) != "v1" {
+		t.Errorf("main.js = %q, %v", data, err)
+	}
+	if left := stagingEntries(t, s); len(left) != 0 {
+		t.Errorf("staging dir not cleaned up: %v", left)
+	}
+}
+
+func TestFailedUpgradeKeepsPluginDir(t *testing.T) {
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(NewInMemoryRepository(), pluginsDir, t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	manifest := []byte(`{"id":"demo","name":"Demo","version":"1.0.0"}`)
+	if ev := installZipUntilTerminal(t, s, "demo", []string{"manifest.json", "main.js"}, [][]byte{manifest, []byte("v1")}); ev.Type != "plugin.installation.done" {
+		t.Fatalf("install v1: %s %+v", ev.Type, ev.Data)
+	}
+
+	// 新包缺少清单，解压到暂存目录后校验失败，插件目录保持原样
+	if ev := installZipUntilTerminal(t, s, "demo", []string{"main.js"}, [][]byte{[]byte("v2")}); ev.Type != "plugin.installation.failed" {
+		t.Fatalf("install without manifest: %s %+v", ev.Type, ev.Data)
+	}
+	if data, err := os.ReadFile(filepath.Join(pluginsDir, "demo", "main.js")); err != nil || string(data) != "v1" {
+		t.Errorf("main.js after failed upgrade = %q, %v, want v1", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(pluginsDir, "demo", "manifest.json")); err != nil {
+		t.Errorf("manifest removed by failed upgrade: %v", err)
+	}
+	if left := stagingEntries(t, s); len(left) != 0 {
+		t.Errorf("staging dir not cleaned up: %v", left)
+	}
+}
+
+func TestLoadPluginsFromDiskIgnoresStagedPlugins(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	stage, err := s.newStagingDir("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePluginFixture(t, stage, map[string]string{"manifest.json": `{"id":"demo","name":"Demo","version":"1.0.0"}`})
+
+	if err := s.LoadPluginsFromDisk(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetPluginByID("demo"); err == nil {
+		t.Error("LoadPluginsFromDisk loaded a plugin from the staging directory")
+	}
+}
@@ -0,0 +1,29 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifestTags(t *testing.T) {
+	manifest := map[string]interface{}{
+		"tags": []interface{}{"Notes", " notes ", "a,b", "", 3, "Sync"},
+	}
+	if got, want := manifestTags(manifest), []string{"notes", "sync"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("manifestTags = %v, want %v", got, want)
+	}
+	if got := manifestTags(map[string]interface{}{}); got != nil {
+		t.Fatalf("manifestTags without tags = %v", got)
+	}
+}
+
+func TestPluginResponseTags(t *testing.T) {
+	s := &ServiceImpl{}
+	resp := s.convertToPluginResponse(&Plugin{PluginID: "p", Tags: "notes,sync"})
+	if want := []string{"notes", "sync"}; !reflect.DeepEqual(resp.Tags, want) {
+		t.Fatalf("Tags = %v, want %v", resp.Tags, want)
+	}
+	if resp := s.convertToPluginResponse(&Plugin{PluginID: "p"}); resp.Tags != nil {
+		t.Fatalf("Tags = %v, want nil", resp.Tags)
+	}
+}
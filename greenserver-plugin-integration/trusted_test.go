@@ -0,0 +1,60 @@
+package plugin
+
+import "testing"
+
+func TestTrustedPluginHasAllPermissions(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{
+		TrustedPlugins: []string{"first-party"},
+	}).(*ServiceImpl)
+	createTestPlugins(t, repo, "first-party", "third-party")
+
+	if !s.HasPermission("first-party", "vault.write") {
+		t.Error("trusted plugin should have vault.write without declaring it")
+	}
+	if s.HasPermission("third-party", "vault.write") {
+		t.Error("untrusted plugin has an undeclared permission")
+	}
+	if s.HasPermission("missing", "vault.write") {
+		t.Error("uninstalled plugin has a permission")
+	}
+
+	for id, want := range map[string]bool{"first-party": true, "third-party": false} {
+		plugin, err := s.GetPlugin(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plugin.Trusted != want {
+			t.Errorf("%s: Trusted = %v, want %v", id, plugin.Trusted, want)
+		}
+	}
+}
+
+func TestTrustedPluginActionsAreAudited(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{
+		TrustedPlugins: []string{"first-party"},
+	}).(*ServiceImpl)
+
+	s.Audit("vault.write", "plugin:first-party", "a.md", map[string]interface{}{"size": 5})
+	s.Audit("vault.write", "plugin:third-party", "b.md", nil)
+
+	logs, err := s.GetAuditLogs(&AuditQuery{Action: "vault.write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("got %d vault.write logs, want 2", len(logs))
+	}
+	for _, l := range logs {
+		switch l.Target {
+		case "a.md":
+			if l.Meta["trusted"] != true || l.Meta["size"] != float64(5) {
+				t.Errorf("trusted entry meta = %v", l.Meta)
+			}
+		case "b.md":
+			if _, ok := l.Meta["trusted"]; ok {
+				t.Errorf("untrusted entry marked trusted: %v", l.Meta)
+			}
+		}
+	}
+}
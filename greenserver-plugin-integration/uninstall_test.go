@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// failingDeleteRepo 删除插件记录时返回错误，事务内的存储库同样包装
+type failingDeleteRepo struct {
+	Repository
+}
+
+func (r *failingDeleteRepo) Transaction(fn func(repo Repository) error) error {
+	return r.Repository.Transaction(func(repo Repository) error {
+		return fn(&failingDeleteRepo{Repository: repo})
+	})
+}
+
+func (r *failingDeleteRepo) DeletePlugin(pluginID string) error {
+	return errors.New("database unavailable")
+}
+
+// setupInstalledPlugin 登记插件、命令和插件目录，返回插件目录
+func setupInstalledPlugin(t *testing.T, repo Repository, pluginsDir, pluginID string) string {
+	t.Helper()
+	createTestPlugins(t, repo, pluginID)
+	if err := repo.CreateCommand(&Command{CommandID: pluginID + ".run", PluginID: pluginID, Title: "Run"}); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(pluginsDir, pluginID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"id":"`+pluginID+`"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestUninstallPluginRollsBackOnDBError(t *testing.T) {
+	repo := &failingDeleteRepo{Repository: NewInMemoryRepository()}
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(repo, pluginsDir, t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	dir := setupInstalledPlugin(t, repo, pluginsDir, "demo")
+
+	if err := s.UninstallPlugin("demo"); err == nil {
+		t.Fatal("expected the DB error to fail the uninstall")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); err != nil {
+		t.Errorf("plugin directory should be restored: %v", err)
+	}
+	if _, err := os.Stat(dir + uninstallingSuffix); !os.IsNotExist(err) {
+		t.Errorf("temporary directory left behind, stat err = %v", err)
+	}
+	if _, err := repo.GetPluginByID("demo"); err != nil {
+		t.Errorf("plugin record should remain: %v", err)
+	}
+	if cmds, _ := repo.GetCommandsByPluginID("demo"); len(cmds) != 1 {
+		t.Errorf("commands deleted in the failed transaction should be restored, got %d", len(cmds))
+	}
+}
+
+func TestUninstallPluginRemovesRecordsAndDirectory(t *testing.T) {
+	repo := NewInMemoryRepository()
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(repo, pluginsDir, t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	dir := setupInstalledPlugin(t, repo, pluginsDir, "demo")
+
+	if err := s.UninstallPlugin("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("plugin directory should be removed, stat err = %v", err)
+	}
+	if _, err := repo.GetPluginByID("demo"); err == nil {
+		t.Error("plugin record should be deleted")
+	}
+	if cmds, _ := repo.GetCommandsByPluginID("demo"); len(cmds) != 0 {
+		t.Errorf("commands should be cascade-deleted, got %d", len(cmds))
+	}
+}
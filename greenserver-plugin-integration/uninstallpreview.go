@@ -0,0 +1,32 @@
+package plugin
+
+// PreviewUninstall 统计卸载插件会删除的内容，不做任何修改。插件未安装时返回 ErrPluginNotFound
+func (s *ServiceImpl) PreviewUninstall(pluginID string) (*UninstallPreviewResponse, error) {
+	files, err := s.ListPluginFiles(pluginID, "")
+	if err != nil {
+		return nil, err
+	}
+	preview := &UninstallPreviewResponse{PluginID: pluginID}
+	for _, f := range files {
+		preview.DirectorySize += f.Size
+	}
+
+	commands, err := s.repo.GetCommandsByPluginID(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	preview.CommandCount = len(commands)
+
+	keys, err := s.repo.ListKVKeys(pluginID, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		kv, err := s.repo.GetKV(pluginID, key)
+		if err != nil {
+			continue
+		}
+		preview.SettingsSize += int64(len(kv.Value))
+	}
+	return preview, nil
+}
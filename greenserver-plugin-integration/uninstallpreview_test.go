@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreviewUninstall(t *testing.T) {
+	repo := NewInMemoryRepository()
+	pluginsDir := t.TempDir()
+	s := NewServiceWithOptions(repo, pluginsDir, t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	dir := setupInstalledPlugin(t, repo, pluginsDir, "demo")
+	writePluginFixture(t, dir, map[string]string{
+		"main.js":         "console.log(1)",
+		"assets/logo.svg": "<svg/>",
+	})
+	if err := repo.CreateCommand(&Command{CommandID: "demo.stop", PluginID: "demo", Title: "Stop"}); err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{"color": "blue", "size": "12"} {
+		if err := repo.SetKV(&PluginKV{PluginID: "demo", Key: key, Value: value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	preview, err := s.PreviewUninstall("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSize := int64(len(`{"id":"demo"}`) + len("console.log(1)") + len("<svg/>"))
+	want := UninstallPreviewResponse{PluginID: "demo", DirectorySize: wantSize, CommandCount: 2, SettingsSize: int64(len("blue") + len("12"))}
+	if *preview != want {
+		t.Errorf("preview = %+v, want %+v", *preview, want)
+	}
+
+	// 预览不删除任何内容
+	for _, name := range []string{"manifest.json", "main.js", "assets/logo.svg"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s removed by preview: %v", name, err)
+		}
+	}
+	if _, err := repo.GetPluginByID("demo"); err != nil {
+		t.Error("plugin record removed by preview")
+	}
+	if cmds, _ := repo.GetCommandsByPluginID("demo"); len(cmds) != 2 {
+		t.Errorf("got %d commands after preview, want 2", len(cmds))
+	}
+}
+
+func TestPreviewUninstallErrors(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "demo")
+	h := &Handler{service: s}
+
+	if _, err := s.PreviewUninstall("missing"); !errors.Is(err, ErrPluginNotFound) {
+		t.Errorf("preview of unknown plugin: err = %v, want ErrPluginNotFound", err)
+	}
+	if code, _ := callTestRPC(t, h, "demo", "host.previewUninstall", map[string]string{"pluginId": "demo"}); code != 403 {
+		t.Errorf("non-admin host.previewUninstall: status = %d, want 403", code)
+	}
+}
@@ -0,0 +1,67 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// postInstall 调用 InstallPlugin，返回状态码和解码后的校验错误
+func postInstall(t *testing.T, h *Handler, body string) (int, []ValidationError) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/plugins/install", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.InstallPlugin(c)
+
+	var resp ValidationErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return w.Code, resp.Errors
+}
+
+func TestInstallPluginStructuredValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	h := &Handler{service: s}
+
+	code, errs := postInstall(t, h, `{"id":"bad id!","url":"ftp://example.com/p.zip","sha256":"zz"}`)
+	if code != 400 {
+		t.Fatalf("status = %d, want 400", code)
+	}
+	byField := map[string]string{}
+	for _, e := range errs {
+		if e.Message == "" {
+			t.Errorf("%s: empty message", e.Field)
+		}
+		byField[e.Field] = e.Code
+	}
+	want := map[string]string{"id": "INVALID_ID_FORMAT", "url": "INSECURE_PROTOCOL", "sha256": "INVALID_HASH_FORMAT"}
+	if len(byField) != len(want) {
+		t.Errorf("errors = %+v, want %v", errs, want)
+	}
+	for field, c := range want {
+		if byField[field] != c {
+			t.Errorf("%s: code = %q, want %q", field, byField[field], c)
+		}
+	}
+}
+
+func TestInstallPluginBlockedIs403(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{BlockedPluginIDs: []string{"demo"}}).(*ServiceImpl)
+	h := &Handler{service: s}
+
+	code, errs := postInstall(t, h, `{"id":"demo","url":"https://example.com/demo.zip"}`)
+	if code != 403 {
+		t.Fatalf("status = %d, want 403", code)
+	}
+	if len(errs) != 1 || errs[0].Field != "id" || errs[0].Code != "PLUGIN_NOT_ALLOWED" {
+		t.Errorf("errors = %+v", errs)
+	}
+}
@@ -0,0 +1,40 @@
+package plugin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVaultFilePathUniquePerUser(t *testing.T) {
+	typ := reflect.TypeOf(VaultFile{})
+	for _, name := range []string{"UserID", "Path"} {
+		field, _ := typ.FieldByName(name)
+		tag := field.Tag.Get("gorm")
+		if !strings.Contains(tag, "uniqueIndex:idx_vault_files_user_path") {
+			t.Errorf("%s gorm tag %q should join the (user_id, path) unique index", name, tag)
+		}
+		// 单列唯一索引会让不同用户无法拥有相同路径
+		if strings.Contains(tag, "uniqueIndex;") || strings.HasSuffix(tag, "uniqueIndex") {
+			t.Errorf("%s gorm tag %q declares a single-column unique index", name, tag)
+		}
+	}
+}
+
+func TestVaultSamePathForDifferentUsers(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	for user, content := range map[uint]string{1: "alice's todo", 2: "bob's todo"} {
+		if err := s.WriteVaultFile(user, &VaultWriteRequest{Path: "notes/todo.md", Content: content}); err != nil {
+			t.Fatalf("user %d write: %v", user, err)
+		}
+	}
+	for user, want := range map[uint]string{1: "alice's todo", 2: "bob's todo"} {
+		resp, err := s.ReadVaultFile(user, "notes/todo.md")
+		if err != nil {
+			t.Fatalf("user %d read: %v", user, err)
+		}
+		if resp.Content != want {
+			t.Errorf("user %d content = %q, want %q", user, resp.Content, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+)
+
+// readVaultContent 读取存储库文件内容，文件不存在时返回错误
+func readVaultContent(t *testing.T, s *ServiceImpl, userID uint, path string) (string, error) {
+	t.Helper()
+	resp, err := s.ReadVaultFile(userID, path)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func TestCopyVaultFile(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "templates/daily.md", Content: "# Daily"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CopyVaultFile(1, &VaultCopyRequest{From: "templates/daily.md", To: "journal/today.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readVaultContent(t, s, 1, "journal/today.md"); err != nil || got != "# Daily" {
+		t.Fatalf("copy = %q, %v", got, err)
+	}
+	if got, _ := readVaultContent(t, s, 1, "templates/daily.md"); got != "# Daily" {
+		t.Errorf("source changed to %q", got)
+	}
+	// 复制只在同一用户的存储库内进行
+	if _, err := readVaultContent(t, s, 2, "journal/today.md"); err == nil {
+		t.Error("copy is visible to another user")
+	}
+}
+
+func TestCopyVaultFileOverwrite(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	for path, content := range map[string]string{"a.md": "new", "b.md": "old"} {
+		if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: path, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.CopyVaultFile(1, &VaultCopyRequest{From: "a.md", To: "b.md"}); !errors.Is(err, ErrVaultFileExists) {
+		t.Fatalf("copy onto existing file: err = %v, want ErrVaultFileExists", err)
+	}
+	if got, _ := readVaultContent(t, s, 1, "b.md"); got != "old" {
+		t.Fatalf("refused copy changed the destination to %q", got)
+	}
+	if err := s.CopyVaultFile(1, &VaultCopyRequest{From: "a.md", To: "b.md", Overwrite: true}); err != nil {
+		t.Fatalf("copy with overwrite: %v", err)
+	}
+	if got, _ := readVaultContent(t, s, 1, "b.md"); got != "new" {
+		t.Errorf("destination after overwrite = %q, want new", got)
+	}
+	// 覆盖后修改源文件不影响副本
+	if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "a.md", Content: "changed"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := readVaultContent(t, s, 1, "b.md"); got != "new" {
+		t.Errorf("copy follows later source writes: %q", got)
+	}
+}
+
+func TestCopyVaultFileMissingSource(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	if err := s.CopyVaultFile(1, &VaultCopyRequest{From: "missing.md", To: "b.md"}); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Fatalf("err = %v, want ErrVaultFileNotFound", err)
+	}
+	if _, err := readVaultContent(t, s, 1, "b.md"); err == nil {
+		t.Error("failed copy created the destination")
+	}
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestExportVaultZipContents(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	fixture := map[string]string{
+		"index.md":        "# Index",
+		"notes/a.md":      "alpha",
+		"notes/deep/b.md": "beta",
+		"notesextra/c.md": "not under notes/",
+	}
+	for p, content := range fixture {
+		if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: p, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 其他用户的文件不应出现在导出中
+	if err := s.WriteVaultFile(2, &VaultWriteRequest{Path: "notes/other.md", Content: "other user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	export := func(prefix string) map[string]string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := s.ExportVault(context.Background(), 1, prefix, &buf); err != nil {
+			t.Fatalf("ExportVault(%q): %v", prefix, err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("export is not a zip: %v", err)
+		}
+		files := make(map[string]string)
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[f.Name] = string(data)
+		}
+		return files
+	}
+
+	all := export("")
+	if len(all) != len(fixture) {
+		t.Errorf("full export has %d files, want %d: %v", len(all), len(fixture), all)
+	}
+	for p, content := range fixture {
+		if all[p] != content {
+			t.Errorf("%s = %q, want %q", p, all[p], content)
+		}
+	}
+
+	notes := export("notes")
+	if len(notes) != 2 || notes["notes/a.md"] != "alpha" || notes["notes/deep/b.md"] != "beta" {
+		t.Errorf("prefix export = %v", notes)
+	}
+}
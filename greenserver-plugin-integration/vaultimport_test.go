@@ -0,0 +1,77 @@
+package plugin
+
+import (
+	"archive/zip"
+	"sort"
+	"testing"
+)
+
+// openTestZip 生成 zip 并打开，关闭由测试清理负责
+func openTestZip(t *testing.T, names []string, contents [][]byte) *zip.Reader {
+	t.Helper()
+	rc, err := zip.OpenReader(writeTestZip(t, t.TempDir(), names, contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rc.Close() })
+	return &rc.Reader
+}
+
+func TestImportVaultSmallZip(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	zr := openTestZip(t, []string{"a.md", "sub/b.md"}, [][]byte{[]byte("# A"), []byte("# B")})
+	result, err := s.ImportVault(1, "imported", zr)
+	if err != nil {
+		t.Fatalf("ImportVault: %v", err)
+	}
+	sort.Strings(result.Created)
+	if len(result.Created) != 2 || result.Created[0] != "imported/a.md" || result.Created[1] != "imported/sub/b.md" {
+		t.Errorf("created = %v", result.Created)
+	}
+	if len(result.Updated) != 0 || len(result.Skipped) != 0 {
+		t.Errorf("updated = %v, skipped = %v", result.Updated, result.Skipped)
+	}
+	got, err := s.ReadVaultFile(1, "imported/sub/b.md")
+	if err != nil || got.Content != "# B" {
+		t.Errorf("ReadVaultFile = %+v, %v", got, err)
+	}
+
+	// 再次导入同名文件记为更新
+	zr = openTestZip(t, []string{"a.md"}, [][]byte{[]byte("# A2")})
+	result, err = s.ImportVault(1, "imported", zr)
+	if err != nil {
+		t.Fatalf("re-import: %v", err)
+	}
+	if len(result.Created) != 0 || len(result.Updated) != 1 || result.Updated[0] != "imported/a.md" {
+		t.Errorf("re-import: created = %v, updated = %v", result.Created, result.Updated)
+	}
+}
+
+func TestImportVaultSkipsTraversal(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	zr := openTestZip(t,
+		[]string{"ok.md", "../evil.md", "../../escape.md", "/etc/absolute.md"},
+		[][]byte{[]byte("ok"), []byte("evil"), []byte("evil"), []byte("evil")})
+	result, err := s.ImportVault(1, "imported", zr)
+	if err != nil {
+		t.Fatalf("ImportVault: %v", err)
+	}
+	if len(result.Created) != 1 || result.Created[0] != "imported/ok.md" {
+		t.Errorf("created = %v", result.Created)
+	}
+	if len(result.Skipped) != 3 {
+		t.Fatalf("skipped = %v, want 3 entries", result.Skipped)
+	}
+	for _, sk := range result.Skipped {
+		if sk.Reason != "invalid path" {
+			t.Errorf("skip %s: reason = %q", sk.Path, sk.Reason)
+		}
+	}
+	for _, p := range []string{"evil.md", "escape.md", "etc/absolute.md"} {
+		if _, err := s.ReadVaultFile(1, p); err == nil {
+			t.Errorf("%s was written outside the import prefix", p)
+		}
+	}
+}
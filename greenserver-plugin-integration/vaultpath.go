@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// MaxVaultPathLength 存储库相对路径的最大字节数，与 vault_files.path 列的 VARCHAR(1000) 一致
+const MaxVaultPathLength = 1000
+
+// ErrInvalidVaultPath 存储库路径过长、包含控制字符或在其他平台上不可用
+var ErrInvalidVaultPath = errors.New("vault.invalidPath")
+
+// windowsReservedNames Windows 保留的设备名，带扩展名时同样不可用
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// validateVaultPath 检查写入的存储库路径能否同步到各个平台：长度不超过 MaxVaultPathLength，
+// 不含控制字符，各级名称不是 Windows 保留设备名且不以点或空格结尾
+func validateVaultPath(path string) error {
+	cleaned := filepath.ToSlash(filepath.Clean(path))
+	if cleaned == "." {
+		return fmt.Errorf("%w: empty path", ErrInvalidVaultPath)
+	}
+	if len(cleaned) > MaxVaultPathLength {
+		return fmt.Errorf("%w: path longer than %d bytes", ErrInvalidVaultPath, MaxVaultPathLength)
+	}
+	for _, r := range cleaned {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("%w: control character in path", ErrInvalidVaultPath)
+		}
+	}
+	for _, name := range strings.Split(strings.TrimPrefix(cleaned, "/"), "/") {
+		base, _, _ := strings.Cut(name, ".")
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+			return fmt.Errorf("%w: reserved name %q", ErrInvalidVaultPath, name)
+		}
+		if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+			return fmt.Errorf("%w: name %q ends with a dot or space", ErrInvalidVaultPath, name)
+		}
+	}
+	return nil
+}
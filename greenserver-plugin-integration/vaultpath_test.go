@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateVaultPath(t *testing.T) {
+	for _, p := range []string{
+		"a.md",
+		"notes/2024/daily.md",
+		"/notes/a.md",
+		"console.md",
+		"COM10.txt",
+		"notes/.hidden",
+		"中文/笔记.md",
+		strings.Repeat("a", MaxVaultPathLength),
+	} {
+		if err := validateVaultPath(p); err != nil {
+			t.Errorf("validateVaultPath(%q) = %v, want ok", p, err)
+		}
+	}
+	for _, p := range []string{
+		"",
+		strings.Repeat("a", MaxVaultPathLength+1),
+		"a\x00b.md",
+		"notes/a\nb.md",
+		"del\x7f.md",
+		"CON",
+		"nul.txt",
+		"/notes/Aux/a.md",
+		"com1.tar.gz",
+		"LPT9 .md",
+		"notes./a.md",
+		"a.md.",
+		"a.md ",
+	} {
+		if err := validateVaultPath(p); !errors.Is(err, ErrInvalidVaultPath) {
+			t.Errorf("validateVaultPath(%q) = %v, want ErrInvalidVaultPath", p, err)
+		}
+	}
+}
+
+func TestWriteVaultFileRejectsInvalidPath(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+
+	for _, p := range []string{"CON.md", "a\x01.md", "trailing.", strings.Repeat("x", MaxVaultPathLength+1)} {
+		if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: p, Content: "x"}); !errors.Is(err, ErrInvalidVaultPath) {
+			t.Errorf("write %q: err = %v, want ErrInvalidVaultPath", p, err)
+		}
+	}
+	if files, err := s.ListVaultFiles(context.Background(), 1); err != nil || len(files) != 0 {
+		t.Fatalf("vault files = %v, %v", files, err)
+	}
+
+	if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "a.md", Content: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	// 复制的目标路径同样校验
+	if err := s.CopyVaultFile(1, &VaultCopyRequest{From: "a.md", To: "notes/NUL.md"}); !errors.Is(err, ErrInvalidVaultPath) {
+		t.Errorf("copy to a reserved name: err = %v, want ErrInvalidVaultPath", err)
+	}
+}
+
+func TestImportVaultSkipsInvalidPaths(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"ok.md", "aux.md", "notes./a.md"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.ImportVault(1, "", zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Skipped) != 2 {
+		t.Fatalf("skipped = %+v, want aux.md and notes./a.md", result.Skipped)
+	}
+	for _, sk := range result.Skipped {
+		if !strings.Contains(sk.Reason, "vault.invalidPath") {
+			t.Errorf("skip reason for %s = %q", sk.Path, sk.Reason)
+		}
+	}
+	if _, err := s.ReadVaultFile(1, "ok.md"); err != nil {
+		t.Errorf("valid entry not imported: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// brokenVaultRepo 查询存储库文件时返回固定的数据库错误
+type brokenVaultRepo struct {
+	Repository
+}
+
+func (r *brokenVaultRepo) GetVaultFileByPath(uint, string) (*VaultFile, error) {
+	return nil, errors.New("connection refused")
+}
+
+// callVaultRPC 以已登录用户的身份调用存储库方法
+func callVaultRPC(t *testing.T, h *Handler, pluginID, method string, params interface{}) (int, RPCResponse) {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"id": "1", "method": method, "pluginId": pluginID, "params": params})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/rpc", strings.NewReader(string(body)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", uint(1))
+	h.HandleRPC(c)
+	var resp RPCResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: decode response %q: %v", method, w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func TestVaultErrorCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: a.md", ErrVaultFileNotFound), 404},
+		{fmt.Errorf("%w: notes", ErrVaultIsDir), 400},
+		{errors.New("connection refused"), 500},
+	} {
+		if got := vaultErrorCode(tc.err); got != tc.want {
+			t.Errorf("vaultErrorCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestReadVaultFileErrors(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "notes/a.md", Content: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.ReadVaultFile(1, "missing.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("missing file: %v, want ErrVaultFileNotFound", err)
+	}
+	for _, dir := range []string{"notes", "notes/", "./notes"} {
+		if _, err := s.ReadVaultFile(1, dir); !errors.Is(err, ErrVaultIsDir) {
+			t.Errorf("read %q: %v, want ErrVaultIsDir", dir, err)
+		}
+	}
+	// 同名前缀和其他用户的目录不算目录
+	if _, err := s.ReadVaultFile(1, "note"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("prefix of a directory: %v, want ErrVaultFileNotFound", err)
+	}
+	if _, err := s.ReadVaultFile(2, "notes"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("another user's directory: %v, want ErrVaultFileNotFound", err)
+	}
+}
+
+func TestVaultReadRPCErrorCodes(t *testing.T) {
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	createTestPlugins(t, repo, "rw")
+	for _, perm := range []string{"vault.read", "vault.write"} {
+		if err := repo.AddPluginPermission("rw", perm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "notes/a.md", Content: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{service: s}
+
+	for path, want := range map[string]int{"notes/a.md": 200, "missing.md": 404, "notes": 400} {
+		if code, resp := callVaultRPC(t, h, "rw", "vault.read", map[string]string{"path": path}); code != want {
+			t.Errorf("vault.read %s: got %d %+v, want %d", path, code, resp.Error, want)
+		}
+	}
+	if code, _ := callVaultRPC(t, h, "rw", "vault.delete", map[string]string{"path": "missing.md"}); code != 404 {
+		t.Errorf("vault.delete missing: got %d, want 404", code)
+	}
+
+	// 数据库错误不再被当作文件不存在
+	broken := NewServiceWithOptions(&brokenVaultRepo{Repository: repo}, t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	code, resp := callVaultRPC(t, &Handler{service: broken}, "rw", "vault.read", map[string]string{"path": "notes/a.md"})
+	if code != 500 || resp.Error == nil || !strings.Contains(resp.Error.Message, "connection refused") {
+		t.Errorf("vault.read with a failing repository: got %d %+v, want 500", code, resp.Error)
+	}
+}
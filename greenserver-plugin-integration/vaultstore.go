@@ -0,0 +1,226 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VaultFileInfo 存储库文件的元数据
+type VaultFileInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// VaultStore 用户存储库的存储后端，各用户的文件互相隔离。
+// 文件不存在时 Read、Delete、Stat 返回包装了 ErrVaultFileNotFound 的错误；
+// 配额和写入策略由服务在调用前检查
+type VaultStore interface {
+	// List 列出用户的全部文件路径，ctx 取消时中止查询
+	List(ctx context.Context, userID uint) ([]string, error)
+	Read(userID uint, path string) ([]byte, error)
+	// Write 创建或覆盖文件
+	Write(userID uint, path string, content []byte) error
+	Delete(userID uint, path string) error
+	Stat(userID uint, path string) (*VaultFileInfo, error)
+}
+
+// vaultUsager 可选接口，后端能直接给出用户占用空间时不必逐个统计文件
+type vaultUsager interface {
+	Usage(userID uint) (int64, error)
+}
+
+// vaultCopier 可选接口，后端能在不读出内容的情况下复制文件
+type vaultCopier interface {
+	Copy(userID uint, from, to string) error
+}
+
+// RepositoryVaultStore 把存储库保存在 Repository 中，文件内容按 SHA256 存为共享的数据块
+type RepositoryVaultStore struct {
+	repo Repository
+}
+
+// NewRepositoryVaultStore 创建基于 Repository 的存储库后端
+func NewRepositoryVaultStore(repo Repository) *RepositoryVaultStore {
+	return &RepositoryVaultStore{repo: repo}
+}
+
+// getFile 查询文件记录，不存在时返回 ErrVaultFileNotFound
+func (v *RepositoryVaultStore) getFile(userID uint, path string) (*VaultFile, error) {
+	file, err := v.repo.GetVaultFileByPath(userID, path)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrVaultFileNotFound, path)
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// content 返回文件内容，旧数据直接存放在记录中
+func (v *RepositoryVaultStore) content(file *VaultFile) ([]byte, error) {
+	if file.BlobHash == "" {
+		return file.Content, nil
+	}
+	blob, err := v.repo.GetVaultBlob(file.BlobHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vault blob for %s: %w", file.Path, err)
+	}
+	return blob.Content, nil
+}
+
+func (v *RepositoryVaultStore) List(ctx context.Context, userID uint) ([]string, error) {
+	files, err := v.repo.GetVaultFilesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	return paths, nil
+}
+
+func (v *RepositoryVaultStore) Read(userID uint, path string) ([]byte, error) {
+	file, err := v.getFile(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	return v.content(file)
+}
+
+func (v *RepositoryVaultStore) Write(userID uint, path string, content []byte) error {
+	existingFile, err := v.repo.GetVaultFileByPath(userID, path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256(content))
+	return v.repo.Transaction(func(repo Repository) error {
+		// 相同内容只保存一个数据块
+		if err := repo.CreateVaultBlob(&VaultBlob{Hash: hash, Content: content, Size: int64(len(content))}); err != nil {
+			return err
+		}
+
+		if exists {
+			// 更新现有文件
+			oldHash := existingFile.BlobHash
+			existingFile.Content = nil
+			existingFile.BlobHash = hash
+			existingFile.Size = int64(len(content))
+			if err := repo.UpdateVaultFile(existingFile); err != nil {
+				return err
+			}
+			if oldHash != "" && oldHash != hash {
+				return gcVaultBlob(repo, oldHash)
+			}
+			return nil
+		}
+
+		// 创建新文件
+		return repo.CreateVaultFile(&VaultFile{
+			Path:     filepath.Clean(path),
+			BlobHash: hash,
+			Size:     int64(len(content)),
+			UserID:   userID,
+		})
+	})
+}
+
+// Copy 复制文件，新记录与源文件共用同一数据块
+func (v *RepositoryVaultStore) Copy(userID uint, from, to string) error {
+	src, err := v.getFile(userID, from)
+	if err != nil {
+		return err
+	}
+	dst, err := v.repo.GetVaultFileByPath(userID, to)
+	exists := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return v.repo.Transaction(func(repo Repository) error {
+		hash := src.BlobHash
+		if hash == "" {
+			// 旧数据内容直接存放在记录中，复制时转存为数据块
+			hash = fmt.Sprintf("%x", sha256.Sum256(src.Content))
+			if err := repo.CreateVaultBlob(&VaultBlob{Hash: hash, Content: src.Content, Size: int64(len(src.Content))}); err != nil {
+				return err
+			}
+		}
+
+		if exists {
+			oldHash := dst.BlobHash
+			dst.Content = nil
+			dst.BlobHash = hash
+			dst.MimeType = src.MimeType
+			dst.Size = src.Size
+			if err := repo.UpdateVaultFile(dst); err != nil {
+				return err
+			}
+			if oldHash != "" && oldHash != hash {
+				return gcVaultBlob(repo, oldHash)
+			}
+			return nil
+		}
+
+		return repo.CreateVaultFile(&VaultFile{
+			Path:     filepath.Clean(to),
+			BlobHash: hash,
+			MimeType: src.MimeType,
+			Size:     src.Size,
+			UserID:   userID,
+		})
+	})
+}
+
+// Delete 删除文件，并回收不再被引用的数据块
+func (v *RepositoryVaultStore) Delete(userID uint, path string) error {
+	file, err := v.getFile(userID, path)
+	if err != nil {
+		return err
+	}
+
+	return v.repo.Transaction(func(repo Repository) error {
+		if err := repo.DeleteVaultFile(userID, file.Path); err != nil {
+			return err
+		}
+		if file.BlobHash == "" {
+			return nil
+		}
+		return gcVaultBlob(repo, file.BlobHash)
+	})
+}
+
+func (v *RepositoryVaultStore) Stat(userID uint, path string) (*VaultFileInfo, error) {
+	file, err := v.getFile(userID, path)
+	if err != nil {
+		return nil, err
+	}
+	return &VaultFileInfo{Path: file.Path, Size: file.Size, ModTime: file.UpdatedAt}, nil
+}
+
+// Usage 返回用户存储库的已用空间
+func (v *RepositoryVaultStore) Usage(userID uint) (int64, error) {
+	return v.repo.GetVaultUsage(userID)
+}
+
+// gcVaultBlob 数据块没有任何文件引用时将其删除
+func gcVaultBlob(repo Repository, hash string) error {
+	refs, err := repo.CountVaultBlobRefs(hash)
+	if err != nil {
+		return err
+	}
+	if refs > 0 {
+		return nil
+	}
+	return repo.DeleteVaultBlob(hash)
+}
@@ -0,0 +1,155 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestVaultStoreDeduplicatesBlobs(t *testing.T) {
+	repo := NewInMemoryRepository()
+	v := NewRepositoryVaultStore(repo)
+	content := []byte("same content")
+	hash := fmt.Sprintf("%x", sha256.Sum256(content))
+
+	for _, write := range []struct {
+		user uint
+		path string
+	}{{1, "a.md"}, {1, "b.md"}, {2, "a.md"}} {
+		if err := v.Write(write.user, write.path, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.Copy(1, "a.md", "c.md"); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _ := repo.CountVaultBlobRefs(hash); refs != 4 {
+		t.Fatalf("blob refs = %d, want 4 files sharing one blob", refs)
+	}
+	if got, err := v.Read(2, "a.md"); err != nil || string(got) != string(content) {
+		t.Fatalf("Read = %q, %v", got, err)
+	}
+
+	// 删除部分引用时保留数据块，最后一个引用删除后回收
+	for _, f := range []struct {
+		user uint
+		path string
+	}{{1, "a.md"}, {1, "b.md"}, {1, "c.md"}} {
+		if err := v.Delete(f.user, f.path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.GetVaultBlob(hash); err != nil {
+		t.Fatalf("blob still referenced by user 2 was collected: %v", err)
+	}
+	if err := v.Delete(2, "a.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetVaultBlob(hash); err == nil {
+		t.Fatal("unreferenced blob should be garbage-collected")
+	}
+}
+
+func TestVaultStoreOverwriteCollectsOldBlob(t *testing.T) {
+	repo := NewInMemoryRepository()
+	v := NewRepositoryVaultStore(repo)
+	old := fmt.Sprintf("%x", sha256.Sum256([]byte("v1")))
+
+	if err := v.Write(1, "a.md", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(1, "a.md", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetVaultBlob(old); err == nil {
+		t.Error("overwritten content's blob should be collected")
+	}
+	if got, _ := v.Read(1, "a.md"); string(got) != "v2" {
+		t.Errorf("Read = %q, want v2", got)
+	}
+}
+
+// testVaultStore 对任意后端运行同一组行为检查
+func testVaultStore(t *testing.T, v VaultStore) {
+	t.Helper()
+	if err := v.Write(1, "notes/a.md", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(1, "notes/a.md", []byte("version 2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(1, "b.md", []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(2, "other.md", []byte("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := v.Read(1, "notes/a.md"); err != nil || string(got) != "version 2" {
+		t.Errorf("Read = %q, %v, want the overwritten content", got, err)
+	}
+	if info, err := v.Stat(1, "notes/a.md"); err != nil || info.Path != "notes/a.md" || info.Size != int64(len("version 2")) {
+		t.Errorf("Stat = %+v, %v", info, err)
+	}
+	paths, err := v.List(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	if got := strings.Join(paths, ","); got != "b.md,notes/a.md" {
+		t.Errorf("List(1) = %s, want b.md,notes/a.md", got)
+	}
+
+	// 各用户的文件互相隔离
+	if _, err := v.Read(2, "b.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("Read of another user's file: err = %v, want ErrVaultFileNotFound", err)
+	}
+
+	if err := v.Delete(1, "b.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Read(1, "b.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("Read after Delete: err = %v, want ErrVaultFileNotFound", err)
+	}
+	if err := v.Delete(1, "b.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrVaultFileNotFound", err)
+	}
+	if _, err := v.Stat(1, "missing.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("Stat of missing file: err = %v, want ErrVaultFileNotFound", err)
+	}
+	if got, _ := v.Read(2, "other.md"); string(got) != "other" {
+		t.Errorf("user 2 file = %q after user 1 changes", got)
+	}
+}
+
+func TestVaultStoreBackends(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		testVaultStore(t, NewMemoryVaultStore())
+	})
+	t.Run("repository", func(t *testing.T) {
+		testVaultStore(t, NewRepositoryVaultStore(NewInMemoryRepository()))
+	})
+}
+
+func TestServiceUsesConfiguredVaultStore(t *testing.T) {
+	v := NewMemoryVaultStore()
+	repo := NewInMemoryRepository()
+	s := NewServiceWithOptions(repo, t.TempDir(), t.TempDir(), "", ServiceOptions{VaultStore: v}).(*ServiceImpl)
+
+	if err := s.WriteVaultFile(1, &VaultWriteRequest{Path: "a.md", Content: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := v.Read(1, "a.md"); err != nil || string(got) != "hello" {
+		t.Errorf("configured store Read = %q, %v", got, err)
+	}
+	if files, _ := repo.GetVaultFilesByUserID(context.Background(), 1); len(files) != 0 {
+		t.Errorf("write reached the repository: %d files", len(files))
+	}
+	if resp, err := s.ReadVaultFile(1, "a.md"); err != nil || resp.Content != "hello" {
+		t.Errorf("ReadVaultFile = %+v, %v", resp, err)
+	}
+}
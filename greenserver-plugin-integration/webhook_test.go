@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// webhookRequest Webhook 服务端收到的一次投递
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// serveWebhook 启动接收 Webhook 的测试服务，收到的请求写入返回的通道
+func serveWebhook(t *testing.T) (string, <-chan webhookRequest) {
+	t.Helper()
+	received := make(chan webhookRequest, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- webhookRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, received
+}
+
+func TestWebhookConfigMatches(t *testing.T) {
+	cases := []struct {
+		events []string
+		typ    string
+		want   bool
+	}{
+		{nil, "vault.changed", true},
+		{[]string{"*"}, "vault.changed", true},
+		{[]string{"plugin.installed"}, "plugin.installed", true},
+		{[]string{"plugin.installed"}, "plugin.uninstalled", false},
+		{[]string{"plugin.*"}, "plugin.uninstalled", true},
+		{[]string{"plugin.*"}, "vault.changed", false},
+	}
+	for _, tc := range cases {
+		if got := (WebhookConfig{Events: tc.events}).matches(tc.typ); got != tc.want {
+			t.Errorf("events %v matches %q = %v, want %v", tc.events, tc.typ, got, tc.want)
+		}
+	}
+}
+
+func TestWebhookDeliversFilteredSignedEvent(t *testing.T) {
+	url, received := serveWebhook(t)
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{
+		Webhooks: []WebhookConfig{{URL: url, Events: []string{"plugin.*"}, Secret: "s3cret"}},
+	}).(*ServiceImpl)
+
+	s.Broadcast(&EventData{Type: "vault.changed", Data: map[string]interface{}{"path": "a.md"}})
+	s.Broadcast(&EventData{Type: "plugin.installed", Data: map[string]interface{}{"pluginId": "demo"}})
+
+	var req webhookRequest
+	select {
+	case req = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	if got := req.header.Get("X-Event-Type"); got != "plugin.installed" {
+		t.Fatalf("delivered %q, want only plugin.installed", got)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(req.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.header.Get("X-Signature") != want {
+		t.Errorf("X-Signature = %q, want %q", req.header.Get("X-Signature"), want)
+	}
+	var payload struct {
+		Type string            `json:"type"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("decode payload %q: %v", req.body, err)
+	}
+	if payload.Type != "plugin.installed" || payload.Data["pluginId"] != "demo" {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	select {
+	case extra := <-received:
+		t.Errorf("unexpected delivery of %q", extra.header.Get("X-Event-Type"))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookWithoutSecretIsUnsigned(t *testing.T) {
+	url, received := serveWebhook(t)
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{Webhooks: []WebhookConfig{{URL: url}}}).(*ServiceImpl)
+
+	s.Broadcast(&EventData{Type: "vault.changed"})
+	select {
+	case req := <-received:
+		if sig := req.header.Get("X-Signature"); sig != "" {
+			t.Errorf("unsigned webhook sent X-Signature %q", sig)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
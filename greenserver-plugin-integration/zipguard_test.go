@@ -0,0 +1,240 @@
+package plugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestZip 按顺序写入条目，返回压缩包路径
+func writeTestZip(t *testing.T, dir string, names []string, contents [][]byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(contents[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "pkg.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractZip(t *testing.T) {
+	s := &ServiceImpl{}
+	src := writeTestZip(t, t.TempDir(),
+		[]string{"manifest.json", "dist/main.js", "../escape.txt"},
+		[][]byte{[]byte(`{"id":"p"}`), []byte("console.log(1)"), []byte("x")})
+	dest := filepath.Join(t.TempDir(), "p")
+	if err := s.extractZip(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "dist", "main.js")); err != nil || string(data) != "console.log(1)" {
+		t.Fatalf("extracted file = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "escape.txt")); !os.IsNotExist(err) {
+		t.Fatal("traversal entry was extracted")
+	}
+}
+
+func TestExtractZipFailureKeepsExistingFiles(t *testing.T) {
+	s := &ServiceImpl{}
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "p")
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "a.txt"), []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 第二个条目压缩比过高，解压在覆盖 a.txt 之后失败
+	src := writeTestZip(t, t.TempDir(),
+		[]string{"a.txt", "zeros.bin"},
+		[][]byte{[]byte("replaced"), make([]byte, 4<<20)})
+	if err := s.extractZip(src, dest); !errors.Is(err, ErrZipBomb) {
+		t.Fatalf("got %v, want ErrZipBomb", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "a.txt")); string(data) != "original" {
+		t.Fatalf("a.txt = %q, want original content", data)
+	}
+	entries, _ := os.ReadDir(parent)
+	for _, e := range entries {
+		if e.Name() != "p" {
+			t.Errorf("leftover temp entry %s", e.Name())
+		}
+	}
+}
+
+func TestExtractZipRejectsTooManyEntries(t *testing.T) {
+	s := &ServiceImpl{}
+	names := make([]string, maxZipEntries+1)
+	contents := make([][]byte, len(names))
+	for i := range names {
+		names[i] = fmt.Sprintf("f%d", i)
+	}
+	src := writeTestZip(t, t.TempDir(), names, contents)
+	if err := s.extractZip(src, filepath.Join(t.TempDir(), "p")); !errors.Is(err, ErrZipBomb) {
+		t.Fatalf("got %v, want ErrZipBomb", err)
+	}
+}
+
+// writeSymlinkZip 写入一个普通文件和一个指向 /etc/passwd 的符号链接条目，返回压缩包路径
+func writeSymlinkZip(t *testing.T, dir string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(`{"id":"p"}`)); err != nil {
+		t.Fatal(err)
+	}
+	hdr := &zip.FileHeader{Name: "passwd", Method: zip.Store}
+	hdr.SetMode(os.ModeSymlink | 0o777)
+	if w, err = zw.CreateHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("/etc/passwd")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "evil.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractZipRejectsSymlinkEntry(t *testing.T) {
+	s := &ServiceImpl{}
+	dest := filepath.Join(t.TempDir(), "p")
+	if err := s.extractZip(writeSymlinkZip(t, t.TempDir()), dest); !errors.Is(err, ErrUnsafeZipEntry) {
+		t.Fatalf("got %v, want ErrUnsafeZipEntry", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "passwd")); !os.IsNotExist(err) {
+		t.Errorf("symlink entry was extracted: %v", err)
+	}
+}
+
+func TestExtractZipDoesNotFollowExistingSymlink(t *testing.T) {
+	s := &ServiceImpl{}
+	dest, outside := t.TempDir(), t.TempDir()
+	// 插件目录中已有指向外部的符号链接，覆盖安装时不能跟随
+	if err := os.Symlink(outside, filepath.Join(dest, "dist")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	src := writeTestZip(t, t.TempDir(), []string{"dist/main.js"}, [][]byte{[]byte("x")})
+	if err := s.extractZip(src, dest); !errors.Is(err, ErrUnsafeZipEntry) {
+		t.Fatalf("got %v, want ErrUnsafeZipEntry", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "main.js")); !os.IsNotExist(err) {
+		t.Error("file was written through the symlink")
+	}
+}
+
+func TestImportVaultSkipsSymlinkEntry(t *testing.T) {
+	s := NewServiceWithOptions(NewInMemoryRepository(), t.TempDir(), t.TempDir(), "", ServiceOptions{}).(*ServiceImpl)
+	rc, err := zip.OpenReader(writeSymlinkZip(t, t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	result, err := s.ImportVault(1, "", &rc.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skipped bool
+	for _, sk := range result.Skipped {
+		if sk.Path == "passwd" && sk.Reason == "unsupported entry type" {
+			skipped = true
+		}
+	}
+	if !skipped {
+		t.Errorf("symlink entry not skipped: %+v", result)
+	}
+}
+
+func TestExtractedFileMode(t *testing.T) {
+	cases := map[os.FileMode]os.FileMode{
+		0o644:                 0o644,
+		0o755:                 0o644,
+		0o600:                 0o600,
+		0o777:                 0o666,
+		0o755 | os.ModeSetuid: 0o644,
+		0o750 | os.ModeSetgid: 0o640,
+		0o111 | os.ModeSticky: 0o644,
+		0:                     0o644,
+	}
+	for in, want := range cases {
+		if got := extractedFileMode(in); got != want {
+			t.Errorf("extractedFileMode(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestExtractZipMasksFileModes(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, mode := range map[string]os.FileMode{
+		"bin/run.sh":  0o755,
+		"suid":        0o755 | os.ModeSetuid,
+		"private.txt": 0o600,
+	} {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		hdr.SetMode(mode)
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "pkg.zip")
+	if err := os.WriteFile(src, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := t.TempDir()
+	// 覆盖安装时已存在的可执行文件也去掉可执行位
+	if err := os.MkdirAll(filepath.Join(dest, "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dest, "bin", "run.sh"), []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := &ServiceImpl{}
+	if err := s.extractZip(src, dest); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]os.FileMode{"bin/run.sh": 0o644, "suid": 0o644, "private.txt": 0o600} {
+		info, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != want {
+			t.Errorf("%s mode = %v, want %v", name, info.Mode(), want)
+		}
+	}
+}
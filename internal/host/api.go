@@ -2,11 +2,33 @@ package host
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"path/filepath"
+	"sort"
+	"strconv"
 )
 
+const (
+	// HostVersion 宿主程序版本
+	HostVersion = "0.1.0"
+	// APIVersion RPC接口版本，接口出现不兼容变更时递增
+	APIVersion = "1"
+	// SDKVersion /sdk/ 下提供的 JS SDK 版本，插件可在清单 engines.sdk 中声明兼容的范围
+	SDKVersion = "1.0.0"
+)
+
+// knownPermissions 宿主支持的插件权限
+var knownPermissions = []string{
+	"vault.read",
+	"vault.write",
+	"kv.read",
+	"kv.write",
+	"commands.register",
+	"events.publish",
+}
+
 type rpcRequest struct {
 	ID       string          `json:"id,omitempty"`
 	Method   string          `json:"method"`
@@ -23,8 +45,13 @@ type rpcResponse struct {
 type rpcError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// Details 参数校验失败时每个字段的问题
+	Details []rpcFieldError `json:"details,omitempty"`
 }
 
+// rpcHandler 处理单个RPC方法
+type rpcHandler func(w http.ResponseWriter, r *http.Request, req rpcRequest)
+
 func (h *PluginHost) StartHTTPServer(addr string) error {
 	mux := http.NewServeMux()
 
@@ -49,14 +76,20 @@ func (h *PluginHost) StartHTTPServer(addr string) error {
 		_, _ = w.Write([]byte("ok"))
 	})
 	mux.HandleFunc("/events", h.handleSSE)
+	mux.HandleFunc("/events/history", h.handleEventHistory)
 	mux.HandleFunc("/rpc", h.handleRPC)
 	mux.HandleFunc("/market", h.handleMarket)
+	mux.HandleFunc("/vault/raw", h.handleVaultRaw)
+	mux.HandleFunc("/vault/import", h.handleVaultImport)
+	mux.HandleFunc("/vault/export", h.handleVaultExport)
+	mux.HandleFunc("/vault/watch", h.handleVaultWatch)
+	mux.HandleFunc("/audit", h.handleAudit)
 
 	// Serve SDK and plugin static assets with CORS
 	sdkDir := filepath.Join(h.config.RootDir, "sdk")
 	webDir := filepath.Join(h.config.RootDir, "web")
 	mux.Handle("/sdk/", corsHandler(http.StripPrefix("/sdk/", http.FileServer(http.Dir(sdkDir)))))
-	mux.Handle("/plugins/", corsHandler(http.StripPrefix("/plugins/", http.FileServer(http.Dir(h.config.PluginsDir)))))
+	mux.Handle("/plugins/", corsHandler(h.pluginsHandler(http.StripPrefix("/plugins/", http.FileServer(http.Dir(h.config.PluginsDir))))))
 	mux.Handle("/web/", corsHandler(http.StripPrefix("/web/", http.FileServer(http.Dir(webDir)))))
 
 	log.Printf("HTTP server listening on %s", addr)
@@ -71,230 +104,1099 @@ func (h *PluginHost) handleRPC(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	body := &rpcStatsReader{ReadCloser: http.MaxBytesReader(w, r.Body, h.maxRPCRequestBytes())}
+	r.Body = body
 	var req rpcRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeRPCError(w, req.ID, 400, "invalid json")
 		return
 	}
-	switch req.Method {
-	case "host.getPlugins":
-		type pluginInfo struct {
-			ID          string       `json:"id"`
-			Name        string       `json:"name"`
-			Version     string       `json:"version"`
-			Enabled     bool         `json:"enabled"`
-			Entrypoints *Entrypoints `json:"entrypoints,omitempty"`
-			BackupPath  string       `json:"backupPath,omitempty"`
-		}
-		h.pluginsMu.RLock()
-		infos := make([]pluginInfo, 0, len(h.plugins))
-		for _, p := range h.plugins {
-			infos = append(infos, pluginInfo{
-				ID:          p.Manifest.ID,
-				Name:        p.Manifest.Name,
-				Version:     p.Manifest.Version,
-				Enabled:     p.Enabled,
-				Entrypoints: p.Manifest.Entrypoints,
-				BackupPath:  p.BackupPath,
+	handler, ok := h.rpcMethods[req.Method]
+	if !ok {
+		writeRPCError(w, req.ID, 404, "unknown method")
+		return
+	}
+	// 运维禁用的方法在任何权限检查之前拒绝
+	if h.methodDisabled(req.Method) {
+		writeRPCError(w, req.ID, 403, "method disabled")
+		return
+	}
+	if err := checkRPCParams(req.Params, h.maxRPCBatchSize(), h.maxRPCParamsDepth()); err != nil {
+		writeRPCError(w, req.ID, 400, err.Error())
+		return
+	}
+	if details := checkRPCSchema(req.Method, req.Params); len(details) > 0 {
+		writeRPCFieldErrors(w, req.ID, details)
+		return
+	}
+	if mutatingRPCMethods[req.Method] && h.IsReadOnly() {
+		writeRPCError(w, req.ID, 503, readOnlyMessage)
+		return
+	}
+	pluginID, err := h.resolvePluginID(r, req.PluginID)
+	if err != nil {
+		writeRPCError(w, req.ID, pluginAuthStatus(err), err.Error())
+		return
+	}
+	req.PluginID = pluginID
+
+	sw := &rpcStatsWriter{ResponseWriter: w}
+	defer func() { h.recordPluginRPC(pluginID, body.n, sw) }()
+	if !h.allowPluginRPC(pluginID) {
+		writeRPCError(sw, req.ID, 429, "rate limit exceeded")
+		return
+	}
+	h.serveRPCWithTimeout(sw, r, req, handler)
+}
+
+// registerRPCMethods 注册所有RPC方法，host.getInfo 返回的方法列表也来自这里
+func (h *PluginHost) registerRPCMethods() {
+	h.rpcMethods = map[string]rpcHandler{
+		"host.getPlugins": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			type pluginInfo struct {
+				ID                 string       `json:"id"`
+				Name               string       `json:"name"`
+				Description        string       `json:"description,omitempty"`
+				Version            string       `json:"version"`
+				Enabled            bool         `json:"enabled"`
+				Entrypoints        *Entrypoints `json:"entrypoints,omitempty"`
+				BackupPath         string       `json:"backupPath,omitempty"`
+				Tags               []string     `json:"tags,omitempty"`
+				Trusted            bool         `json:"trusted,omitempty"`
+				IconURL            string       `json:"iconUrl"`
+				PendingPermissions []string     `json:"pendingPermissions,omitempty"`
+			}
+			var params struct {
+				// Sort 排序字段：id（默认）或 name，名称相同时按 id 排序
+				Sort string `json:"sort"`
+			}
+			if len(req.Params) > 0 {
+				if err := json.Unmarshal(req.Params, &params); err != nil {
+					writeRPCError(w, req.ID, 400, "invalid params")
+					return
+				}
+			}
+			if params.Sort != "" && params.Sort != "id" && params.Sort != "name" {
+				writeRPCError(w, req.ID, 400, "invalid sort: "+params.Sort)
+				return
+			}
+			// 名称和描述按 Accept-Language 选择清单中的本地化文本
+			lang := r.Header.Get("Accept-Language")
+			h.pluginsMu.RLock()
+			infos := make([]pluginInfo, 0, len(h.plugins))
+			for _, p := range h.plugins {
+				var i18n ManifestI18n
+				if p.Manifest.I18n != nil {
+					i18n = *p.Manifest.I18n
+				}
+				infos = append(infos, pluginInfo{
+					ID:                 p.Manifest.ID,
+					Name:               localizedText(p.Manifest.Name, i18n.Name, lang),
+					Description:        localizedText(p.Manifest.Description, i18n.Description, lang),
+					Version:            p.Manifest.Version,
+					Enabled:            p.Enabled,
+					Entrypoints:        p.Manifest.Entrypoints,
+					BackupPath:         p.BackupPath,
+					Tags:               p.Manifest.Tags,
+					Trusted:            h.isTrustedPlugin(p.Manifest.ID),
+					IconURL:            pluginIconURL(p.Manifest.ID),
+					PendingPermissions: p.PendingPermissions,
+				})
+			}
+			h.pluginsMu.RUnlock()
+			sort.Slice(infos, func(i, j int) bool {
+				if params.Sort == "name" && infos[i].Name != infos[j].Name {
+					return infos[i].Name < infos[j].Name
+				}
+				return infos[i].ID < infos[j].ID
 			})
-		}
-		h.pluginsMu.RUnlock()
-		writeRPCResult(w, req.ID, infos)
-	case "vault.list":
-		if !h.hasPermission(req.PluginID, "vault.read") {
-			writeRPCError(w, req.ID, 403, "missing permission: vault.read")
-			return
-		}
-		paths, err := h.listVaultFiles()
-		if err != nil {
-			writeRPCError(w, req.ID, 500, err.Error())
-			return
-		}
-		writeRPCResult(w, req.ID, paths)
-	case "vault.read":
-		if !h.hasPermission(req.PluginID, "vault.read") {
-			writeRPCError(w, req.ID, 403, "missing permission: vault.read")
-			return
-		}
-		var p struct {
-			Path string `json:"path"`
-		}
-		if err := json.Unmarshal(req.Params, &p); err != nil || p.Path == "" {
-			writeRPCError(w, req.ID, 400, "missing path")
-			return
-		}
-		data, err := h.readVaultFile(p.Path)
+			writeRPCResult(w, req.ID, infos)
+		},
+		"vault.list": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.hasPermission(req.PluginID, "vault.read") {
+				writeRPCError(w, req.ID, 403, "missing permission: vault.read")
+				return
+			}
+			paths, err := h.listVaultFiles(r.Context())
+			if err != nil {
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, h.filterVaultSandbox(req.PluginID, paths))
+		},
+		"vault.read": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.hasPermission(req.PluginID, "vault.read") {
+				writeRPCError(w, req.ID, 403, "missing permission: vault.read")
+				return
+			}
+			var p struct {
+				Path string `json:"path"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			if err := h.checkVaultSandbox(req.PluginID, p.Path); err != nil {
+				writeRPCError(w, req.ID, 403, err.Error())
+				return
+			}
+			data, err := h.readVaultFile(p.Path)
+			if err != nil {
+				writeRPCError(w, req.ID, vaultReadStatus(err), err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Path    string `json:"path"`
+				Content string `json:"content"`
+			}{Path: p.Path, Content: string(data)})
+		},
+		"vault.readMeta": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.hasPermission(req.PluginID, "vault.read") {
+				writeRPCError(w, req.ID, 403, "missing permission: vault.read")
+				return
+			}
+			var p struct {
+				Path        string `json:"path"`
+				BodyPreview bool   `json:"bodyPreview"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			if err := h.checkVaultSandbox(req.PluginID, p.Path); err != nil {
+				writeRPCError(w, req.ID, 403, err.Error())
+				return
+			}
+			meta, err := h.readVaultMeta(p.Path, p.BodyPreview)
+			if err != nil {
+				writeRPCError(w, req.ID, vaultReadStatus(err), err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, meta)
+		},
+		"vault.write": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.hasPermission(req.PluginID, "vault.write") {
+				writeRPCError(w, req.ID, 403, "missing permission: vault.write")
+				return
+			}
+			var p struct {
+				Path    string `json:"path"`
+				Content string `json:"content"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			if err := h.checkVaultSandbox(req.PluginID, p.Path); err != nil {
+				writeRPCError(w, req.ID, 403, err.Error())
+				return
+			}
+			if err := h.writeVaultFile(p.Path, []byte(p.Content)); err != nil {
+				if errors.Is(err, ErrInvalidVaultPath) {
+					writeRPCError(w, req.ID, 400, err.Error())
+					return
+				}
+				if errors.Is(err, ErrVaultQuotaExceeded) {
+					writeRPCError(w, req.ID, 413, err.Error())
+					return
+				}
+				if errors.Is(err, ErrVaultWriteRejected) {
+					writeRPCError(w, req.ID, 403, err.Error())
+					return
+				}
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			h.audit("vault.write", requestActor(req.PluginID, r), p.Path, map[string]any{"size": len(p.Content)})
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"vault.copy": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.hasPermission(req.PluginID, "vault.read") {
+				writeRPCError(w, req.ID, 403, "missing permission: vault.read")
+				return
+			}
+			if !h.hasPermission(req.PluginID, "vault.write") {
+				writeRPCError(w, req.ID, 403, "missing permission: vault.write")
+				return
+			}
+			var p struct {
+				From      string `json:"from"`
+				To        string `json:"to"`
+				Overwrite bool   `json:"overwrite"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			for _, vp := range []string{p.From, p.To} {
+				if err := h.checkVaultSandbox(req.PluginID, vp); err != nil {
+					writeRPCError(w, req.ID, 403, err.Error())
+					return
+				}
+			}
+			if err := h.copyVaultFile(p.From, p.To, p.Overwrite); err != nil {
+				switch {
+				case errors.Is(err, ErrVaultFileNotFound):
+					writeRPCError(w, req.ID, 404, err.Error())
+				case errors.Is(err, ErrVaultFileExists):
+					writeRPCError(w, req.ID, 409, err.Error())
+				case errors.Is(err, ErrInvalidVaultPath):
+					writeRPCError(w, req.ID, 400, err.Error())
+				case errors.Is(err, ErrVaultQuotaExceeded):
+					writeRPCError(w, req.ID, 413, err.Error())
+				case errors.Is(err, ErrVaultWriteRejected):
+					writeRPCError(w, req.ID, 403, err.Error())
+				default:
+					writeRPCError(w, req.ID, 500, err.Error())
+				}
+				return
+			}
+			h.audit("vault.copy", requestActor(req.PluginID, r), p.To, map[string]any{"from": p.From})
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"commands.register": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.hasPermission(req.PluginID, "commands.register") {
+				writeRPCError(w, req.ID, 403, "missing permission: commands.register")
+				return
+			}
+			var p struct {
+				ID       string `json:"id"`
+				Title    string `json:"title"`
+				Category string `json:"category"`
+				Hotkey   string `json:"hotkey"`
+				// InvokePermission 其他插件调用该命令需要的权限
+				InvokePermission string `json:"invokePermission"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			h.registerCommand(Command{
+				ID:       p.ID,
+				Title:    p.Title,
+				PluginID: req.PluginID,
+				Category: p.Category,
+				Hotkey:   p.Hotkey,
+				Source:   CommandSourceRuntime,
+
+				InvokePermission: p.InvokePermission,
+			})
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"commands.list": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			cmds := h.listCommands()
+			writeRPCResult(w, req.ID, cmds)
+		},
+		"host.getCommandPalette": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				// IncludeDisabled 为 true 时同时返回已禁用插件的命令
+				IncludeDisabled bool `json:"includeDisabled"`
+			}
+			if len(req.Params) > 0 {
+				if err := json.Unmarshal(req.Params, &p); err != nil {
+					writeRPCError(w, req.ID, 400, "invalid params")
+					return
+				}
+			}
+			writeRPCResult(w, req.ID, h.commandPalette(p.IncludeDisabled))
+		},
+		"commands.invoke": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				ID string `json:"id"`
+				// PluginID 命令所属的插件，为空时为调用方自己的命令
+				PluginID string `json:"pluginId"`
+				// Wait 为 true 时等待处理命令的插件通过 commands.result 返回结果
+				Wait      bool `json:"wait"`
+				TimeoutMs int  `json:"timeoutMs"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil || req.PluginID == "" {
+				writeRPCError(w, req.ID, 400, "missing params")
+				return
+			}
+			owner := req.PluginID
+			if p.PluginID != "" {
+				owner = p.PluginID
+			}
+			if perm := h.commandInvokePermission(owner, p.ID); perm != "" && owner != req.PluginID && !h.hasPermission(req.PluginID, perm) {
+				writeRPCError(w, req.ID, 403, "missing permission: "+perm)
+				return
+			}
+			if p.Wait {
+				invocationID, result, err := h.invokeCommandWait(r.Context(), owner, p.ID, commandTimeout(p.TimeoutMs))
+				switch {
+				case errors.Is(err, ErrUnknownCommand):
+					writeRPCError(w, req.ID, 404, err.Error())
+				case errors.Is(err, ErrCommandTimeout):
+					writeRPCError(w, req.ID, 504, err.Error())
+				case err != nil:
+					writeRPCError(w, req.ID, 500, err.Error())
+				default:
+					writeRPCResult(w, req.ID, struct {
+						Ok           bool            `json:"ok"`
+						InvocationID string          `json:"invocationId"`
+						Result       json.RawMessage `json:"result,omitempty"`
+					}{Ok: true, InvocationID: invocationID, Result: result})
+				}
+				return
+			}
+			ok := h.invokeCommand(owner, p.ID)
+			if !ok {
+				writeRPCError(w, req.ID, 404, "unknown command")
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"commands.result": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				InvocationID string          `json:"invocationId"`
+				Result       json.RawMessage `json:"result"`
+				Error        string          `json:"error"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil || req.PluginID == "" {
+				writeRPCError(w, req.ID, 400, "missing params")
+				return
+			}
+			if err := h.postCommandResult(req.PluginID, p.InvocationID, commandResult{Result: p.Result, Error: p.Error}); err != nil {
+				writeRPCError(w, req.ID, 404, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"kv.get": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				Key string `json:"key"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "missing params")
+				return
+			}
+			if !h.checkKVAccess(w, req, "kv.read") {
+				return
+			}
+			value, ok, err := h.kvGet(req.PluginID, p.Key)
+			if err != nil {
+				writeRPCError(w, req.ID, kvErrorCode(err), err.Error())
+				return
+			}
+			if !ok {
+				writeRPCError(w, req.ID, 404, "key not found")
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Key   string          `json:"key"`
+				Value json.RawMessage `json:"value"`
+			}{Key: p.Key, Value: value})
+		},
+		"kv.set": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				Key   string          `json:"key"`
+				Value json.RawMessage `json:"value"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "missing params")
+				return
+			}
+			if !h.checkKVAccess(w, req, "kv.write") {
+				return
+			}
+			if err := h.kvSet(req.PluginID, p.Key, p.Value); err != nil {
+				writeRPCError(w, req.ID, kvErrorCode(err), err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"kv.delete": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				Key string `json:"key"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "missing params")
+				return
+			}
+			if !h.checkKVAccess(w, req, "kv.write") {
+				return
+			}
+			deleted, err := h.kvDelete(req.PluginID, p.Key)
+			if err != nil {
+				writeRPCError(w, req.ID, kvErrorCode(err), err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Deleted bool `json:"deleted"`
+			}{Deleted: deleted})
+		},
+		"kv.list": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				Prefix string `json:"prefix"`
+			}
+			if len(req.Params) > 0 {
+				if err := json.Unmarshal(req.Params, &p); err != nil {
+					writeRPCError(w, req.ID, 400, "invalid params")
+					return
+				}
+			}
+			if !h.checkKVAccess(w, req, "kv.read") {
+				return
+			}
+			keys, err := h.kvList(req.PluginID, p.Prefix)
+			if err != nil {
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, keys)
+		},
+		"events.publish": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.hasPermission(req.PluginID, "events.publish") {
+				writeRPCError(w, req.ID, 403, "missing permission: events.publish")
+				return
+			}
+			var p struct {
+				Name    string          `json:"name"`
+				Payload json.RawMessage `json:"payload"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			eventType, err := h.publishPluginEvent(req.PluginID, p.Name, p.Payload)
+			if err != nil {
+				if errors.Is(err, ErrEventPayloadTooLarge) {
+					writeRPCError(w, req.ID, 413, err.Error())
+					return
+				}
+				writeRPCError(w, req.ID, 400, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Type string `json:"type"`
+			}{Type: eventType})
+		},
+		"host.getInstallationStatus": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			status := h.installManager.GetInstallationStatus(p.PluginID)
+			writeRPCResult(w, req.ID, status)
+		},
+		"host.enablePlugin": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			if err := h.enablePlugin(p.PluginID); err != nil {
+				if errors.Is(err, ErrPluginIncompatible) {
+					writeRPCError(w, req.ID, 409, err.Error())
+					return
+				}
+				writeRPCError(w, req.ID, 404, err.Error())
+				return
+			}
+			h.audit("plugin.enable", requestActor(req.PluginID, r), p.PluginID, nil)
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"host.disablePlugin": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			if err := h.disablePlugin(p.PluginID); err != nil {
+				writeRPCError(w, req.ID, 404, err.Error())
+				return
+			}
+			h.audit("plugin.disable", requestActor(req.PluginID, r), p.PluginID, nil)
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"host.batchSetEnabled": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginIDs []string `json:"pluginIds"`
+				Enabled   *bool    `json:"enabled"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			results := h.batchSetEnabled(p.PluginIDs, *p.Enabled)
+			action := "plugin.disable"
+			if *p.Enabled {
+				action = "plugin.enable"
+			}
+			for _, res := range results {
+				if res.Ok {
+					h.audit(action, requestActor(req.PluginID, r), res.PluginID, nil)
+				}
+			}
+			writeRPCResult(w, req.ID, results)
+		},
+		"host.batchUninstall": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginIDs []string `json:"pluginIds"`
+				UninstallOptions
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			// 指定备份目录会在宿主文件系统上写入文件，需要管理员令牌
+			if p.BackupDir != "" && !h.isAdmin(r) {
+				writeRPCError(w, req.ID, 403, "backupDir requires admin token")
+				return
+			}
+			if _, err := h.uninstallBackupDir(p.UninstallOptions); err != nil {
+				writeRPCError(w, req.ID, 400, err.Error())
+				return
+			}
+			results := h.batchUninstall(p.PluginIDs, p.UninstallOptions)
+			for _, res := range results {
+				if res.Ok {
+					h.audit("plugin.uninstall", requestActor(req.PluginID, r), res.PluginID, p.UninstallOptions.auditMeta())
+				}
+			}
+			writeRPCResult(w, req.ID, results)
+		},
+		"host.backupPlugin": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			// 以凭据认证的插件只能备份自己，管理员可备份任意插件
+			target, ok := h.authorizeTargetPlugin(r, req, p.PluginID)
+			if !ok {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			p.PluginID = target
+			if _, ok := h.getPlugin(p.PluginID); !ok {
+				writeRPCError(w, req.ID, 404, "plugin not found")
+				return
+			}
+			backupPath, err := h.backupPlugin(p.PluginID)
+			if err != nil {
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				BackupPath string `json:"backupPath"`
+			}{BackupPath: backupPath})
+		},
+		"host.resetPlugin": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string   `json:"pluginId"`
+				Scope    []string `json:"scope"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			// 以凭据认证的插件只能重置自己，管理员可重置任意插件
+			target, ok := h.authorizeTargetPlugin(r, req, p.PluginID)
+			if !ok {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			p.PluginID = target
+			if err := h.resetPlugin(p.PluginID, p.Scope); err != nil {
+				if errors.Is(err, ErrInvalidResetScope) {
+					writeRPCError(w, req.ID, 400, err.Error())
+					return
+				}
+				if _, ok := h.getPlugin(p.PluginID); !ok {
+					writeRPCError(w, req.ID, 404, err.Error())
+					return
+				}
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			h.audit("plugin.reset", requestActor(req.PluginID, r), p.PluginID, map[string]any{"scope": p.Scope})
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"host.checkPermission": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID   string `json:"pluginId"`
+				Permission string `json:"permission"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Granted bool `json:"granted"`
+			}{Granted: h.hasPermission(p.PluginID, p.Permission)})
+		},
+		"host.getAllPermissions": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			writeRPCResult(w, req.ID, h.allPermissions())
+		},
+		"host.getPluginConfigSchema": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			plugin, ok := h.getPlugin(p.PluginID)
+			if !ok {
+				writeRPCError(w, req.ID, 404, "plugin not found: "+p.PluginID)
+				return
+			}
+			schema := plugin.Manifest.ConfigSchema
+			if schema == nil {
+				schema = []ConfigField{}
+			}
+			writeRPCResult(w, req.ID, struct {
+				PluginID string        `json:"pluginId"`
+				Fields   []ConfigField `json:"fields"`
+			}{PluginID: p.PluginID, Fields: schema})
+		},
+		"host.getPluginSettings": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			// 以凭据认证的插件只能读取自己的设置，管理员可读取任意插件
+			if _, ok := h.authorizeTargetPlugin(r, req, p.PluginID); !ok {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			values, err := h.getPluginSettings(p.PluginID)
+			if err != nil {
+				writeRPCError(w, req.ID, 404, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, values)
+		},
+		"host.setPluginSettings": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string         `json:"pluginId"`
+				Values   map[string]any `json:"values"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			// 以凭据认证的插件只能修改自己的设置，管理员可修改任意插件
+			if _, ok := h.authorizeTargetPlugin(r, req, p.PluginID); !ok {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			if _, ok := h.getPlugin(p.PluginID); !ok {
+				writeRPCError(w, req.ID, 404, "plugin not found: "+p.PluginID)
+				return
+			}
+			if err := h.setPluginSettings(p.PluginID, p.Values); err != nil {
+				writeRPCError(w, req.ID, 400, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"host.getUpdates": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			updates, err := h.getUpdates()
+			if err != nil {
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, updates)
+		},
+		"host.updatePlugin": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			update, err := h.updatePlugin(p.PluginID)
+			if err != nil {
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			h.audit("plugin.update", requestActor(req.PluginID, r), p.PluginID, map[string]any{
+				"from": update.Current,
+				"to":   update.Latest,
+			})
+			writeRPCResult(w, req.ID, update)
+		},
+		"host.getMarketTags": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			items, err := h.fetchMarketIndex()
+			if err != nil {
+				writeRPCError(w, req.ID, 502, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, marketTags(items))
+		},
+		"host.pingPlugin": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			result, err := h.pingPlugin(r.Context(), p.PluginID)
+			if err != nil {
+				writeRPCError(w, req.ID, 404, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, result)
+		},
+		"host.getInfo": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			methods := h.enabledMethods()
+			sort.Strings(methods)
+			writeRPCResult(w, req.ID, HostInfo{
+				Version:     HostVersion,
+				APIVersion:  APIVersion,
+				SDKVersion:  SDKVersion,
+				Methods:     methods,
+				Permissions: knownPermissions,
+				Features:    h.features(),
+				ReadOnly:    h.IsReadOnly(),
+				Limits: HostLimits{
+					MaxPluginSize:   h.securityConfig().MaxPluginSize,
+					MaxRequestBytes: h.maxRPCRequestBytes(),
+					MaxBatchSize:    h.maxRPCBatchSize(),
+					MaxParamsDepth:  h.maxRPCParamsDepth(),
+				},
+			})
+		},
+		"host.getStatus": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			writeRPCResult(w, req.ID, h.status())
+		},
+		"host.setReadOnly": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.isAdmin(r) {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			var p struct {
+				ReadOnly *bool `json:"readOnly"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil || p.ReadOnly == nil {
+				writeRPCError(w, req.ID, 400, "missing readOnly")
+				return
+			}
+			h.SetReadOnly(*p.ReadOnly)
+			h.audit("host.setReadOnly", requestActor(req.PluginID, r), "", map[string]any{"readOnly": *p.ReadOnly})
+			writeRPCResult(w, req.ID, struct {
+				ReadOnly bool `json:"readOnly"`
+			}{ReadOnly: *p.ReadOnly})
+		},
+		"host.getPluginStats": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if len(req.Params) > 0 {
+				if err := json.Unmarshal(req.Params, &p); err != nil {
+					writeRPCError(w, req.ID, 400, "invalid params")
+					return
+				}
+			}
+			// 以凭据认证的插件只能查看自己的统计，管理员可查看全部
+			target, ok := h.authorizeTargetPlugin(r, req, p.PluginID)
+			if !ok {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			p.PluginID = target
+			writeRPCResult(w, req.ID, h.pluginRPCStats(p.PluginID))
+		},
+		"host.getPluginProcess": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if len(req.Params) > 0 {
+				if err := json.Unmarshal(req.Params, &p); err != nil {
+					writeRPCError(w, req.ID, 400, "invalid params")
+					return
+				}
+			}
+			// 以凭据认证的插件只能查看自己的后端进程，管理员可查看任意插件
+			target, ok := h.authorizeTargetPlugin(r, req, p.PluginID)
+			if !ok {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			p.PluginID = target
+			if p.PluginID == "" {
+				writeRPCError(w, req.ID, 400, "missing pluginId")
+				return
+			}
+			if _, ok := h.getPlugin(p.PluginID); !ok {
+				writeRPCError(w, req.ID, 404, "plugin not found")
+				return
+			}
+			writeRPCResult(w, req.ID, h.pluginProcess(p.PluginID))
+		},
+		"host.resetPluginStats": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.isAdmin(r) {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if len(req.Params) > 0 {
+				if err := json.Unmarshal(req.Params, &p); err != nil {
+					writeRPCError(w, req.ID, 400, "invalid params")
+					return
+				}
+			}
+			h.resetPluginRPCStats(p.PluginID)
+			h.audit("host.resetPluginStats", requestActor(req.PluginID, r), p.PluginID, nil)
+			writeRPCResult(w, req.ID, struct {
+				Ok bool `json:"ok"`
+			}{Ok: true})
+		},
+		"host.approvePermissions": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.isAdmin(r) {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			approved, err := h.approvePermissions(p.PluginID)
+			if err != nil {
+				if _, ok := h.getPlugin(p.PluginID); !ok {
+					writeRPCError(w, req.ID, 404, "plugin not found")
+					return
+				}
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			if len(approved) > 0 {
+				h.audit("permission.approve", requestActor(req.PluginID, r), p.PluginID, map[string]any{"permissions": approved})
+			}
+			writeRPCResult(w, req.ID, map[string]any{"pluginId": p.PluginID, "approved": approved})
+		},
+		"host.previewUninstall": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.isAdmin(r) {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			var p struct {
+				PluginID string `json:"pluginId"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			if _, ok := h.getPlugin(p.PluginID); !ok {
+				writeRPCError(w, req.ID, 404, "plugin not found")
+				return
+			}
+			preview, err := h.previewUninstall(p.PluginID)
+			if err != nil {
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, preview)
+		},
+		"host.listPluginFiles": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			if !h.isAdmin(r) {
+				writeRPCError(w, req.ID, 403, "admin required")
+				return
+			}
+			var p struct {
+				PluginID string `json:"pluginId"`
+				Glob     string `json:"glob"`
+			}
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				writeRPCError(w, req.ID, 400, "invalid params")
+				return
+			}
+			if _, ok := h.getPlugin(p.PluginID); !ok {
+				writeRPCError(w, req.ID, 404, "plugin not found")
+				return
+			}
+			files, err := h.listPluginFiles(p.PluginID, p.Glob)
+			if err != nil {
+				writeRPCError(w, req.ID, 400, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, files)
+		},
+		"host.getDiskUsage": func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			usage, err := h.getDiskUsage(r.Context())
+			if err != nil {
+				writeRPCError(w, req.ID, 500, err.Error())
+				return
+			}
+			writeRPCResult(w, req.ID, usage)
+		},
+	}
+}
+
+func (h *PluginHost) handleMarket(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := h.fetchMarketIndex()
 		if err != nil {
-			writeRPCError(w, req.ID, 404, err.Error())
-			return
-		}
-		writeRPCResult(w, req.ID, struct {
-			Path    string `json:"path"`
-			Content string `json:"content"`
-		}{Path: p.Path, Content: string(data)})
-	case "vault.write":
-		if !h.hasPermission(req.PluginID, "vault.write") {
-			writeRPCError(w, req.ID, 403, "missing permission: vault.write")
-			return
-		}
-		var p struct {
-			Path    string `json:"path"`
-			Content string `json:"content"`
-		}
-		if err := json.Unmarshal(req.Params, &p); err != nil || p.Path == "" {
-			writeRPCError(w, req.ID, 400, "missing params")
-			return
-		}
-		if err := h.writeVaultFile(p.Path, []byte(p.Content)); err != nil {
-			writeRPCError(w, req.ID, 500, err.Error())
+			writeMarketError(w, http.StatusBadGateway, "MARKET_UNAVAILABLE", err.Error(), "")
 			return
 		}
-		writeRPCResult(w, req.ID, struct {
-			Ok bool `json:"ok"`
-		}{Ok: true})
-	case "commands.register":
-		if !h.hasPermission(req.PluginID, "commands.register") {
-			writeRPCError(w, req.ID, 403, "missing permission: commands.register")
+		items = filterMarketByTag(items, r.URL.Query().Get("tag"))
+		w.Header().Add("Vary", "Accept")
+		if wantsHTML(r.Header.Get("Accept")) {
+			writeMarketHTML(w, items)
 			return
 		}
-		var p struct {
-			ID    string `json:"id"`
-			Title string `json:"title"`
-		}
-		if err := json.Unmarshal(req.Params, &p); err != nil || p.ID == "" || p.Title == "" {
-			writeRPCError(w, req.ID, 400, "missing params")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(items)
+	case http.MethodPost:
+		if h.IsReadOnly() {
+			writeMarketError(w, http.StatusServiceUnavailable, "READ_ONLY", readOnlyMessage, "")
 			return
 		}
-		h.registerCommand(Command{ID: p.ID, Title: p.Title, PluginID: req.PluginID})
-		writeRPCResult(w, req.ID, struct {
-			Ok bool `json:"ok"`
-		}{Ok: true})
-	case "commands.list":
-		cmds := h.listCommands()
-		writeRPCResult(w, req.ID, cmds)
-	case "commands.invoke":
 		var p struct {
-			ID string `json:"id"`
+			ID        string `json:"id"`
+			URL       string `json:"url"`
+			SHA256    string `json:"sha256"`
+			Signature string `json:"signature"`
+			// Source 为 git 时从 Repo 的 Ref 发布版本解析下载地址
+			Source string `json:"source"`
+			Repo   string `json:"repo"`
+			Ref    string `json:"ref"`
+			// AutoEnable 覆盖 Config.EnableOnInstall
+			AutoEnable *bool `json:"autoEnable"`
 		}
-		if err := json.Unmarshal(req.Params, &p); err != nil || p.ID == "" || req.PluginID == "" {
-			writeRPCError(w, req.ID, 400, "missing params")
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeMarketError(w, http.StatusBadRequest, "INVALID_JSON", "invalid json", err.Error())
 			return
 		}
-		ok := h.invokeCommand(req.PluginID, p.ID)
-		if !ok {
-			writeRPCError(w, req.ID, 404, "unknown command")
+		if p.Source != "" && p.Source != InstallSourceGit {
+			writeMarketError(w, http.StatusBadRequest, "UNKNOWN_SOURCE", "unknown source: "+p.Source, "")
 			return
 		}
-		writeRPCResult(w, req.ID, struct {
-			Ok bool `json:"ok"`
-		}{Ok: true})
-	case "host.getInstallationStatus":
-		var p struct {
-			PluginID string `json:"pluginId"`
+		var err error
+		if p.Source == InstallSourceGit {
+			if p.URL, err = h.resolveGitRelease(p.Repo, p.Ref, gitManifestAsset); err != nil {
+				err = &InstallError{Code: InstallErrGitResolve, Err: err}
+			}
 		}
-		if err := json.Unmarshal(req.Params, &p); err != nil || p.PluginID == "" {
-			writeRPCError(w, req.ID, 400, "missing pluginId")
-			return
+		// 空的 id/url 交给校验器，按字段返回结构化错误
+		if err == nil {
+			err = h.installPluginFromURL(p.ID, p.URL, p.SHA256, p.Signature, p.AutoEnable)
 		}
-		if h.installManager == nil {
-			writeRPCResult(w, req.ID, nil)
+		if err != nil {
+			lang := negotiateLanguage(r.Header.Get("Accept-Language"))
+			var vf *ValidationFailedError
+			if errors.As(err, &vf) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(validationStatus(vf.Errors))
+				_ = json.NewEncoder(w).Encode(struct {
+					Error  marketError       `json:"error"`
+					Errors []ValidationError `json:"errors"`
+				}{
+					Error:  marketError{Code: InstallErrValidation, Message: localize(InstallErrValidation, lang)},
+					Errors: localizeErrors(vf.Errors, lang),
+				})
+				return
+			}
+			if code := installErrorCode(err); code != "" {
+				writeMarketError(w, http.StatusBadRequest, code, localize(code, lang), err.Error())
+				return
+			}
+			writeMarketError(w, http.StatusBadRequest, "INSTALL_FAILED", err.Error(), "")
 			return
 		}
-		status := h.installManager.GetInstallationStatus(p.PluginID)
-		writeRPCResult(w, req.ID, status)
-	case "host.enablePlugin":
-		var p struct {
-			PluginID string `json:"pluginId"`
+		meta := map[string]any{"url": p.URL, "sha256": p.SHA256}
+		if p.Source == InstallSourceGit {
+			meta["repo"] = p.Repo
+			meta["ref"] = p.Ref
 		}
-		if err := json.Unmarshal(req.Params, &p); err != nil || p.PluginID == "" {
-			writeRPCError(w, req.ID, 400, "missing pluginId")
-			return
+		if plugin, ok := h.getPlugin(p.ID); ok {
+			meta["version"] = plugin.Manifest.Version
+			meta["permissions"] = plugin.Manifest.Permissions
 		}
-		if err := h.enablePlugin(p.PluginID); err != nil {
-			writeRPCError(w, req.ID, 404, err.Error())
-			return
-		}
-		writeRPCResult(w, req.ID, struct {
-			Ok bool `json:"ok"`
-		}{Ok: true})
-	case "host.disablePlugin":
-		var p struct {
-			PluginID string `json:"pluginId"`
-		}
-		if err := json.Unmarshal(req.Params, &p); err != nil || p.PluginID == "" {
-			writeRPCError(w, req.ID, 400, "missing pluginId")
+		h.audit("plugin.install", requestActor("", r), p.ID, meta)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if h.IsReadOnly() {
+			writeMarketError(w, http.StatusServiceUnavailable, "READ_ONLY", readOnlyMessage, "")
 			return
 		}
-		if err := h.disablePlugin(p.PluginID); err != nil {
-			writeRPCError(w, req.ID, 404, err.Error())
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			writeMarketError(w, http.StatusBadRequest, "MISSING_ID", "missing id", "")
 			return
 		}
-		writeRPCResult(w, req.ID, struct {
-			Ok bool `json:"ok"`
-		}{Ok: true})
-	case "host.backupPlugin":
-		var p struct {
-			PluginID string `json:"pluginId"`
+		opts := UninstallOptions{BackupDir: r.URL.Query().Get("backupDir")}
+		if v := r.URL.Query().Get("skipBackup"); v != "" {
+			skip, err := strconv.ParseBool(v)
+			if err != nil {
+				writeMarketError(w, http.StatusBadRequest, "INVALID_SKIP_BACKUP", "invalid skipBackup", "")
+				return
+			}
+			opts.SkipBackup = skip
 		}
-		if err := json.Unmarshal(req.Params, &p); err != nil || p.PluginID == "" {
-			writeRPCError(w, req.ID, 400, "missing pluginId")
+		if opts.BackupDir != "" && !h.isAdmin(r) {
+			writeMarketError(w, http.StatusForbidden, "FORBIDDEN", "backupDir requires admin token", "")
 			return
 		}
-		backupPath, err := h.backupPlugin(p.PluginID)
-		if err != nil {
-			writeRPCError(w, req.ID, 500, err.Error())
+		if err := h.uninstallPlugin(id, opts); err != nil {
+			writeMarketError(w, http.StatusBadRequest, "UNINSTALL_FAILED", err.Error(), "")
 			return
 		}
-		writeRPCResult(w, req.ID, struct {
-			BackupPath string `json:"backupPath"`
-		}{BackupPath: backupPath})
+		h.audit("plugin.uninstall", requestActor("", r), id, opts.auditMeta())
+		w.WriteHeader(http.StatusNoContent)
 	default:
-		writeRPCError(w, req.ID, 404, "unknown method")
+		writeMarketError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed", "")
 	}
 }
 
-func (h *PluginHost) handleMarket(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		items, err := h.fetchMarketIndex()
-		if err != nil {
-			w.WriteHeader(http.StatusBadGateway)
-			_, _ = w.Write([]byte(err.Error()))
-			return
-		}
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(items)
-	case http.MethodPost:
-		var p struct {
-			ID     string `json:"id"`
-			URL    string `json:"url"`
-			SHA256 string `json:"sha256"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&p); err != nil || p.ID == "" || p.URL == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		if err := h.installPluginFromURL(p.ID, p.URL, p.SHA256); err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte(err.Error()))
-			return
-		}
-		w.WriteHeader(http.StatusCreated)
-	case http.MethodDelete:
-		id := r.URL.Query().Get("id")
-		if id == "" {
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-		if err := h.uninstallPlugin(id); err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte(err.Error()))
-			return
-		}
-		w.WriteHeader(http.StatusNoContent)
-	default:
-		w.WriteHeader(http.StatusMethodNotAllowed)
-	}
+// marketError /market 的错误信息，Code 为稳定的错误码
+type marketError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Detail 原始错误信息，Message 为本地化提示时附带
+	Detail string `json:"detail,omitempty"`
+}
+
+// writeMarketError 以 {"error": {code, message}} 格式返回 /market 的错误
+func writeMarketError(w http.ResponseWriter, status int, code, message, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error marketError `json:"error"`
+	}{Error: marketError{Code: code, Message: message, Detail: detail}})
 }
 
 func writeRPCResult(w http.ResponseWriter, id string, result any) {
@@ -318,6 +1220,18 @@ func httpStatusForCode(code int) int {
 		return http.StatusForbidden
 	case 404:
 		return http.StatusNotFound
+	case 409:
+		return http.StatusConflict
+	case 413:
+		return http.StatusRequestEntityTooLarge
+	case 429:
+		return http.StatusTooManyRequests
+	case 502:
+		return http.StatusBadGateway
+	case 503:
+		return http.StatusServiceUnavailable
+	case 504:
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
@@ -0,0 +1,56 @@
+package host
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// hostInfo 调用 host.getInfo 并解码结果
+func hostInfo(t *testing.T, h *PluginHost) HostInfo {
+	t.Helper()
+	code, resp := callRPC(t, h, "", "host.getInfo", nil)
+	if code != 200 {
+		t.Fatalf("host.getInfo: got %d %+v", code, resp.Error)
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info HostInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestGetInfoMethods(t *testing.T) {
+	h := newTestHost(t, Config{})
+	info := hostInfo(t, h)
+
+	for _, method := range []string{"vault.read", "vault.write", "host.getInfo", "host.batchSetEnabled"} {
+		if !slices.Contains(info.Methods, method) {
+			t.Errorf("methods missing %s", method)
+		}
+	}
+	if !slices.IsSorted(info.Methods) {
+		t.Error("methods should be sorted")
+	}
+	// 方法列表与分发表一致
+	if len(info.Methods) != len(h.rpcMethods) {
+		t.Errorf("listed %d methods, dispatcher has %d", len(info.Methods), len(h.rpcMethods))
+	}
+	if info.Version != HostVersion || info.APIVersion != APIVersion {
+		t.Errorf("version = %s/%s", info.Version, info.APIVersion)
+	}
+	if code, _ := callRPC(t, h, "", "host.noSuchMethod", nil); code != 404 {
+		t.Errorf("unlisted method: got %d, want 404", code)
+	}
+}
+
+func TestGetInfoOmitsDisabledMethods(t *testing.T) {
+	h := newTestHost(t, Config{DisabledMethods: []string{"vault.write"}})
+	if slices.Contains(hostInfo(t, h).Methods, "vault.write") {
+		t.Error("disabled method should not be listed")
+	}
+}
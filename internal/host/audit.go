@@ -0,0 +1,158 @@
+package host
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AuditEntry 特权操作的审计记录
+type AuditEntry struct {
+	Time   time.Time      `json:"time"`
+	Action string         `json:"action"`
+	Actor  string         `json:"actor"`
+	Target string         `json:"target"`
+	Meta   map[string]any `json:"meta,omitempty"`
+}
+
+// auditPath 返回审计日志文件位置（JSONL，只追加）
+func (h *PluginHost) auditPath() string {
+	return filepath.Join(h.config.RootDir, "audit.jsonl")
+}
+
+// audit 追加一条审计记录，写入失败只记录日志不影响操作本身
+func (h *PluginHost) audit(action, actor, target string, meta map[string]any) {
+	if id, ok := strings.CutPrefix(actor, "plugin:"); ok && h.isTrustedPlugin(id) {
+		// 受信任插件绕过了权限检查，在记录中标注以便追查
+		m := map[string]any{"trusted": true}
+		for k, v := range meta {
+			m[k] = v
+		}
+		meta = m
+	}
+	entry := AuditEntry{
+		Time:   time.Now().UTC(),
+		Action: action,
+		Actor:  actor,
+		Target: target,
+		Meta:   meta,
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("audit: marshal %s: %v", action, err)
+		return
+	}
+
+	h.auditMu.Lock()
+	defer h.auditMu.Unlock()
+	f, err := os.OpenFile(h.auditPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		log.Printf("audit: open log: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("audit: write %s: %v", action, err)
+	}
+}
+
+// queryAudit 按操作类型和时间范围读取审计记录，零值表示不过滤
+func (h *PluginHost) queryAudit(action string, since, until time.Time) ([]AuditEntry, error) {
+	h.auditMu.Lock()
+	defer h.auditMu.Unlock()
+
+	entries := []AuditEntry{}
+	f, err := os.Open(h.auditPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if action != "" && e.Action != action {
+			continue
+		}
+		if !since.IsZero() && e.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && e.Time.After(until) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// handleAudit 查询审计日志，需要 Config.AdminToken
+//
+//	GET /audit?action=&since=&until=  时间使用RFC3339格式
+func (h *PluginHost) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	q := r.URL.Query()
+	var since, until time.Time
+	var err error
+	if v := q.Get("since"); v != "" {
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if until, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "invalid until", http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.queryAudit(q.Get("action"), since, until)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+// isAdmin 校验 Authorization: Bearer <AdminToken>，未配置令牌时拒绝所有请求
+func (h *PluginHost) isAdmin(r *http.Request) bool {
+	token := h.config.AdminToken
+	if token == "" {
+		return false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// requestActor 返回请求发起方的标识，用于审计
+func requestActor(pluginID string, r *http.Request) string {
+	if pluginID != "" {
+		return "plugin:" + pluginID
+	}
+	return "remote:" + r.RemoteAddr
+}
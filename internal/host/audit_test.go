@@ -0,0 +1,83 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstallWritesAuditEntry(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.2.0", Permissions: []string{"vault.read"}})
+
+	body := `{"id":"demo","url":"` + url + `"}`
+	w := httptest.NewRecorder()
+	h.handleMarket(w, httptest.NewRequest(http.MethodPost, "/market", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("install: got %d %s", w.Code, w.Body.String())
+	}
+
+	entries, err := h.queryAudit("plugin.install", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d plugin.install entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Target != "demo" || !strings.HasPrefix(e.Actor, "remote:") {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Meta["url"] != url || e.Meta["version"] != "1.2.0" {
+		t.Errorf("meta = %v", e.Meta)
+	}
+	if e.Time.IsZero() {
+		t.Error("entry has no timestamp")
+	}
+}
+
+func TestHandleAuditFilters(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	h.audit("plugin.enable", "plugin:a", "b", nil)
+	h.audit("vault.write", "plugin:a", "notes.md", map[string]any{"size": 3})
+
+	get := func(query string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/audit"+query, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		h.handleAudit(w, r)
+		return w
+	}
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	if w := get("", nil); w.Code != http.StatusForbidden {
+		t.Errorf("without token: got %d, want 403", w.Code)
+	}
+	if w := get("?since=yesterday", admin); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid since: got %d, want 400", w.Code)
+	}
+
+	w := get("?action=vault.write", admin)
+	var entries []AuditEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Target != "notes.md" {
+		t.Errorf("action filter returned %+v", entries)
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w = get("?since="+future, admin)
+	entries = nil
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("since filter returned %d entries, want 0", len(entries))
+	}
+}
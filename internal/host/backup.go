@@ -0,0 +1,325 @@
+package host
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// 备份模式
+const (
+	// BackupModeFull 每次备份都包含全部文件
+	BackupModeFull = "full"
+	// BackupModeDiff 以最近一次完整备份为基准，只保存新增和变化的文件
+	BackupModeDiff = "diff"
+)
+
+// backupManifestName 备份包中记录文件哈希的条目名
+const backupManifestName = "__backup__.json"
+
+// backupManifest 差异备份模式下写入备份包的文件清单
+type backupManifest struct {
+	// Base 差异备份所依赖的完整备份文件名，完整备份为空
+	Base string `json:"base,omitempty"`
+	// Files 备份时插件目录中全部文件的相对路径到 SHA256 的映射
+	Files map[string]string `json:"files"`
+}
+
+// writeDirZip 把目录下的所有文件按路径顺序写入 zip，文件内容逐个流式写入，
+// 不会整体读入内存；输出只取决于文件路径、内容和权限
+func writeDirZip(w io.Writer, dir string) error {
+	return writeBackupZip(w, dir, nil, nil)
+}
+
+// writeBackupZip 同 writeDirZip，include 不为 nil 时只写入其返回 true 的文件，
+// manifest 不为 nil 时追加文件清单条目
+func writeBackupZip(w io.Writer, dir string, include func(rel string) bool, manifest *backupManifest) error {
+	zw := zip.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if include != nil && !include(rel) {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		// 固定压缩方式、不写修改时间，相同内容生成的压缩包逐字节一致
+		hdr := &zip.FileHeader{Name: rel, Method: zip.Deflate}
+		hdr.SetMode(info.Mode())
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err == nil && manifest != nil {
+		var data []byte
+		if data, err = json.Marshal(manifest); err == nil {
+			var fw io.Writer
+			if fw, err = zw.CreateHeader(&zip.FileHeader{Name: backupManifestName, Method: zip.Deflate}); err == nil {
+				_, err = fw.Write(data)
+			}
+		}
+	}
+	if err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// hashDir 计算目录下每个文件的 SHA256，键为以 / 分隔的相对路径
+func hashDir(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	return files, err
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// readBackupManifest 读取备份包中的文件清单，不存在时返回 nil
+func readBackupManifest(zr *zip.Reader) (*backupManifest, error) {
+	for _, f := range zr.File {
+		if f.Name != backupManifestName {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		var m backupManifest
+		if err := json.NewDecoder(rc).Decode(&m); err != nil {
+			return nil, fmt.Errorf("invalid backup manifest: %w", err)
+		}
+		return &m, nil
+	}
+	return nil, nil
+}
+
+// latestFullBackup 返回插件最近一次带文件清单的完整备份，可作为差异备份的基准
+func (h *PluginHost) latestFullBackup(pluginID string) (string, *backupManifest, error) {
+	backups, err := h.pluginBackups(pluginID)
+	if err != nil {
+		return "", nil, err
+	}
+	var (
+		bestPath string
+		best     *backupManifest
+		bestTime int64
+	)
+	for _, p := range backups {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			continue
+		}
+		m, err := readBackupManifest(&zr.Reader)
+		zr.Close()
+		if err != nil || m == nil || m.Base != "" {
+			continue
+		}
+		if t := info.ModTime().UnixNano(); best == nil || t > bestTime {
+			bestPath, best, bestTime = p, m, t
+		}
+	}
+	return bestPath, best, nil
+}
+
+// writePluginBackup 按配置的备份模式把插件目录写入 w，差异模式下返回所依赖的基准备份文件名
+func (h *PluginHost) writePluginBackup(w io.Writer, pluginID, pluginDir string) (string, error) {
+	if h.config.BackupMode != BackupModeDiff {
+		return "", writeDirZip(w, pluginDir)
+	}
+
+	files, err := hashDir(pluginDir)
+	if err != nil {
+		return "", err
+	}
+	basePath, base, err := h.latestFullBackup(pluginID)
+	if err != nil {
+		return "", err
+	}
+	if base == nil {
+		return "", writeBackupZip(w, pluginDir, nil, &backupManifest{Files: files})
+	}
+
+	baseName := filepath.Base(basePath)
+	changed := func(rel string) bool {
+		return base.Files[rel] != files[rel]
+	}
+	return baseName, writeBackupZip(w, pluginDir, changed, &backupManifest{Base: baseName, Files: files})
+}
+
+// maxRestoreBytes 恢复一个备份时解压的总字节数上限
+const maxRestoreBytes = 1 << 30
+
+// RestoreBackup 把备份解压到 destDir。差异备份从同目录下的基准备份中补齐未变化的文件，
+// 并按文件清单校验每个文件的 SHA256。条目数、解压总大小和压缩比受 zipGuard 限制
+func RestoreBackup(backupPath, destDir string) error {
+	zr, err := zip.OpenReader(backupPath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	if err := checkZipEntries(&zr.Reader); err != nil {
+		return err
+	}
+	guard := &zipGuard{maxTotal: maxRestoreBytes}
+
+	m, err := readBackupManifest(&zr.Reader)
+	if err != nil {
+		return err
+	}
+	if m == nil || m.Base == "" {
+		for _, f := range zr.File {
+			if f.Name == backupManifestName || f.FileInfo().IsDir() {
+				continue
+			}
+			if err := restoreBackupEntry(guard, f, destDir, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	baseZr, err := zip.OpenReader(filepath.Join(filepath.Dir(backupPath), filepath.Base(m.Base)))
+	if err != nil {
+		return fmt.Errorf("open base backup: %w", err)
+	}
+	defer baseZr.Close()
+	if err := checkZipEntries(&baseZr.Reader); err != nil {
+		return err
+	}
+
+	entries := make(map[string]*zip.File)
+	for _, f := range baseZr.File {
+		entries[f.Name] = f
+	}
+	for _, f := range zr.File {
+		entries[f.Name] = f
+	}
+	paths := make([]string, 0, len(m.Files))
+	for p := range m.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		f, ok := entries[p]
+		if !ok {
+			return fmt.Errorf("backup entry missing: %s", p)
+		}
+		if err := restoreBackupEntry(guard, f, destDir, m.Files[p]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreBackupEntry 解压单个条目，wantSHA 不为空时校验内容哈希。
+// 非普通文件条目和经过符号链接的目标路径返回 ErrUnsafeZipEntry
+func restoreBackupEntry(guard *zipGuard, f *zip.File, destDir, wantSHA string) error {
+	name := filepath.FromSlash(f.Name)
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("invalid backup entry: %s", f.Name)
+	}
+	if err := checkZipEntryType(f); err != nil {
+		return err
+	}
+	target := filepath.Join(destDir, name)
+	if err := checkNoSymlink(destDir, target); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	rc, err := guard.open(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	mode := extractedFileMode(f.Mode())
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	// 覆盖已存在的文件时 OpenFile 不修改权限，这里统一设置
+	if err := out.Chmod(mode); err != nil {
+		out.Close()
+		return err
+	}
+	hasher := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, hasher), rc)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if wantSHA != "" && hex.EncodeToString(hasher.Sum(nil)) != wantSHA {
+		return fmt.Errorf("backup entry %s: checksum mismatch", f.Name)
+	}
+	return nil
+}
+
+// extractedFileMode 返回解压文件使用的权限：只保留条目的读写权限位，去掉可执行位以及
+// setuid、setgid 和粘滞位，没有任何权限位时使用 DefaultFileMode
+func extractedFileMode(mode os.FileMode) os.FileMode {
+	perm := mode.Perm() &^ 0o111
+	if perm == 0 {
+		return DefaultFileMode
+	}
+	return perm
+}
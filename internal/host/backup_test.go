@@ -0,0 +1,217 @@
+package host
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestZip 按条目名和内容写入压缩包
+func writeTestZip(t *testing.T, path string, entries map[string][]byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreBackupRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(src, "manifest.json"), []byte(`{"id":"p"}`), 0o644)
+	os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("hello"), 0o644)
+
+	var buf bytes.Buffer
+	if err := writeDirZip(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(t.TempDir(), "p.zip")
+	os.WriteFile(backup, buf.Bytes(), 0o644)
+
+	dest := t.TempDir()
+	if err := RestoreBackup(backup, dest); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "sub", "a.txt")); err != nil || string(data) != "hello" {
+		t.Fatalf("restored file = %q, %v", data, err)
+	}
+}
+
+func TestRestoreBackupRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	backup := filepath.Join(dir, "evil.zip")
+	writeTestZip(t, backup, map[string][]byte{"../escape.txt": []byte("x")})
+	dest := filepath.Join(dir, "dest")
+	if err := RestoreBackup(backup, dest); err == nil {
+		t.Fatal("traversal entry should be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.txt")); !os.IsNotExist(err) {
+		t.Fatal("file written outside destination")
+	}
+}
+
+func TestRestoreBackupRejectsZipBombs(t *testing.T) {
+	dir := t.TempDir()
+
+	ratio := filepath.Join(dir, "ratio.zip")
+	writeTestZip(t, ratio, map[string][]byte{"zeros.bin": make([]byte, 4<<20)})
+	if err := RestoreBackup(ratio, t.TempDir()); !errors.Is(err, ErrZipBomb) {
+		t.Errorf("high compression ratio: got %v, want ErrZipBomb", err)
+	}
+
+	many := filepath.Join(dir, "many.zip")
+	entries := make(map[string][]byte, maxZipEntries+1)
+	for i := 0; i <= maxZipEntries; i++ {
+		entries[fmt.Sprintf("f%d", i)] = nil
+	}
+	writeTestZip(t, many, entries)
+	if err := RestoreBackup(many, t.TempDir()); !errors.Is(err, ErrZipBomb) {
+		t.Errorf("too many entries: got %v, want ErrZipBomb", err)
+	}
+}
+
+func TestRestoreBackupRejectsSymlinkTargets(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "dest")
+	outside := filepath.Join(dir, "outside")
+	os.MkdirAll(dest, 0o755)
+	os.MkdirAll(outside, 0o755)
+	if err := os.Symlink(outside, filepath.Join(dest, "link")); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+	backup := filepath.Join(dir, "b.zip")
+	writeTestZip(t, backup, map[string][]byte{"link/a.txt": []byte("x")})
+	if err := RestoreBackup(backup, dest); !errors.Is(err, ErrUnsafeZipEntry) {
+		t.Fatalf("got %v, want ErrUnsafeZipEntry", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "a.txt")); !os.IsNotExist(err) {
+		t.Fatal("file written through symlink")
+	}
+}
+
+// newBenchPluginDir 创建含 50 个 72KB 文件的插件目录
+func newBenchPluginDir(b *testing.B, dir string) {
+	b.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "assets"), 0o755); err != nil {
+		b.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{"id":"bench","name":"bench","version":"1.0.0"}`), 0o644)
+	payload := bytes.Repeat([]byte("plugin asset data "), 4096)
+	for i := 0; i < 50; i++ {
+		os.WriteFile(filepath.Join(dir, "assets", fmt.Sprintf("f%02d.js", i)), payload, 0o644)
+	}
+}
+
+// BenchmarkBackupWriteDirZip 测量把插件目录流式写入压缩包的耗时和内存分配
+func BenchmarkBackupWriteDirZip(b *testing.B) {
+	dir := b.TempDir()
+	newBenchPluginDir(b, dir)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := writeDirZip(io.Discard, dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBackupPlugin(b *testing.B) {
+	root := b.TempDir()
+	h := NewPluginHost(Config{RootDir: root, VaultDir: filepath.Join(root, "vault"), PluginsDir: filepath.Join(root, "plugins")})
+	if err := h.EnsureDirs(); err != nil {
+		b.Fatal(err)
+	}
+	newBenchPluginDir(b, filepath.Join(h.config.PluginsDir, "bench"))
+	h.plugins["bench"] = &Plugin{Manifest: Manifest{ID: "bench", Name: "bench", Version: "1.0.0"}, Enabled: true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.backupPlugin("bench"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestExtractedFileMode(t *testing.T) {
+	cases := map[os.FileMode]os.FileMode{
+		0o644:                                  0o644,
+		0o755:                                  0o644,
+		0o600:                                  0o600,
+		0o777:                                  0o666,
+		0o4755 | os.ModeSetuid:                 0o644,
+		0o2750 | os.ModeSetgid | os.ModeSticky: 0o640,
+		0o111:                                  DefaultFileMode,
+		0:                                      DefaultFileMode,
+	}
+	for in, want := range cases {
+		if got := extractedFileMode(in); got != want {
+			t.Errorf("extractedFileMode(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestRestoreBackupMasksFileModes(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, mode := range map[string]os.FileMode{
+		"run.sh":      0o755,
+		"suid":        0o755 | os.ModeSetuid,
+		"private.txt": 0o600,
+	} {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		hdr.SetMode(mode)
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(t.TempDir(), "p.zip")
+	if err := os.WriteFile(backup, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := t.TempDir()
+	// 已存在的可执行文件被覆盖后同样去掉可执行位
+	if err := os.WriteFile(filepath.Join(dest, "run.sh"), []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreBackup(backup, dest); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]os.FileMode{"run.sh": 0o644, "suid": 0o644, "private.txt": 0o600} {
+		info, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != want {
+			t.Errorf("%s mode = %v, want %v", name, info.Mode(), want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package host
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// handlePluginBackup 处理 GET /plugins/<id>/_host/backup，把插件目录打包为完整备份直接写入响应，
+// 不在磁盘上暂存，需要管理员令牌
+func (h *PluginHost) handlePluginBackup(w http.ResponseWriter, r *http.Request, pluginID string) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	p, ok := h.getPlugin(pluginID)
+	if !ok {
+		http.Error(w, "plugin not found", http.StatusNotFound)
+		return
+	}
+	pluginDir := filepath.Join(h.config.PluginsDir, pluginID)
+	if info, err := os.Stat(pluginDir); err != nil || !info.IsDir() {
+		http.Error(w, "plugin directory not found", http.StatusNotFound)
+		return
+	}
+
+	name := fmt.Sprintf("%s-v%s-%s.zip", pluginID, p.Manifest.Version, time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if err := writeDirZip(w, pluginDir); err != nil {
+		// 响应头已发出，只能中断流并记录
+		log.Printf("plugin backup download %s failed: %v", pluginID, err)
+		return
+	}
+	h.audit("plugin.backup.download", requestActor("", r), pluginID, nil)
+}
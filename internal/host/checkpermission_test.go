@@ -0,0 +1,39 @@
+package host
+
+import "testing"
+
+func TestCheckPermissionRPC(t *testing.T) {
+	h := newTestHost(t, Config{TrustedPlugins: []string{"trusted"}})
+	addTestPlugin(t, h, "reader", "vault.read")
+	addTestPlugin(t, h, "admin", "*")
+	addTestPlugin(t, h, "trusted")
+	addTestPlugin(t, h, "none")
+
+	cases := []struct {
+		pluginID, permission string
+		granted              bool
+	}{
+		{"reader", "vault.read", true},
+		{"reader", "vault.write", false},
+		{"admin", "vault.write", true},
+		{"trusted", "vault.write", true},
+		{"none", "vault.read", false},
+		{"missing", "vault.read", false},
+	}
+	for _, tc := range cases {
+		code, resp := callRPC(t, h, "none", "host.checkPermission", map[string]any{
+			"pluginId":   tc.pluginID,
+			"permission": tc.permission,
+		})
+		if code != 200 || resp.Error != nil {
+			t.Fatalf("%s/%s: got %d %+v", tc.pluginID, tc.permission, code, resp.Error)
+		}
+		if got := resp.Result.(map[string]any)["granted"]; got != tc.granted {
+			t.Errorf("%s/%s: granted = %v, want %v", tc.pluginID, tc.permission, got, tc.granted)
+		}
+	}
+
+	if code, _ := callRPC(t, h, "none", "host.checkPermission", map[string]any{"pluginId": "reader"}); code != 400 {
+		t.Errorf("missing permission param: got %d, want 400", code)
+	}
+}
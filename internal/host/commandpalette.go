@@ -0,0 +1,46 @@
+package host
+
+// PaletteCommand 命令面板中的一条命令，附带所属插件的名称和启用状态
+type PaletteCommand struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	PluginID      string `json:"pluginId"`
+	PluginName    string `json:"pluginName"`
+	PluginEnabled bool   `json:"pluginEnabled"`
+	Category      string `json:"category,omitempty"`
+	Hotkey        string `json:"hotkey,omitempty"`
+}
+
+// commandPalette 返回命令面板需要的全部命令，includeDisabled 为 false 时不含已禁用插件的命令；
+// 所属插件已不存在的命令总是跳过
+func (h *PluginHost) commandPalette(includeDisabled bool) []PaletteCommand {
+	type pluginState struct {
+		name    string
+		enabled bool
+	}
+	h.pluginsMu.RLock()
+	plugins := make(map[string]pluginState, len(h.plugins))
+	for id, p := range h.plugins {
+		plugins[id] = pluginState{name: p.Manifest.Name, enabled: p.Enabled}
+	}
+	h.pluginsMu.RUnlock()
+
+	cmds := h.listCommands()
+	out := make([]PaletteCommand, 0, len(cmds))
+	for _, c := range cmds {
+		p, ok := plugins[c.PluginID]
+		if !ok || (!p.enabled && !includeDisabled) {
+			continue
+		}
+		out = append(out, PaletteCommand{
+			ID:            c.ID,
+			Title:         c.Title,
+			PluginID:      c.PluginID,
+			PluginName:    p.name,
+			PluginEnabled: p.enabled,
+			Category:      c.Category,
+			Hotkey:        c.Hotkey,
+		})
+	}
+	return out
+}
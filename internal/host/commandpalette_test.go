@@ -0,0 +1,81 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// setupPalette 添加启用的 notes、禁用的 draft 两个插件及其命令，另有一条所属插件已不存在的命令
+func setupPalette(t *testing.T, h *PluginHost) {
+	t.Helper()
+	addTestPlugin(t, h, "notes")
+	addTestPlugin(t, h, "draft")
+	h.pluginsMu.Lock()
+	h.plugins["notes"].Manifest.Name = "Notes"
+	h.plugins["draft"].Manifest.Name = "Draft"
+	h.plugins["draft"].Enabled = false
+	h.pluginsMu.Unlock()
+	h.registerCommand(Command{ID: "open", Title: "Open", PluginID: "notes", Category: "File", Hotkey: "Mod+O", Source: CommandSourceRuntime})
+	h.registerCommand(Command{ID: "new", Title: "New", PluginID: "notes", Source: CommandSourceRuntime})
+	h.registerCommand(Command{ID: "publish", Title: "Publish", PluginID: "draft", Category: "Draft", Source: CommandSourceRuntime})
+	h.registerCommand(Command{ID: "ghost", Title: "Ghost", PluginID: "gone", Source: CommandSourceRuntime})
+}
+
+// decodePalette 把 RPC 结果解码为命令面板条目
+func decodePalette(t *testing.T, result any) []PaletteCommand {
+	t.Helper()
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []PaletteCommand
+	if err := json.Unmarshal(data, &items); err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+func TestCommandPalette(t *testing.T) {
+	h := newTestHost(t, Config{})
+	setupPalette(t, h)
+
+	want := []PaletteCommand{
+		{ID: "new", Title: "New", PluginID: "notes", PluginName: "Notes", PluginEnabled: true},
+		{ID: "open", Title: "Open", PluginID: "notes", PluginName: "Notes", PluginEnabled: true, Category: "File", Hotkey: "Mod+O"},
+	}
+	if got := h.commandPalette(false); !reflect.DeepEqual(got, want) {
+		t.Fatalf("palette = %+v\nwant %+v", got, want)
+	}
+
+	// includeDisabled 时包含禁用插件的命令，所属插件不存在的命令仍然跳过
+	want = append([]PaletteCommand{
+		{ID: "publish", Title: "Publish", PluginID: "draft", PluginName: "Draft", PluginEnabled: false, Category: "Draft"},
+	}, want...)
+	if got := h.commandPalette(true); !reflect.DeepEqual(got, want) {
+		t.Fatalf("palette with disabled = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestGetCommandPaletteRPC(t *testing.T) {
+	h := newTestHost(t, Config{})
+	setupPalette(t, h)
+
+	status, resp := callRPC(t, h, "notes", "host.getCommandPalette", nil)
+	if status != http.StatusOK || resp.Error != nil {
+		t.Fatalf("getCommandPalette: %d %+v", status, resp.Error)
+	}
+	if items := decodePalette(t, resp.Result); len(items) != 2 || items[1].PluginName != "Notes" || items[1].Hotkey != "Mod+O" {
+		t.Fatalf("items = %+v", items)
+	}
+
+	_, resp = callRPC(t, h, "notes", "host.getCommandPalette", map[string]bool{"includeDisabled": true})
+	if items := decodePalette(t, resp.Result); len(items) != 3 || items[0].PluginID != "draft" || items[0].PluginEnabled {
+		t.Fatalf("items with disabled = %+v", items)
+	}
+
+	if _, resp := callRPC(t, h, "notes", "host.getCommandPalette", map[string]string{"includeDisabled": "yes"}); resp.Error == nil || resp.Error.Code != 400 {
+		t.Fatalf("invalid params: %+v", resp.Error)
+	}
+}
@@ -0,0 +1,119 @@
+package host
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultCommandTimeout 等待命令结果的默认超时
+	defaultCommandTimeout = 10 * time.Second
+	// maxCommandTimeout 调用方可指定的最长等待时间
+	maxCommandTimeout = 60 * time.Second
+)
+
+var (
+	// ErrUnknownCommand 调用的命令未注册
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrCommandTimeout 处理命令的插件未在超时前返回结果
+	ErrCommandTimeout = errors.New("command result timed out")
+	// ErrUnknownInvocation 调用ID不存在、已完成或不属于该插件
+	ErrUnknownInvocation = errors.New("unknown invocation")
+)
+
+// commandResult 插件通过 commands.result 提交的命令执行结果
+type commandResult struct {
+	Result json.RawMessage
+	Error  string
+}
+
+// pendingInvocation 等待结果的命令调用
+type pendingInvocation struct {
+	pluginID string
+	ch       chan commandResult
+}
+
+func newInvocationID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// commandTimeout 把调用方指定的毫秒数限制在允许范围内，0 表示默认值
+func commandTimeout(ms int) time.Duration {
+	if ms <= 0 {
+		return defaultCommandTimeout
+	}
+	if d := time.Duration(ms) * time.Millisecond; d < maxCommandTimeout {
+		return d
+	}
+	return maxCommandTimeout
+}
+
+// commandInvokePermission 返回已注册命令的 invokePermission，命令不存在或不限制时返回空字符串
+func (h *PluginHost) commandInvokePermission(pluginID, commandID string) string {
+	h.commandsMu.RLock()
+	defer h.commandsMu.RUnlock()
+	return h.commands[pluginID+":"+commandID].InvokePermission
+}
+
+// invokeCommandWait 广播带调用ID的 command.invoked 事件，并等待处理命令的插件提交结果
+func (h *PluginHost) invokeCommandWait(ctx context.Context, pluginID, commandID string, timeout time.Duration) (string, json.RawMessage, error) {
+	h.commandsMu.RLock()
+	_, ok := h.commands[pluginID+":"+commandID]
+	h.commandsMu.RUnlock()
+	if !ok {
+		return "", nil, ErrUnknownCommand
+	}
+
+	id := newInvocationID()
+	inv := &pendingInvocation{pluginID: pluginID, ch: make(chan commandResult, 1)}
+	h.invocationsMu.Lock()
+	h.invocations[id] = inv
+	h.invocationsMu.Unlock()
+	defer func() {
+		h.invocationsMu.Lock()
+		delete(h.invocations, id)
+		h.invocationsMu.Unlock()
+	}()
+
+	h.Broadcast(Event{Type: "command.invoked", Data: map[string]string{
+		"pluginId":     pluginID,
+		"commandId":    commandID,
+		"invocationId": id,
+	}})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-inv.ch:
+		if res.Error != "" {
+			return id, nil, fmt.Errorf("command failed: %s", res.Error)
+		}
+		return id, res.Result, nil
+	case <-timer.C:
+		return id, nil, fmt.Errorf("%w after %s", ErrCommandTimeout, timeout)
+	case <-ctx.Done():
+		return id, nil, ctx.Err()
+	}
+}
+
+// postCommandResult 把插件提交的结果交给等待中的调用方，每个调用只接受一次结果
+func (h *PluginHost) postCommandResult(pluginID, invocationID string, res commandResult) error {
+	h.invocationsMu.Lock()
+	inv, ok := h.invocations[invocationID]
+	if ok && inv.pluginID == pluginID {
+		delete(h.invocations, invocationID)
+	}
+	h.invocationsMu.Unlock()
+	if !ok || inv.pluginID != pluginID {
+		return ErrUnknownInvocation
+	}
+	inv.ch <- res
+	return nil
+}
@@ -0,0 +1,174 @@
+package host
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// awaitInvocation 等待 command.invoked 事件并返回其中的调用ID
+func awaitInvocation(t *testing.T, c *sseClient) string {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-c.ch:
+			var ev struct {
+				Type string            `json:"type"`
+				Data map[string]string `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(string(msg), "data: "))), &ev); err != nil {
+				continue
+			}
+			if ev.Type == "command.invoked" && ev.Data["invocationId"] != "" {
+				return ev.Data["invocationId"]
+			}
+		case <-deadline:
+			t.Fatal("no command.invoked event")
+		}
+	}
+}
+
+func TestCommandInvokeReturnsResult(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "worker", "commands.register")
+	addTestPlugin(t, h, "other")
+	if code, resp := callRPC(t, h, "worker", "commands.register", map[string]any{"id": "worker.sum", "title": "Sum"}); code != 200 {
+		t.Fatalf("commands.register: got %d %+v", code, resp.Error)
+	}
+	events := subscribeEvents(t, h)
+
+	type invokeResult struct {
+		code int
+		resp rpcResponse
+	}
+	done := make(chan invokeResult, 1)
+	go func() {
+		code, resp := callRPC(t, h, "worker", "commands.invoke", map[string]any{"id": "worker.sum", "wait": true, "timeoutMs": 5000})
+		done <- invokeResult{code, resp}
+	}()
+
+	invocationID := awaitInvocation(t, events)
+	// 其他插件不能替处理命令的插件提交结果
+	if code, _ := callRPC(t, h, "other", "commands.result", map[string]any{"invocationId": invocationID, "result": 1}); code != 404 {
+		t.Errorf("result from another plugin: got %d, want 404", code)
+	}
+	if code, resp := callRPC(t, h, "worker", "commands.result", map[string]any{"invocationId": invocationID, "result": map[string]int{"sum": 42}}); code != 200 {
+		t.Fatalf("commands.result: got %d %+v", code, resp.Error)
+	}
+
+	got := <-done
+	if got.code != 200 || got.resp.Error != nil {
+		t.Fatalf("commands.invoke: got %d %+v", got.code, got.resp.Error)
+	}
+	result := got.resp.Result.(map[string]any)
+	if result["invocationId"] != invocationID {
+		t.Errorf("invocationId = %v, want %s", result["invocationId"], invocationID)
+	}
+	if sum := result["result"].(map[string]any)["sum"]; sum != float64(42) {
+		t.Errorf("result = %v, want sum 42", result["result"])
+	}
+
+	// 每个调用只接受一次结果
+	if code, _ := callRPC(t, h, "worker", "commands.result", map[string]any{"invocationId": invocationID, "result": 1}); code != 404 {
+		t.Errorf("second result: got %d, want 404", code)
+	}
+}
+
+func TestCommandInvokeTimesOut(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "worker", "commands.register")
+	if code, resp := callRPC(t, h, "worker", "commands.register", map[string]any{"id": "worker.slow", "title": "Slow"}); code != 200 {
+		t.Fatalf("commands.register: got %d %+v", code, resp.Error)
+	}
+
+	start := time.Now()
+	code, resp := callRPC(t, h, "worker", "commands.invoke", map[string]any{"id": "worker.slow", "wait": true, "timeoutMs": 50})
+	if code != 504 || resp.Error == nil || !strings.Contains(resp.Error.Message, "timed out") {
+		t.Fatalf("unanswered invoke: got %d %+v, want 504 timeout", code, resp.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("timeout took %s", elapsed)
+	}
+
+	if code, _ := callRPC(t, h, "worker", "commands.invoke", map[string]any{"id": "worker.missing", "wait": true}); code != 404 {
+		t.Errorf("unknown command: got %d, want 404", code)
+	}
+}
+
+func TestCommandInvokePermission(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "owner", "commands.register")
+	addTestPlugin(t, h, "granted", "files.export")
+	addTestPlugin(t, h, "plain")
+	for _, params := range []map[string]any{
+		{"id": "owner.export", "title": "Export", "invokePermission": "files.export"},
+		{"id": "owner.open", "title": "Open"},
+	} {
+		if code, resp := callRPC(t, h, "owner", "commands.register", params); code != 200 {
+			t.Fatalf("commands.register: got %d %+v", code, resp.Error)
+		}
+	}
+
+	cases := []struct {
+		caller, command string
+		want            int
+	}{
+		{"plain", "owner.export", 403},
+		{"granted", "owner.export", 200},
+		// 命令所属插件调用自己的命令不需要该权限
+		{"owner", "owner.export", 200},
+		// 未声明 invokePermission 的命令任何插件都可调用
+		{"plain", "owner.open", 200},
+	}
+	for _, c := range cases {
+		code, resp := callRPC(t, h, c.caller, "commands.invoke", map[string]any{"id": c.command, "pluginId": "owner"})
+		if code != c.want {
+			t.Errorf("%s invoking %s: got %d %+v, want %d", c.caller, c.command, code, resp.Error, c.want)
+		}
+		if c.want == 403 && (resp.Error == nil || resp.Error.Message != "missing permission: files.export") {
+			t.Errorf("%s invoking %s: error = %+v", c.caller, c.command, resp.Error)
+		}
+	}
+	// 权限不足时不触发命令
+	events := subscribeEvents(t, h)
+	callRPC(t, h, "plain", "commands.invoke", map[string]any{"id": "owner.export", "pluginId": "owner", "wait": true, "timeoutMs": 50})
+	for _, ev := range receivedEvents(t, events) {
+		if ev.Type == "command.invoked" {
+			t.Errorf("forbidden invocation emitted %+v", ev)
+		}
+	}
+
+	_, resp := callRPC(t, h, "plain", "commands.list", nil)
+	for _, item := range resp.Result.([]any) {
+		cmd := item.(map[string]any)
+		want := map[string]any{"owner.export": "files.export", "owner.open": nil}[cmd["id"].(string)]
+		if cmd["invokePermission"] != want {
+			t.Errorf("%s invokePermission = %v, want %v", cmd["id"], cmd["invokePermission"], want)
+		}
+	}
+}
+
+func TestManifestCommandInvokePermission(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "owner")
+	addTestPlugin(t, h, "plain")
+	h.syncManifestCommands(Manifest{ID: "owner", Commands: []ManifestCommand{
+		{ID: "owner.purge", Title: "Purge", InvokePermission: "vault.write"},
+	}})
+
+	if code, _ := callRPC(t, h, "plain", "commands.invoke", map[string]any{"id": "owner.purge", "pluginId": "owner"}); code != 403 {
+		t.Errorf("invoke without permission: got %d, want 403", code)
+	}
+	h.pluginsMu.Lock()
+	h.plugins["plain"].Manifest.Permissions = []string{"vault.write"}
+	h.pluginsMu.Unlock()
+	if code, resp := callRPC(t, h, "plain", "commands.invoke", map[string]any{"id": "owner.purge", "pluginId": "owner"}); code != 200 {
+		t.Errorf("invoke with permission: got %d %+v", code, resp.Error)
+	}
+	// 未知命令不受权限检查影响，仍返回 404
+	if code, _ := callRPC(t, h, "plain", "commands.invoke", map[string]any{"id": "owner.missing", "pluginId": "owner"}); code != 404 {
+		t.Errorf("unknown command: got %d, want 404", code)
+	}
+}
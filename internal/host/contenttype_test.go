@@ -0,0 +1,130 @@
+package host
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const htmlErrorPage = "<!DOCTYPE html>\n<html><head><title>404 Not Found</title></head><body>not found</body></html>"
+
+// zipBytes 返回包含单个清单文件的 zip 内容
+func zipBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(`{"id":"demo"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckPackageContent(t *testing.T) {
+	manifest := []byte(`{"id":"demo","name":"Demo","version":"1.0.0"}`)
+	for _, tc := range []struct {
+		contentType string
+		head        []byte
+	}{
+		{"application/json", manifest},
+		{"text/plain; charset=utf-8", manifest},
+		{"application/octet-stream", zipBytes(t)},
+		{"application/zip", zipBytes(t)},
+		{"application/x-yaml", []byte("id: demo\nname: Demo\n")},
+		{"", manifest},
+		{"not a media type", manifest},
+	} {
+		if err := checkPackageContent(tc.contentType, tc.head); err != nil {
+			t.Errorf("checkPackageContent(%q, %.20q) = %v, want ok", tc.contentType, tc.head, err)
+		}
+	}
+	for _, tc := range []struct {
+		contentType string
+		head        []byte
+	}{
+		{"text/html; charset=utf-8", manifest},
+		{"application/xhtml+xml", nil},
+		{"application/xml", nil},
+		{"application/octet-stream", []byte(htmlErrorPage)},
+		{"text/plain", []byte("  <html><body>login required</body></html>")},
+		{"", []byte(`<?xml version="1.0"?><Error><Code>AccessDenied</Code></Error>`)},
+	} {
+		if err := checkPackageContent(tc.contentType, tc.head); err == nil {
+			t.Errorf("checkPackageContent(%q, %.20q) accepted", tc.contentType, tc.head)
+		}
+	}
+}
+
+func TestInstallRejectsHTMLDownload(t *testing.T) {
+	valid, _ := json.Marshal(Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	zipped := zipBytes(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/html.json":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(htmlErrorPage))
+		case "/sniffed.json":
+			// 声明为二进制但内容是网页
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte(htmlErrorPage))
+		case "/plugin.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			_, _ = w.Write(zipped)
+		case "/raw.json":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write(valid)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, path := range []string{"/html.json", "/sniffed.json"} {
+		h := newTestHost(t, Config{})
+		events := subscribeEvents(t, h)
+		err := h.installPluginFromURL("demo", srv.URL+path, "", "", nil)
+		if got := installErrorCode(err); got != InstallErrContentType {
+			t.Errorf("%s: code = %q (%v), want %s", path, got, err, InstallErrContentType)
+			continue
+		}
+		var failed map[string]any
+		for _, ev := range receivedEvents(t, events) {
+			if ev.Type == "plugin.installation.failed" {
+				failed, _ = ev.Data.(map[string]any)
+			}
+		}
+		if failed["code"] != InstallErrContentType {
+			t.Errorf("%s: failed event = %v", path, failed)
+		}
+		if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "demo")); !os.IsNotExist(err) {
+			t.Errorf("%s: plugin directory written, stat err = %v", path, err)
+		}
+	}
+
+	// zip 包通过内容类型检查，由后续的清单解析处理
+	h := newTestHost(t, Config{})
+	if got := installErrorCode(h.installPluginFromURL("demo", srv.URL+"/plugin.zip", "", "", nil)); got == InstallErrContentType {
+		t.Errorf("zip package rejected as %s", got)
+	}
+	// 以 text/plain 提供的 JSON 清单可以正常安装
+	if err := h.installPluginFromURL("demo", srv.URL+"/raw.json", "", "", nil); err != nil {
+		t.Fatalf("text/plain manifest: %v", err)
+	}
+}
+
+func TestContentTypeMessageLocalized(t *testing.T) {
+	for _, lang := range []string{"en", "zh"} {
+		if localize(InstallErrContentType, lang) == "" {
+			t.Errorf("no %s message for %s", lang, InstallErrContentType)
+		}
+	}
+}
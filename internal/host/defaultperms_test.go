@@ -0,0 +1,38 @@
+package host
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDefaultPermissions(t *testing.T) {
+	h := newTestHost(t, Config{DefaultPermissions: []string{"events.publish"}})
+	addTestPlugin(t, h, "plain")
+
+	if !h.hasPermission("plain", "events.publish") {
+		t.Error("default permission not granted to a plugin that does not declare it")
+	}
+	if h.hasPermission("plain", "vault.write") {
+		t.Error("permission outside the defaults was granted")
+	}
+	if h.hasPermission("ghost", "events.publish") {
+		t.Error("default permission granted to an uninstalled plugin")
+	}
+	if h.hasPermission("", "events.publish") {
+		t.Error("default permission granted to an anonymous caller")
+	}
+
+	if code, resp := callRPC(t, h, "plain", "events.publish", map[string]any{"name": "ping"}); code != http.StatusOK {
+		t.Errorf("events.publish with a default permission: got %d %+v", code, resp.Error)
+	}
+	if code, _ := callRPC(t, h, "plain", "vault.write", map[string]any{"path": "a.md", "content": "x"}); code != http.StatusForbidden {
+		t.Errorf("vault.write without permission: status = %d, want 403", code)
+	}
+
+	// 未配置默认权限时仍须在清单中声明
+	h = newTestHost(t, Config{})
+	addTestPlugin(t, h, "plain")
+	if code, _ := callRPC(t, h, "plain", "events.publish", map[string]any{"name": "ping"}); code != http.StatusForbidden {
+		t.Errorf("events.publish without defaults: status = %d, want 403", code)
+	}
+}
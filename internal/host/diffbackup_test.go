@@ -0,0 +1,128 @@
+package host
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// zipEntryNames 返回备份包中的条目名，按名称排序
+func zipEntryNames(t *testing.T, path string) []string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	names := make([]string, 0, len(zr.File))
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDiffBackupStoresOnlyChangedFiles(t *testing.T) {
+	h := newTestHost(t, Config{BackupMode: BackupModeDiff})
+	addTestPlugin(t, h, "demo")
+	dir := filepath.Join(h.config.PluginsDir, "demo")
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.js", "v1")
+	write("assets/style.css", "body{}")
+	write("old.txt", "removed later")
+
+	base, err := h.backupPlugin("demo")
+	if err != nil {
+		t.Fatalf("base backup: %v", err)
+	}
+	// 备份文件名精确到秒，改名避免与下一次备份重名
+	renamed := filepath.Join(filepath.Dir(base), "demo-v1.0.0-20000101-000000.zip")
+	if err := os.Rename(base, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if got := zipEntryNames(t, renamed); len(got) != 5 || got[0] != backupManifestName {
+		t.Fatalf("full backup entries = %v", got)
+	}
+
+	write("main.js", "v2")
+	write("assets/new.png", "png")
+	if err := os.Remove(filepath.Join(dir, "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := h.backupPlugin("demo")
+	if err != nil {
+		t.Fatalf("diff backup: %v", err)
+	}
+	want := []string{backupManifestName, "assets/new.png", "main.js"}
+	got := zipEntryNames(t, diff)
+	if len(got) != len(want) {
+		t.Fatalf("diff entries = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("diff entries = %v, want %v", got, want)
+		}
+	}
+
+	// 从基准 + 差异恢复出当前状态
+	dest := t.TempDir()
+	if err := RestoreBackup(diff, dest); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	for name, content := range map[string]string{
+		"main.js":          "v2",
+		"assets/style.css": "body{}",
+		"assets/new.png":   "png",
+		"manifest.json":    "",
+	} {
+		data, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s not restored: %v", name, err)
+			continue
+		}
+		if content != "" && string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "old.txt")); !os.IsNotExist(err) {
+		t.Error("deleted file was restored")
+	}
+	if _, err := os.Stat(filepath.Join(dest, backupManifestName)); !os.IsNotExist(err) {
+		t.Error("backup manifest was restored as a file")
+	}
+}
+
+func TestRestoreDiffBackupMissingBase(t *testing.T) {
+	h := newTestHost(t, Config{BackupMode: BackupModeDiff})
+	addTestPlugin(t, h, "demo")
+
+	base, err := h.backupPlugin("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamed := filepath.Join(filepath.Dir(base), "demo-v1.0.0-20000101-000000.zip")
+	if err := os.Rename(base, renamed); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := h.backupPlugin("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreBackup(diff, t.TempDir()); err == nil {
+		t.Fatal("restoring a diff without its base: expected error")
+	}
+}
@@ -0,0 +1,19 @@
+package host
+
+import "slices"
+
+// methodDisabled 判断RPC方法是否在 Config.DisabledMethods 中，被禁用的方法对所有调用方都不可用
+func (h *PluginHost) methodDisabled(method string) bool {
+	return slices.Contains(h.config.DisabledMethods, method)
+}
+
+// enabledMethods 返回未被禁用的RPC方法名，host.getInfo 和特性列表只公布这些方法
+func (h *PluginHost) enabledMethods() []string {
+	methods := make([]string, 0, len(h.rpcMethods))
+	for name := range h.rpcMethods {
+		if !h.methodDisabled(name) {
+			methods = append(methods, name)
+		}
+	}
+	return methods
+}
@@ -0,0 +1,69 @@
+package host
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestDisabledMethodRejected(t *testing.T) {
+	h := newTestHost(t, Config{
+		AdminToken:      "secret",
+		DisabledMethods: []string{"vault.write", "host.batchUninstall"},
+	})
+	addTestPlugin(t, h, "a", "vault.read", "vault.write")
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	// 无论调用方是否拥有权限、是否为管理员，被禁用的方法都返回 403
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		plugin string
+		method string
+		params any
+	}{
+		{"plugin with permission", nil, "a", "vault.write", map[string]string{"path": "a.md", "content": "x"}},
+		{"admin", admin, "", "host.batchUninstall", map[string][]string{"pluginIds": {"a"}}},
+		// 禁用检查在参数校验之前
+		{"invalid params", admin, "", "vault.write", "not an object"},
+	} {
+		status, resp := callRPCWithHeader(t, h, tc.header, tc.plugin, tc.method, tc.params)
+		if status != http.StatusForbidden || resp.Error == nil || resp.Error.Message != "method disabled" {
+			t.Errorf("%s: %d %+v", tc.name, status, resp.Error)
+		}
+	}
+	if _, err := h.readVaultFile("a.md"); err == nil {
+		t.Error("disabled vault.write still wrote the file")
+	}
+	if _, ok := h.getPlugin("a"); !ok {
+		t.Error("disabled host.batchUninstall still removed the plugin")
+	}
+
+	// 未禁用的方法不受影响
+	if err := h.writeVaultFile("b.md", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	status, resp := callRPC(t, h, "a", "vault.read", map[string]string{"path": "b.md"})
+	if status != http.StatusOK || resp.Error != nil {
+		t.Fatalf("vault.read: %d %+v", status, resp.Error)
+	}
+	// 未知方法仍然返回 404
+	if status, _ := callRPC(t, h, "a", "vault.noSuchMethod", nil); status != http.StatusNotFound {
+		t.Fatalf("unknown method: %d", status)
+	}
+}
+
+func TestFeaturesOmitDisabledMethods(t *testing.T) {
+	h := newTestHost(t, Config{DisabledMethods: []string{"vault.write"}})
+	features := h.features()
+	if slices.Contains(features, "vault.write") {
+		t.Error("disabled method listed in features")
+	}
+	if !slices.Contains(features, "vault.read") || !slices.IsSorted(features) {
+		t.Errorf("features = %v", features)
+	}
+	// 依赖被禁用方法的插件不能启用
+	if err := h.checkCompatible(Manifest{ID: "x", RequiresFeatures: []string{"vault.write"}}); err == nil {
+		t.Error("plugin requiring a disabled method is compatible")
+	}
+}
@@ -0,0 +1,233 @@
+package host
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxDownloadRedirects 下载时允许跟随的最大重定向次数
+const maxDownloadRedirects = 5
+
+// newDownloadClient 基于 outboundTransport 创建用于下载插件的HTTP客户端
+// 连接建立时检查实际解析到的IP（可防御DNS重绑定），重定向目标需重新通过URL校验。
+// 经代理时实际连接的是代理本身，不做内部地址检查，目标仍须通过URL校验
+func newDownloadClient(validator *PluginValidator, transport *http.Transport) *http.Client {
+	checked := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return validator.checkDialAddress(address)
+		},
+	}
+	direct := &net.Dialer{Timeout: 10 * time.Second}
+	proxies := &proxyAddrs{}
+	transport.Proxy = proxies.record(transport.Proxy)
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if proxies.has(address) {
+			return direct.DialContext(ctx, network, address)
+		}
+		return checked.DialContext(ctx, network, address)
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   validator.config.InstallTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxDownloadRedirects {
+				return fmt.Errorf("too many redirects")
+			}
+			if verr := validator.validateDownloadURL(req.URL.String()); verr != nil {
+				return fmt.Errorf("redirect to %s rejected: %w", req.URL.Host, verr)
+			}
+			return nil
+		},
+	}
+}
+
+// checkDialAddress 拒绝连接到私有、回环和链路本地等内部地址。
+// AllowLocalInstall 只放行回环地址，其余内部地址须开启 AllowPrivateNetwork
+func (v *PluginValidator) checkDialAddress(address string) error {
+	if v.config.AllowPrivateNetwork {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("unexpected dial address: %s", address)
+	}
+	if v.config.AllowLocalInstall && ip.IsLoopback() {
+		return nil
+	}
+	if isInternalIP(ip) {
+		return &ValidationError{
+			Field:   "url",
+			Message: fmt.Sprintf("禁止连接内部地址 %s", ip),
+			Code:    "INTERNAL_ADDRESS",
+			Args:    []any{ip.String()},
+		}
+	}
+	return nil
+}
+
+// cgnatNet 运营商级NAT共享地址段（RFC 6598）
+var cgnatNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isInternalIP 判断是否为私有、回环、链路本地、CGNAT或未指定地址，IPv4映射的IPv6地址按IPv4判断
+func isInternalIP(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return ip.IsLoopback() ||
+		cgnatNet.Contains(ip) ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsUnspecified()
+}
+
+// sniffLen 判断下载内容类型时读取的开头字节数，与 http.DetectContentType 使用的长度一致
+const sniffLen = 512
+
+// checkPackageContent 根据响应的 Content-Type 和开头的字节判断下载内容是否明显不是插件包。
+// JSON/YAML 清单常以 text/plain 提供（如 raw.githubusercontent.com），因此只拒绝网页
+func checkPackageContent(contentType string, head []byte) error {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && isMarkupType(mediaType) {
+		return fmt.Errorf("unexpected content type %q", mediaType)
+	}
+	if len(head) > 0 {
+		sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+		if isMarkupType(sniffed) {
+			return fmt.Errorf("unexpected content: response body looks like %s", sniffed)
+		}
+	}
+	return nil
+}
+
+func isMarkupType(mediaType string) bool {
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "text/xml", "application/xml":
+		return true
+	}
+	return false
+}
+
+// isDownloadBlocked 判断下载错误是否由地址或重定向校验导致
+func isDownloadBlocked(err error) bool {
+	var verr *ValidationError
+	return errors.As(err, &verr)
+}
+
+// outboundTransport 返回访问外部地址（下载、市场索引）使用的 Transport，带上配置的代理和证书设置。
+// Config.HTTPProxy 为空时按 HTTP_PROXY/HTTPS_PROXY/NO_PROXY 环境变量决定是否走代理
+func (h *PluginHost) outboundTransport() (*http.Transport, error) {
+	tlsConfig, err := h.outboundTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(h.config.HTTPProxy)
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+// outboundTLSConfig 在系统根证书之外信任 Config.CACertFile 和 CACertPEM 中的CA证书，
+// 未配置证书且未关闭校验时返回 nil 使用默认设置
+func (h *PluginHost) outboundTLSConfig() (*tls.Config, error) {
+	if h.config.CACertFile == "" && h.config.CACertPEM == "" && !h.config.InsecureSkipTLSVerify {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: h.config.InsecureSkipTLSVerify}
+	if h.config.CACertFile == "" && h.config.CACertPEM == "" {
+		return cfg, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if h.config.CACertFile != "" {
+		pem, err := os.ReadFile(h.config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA certificate file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", h.config.CACertFile)
+		}
+	}
+	if h.config.CACertPEM != "" && !pool.AppendCertsFromPEM([]byte(h.config.CACertPEM)) {
+		return nil, fmt.Errorf("no certificates found in CACertPEM")
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// proxyFunc 返回 Transport.Proxy 使用的代理选择函数。proxy 为空时读取环境变量，
+// 未写协议时按 http 代理处理
+func proxyFunc(proxy string) func(*http.Request) (*url.URL, error) {
+	if proxy == "" {
+		return http.ProxyFromEnvironment
+	}
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err == nil && u.Host == "" {
+		err = fmt.Errorf("missing host")
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid proxy %q: %w", proxy, err)
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	return http.ProxyURL(u)
+}
+
+// proxyAddrs 记录代理选择函数返回过的代理地址，拨号时据此区分连接代理还是直连目标
+type proxyAddrs struct {
+	addrs sync.Map
+}
+
+func (p *proxyAddrs) record(next func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := next(req)
+		if u != nil {
+			p.addrs.Store(proxyAddr(u), struct{}{})
+		}
+		return u, err
+	}
+}
+
+func (p *proxyAddrs) has(address string) bool {
+	_, ok := p.addrs.Load(address)
+	return ok
+}
+
+// proxyAddr 返回代理的 host:port，未写端口时使用协议的默认端口
+func proxyAddr(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return net.JoinHostPort(u.Hostname(), port)
+	}
+	port := "80"
+	switch u.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
@@ -0,0 +1,70 @@
+package host
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsInternalIP(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1":           true,
+		"10.1.2.3":            true,
+		"192.168.0.1":         true,
+		"169.254.169.254":     true,
+		"100.64.0.1":          true,
+		"100.127.255.254":     true,
+		"::1":                 true,
+		"fe80::1":             true,
+		"::ffff:127.0.0.1":    true,
+		"::ffff:10.0.0.1":     true,
+		"::ffff:100.64.1.1":   true,
+		"0.0.0.0":             true,
+		"100.128.0.1":         false,
+		"140.82.112.3":        false,
+		"2606:4700::1111":     false,
+		"::ffff:140.82.112.3": false,
+	}
+	for addr, want := range cases {
+		if got := isInternalIP(net.ParseIP(addr)); got != want {
+			t.Errorf("isInternalIP(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestCheckDialAddress(t *testing.T) {
+	local := NewPluginValidator(SecurityConfig{AllowLocalInstall: true})
+	if err := local.checkDialAddress("127.0.0.1:8080"); err != nil {
+		t.Errorf("AllowLocalInstall should permit loopback: %v", err)
+	}
+	for _, addr := range []string{"10.0.0.5:443", "169.254.169.254:80", "100.64.0.1:443", "[::ffff:192.168.1.1]:443"} {
+		if err := local.checkDialAddress(addr); err == nil {
+			t.Errorf("AllowLocalInstall should not permit %s", addr)
+		}
+	}
+	if err := local.checkDialAddress("140.82.112.3:443"); err != nil {
+		t.Errorf("public address rejected: %v", err)
+	}
+
+	strict := NewPluginValidator(SecurityConfig{})
+	if err := strict.checkDialAddress("127.0.0.1:8080"); err == nil {
+		t.Error("loopback should be rejected without AllowLocalInstall")
+	}
+
+	private := NewPluginValidator(SecurityConfig{AllowPrivateNetwork: true})
+	if err := private.checkDialAddress("10.0.0.5:443"); err != nil {
+		t.Errorf("AllowPrivateNetwork should permit private addresses: %v", err)
+	}
+}
+
+func TestValidateDownloadURLDomainSuffix(t *testing.T) {
+	v := NewPluginValidator(SecurityConfig{AllowedDomains: []string{"github.com"}})
+	if err := v.validateDownloadURL("https://evilgithub.com/p.zip"); err == nil {
+		t.Error("evilgithub.com should not match github.com")
+	}
+	if err := v.validateDownloadURL("https://codeload.github.com/p.zip"); err != nil {
+		t.Errorf("subdomain rejected: %v", err)
+	}
+	if err := v.validateDownloadURL("http://github.com/p.zip"); err == nil {
+		t.Error("plain http should be rejected for remote hosts")
+	}
+}
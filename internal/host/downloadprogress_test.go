@@ -0,0 +1,123 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProgressReaderStats(t *testing.T) {
+	start := time.Now()
+	p := &progressReader{total: 1000, read: 250, started: start}
+	if got, want := p.stats(start.Add(time.Second)), (InstallProgress{BytesDownloaded: 250, TotalBytes: 1000, BytesPerSecond: 250, ETASeconds: 3}); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+	// 剩余时间向上取整
+	p.read = 300
+	if got := p.stats(start.Add(time.Second)); got.ETASeconds != 3 || got.BytesPerSecond != 300 {
+		t.Errorf("stats = %+v", got)
+	}
+	// 下载完成后不再有剩余时间
+	p.read = 1000
+	if got := p.stats(start.Add(2 * time.Second)); got.ETASeconds != 0 || got.BytesPerSecond != 500 {
+		t.Errorf("finished stats = %+v", got)
+	}
+	// 未知总长度时省略总字节数和剩余时间
+	p = &progressReader{total: -1, read: 250, started: start}
+	if got, want := p.stats(start.Add(time.Second)), (InstallProgress{BytesDownloaded: 250, BytesPerSecond: 250}); got != want {
+		t.Errorf("unknown total stats = %+v, want %+v", got, want)
+	}
+	// 尚未经过时间时不计算速率
+	if got := p.stats(start); got.BytesPerSecond != 0 || got.ETASeconds != 0 {
+		t.Errorf("zero elapsed stats = %+v", got)
+	}
+}
+
+func TestProgressReaderReportsAtEOF(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 4096)
+	var reports []InstallProgress
+	r := newProgressReader(bytes.NewReader(data), int64(len(data)), func(p InstallProgress) {
+		reports = append(reports, p)
+	})
+	if n, err := io.Copy(io.Discard, r); err != nil || n != int64(len(data)) {
+		t.Fatalf("copy = %d, %v", n, err)
+	}
+	// 读取很快，间隔内只在结尾回报一次
+	if len(reports) != 1 {
+		t.Fatalf("reports = %+v", reports)
+	}
+	if last := reports[0]; last.BytesDownloaded != 4096 || last.TotalBytes != 4096 || last.ETASeconds != 0 {
+		t.Fatalf("final report = %+v", last)
+	}
+}
+
+// downloadProgressEvents 安装插件并返回下载阶段带有字节数的进度事件
+func downloadProgressEvents(t *testing.T, h *PluginHost, url string) []map[string]any {
+	t.Helper()
+	sub := subscribeEvents(t, h)
+	if err := h.installPluginFromURL("demo", url, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	var out []map[string]any
+	for _, ev := range receivedEvents(t, sub) {
+		data, _ := ev.Data.(map[string]any)
+		if ev.Type == "plugin.installation.progress" && data["bytesDownloaded"] != nil {
+			out = append(out, data)
+		}
+	}
+	if len(out) == 0 {
+		t.Fatal("no download progress events")
+	}
+	return out
+}
+
+func TestInstallReportsDownloadBytes(t *testing.T) {
+	manifest, err := json.Marshal(Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHost(t, Config{})
+	events := downloadProgressEvents(t, h, serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"}))
+	last := events[len(events)-1]
+	size := float64(len(manifest))
+	if last["bytesDownloaded"] != size || last["totalBytes"] != size || last["phase"] != "downloading" {
+		t.Fatalf("last download event = %v", last)
+	}
+	// 下载完成时进度推进到 30
+	if last["progress"] != float64(30) {
+		t.Errorf("progress = %v, want 30", last["progress"])
+	}
+	if _, ok := last["etaSeconds"]; ok {
+		t.Errorf("finished download has eta: %v", last)
+	}
+}
+
+func TestInstallReportsDownloadBytesWithoutContentLength(t *testing.T) {
+	manifest, err := json.Marshal(Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 先刷新响应头，使用分块编码而不发送 Content-Length
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(manifest)
+	}))
+	defer srv.Close()
+	h := newTestHost(t, Config{})
+
+	events := downloadProgressEvents(t, h, srv.URL+"/plugin.json")
+	last := events[len(events)-1]
+	if last["bytesDownloaded"] != float64(len(manifest)) || last["progress"] != float64(10) {
+		t.Fatalf("last download event = %v", last)
+	}
+	for _, key := range []string{"totalBytes", "etaSeconds"} {
+		if _, ok := last[key]; ok {
+			t.Errorf("unknown length event has %s: %v", key, last)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package host
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// downloadTempPattern 下载暂存文件的命名模式
+	downloadTempPattern = "plugin-*.zip"
+	// StaleDownloadAge 启动清理时视为残留的暂存文件最短存在时间
+	StaleDownloadAge = time.Hour
+	// downloadProgressInterval 下载进度事件的最短间隔
+	downloadProgressInterval = 250 * time.Millisecond
+)
+
+// tempDir 返回下载暂存目录，未配置时使用系统临时目录
+func (h *PluginHost) tempDir() string {
+	if h.config.TempDir != "" {
+		return h.config.TempDir
+	}
+	return os.TempDir()
+}
+
+// stageDownload 把下载内容写入暂存目录，超过 limit 字节时中止并删除文件，
+// 调用方负责删除返回的文件
+func (h *PluginHost) stageDownload(body io.Reader, limit int64) (string, int64, error) {
+	f, err := os.CreateTemp(h.tempDir(), downloadTempPattern)
+	if err != nil {
+		return "", 0, err
+	}
+	n, err := io.Copy(f, io.LimitReader(body, limit+1))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > limit {
+		err = fmt.Errorf("download exceeds %d bytes", limit)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", n, err
+	}
+	return f.Name(), n, nil
+}
+
+// progressReader 包装下载流，按 downloadProgressInterval 回报已下载字节数、平均速率和预计剩余时间，
+// 读到结尾时再回报一次。total 为 Content-Length，未知时为 -1，此时不回报总字节数和剩余时间
+type progressReader struct {
+	r       io.Reader
+	total   int64
+	read    int64
+	started time.Time
+	last    time.Time
+	report  func(InstallProgress)
+}
+
+func newProgressReader(r io.Reader, total int64, report func(InstallProgress)) *progressReader {
+	now := time.Now()
+	return &progressReader{r: r, total: total, started: now, last: now, report: report}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	now := time.Now()
+	if err == io.EOF || now.Sub(p.last) >= downloadProgressInterval {
+		p.last = now
+		p.report(p.stats(now))
+	}
+	return n, err
+}
+
+// stats 返回只填写了字节数、速率和剩余时间的进度
+func (p *progressReader) stats(now time.Time) InstallProgress {
+	s := InstallProgress{BytesDownloaded: p.read}
+	if elapsed := now.Sub(p.started).Seconds(); elapsed > 0 {
+		s.BytesPerSecond = int64(float64(p.read) / elapsed)
+	}
+	if p.total > 0 {
+		s.TotalBytes = p.total
+		if s.BytesPerSecond > 0 && p.read < p.total {
+			s.ETASeconds = (p.total - p.read + s.BytesPerSecond - 1) / s.BytesPerSecond
+		}
+	}
+	return s
+}
+
+// SweepStaleDownloads 删除暂存目录中修改时间早于 olderThan 的下载残留文件，
+// 用于清理进程异常退出后遗留的暂存文件，返回删除的文件数
+func (h *PluginHost) SweepStaleDownloads(olderThan time.Duration) (int, error) {
+	matches, err := filepath.Glob(filepath.Join(h.tempDir(), downloadTempPattern))
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	removed := 0
+	for _, p := range matches {
+		info, err := os.Lstat(p)
+		if err != nil || !info.Mode().IsRegular() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(p); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
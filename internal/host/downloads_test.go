@@ -0,0 +1,79 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeAgedFile 写入文件并把修改时间设为 age 之前
+func writeAgedFile(t *testing.T, path string, age time.Duration) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-age)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSweepStaleDownloads(t *testing.T) {
+	tmp := t.TempDir()
+	h := newTestHost(t, Config{TempDir: tmp})
+
+	stale := filepath.Join(tmp, "plugin-123.zip")
+	fresh := filepath.Join(tmp, "plugin-456.zip")
+	other := filepath.Join(tmp, "unrelated.zip")
+	writeAgedFile(t, stale, 2*StaleDownloadAge)
+	writeAgedFile(t, fresh, time.Minute)
+	writeAgedFile(t, other, 2*StaleDownloadAge)
+	dir := filepath.Join(tmp, "plugin-dir.zip")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * StaleDownloadAge)
+	if err := os.Chtimes(dir, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := h.SweepStaleDownloads(StaleDownloadAge)
+	if err != nil {
+		t.Fatalf("SweepStaleDownloads: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("removed %d files, want 1", n)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale download was not removed")
+	}
+	for _, p := range []string{fresh, other, dir} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(p), err)
+		}
+	}
+}
+
+func TestStageDownloadUsesTempDir(t *testing.T) {
+	tmp := t.TempDir()
+	h := newTestHost(t, Config{TempDir: tmp})
+
+	path, n, err := h.stageDownload(strings.NewReader("zipdata"), 16)
+	if err != nil {
+		t.Fatalf("stageDownload: %v", err)
+	}
+	defer os.Remove(path)
+	if filepath.Dir(path) != tmp || n != 7 {
+		t.Errorf("staged %d bytes at %s, want 7 bytes in %s", n, path, tmp)
+	}
+
+	if _, _, err := h.stageDownload(strings.NewReader(strings.Repeat("x", 17)), 16); err == nil {
+		t.Fatal("oversized download: expected error")
+	}
+	matches, _ := filepath.Glob(filepath.Join(tmp, downloadTempPattern))
+	if len(matches) != 1 {
+		t.Errorf("temp dir has %v, oversized download should be removed", matches)
+	}
+}
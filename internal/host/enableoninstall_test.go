@@ -0,0 +1,114 @@
+package host
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// installedEnabled 返回 plugin.installed 事件中的 enabled 字段
+func installedEnabled(t *testing.T, c *sseClient, id string) any {
+	t.Helper()
+	for _, ev := range receivedEvents(t, c) {
+		if data, ok := ev.Data.(map[string]any); ok && ev.Type == "plugin.installed" && data["pluginId"] == id {
+			return data["enabled"]
+		}
+	}
+	t.Fatalf("no plugin.installed event for %s", id)
+	return nil
+}
+
+func TestEnableOnInstall(t *testing.T) {
+	off, on := false, true
+	cases := []struct {
+		name       string
+		config     *bool
+		autoEnable *bool
+		want       bool
+	}{
+		{"default", nil, nil, true},
+		{"config disabled", &off, nil, false},
+		{"request enables", &off, &on, true},
+		{"request disables", nil, &off, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHost(t, Config{EnableOnInstall: tc.config})
+			events := subscribeEvents(t, h)
+			url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+			if err := h.installPluginFromURL("demo", url, "", "", tc.autoEnable); err != nil {
+				t.Fatalf("install: %v", err)
+			}
+			if got := h.plugins["demo"].Enabled; got != tc.want {
+				t.Errorf("Enabled = %v, want %v", got, tc.want)
+			}
+			if got := installedEnabled(t, events, "demo"); got != tc.want {
+				t.Errorf("plugin.installed enabled = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMarketInstallAutoEnableOverride(t *testing.T) {
+	off := false
+	h := newTestHost(t, Config{EnableOnInstall: &off})
+	events := subscribeEvents(t, h)
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+
+	code, errs := postMarketInstall(t, h, fmt.Sprintf(`{"id":"demo","url":%q,"autoEnable":true}`, url))
+	if code != http.StatusCreated {
+		t.Fatalf("POST /market: got %d %v", code, errs)
+	}
+	if !h.plugins["demo"].Enabled {
+		t.Error("autoEnable=true should override EnableOnInstall=false")
+	}
+	if got := installedEnabled(t, events, "demo"); got != true {
+		t.Errorf("plugin.installed enabled = %v, want true", got)
+	}
+}
+
+func TestReinstallKeepsEnabledState(t *testing.T) {
+	off, on := false, true
+	h := newTestHost(t, Config{EnableOnInstall: &off})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if err := h.installPluginFromURL("demo", url, "", "", &on); err != nil {
+		t.Fatal(err)
+	}
+
+	// EnableOnInstall 只影响新安装，重新安装已启用的插件不会被禁用
+	events := subscribeEvents(t, h)
+	if code, errs := postMarketInstall(t, h, fmt.Sprintf(`{"id":"demo","url":%q}`, url)); code != http.StatusCreated {
+		t.Fatalf("reinstall: got %d %v", code, errs)
+	}
+	if !h.plugins["demo"].Enabled {
+		t.Error("reinstall disabled an enabled plugin")
+	}
+	if got := installedEnabled(t, events, "demo"); got != true {
+		t.Errorf("plugin.installed enabled = %v, want true", got)
+	}
+
+	// 显式的 autoEnable 仍然生效
+	if err := h.installPluginFromURL("demo", url, "", "", &off); err != nil {
+		t.Fatal(err)
+	}
+	if h.plugins["demo"].Enabled {
+		t.Error("autoEnable=false ignored on reinstall")
+	}
+}
+
+func TestReinstallKeepsDisabledState(t *testing.T) {
+	h := newTestHost(t, Config{})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if err := h.installPluginFromURL("demo", url, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.disablePlugin("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.installPluginFromURL("demo", url, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if h.plugins["demo"].Enabled {
+		t.Error("reinstall enabled a disabled plugin")
+	}
+}
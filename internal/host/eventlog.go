@@ -0,0 +1,238 @@
+package host
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// 事件历史参数
+const (
+	// maxEventLogBytes 事件日志超过该大小后轮转，只保留一个旧文件
+	maxEventLogBytes = 4 << 20
+	// DefaultEventRetention 未配置 EventRetention 时事件历史的保留时长
+	DefaultEventRetention = 7 * 24 * time.Hour
+	// defaultEventHistoryLimit 查询未指定 limit 时返回的条数
+	defaultEventHistoryLimit = 100
+	// maxEventHistoryLimit 单次查询最多返回的条数
+	maxEventHistoryLimit = 1000
+)
+
+// EventRecord 持久化的广播事件，Seq 在主机内单调递增
+type EventRecord struct {
+	Seq  uint64      `json:"seq"`
+	Time time.Time   `json:"time"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// eventLogPath 返回事件日志文件位置（JSONL，只追加）
+func (h *PluginHost) eventLogPath() string {
+	return filepath.Join(h.config.RootDir, "events.jsonl")
+}
+
+// rotatedEventLogPath 返回轮转后的旧事件日志位置
+func (h *PluginHost) rotatedEventLogPath() string {
+	return h.eventLogPath() + ".1"
+}
+
+// eventRetention 返回生效的事件保留时长
+func (h *PluginHost) eventRetention() time.Duration {
+	if h.config.EventRetention > 0 {
+		return h.config.EventRetention
+	}
+	return DefaultEventRetention
+}
+
+// readEventLog 按顺序读取事件日志中的记录，文件不存在时不返回错误
+func readEventLog(path string, fn func(EventRecord)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec EventRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		fn(rec)
+	}
+	return scanner.Err()
+}
+
+// recordEvent 追加一条事件记录，写入失败只记录日志不影响广播
+func (h *PluginHost) recordEvent(ev Event) {
+	h.eventLogMu.Lock()
+	defer h.eventLogMu.Unlock()
+
+	if h.eventSeq == 0 {
+		// 首次写入时从已有日志恢复序号
+		for _, p := range []string{h.rotatedEventLogPath(), h.eventLogPath()} {
+			_ = readEventLog(p, func(rec EventRecord) {
+				if rec.Seq > h.eventSeq {
+					h.eventSeq = rec.Seq
+				}
+			})
+		}
+	}
+
+	rec := EventRecord{Seq: h.eventSeq + 1, Time: time.Now().UTC(), Type: ev.Type, Data: ev.Data}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		log.Printf("events: marshal %s: %v", ev.Type, err)
+		return
+	}
+
+	if info, err := os.Stat(h.eventLogPath()); err == nil && info.Size() >= maxEventLogBytes {
+		if err := os.Rename(h.eventLogPath(), h.rotatedEventLogPath()); err != nil {
+			log.Printf("events: rotate log: %v", err)
+		}
+	}
+	f, err := os.OpenFile(h.eventLogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		log.Printf("events: open log: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		log.Printf("events: write %s: %v", ev.Type, err)
+		return
+	}
+	h.eventSeq = rec.Seq
+}
+
+// queryEvents 返回符合条件的最近 limit 条事件，按序号升序；零值表示不过滤。
+// 超出保留时长的事件不会返回
+func (h *PluginHost) queryEvents(eventType string, since time.Time, limit int) ([]EventRecord, error) {
+	if cutoff := time.Now().Add(-h.eventRetention()); since.Before(cutoff) {
+		since = cutoff
+	}
+
+	h.eventLogMu.Lock()
+	defer h.eventLogMu.Unlock()
+
+	records := []EventRecord{}
+	for _, p := range []string{h.rotatedEventLogPath(), h.eventLogPath()} {
+		err := readEventLog(p, func(rec EventRecord) {
+			if eventType != "" && rec.Type != eventType {
+				return
+			}
+			if rec.Time.Before(since) {
+				return
+			}
+			records = append(records, rec)
+			if len(records) > limit {
+				records = records[1:]
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// PruneEventHistory 删除超出保留时长的事件记录，返回删除的条数
+func (h *PluginHost) PruneEventHistory() (int, error) {
+	cutoff := time.Now().Add(-h.eventRetention())
+
+	h.eventLogMu.Lock()
+	defer h.eventLogMu.Unlock()
+
+	removed := 0
+	for _, p := range []string{h.rotatedEventLogPath(), h.eventLogPath()} {
+		var kept [][]byte
+		expired := 0
+		err := readEventLog(p, func(rec EventRecord) {
+			if rec.Time.Before(cutoff) {
+				expired++
+				return
+			}
+			line, err := json.Marshal(rec)
+			if err == nil {
+				kept = append(kept, line)
+			}
+		})
+		if err != nil {
+			return removed, err
+		}
+		if expired == 0 {
+			continue
+		}
+		if len(kept) == 0 {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return removed, err
+			}
+			removed += expired
+			continue
+		}
+		var data []byte
+		for _, line := range kept {
+			data = append(append(data, line...), '\n')
+		}
+		tmp := p + ".tmp"
+		if err := os.WriteFile(tmp, data, 0o600); err != nil {
+			return removed, err
+		}
+		if err := os.Rename(tmp, p); err != nil {
+			os.Remove(tmp)
+			return removed, err
+		}
+		removed += expired
+	}
+	return removed, nil
+}
+
+// handleEventHistory 查询事件历史，需要 Config.AdminToken
+//
+//	GET /events/history?type=&since=&limit=  时间使用RFC3339格式
+func (h *PluginHost) handleEventHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	q := r.URL.Query()
+	var since time.Time
+	var err error
+	if v := q.Get("since"); v != "" {
+		if since, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultEventHistoryLimit
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		if limit > maxEventHistoryLimit {
+			limit = maxEventHistoryLimit
+		}
+	}
+
+	records, err := h.queryEvents(q.Get("type"), since, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(records)
+}
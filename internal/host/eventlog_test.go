@@ -0,0 +1,117 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+// queryEventHistory 以管理员身份请求 /events/history
+func queryEventHistory(t *testing.T, h *PluginHost, query url.Values) []EventRecord {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/events/history?"+query.Encode(), nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	h.handleEventHistory(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("history %v: got %d %s", query, w.Code, w.Body.String())
+	}
+	var records []EventRecord
+	if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return records
+}
+
+// writeEventLog 直接写入事件日志，用于构造指定时间的历史事件
+func writeEventLog(t *testing.T, h *PluginHost, records ...EventRecord) {
+	t.Helper()
+	var data []byte
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if err := os.WriteFile(h.eventLogPath(), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEventHistoryQueryByType(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	h.Broadcast(Event{Type: "plugin.installed", Data: map[string]string{"pluginId": "a"}})
+	h.Broadcast(Event{Type: "vault.changed"})
+	h.Broadcast(Event{Type: "plugin.installed", Data: map[string]string{"pluginId": "b"}})
+
+	all := queryEventHistory(t, h, nil)
+	if len(all) != 3 {
+		t.Fatalf("history has %d events, want 3", len(all))
+	}
+	for i, rec := range all {
+		if rec.Seq != uint64(i+1) {
+			t.Errorf("event %d: seq = %d, want %d", i, rec.Seq, i+1)
+		}
+	}
+
+	installed := queryEventHistory(t, h, url.Values{"type": {"plugin.installed"}})
+	if len(installed) != 2 || installed[0].Seq != 1 || installed[1].Seq != 3 {
+		t.Errorf("type filter = %+v", installed)
+	}
+	// limit 保留最近的事件
+	latest := queryEventHistory(t, h, url.Values{"limit": {"1"}})
+	if len(latest) != 1 || latest[0].Seq != 3 {
+		t.Errorf("limit 1 = %+v, want the latest event", latest)
+	}
+}
+
+func TestEventHistoryQueryByTimeAndPrune(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret", EventRetention: 24 * time.Hour})
+	now := time.Now().UTC()
+	writeEventLog(t, h,
+		EventRecord{Seq: 1, Time: now.Add(-48 * time.Hour), Type: "plugin.installed"},
+		EventRecord{Seq: 2, Time: now.Add(-2 * time.Hour), Type: "plugin.installed"},
+		EventRecord{Seq: 3, Time: now.Add(-time.Minute), Type: "vault.changed"},
+	)
+
+	// 超出保留时长的事件不会返回
+	if got := queryEventHistory(t, h, nil); len(got) != 2 || got[0].Seq != 2 {
+		t.Errorf("history = %+v, want seq 2 and 3", got)
+	}
+	since := now.Add(-time.Hour).Format(time.RFC3339)
+	if got := queryEventHistory(t, h, url.Values{"since": {since}}); len(got) != 1 || got[0].Seq != 3 {
+		t.Errorf("since %s = %+v, want seq 3", since, got)
+	}
+
+	removed, err := h.PruneEventHistory()
+	if err != nil || removed != 1 {
+		t.Fatalf("PruneEventHistory = %d, %v, want 1", removed, err)
+	}
+	// 新事件的序号接着已有日志递增
+	h.Broadcast(Event{Type: "plugin.uninstalled"})
+	got := queryEventHistory(t, h, url.Values{"type": {"plugin.uninstalled"}})
+	if len(got) != 1 || got[0].Seq != 4 {
+		t.Errorf("new event = %+v, want seq 4", got)
+	}
+}
+
+func TestEventHistoryRequiresAdmin(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	w := httptest.NewRecorder()
+	h.handleEventHistory(w, httptest.NewRequest(http.MethodGet, "/events/history", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("without admin token: got %d, want 403", w.Code)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/events/history?since=yesterday", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	h.handleEventHistory(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid since: got %d, want 400", w.Code)
+	}
+}
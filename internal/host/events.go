@@ -4,8 +4,15 @@ import (
     "encoding/json"
     "net/http"
     "sync"
+    "time"
 )
 
+// defaultSSEKeepAlive 事件流空闲时发送 ping 注释的默认间隔
+const defaultSSEKeepAlive = 15 * time.Second
+
+// sseRetryAfter 事件流连接数已满时建议客户端等待的秒数
+const sseRetryAfter = "5"
+
 type Event struct {
     Type string      `json:"type"`
     Data interface{} `json:"data,omitempty"`
@@ -25,10 +32,15 @@ func NewEventHub() *EventHub {
     return &EventHub{clients: make(map[*sseClient]struct{})}
 }
 
-func (h *EventHub) addClient(c *sseClient) {
+// addClient 注册订阅，max 大于 0 且已有 max 个订阅时拒绝并返回 false
+func (h *EventHub) addClient(c *sseClient, max int) bool {
     h.mu.Lock()
+    defer h.mu.Unlock()
+    if max > 0 && len(h.clients) >= max {
+        return false
+    }
     h.clients[c] = struct{}{}
-    h.mu.Unlock()
+    return true
 }
 
 func (h *EventHub) removeClient(c *sseClient) {
@@ -37,10 +49,22 @@ func (h *EventHub) removeClient(c *sseClient) {
     h.mu.Unlock()
 }
 
-func (h *EventHub) Broadcast(ev Event) {
+// clientCount 返回当前连接的事件流客户端数
+func (h *EventHub) clientCount() int {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+    return len(h.clients)
+}
+
+// encodeSSE 把事件编码为一条 SSE 消息
+func encodeSSE(ev Event) []byte {
     payload, _ := json.Marshal(ev)
     msg := append([]byte("data: "), payload...)
-    msg = append(msg, []byte("\n\n")...)
+    return append(msg, []byte("\n\n")...)
+}
+
+func (h *EventHub) Broadcast(ev Event) {
+    msg := encodeSSE(ev)
     h.mu.RLock()
     for c := range h.clients {
         select {
@@ -51,30 +75,64 @@ func (h *EventHub) Broadcast(ev Event) {
     h.mu.RUnlock()
 }
 
+// sseKeepAlive 返回事件流的保活间隔，Config.SSEKeepAlive 为负数时不发送 ping
+func (h *PluginHost) sseKeepAlive() time.Duration {
+    if h.config.SSEKeepAlive != 0 {
+        return h.config.SSEKeepAlive
+    }
+    return defaultSSEKeepAlive
+}
+
 func (h *PluginHost) handleSSE(w http.ResponseWriter, r *http.Request) {
     flusher, ok := w.(http.Flusher)
     if !ok {
         w.WriteHeader(http.StatusInternalServerError)
         return
     }
+    client := &sseClient{ch: make(chan []byte, 16), done: make(chan struct{})}
+    if !h.eventHub.addClient(client, h.config.MaxSSEClients) {
+        w.Header().Set("Retry-After", sseRetryAfter)
+        http.Error(w, "too many event stream clients", http.StatusServiceUnavailable)
+        return
+    }
+    defer func() { h.eventHub.removeClient(client) }()
+
     w.Header().Set("Content-Type", "text/event-stream")
     w.Header().Set("Cache-Control", "no-cache")
     w.Header().Set("Connection", "keep-alive")
 
-    client := &sseClient{ch: make(chan []byte, 16), done: make(chan struct{})}
-    h.eventHub.addClient(client)
-    defer func() { h.eventHub.removeClient(client) }()
-
     // Send a comment to open the stream
     _, _ = w.Write([]byte(":ok\n\n"))
+    // 补发最近的安装终态事件，避免中途连接的客户端错过结果
+    for _, ev := range h.recentTerminalEvents() {
+        _, _ = w.Write(encodeSSE(ev))
+    }
     flusher.Flush()
 
+    h.streamSSE(w, flusher, r, client.ch)
+}
+
+// streamSSE 把 ch 中的消息写入事件流，直到客户端断开。定期写入 ping 注释，
+// 避免代理关闭空闲连接，写入失败说明客户端已断开
+func (h *PluginHost) streamSSE(w http.ResponseWriter, flusher http.Flusher, r *http.Request, ch <-chan []byte) {
+    var ping <-chan time.Time
+    if interval := h.sseKeepAlive(); interval > 0 {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        ping = ticker.C
+    }
+
     notify := r.Context().Done()
     for {
         select {
         case <-notify:
             return
-        case msg := <-client.ch:
+        case <-ping:
+            if _, err := w.Write([]byte(": ping\n\n")); err != nil {
+                return
+            }
+            flusher.Flush()
+        case msg := <-ch:
             if _, err := w.Write(msg); err != nil {
                 return
             }
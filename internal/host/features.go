@@ -0,0 +1,71 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrPluginIncompatible 插件依赖宿主未实现的特性或不兼容的SDK版本，不能启用
+var ErrPluginIncompatible = errors.New("plugin requires unsupported host features")
+
+// hostFeatures 宿主实现的非RPC特性。RPC方法名同样视为特性，插件可以直接依赖某个方法
+var hostFeatures = []string{
+	"audit",
+	"events.history",
+	"sse",
+	"vault.export",
+	"vault.import",
+	"vault.raw",
+	"webhooks",
+}
+
+// features 返回宿主公布的全部特性（含未禁用的RPC方法名），按名称排序
+func (h *PluginHost) features() []string {
+	out := append(h.enabledMethods(), hostFeatures...)
+	sort.Strings(out)
+	return out
+}
+
+// missingFeatures 返回清单 requiresFeatures 中宿主未实现的特性
+func (h *PluginHost) missingFeatures(m Manifest) []string {
+	if len(m.RequiresFeatures) == 0 {
+		return nil
+	}
+	supported := make(map[string]bool)
+	for _, f := range h.features() {
+		supported[f] = true
+	}
+	var missing []string
+	for _, f := range m.RequiresFeatures {
+		if !supported[f] {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+// checkCompatible 插件缺少依赖的特性或 engines.sdk 不接受宿主的SDK版本时，
+// 广播 plugin.incompatible 并返回 ErrPluginIncompatible
+func (h *PluginHost) checkCompatible(m Manifest) error {
+	if m.Engines != nil && m.Engines.SDK != "" {
+		if ok, err := versionInRange(SDKVersion, m.Engines.SDK); err != nil || !ok {
+			h.Broadcast(Event{Type: "plugin.incompatible", Data: map[string]any{
+				"pluginId":   m.ID,
+				"sdk":        m.Engines.SDK,
+				"sdkVersion": SDKVersion,
+			}})
+			return fmt.Errorf("%w: %s needs SDK %s, host provides %s", ErrPluginIncompatible, m.ID, m.Engines.SDK, SDKVersion)
+		}
+	}
+	missing := h.missingFeatures(m)
+	if len(missing) == 0 {
+		return nil
+	}
+	h.Broadcast(Event{Type: "plugin.incompatible", Data: map[string]any{
+		"pluginId": m.ID,
+		"missing":  missing,
+	}})
+	return fmt.Errorf("%w: %s needs %s", ErrPluginIncompatible, m.ID, strings.Join(missing, ", "))
+}
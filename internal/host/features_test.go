@@ -0,0 +1,116 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInstallWithSatisfiedFeatures(t *testing.T) {
+	h := newTestHost(t, Config{})
+	events := subscribeEvents(t, h)
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", RequiresFeatures: []string{"vault.read", "sse"}})
+
+	if err := h.installPluginFromURL("demo", url, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := h.getPlugin("demo"); !p.Enabled {
+		t.Error("plugin with satisfied requirements is disabled")
+	}
+	if types := eventTypes(receivedEvents(t, events)); slices.Contains(types, "plugin.incompatible") {
+		t.Errorf("unexpected plugin.incompatible: %v", types)
+	}
+}
+
+// incompatibleMissing 返回 plugin.incompatible 事件中缺少的特性
+func incompatibleMissing(t *testing.T, c *sseClient) []any {
+	t.Helper()
+	for _, ev := range receivedEvents(t, c) {
+		if ev.Type == "plugin.incompatible" {
+			missing, _ := ev.Data.(map[string]any)["missing"].([]any)
+			return missing
+		}
+	}
+	return nil
+}
+
+func TestInstallWithMissingFeatures(t *testing.T) {
+	h := newTestHost(t, Config{})
+	events := subscribeEvents(t, h)
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", RequiresFeatures: []string{"vault.read", "websocket"}})
+
+	err := h.installPluginFromURL("demo", url, "", "", nil)
+	var installErr *InstallError
+	if !errors.As(err, &installErr) || installErr.Code != InstallErrIncompatible {
+		t.Fatalf("err = %v, want %s", err, InstallErrIncompatible)
+	}
+	if _, ok := h.getPlugin("demo"); ok {
+		t.Error("incompatible plugin was registered")
+	}
+	if missing := incompatibleMissing(t, events); len(missing) != 1 || missing[0] != "websocket" {
+		t.Errorf("plugin.incompatible missing = %v, want [websocket]", missing)
+	}
+}
+
+func TestLoadPluginsDisablesIncompatible(t *testing.T) {
+	h := newTestHost(t, Config{})
+	dir := filepath.Join(h.config.PluginsDir, "demo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", RequiresFeatures: []string{"websocket"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events := subscribeEvents(t, h)
+
+	// 已安装但缺少特性的插件加载后保持禁用，也不能手动启用
+	if err := h.LoadPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := h.getPlugin("demo"); !ok || p.Enabled {
+		t.Fatalf("loaded plugin = %+v, want disabled", p)
+	}
+	if missing := incompatibleMissing(t, events); len(missing) != 1 || missing[0] != "websocket" {
+		t.Errorf("plugin.incompatible missing = %v, want [websocket]", missing)
+	}
+	if code, _ := callRPC(t, h, "demo", "host.enablePlugin", map[string]string{"pluginId": "demo"}); code != http.StatusConflict {
+		t.Errorf("enable incompatible plugin: status = %d, want 409", code)
+	}
+	if p, _ := h.getPlugin("demo"); p.Enabled {
+		t.Error("host.enablePlugin enabled an incompatible plugin")
+	}
+}
+
+func TestGetInfoAdvertisesFeatures(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo")
+
+	code, resp := callRPC(t, h, "demo", "host.getInfo", nil)
+	if code != http.StatusOK {
+		t.Fatalf("host.getInfo: got %d %+v", code, resp.Error)
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info HostInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"sse", "vault.read", "host.getInfo"} {
+		if !slices.Contains(info.Features, f) {
+			t.Errorf("features %v missing %s", info.Features, f)
+		}
+	}
+	if !slices.IsSorted(info.Features) {
+		t.Errorf("features not sorted: %v", info.Features)
+	}
+}
@@ -0,0 +1,103 @@
+package host
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// maxFrontmatterBytes 前置元数据的最大长度，超出时视为格式错误
+	maxFrontmatterBytes = 64 << 10
+	// bodyPreviewBytes 正文预览的最大长度
+	bodyPreviewBytes = 1 << 10
+)
+
+// VaultFileMeta 存储库文件的前置元数据（frontmatter）
+type VaultFileMeta struct {
+	Path string         `json:"path"`
+	Meta map[string]any `json:"meta"`
+	// BodyOffset 正文在文件中的字节偏移，没有前置元数据时为 0
+	BodyOffset int64  `json:"bodyOffset"`
+	Preview    string `json:"preview,omitempty"`
+	// Error 前置元数据格式错误时的说明，此时 Meta 为空
+	Error string `json:"error,omitempty"`
+}
+
+// readVaultMeta 读取文件开头以 --- 分隔的 YAML 前置元数据，只读取元数据部分，
+// bodyPreview 为 true 时附带正文开头的一段内容
+func (h *PluginHost) readVaultMeta(relPath string, bodyPreview bool) (*VaultFileMeta, error) {
+	f, err := h.vault.Read(relPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	result := &VaultFileMeta{Path: relPath, Meta: map[string]any{}}
+	br := bufio.NewReader(f)
+	block, offset, err := readFrontmatterBlock(br)
+	switch {
+	case errors.Is(err, errNoFrontmatter):
+		offset = 0
+	case err != nil:
+		result.Error = err.Error()
+		offset = 0
+	default:
+		// 补回开头的 --- 使错误信息中的行号与文件一致
+		meta, err := parseYAMLDocument("---\n" + strings.Join(block, "\n"))
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Meta = meta
+		}
+	}
+	result.BodyOffset = offset
+
+	if bodyPreview {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, bodyPreviewBytes)
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		result.Preview = strings.ToValidUTF8(string(buf[:n]), "")
+	}
+	return result, nil
+}
+
+var errNoFrontmatter = errors.New("no frontmatter")
+
+// readFrontmatterBlock 读取首行 --- 与下一个 --- 之间的内容，返回内容和正文偏移
+func readFrontmatterBlock(br *bufio.Reader) ([]string, int64, error) {
+	first, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+	if strings.TrimRight(first, "\r\n") != "---" {
+		return nil, 0, errNoFrontmatter
+	}
+	offset := int64(len(first))
+	var lines []string
+	for {
+		line, err := br.ReadString('\n')
+		offset += int64(len(line))
+		if offset > maxFrontmatterBytes {
+			return nil, 0, fmt.Errorf("frontmatter exceeds %d bytes", maxFrontmatterBytes)
+		}
+		trimmed := strings.TrimRight(line, "\r\n")
+		if trimmed == "---" || trimmed == "..." {
+			return lines, offset, nil
+		}
+		if err == io.EOF {
+			return nil, 0, fmt.Errorf("unterminated frontmatter")
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		lines = append(lines, trimmed)
+	}
+}
@@ -0,0 +1,54 @@
+package host
+
+import "testing"
+
+func TestReadVaultMetaWithoutFrontmatter(t *testing.T) {
+	h := newTestHost(t, Config{})
+	if err := h.writeVaultFile("plain.md", []byte("# Just a note\n")); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := h.readVaultMeta("plain.md", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Meta) != 0 || meta.BodyOffset != 0 || meta.Error != "" {
+		t.Errorf("without frontmatter: %+v", meta)
+	}
+	if meta.Preview != "# Just a note\n" {
+		t.Errorf("preview = %q", meta.Preview)
+	}
+	if meta, _ := h.readVaultMeta("plain.md", false); meta.Preview != "" {
+		t.Errorf("preview without bodyPreview = %q", meta.Preview)
+	}
+}
+
+func TestReadVaultMetaMalformed(t *testing.T) {
+	h := newTestHost(t, Config{})
+	cases := []struct {
+		name, content, preview string
+	}{
+		// 缺少结束分隔线时无法确定正文位置，正文从文件开头算起
+		{"unterminated.md", "---\ntitle: Hello\nBody without a closing fence\n", "---\ntitle: Hello\nBody without a closing fence\n"},
+		// 分隔线完整但内容不是合法的 YAML，正文仍从分隔线之后开始
+		{"invalid.md", "---\njust text\n---\nBody\n", "Body\n"},
+	}
+	for _, c := range cases {
+		if err := h.writeVaultFile(c.name, []byte(c.content)); err != nil {
+			t.Fatal(err)
+		}
+		meta, err := h.readVaultMeta(c.name, true)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if meta.Error == "" || len(meta.Meta) != 0 {
+			t.Errorf("%s: malformed frontmatter should report an error with empty meta: %+v", c.name, meta)
+		}
+		if meta.Preview != c.preview || meta.BodyOffset != int64(len(c.content)-len(c.preview)) {
+			t.Errorf("%s: offset = %d preview = %q", c.name, meta.BodyOffset, meta.Preview)
+		}
+	}
+
+	if _, err := h.readVaultMeta("missing.md", false); err == nil {
+		t.Error("missing file should return an error")
+	}
+}
@@ -0,0 +1,207 @@
+package host
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const githubAssetURL = "https://github.com/acme/demo/releases/download/v1.0.0/manifest.json"
+
+// githubMock 通过代理模拟 GitHub：普通请求由 api 处理，访问 github.com 的 CONNECT 隧道接到返回 asset 的TLS服务
+type githubMock struct {
+	apiCalls atomic.Int32
+	api      http.HandlerFunc
+}
+
+// serveGitHubViaProxy 启动模拟 GitHub 的代理，返回配置好代理和 API 地址的宿主配置
+func serveGitHubViaProxy(t *testing.T, asset []byte, mock *githubMock) Config {
+	t.Helper()
+	download := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(asset)
+	}))
+	t.Cleanup(download.Close)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			mock.apiCalls.Add(1)
+			mock.api(w, r)
+			return
+		}
+		if r.Host != "github.com:443" {
+			http.Error(w, "unexpected tunnel to "+r.Host, http.StatusForbidden)
+			return
+		}
+		upstream, err := net.Dial("tcp", download.Listener.Addr().String())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			_, _ = io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		_, _ = io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+	return Config{
+		HTTPProxy:             proxy.URL,
+		InsecureSkipTLSVerify: true,
+		GitHubAPIURL:          "http://api.github.test",
+		VerifyGitHubDigest:    true,
+	}
+}
+
+// releaseWithDigest 返回 v1.0.0 发布版本的接口响应，manifest.json 资源带有 digest
+func releaseWithDigest(digest string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/demo/releases/tags/v1.0.0" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"tag_name": "v1.0.0",
+			"assets": []map[string]string{
+				{"name": "manifest.json", "browser_download_url": githubAssetURL, "digest": digest},
+			},
+		})
+	}
+}
+
+func TestParseGitHubAssetURL(t *testing.T) {
+	repo, tag, asset, ok := parseGitHubAssetURL("https://github.com/acme/demo/releases/download/v1.0.0%2Brc/plugin%20v1.zip")
+	if !ok || repo != "acme/demo" || tag != "v1.0.0+rc" || asset != "plugin v1.zip" {
+		t.Fatalf("parsed = %q %q %q %v", repo, tag, asset, ok)
+	}
+	for _, raw := range []string{
+		"http://github.com/acme/demo/releases/download/v1/manifest.json",
+		"https://example.com/acme/demo/releases/download/v1/manifest.json",
+		"https://github.com/acme/demo/archive/v1/manifest.json",
+		"https://github.com/acme/demo/releases/download/v1",
+		"https://github.com/acme/demo/releases/download/v1/a/b",
+		"https://raw.githubusercontent.com/acme/demo/main/manifest.json",
+	} {
+		if _, _, _, ok := parseGitHubAssetURL(raw); ok {
+			t.Errorf("parseGitHubAssetURL(%q) accepted", raw)
+		}
+	}
+}
+
+func TestGitHubAssetDigest(t *testing.T) {
+	sum := sha256.Sum256([]byte("asset"))
+	hexSum := hex.EncodeToString(sum[:])
+	mock := &githubMock{api: releaseWithDigest("sha256:" + strings.ToUpper(hexSum))}
+	h := newTestHost(t, serveGitHubViaProxy(t, nil, mock))
+
+	if digest, err := h.githubAssetDigest(githubAssetURL); err != nil || digest != hexSum {
+		t.Fatalf("digest = %q, %v", digest, err)
+	}
+	// 不是 GitHub 发布资源的地址不查询接口
+	calls := mock.apiCalls.Load()
+	if digest, err := h.githubAssetDigest("https://example.com/manifest.json"); err != nil || digest != "" {
+		t.Fatalf("non-GitHub URL: %q, %v", digest, err)
+	}
+	if mock.apiCalls.Load() != calls {
+		t.Error("queried the GitHub API for a non-GitHub URL")
+	}
+	if _, err := h.githubAssetDigest("https://github.com/acme/demo/releases/download/v1.0.0/other.zip"); err == nil {
+		t.Error("missing asset: expected error")
+	}
+
+	mock.api = releaseWithDigest("sha256:abc")
+	if _, err := h.githubAssetDigest(githubAssetURL); err == nil || !strings.Contains(err.Error(), "invalid digest") {
+		t.Errorf("short digest: %v", err)
+	}
+	// 未公布摘要或不是 sha256 摘要时按未提供处理
+	for _, digest := range []string{"", "sha512:" + hexSum} {
+		mock.api = releaseWithDigest(digest)
+		if got, err := h.githubAssetDigest(githubAssetURL); err != nil || got != "" {
+			t.Errorf("digest %q: got %q, %v", digest, got, err)
+		}
+	}
+}
+
+func TestInstallVerifiesGitHubDigest(t *testing.T) {
+	manifest, err := json.Marshal(Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(manifest)
+	mock := &githubMock{api: releaseWithDigest("sha256:" + hex.EncodeToString(sum[:]))}
+	cfg := serveGitHubViaProxy(t, manifest, mock)
+	security := DefaultSecurityConfig()
+	security.RequireMarketSHA256 = true
+	cfg.Security = &security
+	h := newTestHost(t, cfg)
+
+	// 公布的摘要代替缺失的SHA256，满足 RequireMarketSHA256
+	if err := h.installPluginFromURL("demo", githubAssetURL, "", "", nil); err != nil {
+		t.Fatalf("install with published digest: %v", err)
+	}
+	if _, ok := h.getPlugin("demo"); !ok {
+		t.Fatal("plugin not installed")
+	}
+
+	mock.api = releaseWithDigest("sha256:" + strings.Repeat("0", 64))
+	if code := installErrorCode(h.installPluginFromURL("demo", githubAssetURL, "", "", nil)); code != InstallErrIntegrity {
+		t.Errorf("mismatched digest: code = %s", code)
+	}
+
+	// 显式提供的SHA256优先，不查询发布版本
+	calls := mock.apiCalls.Load()
+	if err := h.installPluginFromURL("demo", githubAssetURL, hex.EncodeToString(sum[:]), "", nil); err != nil {
+		t.Fatalf("install with explicit sha256: %v", err)
+	}
+	if mock.apiCalls.Load() != calls {
+		t.Error("queried the release although sha256 was provided")
+	}
+
+	mock.api = releaseWithDigest("")
+	if code := installErrorCode(h.installPluginFromURL("demo", githubAssetURL, "", "", nil)); code != InstallErrIntegrityRequired {
+		t.Errorf("no published digest: code = %s", code)
+	}
+}
+
+func TestInstallGitHubDigestLookupFailures(t *testing.T) {
+	mock := &githubMock{}
+	h := newTestHost(t, serveGitHubViaProxy(t, nil, mock))
+
+	mock.api = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "0")
+		w.WriteHeader(http.StatusForbidden)
+	}
+	err := h.installPluginFromURL("demo", githubAssetURL, "", "", nil)
+	if code := installErrorCode(err); code != InstallErrDigestLookup || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("rate limited: %s %v", code, err)
+	}
+
+	mock.api = http.NotFound
+	if code := installErrorCode(h.installPluginFromURL("demo", githubAssetURL, "", "", nil)); code != InstallErrDigestLookup {
+		t.Errorf("release not found: code = %s", code)
+	}
+	if _, ok := h.getPlugin("demo"); ok {
+		t.Error("plugin installed although the digest lookup failed")
+	}
+
+	// 未开启 VerifyGitHubDigest 时不查询
+	h.config.VerifyGitHubDigest = false
+	calls := mock.apiCalls.Load()
+	_ = h.installPluginFromURL("demo", githubAssetURL, "", "", nil)
+	if mock.apiCalls.Load() != calls {
+		t.Error("queried the release with VerifyGitHubDigest disabled")
+	}
+}
@@ -0,0 +1,154 @@
+package host
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// InstallSourceGit 从 GitHub 仓库的发布版本安装
+	InstallSourceGit = "git"
+	// defaultGitHubAPIURL 未配置 GitHubAPIURL 时使用的 GitHub API 地址
+	defaultGitHubAPIURL = "https://api.github.com"
+	// gitManifestAsset 发布版本中作为安装包下载的资源文件名
+	gitManifestAsset = "manifest.json"
+)
+
+var gitRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// gitRelease GitHub 发布版本接口响应中用到的字段
+type gitRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+		// Digest 资源的摘要，形如 sha256:<hex>，旧的发布版本可能没有
+		Digest string `json:"digest"`
+	} `json:"assets"`
+}
+
+// resolveGitRelease 通过 GitHub API 把 owner/repo 与 ref（发布标签，为空时取最新发布）
+// 解析为名为 asset 的发布资源下载地址。解析结果仍需经过常规的安装校验
+func (h *PluginHost) resolveGitRelease(repo, ref, asset string) (string, error) {
+	rel, err := h.fetchGitRelease(repo, ref)
+	if err != nil {
+		return "", err
+	}
+	for _, a := range rel.Assets {
+		if a.Name == asset && a.URL != "" {
+			return a.URL, nil
+		}
+	}
+	return "", fmt.Errorf("release %s of %s has no %s asset", rel.TagName, repo, asset)
+}
+
+// fetchGitRelease 查询 owner/repo 在 ref 标签下的发布版本，ref 为空时取最新发布。
+// 请求经过下载客户端，受域名白名单约束；触发 GitHub API 限流时返回明确的错误
+func (h *PluginHost) fetchGitRelease(repo, ref string) (*gitRelease, error) {
+	if !gitRepoPattern.MatchString(repo) || strings.Contains(repo, "..") {
+		return nil, fmt.Errorf("invalid repo %q, expected owner/name", repo)
+	}
+	api := strings.TrimRight(h.config.GitHubAPIURL, "/")
+	if api == "" {
+		api = defaultGitHubAPIURL
+	}
+	endpoint := api + "/repos/" + repo + "/releases/latest"
+	if ref != "" {
+		endpoint = api + "/repos/" + repo + "/releases/tags/" + url.PathEscape(ref)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	transport, err := h.outboundTransport()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newDownloadClient(NewPluginValidator(h.securityConfig()), transport).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("query release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0") {
+		return nil, fmt.Errorf("GitHub API rate limit exceeded%s", gitRateLimitReset(resp.Header))
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("release not found: %s@%s", repo, ref)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query release failed with status: %d", resp.StatusCode)
+	}
+
+	var rel gitRelease
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, fmt.Errorf("invalid release response: %w", err)
+	}
+	return &rel, nil
+}
+
+// gitRateLimitReset 从限流响应头中取出恢复时间，用于错误消息
+func gitRateLimitReset(header http.Header) string {
+	if retry := header.Get("Retry-After"); retry != "" {
+		return ", retry after " + retry + "s"
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return ", resets at " + time.Unix(reset, 0).UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+// parseGitHubAssetURL 从 https://github.com/{owner}/{repo}/releases/download/{tag}/{asset}
+// 形式的发布资源地址中取出仓库、标签和资源名，其他地址返回 false
+func parseGitHubAssetURL(raw string) (repo, tag, asset string, ok bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || !strings.EqualFold(u.Hostname(), "github.com") {
+		return "", "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	if len(parts) != 6 || parts[2] != "releases" || parts[3] != "download" {
+		return "", "", "", false
+	}
+	tag, err1 := url.PathUnescape(parts[4])
+	asset, err2 := url.PathUnescape(parts[5])
+	if err1 != nil || err2 != nil || tag == "" || asset == "" {
+		return "", "", "", false
+	}
+	return parts[0] + "/" + parts[1], tag, asset, true
+}
+
+// githubAssetDigest 查询 GitHub 发布资源公布的 SHA256 摘要。地址不是 GitHub 发布资源或
+// 发布版本未公布 sha256 摘要时返回空串，由调用方按未提供哈希处理
+func (h *PluginHost) githubAssetDigest(downloadURL string) (string, error) {
+	repo, tag, asset, ok := parseGitHubAssetURL(downloadURL)
+	if !ok {
+		return "", nil
+	}
+	rel, err := h.fetchGitRelease(repo, tag)
+	if err != nil {
+		return "", err
+	}
+	for _, a := range rel.Assets {
+		if a.Name != asset {
+			continue
+		}
+		digest, ok := strings.CutPrefix(a.Digest, "sha256:")
+		if !ok {
+			return "", nil
+		}
+		if len(digest) != sha256.Size*2 {
+			return "", fmt.Errorf("invalid digest for %s: %q", asset, a.Digest)
+		}
+		return strings.ToLower(digest), nil
+	}
+	return "", fmt.Errorf("release %s of %s has no %s asset", tag, repo, asset)
+}
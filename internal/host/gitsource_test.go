@@ -0,0 +1,113 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveGitHubAPI 模拟 GitHub 发布版本接口，releases 的键为标签，"latest" 表示最新发布
+func serveGitHubAPI(t *testing.T, repo string, releases map[string]string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tag, ok := strings.CutPrefix(r.URL.Path, "/repos/"+repo+"/releases/tags/")
+		if !ok && r.URL.Path == "/repos/"+repo+"/releases/latest" {
+			tag, ok = "latest", true
+		}
+		assetURL, found := releases[tag]
+		if !ok || !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"tag_name": tag,
+			"assets": []map[string]string{
+				{"name": "README.md", "browser_download_url": "https://github.com/readme"},
+				{"name": gitManifestAsset, "browser_download_url": assetURL},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// postGitInstall 以 git 来源向 /market 提交安装请求
+func postGitInstall(t *testing.T, h *PluginHost, id, repo, ref string) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"id": id, "source": InstallSourceGit, "repo": repo, "ref": ref})
+	w := httptest.NewRecorder()
+	h.handleMarket(w, httptest.NewRequest(http.MethodPost, "/market", strings.NewReader(string(body))))
+	return w
+}
+
+func TestResolveGitRelease(t *testing.T) {
+	api := serveGitHubAPI(t, "acme/demo", map[string]string{
+		"v1.0.0": "https://github.com/acme/demo/releases/download/v1.0.0/manifest.json",
+		"latest": "https://github.com/acme/demo/releases/download/v2.0.0/manifest.json",
+	})
+	h := newTestHost(t, Config{GitHubAPIURL: api})
+
+	if got, err := h.resolveGitRelease("acme/demo", "v1.0.0", gitManifestAsset); err != nil || !strings.HasSuffix(got, "/v1.0.0/manifest.json") {
+		t.Errorf("tagged release = %q, %v", got, err)
+	}
+	if got, err := h.resolveGitRelease("acme/demo", "", gitManifestAsset); err != nil || !strings.HasSuffix(got, "/v2.0.0/manifest.json") {
+		t.Errorf("latest release = %q, %v", got, err)
+	}
+	if _, err := h.resolveGitRelease("acme/demo", "v9.9.9", gitManifestAsset); err == nil || !strings.Contains(err.Error(), "release not found") {
+		t.Errorf("missing tag: err = %v", err)
+	}
+	if _, err := h.resolveGitRelease("acme/demo", "v1.0.0", "plugin.zip"); err == nil {
+		t.Error("missing asset: expected error")
+	}
+	for _, repo := range []string{"acme", "acme/demo/extra", "../etc/passwd", "acme/.."} {
+		if _, err := h.resolveGitRelease(repo, "", gitManifestAsset); err == nil {
+			t.Errorf("repo %q: expected error", repo)
+		}
+	}
+}
+
+func TestInstallFromGitSource(t *testing.T) {
+	manifestURL := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	api := serveGitHubAPI(t, "acme/demo", map[string]string{"v1.0.0": manifestURL})
+	h := newTestHost(t, Config{GitHubAPIURL: api})
+
+	if w := postGitInstall(t, h, "demo", "acme/demo", "v1.0.0"); w.Code != http.StatusCreated {
+		t.Fatalf("git install: got %d %s", w.Code, w.Body.String())
+	}
+	if p, ok := h.getPlugin("demo"); !ok || p.Manifest.Version != "1.0.0" {
+		t.Fatalf("plugin not installed from git source: %+v", p)
+	}
+
+	w := postGitInstall(t, h, "demo", "acme/demo", "v9.9.9")
+	var resp struct {
+		Error marketError `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusBadRequest || resp.Error.Code != InstallErrGitResolve {
+		t.Errorf("unknown tag: got %d %+v, want %s", w.Code, resp.Error, InstallErrGitResolve)
+	}
+}
+
+func TestInstallFromGitSourceEnforcesDomainAllowList(t *testing.T) {
+	api := serveGitHubAPI(t, "acme/demo", map[string]string{"v1.0.0": "https://evil.example.com/manifest.json"})
+	h := newTestHost(t, Config{GitHubAPIURL: api})
+
+	w := postGitInstall(t, h, "demo", "acme/demo", "v1.0.0")
+	var resp struct {
+		Errors []ValidationError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusBadRequest || len(resp.Errors) != 1 || resp.Errors[0].Code != "DOMAIN_NOT_ALLOWED" {
+		t.Errorf("resolved URL outside allow-list: got %d %+v", w.Code, resp.Errors)
+	}
+	if _, ok := h.getPlugin("demo"); ok {
+		t.Error("plugin installed from a disallowed domain")
+	}
+}
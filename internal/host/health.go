@@ -0,0 +1,161 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultProbeTimeout 未配置 ProbeTimeout 时单次探测的超时时间
+const defaultProbeTimeout = 5 * time.Second
+
+// PluginHealth 插件后端的一次健康探测结果
+type PluginHealth struct {
+	PluginID  string    `json:"pluginId"`
+	Healthy   bool      `json:"healthy"`
+	Status    int       `json:"status,omitempty"`
+	LatencyMs int64     `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// backendHealthURL 返回插件后端的健康检查地址：优先使用清单声明的 health，
+// 否则为 backend 地址下的 /healthz；backend 不是 http(s) 地址时返回 false
+func backendHealthURL(m Manifest) (string, bool) {
+	if m.Entrypoints == nil || m.Entrypoints.Backend == "" {
+		return "", false
+	}
+	base, err := url.Parse(m.Entrypoints.Backend)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return "", false
+	}
+	if m.Entrypoints.Health != "" {
+		ref, err := url.Parse(m.Entrypoints.Health)
+		if err != nil {
+			return "", false
+		}
+		return base.ResolveReference(ref).String(), true
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + "/healthz"
+	return base.String(), true
+}
+
+// probeTimeout 返回生效的单次探测超时时间
+func (h *PluginHost) probeTimeout() time.Duration {
+	if h.config.ProbeTimeout > 0 {
+		return h.config.ProbeTimeout
+	}
+	return defaultProbeTimeout
+}
+
+// probeClient 创建健康探测使用的HTTP客户端，与插件下载共用出站传输配置和内部地址检查
+func (h *PluginHost) probeClient() (*http.Client, error) {
+	transport, err := h.outboundTransport()
+	if err != nil {
+		return nil, err
+	}
+	client := newDownloadClient(NewPluginValidator(h.securityConfig()), transport)
+	client.Timeout = h.probeTimeout()
+	return client, nil
+}
+
+// pingPlugin 请求插件后端的健康检查地址，2xx 视为健康
+func (h *PluginHost) pingPlugin(ctx context.Context, pluginID string) (*PluginHealth, error) {
+	p, ok := h.getPlugin(pluginID)
+	if !ok {
+		return nil, fmt.Errorf("plugin not found: %s", pluginID)
+	}
+	h.pluginsMu.RLock()
+	target, ok := backendHealthURL(p.Manifest)
+	h.pluginsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("plugin %s has no http backend entrypoint", pluginID)
+	}
+
+	client, err := h.probeClient()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.probeTimeout())
+	defer cancel()
+
+	result := &PluginHealth{PluginID: pluginID}
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.CheckedAt = time.Now()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	result.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !result.Healthy {
+		result.Error = fmt.Sprintf("unexpected status: %d", resp.StatusCode)
+	}
+	return result, nil
+}
+
+// StartHealthProber 按固定间隔探测已启用插件的后端，直到 ctx 结束；
+// 后端由健康变为无响应时广播 plugin.unhealthy，恢复后广播 plugin.healthy
+func (h *PluginHost) StartHealthProber(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		healthy := make(map[string]bool)
+		for {
+			h.probePlugins(ctx, healthy)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// probePlugins 探测一轮并根据状态变化广播事件，healthy 记录上一轮的结果
+func (h *PluginHost) probePlugins(ctx context.Context, healthy map[string]bool) {
+	h.pluginsMu.RLock()
+	ids := make([]string, 0, len(h.plugins))
+	for id, p := range h.plugins {
+		if _, ok := backendHealthURL(p.Manifest); ok && p.Enabled {
+			ids = append(ids, id)
+		}
+	}
+	h.pluginsMu.RUnlock()
+
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+		result, err := h.pingPlugin(ctx, id)
+		if err != nil {
+			// 插件在探测期间被卸载或修改
+			continue
+		}
+		was, known := healthy[id]
+		healthy[id] = result.Healthy
+		switch {
+		case !result.Healthy && (!known || was):
+			log.Printf("plugin %s backend unhealthy: %s", id, result.Error)
+			h.Broadcast(Event{Type: "plugin.unhealthy", Data: result})
+		case result.Healthy && known && !was:
+			h.Broadcast(Event{Type: "plugin.healthy", Data: result})
+		}
+	}
+	for id := range healthy {
+		if !seen[id] {
+			delete(healthy, id)
+		}
+	}
+}
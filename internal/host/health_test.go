@@ -0,0 +1,52 @@
+package host
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func addBackendPlugin(h *PluginHost, id, backend string) {
+	h.pluginsMu.Lock()
+	defer h.pluginsMu.Unlock()
+	h.plugins[id] = &Plugin{
+		Manifest: Manifest{ID: id, Name: id, Version: "1.0.0", Entrypoints: &Entrypoints{Backend: backend}},
+		Enabled:  true,
+	}
+}
+
+func TestPingPluginHealthy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	h := newTestHost(t, Config{})
+	addBackendPlugin(h, "svc", srv.URL)
+	result, err := h.pingPlugin(context.Background(), "svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Healthy || result.Status != 200 {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestPingPluginUsesDialChecks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// 未允许本地地址时，探测与下载一样拒绝连接回环地址
+	h := newTestHost(t, Config{Security: &SecurityConfig{}})
+	addBackendPlugin(h, "svc", srv.URL)
+	result, err := h.pingPlugin(context.Background(), "svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Healthy || result.Error == "" {
+		t.Fatalf("probe to loopback should be rejected, got %+v", result)
+	}
+}
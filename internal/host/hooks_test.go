@@ -0,0 +1,66 @@
+package host
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreInstallHookVetoesInstall(t *testing.T) {
+	var called int
+	h := newTestHost(t, Config{PreInstall: func(pluginID string, m Manifest) error {
+		called++
+		return errors.New("not on the approved list")
+	}})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+
+	err := h.installPluginFromURL("demo", url, "", "", nil)
+	var installErr *InstallError
+	if !errors.As(err, &installErr) || installErr.Code != InstallErrHookRejected {
+		t.Fatalf("err = %v, want %s", err, InstallErrHookRejected)
+	}
+	if called != 1 {
+		t.Errorf("pre-install hook called %d times, want 1", called)
+	}
+	if _, ok := h.getPlugin("demo"); ok {
+		t.Error("vetoed plugin was registered")
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "demo")); !os.IsNotExist(err) {
+		t.Errorf("vetoed plugin was written to disk: %v", err)
+	}
+}
+
+func TestInstallAndUninstallHooksObserveManifest(t *testing.T) {
+	var installed, uninstalled []Manifest
+	h := newTestHost(t, Config{
+		PreInstall: func(pluginID string, m Manifest) error { return nil },
+		OnInstall: func(pluginID string, m Manifest) error {
+			installed = append(installed, m)
+			// 安装后回调的错误只记录日志
+			return errors.New("indexer offline")
+		},
+		OnUninstall: func(pluginID string, m Manifest) error {
+			uninstalled = append(uninstalled, m)
+			return nil
+		},
+	})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.2.0", Permissions: []string{"vault.read"}})
+
+	if err := h.installPluginFromURL("demo", url, "", "", nil); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if len(installed) != 1 || installed[0].ID != "demo" || installed[0].Version != "1.2.0" || len(installed[0].Permissions) != 1 {
+		t.Fatalf("install hook saw %+v", installed)
+	}
+	if _, ok := h.getPlugin("demo"); !ok {
+		t.Fatal("install hook error failed the install")
+	}
+
+	if err := h.uninstallPlugin("demo", UninstallOptions{SkipBackup: true}); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	if len(uninstalled) != 1 || uninstalled[0].ID != "demo" || uninstalled[0].Version != "1.2.0" {
+		t.Errorf("uninstall hook saw %+v", uninstalled)
+	}
+}
@@ -1,16 +1,23 @@
 package host
 
 import (
-	"archive/zip"
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"io/fs"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrVaultQuotaExceeded 写入会超出存储库容量上限
+var ErrVaultQuotaExceeded = errors.New("vault quota exceeded")
+
 type PluginHost struct {
 	config         Config
 	pluginsMu      sync.RWMutex
@@ -19,15 +26,94 @@ type PluginHost struct {
     commands       map[string]Command
     eventHub       *EventHub
     installManager *InstallationManager
+    pluginLocks    *keyedMutex
+    usageMu        sync.Mutex
+    usage          *DiskUsage
+    auditMu        sync.Mutex
+    updatesMu      sync.RWMutex
+    updates        []PluginUpdate
+    rpcMethods     map[string]rpcHandler
+    terminalMu     sync.Mutex
+    terminal       []terminalEvent
+    invocationsMu  sync.Mutex
+    invocations    map[string]*pendingInvocation
+    kvMu           sync.Mutex
+    webhooks       []*webhook
+    eventLogMu     sync.Mutex
+    eventSeq       uint64
+    readOnly       atomic.Bool
+    vault          VaultStore
+    rpcStatsMu     sync.Mutex
+    rpcStats       map[string]*pluginRPCCounter
+    processesMu    sync.Mutex
+    processes      map[string]*managedProcess
+    vaultWatchMu   sync.Mutex
+    vaultWatchers  map[*vaultWatcher]struct{}
+    vaultWatchStop chan struct{}
+    startedAt      time.Time
 }
 
 func NewPluginHost(cfg Config) *PluginHost {
-	return &PluginHost{
+	h := &PluginHost{
 		config:  cfg,
         plugins: make(map[string]*Plugin),
         commands: make(map[string]Command),
         eventHub: NewEventHub(),
+        installManager: NewInstallationManager(3),
+        pluginLocks: newKeyedMutex(),
+        invocations: make(map[string]*pendingInvocation),
+        webhooks: newWebhooks(cfg.Webhooks),
+        startedAt: time.Now(),
+	}
+	h.readOnly.Store(cfg.ReadOnly)
+	if err := h.installManager.Restore(h.installationStore()); err != nil {
+		log.Printf("installations: restore records: %v", err)
+	}
+	if len(cfg.PluginKeys) == 0 {
+		log.Printf("warning: no plugin keys configured; plugins are identified only by the pluginId they claim and cannot authenticate, so host operations targeting a plugin require the admin token")
+	}
+	if cfg.InsecureSkipTLSVerify {
+		log.Printf("warning: TLS certificate verification is disabled for plugin downloads and market fetches")
+	}
+	h.vault = cfg.VaultStore
+	if h.vault == nil {
+		fsVault := NewFSVaultStore(cfg.VaultDir)
+		fsVault.FileMode = cfg.FileMode
+		fsVault.DirMode = cfg.DirMode
+		h.vault = fsVault
+	}
+	h.registerRPCMethods()
+	return h
+}
+
+// EnsureDirs 创建插件、存储库、备份和下载暂存目录，路径被普通文件占用或无法创建时返回错误
+func (h *PluginHost) EnsureDirs() error {
+	dirs := []struct {
+		name string
+		path string
+	}{
+		{"plugins", h.config.PluginsDir},
+		{"vault", h.config.VaultDir},
+		{"backups", h.backupDir()},
+		{"temp", h.tempDir()},
+		{"staging", h.stagingDir()},
 	}
+	for _, d := range dirs {
+		info, err := os.Stat(d.path)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s path %s exists but is not a directory", d.name, d.path)
+			}
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("%s path %s: %w", d.name, d.path, err)
+		}
+		if err := os.MkdirAll(d.path, 0o755); err != nil {
+			return fmt.Errorf("create %s directory: %w", d.name, err)
+		}
+	}
+	return nil
 }
 
 func (h *PluginHost) LoadPlugins() error {
@@ -43,25 +129,36 @@ func (h *PluginHost) LoadPlugins() error {
 		if !e.IsDir() {
 			continue
 		}
-		manifestPath := filepath.Join(dir, e.Name(), "manifest.json")
-		manifestBytes, err := os.ReadFile(manifestPath)
+		m, _, err := h.readPluginManifest(filepath.Join(dir, e.Name()))
 		if err != nil {
 			continue
 		}
-		var m Manifest
-		if err := json.Unmarshal(manifestBytes, &m); err != nil {
-			continue
-		}
 		if m.ID == "" || m.Name == "" || m.Version == "" {
 			continue
 		}
+		m.Tags = normalizeTags(m.Tags)
+		unlock := h.pluginLocks.Lock(m.ID)
 		h.pluginsMu.Lock()
-		h.plugins[m.ID] = &Plugin{Manifest: m, Enabled: true} // 默认启用
+		h.plugins[m.ID] = &Plugin{
+			Manifest:           m,
+			Enabled:            h.checkCompatible(m) == nil, // 默认启用，缺少依赖的特性时禁用
+			PendingPermissions: h.loadPendingPermissions(m.ID),
+		}
 		h.pluginsMu.Unlock()
+		h.syncManifestCommands(m)
+		unlock()
 	}
 	return nil
 }
 
+// securityConfig 返回生效的安全配置
+func (h *PluginHost) securityConfig() SecurityConfig {
+	if h.config.Security != nil {
+		return *h.config.Security
+	}
+	return DefaultSecurityConfig()
+}
+
 func (h *PluginHost) CountPlugins() int {
 	h.pluginsMu.RLock()
 	defer h.pluginsMu.RUnlock()
@@ -75,6 +172,16 @@ func (h *PluginHost) getPlugin(id string) (*Plugin, bool) {
 	return p, ok
 }
 
+// isTrustedPlugin 判断插件是否在 Config.TrustedPlugins 中
+func (h *PluginHost) isTrustedPlugin(pluginID string) bool {
+	for _, id := range h.config.TrustedPlugins {
+		if id == pluginID {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *PluginHost) hasPermission(pluginID, perm string) bool {
 	if pluginID == "" {
 		return false
@@ -83,7 +190,17 @@ func (h *PluginHost) hasPermission(pluginID, perm string) bool {
 	if !ok {
 		return false
 	}
-	for _, pstr := range p.Manifest.Permissions {
+	if h.isTrustedPlugin(pluginID) {
+		return true
+	}
+	for _, pstr := range h.config.DefaultPermissions {
+		if pstr == perm {
+			return true
+		}
+	}
+	h.pluginsMu.RLock()
+	defer h.pluginsMu.RUnlock()
+	for _, pstr := range grantedPermissions(p) {
 		if pstr == perm || pstr == "*" {
 			return true
 		}
@@ -91,55 +208,135 @@ func (h *PluginHost) hasPermission(pluginID, perm string) bool {
 	return false
 }
 
-func (h *PluginHost) listVaultFiles() ([]string, error) {
-	root := h.config.VaultDir
-	var paths []string
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil
+// listVaultFiles 列出存储库中的文件，ctx 取消时中止遍历并返回 ctx.Err()
+func (h *PluginHost) listVaultFiles(ctx context.Context) ([]string, error) {
+	return h.vault.List(ctx)
+}
+
+func (h *PluginHost) readVaultFile(relPath string) ([]byte, error) {
+	f, err := h.vault.Read(relPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func (h *PluginHost) writeVaultFile(relPath string, data []byte) error {
+	if err := h.checkVaultPath(relPath); err != nil {
+		return err
+	}
+	if err := h.checkVaultWrite(relPath, data); err != nil {
+		return err
+	}
+	if err := h.checkVaultQuota(relPath, int64(len(data))); err != nil {
+		return err
+	}
+	return h.vault.Write(relPath, bytes.NewReader(data))
+}
+
+// writeVaultStream 将数据流写入存储库，目标文件要么保持原样要么被完整替换。
+// size 为已知的内容长度，未知时传入 -1；长度未知或配置了写入策略时先暂存到临时文件
+func (h *PluginHost) writeVaultStream(relPath string, r io.Reader, size int64) error {
+	if err := h.checkVaultPath(relPath); err != nil {
+		return err
+	}
+	if size >= 0 {
+		if err := h.checkVaultQuota(relPath, size); err != nil {
+			return err
 		}
-		if d.IsDir() {
-			return nil
+	}
+	if size >= 0 && h.config.VaultWritePolicy == nil {
+		return h.vault.Write(relPath, r)
+	}
+
+	tmp, err := os.CreateTemp(h.tempDir(), ".vault-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		return err
+	}
+	if size < 0 {
+		if err := h.checkVaultQuota(relPath, n); err != nil {
+			return err
 		}
-		rel, err := filepath.Rel(root, path)
-		if err != nil {
-			return nil
+	}
+	if h.config.VaultWritePolicy != nil {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := h.checkVaultWriteStream(relPath, tmp); err != nil {
+			return err
 		}
-		paths = append(paths, rel)
-		return nil
-	})
-	if os.IsNotExist(err) {
-		return []string{}, nil
 	}
-	return paths, err
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return h.vault.Write(relPath, tmp)
 }
 
-func (h *PluginHost) readVaultFile(relPath string) ([]byte, error) {
-	root := h.config.VaultDir
-	path := filepath.Join(root, filepath.Clean(relPath))
-	data, err := os.ReadFile(path)
-	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("not found")
+// vaultUsage 返回存储库已用空间，后端未实现 Usage 时逐个统计文件大小，ctx 取消时中止统计
+func (h *PluginHost) vaultUsage(ctx context.Context) (int64, error) {
+	if u, ok := h.vault.(vaultUsager); ok {
+		return u.Usage()
+	}
+	paths, err := h.vault.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var used int64
+	for _, p := range paths {
+		info, err := h.vault.Stat(p)
+		if err != nil {
+			continue
+		}
+		used += info.Size
 	}
-	return data, err
+	return used, nil
 }
 
-func (h *PluginHost) writeVaultFile(relPath string, data []byte) error {
-	root := h.config.VaultDir
-	path := filepath.Join(root, filepath.Clean(relPath))
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+// checkVaultQuota 检查写入后是否超出容量上限，覆盖已有文件时只计算增量
+func (h *PluginHost) checkVaultQuota(relPath string, size int64) error {
+	quota := h.config.VaultQuotaBytes
+	if quota <= 0 {
+		return nil
+	}
+	used, err := h.vaultUsage(context.Background())
+	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0o644)
+	if info, err := h.vault.Stat(relPath); err == nil {
+		used -= info.Size
+	}
+	if used+size > quota {
+		h.Broadcast(Event{Type: "vault.quota.exceeded", Data: map[string]any{
+			"used":      used,
+			"requested": size,
+			"quota":     quota,
+		}})
+		return fmt.Errorf("%w: %d + %d bytes exceeds %d", ErrVaultQuotaExceeded, used, size, quota)
+	}
+	return nil
 }
 
+// listCommands 返回全部命令，按 (pluginId, id) 排序，多次调用顺序一致
 func (h *PluginHost) listCommands() []Command {
     h.commandsMu.RLock()
-    defer h.commandsMu.RUnlock()
     cmds := make([]Command, 0, len(h.commands))
     for _, c := range h.commands {
         cmds = append(cmds, c)
     }
+    h.commandsMu.RUnlock()
+    sort.Slice(cmds, func(i, j int) bool {
+        if cmds[i].PluginID != cmds[j].PluginID {
+            return cmds[i].PluginID < cmds[j].PluginID
+        }
+        return cmds[i].ID < cmds[j].ID
+    })
     return cmds
 }
 
@@ -150,6 +347,40 @@ func (h *PluginHost) registerCommand(c Command) {
     h.commandsMu.Unlock()
 }
 
+// syncManifestCommands 用清单声明的命令替换插件之前由清单注册的命令，运行时注册的命令保持不变
+func (h *PluginHost) syncManifestCommands(m Manifest) {
+    h.commandsMu.Lock()
+    defer h.commandsMu.Unlock()
+    for key, c := range h.commands {
+        if c.PluginID == m.ID && c.Source == CommandSourceManifest {
+            delete(h.commands, key)
+        }
+    }
+    for _, mc := range m.Commands {
+        h.commands[m.ID+":"+mc.ID] = Command{
+            ID:       mc.ID,
+            Title:    mc.Title,
+            PluginID: m.ID,
+            Category: mc.Category,
+            Hotkey:   mc.Hotkey,
+            Source:   CommandSourceManifest,
+
+            InvokePermission: mc.InvokePermission,
+        }
+    }
+}
+
+// removePluginCommands 删除插件注册的全部命令
+func (h *PluginHost) removePluginCommands(pluginID string) {
+    h.commandsMu.Lock()
+    defer h.commandsMu.Unlock()
+    for key, c := range h.commands {
+        if c.PluginID == pluginID {
+            delete(h.commands, key)
+        }
+    }
+}
+
 func (h *PluginHost) invokeCommand(pluginID, commandID string) bool {
     key := pluginID + ":" + commandID
     h.commandsMu.RLock()
@@ -165,6 +396,8 @@ func (h *PluginHost) Broadcast(ev Event) {
     if h.eventHub != nil {
         h.eventHub.Broadcast(ev)
     }
+    h.recordEvent(ev)
+    h.dispatchWebhooks(ev)
 }
 
 // enablePlugin 启用插件
@@ -176,14 +409,20 @@ func (h *PluginHost) enablePlugin(pluginID string) error {
     if !exists {
         return fmt.Errorf("plugin not found: %s", pluginID)
     }
+    if err := h.checkCompatible(plugin.Manifest); err != nil {
+        return err
+    }
     
     plugin.Enabled = true
+    h.startPluginProcess(plugin.Manifest)
     h.Broadcast(Event{Type: "plugin.enabled", Data: map[string]string{"pluginId": pluginID}})
     return nil
 }
 
 // disablePlugin 禁用插件
 func (h *PluginHost) disablePlugin(pluginID string) error {
+    // 后端进程在释放锁之后停止，停止时需要等待进程退出
+    defer h.stopPluginProcess(pluginID)
     h.pluginsMu.Lock()
     defer h.pluginsMu.Unlock()
     
@@ -197,8 +436,55 @@ func (h *PluginHost) disablePlugin(pluginID string) error {
     return nil
 }
 
-// backupPlugin 备份插件到zip文件
+// batchSetEnabled 批量启用或禁用插件，单个失败不影响其余插件
+func (h *PluginHost) batchSetEnabled(pluginIDs []string, enabled bool) []BatchResult {
+    results := make([]BatchResult, 0, len(pluginIDs))
+    for _, id := range pluginIDs {
+        var err error
+        if enabled {
+            err = h.enablePlugin(id)
+        } else {
+            err = h.disablePlugin(id)
+        }
+        results = append(results, newBatchResult(id, err))
+    }
+    return results
+}
+
+// batchUninstall 按相同的卸载选项批量卸载插件，单个失败不影响其余插件
+func (h *PluginHost) batchUninstall(pluginIDs []string, opts UninstallOptions) []BatchResult {
+    results := make([]BatchResult, 0, len(pluginIDs))
+    for _, id := range pluginIDs {
+        var err error
+        if _, ok := h.getPlugin(id); !ok {
+            err = fmt.Errorf("plugin not found: %s", id)
+        } else {
+            err = h.uninstallPlugin(id, opts)
+        }
+        results = append(results, newBatchResult(id, err))
+    }
+    return results
+}
+
+func newBatchResult(pluginID string, err error) BatchResult {
+    if err != nil {
+        return BatchResult{PluginID: pluginID, Error: err.Error()}
+    }
+    return BatchResult{PluginID: pluginID, Ok: true}
+}
+
+// backupDir 返回备份文件所在目录
+func (h *PluginHost) backupDir() string {
+    return filepath.Join(h.config.RootDir, "backups")
+}
+
+// backupPlugin 备份插件到备份目录下的zip文件
 func (h *PluginHost) backupPlugin(pluginID string) (string, error) {
+    return h.backupPluginTo(pluginID, h.backupDir())
+}
+
+// backupPluginTo 备份插件到 backupDir 下的zip文件
+func (h *PluginHost) backupPluginTo(pluginID, backupDir string) (string, error) {
     h.pluginsMu.RLock()
     plugin, exists := h.plugins[pluginID]
     h.pluginsMu.RUnlock()
@@ -208,7 +494,6 @@ func (h *PluginHost) backupPlugin(pluginID string) (string, error) {
     }
     
     // 创建备份目录
-    backupDir := filepath.Join(h.config.RootDir, "backups")
     if err := os.MkdirAll(backupDir, 0o755); err != nil {
         return "", fmt.Errorf("failed to create backup directory: %w", err)
     }
@@ -223,43 +508,13 @@ func (h *PluginHost) backupPlugin(pluginID string) (string, error) {
     if err != nil {
         return "", fmt.Errorf("failed to create backup file: %w", err)
     }
-    defer zipFile.Close()
-    
-    zipWriter := zip.NewWriter(zipFile)
-    defer zipWriter.Close()
     
     // 添加插件目录中的所有文件到zip
     pluginDir := filepath.Join(h.config.PluginsDir, pluginID)
-    err = filepath.WalkDir(pluginDir, func(path string, d fs.DirEntry, err error) error {
-        if err != nil {
-            return err
-        }
-        
-        if d.IsDir() {
-            return nil
-        }
-        
-        // 获取相对路径
-        relPath, err := filepath.Rel(pluginDir, path)
-        if err != nil {
-            return err
-        }
-        
-        // 读取文件内容
-        fileData, err := os.ReadFile(path)
-        if err != nil {
-            return err
-        }
-        
-        // 添加到zip
-        zipFileWriter, err := zipWriter.Create(relPath)
-        if err != nil {
-            return err
-        }
-        
-        _, err = zipFileWriter.Write(fileData)
-        return err
-    })
+    _, err = h.writePluginBackup(zipFile, pluginID, pluginDir)
+    if cerr := zipFile.Close(); err == nil {
+        err = cerr
+    }
     
     if err != nil {
         os.Remove(backupPath) // 清理失败的备份文件
@@ -0,0 +1,313 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestHost 创建所有目录都位于临时目录中的宿主
+func newTestHost(t *testing.T, cfg Config) *PluginHost {
+	t.Helper()
+	root := t.TempDir()
+	if cfg.RootDir == "" {
+		cfg.RootDir = root
+	}
+	if cfg.VaultDir == "" {
+		cfg.VaultDir = filepath.Join(root, "vault")
+	}
+	if cfg.PluginsDir == "" {
+		cfg.PluginsDir = filepath.Join(root, "plugins")
+	}
+	h := NewPluginHost(cfg)
+	if err := h.EnsureDirs(); err != nil {
+		t.Fatalf("EnsureDirs: %v", err)
+	}
+	return h
+}
+
+// addTestPlugin 写入插件目录和清单，并直接登记为已启用的插件
+func addTestPlugin(t *testing.T, h *PluginHost, id string, perms ...string) {
+	t.Helper()
+	m := Manifest{ID: id, Name: id, Version: "1.0.0", Permissions: perms}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(h.config.PluginsDir, id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h.pluginsMu.Lock()
+	defer h.pluginsMu.Unlock()
+	h.plugins[id] = &Plugin{Manifest: m, Enabled: true}
+}
+
+// callRPC 通过 handleRPC 调用方法，返回 HTTP 状态码和解码后的响应
+func callRPC(t *testing.T, h *PluginHost, pluginID, method string, params any) (int, rpcResponse) {
+	t.Helper()
+	return callRPCWithHeader(t, h, nil, pluginID, method, params)
+}
+
+// callRPCWithHeader 与 callRPC 相同，并附带请求头（如管理员令牌、插件密钥）
+func callRPCWithHeader(t *testing.T, h *PluginHost, header http.Header, pluginID, method string, params any) (int, rpcResponse) {
+	t.Helper()
+	req := map[string]any{"id": "1", "method": method, "pluginId": pluginID}
+	if params != nil {
+		req["params"] = params
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", "/rpc", strings.NewReader(string(body)))
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.handleRPC(w, r)
+	var resp rpcResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s: decode response %q: %v", method, w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+// subscribeEvents 在事件中心登记一个订阅，测试结束时注销
+func subscribeEvents(t *testing.T, h *PluginHost) *sseClient {
+	t.Helper()
+	c := &sseClient{ch: make(chan []byte, 256), done: make(chan struct{})}
+	h.eventHub.addClient(c, 0)
+	t.Cleanup(func() { h.eventHub.removeClient(c) })
+	return c
+}
+
+// receivedEvents 取出订阅中已收到的事件，Data 解码为 map
+func receivedEvents(t *testing.T, c *sseClient) []Event {
+	t.Helper()
+	var events []Event
+	for {
+		select {
+		case msg := <-c.ch:
+			var ev Event
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(string(msg), "data: "))), &ev); err != nil {
+				t.Fatalf("decode event %q: %v", msg, err)
+			}
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+// eventTypes 返回事件类型列表
+func eventTypes(events []Event) []string {
+	types := make([]string, len(events))
+	for i, ev := range events {
+		types[i] = ev.Type
+	}
+	return types
+}
+
+// batchResults 解码批量操作RPC的结果
+func batchResults(t *testing.T, resp rpcResponse) []BatchResult {
+	t.Helper()
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []BatchResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		t.Fatalf("decode batch results %s: %v", data, err)
+	}
+	return results
+}
+
+func TestBatchSetEnabled(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "a")
+	addTestPlugin(t, h, "b")
+	events := subscribeEvents(t, h)
+
+	code, resp := callRPC(t, h, "", "host.batchSetEnabled", map[string]any{"pluginIds": []string{"a", "missing", "b"}, "enabled": false})
+	if code != 200 {
+		t.Fatalf("host.batchSetEnabled: got %d %+v", code, resp.Error)
+	}
+	results := batchResults(t, resp)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for i, want := range []BatchResult{{PluginID: "a", Ok: true}, {PluginID: "missing"}, {PluginID: "b", Ok: true}} {
+		got := results[i]
+		if got.PluginID != want.PluginID || got.Ok != want.Ok || (got.Error == "") != want.Ok {
+			t.Errorf("result %d = %+v, want %+v", i, got, want)
+		}
+	}
+	for _, id := range []string{"a", "b"} {
+		if p, _ := h.getPlugin(id); p.Enabled {
+			t.Errorf("%s still enabled", id)
+		}
+	}
+	disabled := 0
+	for _, ev := range receivedEvents(t, events) {
+		if ev.Type == "plugin.disabled" {
+			disabled++
+		}
+	}
+	if disabled != 2 {
+		t.Errorf("got %d plugin.disabled events, want 2", disabled)
+	}
+
+	if code, _ := callRPC(t, h, "", "host.batchSetEnabled", map[string]any{"pluginIds": []string{"a"}}); code != 400 {
+		t.Errorf("missing enabled: got %d, want 400", code)
+	}
+}
+
+func TestBatchUninstall(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "a")
+	addTestPlugin(t, h, "b")
+
+	code, resp := callRPC(t, h, "", "host.batchUninstall", map[string]any{"pluginIds": []string{"missing", "a"}, "skipBackup": true})
+	if code != 200 {
+		t.Fatalf("host.batchUninstall: got %d %+v", code, resp.Error)
+	}
+	results := batchResults(t, resp)
+	if len(results) != 2 || results[0].Ok || results[0].Error == "" || !results[1].Ok {
+		t.Fatalf("results = %+v", results)
+	}
+	if _, ok := h.getPlugin("a"); ok {
+		t.Error("a should be uninstalled")
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "a")); !os.IsNotExist(err) {
+		t.Errorf("a's directory should be removed, stat err = %v", err)
+	}
+	if _, ok := h.getPlugin("b"); !ok {
+		t.Error("b should be untouched")
+	}
+}
+
+func TestEnsureDirsCreatesMissingDirs(t *testing.T) {
+	root := t.TempDir()
+	h := NewPluginHost(Config{RootDir: root, PluginsDir: filepath.Join(root, "a", "plugins"), VaultDir: filepath.Join(root, "b", "vault")})
+	if err := h.EnsureDirs(); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{h.config.PluginsDir, h.config.VaultDir, h.backupDir(), h.tempDir(), h.stagingDir()} {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("%s not created: %v", dir, err)
+		}
+	}
+	// 目录已存在时再次调用不报错
+	if err := h.EnsureDirs(); err != nil {
+		t.Errorf("second EnsureDirs: %v", err)
+	}
+}
+
+func TestEnsureDirsFileCollision(t *testing.T) {
+	root := t.TempDir()
+	vault := filepath.Join(root, "vault")
+	if err := os.WriteFile(vault, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := NewPluginHost(Config{RootDir: root, PluginsDir: filepath.Join(root, "plugins"), VaultDir: vault})
+	err := h.EnsureDirs()
+	if err == nil || !strings.Contains(err.Error(), "not a directory") || !strings.Contains(err.Error(), "vault") {
+		t.Fatalf("EnsureDirs = %v, want a vault not-a-directory error", err)
+	}
+}
+
+func TestEnsureDirsCreateFailure(t *testing.T) {
+	root := t.TempDir()
+	blocker := filepath.Join(root, "file")
+	if err := os.WriteFile(blocker, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 父路径是普通文件，目录无法创建
+	h := NewPluginHost(Config{RootDir: root, PluginsDir: filepath.Join(blocker, "plugins"), VaultDir: filepath.Join(root, "vault")})
+	if err := h.EnsureDirs(); err == nil || !strings.Contains(err.Error(), "plugins") {
+		t.Fatalf("EnsureDirs = %v, want a plugins directory error", err)
+	}
+}
+
+func TestEnsureDirsPermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	if err := os.Mkdir(locked, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(locked, 0o755) })
+	h := NewPluginHost(Config{RootDir: root, PluginsDir: filepath.Join(root, "plugins"), VaultDir: filepath.Join(locked, "vault")})
+	err := h.EnsureDirs()
+	if err == nil || !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("EnsureDirs = %v, want a permission error", err)
+	}
+}
+
+// listedCommands 通过 commands.list 返回命令列表
+func listedCommands(t *testing.T, h *PluginHost) []Command {
+	t.Helper()
+	code, resp := callRPC(t, h, "", "commands.list", nil)
+	if code != 200 {
+		t.Fatalf("commands.list: got %d %+v", code, resp.Error)
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cmds []Command
+	if err := json.Unmarshal(data, &cmds); err != nil {
+		t.Fatal(err)
+	}
+	return cmds
+}
+
+func TestManifestCommandsRegisteredOnInstall(t *testing.T) {
+	h := newTestHost(t, Config{})
+	v1 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", Permissions: []string{"commands.register"},
+		Commands: []ManifestCommand{{ID: "demo.old", Title: "Old"}, {ID: "demo.run", Title: "Run", Hotkey: "Ctrl+R"}}})
+	if err := h.installPluginFromURL("demo", v1, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cmds := listedCommands(t, h)
+	if len(cmds) != 2 || cmds[1].ID != "demo.run" || cmds[1].PluginID != "demo" || cmds[1].Hotkey != "Ctrl+R" || cmds[1].Source != CommandSourceManifest {
+		t.Fatalf("commands after install = %+v", cmds)
+	}
+
+	// 升级时替换清单命令，运行时注册的命令保留
+	if code, resp := callRPC(t, h, "demo", "commands.register", map[string]any{"id": "demo.dynamic", "title": "Dynamic"}); code != 200 {
+		t.Fatalf("commands.register: got %d %+v", code, resp.Error)
+	}
+	v2 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "2.0.0", Permissions: []string{"commands.register"},
+		Commands: []ManifestCommand{{ID: "demo.run", Title: "Run"}}})
+	if err := h.installPluginFromURL("demo", v2, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, c := range listedCommands(t, h) {
+		ids = append(ids, c.ID)
+	}
+	if strings.Join(ids, ",") != "demo.dynamic,demo.run" {
+		t.Fatalf("commands after upgrade = %v", ids)
+	}
+
+	if err := h.uninstallPlugin("demo", UninstallOptions{SkipBackup: true}); err != nil {
+		t.Fatal(err)
+	}
+	if cmds := listedCommands(t, h); len(cmds) != 0 {
+		t.Errorf("commands after uninstall = %+v", cmds)
+	}
+}
@@ -0,0 +1,289 @@
+package host
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// defaultLanguage 请求的语言没有对应翻译时使用的语言
+const defaultLanguage = "en"
+
+// messageCatalog 按错误码和语言索引的消息模板，模板参数与 ValidationError.Args 对应
+var messageCatalog = map[string]map[string]string{
+	"EMPTY_ID": {
+		"en": "plugin ID must not be empty",
+		"zh": "插件ID不能为空",
+	},
+	"INVALID_ID_FORMAT": {
+		"en": "plugin ID may only contain letters, digits, hyphens and underscores",
+		"zh": "插件ID只能包含字母、数字、连字符和下划线",
+	},
+	"ID_TOO_LONG": {
+		"en": "plugin ID must not exceed 50 characters",
+		"zh": "插件ID长度不能超过50个字符",
+	},
+	"PLUGIN_NOT_ALLOWED": {
+		"en": "plugin %s is not allowed to be installed",
+		"zh": "插件 %s 不允许安装",
+	},
+	"EMPTY_URL": {
+		"en": "download URL must not be empty",
+		"zh": "下载URL不能为空",
+	},
+	"INVALID_URL": {
+		"en": "invalid URL",
+		"zh": "无效的URL格式",
+	},
+	"INSECURE_PROTOCOL": {
+		"en": "only HTTPS download URLs are allowed (except for local development)",
+		"zh": "只允许HTTPS协议的下载链接（本地开发除外）",
+	},
+	"DOMAIN_NOT_ALLOWED": {
+		"en": "domain %s is not in the list of allowed domains",
+		"zh": "域名 %s 不在允许的域名列表中",
+	},
+	"INTERNAL_ADDRESS": {
+		"en": "connecting to internal address %s is not allowed",
+		"zh": "禁止连接内部地址 %s",
+	},
+	"EMPTY_HASH": {
+		"en": "SHA256 hash must not be empty",
+		"zh": "SHA256哈希不能为空",
+	},
+	"INVALID_HASH_FORMAT": {
+		"en": "invalid SHA256 hash format",
+		"zh": "无效的SHA256哈希格式",
+	},
+	"EMPTY_MANIFEST_ID": {
+		"en": "manifest ID must not be empty",
+		"zh": "清单中的ID不能为空",
+	},
+	"EMPTY_MANIFEST_NAME": {
+		"en": "manifest name must not be empty",
+		"zh": "清单中的名称不能为空",
+	},
+	"EMPTY_MANIFEST_VERSION": {
+		"en": "manifest version must not be empty",
+		"zh": "清单中的版本不能为空",
+	},
+	"INVALID_VERSION_FORMAT": {
+		"en": "version must be in x.y.z format",
+		"zh": "版本格式应为 x.y.z 格式",
+	},
+	"TOO_MANY_PERMISSIONS": {
+		"en": "a plugin may not request more than 10 permissions",
+		"zh": "权限数量不能超过10个",
+	},
+	"INVALID_CONFIG_FIELD": {
+		"en": "config field name is empty or duplicated: %q",
+		"zh": "配置项名称为空或重复: %q",
+	},
+	"UNSUPPORTED_CONFIG_TYPE": {
+		"en": "config field %s has unsupported type %q",
+		"zh": "配置项 %s 的类型 %q 不受支持",
+	},
+	"INVALID_CONFIG_DEFAULT": {
+		"en": "default value of config field %s does not match type %s",
+		"zh": "配置项 %s 的默认值与类型 %s 不符",
+	},
+	"INVALID_COMMAND": {
+		"en": "command id or title is empty, or the id is duplicated: %q",
+		"zh": "命令ID或标题为空，或ID重复: %q",
+	},
+	"INVALID_SANDBOX": {
+		"en": "sandbox vault root must be a relative path inside the vault: %q",
+		"zh": "沙箱目录必须是存储库内的相对路径: %q",
+	},
+	"INVALID_LOCALE": {
+		"en": "i18n key is not a valid language tag: %q",
+		"zh": "本地化文本的键不是有效的语言标签: %q",
+	},
+	"INVALID_ICON": {
+		"en": "icon must be a relative path to an image file inside the package: %q",
+		"zh": "图标必须是插件包内的图片文件相对路径: %q",
+	},
+	"INVALID_UPDATE_URL": {
+		"en": "update URL must be an HTTPS address on an allowed domain: %q",
+		"zh": "更新地址必须是允许域名下的HTTPS地址: %q",
+	},
+	"INVALID_ENGINES_SDK": {
+		"en": "engines.sdk is not a valid version range: %q",
+		"zh": "SDK版本范围无效: %q",
+	},
+	InstallErrValidation: {
+		"en": "the install request is invalid",
+		"zh": "安装请求未通过校验",
+	},
+	InstallErrBusy: {
+		"en": "the plugin is already being installed or too many installs are running",
+		"zh": "插件正在安装中或并发安装数已达上限",
+	},
+	InstallErrDownload: {
+		"en": "the plugin package could not be downloaded",
+		"zh": "插件包下载失败",
+	},
+	InstallErrDownloadBlocked: {
+		"en": "the download address is not allowed",
+		"zh": "下载地址不被允许",
+	},
+	InstallErrSizeExceeded: {
+		"en": "the plugin package exceeds the size limit",
+		"zh": "插件包大小超过限制",
+	},
+	InstallErrIntegrity: {
+		"en": "the plugin package failed the integrity check",
+		"zh": "插件包完整性校验失败",
+	},
+	InstallErrSignature: {
+		"en": "the plugin package signature is invalid",
+		"zh": "插件包签名无效",
+	},
+	InstallErrManifest: {
+		"en": "the plugin manifest is invalid",
+		"zh": "插件清单无效",
+	},
+	InstallErrIDMismatch: {
+		"en": "the manifest ID does not match the requested plugin ID",
+		"zh": "清单中的ID与请求的插件ID不一致",
+	},
+	InstallErrWrite: {
+		"en": "the plugin files could not be written",
+		"zh": "插件文件写入失败",
+	},
+	InstallErrIntegrityRequired: {
+		"en": "a SHA256 checksum is required for installs from this source",
+		"zh": "该来源的安装必须提供SHA256校验和",
+	},
+	InstallErrGitResolve: {
+		"en": "the git release could not be resolved to a download URL",
+		"zh": "无法从 Git 发布版本解析出下载地址",
+	},
+	InstallErrDigestLookup: {
+		"en": "the published digest of the GitHub release asset could not be fetched",
+		"zh": "无法获取 GitHub 发布资源公布的摘要",
+	},
+	InstallErrMaxPlugins: {
+		"en": "the maximum number of installed plugins has been reached",
+		"zh": "已安装插件数量达到上限",
+	},
+	InstallErrHookRejected: {
+		"en": "the install was rejected by the host",
+		"zh": "安装被宿主拒绝",
+	},
+	InstallErrContentType: {
+		"en": "the download address returned a web page instead of a plugin package",
+		"zh": "下载地址返回的是网页而不是插件包",
+	},
+	InstallErrInterrupted: {
+		"en": "the install was interrupted because the host restarted",
+		"zh": "宿主重启，安装被中断",
+	},
+	InstallErrIncompatible: {
+		"en": "the plugin is not compatible with this host",
+		"zh": "插件与当前宿主不兼容",
+	},
+}
+
+// localize 返回错误码在指定语言下的消息，缺少该语言时回退到英文，未收录的错误码返回空串
+func localize(code, lang string, args ...any) string {
+	msgs, ok := messageCatalog[code]
+	if !ok {
+		return ""
+	}
+	tmpl, ok := msgs[lang]
+	if !ok {
+		tmpl = msgs[defaultLanguage]
+	}
+	if len(args) == 0 {
+		return tmpl
+	}
+	return fmt.Sprintf(tmpl, args...)
+}
+
+// negotiateLanguage 从 Accept-Language 中选出第一个有翻译的语言，按主语言标签匹配（zh-CN 匹配 zh）
+func negotiateLanguage(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(tag, "-")
+		primary = strings.ToLower(primary)
+		if primary == "en" || primary == "zh" {
+			return primary
+		}
+	}
+	return defaultLanguage
+}
+
+// Localized 返回消息按指定语言重新生成的副本，未收录的错误码保留原消息
+func (e ValidationError) Localized(lang string) ValidationError {
+	if msg := localize(e.Code, lang, e.Args...); msg != "" {
+		e.Message = msg
+	}
+	return e
+}
+
+// localizeErrors 按语言生成一组校验错误的本地化副本
+func localizeErrors(errs []ValidationError, lang string) []ValidationError {
+	out := make([]ValidationError, len(errs))
+	for i, e := range errs {
+		out[i] = e.Localized(lang)
+	}
+	return out
+}
+
+// localeTagPattern 清单本地化文本接受的语言标签：主语言加可选的地区、文字等子标签，如 zh、zh-Hant-TW
+var localeTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// invalidLocaleKeys 返回清单本地化文本中不是有效语言标签的键，已排序
+func invalidLocaleKeys(i18n *ManifestI18n) []string {
+	if i18n == nil {
+		return nil
+	}
+	var keys []string
+	for _, values := range []map[string]string{i18n.Name, i18n.Description} {
+		for key := range values {
+			if !localeTagPattern.MatchString(key) && !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// localizedText 按 Accept-Language 中的顺序选出 values 里最匹配的文本：先找完全相同的标签，
+// 再按主语言匹配（请求 zh-CN 时可用 zh，请求 zh 时可用 zh-TW），都没有时返回 base
+func localizedText(base string, values map[string]string, acceptLanguage string) string {
+	if len(values) == 0 {
+		return base
+	}
+	// 同一主语言有多个键时按键排序取第一个，结果不随 map 遍历顺序变化
+	keys := make([]string, 0, len(values))
+	for key, text := range values {
+		if text != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		for _, key := range keys {
+			if strings.EqualFold(key, tag) {
+				return values[key]
+			}
+		}
+		primary, _, _ := strings.Cut(tag, "-")
+		for _, key := range keys {
+			keyPrimary, _, _ := strings.Cut(key, "-")
+			if strings.EqualFold(keyPrimary, primary) {
+				return values[key]
+			}
+		}
+	}
+	return base
+}
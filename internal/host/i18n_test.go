@@ -0,0 +1,150 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInstallValidationErrorsLocalized(t *testing.T) {
+	h := newTestHost(t, Config{})
+	body := `{"url":"` + serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"}) + `"}`
+	cases := map[string]string{
+		"zh":             "插件ID不能为空",
+		"zh-CN,zh;q=0.9": "插件ID不能为空",
+		"en":             "plugin ID must not be empty",
+		"fr, en;q=0.5":   "plugin ID must not be empty",
+		"":               "plugin ID must not be empty",
+	}
+	for lang, want := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/market", strings.NewReader(body))
+		if lang != "" {
+			r.Header.Set("Accept-Language", lang)
+		}
+		w := httptest.NewRecorder()
+		h.handleMarket(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Accept-Language %q: status = %d, want 400", lang, w.Code)
+		}
+		var resp struct {
+			Error  marketError       `json:"error"`
+			Errors []ValidationError `json:"errors"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error.Code != InstallErrValidation {
+			t.Errorf("Accept-Language %q: error code = %s", lang, resp.Error.Code)
+		}
+		if len(resp.Errors) != 1 || resp.Errors[0].Code != "EMPTY_ID" || resp.Errors[0].Message != want {
+			t.Errorf("Accept-Language %q: errors = %+v, want EMPTY_ID %q", lang, resp.Errors, want)
+		}
+	}
+}
+
+func TestLocalizeWithArgs(t *testing.T) {
+	e := ValidationError{Field: "id", Code: "PLUGIN_NOT_ALLOWED", Message: "原始消息", Args: []any{"demo"}}
+	if got := e.Localized("en").Message; got != "plugin demo is not allowed to be installed" {
+		t.Errorf("en = %q", got)
+	}
+	if got := e.Localized("zh").Message; got != "插件 demo 不允许安装" {
+		t.Errorf("zh = %q", got)
+	}
+	unknown := ValidationError{Code: "NO_SUCH_CODE", Message: "kept"}
+	if got := unknown.Localized("zh").Message; got != "kept" {
+		t.Errorf("uncatalogued code = %q, want the original message", got)
+	}
+}
+
+func TestLocalizedText(t *testing.T) {
+	values := map[string]string{"zh": "演示", "zh-TW": "演示繁體", "ja": "デモ", "fr": ""}
+	cases := map[string]string{
+		"zh":              "演示",
+		"ZH-tw":           "演示繁體",
+		"zh-CN,zh;q=0.9":  "演示",
+		"de, ja;q=0.8":    "デモ",
+		"ja-JP":           "デモ",
+		"ko;q=0.9, zh-HK": "演示",
+		// 空文本视为未提供
+		"fr":   "Demo",
+		"de,*": "Demo",
+		"":     "Demo",
+	}
+	for lang, want := range cases {
+		if got := localizedText("Demo", values, lang); got != want {
+			t.Errorf("Accept-Language %q: got %q, want %q", lang, got, want)
+		}
+	}
+	// 同一主语言有多个键时按键排序选择
+	if got := localizedText("Demo", map[string]string{"zh-TW": "繁體", "zh-CN": "简体"}, "zh"); got != "简体" {
+		t.Errorf("primary language match = %q, want 简体", got)
+	}
+}
+
+func TestValidateManifestLocaleKeys(t *testing.T) {
+	v := NewPluginValidator(DefaultSecurityConfig())
+	m := &Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", I18n: &ManifestI18n{
+		Name:        map[string]string{"zh": "演示", "zh-Hant-TW": "演示", "zh_CN": "演示"},
+		Description: map[string]string{"chinese": "说明", "zh_CN": "说明"},
+	}}
+	result := v.ValidateManifest(m)
+	var keys []string
+	for _, e := range result.Errors {
+		if e.Code == "INVALID_LOCALE" {
+			keys = append(keys, e.Args[0].(string))
+		}
+	}
+	// 重复的非法键只报告一次
+	if result.Valid || strings.Join(keys, ",") != "chinese,zh_CN" {
+		t.Fatalf("invalid locale keys = %v (errors %+v)", keys, result.Errors)
+	}
+	m.I18n = &ManifestI18n{Name: map[string]string{"ja": "デモ", "pt-BR": "Demonstração"}}
+	if result := v.ValidateManifest(m); !result.Valid {
+		t.Errorf("valid locale keys rejected: %+v", result.Errors)
+	}
+}
+
+func TestGetPluginsLocalized(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo")
+	h.pluginsMu.Lock()
+	m := &h.plugins["demo"].Manifest
+	m.Name, m.Description = "Demo", "A demo"
+	m.I18n = &ManifestI18n{Name: map[string]string{"zh": "演示", "ja": "デモ"}, Description: map[string]string{"zh": "演示插件"}}
+	h.pluginsMu.Unlock()
+
+	for lang, want := range map[string][2]string{
+		"zh-CN":       {"演示", "演示插件"},
+		"ja":          {"デモ", "A demo"},
+		"fr,en;q=0.5": {"Demo", "A demo"},
+		"":            {"Demo", "A demo"},
+	} {
+		header := http.Header{}
+		if lang != "" {
+			header.Set("Accept-Language", lang)
+		}
+		_, resp := callRPCWithHeader(t, h, header, "", "host.getPlugins", nil)
+		if resp.Error != nil {
+			t.Fatal(resp.Error)
+		}
+		if got := resultField(t, resp.Result, "name"); got != want[0] {
+			t.Errorf("Accept-Language %q: name = %q, want %q", lang, got, want[0])
+		}
+		if got := resultField(t, resp.Result, "description"); got != want[1] {
+			t.Errorf("Accept-Language %q: description = %q, want %q", lang, got, want[1])
+		}
+	}
+}
+
+func TestInstallRejectsInvalidLocale(t *testing.T) {
+	h := newTestHost(t, Config{})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", I18n: &ManifestI18n{Name: map[string]string{"zh_CN": "演示"}}})
+	if err := h.installPluginFromURL("demo", url, "", "", nil); err == nil || !strings.Contains(err.Error(), "zh_CN") {
+		t.Fatalf("err = %v", err)
+	}
+	if _, ok := h.getPlugin("demo"); ok {
+		t.Error("plugin with an invalid locale key was installed")
+	}
+}
@@ -0,0 +1,82 @@
+package host
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// installProgressKeys InstallProgress 可能出现的全部 JSON 字段，与 gin 服务的测试保持一致
+var installProgressKeys = map[string]bool{
+	"pluginId": true, "status": true, "progress": true, "message": true, "phase": true,
+	"bytesDownloaded": true, "totalBytes": true, "bytesPerSecond": true, "etaSeconds": true,
+	"code": true, "error": true, "terminal": true, "requiresApproval": true,
+}
+
+func TestInstallProgressJSONShape(t *testing.T) {
+	cases := []struct {
+		progress InstallProgress
+		want     string
+	}{
+		{
+			InstallProgress{PluginID: "demo", Status: "downloading", Progress: 10, Phase: "downloading"},
+			`{"pluginId":"demo","status":"downloading","progress":10,"phase":"downloading","terminal":false}`,
+		},
+		{
+			InstallProgress{PluginID: "demo", Status: "failed", Progress: 30, Message: "bad checksum", Phase: "verifying", Code: "X", Error: "mismatch", Terminal: true},
+			`{"pluginId":"demo","status":"failed","progress":30,"message":"bad checksum","phase":"verifying","code":"X","error":"mismatch","terminal":true}`,
+		},
+		{
+			InstallProgress{PluginID: "demo", Status: "downloading", Progress: 50, BytesDownloaded: 512, TotalBytes: 1024, BytesPerSecond: 256, ETASeconds: 2},
+			`{"pluginId":"demo","status":"downloading","progress":50,"bytesDownloaded":512,"totalBytes":1024,"bytesPerSecond":256,"etaSeconds":2,"terminal":false}`,
+		},
+		{
+			InstallProgress{PluginID: "demo", Status: "completed", Progress: 100, Terminal: true, RequiresApproval: true},
+			`{"pluginId":"demo","status":"completed","progress":100,"terminal":true,"requiresApproval":true}`,
+		},
+	}
+	for _, tc := range cases {
+		data, err := json.Marshal(tc.progress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.want {
+			t.Errorf("json = %s\nwant   %s", data, tc.want)
+		}
+	}
+}
+
+func TestInstallEventsCarryInstallProgress(t *testing.T) {
+	h := newTestHost(t, Config{})
+	events := subscribeEvents(t, h)
+	if err := h.installPluginFromURL("good", serveManifest(t, Manifest{ID: "good", Name: "Good", Version: "1.0.0"}), "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var installEvents int
+	for _, ev := range receivedEvents(t, events) {
+		switch ev.Type {
+		case "plugin.installation.progress", "plugin.installation.failed", "plugin.installation.done":
+		default:
+			continue
+		}
+		installEvents++
+		data, ok := ev.Data.(map[string]any)
+		if !ok {
+			t.Fatalf("%s data = %T, want an object", ev.Type, ev.Data)
+		}
+		for key := range data {
+			if !installProgressKeys[key] {
+				t.Errorf("%s has unexpected field %q", ev.Type, key)
+			}
+		}
+		if data["pluginId"] != "good" || data["status"] == "" {
+			t.Errorf("%s data = %v", ev.Type, data)
+		}
+		if _, ok := data["terminal"].(bool); !ok {
+			t.Errorf("%s data missing terminal: %v", ev.Type, data)
+		}
+	}
+	if installEvents < 2 {
+		t.Errorf("got %d installation events, want progress and done", installEvents)
+	}
+}
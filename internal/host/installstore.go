@@ -0,0 +1,84 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// installationsFileName 默认安装记录文件名，位于 RootDir 下
+const installationsFileName = "installations.json"
+
+// InstallationStore 持久化安装记录，宿主重启后据此恢复安装状态。
+// Save 每次传入全部记录，按插件ID排序
+type InstallationStore interface {
+	Load() ([]*InstallationContext, error)
+	Save(records []*InstallationContext) error
+}
+
+// FileInstallationStore 把安装记录保存为 JSON 文件
+type FileInstallationStore struct {
+	Path string
+}
+
+// NewFileInstallationStore 创建保存到 path 的安装记录存储
+func NewFileInstallationStore(path string) *FileInstallationStore {
+	return &FileInstallationStore{Path: path}
+}
+
+// Load 读取安装记录，文件不存在时返回空
+func (s *FileInstallationStore) Load() ([]*InstallationContext, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []*InstallationContext
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Save 原子地写回全部安装记录
+func (s *FileInstallationStore) Save(records []*InstallationContext) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// installationStore 返回配置的安装记录存储，未配置时使用 RootDir 下的 JSON 文件
+func (h *PluginHost) installationStore() InstallationStore {
+	if h.config.InstallationStore != nil {
+		return h.config.InstallationStore
+	}
+	return NewFileInstallationStore(filepath.Join(h.config.RootDir, installationsFileName))
+}
+
+// sortedInstallations 返回按插件ID排序的安装记录副本
+func sortedInstallations(installations map[string]*InstallationContext) []*InstallationContext {
+	records := make([]*InstallationContext, 0, len(installations))
+	for _, ctx := range installations {
+		snapshot := *ctx
+		records = append(records, &snapshot)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].PluginID < records[j].PluginID })
+	return records
+}
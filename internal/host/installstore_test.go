@@ -0,0 +1,161 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryInstallationStore 在内存中保存安装记录，记录每次写入的内容
+type memoryInstallationStore struct {
+	mu      sync.Mutex
+	records []*InstallationContext
+	saves   int
+}
+
+func (s *memoryInstallationStore) Load() ([]*InstallationContext, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records, nil
+}
+
+func (s *memoryInstallationStore) Save(records []*InstallationContext) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = records
+	s.saves++
+	return nil
+}
+
+func (s *memoryInstallationStore) snapshot() ([]*InstallationContext, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records, s.saves
+}
+
+func TestFileInstallationStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", installationsFileName)
+	store := NewFileInstallationStore(path)
+
+	if records, err := store.Load(); err != nil || records != nil {
+		t.Fatalf("load missing file = %v, %v", records, err)
+	}
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []*InstallationContext{
+		{PluginID: "a", Status: "completed", StartTime: start},
+		{PluginID: "b", Status: "failed", StartTime: start, Error: "boom", ErrorCode: InstallErrDownload},
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind, stat err = %v", err)
+	}
+	got, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || *got[0] != *want[0] || *got[1] != *want[1] {
+		t.Fatalf("loaded = %+v %+v", got[0], got[1])
+	}
+
+	if err := os.WriteFile(path, []byte("{broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(); err == nil {
+		t.Fatal("corrupt file loaded without error")
+	}
+}
+
+func TestInstallationRecordsSurviveRestart(t *testing.T) {
+	root := t.TempDir()
+	h := newTestHost(t, Config{RootDir: root})
+	url := serveManifest(t, Manifest{ID: "done", Name: "Done", Version: "1.0.0"})
+	if err := h.installPluginFromURL("done", url, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	// 宿主在安装过程中退出
+	if err := h.installManager.StartInstallation("pending"); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := newTestHost(t, Config{RootDir: root})
+	status := restarted.installManager.GetInstallationStatus("pending")
+	if status == nil || status.Status != "failed" || status.ErrorCode != InstallErrInterrupted || status.Error == "" {
+		t.Fatalf("interrupted install = %+v", status)
+	}
+	if status := restarted.installManager.GetInstallationStatus("done"); status == nil || status.Status != "completed" {
+		t.Fatalf("completed install = %+v", status)
+	}
+	if n := restarted.installManager.ActiveCount(); n != 0 {
+		t.Errorf("active installs after restart = %d", n)
+	}
+
+	// 标记结果已写回文件
+	records, err := NewFileInstallationStore(filepath.Join(root, installationsFileName)).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1].PluginID != "pending" || records[1].ErrorCode != InstallErrInterrupted {
+		t.Fatalf("persisted records = %+v", records)
+	}
+
+	code, resp := callRPC(t, restarted, "", "host.getInstallationStatus", map[string]any{"pluginId": "pending"})
+	result, _ := resp.Result.(map[string]any)
+	if code != 200 || result["status"] != "failed" || result["errorCode"] != InstallErrInterrupted {
+		t.Fatalf("host.getInstallationStatus: got %d %+v", code, resp)
+	}
+
+	// 被中断的插件可以重新安装
+	url = serveManifest(t, Manifest{ID: "pending", Name: "Pending", Version: "1.0.0"})
+	if err := restarted.installPluginFromURL("pending", url, "", "", nil); err != nil {
+		t.Fatalf("reinstall after restart: %v", err)
+	}
+}
+
+func TestInstallationStoreConfig(t *testing.T) {
+	store := &memoryInstallationStore{records: []*InstallationContext{
+		{PluginID: "old", Status: "installing", StartTime: time.Now()},
+		nil,
+		{Status: "installing"},
+	}}
+	h := newTestHost(t, Config{InstallationStore: store})
+	if status := h.installManager.GetInstallationStatus("old"); status == nil || status.ErrorCode != InstallErrInterrupted {
+		t.Fatalf("restored record = %+v", status)
+	}
+	if _, err := os.Stat(filepath.Join(h.config.RootDir, installationsFileName)); !os.IsNotExist(err) {
+		t.Errorf("default file written with a custom store, stat err = %v", err)
+	}
+
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if err := h.installPluginFromURL("demo", url, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	records, saves := store.snapshot()
+	if saves < 3 {
+		t.Errorf("saves = %d, want restore, start and completion", saves)
+	}
+	if len(records) != 2 || records[0].PluginID != "demo" || records[0].Status != "completed" || records[1].PluginID != "old" {
+		t.Fatalf("saved records = %+v", records)
+	}
+}
+
+func TestInstallationRestoreLoadError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), installationsFileName)
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	im := NewInstallationManager(1)
+	if err := im.Restore(NewFileInstallationStore(path)); err == nil {
+		t.Fatal("restore from a corrupt file succeeded")
+	}
+	// 读取失败时不接管存储，也不覆盖原文件
+	if err := im.StartInstallation("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "not json" {
+		t.Fatalf("file = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,58 @@
+package host
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRequiresSHA256(t *testing.T) {
+	cases := []struct {
+		name     string
+		require  bool
+		allowLoc bool
+		url      string
+		want     bool
+	}{
+		{"disabled", false, true, "https://github.com/p.zip", false},
+		{"market", true, true, "https://github.com/p.zip", true},
+		{"localhost", true, true, "http://localhost:8080/p.zip", false},
+		{"loopback", true, true, "http://127.0.0.1:8080/p.zip", false},
+		{"local install disabled", true, false, "http://127.0.0.1:8080/p.zip", true},
+		{"unparseable", true, true, "http://[::1", true},
+	}
+	for _, tc := range cases {
+		v := NewPluginValidator(SecurityConfig{RequireMarketSHA256: tc.require, AllowLocalInstall: tc.allowLoc})
+		if got := v.RequiresSHA256(tc.url); got != tc.want {
+			t.Errorf("%s: RequiresSHA256(%q) = %v, want %v", tc.name, tc.url, got, tc.want)
+		}
+	}
+}
+
+func TestInstallRequiresSHA256ForMarketSource(t *testing.T) {
+	cfg := DefaultSecurityConfig()
+	cfg.RequireMarketSHA256 = true
+	h := newTestHost(t, Config{Security: &cfg})
+	events := subscribeEvents(t, h)
+
+	// 市场来源缺少哈希时在下载前失败
+	err := h.installPluginFromURL("demo", "https://github.com/acme/demo/releases/download/v1.0.0/manifest.json", "", "", nil)
+	var installErr *InstallError
+	if !errors.As(err, &installErr) || installErr.Code != InstallErrIntegrityRequired {
+		t.Fatalf("market install without sha256: err = %v, want %s", err, InstallErrIntegrityRequired)
+	}
+	var failed bool
+	for _, ev := range receivedEvents(t, events) {
+		if ev.Type == "plugin.installation.failed" && ev.Data.(map[string]any)["code"] == InstallErrIntegrityRequired {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Error("no plugin.installation.failed event with INTEGRITY_REQUIRED")
+	}
+
+	// 本地开发安装不要求哈希
+	url := serveManifest(t, Manifest{ID: "local", Name: "Local", Version: "1.0.0"})
+	if err := h.installPluginFromURL("local", url, "", "", nil); err != nil {
+		t.Fatalf("local install without sha256: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	k := newKeyedMutex()
+	unlockA := k.Lock("a")
+
+	// 不同的键不受阻塞
+	done := make(chan struct{})
+	go func() {
+		k.Lock("b")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lock on a different key blocked")
+	}
+
+	// 相同的键等待释放
+	acquired := make(chan struct{})
+	go func() {
+		k.Lock("a")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("lock on the same key acquired while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	<-acquired
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(k.locks) != 0 {
+		t.Errorf("released locks not reclaimed: %d left", len(k.locks))
+	}
+}
+
+// 并发安装和卸载同一插件后，注册表与插件目录必须一致；配合 go test -race 检查数据竞争
+func TestConcurrentInstallUninstall(t *testing.T) {
+	h := newTestHost(t, Config{})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = h.installPluginFromURL("demo", url, "", "", nil)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = h.uninstallPlugin("demo", UninstallOptions{SkipBackup: true})
+		}()
+	}
+	wg.Wait()
+
+	_, registered := h.getPlugin("demo")
+	_, statErr := os.Stat(filepath.Join(h.config.PluginsDir, "demo"))
+	if registered != (statErr == nil) {
+		t.Fatalf("registry and disk disagree: registered=%v, stat err=%v", registered, statErr)
+	}
+}
@@ -0,0 +1,193 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxKVKeys 每个插件键值存储的键数量上限
+	maxKVKeys = 1000
+	// maxKVBytes 每个插件键值存储的总大小上限（键与值的字节数之和）
+	maxKVBytes = 1 << 20
+)
+
+var (
+	// ErrInvalidKVKey 键为空、过长或包含不允许的字符
+	ErrInvalidKVKey = errors.New("invalid key")
+	// ErrKVQuotaExceeded 写入会超出插件键值存储的键数量或大小上限
+	ErrKVQuotaExceeded = errors.New("kv quota exceeded")
+)
+
+var kvKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._:/-]{1,128}$`)
+
+// errInvalidKVPlugin 插件ID不合法，不能作为键值存储的文件名
+var errInvalidKVPlugin = errors.New("invalid plugin id")
+
+// checkKVAccess 键值存储只对已安装且声明了 perm 的插件开放，读写的总是调用方（已解析的插件ID）自己的存储
+func (h *PluginHost) checkKVAccess(w http.ResponseWriter, req rpcRequest, perm string) bool {
+	if req.PluginID == "" {
+		writeRPCError(w, req.ID, 400, "missing pluginId")
+		return false
+	}
+	if _, ok := h.getPlugin(req.PluginID); !ok || !pluginIDPattern.MatchString(req.PluginID) {
+		writeRPCError(w, req.ID, 404, "plugin not found")
+		return false
+	}
+	if !h.hasPermission(req.PluginID, perm) {
+		writeRPCError(w, req.ID, 403, "missing permission: "+perm)
+		return false
+	}
+	return true
+}
+
+// kvPath 返回插件键值存储的文件位置，插件ID须先通过 pluginIDPattern 校验
+func (h *PluginHost) kvPath(pluginID string) string {
+	return filepath.Join(h.config.RootDir, "kv", pluginID+".json")
+}
+
+// loadKV 读取插件的键值存储，调用方须持有 kvMu
+func (h *PluginHost) loadKV(pluginID string) (map[string]json.RawMessage, error) {
+	if !pluginIDPattern.MatchString(pluginID) {
+		return nil, errInvalidKVPlugin
+	}
+	data, err := os.ReadFile(h.kvPath(pluginID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]json.RawMessage{}, nil
+		}
+		return nil, err
+	}
+	store := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("invalid kv file: %w", err)
+	}
+	return store, nil
+}
+
+// saveKV 原子地写回插件的键值存储，调用方须持有 kvMu
+func (h *PluginHost) saveKV(pluginID string, store map[string]json.RawMessage) error {
+	if !pluginIDPattern.MatchString(pluginID) {
+		return errInvalidKVPlugin
+	}
+	path := h.kvPath(pluginID)
+	if len(store) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func validateKVKey(key string) error {
+	if !kvKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKVKey, key)
+	}
+	return nil
+}
+
+// kvGet 读取键对应的值，键不存在时返回 false
+func (h *PluginHost) kvGet(pluginID, key string) (json.RawMessage, bool, error) {
+	if err := validateKVKey(key); err != nil {
+		return nil, false, err
+	}
+	h.kvMu.Lock()
+	defer h.kvMu.Unlock()
+	store, err := h.loadKV(pluginID)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := store[key]
+	return v, ok, nil
+}
+
+// kvSet 写入键值，超出键数量或总大小上限时返回 ErrKVQuotaExceeded
+func (h *PluginHost) kvSet(pluginID, key string, value json.RawMessage) error {
+	if err := validateKVKey(key); err != nil {
+		return err
+	}
+	h.kvMu.Lock()
+	defer h.kvMu.Unlock()
+	store, err := h.loadKV(pluginID)
+	if err != nil {
+		return err
+	}
+	if _, exists := store[key]; !exists && len(store) >= maxKVKeys {
+		return fmt.Errorf("%w: at most %d keys", ErrKVQuotaExceeded, maxKVKeys)
+	}
+	store[key] = value
+	size := 0
+	for k, v := range store {
+		size += len(k) + len(v)
+	}
+	if size > maxKVBytes {
+		return fmt.Errorf("%w: at most %d bytes", ErrKVQuotaExceeded, maxKVBytes)
+	}
+	return h.saveKV(pluginID, store)
+}
+
+// kvDelete 删除键，返回键是否存在
+func (h *PluginHost) kvDelete(pluginID, key string) (bool, error) {
+	if err := validateKVKey(key); err != nil {
+		return false, err
+	}
+	h.kvMu.Lock()
+	defer h.kvMu.Unlock()
+	store, err := h.loadKV(pluginID)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := store[key]; !ok {
+		return false, nil
+	}
+	delete(store, key)
+	return true, h.saveKV(pluginID, store)
+}
+
+// kvList 按字典序列出以 prefix 开头的键
+func (h *PluginHost) kvList(pluginID, prefix string) ([]string, error) {
+	h.kvMu.Lock()
+	defer h.kvMu.Unlock()
+	store, err := h.loadKV(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(store))
+	for k := range store {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// kvErrorCode 把键值存储错误映射为 RPC 错误码
+func kvErrorCode(err error) int {
+	switch {
+	case errors.Is(err, ErrKVQuotaExceeded):
+		return 413
+	case errors.Is(err, ErrInvalidKVKey):
+		return 400
+	}
+	return 500
+}
@@ -0,0 +1,55 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKVRejectsUnknownAndTraversalPluginIDs(t *testing.T) {
+	h := newTestHost(t, Config{})
+	for _, id := range []string{"../../etc/passwd", "missing"} {
+		code, resp := callRPC(t, h, id, "kv.set", map[string]any{"key": "k", "value": 1})
+		if code == 200 || resp.Error == nil {
+			t.Fatalf("kv.set as %q: got %d, want error", id, code)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(h.config.RootDir, "kv")); !os.IsNotExist(err) {
+		t.Fatalf("kv directory should not be created, stat err = %v", err)
+	}
+	if err := h.saveKV("../escape", nil); err != errInvalidKVPlugin {
+		t.Fatalf("saveKV with traversal id: got %v", err)
+	}
+}
+
+func TestKVRequiresPermission(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "reader", "kv.read")
+	code, _ := callRPC(t, h, "reader", "kv.set", map[string]any{"key": "k", "value": 1})
+	if code != 403 {
+		t.Fatalf("kv.set without kv.write: got %d, want 403", code)
+	}
+	code, _ = callRPC(t, h, "reader", "kv.list", nil)
+	if code != 200 {
+		t.Fatalf("kv.list with kv.read: got %d, want 200", code)
+	}
+}
+
+func TestKVSetGetRoundTrip(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "notes", "kv.read", "kv.write")
+	if code, resp := callRPC(t, h, "notes", "kv.set", map[string]any{"key": "theme", "value": "dark"}); code != 200 {
+		t.Fatalf("kv.set: %d %+v", code, resp.Error)
+	}
+	code, resp := callRPC(t, h, "notes", "kv.get", map[string]any{"key": "theme"})
+	if code != 200 {
+		t.Fatalf("kv.get: %d %+v", code, resp.Error)
+	}
+	result, _ := resp.Result.(map[string]any)
+	if result["value"] != "dark" {
+		t.Fatalf("kv.get result = %#v", resp.Result)
+	}
+	if _, err := os.Stat(filepath.Join(h.config.RootDir, "kv", "notes.json")); err != nil {
+		t.Fatalf("kv file: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// countdownContext 在 Err 被调用 remaining 次之后报告已取消，用于模拟遍历途中断开
+type countdownContext struct {
+	context.Context
+	remaining int
+	calls     int
+}
+
+func (c *countdownContext) Err() error {
+	c.calls++
+	if c.calls > c.remaining {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestListVaultFilesCancelledMidWalk(t *testing.T) {
+	h := newTestHost(t, Config{})
+	for i := 0; i < 100; i++ {
+		dir := filepath.Join(h.config.VaultDir, fmt.Sprintf("d%02d", i%10))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%03d.md", i)), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := h.listVaultFiles(context.Background())
+	if err != nil || len(all) != 100 {
+		t.Fatalf("uncancelled list = %d files, %v", len(all), err)
+	}
+
+	ctx := &countdownContext{Context: context.Background(), remaining: 5}
+	paths, err := h.listVaultFiles(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if ctx.calls != ctx.remaining+1 {
+		t.Errorf("walk continued after cancellation: %d context checks", ctx.calls)
+	}
+	if len(paths) >= len(all) {
+		t.Errorf("cancelled walk returned all %d files", len(paths))
+	}
+}
+
+func TestListVaultFilesAlreadyCancelled(t *testing.T) {
+	h := newTestHost(t, Config{})
+	if err := os.WriteFile(filepath.Join(h.config.VaultDir, "a.md"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 客户端已断开时不再遍历存储库
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if paths, err := h.listVaultFiles(ctx); !errors.Is(err, context.Canceled) || len(paths) != 0 {
+		t.Errorf("cancelled context: %v, %v, want no paths and context.Canceled", paths, err)
+	}
+}
+
+func TestExportVaultCancelledMidWalk(t *testing.T) {
+	h := newTestHost(t, Config{})
+	for i := 0; i < 20; i++ {
+		if err := h.writeVaultFile(fmt.Sprintf("notes/%03d.md", i), []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 统计完整导出时检查 ctx 的次数，其中每个文件检查一次
+	full := &countdownContext{Context: context.Background(), remaining: 1 << 30}
+	if err := h.exportVaultZip(full, &bytes.Buffer{}, ""); err != nil {
+		t.Fatal(err)
+	}
+	walkCalls := full.calls - 20
+
+	// 遍历途中断开时不再写出 zip
+	var buf bytes.Buffer
+	ctx := &countdownContext{Context: context.Background(), remaining: walkCalls / 2}
+	if err := h.exportVaultZip(ctx, &buf, ""); !errors.Is(err, context.Canceled) || buf.Len() != 0 {
+		t.Fatalf("cancelled during the walk: %v, %d bytes written", err, buf.Len())
+	}
+
+	// 导出途中断开时不再读取剩余文件
+	ctx = &countdownContext{Context: context.Background(), remaining: walkCalls + 3}
+	if err := h.exportVaultZip(ctx, &bytes.Buffer{}, ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled during the export: %v, want context.Canceled", err)
+	}
+	if ctx.calls != ctx.remaining+1 {
+		t.Errorf("export continued after cancellation: %d context checks", ctx.calls)
+	}
+}
+
+func TestVaultScansAlreadyCancelled(t *testing.T) {
+	h := newTestHost(t, Config{})
+	if err := h.writeVaultFile("a.md", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := h.getDiskUsage(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("getDiskUsage: %v, want context.Canceled", err)
+	}
+	if _, err := h.vaultSnapshot(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("vaultSnapshot: %v, want context.Canceled", err)
+	}
+	// 后端未实现 Usage 时逐个统计文件，同样响应取消
+	mem := newTestHost(t, Config{VaultStore: NewMemoryVaultStore()})
+	if _, err := mem.vaultUsage(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("vaultUsage: %v, want context.Canceled", err)
+	}
+}
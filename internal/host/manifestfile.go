@@ -0,0 +1,139 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultMaxManifestBytes 未配置 Config.MaxManifestBytes 时清单文件的最大字节数
+	DefaultMaxManifestBytes = 64 << 10
+	// DefaultMaxManifestDepth 未配置 Config.MaxManifestDepth 时清单的最大嵌套层数
+	DefaultMaxManifestDepth = 32
+)
+
+// DefaultManifestNames 默认接受的清单文件名，按顺序查找，JSON 优先
+var DefaultManifestNames = []string{"manifest.json", "manifest.yaml", "manifest.yml"}
+
+var (
+	ErrManifestTooLarge = errors.New("manifest too large")
+	ErrManifestTooDeep  = errors.New("manifest nested too deeply")
+)
+
+// maxManifestBytes 返回生效的清单字节数上限
+func (h *PluginHost) maxManifestBytes() int64 {
+	if h.config.MaxManifestBytes > 0 {
+		return h.config.MaxManifestBytes
+	}
+	return DefaultMaxManifestBytes
+}
+
+// maxManifestDepth 返回生效的清单嵌套层数上限
+func (h *PluginHost) maxManifestDepth() int {
+	if h.config.MaxManifestDepth > 0 {
+		return h.config.MaxManifestDepth
+	}
+	return DefaultMaxManifestDepth
+}
+
+// manifestNames 返回接受的清单文件名，未配置 Config.ManifestNames 时使用 DefaultManifestNames
+func (h *PluginHost) manifestNames() []string {
+	if len(h.config.ManifestNames) > 0 {
+		return h.config.ManifestNames
+	}
+	return DefaultManifestNames
+}
+
+// isYAMLManifest 按扩展名判断清单文件是否为 YAML
+func isYAMLManifest(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// parseManifest 按格式解析清单。YAML 先转换为 JSON 再解析，两种格式的字段名和校验完全一致；
+// 超过字节数或嵌套层数上限时不解析，直接返回 ErrManifestTooLarge 或 ErrManifestTooDeep
+func (h *PluginHost) parseManifest(data []byte, yaml bool) (Manifest, error) {
+	var m Manifest
+	if limit := h.maxManifestBytes(); int64(len(data)) > limit {
+		return m, fmt.Errorf("%w: exceeds %d bytes", ErrManifestTooLarge, limit)
+	}
+	if yaml {
+		doc, err := parseYAMLDocument(string(data))
+		if err != nil {
+			return m, err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return m, err
+		}
+	}
+	if limit := h.maxManifestDepth(); jsonDepthExceeds(data, limit) {
+		return m, fmt.Errorf("%w: exceeds %d levels", ErrManifestTooDeep, limit)
+	}
+	err := json.Unmarshal(data, &m)
+	return m, err
+}
+
+// jsonDepthExceeds 逐个读取 JSON 记号，判断对象和数组的嵌套层数是否超过 limit，
+// 格式错误时返回 false，交给随后的解析报告
+func jsonDepthExceeds(data []byte, limit int) bool {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		switch tok {
+		case json.Delim('['), json.Delim('{'):
+			depth++
+			if depth > limit {
+				return true
+			}
+		case json.Delim(']'), json.Delim('}'):
+			depth--
+		}
+	}
+}
+
+// readPluginManifest 按 manifestNames 的顺序读取插件目录中第一个存在的清单，返回清单和文件名。
+// 最多读取比上限多一个字节，超大的清单不会整个读入内存
+func (h *PluginHost) readPluginManifest(dir string) (Manifest, string, error) {
+	for _, name := range h.manifestNames() {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return Manifest{}, "", err
+		}
+		data, err := io.ReadAll(io.LimitReader(f, h.maxManifestBytes()+1))
+		f.Close()
+		if err != nil {
+			return Manifest{}, name, err
+		}
+		m, err := h.parseManifest(data, isYAMLManifest(name))
+		if err != nil {
+			return Manifest{}, name, fmt.Errorf("parse %s: %w", name, err)
+		}
+		return m, name, nil
+	}
+	return Manifest{}, "", os.ErrNotExist
+}
+
+// downloadedManifestName 为下载的清单选择保存的文件名：内容是 JSON 时用第一个 JSON 文件名，
+// 否则用第一个 YAML 文件名，没有接受该格式的文件名时返回空
+func (h *PluginHost) downloadedManifestName(data []byte) (string, bool) {
+	yaml := !json.Valid(bytes.TrimSpace(data))
+	for _, name := range h.manifestNames() {
+		if isYAMLManifest(name) == yaml {
+			return name, yaml
+		}
+	}
+	return "", yaml
+}
@@ -0,0 +1,175 @@
+package host
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const yamlManifest = `id: notes
+name: Notes
+version: 1.2.0
+permissions:
+  - vault.read
+tags: [Writing, sync]
+entrypoints:
+  ui: index.html
+commands:
+  - id: open
+    title: Open
+`
+
+const jsonManifest = `{"id":"notes","name":"Notes","version":"1.2.0","permissions":["vault.read"],"tags":["Writing","sync"],"entrypoints":{"ui":"index.html"},"commands":[{"id":"open","title":"Open"}]}`
+
+// serveManifestData 启动返回原始清单内容的服务，返回清单地址
+func serveManifestData(t *testing.T, data string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/manifest"
+}
+
+func TestParseManifestYAMLMatchesJSON(t *testing.T) {
+	h := newTestHost(t, Config{})
+	fromJSON, err := h.parseManifest([]byte(jsonManifest), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML, err := h.parseManifest([]byte(yamlManifest), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Fatalf("yaml = %+v\njson = %+v", fromYAML, fromJSON)
+	}
+}
+
+func TestLoadPluginsJSONAndYAMLManifests(t *testing.T) {
+	h := newTestHost(t, Config{})
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "notes"), map[string]string{"manifest.json": jsonManifest})
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "yaml"), map[string]string{"manifest.yaml": strings.Replace(yamlManifest, "id: notes", "id: yaml", 1)})
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "yml"), map[string]string{"manifest.yml": "id: yml\nname: Yml\nversion: 0.1.0\n"})
+	// 不在接受列表中的文件名不会被读取
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "other"), map[string]string{"plugin.yaml": "id: other\nname: Other\nversion: 1.0.0\n"})
+
+	if err := h.LoadPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if n := h.CountPlugins(); n != 3 {
+		t.Fatalf("loaded %d plugins, want 3", n)
+	}
+	p, ok := h.getPlugin("yaml")
+	if !ok {
+		t.Fatal("yaml plugin not loaded")
+	}
+	m := p.Manifest
+	if m.Name != "Notes" || m.Version != "1.2.0" || !reflect.DeepEqual(m.Permissions, []string{"vault.read"}) {
+		t.Fatalf("yaml manifest = %+v", m)
+	}
+	// 与 JSON 清单一样规范化标签并注册清单中的命令
+	if !reflect.DeepEqual(m.Tags, []string{"writing", "sync"}) {
+		t.Errorf("tags = %v", m.Tags)
+	}
+	if len(m.Commands) != 1 || m.Commands[0].ID != "open" {
+		t.Errorf("commands = %+v", m.Commands)
+	}
+	if _, ok := h.getPlugin("yml"); !ok {
+		t.Error("manifest.yml not loaded")
+	}
+}
+
+func TestReadPluginManifestPrefersJSON(t *testing.T) {
+	h := newTestHost(t, Config{})
+	dir := filepath.Join(h.config.PluginsDir, "notes")
+	writePluginFixture(t, dir, map[string]string{
+		"manifest.json": jsonManifest,
+		"manifest.yaml": "id: notes\nname: From YAML\nversion: 9.9.9\n",
+	})
+	m, name, err := h.readPluginManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "manifest.json" || m.Name != "Notes" {
+		t.Fatalf("read %s: %+v", name, m)
+	}
+
+	// 调整顺序后 YAML 优先
+	h.config.ManifestNames = []string{"manifest.yaml", "manifest.json"}
+	if m, name, err = h.readPluginManifest(dir); err != nil || name != "manifest.yaml" || m.Name != "From YAML" {
+		t.Fatalf("read %s: %+v, %v", name, m, err)
+	}
+
+	if _, _, err := h.readPluginManifest(t.TempDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("empty dir: %v", err)
+	}
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "bad"), map[string]string{"manifest.yaml": "name: [a"})
+	if _, name, err := h.readPluginManifest(filepath.Join(h.config.PluginsDir, "bad")); err == nil || name != "manifest.yaml" {
+		t.Fatalf("bad yaml: %s %v", name, err)
+	}
+}
+
+func TestConfiguredManifestNames(t *testing.T) {
+	h := newTestHost(t, Config{ManifestNames: []string{"plugin.yaml"}})
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "json"), map[string]string{"manifest.json": `{"id":"json","name":"Json","version":"1.0.0"}`})
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "custom"), map[string]string{"plugin.yaml": "id: custom\nname: Custom\nversion: 1.0.0\n"})
+
+	if err := h.LoadPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.getPlugin("custom"); !ok {
+		t.Error("plugin.yaml not loaded")
+	}
+	if _, ok := h.getPlugin("json"); ok {
+		t.Error("manifest.json loaded although not in ManifestNames")
+	}
+}
+
+func TestInstallYAMLManifest(t *testing.T) {
+	h := newTestHost(t, Config{})
+	if err := h.installPluginFromURL("notes", serveManifestData(t, yamlManifest), "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(h.config.PluginsDir, "notes")
+	if data, err := os.ReadFile(filepath.Join(dir, "manifest.yaml")); err != nil || string(data) != yamlManifest {
+		t.Fatalf("manifest.yaml = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); !os.IsNotExist(err) {
+		t.Fatalf("manifest.json written for a YAML manifest: %v", err)
+	}
+	p, ok := h.getPlugin("notes")
+	if !ok || !reflect.DeepEqual(p.Manifest.Tags, []string{"writing", "sync"}) {
+		t.Fatalf("installed plugin = %+v", p)
+	}
+}
+
+func TestInstallValidatesYAMLLikeJSON(t *testing.T) {
+	h := newTestHost(t, Config{})
+	jsonErr := h.installPluginFromURL("notes", serveManifestData(t, `{"id":"notes","version":"1.0.0"}`), "", "", nil)
+	yamlErr := h.installPluginFromURL("notes", serveManifestData(t, "id: notes\nversion: 1.0.0\n"), "", "", nil)
+	var jsonInstall, yamlInstall *InstallError
+	if !errors.As(jsonErr, &jsonInstall) || !errors.As(yamlErr, &yamlInstall) {
+		t.Fatalf("errors = %v / %v", jsonErr, yamlErr)
+	}
+	if jsonInstall.Code != InstallErrManifest || yamlInstall.Code != jsonInstall.Code || yamlErr.Error() != jsonErr.Error() {
+		t.Fatalf("json error %v (%s), yaml error %v (%s)", jsonErr, jsonInstall.Code, yamlErr, yamlInstall.Code)
+	}
+}
+
+func TestInstallRejectsFormatWithoutAcceptedName(t *testing.T) {
+	h := newTestHost(t, Config{ManifestNames: []string{"manifest.json"}})
+	err := h.installPluginFromURL("notes", serveManifestData(t, yamlManifest), "", "", nil)
+	var installErr *InstallError
+	if !errors.As(err, &installErr) || installErr.Code != InstallErrManifest {
+		t.Fatalf("err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "notes")); !os.IsNotExist(err) {
+		t.Fatalf("plugin dir created: %v", err)
+	}
+}
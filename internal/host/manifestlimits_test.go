@@ -0,0 +1,120 @@
+package host
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// nestedJSON 返回嵌套 depth 层对象的清单
+func nestedJSON(depth int) string {
+	return `{"id":"demo","name":"Demo","version":"1.0.0","x":` + strings.Repeat(`{"a":`, depth-1) + "1" + strings.Repeat("}", depth)
+}
+
+// largeManifest 返回大约 size 字节的合法清单
+func largeManifest(size int) string {
+	return `{"id":"demo","name":"Demo","version":"1.0.0","description":"` + strings.Repeat("x", size) + `"}`
+}
+
+func TestJSONDepthExceeds(t *testing.T) {
+	cases := []struct {
+		data  string
+		limit int
+		want  bool
+	}{
+		{`{}`, 1, false},
+		{`{"a":{}}`, 1, true},
+		{`{"a":[1,[2]]}`, 3, false},
+		{`{"a":[1,[2]]}`, 2, true},
+		// 兄弟节点不累加层数
+		{`{"a":{},"b":{},"c":[]}`, 2, false},
+		// 字符串中的括号不计入
+		{`{"a":"[[[[{{{{"}`, 1, false},
+		// 格式错误交给随后的解析报告
+		{`{"a":`, 1, false},
+	}
+	for _, c := range cases {
+		if got := jsonDepthExceeds([]byte(c.data), c.limit); got != c.want {
+			t.Errorf("jsonDepthExceeds(%s, %d) = %v, want %v", c.data, c.limit, got, c.want)
+		}
+	}
+}
+
+func TestParseManifestLimits(t *testing.T) {
+	h := newTestHost(t, Config{})
+	if _, err := h.parseManifest([]byte(largeManifest(DefaultMaxManifestBytes)), false); !errors.Is(err, ErrManifestTooLarge) {
+		t.Errorf("oversized manifest: %v", err)
+	}
+	if _, err := h.parseManifest([]byte(largeManifest(DefaultMaxManifestBytes-200)), false); err != nil {
+		t.Errorf("manifest under the limit: %v", err)
+	}
+	// 最外层对象也算一层
+	if _, err := h.parseManifest([]byte(nestedJSON(DefaultMaxManifestDepth)), false); err != nil {
+		t.Errorf("manifest at the depth limit: %v", err)
+	}
+	if _, err := h.parseManifest([]byte(nestedJSON(DefaultMaxManifestDepth+1)), false); !errors.Is(err, ErrManifestTooDeep) {
+		t.Errorf("deeply nested manifest: %v", err)
+	}
+	// YAML 清单转换为 JSON 后同样检查层数
+	deepYAML := "id: demo\nname: Demo\nversion: 1.0.0\nx:\n"
+	for i := 1; i <= DefaultMaxManifestDepth; i++ {
+		deepYAML += strings.Repeat("  ", i) + "a:\n"
+	}
+	deepYAML += strings.Repeat("  ", DefaultMaxManifestDepth+1) + "b: 1\n"
+	if _, err := h.parseManifest([]byte(deepYAML), true); !errors.Is(err, ErrManifestTooDeep) {
+		t.Errorf("deeply nested YAML manifest: %v", err)
+	}
+
+	h = newTestHost(t, Config{MaxManifestBytes: 100, MaxManifestDepth: 2})
+	if _, err := h.parseManifest([]byte(largeManifest(100)), false); !errors.Is(err, ErrManifestTooLarge) {
+		t.Errorf("configured size limit: %v", err)
+	}
+	if _, err := h.parseManifest([]byte(nestedJSON(3)), false); !errors.Is(err, ErrManifestTooDeep) {
+		t.Errorf("configured depth limit: %v", err)
+	}
+	if _, err := h.parseManifest([]byte(nestedJSON(2)), false); err != nil {
+		t.Errorf("manifest within configured limits: %v", err)
+	}
+}
+
+func TestLoadPluginsSkipsOversizedManifests(t *testing.T) {
+	h := newTestHost(t, Config{MaxManifestBytes: 1024})
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "big"), map[string]string{"manifest.json": strings.Replace(largeManifest(2048), "demo", "big", 1)})
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "deep"), map[string]string{"manifest.json": strings.Replace(nestedJSON(100), "demo", "deep", 1)})
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "ok"), map[string]string{"manifest.json": `{"id":"ok","name":"Ok","version":"1.0.0"}`})
+
+	if _, _, err := h.readPluginManifest(filepath.Join(h.config.PluginsDir, "big")); !errors.Is(err, ErrManifestTooLarge) {
+		t.Errorf("read oversized manifest: %v", err)
+	}
+	if err := h.LoadPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if n := h.CountPlugins(); n != 1 {
+		t.Fatalf("loaded %d plugins, want only ok", n)
+	}
+}
+
+func TestInstallRejectsOversizedManifest(t *testing.T) {
+	h := newTestHost(t, Config{MaxManifestBytes: 1024, MaxManifestDepth: 8})
+	for _, tc := range []struct {
+		name     string
+		manifest string
+		want     error
+	}{
+		{"oversized", largeManifest(2048), ErrManifestTooLarge},
+		{"deeply nested", nestedJSON(9), ErrManifestTooDeep},
+	} {
+		err := h.installPluginFromURL("demo", serveManifestData(t, tc.manifest), "", "", nil)
+		if code := installErrorCode(err); code != InstallErrManifest || !errors.Is(err, tc.want) {
+			t.Errorf("%s: %s %v", tc.name, code, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "demo")); !os.IsNotExist(err) {
+		t.Fatalf("plugin dir created: %v", err)
+	}
+	if err := h.installPluginFromURL("demo", serveManifestData(t, nestedJSON(8)), "", "", nil); err != nil {
+		t.Fatalf("manifest within limits: %v", err)
+	}
+}
@@ -1,24 +1,39 @@
 package host
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 )
 
 type MarketItem struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	URL     string `json:"url"`
-	SHA256  string `json:"sha256"`
-	Desc    string `json:"description,omitempty"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	URL       string `json:"url"`
+	SHA256    string `json:"sha256"`
+	Signature string   `json:"signature,omitempty"`
+	Desc      string   `json:"description,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
 }
 
 func (h *PluginHost) fetchMarketIndex() ([]MarketItem, error) {
+	items, err := h.loadMarketIndex()
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Tags = normalizeTags(items[i].Tags)
+	}
+	return items, nil
+}
+
+func (h *PluginHost) loadMarketIndex() ([]MarketItem, error) {
 	src := h.config.MarketIndex
 	if src == "" {
 		// Use local fallback from /plugins/index.json if exists
@@ -37,7 +52,11 @@ func (h *PluginHost) fetchMarketIndex() ([]MarketItem, error) {
 		return items, nil
 	}
 	// Remote fetch
-	resp, err := http.Get(src)
+	transport, err := h.outboundTransport()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(src)
 	if err != nil {
 		return nil, err
 	}
@@ -53,23 +72,34 @@ func (h *PluginHost) fetchMarketIndex() ([]MarketItem, error) {
 	return items, nil
 }
 
-func (h *PluginHost) installPluginFromURL(id, url, wantSHA string) error {
+// installPluginFromURL 下载并安装插件，同一插件ID的安装、升级与卸载串行执行。
+// autoEnable 为 nil 时重新安装保持原有的启用状态，新插件按 Config.EnableOnInstall 决定是否启用
+func (h *PluginHost) installPluginFromURL(id, url, wantSHA, signature string, autoEnable *bool) error {
+	unlock := h.pluginLocks.Lock(id)
+	defer unlock()
+	return h.installPluginLocked(id, url, wantSHA, signature, autoEnable)
+}
+
+// enableOnInstall 返回新安装的插件是否默认启用
+func (h *PluginHost) enableOnInstall() bool {
+	return h.config.EnableOnInstall == nil || *h.config.EnableOnInstall
+}
+
+// installPluginLocked 执行安装流程，autoEnable 为 nil 时的启用状态同 installPluginFromURL，
+// 调用方须持有该插件ID的锁
+func (h *PluginHost) installPluginLocked(id, url, wantSHA, signature string, autoEnable *bool) error {
 	// 安全验证
-	validator := NewPluginValidator(DefaultSecurityConfig())
+	validator := NewPluginValidator(h.securityConfig())
 
 	// 验证安装请求
 	validationResult := validator.ValidateInstallRequest(id, url, wantSHA)
 	if !validationResult.Valid {
-		return fmt.Errorf("validation failed: %v", validationResult.Errors)
+		return &InstallError{Code: InstallErrValidation, Err: &ValidationFailedError{Errors: validationResult.Errors}}
 	}
 
 	// 开始安装管理
-	if h.installManager == nil {
-		h.installManager = NewInstallationManager(3)
-	}
-
 	if err := h.installManager.StartInstallation(id); err != nil {
-		return fmt.Errorf("installation start failed: %w", err)
+		return &InstallError{Code: InstallErrBusy, Err: fmt.Errorf("installation start failed: %w", err)}
 	}
 	defer func() {
 		if r := recover(); r != nil {
@@ -78,103 +108,281 @@ func (h *PluginHost) installPluginFromURL(id, url, wantSHA string) error {
 		}
 	}()
 
+	// progress 广播安装进度
+	phase := ""
+	progress := func(p string, percent int, message string) {
+		phase = p
+		h.Broadcast(Event{Type: "plugin.installation.progress", Data: InstallProgress{
+			PluginID: id,
+			Status:   p,
+			Progress: percent,
+			Message:  message,
+			Phase:    p,
+		}})
+	}
+
+	// fail 记录失败状态并广播带错误码的失败事件
+	fail := func(code string, err error) error {
+		installErr := &InstallError{Code: code, Err: err}
+		h.installManager.CompleteInstallation(id, installErr)
+		h.broadcastTerminal(Event{Type: "plugin.installation.failed", Data: InstallProgress{
+			PluginID: id,
+			Status:   "failed",
+			Phase:    phase,
+			Code:     code,
+			Error:    err.Error(),
+			Terminal: true,
+		}})
+		return installErr
+	}
+
+	// GitHub 发布资源未提供SHA256时改用发布版本公布的摘要，未公布摘要时按未提供处理
+	if wantSHA == "" && h.config.VerifyGitHubDigest {
+		digest, err := h.githubAssetDigest(url)
+		if err != nil {
+			return fail(InstallErrDigestLookup, fmt.Errorf("github digest lookup failed: %w", err))
+		}
+		wantSHA = digest
+	}
+
+	// 市场来源要求提供SHA256，本地开发安装不受限制
+	if wantSHA == "" && validator.RequiresSHA256(url) {
+		return fail(InstallErrIntegrityRequired, fmt.Errorf("sha256 is required for %s", url))
+	}
+
+	// 插件数量上限，注册时会在锁内再次检查
+	h.pluginsMu.RLock()
+	limitReached := h.pluginLimitReachedLocked(id)
+	h.pluginsMu.RUnlock()
+	if limitReached {
+		return fail(InstallErrMaxPlugins, fmt.Errorf("maximum of %d plugins reached", h.securityConfig().MaxPlugins))
+	}
+
 	// 下载插件
-	resp, err := http.Get(url)
+	progress("downloading", 10, "downloading plugin")
+	transport, err := h.outboundTransport()
 	if err != nil {
-		h.installManager.CompleteInstallation(id, err)
-		return fmt.Errorf("download failed: %w", err)
+		return fail(InstallErrDownload, fmt.Errorf("download failed: %w", err))
+	}
+	resp, err := newDownloadClient(validator, transport).Get(url)
+	if err != nil {
+		if isDownloadBlocked(err) {
+			return fail(InstallErrDownloadBlocked, fmt.Errorf("download blocked: %w", err))
+		}
+		return fail(InstallErrDownload, fmt.Errorf("download failed: %w", err))
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("download failed with status: %d", resp.StatusCode)
-		h.installManager.CompleteInstallation(id, err)
-		return err
+		return fail(InstallErrDownload, fmt.Errorf("download failed with status: %d", resp.StatusCode))
+	}
+
+	// 地址配置错误时常返回 HTML 页面，在下载完整内容和解析清单之前拒绝
+	download := bufio.NewReader(resp.Body)
+	head, _ := download.Peek(sniffLen)
+	if err := checkPackageContent(resp.Header.Get("Content-Type"), head); err != nil {
+		return fail(InstallErrContentType, err)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	// 先暂存到磁盘，超过大小上限时立即中止下载
+	maxSize := h.securityConfig().MaxPluginSize
+	// 下载过程中广播字节数、速率和剩余时间，总长度已知时进度在 10 到 30 之间推进
+	body := newProgressReader(download, resp.ContentLength, func(p InstallProgress) {
+		p.PluginID = id
+		p.Status = phase
+		p.Phase = phase
+		p.Progress = 10
+		if p.TotalBytes > 0 {
+			p.Progress += int(min(p.BytesDownloaded, p.TotalBytes) * 20 / p.TotalBytes)
+		}
+		p.Message = "downloading plugin"
+		h.Broadcast(Event{Type: "plugin.installation.progress", Data: p})
+	})
+	staged, size, err := h.stageDownload(body, maxSize)
 	if err != nil {
-		h.installManager.CompleteInstallation(id, err)
-		return fmt.Errorf("read response failed: %w", err)
+		if size > maxSize {
+			return fail(InstallErrSizeExceeded, fmt.Errorf("size validation failed: %w", validator.CheckPluginSize(size)))
+		}
+		return fail(InstallErrDownload, fmt.Errorf("read response failed: %w", err))
 	}
+	defer os.Remove(staged)
 
-	// 检查文件大小
-	if err := validator.CheckPluginSize(int64(len(data))); err != nil {
-		h.installManager.CompleteInstallation(id, err)
-		return fmt.Errorf("size validation failed: %w", err)
+	// 下载内容即清单，超过清单大小上限时不读入内存
+	if limit := h.maxManifestBytes(); size > limit {
+		return fail(InstallErrManifest, fmt.Errorf("failed to parse manifest: %w: exceeds %d bytes", ErrManifestTooLarge, limit))
+	}
+
+	data, err := os.ReadFile(staged)
+	if err != nil {
+		return fail(InstallErrDownload, fmt.Errorf("read staged download failed: %w", err))
 	}
 
 	// 验证文件完整性
+	progress("verifying", 30, "verifying plugin")
 	if err := validator.VerifyFileIntegrity(data, wantSHA); err != nil {
-		h.installManager.CompleteInstallation(id, err)
-		return fmt.Errorf("integrity verification failed: %w", err)
+		return fail(InstallErrIntegrity, fmt.Errorf("integrity verification failed: %w", err))
 	}
-	// 解析并验证清单
-	var mf Manifest
-	if err := json.Unmarshal(data, &mf); err != nil {
-		installErr := fmt.Errorf("failed to parse manifest: %w", err)
-		h.installManager.CompleteInstallation(id, installErr)
-		return installErr
+
+	// 验证固定的发布者签名
+	if pubKey, ok := h.config.PinnedKeys[id]; ok {
+		if err := VerifyPinnedSignature(pubKey, data, signature); err != nil {
+			return fail(InstallErrSignature, fmt.Errorf("signature verification failed: %w", err))
+		}
+	}
+
+	// 解析并验证清单，JSON 和 YAML 清单解析为同一结构后走相同的校验
+	manifestName, yaml := h.downloadedManifestName(data)
+	if manifestName == "" {
+		return fail(InstallErrManifest, fmt.Errorf("failed to parse manifest: no accepted manifest file name for this format"))
+	}
+	mf, err := h.parseManifest(data, yaml)
+	if err != nil {
+		return fail(InstallErrManifest, fmt.Errorf("failed to parse manifest: %w", err))
 	}
 
 	// 验证清单内容
 	manifestValidation := validator.ValidateManifest(&mf)
 	if !manifestValidation.Valid {
-		installErr := fmt.Errorf("manifest validation failed: %v", manifestValidation.Errors)
-		h.installManager.CompleteInstallation(id, installErr)
-		return installErr
+		return fail(InstallErrManifest, fmt.Errorf("manifest %w", &ValidationFailedError{Errors: manifestValidation.Errors}))
 	}
 
+	mf.Tags = normalizeTags(mf.Tags)
+
 	// 验证ID匹配
 	if mf.ID != id {
-		installErr := fmt.Errorf("manifest ID '%s' does not match requested ID '%s'", mf.ID, id)
-		h.installManager.CompleteInstallation(id, installErr)
-		return installErr
+		return fail(InstallErrIDMismatch, fmt.Errorf("manifest ID '%s' does not match requested ID '%s'", mf.ID, id))
 	}
 
-	if mf.ID != "" {
-		// 创建插件目录
-		dir := filepath.Join(h.config.PluginsDir, mf.ID)
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			installErr := fmt.Errorf("failed to create plugin directory: %w", err)
-			h.installManager.CompleteInstallation(id, installErr)
-			return installErr
-		}
+	// 依赖宿主未实现的特性或 engines.sdk 不接受宿主SDK版本的插件不写入磁盘
+	if err := h.checkCompatible(mf); err != nil {
+		return fail(InstallErrIncompatible, err)
+	}
 
-		// 写入清单文件
-		manifestPath := filepath.Join(dir, "manifest.json")
-		if err := os.WriteFile(manifestPath, data, 0o644); err != nil {
-			installErr := fmt.Errorf("failed to write manifest file: %w", err)
-			h.installManager.CompleteInstallation(id, installErr)
-			return installErr
+	if hook := h.config.PreInstall; hook != nil {
+		if err := hook(mf.ID, mf); err != nil {
+			return fail(InstallErrHookRejected, fmt.Errorf("pre-install hook: %w", err))
 		}
+	}
+
+	// 创建插件目录
+	progress("configuring", 80, "configuring plugin")
+	dir := filepath.Join(h.config.PluginsDir, mf.ID)
+	_, statErr := os.Stat(dir)
+	newDir := os.IsNotExist(statErr)
+
+	// 在暂存目录写好清单后再放入插件目录，升级时先留存原有清单以便失败时恢复
+	var previous map[string][]byte
+	if !newDir {
+		previous = h.snapshotManifests(dir)
+	}
+	if err := h.placePluginManifest(dir, manifestName, data, newDir); err != nil {
+		return fail(InstallErrWrite, err)
+	}
 
-        // 注册插件
-        h.pluginsMu.Lock()
-        h.plugins[mf.ID] = &Plugin{Manifest: mf, Enabled: true}
-        h.pluginsMu.Unlock()
+	// 注册插件，数量检查与注册在同一把锁内完成，并发安装不会超出上限。
+	// 可能失败的步骤都在修改插件登记之前完成，失败时只需撤销清单
+	h.pluginsMu.Lock()
+	if h.pluginLimitReachedLocked(mf.ID) {
+		h.pluginsMu.Unlock()
+		h.undoPluginManifest(dir, newDir, previous)
+		return fail(InstallErrMaxPlugins, fmt.Errorf("maximum of %d plugins reached", h.securityConfig().MaxPlugins))
+	}
+	// 升级时新增的权限需批准后才生效，全新安装直接授予清单声明的权限
+	var pending, oldPending []string
+	enable := h.enableOnInstall()
+	if old, ok := h.plugins[mf.ID]; ok {
+		pending = addedPermissions(grantedPermissions(old), mf.Permissions)
+		oldPending = old.PendingPermissions
+		enable = old.Enabled
+	}
+	if autoEnable != nil {
+		enable = *autoEnable
+	}
+	if err := h.savePendingPermissions(mf.ID, pending); err != nil {
+		if restoreErr := h.savePendingPermissions(mf.ID, oldPending); restoreErr != nil {
+			log.Printf("restore pending permissions for %s: %v", mf.ID, restoreErr)
+		}
+		h.pluginsMu.Unlock()
+		h.undoPluginManifest(dir, newDir, previous)
+		return fail(InstallErrWrite, fmt.Errorf("failed to save pending permissions: %w", err))
+	}
+	h.plugins[mf.ID] = &Plugin{Manifest: mf, Enabled: enable, PendingPermissions: pending}
+	h.pluginsMu.Unlock()
+	h.syncManifestCommands(mf)
+	// 升级后以新清单重启后端进程
+	h.stopPluginProcess(mf.ID)
+	if enable {
+		h.startPluginProcess(mf)
+	}
+	if len(pending) > 0 {
+		h.Broadcast(Event{Type: "plugin.permissions.changed", Data: map[string]any{
+			"pluginId":         mf.ID,
+			"added":            pending,
+			"requiresApproval": true,
+		}})
+	}
 
-		// 完成安装
-		h.installManager.CompleteInstallation(id, nil)
-		return nil
+	if hook := h.config.OnInstall; hook != nil {
+		if err := hook(mf.ID, mf); err != nil {
+			log.Printf("install hook for %s: %v", mf.ID, err)
+		}
 	}
 
-	installErr := fmt.Errorf("unsupported plugin package format")
-	h.installManager.CompleteInstallation(id, installErr)
-	return installErr
+	// 完成安装
+	h.installManager.CompleteInstallation(id, nil)
+	h.Broadcast(Event{Type: "plugin.installed", Data: map[string]any{"pluginId": id, "enabled": enable}})
+	h.broadcastTerminal(Event{Type: "plugin.installation.done", Data: InstallProgress{
+		PluginID:         id,
+		Status:           "completed",
+		Progress:         100,
+		Terminal:         true,
+		RequiresApproval: len(pending) > 0,
+	}})
+	return nil
+}
+
+// pluginLimitReachedLocked 判断安装新插件是否会超出 MaxPlugins，已安装的插件（升级）不受限制，
+// 调用方须持有 pluginsMu
+func (h *PluginHost) pluginLimitReachedLocked(id string) bool {
+	limit := h.securityConfig().MaxPlugins
+	if limit <= 0 {
+		return false
+	}
+	_, exists := h.plugins[id]
+	return !exists && len(h.plugins) >= limit
 }
 
-func (h *PluginHost) uninstallPlugin(id string) error {
+// uninstallPlugin 卸载插件，默认先备份到 RootDir/backups，opts 可以跳过备份或指定备份目录
+func (h *PluginHost) uninstallPlugin(id string, opts UninstallOptions) error {
+    backupDir, err := h.uninstallBackupDir(opts)
+    if err != nil {
+        return err
+    }
+
+    unlock := h.pluginLocks.Lock(id)
+    defer unlock()
+
     // 先备份插件
-    backupPath, backupErr := h.backupPlugin(id)
-    if backupErr != nil {
-        // 备份失败，记录警告但继续卸载
-        fmt.Printf("Warning: Failed to backup plugin %s before uninstall: %v\n", id, backupErr)
-    } else {
-        fmt.Printf("Plugin %s backed up to: %s\n", id, backupPath)
+    backupPath := ""
+    if !opts.SkipBackup {
+        path, backupErr := h.backupPluginTo(id, backupDir)
+        if backupErr != nil {
+            // 备份失败，记录警告但继续卸载
+            fmt.Printf("Warning: Failed to backup plugin %s before uninstall: %v\n", id, backupErr)
+        } else {
+            backupPath = path
+            fmt.Printf("Plugin %s backed up to: %s\n", id, backupPath)
+        }
     }
     
+    manifest := Manifest{ID: id}
+    if p, ok := h.getPlugin(id); ok {
+        manifest = p.Manifest
+    }
+
+    h.stopPluginProcess(id)
+
     // 删除插件目录
     dir := filepath.Join(h.config.PluginsDir, id)
     if err := os.RemoveAll(dir); err != nil {
@@ -185,12 +393,22 @@ func (h *PluginHost) uninstallPlugin(id string) error {
     h.pluginsMu.Lock()
     delete(h.plugins, id)
     h.pluginsMu.Unlock()
+    h.removePluginCommands(id)
+    if err := h.savePendingPermissions(id, nil); err != nil {
+        log.Printf("remove pending permissions for %s: %v", id, err)
+    }
     
     // 广播卸载事件
     h.Broadcast(Event{Type: "plugin.uninstalled", Data: map[string]string{
         "pluginId": id,
         "backupPath": backupPath,
     }})
+
+    if hook := h.config.OnUninstall; hook != nil {
+        if err := hook(id, manifest); err != nil {
+            log.Printf("uninstall hook for %s: %v", id, err)
+        }
+    }
     
     return nil
 }
@@ -0,0 +1,207 @@
+package host
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveManifest 在本地服务器上提供清单，返回下载地址
+func serveManifest(t *testing.T, m Manifest) string {
+	t.Helper()
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/plugin.json"
+}
+
+func TestInstallRejectsIncompatibleSDKBeforeWriting(t *testing.T) {
+	h := newTestHost(t, Config{})
+	url := serveManifest(t, Manifest{ID: "future", Name: "Future", Version: "1.0.0", Engines: &Engines{SDK: ">=2.0.0"}})
+
+	err := h.installPluginFromURL("future", url, "", "", nil)
+	var installErr *InstallError
+	if !errors.As(err, &installErr) || installErr.Code != InstallErrIncompatible {
+		t.Fatalf("install error = %v, want %s", err, InstallErrIncompatible)
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "future")); !os.IsNotExist(err) {
+		t.Fatalf("plugin directory should not exist, stat err = %v", err)
+	}
+	if _, ok := h.getPlugin("future"); ok {
+		t.Fatal("incompatible plugin should not be registered")
+	}
+}
+
+func TestInstallAcceptsCompatibleSDK(t *testing.T) {
+	h := newTestHost(t, Config{})
+	url := serveManifest(t, Manifest{ID: "current", Name: "Current", Version: "1.0.0", Engines: &Engines{SDK: "^1.0.0"}})
+
+	if err := h.installPluginFromURL("current", url, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := h.getPlugin("current"); !ok || !p.Enabled {
+		t.Fatalf("plugin not installed and enabled: %+v", p)
+	}
+}
+
+func TestInstallUpgradeRollsBackWhenPendingPermissionsFail(t *testing.T) {
+	h := newTestHost(t, Config{})
+	v1 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", Permissions: []string{"vault.read"}})
+	if err := h.installPluginFromURL("demo", v1, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	before := h.snapshotManifests(filepath.Join(h.config.PluginsDir, "demo"))
+
+	// 待批准权限文件的位置被目录占用，保存新增权限时失败
+	blocker := h.pendingPermissionsPath("demo")
+	if err := os.MkdirAll(filepath.Join(blocker, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	v2 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "2.0.0", Permissions: []string{"vault.read", "vault.write"}})
+	err := h.installPluginFromURL("demo", v2, "", "", nil)
+	var installErr *InstallError
+	if !errors.As(err, &installErr) || installErr.Code != InstallErrWrite {
+		t.Fatalf("install error = %v, want %s", err, InstallErrWrite)
+	}
+
+	p, ok := h.getPlugin("demo")
+	if !ok || p.Manifest.Version != "1.0.0" || len(p.PendingPermissions) != 0 {
+		t.Fatalf("registry changed by failed upgrade: %+v", p)
+	}
+	after := h.snapshotManifests(filepath.Join(h.config.PluginsDir, "demo"))
+	if len(after) != len(before) {
+		t.Fatalf("manifest files = %v, want %v", len(after), len(before))
+	}
+	for name, data := range before {
+		if string(after[name]) != string(data) {
+			t.Errorf("manifest %s not restored: %s", name, after[name])
+		}
+	}
+}
+
+// signManifest 生成一对密钥并对清单序列化后的内容签名，返回base64编码的公钥和签名
+func signManifest(t *testing.T, m Manifest) (pubKey, signature string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data))
+}
+
+func TestInstallWithPinnedKey(t *testing.T) {
+	m := Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"}
+	pub, sig := signManifest(t, m)
+	h := newTestHost(t, Config{PinnedKeys: map[string]string{"demo": pub}})
+	url := serveManifest(t, m)
+
+	if err := h.installPluginFromURL("demo", url, "", sig, nil); err != nil {
+		t.Fatalf("correctly signed install: %v", err)
+	}
+	if _, ok := h.getPlugin("demo"); !ok {
+		t.Fatal("plugin not registered")
+	}
+}
+
+func TestInstallRejectsPinnedKeyMismatch(t *testing.T) {
+	m := Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"}
+	pinned, _ := signManifest(t, m)
+	// 另一把密钥的签名本身有效，但与固定的公钥不符
+	_, otherSig := signManifest(t, m)
+	h := newTestHost(t, Config{PinnedKeys: map[string]string{"demo": pinned}})
+	url := serveManifest(t, m)
+
+	for name, sig := range map[string]string{"other key": otherSig, "missing": ""} {
+		err := h.installPluginFromURL("demo", url, "", sig, nil)
+		var installErr *InstallError
+		if !errors.As(err, &installErr) || installErr.Code != InstallErrSignature {
+			t.Errorf("%s signature: install error = %v, want %s", name, err, InstallErrSignature)
+		}
+	}
+	if _, ok := h.getPlugin("demo"); ok {
+		t.Fatal("plugin with mismatched signature should not be registered")
+	}
+
+	// 未固定公钥的插件不要求签名
+	other := serveManifest(t, Manifest{ID: "other", Name: "Other", Version: "1.0.0"})
+	if err := h.installPluginFromURL("other", other, "", "", nil); err != nil {
+		t.Fatalf("unpinned install: %v", err)
+	}
+}
+
+func TestInstallErrorCodes(t *testing.T) {
+	valid, _ := json.Marshal(Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	other, _ := json.Marshal(Manifest{ID: "other", Name: "Other", Version: "1.0.0"})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/valid.json":
+			_, _ = w.Write(valid)
+		case "/other.json":
+			_, _ = w.Write(other)
+		case "/broken.json":
+			_, _ = w.Write([]byte(`{"id":`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cases := []struct {
+		name    string
+		cfg     func(*SecurityConfig)
+		path    string
+		wantSHA string
+		code    string
+	}{
+		{"download failed", nil, "/missing.json", "", InstallErrDownload},
+		{"size exceeded", func(c *SecurityConfig) { c.MaxPluginSize = 8 }, "/valid.json", "", InstallErrSizeExceeded},
+		{"integrity failed", nil, "/valid.json", strings.Repeat("0", 64), InstallErrIntegrity},
+		{"manifest invalid", nil, "/broken.json", "", InstallErrManifest},
+		{"id mismatch", nil, "/other.json", "", InstallErrIDMismatch},
+	}
+	for _, tc := range cases {
+		security := DefaultSecurityConfig()
+		if tc.cfg != nil {
+			tc.cfg(&security)
+		}
+		h := newTestHost(t, Config{Security: &security})
+		events := subscribeEvents(t, h)
+
+		err := h.installPluginFromURL("demo", srv.URL+tc.path, tc.wantSHA, "", nil)
+		if got := installErrorCode(err); got != tc.code {
+			t.Errorf("%s: code = %q (%v), want %s", tc.name, got, err, tc.code)
+			continue
+		}
+		if status := h.installManager.GetInstallationStatus("demo"); status == nil || status.Status != "failed" || status.ErrorCode != tc.code {
+			t.Errorf("%s: installation status = %+v", tc.name, status)
+		}
+		var failed map[string]any
+		for _, ev := range receivedEvents(t, events) {
+			if ev.Type == "plugin.installation.failed" {
+				failed, _ = ev.Data.(map[string]any)
+			}
+		}
+		if failed["code"] != tc.code {
+			t.Errorf("%s: failed event = %v", tc.name, failed)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveMarket 调用 /market 并返回响应
+func serveMarket(h *PluginHost, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.handleMarket(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+// marketErrorCode 校验响应为 JSON 错误信封并返回其中的错误码
+func marketErrorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("status %d: Content-Type = %q, body %q", w.Code, ct, w.Body.String())
+	}
+	var resp struct {
+		Error *marketError `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error == nil {
+		t.Fatalf("status %d: body %q is not an error envelope: %v", w.Code, w.Body.String(), err)
+	}
+	if resp.Error.Message == "" {
+		t.Errorf("%s: empty message", resp.Error.Code)
+	}
+	return resp.Error.Code
+}
+
+func TestMarketErrorsAreJSON(t *testing.T) {
+	h := newTestHost(t, Config{})
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"invalid json", http.MethodPost, "/market", "{", http.StatusBadRequest, "INVALID_JSON"},
+		{"unknown source", http.MethodPost, "/market", `{"id":"demo","source":"svn"}`, http.StatusBadRequest, "UNKNOWN_SOURCE"},
+		{"validation", http.MethodPost, "/market", `{"id":"bad id!","url":"https://github.com/p.json"}`, http.StatusBadRequest, InstallErrValidation},
+		{"download failed", http.MethodPost, "/market", `{"id":"demo","url":"http://127.0.0.1:1/p.json"}`, http.StatusBadRequest, InstallErrDownload},
+		{"delete missing id", http.MethodDelete, "/market", "", http.StatusBadRequest, "MISSING_ID"},
+		{"delete invalid skipBackup", http.MethodDelete, "/market?id=demo&skipBackup=maybe", "", http.StatusBadRequest, "INVALID_SKIP_BACKUP"},
+		{"delete backupDir without admin", http.MethodDelete, "/market?id=demo&backupDir=/tmp", "", http.StatusForbidden, "FORBIDDEN"},
+		{"method", http.MethodPut, "/market", "", http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+	}
+	for _, tc := range cases {
+		w := serveMarket(h, tc.method, tc.target, tc.body)
+		if w.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d", tc.name, w.Code, tc.status)
+		}
+		if code := marketErrorCode(t, w); code != tc.code {
+			t.Errorf("%s: code = %s, want %s", tc.name, code, tc.code)
+		}
+	}
+
+	h.SetReadOnly(true)
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		w := serveMarket(h, method, "/market?id=demo", `{}`)
+		if w.Code != http.StatusServiceUnavailable || marketErrorCode(t, w) != "READ_ONLY" {
+			t.Errorf("%s in read-only mode: got %d %s", method, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestMarketSuccessKeepsStatusCodes(t *testing.T) {
+	h := newTestHost(t, Config{})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+
+	if w := serveMarket(h, http.MethodPost, "/market", fmt.Sprintf(`{"id":"demo","url":%q}`, url)); w.Code != http.StatusCreated || w.Body.Len() != 0 {
+		t.Errorf("install: got %d %q, want 201 with empty body", w.Code, w.Body.String())
+	}
+	if w := serveMarket(h, http.MethodDelete, "/market?id=demo&skipBackup=true", ""); w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("uninstall: got %d %q, want 204 with empty body", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package host
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// marketPage 市场索引的简易 HTML 页面，安装按钮以 JSON 请求 POST /market
+var marketPage = template.Must(template.New("market").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Plugin Market</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: .5em; text-align: left; vertical-align: top; }
+.tag { background: #eee; border-radius: 3px; padding: 0 .3em; margin-right: .3em; font-size: .85em; }
+</style>
+</head>
+<body>
+<h1>Plugin Market</h1>
+{{if .}}
+<table>
+<thead><tr><th>Name</th><th>Version</th><th>Description</th><th>Tags</th><th></th></tr></thead>
+<tbody>
+{{range .}}<tr>
+<td>{{.Name}}<br><small>{{.ID}}</small></td>
+<td>{{.Version}}</td>
+<td>{{.Desc}}</td>
+<td>{{range .Tags}}<span class="tag">{{.}}</span>{{end}}</td>
+<td><button class="install" data-id="{{.ID}}" data-url="{{.URL}}" data-sha256="{{.SHA256}}" data-signature="{{.Signature}}">Install</button> <a href="{{.URL}}">Download</a></td>
+</tr>
+{{end}}</tbody>
+</table>
+{{else}}
+<p>No plugins available.</p>
+{{end}}
+<script>
+document.querySelectorAll("button.install").forEach(function (b) {
+  b.addEventListener("click", function () {
+    b.disabled = true;
+    fetch("/market", {
+      method: "POST",
+      headers: {"Content-Type": "application/json"},
+      body: JSON.stringify({id: b.dataset.id, url: b.dataset.url, sha256: b.dataset.sha256, signature: b.dataset.signature})
+    }).then(function (r) {
+      b.textContent = r.ok ? "Installing" : "Failed (" + r.status + ")";
+    }, function () {
+      b.textContent = "Failed";
+      b.disabled = false;
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// wantsHTML 按 Accept 请求头中的顺序，text/html 先于 application/json 出现时返回 true
+func wantsHTML(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		switch strings.ToLower(mediaType) {
+		case "text/html":
+			return true
+		case "application/json":
+			return false
+		}
+	}
+	return false
+}
+
+// writeMarketHTML 以 HTML 表格输出市场条目
+func writeMarketHTML(w http.ResponseWriter, items []MarketItem) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := marketPage.Execute(w, items); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,86 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWantsHTML(t *testing.T) {
+	cases := map[string]bool{
+		"":                                 false,
+		"*/*":                              false,
+		"application/json":                 false,
+		"text/html":                        true,
+		"TEXT/HTML; charset=utf-8":         true,
+		"text/html,application/json;q=0.9": true,
+		"application/json, text/html":      false,
+		"image/webp, text/html;q=0.8, */*": true,
+	}
+	for accept, want := range cases {
+		if got := wantsHTML(accept); got != want {
+			t.Errorf("wantsHTML(%q) = %v, want %v", accept, got, want)
+		}
+	}
+}
+
+func TestMarketIndexContentNegotiation(t *testing.T) {
+	index := serveMarketIndex(t, []MarketItem{
+		{ID: "demo", Name: "<b>Demo</b>", Version: "1.2.0", URL: "https://example.com/demo.zip", Tags: []string{"notes"}},
+	})
+	h := newTestHost(t, Config{MarketIndex: index})
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/market", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		h.handleMarket(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /market (Accept %q): got %d %s", accept, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: Vary = %q, want Accept", accept, w.Header().Get("Vary"))
+		}
+		return w
+	}
+
+	w := get("text/html,application/xhtml+xml,*/*;q=0.8")
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Fatalf("browser Accept: Content-Type = %q", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<table>", `data-id="demo"`, "1.2.0", `<span class="tag">notes</span>`, "&lt;b&gt;Demo&lt;/b&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("HTML page missing %q", want)
+		}
+	}
+	if strings.Contains(body, "<b>Demo</b>") {
+		t.Error("plugin name was not escaped")
+	}
+
+	for _, accept := range []string{"", "application/json", "application/json, text/html"} {
+		w := get(accept)
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Accept %q: Content-Type = %q, want application/json", accept, ct)
+		}
+		var items []MarketItem
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || len(items) != 1 {
+			t.Errorf("Accept %q: body %q is not the JSON index", accept, w.Body.String())
+		}
+	}
+}
+
+func TestMarketHTMLEmpty(t *testing.T) {
+	h := newTestHost(t, Config{MarketIndex: serveMarketIndex(t, nil)})
+	r := httptest.NewRequest(http.MethodGet, "/market", nil)
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	h.handleMarket(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "No plugins available.") {
+		t.Errorf("empty market page = %d %q", w.Code, w.Body.String())
+	}
+}
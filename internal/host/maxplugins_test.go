@@ -0,0 +1,78 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// maxPluginsHost 返回插件数量上限为 limit 的测试宿主
+func maxPluginsHost(t *testing.T, limit int) *PluginHost {
+	t.Helper()
+	cfg := DefaultSecurityConfig()
+	cfg.MaxPlugins = limit
+	return newTestHost(t, Config{Security: &cfg})
+}
+
+func TestMaxPluginsRejectsNewInstallsPastLimit(t *testing.T) {
+	h := maxPluginsHost(t, 2)
+	for _, id := range []string{"a", "b"} {
+		if err := h.installPluginFromURL(id, serveManifest(t, Manifest{ID: id, Name: id, Version: "1.0.0"}), "", "", nil); err != nil {
+			t.Fatalf("install %s: %v", id, err)
+		}
+	}
+
+	err := h.installPluginFromURL("c", serveManifest(t, Manifest{ID: "c", Name: "c", Version: "1.0.0"}), "", "", nil)
+	var installErr *InstallError
+	if !errors.As(err, &installErr) || installErr.Code != InstallErrMaxPlugins {
+		t.Fatalf("third install: err = %v, want %s", err, InstallErrMaxPlugins)
+	}
+	if _, ok := h.getPlugin("c"); ok {
+		t.Error("plugin c was registered past the limit")
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "c")); !os.IsNotExist(err) {
+		t.Errorf("rejected install left its directory: %v", err)
+	}
+
+	// 升级已安装的插件不受上限影响
+	if err := h.installPluginFromURL("a", serveManifest(t, Manifest{ID: "a", Name: "a", Version: "2.0.0"}), "", "", nil); err != nil {
+		t.Fatalf("upgrade at limit: %v", err)
+	}
+	if p, _ := h.getPlugin("a"); p.Manifest.Version != "2.0.0" {
+		t.Errorf("version after upgrade = %s", p.Manifest.Version)
+	}
+}
+
+func TestMaxPluginsConcurrentInstalls(t *testing.T) {
+	const limit = 3
+	h := maxPluginsHost(t, limit)
+	urls := make([]string, 8)
+	for i := range urls {
+		id := fmt.Sprintf("p%d", i)
+		urls[i] = serveManifest(t, Manifest{ID: id, Name: id, Version: "1.0.0"})
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(urls))
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			errs[i] = h.installPluginFromURL(fmt.Sprintf("p%d", i), url, "", "", nil)
+		}(i, url)
+	}
+	wg.Wait()
+
+	installed := 0
+	for _, err := range errs {
+		if err == nil {
+			installed++
+		}
+	}
+	if installed != limit || h.CountPlugins() != limit {
+		t.Errorf("installed %d (count %d), want exactly %d", installed, h.CountPlugins(), limit)
+	}
+}
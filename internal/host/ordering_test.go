@@ -0,0 +1,84 @@
+package host
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// resultField 依次取出 RPC 结果数组中每一项的 field 字段，以逗号连接
+func resultField(t *testing.T, result any, field string) string {
+	t.Helper()
+	items, ok := result.([]any)
+	if !ok {
+		t.Fatalf("result = %#v, want array", result)
+	}
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = fmt.Sprint(item.(map[string]any)[field])
+	}
+	return strings.Join(values, ",")
+}
+
+func TestGetPluginsStableOrder(t *testing.T) {
+	h := newTestHost(t, Config{})
+	names := map[string]string{"delta": "Alpha", "bravo": "Zulu", "alpha": "Mike", "charlie": "Alpha", "echo": "Kilo"}
+	for id, name := range names {
+		addTestPlugin(t, h, id)
+		h.pluginsMu.Lock()
+		h.plugins[id].Manifest.Name = name
+		h.pluginsMu.Unlock()
+	}
+
+	// map 遍历顺序随机，多次调用结果应始终相同
+	for i := 0; i < 20; i++ {
+		_, resp := callRPC(t, h, "alpha", "host.getPlugins", nil)
+		if got := resultField(t, resp.Result, "id"); got != "alpha,bravo,charlie,delta,echo" {
+			t.Fatalf("call %d: order = %s", i, got)
+		}
+		// 名称相同时按 id 排序
+		_, resp = callRPC(t, h, "alpha", "host.getPlugins", map[string]string{"sort": "name"})
+		if got := resultField(t, resp.Result, "id"); got != "charlie,delta,echo,alpha,bravo" {
+			t.Fatalf("call %d: name order = %s", i, got)
+		}
+	}
+	_, resp := callRPC(t, h, "alpha", "host.getPlugins", map[string]string{"sort": "id"})
+	if got := resultField(t, resp.Result, "id"); got != "alpha,bravo,charlie,delta,echo" {
+		t.Fatalf("sort=id order = %s", got)
+	}
+
+	status, resp := callRPC(t, h, "alpha", "host.getPlugins", map[string]string{"sort": "version"})
+	if status != http.StatusBadRequest || resp.Error == nil || resp.Error.Code != 400 {
+		t.Fatalf("invalid sort: %d %+v", status, resp.Error)
+	}
+}
+
+func TestListCommandsStableOrder(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "a")
+	for _, c := range []Command{
+		{ID: "zoom", PluginID: "b"}, {ID: "open", PluginID: "a"}, {ID: "close", PluginID: "b"},
+		{ID: "new", PluginID: "c"}, {ID: "save", PluginID: "a"}, {ID: "copy", PluginID: "a"},
+	} {
+		c.Title = c.ID
+		c.Source = CommandSourceRuntime
+		h.registerCommand(c)
+	}
+
+	want := "a:copy,a:open,a:save,b:close,b:zoom,c:new"
+	for i := 0; i < 20; i++ {
+		cmds := h.listCommands()
+		keys := make([]string, len(cmds))
+		for j, c := range cmds {
+			keys[j] = c.PluginID + ":" + c.ID
+		}
+		if got := strings.Join(keys, ","); got != want {
+			t.Fatalf("call %d: order = %s", i, got)
+		}
+	}
+	_, resp := callRPC(t, h, "a", "commands.list", nil)
+	if got := resultField(t, resp.Result, "id"); got != "copy,open,save,close,zoom,new" {
+		t.Fatalf("commands.list order = %s", got)
+	}
+}
@@ -0,0 +1,117 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// pendingPermissionsPath 返回插件待批准权限的保存位置，宿主重启后仍然保留
+func (h *PluginHost) pendingPermissionsPath(pluginID string) string {
+	return filepath.Join(h.config.RootDir, "permissions", pluginID+".pending.json")
+}
+
+// loadPendingPermissions 读取插件待批准的权限，文件不存在或损坏时返回空
+func (h *PluginHost) loadPendingPermissions(pluginID string) []string {
+	data, err := os.ReadFile(h.pendingPermissionsPath(pluginID))
+	if err != nil {
+		return nil
+	}
+	var perms []string
+	if err := json.Unmarshal(data, &perms); err != nil {
+		return nil
+	}
+	return perms
+}
+
+// savePendingPermissions 保存插件待批准的权限，为空时删除文件
+func (h *PluginHost) savePendingPermissions(pluginID string, perms []string) error {
+	path := h.pendingPermissionsPath(pluginID)
+	if len(perms) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(perms)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// grantedPermissions 返回插件已生效的清单权限，即清单声明中除去待批准的部分
+func grantedPermissions(p *Plugin) []string {
+	var granted []string
+	for _, perm := range p.Manifest.Permissions {
+		if !slices.Contains(p.PendingPermissions, perm) {
+			granted = append(granted, perm)
+		}
+	}
+	return granted
+}
+
+// allPermissions 返回插件ID到已授予权限的映射，待批准的权限不包括在内
+func (h *PluginHost) allPermissions() map[string][]string {
+	h.pluginsMu.RLock()
+	defer h.pluginsMu.RUnlock()
+	result := make(map[string][]string, len(h.plugins))
+	for id, p := range h.plugins {
+		granted := grantedPermissions(p)
+		if granted == nil {
+			granted = []string{}
+		}
+		result[id] = granted
+	}
+	return result
+}
+
+// addedPermissions 返回 next 中不在 granted 里的权限，已拥有 * 时不视为新增
+func addedPermissions(granted, next []string) []string {
+	if slices.Contains(granted, "*") {
+		return nil
+	}
+	var added []string
+	for _, perm := range next {
+		if !slices.Contains(granted, perm) && !slices.Contains(added, perm) {
+			added = append(added, perm)
+		}
+	}
+	return added
+}
+
+// approvePermissions 批准插件升级时新增的权限，返回本次批准的权限
+func (h *PluginHost) approvePermissions(pluginID string) ([]string, error) {
+	unlock := h.pluginLocks.Lock(pluginID)
+	defer unlock()
+
+	h.pluginsMu.Lock()
+	p, ok := h.plugins[pluginID]
+	if !ok {
+		h.pluginsMu.Unlock()
+		return nil, fmt.Errorf("plugin not found: %s", pluginID)
+	}
+	approved := p.PendingPermissions
+	h.pluginsMu.Unlock()
+	if len(approved) == 0 {
+		return []string{}, nil
+	}
+
+	if err := h.savePendingPermissions(pluginID, nil); err != nil {
+		return nil, err
+	}
+	h.pluginsMu.Lock()
+	p.PendingPermissions = nil
+	h.pluginsMu.Unlock()
+
+	h.Broadcast(Event{Type: "plugin.permissions.approved", Data: map[string]any{
+		"pluginId":    pluginID,
+		"permissions": approved,
+	}})
+	return approved, nil
+}
@@ -0,0 +1,178 @@
+package host
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+// upgradeWithPermissions 先安装声明 v1 权限的插件，再升级到声明 v2 权限的版本
+func upgradeWithPermissions(t *testing.T, h *PluginHost, v1, v2 []string) {
+	t.Helper()
+	if err := h.installPluginFromURL("demo", serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", Permissions: v1}), "", "", nil); err != nil {
+		t.Fatalf("install v1: %v", err)
+	}
+	if err := h.installPluginFromURL("demo", serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "2.0.0", Permissions: v2}), "", "", nil); err != nil {
+		t.Fatalf("install v2: %v", err)
+	}
+}
+
+func TestAddedPermissions(t *testing.T) {
+	cases := []struct {
+		granted, next, want []string
+	}{
+		{nil, []string{"vault.read"}, []string{"vault.read"}},
+		{[]string{"vault.read"}, []string{"vault.read", "vault.write", "vault.write"}, []string{"vault.write"}},
+		{[]string{"vault.read", "vault.write"}, []string{"vault.read"}, nil},
+		{[]string{"*"}, []string{"vault.write"}, nil},
+	}
+	for _, tc := range cases {
+		if got := addedPermissions(tc.granted, tc.next); !slices.Equal(got, tc.want) {
+			t.Errorf("addedPermissions(%v, %v) = %v, want %v", tc.granted, tc.next, got, tc.want)
+		}
+	}
+}
+
+func TestUpgradeWithholdsAddedPermissions(t *testing.T) {
+	h := newTestHost(t, Config{})
+	events := subscribeEvents(t, h)
+	upgradeWithPermissions(t, h, []string{"vault.read"}, []string{"vault.read", "vault.write"})
+
+	if !h.hasPermission("demo", "vault.read") {
+		t.Error("permission granted before the upgrade was withdrawn")
+	}
+	if h.hasPermission("demo", "vault.write") {
+		t.Error("permission added by the upgrade was granted before approval")
+	}
+	if code, _ := callRPC(t, h, "demo", "vault.write", map[string]any{"path": "a.md", "content": "x"}); code != http.StatusForbidden {
+		t.Errorf("vault.write before approval: status = %d, want 403", code)
+	}
+
+	var changed, done map[string]any
+	for _, ev := range receivedEvents(t, events) {
+		switch ev.Type {
+		case "plugin.permissions.changed":
+			changed = ev.Data.(map[string]any)
+		case "plugin.installation.done":
+			done = ev.Data.(map[string]any)
+		}
+	}
+	if changed == nil || changed["requiresApproval"] != true || !slices.Equal(changed["added"].([]any), []any{"vault.write"}) {
+		t.Errorf("plugin.permissions.changed = %v, want added [vault.write]", changed)
+	}
+	if done == nil || done["requiresApproval"] != true {
+		t.Errorf("plugin.installation.done = %v, want requiresApproval", done)
+	}
+
+	_, resp := callRPC(t, h, "", "host.getPlugins", nil)
+	plugins, _ := resp.Result.([]any)
+	if len(plugins) != 1 || !slices.Equal(plugins[0].(map[string]any)["pendingPermissions"].([]any), []any{"vault.write"}) {
+		t.Errorf("host.getPlugins = %v, want pendingPermissions [vault.write]", resp.Result)
+	}
+
+	// 待批准的权限在宿主重启后仍然保留
+	restarted := newTestHost(t, Config{RootDir: h.config.RootDir, PluginsDir: h.config.PluginsDir, VaultDir: h.config.VaultDir})
+	if err := restarted.LoadPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.hasPermission("demo", "vault.write") {
+		t.Error("pending permission was granted after a restart")
+	}
+}
+
+func TestUpgradeWithoutNewPermissions(t *testing.T) {
+	h := newTestHost(t, Config{})
+	events := subscribeEvents(t, h)
+	upgradeWithPermissions(t, h, []string{"vault.read", "vault.write"}, []string{"vault.read"})
+
+	if p, _ := h.getPlugin("demo"); len(p.PendingPermissions) != 0 {
+		t.Errorf("pending = %v, want none", p.PendingPermissions)
+	}
+	if h.hasPermission("demo", "vault.write") {
+		t.Error("permission dropped by the upgrade is still granted")
+	}
+	for _, ev := range receivedEvents(t, events) {
+		if ev.Type == "plugin.permissions.changed" {
+			t.Errorf("unexpected plugin.permissions.changed: %v", ev.Data)
+		}
+	}
+	if _, err := os.Stat(h.pendingPermissionsPath("demo")); !os.IsNotExist(err) {
+		t.Errorf("pending permissions file exists: %v", err)
+	}
+}
+
+func TestApprovePermissionsRPC(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	upgradeWithPermissions(t, h, []string{"vault.read"}, []string{"vault.read", "vault.write"})
+	events := subscribeEvents(t, h)
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	if code, _ := callRPC(t, h, "demo", "host.approvePermissions", map[string]any{"pluginId": "demo"}); code != http.StatusForbidden {
+		t.Errorf("non-admin approve: status = %d, want 403", code)
+	}
+	if code, _ := callRPCWithHeader(t, h, admin, "", "host.approvePermissions", map[string]any{"pluginId": "missing"}); code != http.StatusNotFound {
+		t.Errorf("approve unknown plugin: status = %d, want 404", code)
+	}
+
+	code, resp := callRPCWithHeader(t, h, admin, "", "host.approvePermissions", map[string]any{"pluginId": "demo"})
+	if code != http.StatusOK {
+		t.Fatalf("approve: got %d %+v", code, resp.Error)
+	}
+	if approved := resp.Result.(map[string]any)["approved"].([]any); !slices.Equal(approved, []any{"vault.write"}) {
+		t.Errorf("approved = %v, want [vault.write]", approved)
+	}
+	if !h.hasPermission("demo", "vault.write") {
+		t.Error("approved permission is not granted")
+	}
+	if _, err := os.Stat(h.pendingPermissionsPath("demo")); !os.IsNotExist(err) {
+		t.Errorf("pending permissions file kept after approval: %v", err)
+	}
+	if types := eventTypes(receivedEvents(t, events)); !slices.Contains(types, "plugin.permissions.approved") {
+		t.Errorf("events = %v, want plugin.permissions.approved", types)
+	}
+	if logs, _ := h.queryAudit("permission.approve", time.Time{}, time.Time{}); len(logs) != 1 || logs[0].Target != "demo" {
+		t.Errorf("permission.approve audit = %+v, want one entry for demo", logs)
+	}
+
+	// 没有待批准的权限时返回空列表，不再写审计
+	code, resp = callRPCWithHeader(t, h, admin, "", "host.approvePermissions", map[string]any{"pluginId": "demo"})
+	if approved, _ := resp.Result.(map[string]any)["approved"].([]any); code != http.StatusOK || len(approved) != 0 {
+		t.Errorf("second approve = %d %v, want nothing approved", code, resp.Result)
+	}
+	if logs, _ := h.queryAudit("permission.approve", time.Time{}, time.Time{}); len(logs) != 1 {
+		t.Errorf("got %d permission.approve audit entries, want 1", len(logs))
+	}
+}
+
+func TestGetAllPermissions(t *testing.T) {
+	h := newTestHost(t, Config{})
+	upgradeWithPermissions(t, h, []string{"vault.read"}, []string{"vault.read", "vault.write"})
+	addTestPlugin(t, h, "reader", "vault.read", "commands.register")
+	addTestPlugin(t, h, "plain")
+
+	code, resp := callRPC(t, h, "", "host.getAllPermissions", nil)
+	if code != http.StatusOK {
+		t.Fatalf("host.getAllPermissions: got %d %+v", code, resp.Error)
+	}
+	all := resp.Result.(map[string]any)
+	if len(all) != 3 {
+		t.Fatalf("result = %v, want every plugin", all)
+	}
+	// 与逐个插件查询的结果一致，待批准的权限不包括在内
+	for _, id := range []string{"demo", "reader", "plain"} {
+		p, _ := h.getPlugin(id)
+		want := []any{}
+		for _, perm := range grantedPermissions(p) {
+			want = append(want, perm)
+		}
+		got, ok := all[id].([]any)
+		if !ok || !slices.Equal(got, want) {
+			t.Errorf("%s permissions = %#v, want %v", id, all[id], want)
+		}
+	}
+	if got := all["demo"].([]any); slices.Contains(got, any("vault.write")) {
+		t.Errorf("pending permission listed as granted: %v", got)
+	}
+}
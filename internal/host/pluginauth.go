@@ -0,0 +1,80 @@
+package host
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+// pluginKeyHeader 插件凭据所在的请求头
+const pluginKeyHeader = "X-Plugin-Key"
+
+var (
+	// errInvalidPluginKey 凭据不对应任何插件
+	errInvalidPluginKey = errors.New("invalid plugin key")
+	// errPluginKeyRequired 已配置插件凭据时，声明 pluginId 的请求必须携带凭据
+	errPluginKeyRequired = errors.New("plugin key required")
+	// errPluginIDMismatch 请求声明的 pluginId 与凭据不符
+	errPluginIDMismatch = errors.New("pluginId does not match credential")
+)
+
+// pluginIDForKey 返回凭据对应的插件ID
+func (h *PluginHost) pluginIDForKey(key string) (string, bool) {
+	for id, want := range h.config.PluginKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(want)) == 1 {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// resolvePluginID 根据请求凭据确定生效的插件ID：携带凭据时以凭据为准并拒绝不一致的声明；
+// 未配置任何插件凭据时沿用请求中声明的 pluginId，这样得到的ID只用于权限检查，不能证明调用方身份，
+// 操作其他插件或代替管理员操作时须经 authorizeTargetPlugin
+func (h *PluginHost) resolvePluginID(r *http.Request, claimed string) (string, error) {
+	key := r.Header.Get(pluginKeyHeader)
+	if key == "" {
+		if len(h.config.PluginKeys) > 0 && claimed != "" {
+			return "", errPluginKeyRequired
+		}
+		return claimed, nil
+	}
+	id, ok := h.pluginIDForKey(key)
+	if !ok {
+		return "", errInvalidPluginKey
+	}
+	if claimed != "" && claimed != id {
+		return "", errPluginIDMismatch
+	}
+	return id, nil
+}
+
+// pluginAuthStatus 返回凭据错误对应的HTTP状态码
+func pluginAuthStatus(err error) int {
+	if errors.Is(err, errPluginIDMismatch) {
+		return http.StatusForbidden
+	}
+	return http.StatusUnauthorized
+}
+
+// authenticatedAs 判断请求是否携带了属于 pluginID 的凭据，仅声明 pluginId 不算认证
+func (h *PluginHost) authenticatedAs(r *http.Request, pluginID string) bool {
+	key := r.Header.Get(pluginKeyHeader)
+	if pluginID == "" || key == "" {
+		return false
+	}
+	id, ok := h.pluginIDForKey(key)
+	return ok && id == pluginID
+}
+
+// authorizeTargetPlugin 判断请求能否操作 target 插件并返回实际操作的插件ID：管理员可操作任意插件；
+// 其他调用方须以凭据认证，只能操作自己，target 为空时取调用方自身
+func (h *PluginHost) authorizeTargetPlugin(r *http.Request, req rpcRequest, target string) (string, bool) {
+	if h.isAdmin(r) {
+		return target, true
+	}
+	if !h.authenticatedAs(r, req.PluginID) || (target != "" && target != req.PluginID) {
+		return "", false
+	}
+	return req.PluginID, true
+}
@@ -0,0 +1,85 @@
+package host
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClaimedPluginIDDoesNotAuthorizeTargetOps(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "alpha")
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	// 未配置插件凭据时，声明的 pluginId 仍可用于普通 RPC，但不能操作指定的插件
+	methods := []string{"host.resetPlugin", "host.backupPlugin", "host.getPluginStats", "host.getPluginProcess", "host.getPluginSettings"}
+	for _, method := range methods {
+		params := map[string]any{"pluginId": "alpha", "scope": []string{ResetScopeSettings}}
+		if code, _ := callRPC(t, h, "alpha", method, params); code != 403 {
+			t.Errorf("%s with claimed pluginId: got %d, want 403", method, code)
+		}
+		if code, resp := callRPCWithHeader(t, h, admin, "", method, params); code != 200 {
+			t.Errorf("%s as admin: got %d %+v", method, code, resp.Error)
+		}
+	}
+}
+
+func TestAuthorizeTargetPlugin(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret", PluginKeys: map[string]string{"alpha": "key-a"}})
+	cases := []struct {
+		name   string
+		header http.Header
+		caller string
+		target string
+		want   string
+		ok     bool
+	}{
+		{"admin any plugin", http.Header{"Authorization": {"Bearer secret"}}, "", "beta", "beta", true},
+		{"authenticated self", http.Header{pluginKeyHeader: {"key-a"}}, "alpha", "alpha", "alpha", true},
+		{"authenticated default self", http.Header{pluginKeyHeader: {"key-a"}}, "alpha", "", "alpha", true},
+		{"authenticated other", http.Header{pluginKeyHeader: {"key-a"}}, "alpha", "beta", "", false},
+		{"claimed only", nil, "alpha", "alpha", "", false},
+		{"anonymous", nil, "", "", "", false},
+	}
+	for _, c := range cases {
+		r, _ := http.NewRequest(http.MethodPost, "/rpc", nil)
+		for k, v := range c.header {
+			r.Header[k] = v
+		}
+		got, ok := h.authorizeTargetPlugin(r, rpcRequest{PluginID: c.caller}, c.target)
+		if got != c.want || ok != c.ok {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", c.name, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestCannotRegisterCommandsAsAnotherPlugin(t *testing.T) {
+	h := newTestHost(t, Config{PluginKeys: map[string]string{"alpha": "key-a", "beta": "key-b"}})
+	addTestPlugin(t, h, "alpha", "commands.register")
+	addTestPlugin(t, h, "beta", "commands.register")
+	alpha := http.Header{pluginKeyHeader: {"key-a"}}
+	params := map[string]any{"id": "beta.hijack", "title": "Hijack"}
+
+	if code, _ := callRPCWithHeader(t, h, alpha, "beta", "commands.register", params); code != 403 {
+		t.Errorf("claiming another plugin's ID with a credential: got %d, want 403", code)
+	}
+	if code, _ := callRPC(t, h, "beta", "commands.register", params); code != 401 {
+		t.Errorf("claiming a plugin ID without a credential: got %d, want 401", code)
+	}
+	if code, _ := callRPCWithHeader(t, h, http.Header{pluginKeyHeader: {"forged"}}, "", "commands.register", params); code != 401 {
+		t.Errorf("unknown credential: got %d, want 401", code)
+	}
+	for _, c := range h.listCommands() {
+		if c.PluginID == "beta" {
+			t.Fatalf("command registered under beta: %+v", c)
+		}
+	}
+
+	// 不声明 pluginId 时以凭据确定身份
+	if code, resp := callRPCWithHeader(t, h, alpha, "", "commands.register", params); code != 200 {
+		t.Fatalf("register with credential: got %d %+v", code, resp.Error)
+	}
+	cmds := h.listCommands()
+	if len(cmds) != 1 || cmds[0].PluginID != "alpha" {
+		t.Errorf("commands = %+v, want one owned by alpha", cmds)
+	}
+}
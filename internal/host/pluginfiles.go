@@ -0,0 +1,64 @@
+package host
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PluginFile 插件包中的一个文件
+type PluginFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Mode string `json:"mode"`
+}
+
+// listPluginFiles 列出已安装插件目录下的文件，路径以 / 分隔并相对于插件目录。
+// glob 不含 / 时匹配文件名，否则匹配完整相对路径；符号链接只列出自身，不会跟随
+func (h *PluginHost) listPluginFiles(pluginID, glob string) ([]PluginFile, error) {
+	if _, ok := h.getPlugin(pluginID); !ok {
+		return nil, fmt.Errorf("plugin not found: %s", pluginID)
+	}
+	if !filepath.IsLocal(pluginID) || strings.ContainsAny(pluginID, `/\`) {
+		return nil, fmt.Errorf("invalid plugin id: %s", pluginID)
+	}
+	if glob != "" {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+	}
+
+	dir := filepath.Join(h.config.PluginsDir, pluginID)
+	files := []PluginFile{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if glob != "" {
+			name := rel
+			if !strings.Contains(glob, "/") {
+				name = path.Base(rel)
+			}
+			if ok, _ := path.Match(glob, name); !ok {
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, PluginFile{Path: rel, Size: info.Size(), Mode: info.Mode().String()})
+		return nil
+	})
+	return files, err
+}
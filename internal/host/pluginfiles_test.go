@@ -0,0 +1,122 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePluginFixture 在插件目录下写入测试文件
+func writePluginFixture(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listFilesRPC 以管理员身份调用 host.listPluginFiles
+func listFilesRPC(t *testing.T, h *PluginHost, params any) (int, []PluginFile) {
+	t.Helper()
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	code, resp := callRPCWithHeader(t, h, admin, "", "host.listPluginFiles", params)
+	if code != http.StatusOK {
+		return code, nil
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []PluginFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	return code, files
+}
+
+// filePaths 返回文件列表中的路径，以逗号连接
+func filePaths(files []PluginFile) string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return strings.Join(paths, ",")
+}
+
+func TestListPluginFiles(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "demo")
+	dir := filepath.Join(h.config.PluginsDir, "demo")
+	writePluginFixture(t, dir, map[string]string{
+		"manifest.json":     `{"id":"demo"}`,
+		"dist/main.js":      "console.log(1)",
+		"dist/lib/util.js":  "export {}",
+		"assets/icon.png":   "png",
+		"assets/styles.css": "body{}",
+	})
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	writePluginFixture(t, filepath.Dir(outside), map[string]string{"secret.txt": "top secret"})
+	if err := os.Symlink(outside, filepath.Join(dir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	code, files := listFilesRPC(t, h, map[string]string{"pluginId": "demo"})
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if got := filePaths(files); got != "assets/icon.png,assets/styles.css,dist/lib/util.js,dist/main.js,link.txt,manifest.json" {
+		t.Fatalf("paths = %s", got)
+	}
+	for _, f := range files {
+		switch f.Path {
+		case "dist/main.js":
+			if f.Size != int64(len("console.log(1)")) || !strings.HasPrefix(f.Mode, "-rw") {
+				t.Errorf("main.js = %+v", f)
+			}
+		case "link.txt":
+			// 符号链接只列出自身，不跟随到插件目录之外
+			if !strings.HasPrefix(f.Mode, "L") || f.Size == int64(len("top secret")) {
+				t.Errorf("symlink = %+v", f)
+			}
+		}
+	}
+
+	if _, files = listFilesRPC(t, h, map[string]string{"pluginId": "demo", "glob": "*.js"}); filePaths(files) != "dist/lib/util.js,dist/main.js" {
+		t.Errorf("glob *.js = %s", filePaths(files))
+	}
+	if _, files = listFilesRPC(t, h, map[string]string{"pluginId": "demo", "glob": "assets/*"}); filePaths(files) != "assets/icon.png,assets/styles.css" {
+		t.Errorf("glob assets/* = %s", filePaths(files))
+	}
+}
+
+func TestListPluginFilesErrors(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "demo")
+	writePluginFixture(t, filepath.Join(h.config.PluginsDir, "demo"), map[string]string{"manifest.json": "{}"})
+
+	if code, _ := callRPC(t, h, "demo", "host.listPluginFiles", map[string]string{"pluginId": "demo"}); code != http.StatusForbidden {
+		t.Errorf("non-admin: status = %d, want 403", code)
+	}
+	cases := []struct {
+		params map[string]string
+		want   int
+	}{
+		{map[string]string{}, http.StatusBadRequest},
+		{map[string]string{"pluginId": "missing"}, http.StatusNotFound},
+		{map[string]string{"pluginId": "../demo"}, http.StatusNotFound},
+		{map[string]string{"pluginId": "demo", "glob": "["}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if code, _ := listFilesRPC(t, h, tc.params); code != tc.want {
+			t.Errorf("%v: status = %d, want %d", tc.params, code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package host
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultPluginIcon 插件未声明图标或图标文件缺失时返回的默认图标
+const defaultPluginIcon = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 64 64"><rect width="64" height="64" rx="12" fill="#8c8c8c"/><path d="M26 14h12v8a4 4 0 0 0 8 0v-2h4v12h-2a4 4 0 0 0 0 8h2v12H38v-2a4 4 0 0 0-8 0v2H14V38h2a4 4 0 0 0 0-8h-2V20h12z" fill="#fff"/></svg>`
+
+// iconContentTypes 允许作为插件图标的文件扩展名及其内容类型
+var iconContentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+}
+
+// isIconPath 检查清单中的图标是否为插件包内允许类型的相对路径
+func isIconPath(icon string) bool {
+	p := filepath.ToSlash(icon)
+	if strings.HasPrefix(p, "/") || p == ".." || strings.HasPrefix(p, "../") || strings.Contains(p, "/../") {
+		return false
+	}
+	_, ok := iconContentTypes[strings.ToLower(path.Ext(p))]
+	return ok
+}
+
+// pluginIconURL 返回插件图标的固定访问地址
+func pluginIconURL(pluginID string) string {
+	return "/plugins/" + pluginID + "/" + hostRoutePrefix + "icon"
+}
+
+// resolvePluginIcon 返回插件图标文件的绝对路径，解析符号链接后仍须位于插件目录内
+func (h *PluginHost) resolvePluginIcon(m Manifest) (string, bool) {
+	if m.Icon == "" || !isIconPath(m.Icon) {
+		return "", false
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Join(h.config.PluginsDir, m.ID))
+	if err != nil {
+		return "", false
+	}
+	target, err := filepath.EvalSymlinks(filepath.Join(dir, filepath.FromSlash(path.Clean("/"+m.Icon))))
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return target, true
+}
+
+// handlePluginIcon 处理 GET /plugins/<id>/_host/icon，插件未声明图标或文件缺失时返回默认图标
+func (h *PluginHost) handlePluginIcon(w http.ResponseWriter, r *http.Request, pluginID string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	p, ok := h.getPlugin(pluginID)
+	if !ok {
+		http.Error(w, "plugin not found", http.StatusNotFound)
+		return
+	}
+	// 图标可能是 SVG，禁止浏览器嗅探类型和执行其中的脚本
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+
+	if target, ok := h.resolvePluginIcon(p.Manifest); ok {
+		if f, err := os.Open(target); err == nil {
+			defer f.Close()
+			if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+				w.Header().Set("Content-Type", iconContentTypes[strings.ToLower(filepath.Ext(target))])
+				http.ServeContent(w, r, "", info.ModTime(), f)
+				return
+			}
+		}
+	}
+	w.Header().Set("Content-Type", iconContentTypes[".svg"])
+	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(defaultPluginIcon))
+}
+
+// hostRoutePrefix 插件目录下保留给宿主的路径前缀，插件包内同名的文件不会被当作静态资源提供，
+// 宿主路由因此不会遮蔽插件自己的 icon、backup 等文件
+const hostRoutePrefix = "_host/"
+
+// pluginsHandler 提供插件静态资源，/plugins/<id>/_host/icon 交给 handlePluginIcon，
+// /plugins/<id>/_host/backup 交给 handlePluginBackup，其余 _host/ 下的路径返回 404
+func (h *PluginHost) pluginsHandler(files http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.TrimPrefix(r.URL.Path, "/plugins/")
+		if id, name, ok := strings.Cut(rest, "/"); ok && id != "" {
+			if route, ok := strings.CutPrefix(name, hostRoutePrefix); ok {
+				switch route {
+				case "icon":
+					h.handlePluginIcon(w, r, id)
+				case "backup":
+					h.handlePluginBackup(w, r, id)
+				default:
+					http.NotFound(w, r)
+				}
+				return
+			}
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,168 @@
+package host
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPluginsHandlerHostRoutes(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "demo")
+	// 插件包内自带名为 icon、backup 的文件，不应被宿主路由遮蔽
+	for _, name := range []string{"icon", "backup"} {
+		if err := os.WriteFile(filepath.Join(h.config.PluginsDir, "demo", name), []byte("plugin "+name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := h.pluginsHandler(http.StripPrefix("/plugins/", http.FileServer(http.Dir(h.config.PluginsDir))))
+
+	serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for _, name := range []string{"icon", "backup"} {
+		w := serve("/plugins/demo/"+name, nil)
+		if w.Code != http.StatusOK || w.Body.String() != "plugin "+name {
+			t.Errorf("/plugins/demo/%s = %d %q, want the plugin's own file", name, w.Code, w.Body.String())
+		}
+	}
+
+	if w := serve(pluginIconURL("demo"), nil); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<svg") {
+		t.Errorf("icon route = %d %q, want default icon", w.Code, w.Body.String())
+	}
+	if w := serve("/plugins/demo/_host/backup", nil); w.Code != http.StatusForbidden {
+		t.Errorf("backup route without admin = %d, want 403", w.Code)
+	}
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	if w := serve("/plugins/demo/_host/backup", admin); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Errorf("backup route as admin = %d %q, want zip", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w := serve("/plugins/demo/_host/unknown", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown host route = %d, want 404", w.Code)
+	}
+}
+
+func TestIsIconPath(t *testing.T) {
+	cases := map[string]bool{
+		"icon.png":           true,
+		"assets/logo.SVG":    true,
+		"./icon.webp":        true,
+		"icon.txt":           false,
+		"icon":               false,
+		"/etc/icon.png":      false,
+		"../icon.png":        false,
+		"assets/../../x.png": false,
+		"..":                 false,
+	}
+	for icon, want := range cases {
+		if got := isIconPath(icon); got != want {
+			t.Errorf("isIconPath(%q) = %v, want %v", icon, got, want)
+		}
+	}
+}
+
+// setTestIcon 在插件目录写入图标文件并把清单 icon 指向它
+func setTestIcon(t *testing.T, h *PluginHost, id, icon string, data []byte) {
+	t.Helper()
+	if data != nil {
+		path := filepath.Join(h.config.PluginsDir, id, filepath.FromSlash(icon))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.pluginsMu.Lock()
+	defer h.pluginsMu.Unlock()
+	h.plugins[id].Manifest.Icon = icon
+}
+
+func TestPluginIconServesDeclaredIcon(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo")
+	png := []byte("\x89PNG\r\n\x1a\nfake")
+	setTestIcon(t, h, "demo", "assets/icon.png", png)
+
+	w := httptest.NewRecorder()
+	h.handlePluginIcon(w, httptest.NewRequest(http.MethodGet, pluginIconURL("demo"), nil), "demo")
+	if w.Code != http.StatusOK || w.Body.String() != string(png) {
+		t.Fatalf("icon = %d %q, want the declared png", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", ct)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || !strings.Contains(w.Header().Get("Content-Security-Policy"), "sandbox") {
+		t.Errorf("icon response missing hardening headers: %v", w.Header())
+	}
+}
+
+func TestPluginIconFallsBackToDefault(t *testing.T) {
+	h := newTestHost(t, Config{})
+	outside := filepath.Join(t.TempDir(), "secret.png")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]func(id string){
+		"undeclared": func(id string) {},
+		"missing":    func(id string) { setTestIcon(t, h, id, "icon.png", nil) },
+		"traversal":  func(id string) { setTestIcon(t, h, id, "../secret.png", nil) },
+		"directory": func(id string) {
+			if err := os.Mkdir(filepath.Join(h.config.PluginsDir, id, "dir.png"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			setTestIcon(t, h, id, "dir.png", nil)
+		},
+		// 图标是指向插件目录外的符号链接，不应被跟随
+		"symlink escape": func(id string) {
+			if err := os.Symlink(outside, filepath.Join(h.config.PluginsDir, id, "icon.png")); err != nil {
+				t.Skip(err)
+			}
+			setTestIcon(t, h, id, "icon.png", nil)
+		},
+	}
+	i := 0
+	for name, setup := range cases {
+		id := fmt.Sprintf("p%d", i)
+		i++
+		addTestPlugin(t, h, id)
+		setup(id)
+
+		w := httptest.NewRecorder()
+		h.handlePluginIcon(w, httptest.NewRequest(http.MethodGet, pluginIconURL(id), nil), id)
+		if w.Code != http.StatusOK || w.Body.String() != defaultPluginIcon {
+			t.Errorf("%s: icon = %d %q, want default icon", name, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+			t.Errorf("%s: Content-Type = %q, want image/svg+xml", name, ct)
+		}
+	}
+}
+
+func TestPluginIconErrors(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo")
+
+	w := httptest.NewRecorder()
+	h.handlePluginIcon(w, httptest.NewRequest(http.MethodGet, pluginIconURL("missing"), nil), "missing")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown plugin: status = %d, want 404", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.handlePluginIcon(w, httptest.NewRequest(http.MethodPost, pluginIconURL("demo"), nil), "demo")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST icon: status = %d, want 405", w.Code)
+	}
+}
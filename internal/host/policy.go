@@ -0,0 +1,124 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ErrVaultWriteRejected 写入被存储库写入策略拒绝
+var ErrVaultWriteRejected = errors.New("vault write rejected")
+
+// VaultWritePolicy 存储库写入策略，在每次写入前调用，返回错误时拒绝写入。
+// 流式写入时 content 只包含开头的 1 MiB，需要检查完整内容的策略应实现 VaultStreamPolicy
+type VaultWritePolicy interface {
+	Allow(path string, content []byte) error
+}
+
+// VaultStreamPolicy 可选接口，流式写入时以读取器提供完整内容代替截断的 content
+type VaultStreamPolicy interface {
+	AllowStream(path string, r io.Reader) error
+}
+
+// vaultPolicyInspectBytes 流式写入时交给 VaultWritePolicy.Allow 的最大字节数
+const vaultPolicyInspectBytes = 1 << 20
+
+// AllowAllPolicy 默认策略，允许所有写入
+type AllowAllPolicy struct{}
+
+func (AllowAllPolicy) Allow(string, []byte) error { return nil }
+
+// ExtensionBlocklistPolicy 拒绝写入指定扩展名的文件，扩展名不区分大小写，如 ".exe"
+type ExtensionBlocklistPolicy struct {
+	Extensions []string
+}
+
+func (p ExtensionBlocklistPolicy) Allow(path string, _ []byte) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return nil
+	}
+	for _, blocked := range p.Extensions {
+		if strings.ToLower(blocked) == ext {
+			return fmt.Errorf("%w: files with extension %s are not allowed", ErrVaultWriteRejected, ext)
+		}
+	}
+	return nil
+}
+
+// normalizeExtension 把 "md"、".MD" 这样的配置项规整为 ".md"
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// checkVaultExtension 按扩展名列表检查写入路径，禁止列表优先；两个列表都为空时允许所有扩展名。
+// 配置了允许列表时没有扩展名的文件同样被拒绝
+func checkVaultExtension(path string, allowed, blocked []string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != "" {
+		for _, b := range blocked {
+			if normalizeExtension(b) == ext {
+				return fmt.Errorf("%w: files with extension %s are not allowed", ErrVaultWriteRejected, ext)
+			}
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, a := range allowed {
+		if normalizeExtension(a) == ext {
+			return nil
+		}
+	}
+	if ext == "" {
+		return fmt.Errorf("%w: files without an extension are not allowed", ErrVaultWriteRejected)
+	}
+	return fmt.Errorf("%w: extension %s is not in the allowed list", ErrVaultWriteRejected, ext)
+}
+
+// checkVaultWrite 按配置的策略检查写入，策略返回的错误统一包装为 ErrVaultWriteRejected
+func (h *PluginHost) checkVaultWrite(relPath string, content []byte) error {
+	policy := h.config.VaultWritePolicy
+	if policy == nil {
+		return nil
+	}
+	if err := policy.Allow(filepath.Clean(relPath), content); err != nil {
+		if errors.Is(err, ErrVaultWriteRejected) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrVaultWriteRejected, err)
+	}
+	return nil
+}
+
+// checkVaultWriteStream 按配置的策略检查流式写入：策略实现了 VaultStreamPolicy 时读取完整内容，
+// 否则只把开头的 vaultPolicyInspectBytes 字节交给 Allow
+func (h *PluginHost) checkVaultWriteStream(relPath string, r io.Reader) error {
+	policy := h.config.VaultWritePolicy
+	if policy == nil {
+		return nil
+	}
+	var err error
+	if sp, ok := policy.(VaultStreamPolicy); ok {
+		err = sp.AllowStream(filepath.Clean(relPath), r)
+	} else {
+		head, rerr := io.ReadAll(io.LimitReader(r, vaultPolicyInspectBytes))
+		if rerr != nil {
+			return rerr
+		}
+		err = policy.Allow(filepath.Clean(relPath), head)
+	}
+	if err != nil {
+		if errors.Is(err, ErrVaultWriteRejected) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", ErrVaultWriteRejected, err)
+	}
+	return nil
+}
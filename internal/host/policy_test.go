@@ -0,0 +1,183 @@
+package host
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingPolicy 记录交给 Allow 的内容长度
+type recordingPolicy struct {
+	seen int
+}
+
+func (p *recordingPolicy) Allow(_ string, content []byte) error {
+	p.seen = len(content)
+	if bytes.Contains(content, []byte("forbidden")) {
+		return errors.New("forbidden content")
+	}
+	return nil
+}
+
+// streamPolicy 读取完整内容检查
+type streamPolicy struct {
+	recordingPolicy
+	streamed int64
+}
+
+func (p *streamPolicy) AllowStream(_ string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	p.streamed = int64(len(data))
+	if bytes.Contains(data, []byte("forbidden")) {
+		return errors.New("forbidden content")
+	}
+	return err
+}
+
+func TestWriteVaultStreamCapsPolicyInput(t *testing.T) {
+	policy := &recordingPolicy{}
+	h := newTestHost(t, Config{VaultWritePolicy: policy})
+	content := strings.Repeat("x", vaultPolicyInspectBytes+100)
+	if err := h.writeVaultStream("big.txt", strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	if policy.seen != vaultPolicyInspectBytes {
+		t.Fatalf("policy saw %d bytes, want %d", policy.seen, vaultPolicyInspectBytes)
+	}
+	err := h.writeVaultStream("bad.txt", strings.NewReader("forbidden"), -1)
+	if !errors.Is(err, ErrVaultWriteRejected) {
+		t.Fatalf("got %v, want ErrVaultWriteRejected", err)
+	}
+}
+
+func TestWriteVaultStreamUsesStreamPolicy(t *testing.T) {
+	policy := &streamPolicy{}
+	h := newTestHost(t, Config{VaultWritePolicy: policy})
+	content := strings.Repeat("x", vaultPolicyInspectBytes+100) + "forbidden"
+	err := h.writeVaultStream("big.txt", strings.NewReader(content), -1)
+	if !errors.Is(err, ErrVaultWriteRejected) {
+		t.Fatalf("got %v, want ErrVaultWriteRejected", err)
+	}
+	if policy.streamed != int64(len(content)) || policy.seen != 0 {
+		t.Fatalf("streamed %d bytes, Allow saw %d", policy.streamed, policy.seen)
+	}
+	if _, err := h.vault.Stat("big.txt"); err == nil {
+		t.Fatal("rejected file was written")
+	}
+}
+
+func TestCheckVaultExtension(t *testing.T) {
+	if err := checkVaultExtension("a.EXE", nil, []string{"exe"}); !errors.Is(err, ErrVaultWriteRejected) {
+		t.Errorf("blocked extension: %v", err)
+	}
+	if err := checkVaultExtension("a.md", []string{".md"}, nil); err != nil {
+		t.Errorf("allowed extension: %v", err)
+	}
+	if err := checkVaultExtension("README", []string{".md"}, nil); err == nil {
+		t.Error("file without extension should be rejected when an allow list is set")
+	}
+}
+
+func TestExtensionBlocklistPolicyBlocksExe(t *testing.T) {
+	h := newTestHost(t, Config{VaultWritePolicy: ExtensionBlocklistPolicy{Extensions: []string{".exe"}}})
+	addTestPlugin(t, h, "writer", "vault.write")
+
+	code, resp := callRPC(t, h, "writer", "vault.write", map[string]any{"path": "tools/setup.EXE", "content": "MZ"})
+	if code != 403 {
+		t.Fatalf("write .exe: got %d, want 403", code)
+	}
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, ".exe") {
+		t.Errorf("error should name the blocked extension: %+v", resp.Error)
+	}
+	if _, err := h.vault.Stat("tools/setup.EXE"); err == nil {
+		t.Error("rejected file was written")
+	}
+	if code, resp := callRPC(t, h, "writer", "vault.write", map[string]any{"path": "notes/a.md", "content": "ok"}); code != 200 {
+		t.Errorf("write .md: got %d %+v", code, resp.Error)
+	}
+}
+
+func TestCheckVaultExtensionLists(t *testing.T) {
+	cases := []struct {
+		path             string
+		allowed, blocked []string
+		ok               bool
+	}{
+		// 两个列表都为空时不限制
+		{"a.exe", nil, nil, true},
+		{"README", nil, nil, true},
+		{"a.md", []string{"md", ".PNG"}, nil, true},
+		{"img/a.png", []string{"md", ".PNG"}, nil, true},
+		{"a.pdf", []string{"md"}, nil, false},
+		{"archive.tar.gz", []string{".gz"}, nil, true},
+		// 禁止列表优先于允许列表
+		{"a.md", []string{".md"}, []string{".md"}, false},
+		{"a.sh", nil, []string{" .SH "}, false},
+		{"README", nil, []string{".sh"}, true},
+	}
+	for _, c := range cases {
+		err := checkVaultExtension(c.path, c.allowed, c.blocked)
+		if (err == nil) != c.ok {
+			t.Errorf("checkVaultExtension(%q, %v, %v) = %v, want ok=%v", c.path, c.allowed, c.blocked, err, c.ok)
+		}
+		if err != nil && !errors.Is(err, ErrVaultWriteRejected) {
+			t.Errorf("%q: %v is not ErrVaultWriteRejected", c.path, err)
+		}
+	}
+}
+
+func TestVaultExtensionConfig(t *testing.T) {
+	h := newTestHost(t, Config{AllowedVaultExtensions: []string{".md", ".png"}, BlockedVaultExtensions: []string{".png"}})
+	addTestPlugin(t, h, "writer", "vault.read", "vault.write")
+
+	if code, resp := callRPC(t, h, "writer", "vault.write", map[string]any{"path": "notes/a.md", "content": "ok"}); code != http.StatusOK {
+		t.Fatalf("write .md: got %d %+v", code, resp.Error)
+	}
+	for _, p := range []string{"bin/tool.exe", "img/a.png"} {
+		code, resp := callRPC(t, h, "writer", "vault.write", map[string]any{"path": p, "content": "x"})
+		if code != http.StatusForbidden || resp.Error == nil || !strings.Contains(resp.Error.Message, filepath.Ext(p)) {
+			t.Errorf("write %s: got %d %+v", p, code, resp.Error)
+		}
+	}
+	if w := serveVaultRaw(h, http.MethodPut, "writer", "bin/tool.exe", []byte("MZ"), nil); w.Code != http.StatusForbidden {
+		t.Errorf("PUT /vault/raw .exe: got %d %s", w.Code, w.Body.String())
+	}
+	if code, _ := callRPC(t, h, "writer", "vault.copy", map[string]any{"from": "notes/a.md", "to": "notes/a.exe"}); code != http.StatusForbidden {
+		t.Errorf("copy to .exe: got %d, want 403", code)
+	}
+
+	// 导入时跳过不允许的扩展名
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"b.md", "c.exe"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := h.importVaultZip(zr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Path != "c.exe" {
+		t.Errorf("skipped = %+v, want c.exe", result.Skipped)
+	}
+	if _, err := h.vault.Stat("b.md"); err != nil {
+		t.Errorf("allowed entry not imported: %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package host
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// processRestartMinDelay 后端进程退出后第一次重启前的等待时间，之后每次翻倍
+	processRestartMinDelay = time.Second
+	// processRestartMaxDelay 重启等待时间的上限
+	processRestartMaxDelay = time.Minute
+	// processStableAfter 进程运行超过该时长后退出时重新从最短等待时间开始计算
+	processStableAfter = time.Minute
+	// processStopGrace 停止进程时发送中断信号后等待退出的时间，超时后强制结束
+	processStopGrace = 5 * time.Second
+	// processStartTimeout 启动后等待健康检查通过的最长时间
+	processStartTimeout = 30 * time.Second
+	// processHealthInterval 启动阶段健康检查的间隔
+	processHealthInterval = 500 * time.Millisecond
+)
+
+// 后端进程的状态
+const (
+	ProcessStarting  = "starting"
+	ProcessRunning   = "running"
+	ProcessUnhealthy = "unhealthy"
+	ProcessBackoff   = "backoff"
+	ProcessStopped   = "stopped"
+	ProcessFailed    = "failed"
+)
+
+// PluginProcess 插件后端进程的运行状态
+type PluginProcess struct {
+	PluginID string `json:"pluginId"`
+	State    string `json:"state"`
+	PID      int    `json:"pid,omitempty"`
+	// Restarts 进程退出后被重新启动的次数
+	Restarts  int       `json:"restarts"`
+	Healthy   bool      `json:"healthy"`
+	StartedAt time.Time `json:"startedAt,omitempty"`
+	// LastExit 最近一次退出的原因
+	LastExit string `json:"lastExit,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// managedProcess 由宿主守护的后端进程，status 受 processesMu 保护
+type managedProcess struct {
+	status PluginProcess
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startPluginProcess 为声明了 entrypoints.process 的插件启动后端进程并在退出后按退避时间重启，
+// 未开启 Config.AllowBackendProcesses 或进程已在运行时不做任何事
+func (h *PluginHost) startPluginProcess(m Manifest) {
+	if !h.config.AllowBackendProcesses || m.Entrypoints == nil || m.Entrypoints.Process == nil {
+		return
+	}
+	h.processesMu.Lock()
+	defer h.processesMu.Unlock()
+	if _, ok := h.processes[m.ID]; ok {
+		return
+	}
+	if h.processes == nil {
+		h.processes = make(map[string]*managedProcess)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	mp := &managedProcess{
+		status: PluginProcess{PluginID: m.ID, State: ProcessStarting},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	h.processes[m.ID] = mp
+	go h.superviseProcess(ctx, mp, m)
+}
+
+// stopPluginProcess 停止插件的后端进程并等待其退出，进程未运行时直接返回
+func (h *PluginHost) stopPluginProcess(pluginID string) {
+	h.processesMu.Lock()
+	mp, ok := h.processes[pluginID]
+	delete(h.processes, pluginID)
+	h.processesMu.Unlock()
+	if !ok {
+		return
+	}
+	mp.cancel()
+	<-mp.done
+}
+
+// StartPluginProcesses 启动所有已启用插件的后端进程，在 LoadPlugins 之后调用
+func (h *PluginHost) StartPluginProcesses() {
+	h.pluginsMu.RLock()
+	var manifests []Manifest
+	for _, p := range h.plugins {
+		if p.Enabled {
+			manifests = append(manifests, p.Manifest)
+		}
+	}
+	h.pluginsMu.RUnlock()
+	for _, m := range manifests {
+		h.startPluginProcess(m)
+	}
+}
+
+// pluginProcess 返回插件后端进程的状态，没有受管进程时状态为 stopped
+func (h *PluginHost) pluginProcess(pluginID string) PluginProcess {
+	h.processesMu.Lock()
+	defer h.processesMu.Unlock()
+	if mp, ok := h.processes[pluginID]; ok {
+		return mp.status
+	}
+	return PluginProcess{PluginID: pluginID, State: ProcessStopped}
+}
+
+// updateProcess 在 processesMu 保护下修改进程状态
+func (h *PluginHost) updateProcess(mp *managedProcess, update func(*PluginProcess)) {
+	h.processesMu.Lock()
+	update(&mp.status)
+	h.processesMu.Unlock()
+}
+
+// superviseProcess 运行后端进程直到 ctx 结束，进程退出后按指数退避重启
+func (h *PluginHost) superviseProcess(ctx context.Context, mp *managedProcess, m Manifest) {
+	defer close(mp.done)
+	// 命令必须位于插件目录内，否则不启动也不重试
+	if !filepath.IsLocal(m.Entrypoints.Process.Command) {
+		h.updateProcess(mp, func(s *PluginProcess) {
+			s.State = ProcessFailed
+			s.Error = "invalid process command: " + m.Entrypoints.Process.Command
+		})
+		return
+	}
+	delay := processRestartMinDelay
+	for {
+		started := time.Now()
+		err := h.runProcess(ctx, mp, m)
+		if ctx.Err() != nil {
+			h.updateProcess(mp, func(s *PluginProcess) {
+				s.State = ProcessStopped
+				s.PID = 0
+				s.Healthy = false
+			})
+			return
+		}
+
+		exit := "exited"
+		if err != nil {
+			exit = err.Error()
+		}
+		if time.Since(started) >= processStableAfter {
+			delay = processRestartMinDelay
+		}
+		h.updateProcess(mp, func(s *PluginProcess) {
+			s.State = ProcessBackoff
+			s.PID = 0
+			s.Healthy = false
+			s.LastExit = exit
+		})
+		log.Printf("backend process for %s exited: %s, restarting in %s", m.ID, exit, delay)
+		h.Broadcast(Event{Type: "plugin.process.exited", Data: map[string]any{
+			"pluginId":  m.ID,
+			"exit":      exit,
+			"restartIn": delay.String(),
+		}})
+
+		select {
+		case <-ctx.Done():
+			h.updateProcess(mp, func(s *PluginProcess) { s.State = ProcessStopped })
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > processRestartMaxDelay {
+			delay = processRestartMaxDelay
+		}
+		h.updateProcess(mp, func(s *PluginProcess) {
+			s.State = ProcessStarting
+			s.Restarts++
+		})
+	}
+}
+
+// runProcess 启动一次后端进程并等待其退出。进程以插件目录为工作目录，
+// 只继承 PATH，不会拿到宿主的令牌等环境变量
+func (h *PluginHost) runProcess(ctx context.Context, mp *managedProcess, m Manifest) error {
+	proc := m.Entrypoints.Process
+	dir, err := filepath.Abs(filepath.Join(h.config.PluginsDir, m.ID))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, filepath.Join(dir, proc.Command), proc.Args...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"PLUGIN_ID=" + m.ID,
+		"PLUGIN_DIR=" + dir,
+	}, proc.Env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = processStopGrace
+
+	if err := cmd.Start(); err != nil {
+		h.updateProcess(mp, func(s *PluginProcess) { s.Error = err.Error() })
+		return err
+	}
+	h.updateProcess(mp, func(s *PluginProcess) {
+		s.State = ProcessStarting
+		s.PID = cmd.Process.Pid
+		s.StartedAt = time.Now()
+		s.Error = ""
+	})
+	h.Broadcast(Event{Type: "plugin.process.started", Data: map[string]any{
+		"pluginId": m.ID,
+		"pid":      cmd.Process.Pid,
+	}})
+
+	exited := make(chan struct{})
+	go h.awaitProcessHealthy(ctx, exited, mp, m)
+	err = cmd.Wait()
+	close(exited)
+	return err
+}
+
+// awaitProcessHealthy 启动后轮询健康检查地址，通过后标记为 running；
+// 清单没有 http 后端地址时启动即视为 running
+func (h *PluginHost) awaitProcessHealthy(ctx context.Context, exited <-chan struct{}, mp *managedProcess, m Manifest) {
+	target, ok := backendHealthURL(m)
+	if !ok {
+		h.updateProcess(mp, func(s *PluginProcess) { s.State = ProcessRunning })
+		return
+	}
+
+	deadline := time.NewTimer(processStartTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(processHealthInterval)
+	defer ticker.Stop()
+	client, err := h.probeClient()
+	if err != nil {
+		h.updateProcess(mp, func(s *PluginProcess) {
+			s.State = ProcessUnhealthy
+			s.Error = err.Error()
+		})
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-exited:
+			return
+		case <-deadline.C:
+			h.updateProcess(mp, func(s *PluginProcess) {
+				s.State = ProcessUnhealthy
+				s.Error = "health check did not pass within " + processStartTimeout.String()
+			})
+			return
+		case <-ticker.C:
+			resp, err := client.Get(target)
+			if err != nil {
+				continue
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				h.updateProcess(mp, func(s *PluginProcess) {
+					s.State = ProcessRunning
+					s.Healthy = true
+				})
+				return
+			}
+		}
+	}
+}
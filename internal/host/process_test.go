@@ -0,0 +1,204 @@
+package host
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addProcessPlugin 添加一个声明了 entrypoints.process 的插件，script 写入插件目录下的 backend.sh
+func addProcessPlugin(t *testing.T, h *PluginHost, id, script string) Manifest {
+	t.Helper()
+	addTestPlugin(t, h, id)
+	dir := filepath.Join(h.config.PluginsDir, id)
+	if err := os.WriteFile(filepath.Join(dir, "backend.sh"), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	h.pluginsMu.Lock()
+	defer h.pluginsMu.Unlock()
+	h.plugins[id].Manifest.Entrypoints = &Entrypoints{Process: &BackendProcess{Command: "backend.sh"}}
+	return h.plugins[id].Manifest
+}
+
+// waitProcess 等待进程状态满足条件，超时则失败
+func waitProcess(t *testing.T, h *PluginHost, id string, ok func(PluginProcess) bool) PluginProcess {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := h.pluginProcess(id)
+		if ok(st) {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("process state of %s: %+v", id, st)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestBackendProcessDisabledByDefault(t *testing.T) {
+	h := newTestHost(t, Config{})
+	m := addProcessPlugin(t, h, "a", "touch started\nsleep 60\n")
+
+	h.startPluginProcess(m)
+	if st := h.pluginProcess("a"); st.State != ProcessStopped || st.PID != 0 {
+		t.Fatalf("process = %+v, want stopped", st)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "a", "started")); !os.IsNotExist(err) {
+		t.Fatalf("backend ran without AllowBackendProcesses: %v", err)
+	}
+}
+
+func TestBackendProcessRestartsAfterExit(t *testing.T) {
+	h := newTestHost(t, Config{AllowBackendProcesses: true})
+	m := addProcessPlugin(t, h, "a", "echo run >> runs\nexit 3\n")
+	sub := subscribeEvents(t, h)
+
+	h.startPluginProcess(m)
+	t.Cleanup(func() { h.stopPluginProcess("a") })
+	st := waitProcess(t, h, "a", func(s PluginProcess) bool { return s.Restarts >= 1 })
+	if st.LastExit != "exit status 3" {
+		t.Fatalf("lastExit = %q", st.LastExit)
+	}
+	h.stopPluginProcess("a")
+
+	data, err := os.ReadFile(filepath.Join(h.config.PluginsDir, "a", "runs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "run"); runs < 2 {
+		t.Fatalf("backend ran %d times, want it restarted", runs)
+	}
+	events := receivedEvents(t, sub)
+	types := eventTypes(events)
+	if !slices.Contains(types, "plugin.process.started") || !slices.Contains(types, "plugin.process.exited") {
+		t.Fatalf("events = %v", types)
+	}
+	for _, ev := range events {
+		if ev.Type != "plugin.process.exited" {
+			continue
+		}
+		data := ev.Data.(map[string]any)
+		if data["pluginId"] != "a" || data["exit"] != "exit status 3" || data["restartIn"] != processRestartMinDelay.String() {
+			t.Fatalf("exited event = %v", data)
+		}
+		break
+	}
+}
+
+func TestBackendProcessStop(t *testing.T) {
+	h := newTestHost(t, Config{AllowBackendProcesses: true})
+	m := addProcessPlugin(t, h, "a", "exec sleep 60\n")
+
+	h.startPluginProcess(m)
+	st := waitProcess(t, h, "a", func(s PluginProcess) bool { return s.State == ProcessRunning })
+	if st.PID == 0 || st.StartedAt.IsZero() {
+		t.Fatalf("running process = %+v", st)
+	}
+	// 已在运行时再次启动不会产生第二个进程
+	h.startPluginProcess(m)
+	if again := h.pluginProcess("a"); again.PID != st.PID {
+		t.Fatalf("pid changed from %d to %d", st.PID, again.PID)
+	}
+
+	if err := h.disablePlugin("a"); err != nil {
+		t.Fatal(err)
+	}
+	if st := h.pluginProcess("a"); st.State != ProcessStopped || st.PID != 0 {
+		t.Fatalf("process after disable = %+v", st)
+	}
+	if err := h.enablePlugin("a"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.stopPluginProcess("a") })
+	waitProcess(t, h, "a", func(s PluginProcess) bool { return s.State == ProcessRunning })
+}
+
+func TestBackendProcessEnvironment(t *testing.T) {
+	t.Setenv("LUCKIN_TEST_SECRET", "leaked")
+	h := newTestHost(t, Config{AllowBackendProcesses: true})
+	m := addProcessPlugin(t, h, "a", "env > env.txt\nexec sleep 60\n")
+	m.Entrypoints.Process.Env = []string{"EXTRA=1"}
+
+	h.startPluginProcess(m)
+	t.Cleanup(func() { h.stopPluginProcess("a") })
+	waitProcess(t, h, "a", func(s PluginProcess) bool { return s.State == ProcessRunning })
+
+	dir := filepath.Join(h.config.PluginsDir, "a")
+	var env string
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(env, "EXTRA=") && time.Now().Before(deadline) {
+		data, _ := os.ReadFile(filepath.Join(dir, "env.txt"))
+		env = string(data)
+		time.Sleep(20 * time.Millisecond)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"PLUGIN_ID=a", "PLUGIN_DIR=" + abs, "HOME=" + abs, "EXTRA=1"} {
+		if !strings.Contains(env, want+"\n") {
+			t.Errorf("env missing %q:\n%s", want, env)
+		}
+	}
+	if strings.Contains(env, "LUCKIN_TEST_SECRET") {
+		t.Errorf("host environment leaked to backend:\n%s", env)
+	}
+}
+
+func TestBackendProcessRejectsNonLocalCommand(t *testing.T) {
+	h := newTestHost(t, Config{AllowBackendProcesses: true})
+	m := addProcessPlugin(t, h, "a", "exit 0\n")
+	m.Entrypoints.Process.Command = "../b/backend.sh"
+
+	h.startPluginProcess(m)
+	t.Cleanup(func() { h.stopPluginProcess("a") })
+	st := waitProcess(t, h, "a", func(s PluginProcess) bool { return s.State != ProcessStarting })
+	if st.State != ProcessFailed || !strings.Contains(st.Error, "invalid process command") || st.Restarts != 0 {
+		t.Fatalf("process = %+v", st)
+	}
+}
+
+func TestGetPluginProcessRPC(t *testing.T) {
+	h := newTestHost(t, Config{
+		AllowBackendProcesses: true,
+		AdminToken:            "secret",
+		PluginKeys:            map[string]string{"a": "key-a", "b": "key-b"},
+	})
+	m := addProcessPlugin(t, h, "a", "exec sleep 60\n")
+	addTestPlugin(t, h, "b")
+	h.startPluginProcess(m)
+	t.Cleanup(func() { h.stopPluginProcess("a") })
+	waitProcess(t, h, "a", func(s PluginProcess) bool { return s.State == ProcessRunning })
+
+	own := http.Header{pluginKeyHeader: {"key-a"}}
+	status, resp := callRPCWithHeader(t, h, own, "a", "host.getPluginProcess", nil)
+	if status != http.StatusOK || resp.Error != nil {
+		t.Fatalf("own process: %d %+v", status, resp.Error)
+	}
+	if got := resp.Result.(map[string]any); got["state"] != ProcessRunning || got["pluginId"] != "a" {
+		t.Fatalf("result = %v", got)
+	}
+
+	other := http.Header{pluginKeyHeader: {"key-b"}}
+	if _, resp := callRPCWithHeader(t, h, other, "b", "host.getPluginProcess", map[string]string{"pluginId": "a"}); resp.Error == nil || resp.Error.Code != 403 {
+		t.Fatalf("other plugin: %+v", resp.Error)
+	}
+
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	status, resp = callRPCWithHeader(t, h, admin, "", "host.getPluginProcess", map[string]string{"pluginId": "b"})
+	if status != http.StatusOK || resp.Error != nil {
+		t.Fatalf("admin: %d %+v", status, resp.Error)
+	}
+	if got := resp.Result.(map[string]any); got["state"] != ProcessStopped {
+		t.Fatalf("plugin without process = %v", got)
+	}
+	if _, resp := callRPCWithHeader(t, h, admin, "", "host.getPluginProcess", map[string]string{"pluginId": "missing"}); resp.Error == nil || resp.Error.Code != 404 {
+		t.Fatalf("missing plugin: %+v", resp.Error)
+	}
+}
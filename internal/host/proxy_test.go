@@ -0,0 +1,127 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// stubProxy 记录经过代理的请求目标，并用 handler 代替目标服务器响应
+type stubProxy struct {
+	mu      sync.Mutex
+	targets []string
+}
+
+func (p *stubProxy) seen() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.targets...)
+}
+
+// serveStubProxy 启动HTTP正向代理，返回代理地址
+func serveStubProxy(t *testing.T, p *stubProxy, handler http.HandlerFunc) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.targets = append(p.targets, r.URL.String())
+		p.mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestProxyFunc(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://github.com/a.zip", nil)
+	for proxy, want := range map[string]string{
+		"http://proxy.internal:3128": "http://proxy.internal:3128",
+		"proxy.internal:3128":        "http://proxy.internal:3128",
+		"socks5://proxy.internal":    "socks5://proxy.internal",
+	} {
+		u, err := proxyFunc(proxy)(req)
+		if err != nil || u == nil || u.String() != want {
+			t.Errorf("proxyFunc(%q) = %v, %v, want %s", proxy, u, err, want)
+		}
+	}
+	for _, proxy := range []string{"http://", "http://[::1"} {
+		if u, err := proxyFunc(proxy)(req); err == nil {
+			t.Errorf("proxyFunc(%q) = %v, want error", proxy, u)
+		}
+	}
+}
+
+func TestProxyAddr(t *testing.T) {
+	for raw, want := range map[string]string{
+		"http://proxy:3128":    "proxy:3128",
+		"http://proxy":         "proxy:80",
+		"https://proxy":        "proxy:443",
+		"socks5h://proxy":      "proxy:1080",
+		"http://[2001:db8::1]": "[2001:db8::1]:80",
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := proxyAddr(u); got != want {
+			t.Errorf("proxyAddr(%s) = %s, want %s", raw, got, want)
+		}
+	}
+}
+
+func TestInstallDownloadsThroughProxy(t *testing.T) {
+	data, err := json.Marshal(Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &stubProxy{}
+	proxyURL := serveStubProxy(t, proxy, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	})
+	h := newTestHost(t, Config{HTTPProxy: proxyURL})
+
+	// 目标端口上没有服务，只有经代理才能下载成功
+	const target = "http://localhost:1/demo.json"
+	if err := h.installPluginFromURL("demo", target, "", "", nil); err != nil {
+		t.Fatalf("install through proxy: %v", err)
+	}
+	if got := proxy.seen(); len(got) != 1 || got[0] != target {
+		t.Fatalf("proxy requests = %v, want [%s]", got, target)
+	}
+	if _, ok := h.getPlugin("demo"); !ok {
+		t.Fatal("plugin not registered")
+	}
+}
+
+func TestMarketIndexThroughProxy(t *testing.T) {
+	proxy := &stubProxy{}
+	proxyURL := serveStubProxy(t, proxy, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]MarketItem{{ID: "demo", Name: "Demo", Version: "1.0.0"}})
+	})
+	const index = "http://market.example.test/index.json"
+	h := newTestHost(t, Config{HTTPProxy: proxyURL, MarketIndex: index})
+
+	items, err := h.fetchMarketIndex()
+	if err != nil {
+		t.Fatalf("fetch market index: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != "demo" {
+		t.Fatalf("items = %+v", items)
+	}
+	if got := proxy.seen(); len(got) != 1 || got[0] != index {
+		t.Fatalf("proxy requests = %v, want [%s]", got, index)
+	}
+}
+
+func TestInstallInvalidProxy(t *testing.T) {
+	h := newTestHost(t, Config{HTTPProxy: "http://"})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+
+	err := h.installPluginFromURL("demo", url, "", "", nil)
+	if got := installErrorCode(err); got != InstallErrDownload {
+		t.Fatalf("code = %q (%v), want %s", got, err, InstallErrDownload)
+	}
+}
@@ -0,0 +1,36 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// maxEventPayloadBytes 插件发布事件的载荷大小上限
+const maxEventPayloadBytes = 64 << 10
+
+var (
+	// ErrInvalidEventName 事件名称不合法
+	ErrInvalidEventName = errors.New("invalid event name")
+	// ErrEventPayloadTooLarge 事件载荷超过大小上限
+	ErrEventPayloadTooLarge = errors.New("event payload too large")
+)
+
+var eventNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// publishPluginEvent 以 plugin:<id>:<name> 为类型广播插件自定义事件
+func (h *PluginHost) publishPluginEvent(pluginID, name string, payload json.RawMessage) (string, error) {
+	if !eventNamePattern.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidEventName, name)
+	}
+	if len(payload) > maxEventPayloadBytes {
+		return "", fmt.Errorf("%w: %d bytes exceeds %d", ErrEventPayloadTooLarge, len(payload), maxEventPayloadBytes)
+	}
+	eventType := "plugin:" + pluginID + ":" + name
+	h.Broadcast(Event{Type: eventType, Data: map[string]any{
+		"pluginId": pluginID,
+		"payload":  payload,
+	}})
+	return eventType, nil
+}
@@ -0,0 +1,58 @@
+package host
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPublishEventReachesSubscriber(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "pub", "events.publish")
+	events := subscribeEvents(t, h)
+
+	code, resp := callRPC(t, h, "pub", "events.publish", map[string]any{
+		"name":    "note.synced",
+		"payload": map[string]any{"count": 3},
+	})
+	if code != 200 || resp.Error != nil {
+		t.Fatalf("events.publish: got %d %+v", code, resp.Error)
+	}
+	if got := resp.Result.(map[string]any)["type"]; got != "plugin:pub:note.synced" {
+		t.Errorf("result type = %v", got)
+	}
+
+	got := receivedEvents(t, events)
+	if len(got) != 1 || got[0].Type != "plugin:pub:note.synced" {
+		t.Fatalf("events = %v, want [plugin:pub:note.synced]", eventTypes(got))
+	}
+	data := got[0].Data.(map[string]any)
+	if data["pluginId"] != "pub" {
+		t.Errorf("pluginId = %v", data["pluginId"])
+	}
+	payload, _ := json.Marshal(data["payload"])
+	if string(payload) != `{"count":3}` {
+		t.Errorf("payload = %s", payload)
+	}
+}
+
+func TestPublishEventRejected(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "quiet")
+	addTestPlugin(t, h, "pub", "events.publish")
+	events := subscribeEvents(t, h)
+
+	if code, resp := callRPC(t, h, "quiet", "events.publish", map[string]any{"name": "x"}); code != 403 || resp.Error == nil {
+		t.Errorf("publish without permission: got %d %+v, want 403", code, resp.Error)
+	}
+	if code, _ := callRPC(t, h, "pub", "events.publish", map[string]any{"name": "bad name!"}); code != 400 {
+		t.Errorf("invalid event name: got %d, want 400", code)
+	}
+	big := strings.Repeat("x", maxEventPayloadBytes)
+	if code, _ := callRPC(t, h, "pub", "events.publish", map[string]any{"name": "big", "payload": big}); code != 413 {
+		t.Errorf("oversized payload: got %d, want 413", code)
+	}
+	if got := receivedEvents(t, events); len(got) != 0 {
+		t.Errorf("rejected publishes broadcast %v", eventTypes(got))
+	}
+}
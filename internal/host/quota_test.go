@@ -0,0 +1,45 @@
+package host
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVaultQuota(t *testing.T) {
+	h := newTestHost(t, Config{VaultQuotaBytes: 100})
+	addTestPlugin(t, h, "writer", "vault.write")
+	events := subscribeEvents(t, h)
+
+	write := func(path string, size int) int {
+		code, _ := callRPC(t, h, "writer", "vault.write", map[string]any{"path": path, "content": strings.Repeat("x", size)})
+		return code
+	}
+
+	if code := write("a.md", 60); code != 200 {
+		t.Fatalf("write within quota: got %d", code)
+	}
+	if code := write("b.md", 50); code != 413 {
+		t.Fatalf("write exceeding quota: got %d, want 413", code)
+	}
+	if !slices.Contains(eventTypes(receivedEvents(t, events)), "vault.quota.exceeded") {
+		t.Error("rejected write should broadcast vault.quota.exceeded")
+	}
+	// 覆盖已有文件只计算增量：60 -> 90 仍在配额内
+	if code := write("a.md", 90); code != 200 {
+		t.Fatalf("overwrite within quota: got %d", code)
+	}
+	if code := write("c.md", 20); code != 413 {
+		t.Fatalf("write after overwrite: got %d, want 413", code)
+	}
+	if code := write("c.md", 10); code != 200 {
+		t.Fatalf("write filling the quota exactly: got %d", code)
+	}
+}
+
+func TestVaultQuotaDisabled(t *testing.T) {
+	h := newTestHost(t, Config{})
+	if err := h.checkVaultQuota("a.md", 1<<40); err != nil {
+		t.Fatalf("no quota configured: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package host
+
+import (
+	"log"
+	"net/http"
+)
+
+// readOnlyMessage 只读模式下拒绝写操作时返回的错误信息
+const readOnlyMessage = "host is read-only"
+
+// mutatingRPCMethods 会修改插件、存储库或持久化数据的RPC方法，只读模式下拒绝
+var mutatingRPCMethods = map[string]bool{
+	"vault.write":             true,
+	"vault.copy":              true,
+	"kv.set":                  true,
+	"kv.delete":               true,
+	"host.enablePlugin":       true,
+	"host.disablePlugin":      true,
+	"host.batchSetEnabled":    true,
+	"host.batchUninstall":     true,
+	"host.resetPlugin":        true,
+	"host.setPluginSettings":  true,
+	"host.updatePlugin":       true,
+	"host.approvePermissions": true,
+}
+
+// IsReadOnly 返回宿主当前是否处于只读模式
+func (h *PluginHost) IsReadOnly() bool {
+	return h.readOnly.Load()
+}
+
+// SetReadOnly 切换只读模式，状态变化时广播 host.readonly.changed
+func (h *PluginHost) SetReadOnly(readOnly bool) {
+	if h.readOnly.Swap(readOnly) == readOnly {
+		return
+	}
+	log.Printf("host read-only mode: %v", readOnly)
+	h.Broadcast(Event{Type: "host.readonly.changed", Data: map[string]any{"readOnly": readOnly}})
+}
+
+// rejectReadOnly 只读模式下对写操作返回 503，返回 true 表示请求已被拒绝
+func (h *PluginHost) rejectReadOnly(w http.ResponseWriter) bool {
+	if !h.IsReadOnly() {
+		return false
+	}
+	http.Error(w, readOnlyMessage, http.StatusServiceUnavailable)
+	return true
+}
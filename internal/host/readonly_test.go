@@ -0,0 +1,87 @@
+package host
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	h := newTestHost(t, Config{ReadOnly: true})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+	writeVaultFixture(t, h, "a.md", "hello", time.Now())
+
+	writes := []struct {
+		method string
+		params any
+	}{
+		{"vault.write", map[string]any{"path": "b.md", "content": "x"}},
+		{"vault.copy", map[string]any{"from": "a.md", "to": "c.md"}},
+		{"host.disablePlugin", map[string]any{"pluginId": "rw"}},
+	}
+	for _, tc := range writes {
+		code, resp := callRPC(t, h, "rw", tc.method, tc.params)
+		if code != http.StatusServiceUnavailable || resp.Error == nil || resp.Error.Message != readOnlyMessage {
+			t.Errorf("%s in read-only mode: got %d %+v, want 503", tc.method, code, resp.Error)
+		}
+	}
+	if w := serveVaultRaw(h, http.MethodPut, "rw", "raw.bin", []byte("x"), nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("PUT /vault/raw in read-only mode: got %d, want 503", w.Code)
+	}
+	if p, _ := h.getPlugin("rw"); !p.Enabled {
+		t.Error("read-only mode did not prevent disabling the plugin")
+	}
+
+	// 读操作照常可用
+	reads := []struct {
+		method string
+		params any
+	}{
+		{"vault.read", map[string]any{"path": "a.md"}},
+		{"vault.list", map[string]any{}},
+		{"host.getPlugins", nil},
+	}
+	for _, tc := range reads {
+		if code, resp := callRPC(t, h, "rw", tc.method, tc.params); code != http.StatusOK {
+			t.Errorf("%s in read-only mode: got %d %+v, want 200", tc.method, code, resp.Error)
+		}
+	}
+}
+
+func TestSetReadOnlyRPC(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+	events := subscribeEvents(t, h)
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	if code, _ := callRPC(t, h, "rw", "host.setReadOnly", map[string]any{"readOnly": true}); code != http.StatusForbidden {
+		t.Errorf("non-admin host.setReadOnly: status = %d, want 403", code)
+	}
+	if code, _ := callRPCWithHeader(t, h, admin, "", "host.setReadOnly", map[string]any{}); code != http.StatusBadRequest {
+		t.Errorf("host.setReadOnly without readOnly: status = %d, want 400", code)
+	}
+	if code, resp := callRPCWithHeader(t, h, admin, "", "host.setReadOnly", map[string]any{"readOnly": true}); code != http.StatusOK {
+		t.Fatalf("host.setReadOnly: got %d %+v", code, resp.Error)
+	}
+	if code, _ := callRPC(t, h, "rw", "vault.write", map[string]any{"path": "a.md", "content": "x"}); code != http.StatusServiceUnavailable {
+		t.Errorf("vault.write after enabling read-only: status = %d, want 503", code)
+	}
+
+	if code, resp := callRPCWithHeader(t, h, admin, "", "host.setReadOnly", map[string]any{"readOnly": false}); code != http.StatusOK {
+		t.Fatalf("host.setReadOnly: got %d %+v", code, resp.Error)
+	}
+	if code, resp := callRPC(t, h, "rw", "vault.write", map[string]any{"path": "a.md", "content": "x"}); code != http.StatusOK {
+		t.Errorf("vault.write after leaving read-only: got %d %+v", code, resp.Error)
+	}
+
+	var changes []any
+	for _, ev := range receivedEvents(t, events) {
+		if ev.Type == "host.readonly.changed" {
+			changes = append(changes, ev.Data.(map[string]any)["readOnly"])
+		}
+	}
+	if !slices.Equal(changes, []any{true, false}) {
+		t.Errorf("host.readonly.changed values = %v, want [true false]", changes)
+	}
+}
@@ -0,0 +1,116 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 插件数据重置范围
+const (
+	ResetScopeSettings = "settings"
+	ResetScopeBackups  = "backups"
+	ResetScopeCommands = "commands"
+)
+
+// ErrInvalidResetScope 重置范围为空或包含未知取值
+var ErrInvalidResetScope = errors.New("invalid reset scope")
+
+// resetPlugin 清除插件的设置、备份或命令，插件保持安装和启用状态。
+// 命令重置后只保留清单中声明的命令
+func (h *PluginHost) resetPlugin(pluginID string, scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: empty", ErrInvalidResetScope)
+	}
+	for _, s := range scopes {
+		switch s {
+		case ResetScopeSettings, ResetScopeBackups, ResetScopeCommands:
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidResetScope, s)
+		}
+	}
+
+	unlock := h.pluginLocks.Lock(pluginID)
+	defer unlock()
+
+	p, ok := h.getPlugin(pluginID)
+	if !ok {
+		return fmt.Errorf("plugin not found: %s", pluginID)
+	}
+
+	for _, s := range scopes {
+		switch s {
+		case ResetScopeSettings:
+			if err := os.Remove(h.settingsPath(pluginID)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove settings: %w", err)
+			}
+		case ResetScopeBackups:
+			backups, err := h.pluginBackups(pluginID)
+			if err != nil {
+				return fmt.Errorf("list backups: %w", err)
+			}
+			for _, b := range backups {
+				if err := os.Remove(b); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("remove backup: %w", err)
+				}
+			}
+			h.pluginsMu.Lock()
+			if cur, ok := h.plugins[pluginID]; ok {
+				cur.BackupPath = ""
+			}
+			h.pluginsMu.Unlock()
+		case ResetScopeCommands:
+			h.removePluginCommands(pluginID)
+			h.syncManifestCommands(p.Manifest)
+		}
+	}
+
+	h.Broadcast(Event{Type: "plugin.reset", Data: map[string]any{
+		"pluginId": pluginID,
+		"scope":    scopes,
+	}})
+	return nil
+}
+
+// pluginBackups 返回插件的备份文件。备份文件名为 <id>-v<version>-<时间>.zip，
+// ID 以 pluginID 加 -v 开头的其他已安装插件的备份会被排除
+func (h *PluginHost) pluginBackups(pluginID string) ([]string, error) {
+	entries, err := os.ReadDir(h.backupDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	prefix := pluginID + "-v"
+	var others []string
+	h.pluginsMu.RLock()
+	for id := range h.plugins {
+		if id != pluginID && strings.HasPrefix(id, prefix) {
+			others = append(others, id+"-v")
+		}
+	}
+	h.pluginsMu.RUnlock()
+
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".zip") {
+			continue
+		}
+		owned := true
+		for _, o := range others {
+			if strings.HasPrefix(name, o) {
+				owned = false
+				break
+			}
+		}
+		if owned {
+			paths = append(paths, filepath.Join(h.backupDir(), name))
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,31 @@
+package host
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestResetAndBackupRequireAdminForOtherPlugins(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret", PluginKeys: map[string]string{"alpha": "key-a", "beta": "key-b"}})
+	addTestPlugin(t, h, "alpha")
+	addTestPlugin(t, h, "beta")
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	alpha := http.Header{pluginKeyHeader: {"key-a"}}
+	beta := http.Header{pluginKeyHeader: {"key-b"}}
+
+	for _, method := range []string{"host.resetPlugin", "host.backupPlugin"} {
+		params := map[string]any{"pluginId": "beta", "scope": []string{ResetScopeSettings}}
+		if code, _ := callRPCWithHeader(t, h, alpha, "alpha", method, params); code != 403 {
+			t.Errorf("%s on another plugin without admin: got %d, want 403", method, code)
+		}
+		if code, _ := callRPC(t, h, "", method, params); code != 403 {
+			t.Errorf("%s anonymously: got %d, want 403", method, code)
+		}
+		if code, resp := callRPCWithHeader(t, h, beta, "beta", method, params); code != 200 {
+			t.Errorf("%s on itself: got %d %+v", method, code, resp.Error)
+		}
+		if code, resp := callRPCWithHeader(t, h, admin, "", method, params); code != 200 {
+			t.Errorf("%s as admin: got %d %+v", method, code, resp.Error)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	// DefaultMaxRPCRequestBytes 未配置 MaxRPCRequestBytes 时单个RPC请求体的最大字节数
+	DefaultMaxRPCRequestBytes = 1 << 20
+	// DefaultMaxRPCBatchSize 未配置 MaxRPCBatchSize 时参数中单个数组的最大元素数
+	DefaultMaxRPCBatchSize = 1000
+	// DefaultMaxRPCParamsDepth 未配置 MaxRPCParamsDepth 时参数的最大嵌套层数
+	DefaultMaxRPCParamsDepth = 32
+)
+
+var (
+	errRPCBatchTooLarge = errors.New("batch too large")
+	errRPCParamsTooDeep = errors.New("params nested too deeply")
+)
+
+// maxRPCRequestBytes 返回生效的请求体字节数上限
+func (h *PluginHost) maxRPCRequestBytes() int64 {
+	if h.config.MaxRPCRequestBytes > 0 {
+		return h.config.MaxRPCRequestBytes
+	}
+	return DefaultMaxRPCRequestBytes
+}
+
+// maxRPCBatchSize 返回生效的数组元素数上限
+func (h *PluginHost) maxRPCBatchSize() int {
+	if h.config.MaxRPCBatchSize > 0 {
+		return h.config.MaxRPCBatchSize
+	}
+	return DefaultMaxRPCBatchSize
+}
+
+// maxRPCParamsDepth 返回生效的嵌套层数上限
+func (h *PluginHost) maxRPCParamsDepth() int {
+	if h.config.MaxRPCParamsDepth > 0 {
+		return h.config.MaxRPCParamsDepth
+	}
+	return DefaultMaxRPCParamsDepth
+}
+
+// checkRPCParams 逐个读取参数的 JSON 记号，数组元素数或嵌套层数超过上限时返回错误。
+// 与请求体字节数限制互补：小请求体也可能包含大量元素或极深的嵌套
+func checkRPCParams(params json.RawMessage, maxLen, maxDepth int) error {
+	if len(params) == 0 {
+		return nil
+	}
+	type frame struct {
+		array bool
+		n     int
+	}
+	var stack []frame
+	// countElem 在数组中计入一个元素
+	countElem := func() error {
+		if len(stack) == 0 || !stack[len(stack)-1].array {
+			return nil
+		}
+		stack[len(stack)-1].n++
+		if stack[len(stack)-1].n > maxLen {
+			return errRPCBatchTooLarge
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(params))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			// 读完或格式错误都交给方法自己解析参数时处理
+			return nil
+		}
+		d, ok := tok.(json.Delim)
+		if !ok {
+			if err := countElem(); err != nil {
+				return err
+			}
+			continue
+		}
+		switch d {
+		case '[', '{':
+			if err := countElem(); err != nil {
+				return err
+			}
+			stack = append(stack, frame{array: d == '['})
+			if len(stack) > maxDepth {
+				return errRPCParamsTooDeep
+			}
+		case ']', '}':
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
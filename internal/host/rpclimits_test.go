@@ -0,0 +1,103 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRPCRequestBytesLimit(t *testing.T) {
+	content := strings.Repeat("a", DefaultMaxRPCRequestBytes)
+
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "writer", "vault.write")
+	if code, _ := callRPC(t, h, "writer", "vault.write", map[string]any{"path": "big.md", "content": content}); code != 400 {
+		t.Fatalf("oversized request with default limit: got %d, want 400", code)
+	}
+
+	h = newTestHost(t, Config{MaxRPCRequestBytes: 4 << 20})
+	addTestPlugin(t, h, "writer", "vault.write")
+	if code, resp := callRPC(t, h, "writer", "vault.write", map[string]any{"path": "big.md", "content": content}); code != 200 {
+		t.Fatalf("write under configured limit: %d %+v", code, resp.Error)
+	}
+	_, resp := callRPC(t, h, "", "host.getInfo", nil)
+	info, _ := resp.Result.(map[string]any)
+	limits, _ := info["limits"].(map[string]any)
+	if limits["maxRequestBytes"] != float64(4<<20) {
+		t.Fatalf("host.getInfo limits = %v", info["limits"])
+	}
+}
+
+func TestCheckRPCParams(t *testing.T) {
+	if err := checkRPCParams([]byte(`{"a":[1,2,3]}`), 2, 10); err == nil {
+		t.Error("array longer than the limit should be rejected")
+	}
+	if err := checkRPCParams([]byte(`[[[[1]]]]`), 10, 3); err == nil {
+		t.Error("nesting deeper than the limit should be rejected")
+	}
+	if err := checkRPCParams([]byte(`{"a":[1,2],"b":{"c":1}}`), 2, 3); err != nil {
+		t.Errorf("params within limits rejected: %v", err)
+	}
+}
+
+func TestCheckRPCParamsLimits(t *testing.T) {
+	cases := []struct {
+		name   string
+		params string
+		want   error
+	}{
+		{"empty", "", nil},
+		{"flat", `{"pluginIds":["a","b","c"]}`, nil},
+		{"at batch limit", `{"pluginIds":["a","b","c"],"tags":[1,2,3]}`, nil},
+		{"batch too large", `{"pluginIds":["a","b","c","d"]}`, errRPCBatchTooLarge},
+		{"nested array too large", `{"a":[[1],[1,2,3,4]]}`, errRPCBatchTooLarge},
+		{"at depth limit", `{"a":{"b":[1]}}`, nil},
+		{"too deep", `{"a":{"b":{"c":{}}}}`, errRPCParamsTooDeep},
+		{"malformed", `{"a":[1,`, nil},
+	}
+	for _, tc := range cases {
+		if got := checkRPCParams(json.RawMessage(tc.params), 3, 3); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRPCBatchSizeLimit(t *testing.T) {
+	h := newTestHost(t, Config{MaxRPCBatchSize: 3})
+	ids := make([]string, 4)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("p%d", i)
+		addTestPlugin(t, h, ids[i])
+	}
+
+	code, resp := callRPC(t, h, "p0", "host.batchSetEnabled", map[string]any{"pluginIds": ids, "enabled": false})
+	if code != http.StatusBadRequest || resp.Error == nil || resp.Error.Message != "batch too large" {
+		t.Fatalf("over-long batch: got %d %+v, want 400 batch too large", code, resp.Error)
+	}
+	for _, id := range ids {
+		if p, _ := h.getPlugin(id); !p.Enabled {
+			t.Errorf("rejected batch disabled %s", id)
+		}
+	}
+
+	code, resp = callRPC(t, h, "p0", "host.batchSetEnabled", map[string]any{"pluginIds": ids[:3], "enabled": false})
+	if code != http.StatusOK {
+		t.Fatalf("batch at the limit: got %d %+v", code, resp.Error)
+	}
+	if results := batchResults(t, resp); len(results) != 3 {
+		t.Errorf("got %d results, want 3", len(results))
+	}
+}
+
+func TestRPCParamsDepthLimit(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo", "kv")
+
+	deep := strings.Repeat(`{"a":`, DefaultMaxRPCParamsDepth) + "1" + strings.Repeat("}", DefaultMaxRPCParamsDepth)
+	code, resp := callRPC(t, h, "demo", "kv.set", map[string]any{"key": "k", "value": json.RawMessage(deep)})
+	if code != http.StatusBadRequest || resp.Error == nil || resp.Error.Message != "params nested too deeply" {
+		t.Errorf("deeply nested params: got %d %+v, want 400 params nested too deeply", code, resp.Error)
+	}
+}
@@ -0,0 +1,180 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// rpcParamType 参数字段的 JSON 类型，为空时接受任意类型
+type rpcParamType string
+
+const (
+	rpcString rpcParamType = "string"
+	rpcBool   rpcParamType = "boolean"
+	rpcNumber rpcParamType = "number"
+	rpcArray  rpcParamType = "array"
+	rpcObject rpcParamType = "object"
+	rpcAny    rpcParamType = ""
+)
+
+// rpcParam 单个参数字段的约束。必填的字符串和数组还不能为空
+type rpcParam struct {
+	Type     rpcParamType
+	Required bool
+}
+
+// rpcFieldError 参数校验失败的字段和原因，随 invalid params 错误返回给调用方
+type rpcFieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+var pluginIDParam = map[string]rpcParam{"pluginId": {Type: rpcString, Required: true}}
+
+// rpcParamSchemas 各方法参数的字段约束，在分发前统一检查；未登记的方法和未登记的字段不检查
+var rpcParamSchemas = map[string]map[string]rpcParam{
+	"host.getPlugins": {"sort": {Type: rpcString}},
+	"vault.read":      {"path": {Type: rpcString, Required: true}},
+	"vault.readMeta": {
+		"path":        {Type: rpcString, Required: true},
+		"bodyPreview": {Type: rpcBool},
+	},
+	"vault.write": {
+		"path":    {Type: rpcString, Required: true},
+		"content": {Type: rpcString},
+	},
+	"vault.copy": {
+		"from":      {Type: rpcString, Required: true},
+		"to":        {Type: rpcString, Required: true},
+		"overwrite": {Type: rpcBool},
+	},
+	"commands.register": {
+		"id":               {Type: rpcString, Required: true},
+		"title":            {Type: rpcString, Required: true},
+		"category":         {Type: rpcString},
+		"hotkey":           {Type: rpcString},
+		"invokePermission": {Type: rpcString},
+	},
+	"host.getCommandPalette": {"includeDisabled": {Type: rpcBool}},
+	"commands.invoke": {
+		"id":        {Type: rpcString, Required: true},
+		"pluginId":  {Type: rpcString},
+		"wait":      {Type: rpcBool},
+		"timeoutMs": {Type: rpcNumber},
+	},
+	"commands.result": {
+		"invocationId": {Type: rpcString, Required: true},
+		"error":        {Type: rpcString},
+	},
+	"kv.get": {"key": {Type: rpcString}},
+	"kv.set": {
+		"key":   {Type: rpcString},
+		"value": {Type: rpcAny, Required: true},
+	},
+	"kv.delete":                  {"key": {Type: rpcString}},
+	"kv.list":                    {"prefix": {Type: rpcString}},
+	"events.publish":             {"name": {Type: rpcString, Required: true}},
+	"host.getInstallationStatus": pluginIDParam,
+	"host.enablePlugin":          pluginIDParam,
+	"host.disablePlugin":         pluginIDParam,
+	"host.batchSetEnabled": {
+		"pluginIds": {Type: rpcArray, Required: true},
+		"enabled":   {Type: rpcBool, Required: true},
+	},
+	"host.batchUninstall": {
+		"pluginIds":  {Type: rpcArray, Required: true},
+		"skipBackup": {Type: rpcBool},
+		"backupDir":  {Type: rpcString},
+	},
+	"host.backupPlugin": pluginIDParam,
+	"host.resetPlugin": {
+		"pluginId": {Type: rpcString, Required: true},
+		"scope":    {Type: rpcArray},
+	},
+	"host.checkPermission": {
+		"pluginId":   {Type: rpcString, Required: true},
+		"permission": {Type: rpcString, Required: true},
+	},
+	"host.getPluginConfigSchema": pluginIDParam,
+	"host.getPluginSettings":     pluginIDParam,
+	"host.setPluginSettings": {
+		"pluginId": {Type: rpcString, Required: true},
+		"values":   {Type: rpcObject, Required: true},
+	},
+	"host.updatePlugin":       pluginIDParam,
+	"host.pingPlugin":         pluginIDParam,
+	"host.setReadOnly":        {"readOnly": {Type: rpcBool, Required: true}},
+	"host.getPluginStats":     {"pluginId": {Type: rpcString}},
+	"host.getPluginProcess":   {"pluginId": {Type: rpcString}},
+	"host.resetPluginStats":   {"pluginId": {Type: rpcString}},
+	"host.approvePermissions": pluginIDParam,
+	"host.previewUninstall":   pluginIDParam,
+	"host.listPluginFiles": {
+		"pluginId": {Type: rpcString, Required: true},
+		"glob":     {Type: rpcString},
+	},
+}
+
+// checkRPCSchema 按方法登记的约束检查参数，返回全部不符合的字段，按字段名排序
+func checkRPCSchema(method string, params json.RawMessage) []rpcFieldError {
+	schema, ok := rpcParamSchemas[method]
+	if !ok {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &fields); err != nil {
+			return []rpcFieldError{{Field: "params", Reason: "must be an object"}}
+		}
+	}
+	var errs []rpcFieldError
+	for name, param := range schema {
+		raw, present := fields[name]
+		if !present || string(raw) == "null" {
+			if param.Required {
+				errs = append(errs, rpcFieldError{Field: name, Reason: "required"})
+			}
+			continue
+		}
+		if param.Type != rpcAny && jsonValueType(raw) != param.Type {
+			errs = append(errs, rpcFieldError{Field: name, Reason: "must be " + articleFor(param.Type) + string(param.Type)})
+			continue
+		}
+		if param.Required && (string(raw) == `""` || string(raw) == "[]") {
+			errs = append(errs, rpcFieldError{Field: name, Reason: "must not be empty"})
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// jsonValueType 根据首个字符判断 JSON 值的类型，值已由 json.Unmarshal 校验过
+func jsonValueType(raw json.RawMessage) rpcParamType {
+	switch raw[0] {
+	case '"':
+		return rpcString
+	case 't', 'f':
+		return rpcBool
+	case '[':
+		return rpcArray
+	case '{':
+		return rpcObject
+	default:
+		return rpcNumber
+	}
+}
+
+func articleFor(t rpcParamType) string {
+	if t == rpcArray || t == rpcObject {
+		return "an "
+	}
+	return "a "
+}
+
+// writeRPCFieldErrors 返回 400 invalid params，details 中列出每个字段的问题
+func writeRPCFieldErrors(w http.ResponseWriter, id string, details []rpcFieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(rpcResponse{ID: id, Error: &rpcError{Code: 400, Message: "invalid params", Details: details}})
+}
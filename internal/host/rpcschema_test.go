@@ -0,0 +1,52 @@
+package host
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCheckRPCSchema(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		params string
+		want   []rpcFieldError
+	}{
+		{"valid", "vault.copy", `{"from":"a.md","to":"b.md","overwrite":true}`, nil},
+		{"unregistered method", "vault.list", `{"anything":1}`, nil},
+		{"unregistered field ignored", "vault.read", `{"path":"a.md","extra":1}`, nil},
+		{"missing required", "vault.copy", `{"from":"a.md"}`, []rpcFieldError{{Field: "to", Reason: "required"}}},
+		{"null counts as missing", "vault.read", `{"path":null}`, []rpcFieldError{{Field: "path", Reason: "required"}}},
+		{"no params", "vault.read", ``, []rpcFieldError{{Field: "path", Reason: "required"}}},
+		{"wrong type", "vault.readMeta", `{"path":"a.md","bodyPreview":"yes"}`, []rpcFieldError{{Field: "bodyPreview", Reason: "must be a boolean"}}},
+		{"array article", "host.batchSetEnabled", `{"pluginIds":"a","enabled":true}`, []rpcFieldError{{Field: "pluginIds", Reason: "must be an array"}}},
+		{"empty required string", "vault.read", `{"path":""}`, []rpcFieldError{{Field: "path", Reason: "must not be empty"}}},
+		{"empty required array", "host.batchSetEnabled", `{"pluginIds":[],"enabled":false}`, []rpcFieldError{{Field: "pluginIds", Reason: "must not be empty"}}},
+		{"any type", "kv.set", `{"value":[1,2]}`, nil},
+		{"not an object", "vault.read", `["a.md"]`, []rpcFieldError{{Field: "params", Reason: "must be an object"}}},
+		{"sorted by field", "vault.copy", `{"overwrite":1}`, []rpcFieldError{
+			{Field: "from", Reason: "required"},
+			{Field: "overwrite", Reason: "must be a boolean"},
+			{Field: "to", Reason: "required"},
+		}},
+	}
+	for _, c := range cases {
+		if got := checkRPCSchema(c.method, json.RawMessage(c.params)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestHandleRPCReturnsSchemaDetails(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo", "vault.read")
+
+	code, resp := callRPC(t, h, "demo", "vault.read", map[string]any{"path": 3})
+	if code != 400 || resp.Error == nil || resp.Error.Message != "invalid params" {
+		t.Fatalf("got %d %+v, want 400 invalid params", code, resp.Error)
+	}
+	if want := []rpcFieldError{{Field: "path", Reason: "must be a string"}}; !reflect.DeepEqual(resp.Error.Details, want) {
+		t.Errorf("details = %+v, want %+v", resp.Error.Details, want)
+	}
+}
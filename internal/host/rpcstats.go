@@ -0,0 +1,150 @@
+package host
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// rpcRateWindow 插件RPC限流的统计窗口
+const rpcRateWindow = time.Minute
+
+// PluginRPCStats 插件的RPC调用统计，保存在内存中，宿主重启或调用 host.resetPluginStats 后清零
+type PluginRPCStats struct {
+	PluginID string `json:"pluginId"`
+	Calls    int64  `json:"calls"`
+	// Errors 返回错误的调用数，包括被限流拒绝的调用
+	Errors int64 `json:"errors"`
+	// Limited 被限流拒绝的调用数
+	Limited  int64     `json:"limited"`
+	BytesIn  int64     `json:"bytesIn"`
+	BytesOut int64     `json:"bytesOut"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// pluginRPCCounter 单个插件的统计和当前限流窗口
+type pluginRPCCounter struct {
+	stats       PluginRPCStats
+	windowStart time.Time
+	windowCalls int
+}
+
+// allowPluginRPC 按 Config.PluginRPCRateLimit 检查插件在当前窗口内能否再发起调用，
+// 只统计已安装的插件，避免任意声明的插件ID占用内存
+func (h *PluginHost) allowPluginRPC(pluginID string) bool {
+	limit := h.config.PluginRPCRateLimit
+	if limit <= 0 || pluginID == "" {
+		return true
+	}
+	if _, ok := h.getPlugin(pluginID); !ok {
+		return true
+	}
+	h.rpcStatsMu.Lock()
+	defer h.rpcStatsMu.Unlock()
+	c := h.rpcCounterLocked(pluginID)
+	now := time.Now()
+	if now.Sub(c.windowStart) >= rpcRateWindow {
+		c.windowStart = now
+		c.windowCalls = 0
+	}
+	if c.windowCalls >= limit {
+		c.stats.Limited++
+		return false
+	}
+	c.windowCalls++
+	return true
+}
+
+// recordPluginRPC 记录一次调用的流量和结果
+func (h *PluginHost) recordPluginRPC(pluginID string, bytesIn int64, w *rpcStatsWriter) {
+	if pluginID == "" {
+		return
+	}
+	if _, ok := h.getPlugin(pluginID); !ok {
+		return
+	}
+	h.rpcStatsMu.Lock()
+	defer h.rpcStatsMu.Unlock()
+	c := h.rpcCounterLocked(pluginID)
+	c.stats.Calls++
+	if w.status >= 400 {
+		c.stats.Errors++
+	}
+	c.stats.BytesIn += bytesIn
+	c.stats.BytesOut += w.written
+	c.stats.LastSeen = time.Now()
+}
+
+// rpcCounterLocked 返回插件的计数器，不存在时创建，调用方须持有 rpcStatsMu
+func (h *PluginHost) rpcCounterLocked(pluginID string) *pluginRPCCounter {
+	if h.rpcStats == nil {
+		h.rpcStats = make(map[string]*pluginRPCCounter)
+	}
+	c, ok := h.rpcStats[pluginID]
+	if !ok {
+		c = &pluginRPCCounter{stats: PluginRPCStats{PluginID: pluginID}}
+		h.rpcStats[pluginID] = c
+	}
+	return c
+}
+
+// pluginRPCStats 返回插件的调用统计，pluginID 为空时返回全部插件，按插件ID排序
+func (h *PluginHost) pluginRPCStats(pluginID string) []PluginRPCStats {
+	h.rpcStatsMu.Lock()
+	defer h.rpcStatsMu.Unlock()
+	out := make([]PluginRPCStats, 0, len(h.rpcStats))
+	for id, c := range h.rpcStats {
+		if pluginID == "" || id == pluginID {
+			out = append(out, c.stats)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PluginID < out[j].PluginID })
+	return out
+}
+
+// resetPluginRPCStats 清零插件的调用统计，pluginID 为空时清零全部
+func (h *PluginHost) resetPluginRPCStats(pluginID string) {
+	h.rpcStatsMu.Lock()
+	defer h.rpcStatsMu.Unlock()
+	if pluginID == "" {
+		h.rpcStats = nil
+		return
+	}
+	delete(h.rpcStats, pluginID)
+}
+
+// rpcStatsReader 统计读取的请求体字节数
+type rpcStatsReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *rpcStatsReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// rpcStatsWriter 记录响应状态码和写出的字节数
+type rpcStatsWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *rpcStatsWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *rpcStatsWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
@@ -0,0 +1,130 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// pluginStats 以管理员身份调用 host.getPluginStats
+func pluginStats(t *testing.T, h *PluginHost, pluginID string) []PluginRPCStats {
+	t.Helper()
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	code, resp := callRPCWithHeader(t, h, admin, "", "host.getPluginStats", map[string]any{"pluginId": pluginID})
+	if code != http.StatusOK {
+		t.Fatalf("host.getPluginStats: got %d %+v", code, resp.Error)
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats []PluginRPCStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatal(err)
+	}
+	return stats
+}
+
+func TestPluginRPCStatsCountCalls(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "a", "vault.read")
+	addTestPlugin(t, h, "b")
+
+	for i := 0; i < 3; i++ {
+		if code, resp := callRPC(t, h, "a", "host.getPlugins", nil); code != http.StatusOK {
+			t.Fatalf("host.getPlugins: got %d %+v", code, resp.Error)
+		}
+	}
+	if code, _ := callRPC(t, h, "a", "vault.read", map[string]any{"path": "missing.md"}); code < http.StatusBadRequest {
+		t.Fatalf("vault.read of a missing file: status = %d, want an error", code)
+	}
+	// 未安装的插件不统计
+	callRPC(t, h, "ghost", "host.getPlugins", nil)
+
+	stats := pluginStats(t, h, "")
+	if len(stats) != 1 || stats[0].PluginID != "a" {
+		t.Fatalf("stats = %+v, want only plugin a", stats)
+	}
+	s := stats[0]
+	if s.Calls != 4 || s.Errors != 1 || s.Limited != 0 {
+		t.Errorf("calls/errors/limited = %d/%d/%d, want 4/1/0", s.Calls, s.Errors, s.Limited)
+	}
+	if s.BytesIn == 0 || s.BytesOut == 0 || s.LastSeen.IsZero() {
+		t.Errorf("traffic not recorded: %+v", s)
+	}
+
+	callRPC(t, h, "a", "host.getPlugins", nil)
+	if got := pluginStats(t, h, "a"); len(got) != 1 || got[0].Calls != 5 || got[0].BytesIn <= s.BytesIn {
+		t.Errorf("stats after another call = %+v, want 5 calls", got)
+	}
+}
+
+func TestPluginRPCRateLimit(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret", PluginRPCRateLimit: 2})
+	addTestPlugin(t, h, "a")
+	addTestPlugin(t, h, "b")
+
+	for i := 0; i < 2; i++ {
+		if code, resp := callRPC(t, h, "a", "host.getPlugins", nil); code != http.StatusOK {
+			t.Fatalf("call %d under the limit: got %d %+v", i, code, resp.Error)
+		}
+	}
+	code, resp := callRPC(t, h, "a", "host.getPlugins", nil)
+	if code != http.StatusTooManyRequests || resp.Error == nil || resp.Error.Message != "rate limit exceeded" {
+		t.Fatalf("call over the limit: got %d %+v, want 429", code, resp.Error)
+	}
+	// 限流按插件计算
+	if code, resp := callRPC(t, h, "b", "host.getPlugins", nil); code != http.StatusOK {
+		t.Errorf("other plugin was limited: got %d %+v", code, resp.Error)
+	}
+
+	stats := pluginStats(t, h, "a")
+	if len(stats) != 1 || stats[0].Calls != 3 || stats[0].Errors != 1 || stats[0].Limited != 1 {
+		t.Errorf("stats = %+v, want 3 calls, 1 error, 1 limited", stats)
+	}
+}
+
+func TestPluginStatsAccess(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret", PluginKeys: map[string]string{"a": "key-a", "b": "key-b"}})
+	addTestPlugin(t, h, "a")
+	addTestPlugin(t, h, "b")
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	keyA := http.Header{pluginKeyHeader: {"key-a"}}
+	callRPCWithHeader(t, h, keyA, "a", "host.getPlugins", nil)
+	callRPCWithHeader(t, h, http.Header{pluginKeyHeader: {"key-b"}}, "b", "host.getPlugins", nil)
+
+	// 以凭据认证的插件只能查看自己的统计
+	code, resp := callRPCWithHeader(t, h, keyA, "a", "host.getPluginStats", nil)
+	if code != http.StatusOK {
+		t.Fatalf("own stats: got %d %+v", code, resp.Error)
+	}
+	if own, _ := resp.Result.([]any); len(own) != 1 || own[0].(map[string]any)["pluginId"] != "a" {
+		t.Errorf("own stats = %v, want only plugin a", resp.Result)
+	}
+	if code, _ := callRPCWithHeader(t, h, keyA, "a", "host.getPluginStats", map[string]any{"pluginId": "b"}); code != http.StatusForbidden {
+		t.Errorf("another plugin's stats: status = %d, want 403", code)
+	}
+	if code, _ := callRPC(t, h, "a", "host.getPluginStats", nil); code != http.StatusUnauthorized {
+		t.Errorf("stats without the plugin key: status = %d, want 401", code)
+	}
+
+	if code, _ := callRPCWithHeader(t, h, keyA, "a", "host.resetPluginStats", nil); code != http.StatusForbidden {
+		t.Errorf("non-admin reset: status = %d, want 403", code)
+	}
+	if code, resp := callRPCWithHeader(t, h, admin, "", "host.resetPluginStats", map[string]any{"pluginId": "a"}); code != http.StatusOK {
+		t.Fatalf("reset a: got %d %+v", code, resp.Error)
+	}
+	if stats := pluginStats(t, h, ""); len(stats) != 1 || stats[0].PluginID != "b" {
+		t.Errorf("stats after resetting a = %+v, want only b", stats)
+	}
+	if code, resp := callRPCWithHeader(t, h, admin, "", "host.resetPluginStats", nil); code != http.StatusOK {
+		t.Fatalf("reset all: got %d %+v", code, resp.Error)
+	}
+	if stats := pluginStats(t, h, ""); len(stats) != 0 {
+		t.Errorf("stats after reset = %+v, want none", stats)
+	}
+	if logs, _ := h.queryAudit("host.resetPluginStats", time.Time{}, time.Time{}); len(logs) != 2 {
+		t.Errorf("got %d host.resetPluginStats audit entries, want 2", len(logs))
+	}
+}
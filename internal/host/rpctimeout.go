@@ -0,0 +1,144 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRPCTimeout 未配置 RPCTimeout 时单个RPC请求的超时
+	DefaultRPCTimeout = 30 * time.Second
+	// DefaultSlowRPCThreshold 未配置 SlowRPCThreshold 时记录慢请求的耗时阈值
+	DefaultSlowRPCThreshold = 2 * time.Second
+)
+
+// defaultRPCMethodTimeouts 耗时可能较长的方法的内置超时，可被 Config.RPCMethodTimeouts 覆盖
+var defaultRPCMethodTimeouts = map[string]time.Duration{
+	"vault.list":      2 * time.Minute,
+	"commands.invoke": maxCommandTimeout + 5*time.Second,
+}
+
+// rpcTimeout 返回方法生效的超时
+func (h *PluginHost) rpcTimeout(method string) time.Duration {
+	if d, ok := h.config.RPCMethodTimeouts[method]; ok && d > 0 {
+		return d
+	}
+	if d, ok := defaultRPCMethodTimeouts[method]; ok {
+		return d
+	}
+	if h.config.RPCTimeout > 0 {
+		return h.config.RPCTimeout
+	}
+	return DefaultRPCTimeout
+}
+
+// slowRPCThreshold 返回生效的慢请求阈值
+func (h *PluginHost) slowRPCThreshold() time.Duration {
+	if h.config.SlowRPCThreshold > 0 {
+		return h.config.SlowRPCThreshold
+	}
+	return DefaultSlowRPCThreshold
+}
+
+// serveRPCWithTimeout 在超时上下文中执行RPC方法。处理函数的输出先写入缓冲，
+// 超时后取消上下文并返回 504 错误，之后处理函数的写入全部丢弃。
+// 超时只限制响应时间：不检查上下文的处理函数在 504 之后仍会执行完毕，
+// 因此 mutatingRPCMethods 中的方法不受超时限制，以免客户端收到 504 而修改实际已生效
+func (h *PluginHost) serveRPCWithTimeout(w http.ResponseWriter, r *http.Request, req rpcRequest, handler rpcHandler) {
+	start := time.Now()
+	defer func() {
+		if d := time.Since(start); d >= h.slowRPCThreshold() {
+			log.Printf("rpc: slow request %s (plugin %q) took %s", req.Method, req.PluginID, d)
+		}
+	}()
+
+	if mutatingRPCMethods[req.Method] {
+		handler(w, r, req)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.rpcTimeout(req.Method))
+	defer cancel()
+
+	buf := &rpcBuffer{header: make(http.Header)}
+	done := make(chan struct{})
+	panicked := make(chan any, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		handler(buf, r.WithContext(ctx), req)
+		close(done)
+	}()
+
+	select {
+	case p := <-panicked:
+		panic(p)
+	case <-done:
+		buf.flush(w)
+	case <-ctx.Done():
+		buf.discard()
+		if r.Context().Err() != nil {
+			// 客户端已断开，无需响应
+			return
+		}
+		writeRPCError(w, req.ID, 504, "request timed out")
+	}
+}
+
+// rpcBuffer 缓冲RPC处理函数的响应，超时后丢弃后续写入
+type rpcBuffer struct {
+	mu        sync.Mutex
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	discarded bool
+}
+
+func (b *rpcBuffer) Header() http.Header { return b.header }
+
+func (b *rpcBuffer) WriteHeader(status int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *rpcBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.discarded {
+		return 0, http.ErrHandlerTimeout
+	}
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *rpcBuffer) discard() {
+	b.mu.Lock()
+	b.discarded = true
+	b.mu.Unlock()
+}
+
+// flush 把缓冲的响应写入 w，只在处理函数返回后调用
+func (b *rpcBuffer) flush(w http.ResponseWriter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	w.WriteHeader(b.status)
+	_, _ = w.Write(b.body.Bytes())
+}
@@ -0,0 +1,35 @@
+package host
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServeRPCWithTimeoutReturns504ForReadMethods(t *testing.T) {
+	h := newTestHost(t, Config{RPCMethodTimeouts: map[string]time.Duration{"vault.read": 10 * time.Millisecond}})
+	release := make(chan struct{})
+	defer close(release)
+	w := httptest.NewRecorder()
+	h.serveRPCWithTimeout(w, httptest.NewRequest("POST", "/rpc", nil), rpcRequest{ID: "1", Method: "vault.read"},
+		func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			<-release
+		})
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", w.Code)
+	}
+}
+
+func TestServeRPCWithTimeoutExemptsMutatingMethods(t *testing.T) {
+	h := newTestHost(t, Config{RPCMethodTimeouts: map[string]time.Duration{"vault.write": 10 * time.Millisecond}})
+	w := httptest.NewRecorder()
+	h.serveRPCWithTimeout(w, httptest.NewRequest("POST", "/rpc", nil), rpcRequest{ID: "1", Method: "vault.write"},
+		func(w http.ResponseWriter, r *http.Request, req rpcRequest) {
+			time.Sleep(50 * time.Millisecond)
+			writeRPCResult(w, req.ID, "ok")
+		})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutsideSandbox 访问的存储库路径不在插件沙箱目录内
+var ErrOutsideSandbox = errors.New("path outside plugin sandbox")
+
+// cleanVaultPath 把存储库相对路径规整为不带前导 / 的 slash 形式，存储库根目录为 "."
+func cleanVaultPath(p string) string {
+	cleaned := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(p)), "/")
+	if cleaned == "" {
+		return "."
+	}
+	return cleaned
+}
+
+// vaultSandboxRoot 返回插件可访问的存储库子目录，清单未声明 sandbox 时返回 false
+func (h *PluginHost) vaultSandboxRoot(pluginID string) (string, bool) {
+	p, ok := h.getPlugin(pluginID)
+	if !ok || p.Manifest.Sandbox == nil {
+		return "", false
+	}
+	root := p.Manifest.Sandbox.VaultRoot
+	if root == "" {
+		root = "plugins/" + pluginID + "/data"
+	}
+	return cleanVaultPath(root), true
+}
+
+// inVaultRoot 判断规整后的路径是否位于 root 之内
+func inVaultRoot(root, p string) bool {
+	return root == "." || p == root || strings.HasPrefix(p, root+"/")
+}
+
+// checkVaultSandbox 检查插件能否访问该存储库路径
+func (h *PluginHost) checkVaultSandbox(pluginID, relPath string) error {
+	root, ok := h.vaultSandboxRoot(pluginID)
+	if !ok || inVaultRoot(root, cleanVaultPath(relPath)) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is outside %s", ErrOutsideSandbox, relPath, root)
+}
+
+// filterVaultSandbox 只保留插件沙箱目录内的路径
+func (h *PluginHost) filterVaultSandbox(pluginID string, paths []string) []string {
+	root, ok := h.vaultSandboxRoot(pluginID)
+	if !ok {
+		return paths
+	}
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if inVaultRoot(root, cleanVaultPath(p)) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
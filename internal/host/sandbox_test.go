@@ -0,0 +1,34 @@
+package host
+
+import "testing"
+
+func TestVaultSandboxRPC(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "boxed", "vault.read", "vault.write")
+	h.plugins["boxed"].Manifest.Sandbox = &Sandbox{VaultRoot: "notes"}
+
+	if code, resp := callRPC(t, h, "boxed", "vault.write", map[string]any{"path": "notes/a.md", "content": "hi"}); code != 200 {
+		t.Fatalf("write inside sandbox: %d %+v", code, resp.Error)
+	}
+	for _, p := range []string{"other.md", "notes/../other.md", "notesx/a.md"} {
+		if code, _ := callRPC(t, h, "boxed", "vault.write", map[string]any{"path": p, "content": "x"}); code != 403 {
+			t.Errorf("write %q: got %d, want 403", p, code)
+		}
+	}
+	if code, _ := callRPC(t, h, "boxed", "vault.read", map[string]any{"path": "other.md"}); code != 403 {
+		t.Errorf("read outside sandbox: got %d, want 403", code)
+	}
+}
+
+func TestVaultSandboxDefaultRoot(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "boxed")
+	h.plugins["boxed"].Manifest.Sandbox = &Sandbox{}
+	if root, ok := h.vaultSandboxRoot("boxed"); !ok || root != "plugins/boxed/data" {
+		t.Fatalf("root = %q, %v", root, ok)
+	}
+	got := h.filterVaultSandbox("boxed", []string{"plugins/boxed/data/a", "plugins/other/data/b"})
+	if len(got) != 1 || got[0] != "plugins/boxed/data/a" {
+		t.Fatalf("filterVaultSandbox = %v", got)
+	}
+}
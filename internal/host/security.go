@@ -1,13 +1,20 @@
 package host
 
 import (
+    "crypto/ed25519"
     "crypto/sha256"
+    "encoding/base64"
     "encoding/hex"
+    "errors"
     "fmt"
+    "log"
+    "net"
+    "net/http"
     "net/url"
     "path/filepath"
     "regexp"
     "strings"
+    "sync"
     "time"
 )
 
@@ -17,8 +24,13 @@ type SecurityConfig struct {
     AllowedDomains        []string      `json:"allowedDomains"`        // 允许的下载域名
     InstallTimeout        time.Duration `json:"installTimeout"`       // 安装超时时间
     RequireSignature      bool          `json:"requireSignature"`     // 是否要求签名验证
-    AllowLocalInstall     bool          `json:"allowLocalInstall"`    // 是否允许本地安装
+    AllowLocalInstall     bool          `json:"allowLocalInstall"`    // 是否允许本地安装（仅放行回环地址）
+    AllowPrivateNetwork   bool          `json:"allowPrivateNetwork"`   // 是否允许下载时连接私有网段、链路本地等内部地址
     MaxConcurrentInstalls int           `json:"maxConcurrentInstalls"` // 最大并发安装数
+    AllowedPluginIDs      []string      `json:"allowedPluginIds"`      // 允许安装的插件ID，为空表示不限制
+    BlockedPluginIDs      []string      `json:"blockedPluginIds"`      // 禁止安装的插件ID，优先于允许列表
+    RequireMarketSHA256   bool          `json:"requireMarketSha256"`   // 非本地来源的安装必须提供SHA256
+    MaxPlugins            int           `json:"maxPlugins"`            // 最多可安装的插件数，0表示不限制，升级不受影响
 }
 
 // DefaultSecurityConfig 返回默认安全配置
@@ -48,12 +60,32 @@ type ValidationError struct {
     Field   string `json:"field"`
     Message string `json:"message"`
     Code    string `json:"code"`
+    Args    []any  `json:"-"` // 消息模板参数，用于按语言重新生成消息
 }
 
 func (e *ValidationError) Error() string {
     return fmt.Sprintf("validation error in %s: %s", e.Field, e.Message)
 }
 
+// ValidationFailedError 安装请求或清单未通过校验
+type ValidationFailedError struct {
+    Errors []ValidationError
+}
+
+func (e *ValidationFailedError) Error() string {
+    return fmt.Sprintf("validation failed: %v", e.Errors)
+}
+
+// validationStatus 返回校验失败对应的 HTTP 状态码，插件被禁止安装时为 403，其余为 400
+func validationStatus(errs []ValidationError) int {
+    for _, e := range errs {
+        if e.Code == "PLUGIN_NOT_ALLOWED" {
+            return http.StatusForbidden
+        }
+    }
+    return http.StatusBadRequest
+}
+
 // ValidationResult 验证结果
 type ValidationResult struct {
     Valid  bool              `json:"valid"`
@@ -70,6 +102,12 @@ func (v *PluginValidator) ValidateInstallRequest(id, downloadURL, sha256Hash str
         result.Errors = append(result.Errors, *err)
     }
 
+    // 验证插件ID是否允许安装
+    if err := v.validatePluginAllowed(id); err != nil {
+        result.Valid = false
+        result.Errors = append(result.Errors, *err)
+    }
+
     // 验证下载URL
     if err := v.validateDownloadURL(downloadURL); err != nil {
         result.Valid = false
@@ -87,6 +125,9 @@ func (v *PluginValidator) ValidateInstallRequest(id, downloadURL, sha256Hash str
     return result
 }
 
+// pluginIDPattern 插件ID只能包含字母、数字、连字符和下划线
+var pluginIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 // validatePluginID 验证插件ID格式
 func (v *PluginValidator) validatePluginID(id string) *ValidationError {
     if id == "" {
@@ -98,7 +139,7 @@ func (v *PluginValidator) validatePluginID(id string) *ValidationError {
     }
 
     // 插件ID只能包含字母、数字、连字符和下划线
-    if matched, _ := regexp.MatchString(`^[a-zA-Z0-9_-]+$`, id); !matched {
+    if !pluginIDPattern.MatchString(id) {
         return &ValidationError{
             Field:   "id",
             Message: "插件ID只能包含字母、数字、连字符和下划线",
@@ -118,6 +159,36 @@ func (v *PluginValidator) validatePluginID(id string) *ValidationError {
     return nil
 }
 
+// validatePluginAllowed 检查插件ID的允许/禁止列表，禁止列表优先
+func (v *PluginValidator) validatePluginAllowed(id string) *ValidationError {
+    for _, blocked := range v.config.BlockedPluginIDs {
+        if blocked == id {
+            return &ValidationError{
+                Field:   "id",
+                Message: fmt.Sprintf("插件 %s 已被禁止安装", id),
+                Code:    "PLUGIN_NOT_ALLOWED",
+                Args:    []any{id},
+            }
+        }
+    }
+
+    if len(v.config.AllowedPluginIDs) > 0 {
+        for _, allowed := range v.config.AllowedPluginIDs {
+            if allowed == id {
+                return nil
+            }
+        }
+        return &ValidationError{
+            Field:   "id",
+            Message: fmt.Sprintf("插件 %s 不在允许安装的列表中", id),
+            Code:    "PLUGIN_NOT_ALLOWED",
+            Args:    []any{id},
+        }
+    }
+
+    return nil
+}
+
 // validateDownloadURL 验证下载URL
 func (v *PluginValidator) validateDownloadURL(downloadURL string) *ValidationError {
     if downloadURL == "" {
@@ -152,7 +223,7 @@ func (v *PluginValidator) validateDownloadURL(downloadURL string) *ValidationErr
     if len(v.config.AllowedDomains) > 0 {
         allowed := false
         for _, domain := range v.config.AllowedDomains {
-            if strings.HasSuffix(hostname, domain) || hostname == domain {
+            if hostname == domain || strings.HasSuffix(hostname, "."+domain) {
                 allowed = true
                 break
             }
@@ -162,6 +233,7 @@ func (v *PluginValidator) validateDownloadURL(downloadURL string) *ValidationErr
                 Field:   "url",
                 Message: fmt.Sprintf("域名 %s 不在允许的域名列表中", hostname),
                 Code:    "DOMAIN_NOT_ALLOWED",
+                Args:    []any{hostname},
             }
         }
     }
@@ -250,6 +322,119 @@ func (v *PluginValidator) ValidateManifest(manifest *Manifest) *ValidationResult
         })
     }
 
+    // 验证配置结构
+    seen := make(map[string]bool, len(manifest.ConfigSchema))
+    for _, f := range manifest.ConfigSchema {
+        if f.Name == "" || seen[f.Name] {
+            result.Valid = false
+            result.Errors = append(result.Errors, ValidationError{
+                Field:   "manifest.configSchema",
+                Message: fmt.Sprintf("配置项名称为空或重复: %q", f.Name),
+                Code:    "INVALID_CONFIG_FIELD",
+                Args:    []any{f.Name},
+            })
+            continue
+        }
+        seen[f.Name] = true
+        if !isConfigType(f.Type) {
+            result.Valid = false
+            result.Errors = append(result.Errors, ValidationError{
+                Field:   "manifest.configSchema",
+                Message: fmt.Sprintf("配置项 %s 的类型 %q 不受支持", f.Name, f.Type),
+                Code:    "UNSUPPORTED_CONFIG_TYPE",
+                Args:    []any{f.Name, f.Type},
+            })
+            continue
+        }
+        if f.Default != nil && !matchesConfigType(f.Type, f.Default) {
+            result.Valid = false
+            result.Errors = append(result.Errors, ValidationError{
+                Field:   "manifest.configSchema",
+                Message: fmt.Sprintf("配置项 %s 的默认值与类型 %s 不符", f.Name, f.Type),
+                Code:    "INVALID_CONFIG_DEFAULT",
+                Args:    []any{f.Name, f.Type},
+            })
+        }
+    }
+
+    // 验证声明的命令
+    commandIDs := make(map[string]bool, len(manifest.Commands))
+    for _, c := range manifest.Commands {
+        if c.ID == "" || c.Title == "" || commandIDs[c.ID] {
+            result.Valid = false
+            result.Errors = append(result.Errors, ValidationError{
+                Field:   "manifest.commands",
+                Message: fmt.Sprintf("命令ID或标题为空，或ID重复: %q", c.ID),
+                Code:    "INVALID_COMMAND",
+                Args:    []any{c.ID},
+            })
+            continue
+        }
+        commandIDs[c.ID] = true
+    }
+
+    // 验证沙箱目录
+    if manifest.Sandbox != nil && manifest.Sandbox.VaultRoot != "" {
+        root := filepath.ToSlash(manifest.Sandbox.VaultRoot)
+        if strings.HasPrefix(root, "/") || root == ".." || strings.HasPrefix(root, "../") || strings.Contains(root, "/../") || strings.HasSuffix(root, "/..") {
+            result.Valid = false
+            result.Errors = append(result.Errors, ValidationError{
+                Field:   "manifest.sandbox.vaultRoot",
+                Message: fmt.Sprintf("沙箱目录必须是存储库内的相对路径: %q", manifest.Sandbox.VaultRoot),
+                Code:    "INVALID_SANDBOX",
+                Args:    []any{manifest.Sandbox.VaultRoot},
+            })
+        }
+    }
+
+    // 验证图标路径
+    if manifest.Icon != "" && !isIconPath(manifest.Icon) {
+        result.Valid = false
+        result.Errors = append(result.Errors, ValidationError{
+            Field:   "manifest.icon",
+            Message: fmt.Sprintf("图标必须是插件包内的图片文件相对路径: %q", manifest.Icon),
+            Code:    "INVALID_ICON",
+            Args:    []any{manifest.Icon},
+        })
+    }
+
+    // 验证本地化文本的语言标签
+    for _, key := range invalidLocaleKeys(manifest.I18n) {
+        result.Valid = false
+        result.Errors = append(result.Errors, ValidationError{
+            Field:   "manifest.i18n",
+            Message: fmt.Sprintf("本地化文本的键不是有效的语言标签: %q", key),
+            Code:    "INVALID_LOCALE",
+            Args:    []any{key},
+        })
+    }
+
+    // 验证依赖的SDK版本范围
+    if manifest.Engines != nil && manifest.Engines.SDK != "" {
+        if _, err := parseVersionRange(manifest.Engines.SDK); err != nil {
+            result.Valid = false
+            result.Errors = append(result.Errors, ValidationError{
+                Field:   "manifest.engines.sdk",
+                Message: fmt.Sprintf("SDK版本范围无效: %q", manifest.Engines.SDK),
+                Code:    "INVALID_ENGINES_SDK",
+                Args:    []any{manifest.Engines.SDK},
+            })
+        }
+    }
+
+    // 验证自托管更新地址
+    if manifest.UpdateURL != "" {
+        if verr := v.validateDownloadURL(manifest.UpdateURL); verr != nil {
+            result.Valid = false
+            result.Errors = append(result.Errors, ValidationError{
+                Field:   "manifest.updateUrl",
+                Message: fmt.Sprintf("更新地址必须是允许域名下的HTTPS地址: %q", manifest.UpdateURL),
+                Code:    "INVALID_UPDATE_URL",
+                Args:    []any{manifest.UpdateURL},
+            })
+        }
+    }
+
     return result
 }
 
@@ -275,6 +460,52 @@ func (v *PluginValidator) VerifyFileIntegrity(data []byte, expectedHash string)
     return nil
 }
 
+// VerifyPinnedSignature 使用固定的发布者公钥验证安装包签名
+// pubKey 和 signature 均为base64编码的ed25519公钥和签名
+func VerifyPinnedSignature(pubKey string, data []byte, signature string) error {
+    if signature == "" {
+        return fmt.Errorf("插件已固定发布者公钥，必须提供签名")
+    }
+
+    key, err := base64.StdEncoding.DecodeString(pubKey)
+    if err != nil || len(key) != ed25519.PublicKeySize {
+        return fmt.Errorf("无效的固定公钥")
+    }
+
+    sig, err := base64.StdEncoding.DecodeString(signature)
+    if err != nil || len(sig) != ed25519.SignatureSize {
+        return fmt.Errorf("无效的签名格式")
+    }
+
+    if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+        return fmt.Errorf("签名与固定的发布者公钥不匹配")
+    }
+
+    return nil
+}
+
+// RequiresSHA256 判断该下载地址的安装是否必须提供SHA256：开启 RequireMarketSHA256 时，
+// 除 AllowLocalInstall 下的本地地址外都必须提供
+func (v *PluginValidator) RequiresSHA256(downloadURL string) bool {
+    if !v.config.RequireMarketSHA256 {
+        return false
+    }
+    u, err := url.Parse(downloadURL)
+    if err != nil {
+        return true
+    }
+    if v.config.AllowLocalInstall {
+        host := u.Hostname()
+        if host == "localhost" {
+            return false
+        }
+        if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+            return false
+        }
+    }
+    return true
+}
+
 // CheckPluginSize 检查插件大小
 func (v *PluginValidator) CheckPluginSize(size int64) error {
     if size > v.config.MaxPluginSize {
@@ -301,18 +532,67 @@ func (v *PluginValidator) SanitizePluginPath(pluginID, path string) (string, err
     return cleanPath, nil
 }
 
+// 安装失败的错误码
+const (
+    InstallErrValidation        = "VALIDATION_FAILED"
+    InstallErrBusy              = "INSTALL_BUSY"
+    InstallErrDownload          = "DOWNLOAD_FAILED"
+    InstallErrDownloadBlocked   = "DOWNLOAD_BLOCKED"
+    InstallErrSizeExceeded      = "SIZE_EXCEEDED"
+    InstallErrIntegrity         = "INTEGRITY_FAILED"
+    InstallErrSignature         = "SIGNATURE_INVALID"
+    InstallErrManifest          = "MANIFEST_INVALID"
+    InstallErrIDMismatch        = "ID_MISMATCH"
+    InstallErrWrite             = "WRITE_FAILED"
+    InstallErrGitResolve        = "GIT_RESOLVE_FAILED"
+    InstallErrDigestLookup      = "DIGEST_LOOKUP_FAILED"
+    InstallErrIntegrityRequired = "INTEGRITY_REQUIRED"
+    InstallErrMaxPlugins        = "MAX_PLUGINS_REACHED"
+    InstallErrHookRejected      = "INSTALL_REJECTED"
+    InstallErrContentType       = "UNEXPECTED_CONTENT_TYPE"
+    InstallErrInterrupted       = "INSTALL_INTERRUPTED"
+    InstallErrIncompatible      = "PLUGIN_INCOMPATIBLE"
+)
+
+// InstallError 安装失败错误，Code 为稳定的错误码，客户端据此展示本地化提示
+type InstallError struct {
+    Code string
+    Err  error
+}
+
+func (e *InstallError) Error() string {
+    return e.Err.Error()
+}
+
+func (e *InstallError) Unwrap() error {
+    return e.Err
+}
+
+// installErrorCode 返回错误链中的安装错误码，不是安装错误时返回空串
+func installErrorCode(err error) string {
+    var installErr *InstallError
+    if errors.As(err, &installErr) {
+        return installErr.Code
+    }
+    return ""
+}
+
 // InstallationContext 安装上下文，用于跟踪安装状态
 type InstallationContext struct {
     PluginID  string    `json:"pluginId"`
     Status    string    `json:"status"`
     StartTime time.Time `json:"startTime"`
     Error     string    `json:"error,omitempty"`
+    ErrorCode string    `json:"errorCode,omitempty"`
 }
 
 // InstallationManager 安装管理器
 type InstallationManager struct {
+    mu            sync.Mutex
     installations map[string]*InstallationContext
     maxConcurrent int
+    // store 不为 nil 时每次记录变化后写回
+    store         InstallationStore
 }
 
 // NewInstallationManager 创建新的安装管理器
@@ -323,8 +603,52 @@ func NewInstallationManager(maxConcurrent int) *InstallationManager {
     }
 }
 
+// Restore 从 store 读取上次运行留下的安装记录，之后的记录变化都会写回 store。
+// 宿主退出时仍在进行的安装标记为 failed，错误码为 INSTALL_INTERRUPTED
+func (im *InstallationManager) Restore(store InstallationStore) error {
+    records, err := store.Load()
+    if err != nil {
+        return err
+    }
+
+    im.mu.Lock()
+    defer im.mu.Unlock()
+
+    im.store = store
+    interrupted := false
+    for _, ctx := range records {
+        if ctx == nil || ctx.PluginID == "" {
+            continue
+        }
+        if ctx.Status == "installing" {
+            ctx.Status = "failed"
+            ctx.Error = "installation interrupted by host restart"
+            ctx.ErrorCode = InstallErrInterrupted
+            interrupted = true
+        }
+        im.installations[ctx.PluginID] = ctx
+    }
+    if interrupted {
+        return store.Save(sortedInstallations(im.installations))
+    }
+    return nil
+}
+
+// persistLocked 把全部记录写回 store，调用方须持有 mu，写入失败只记录日志
+func (im *InstallationManager) persistLocked() {
+    if im.store == nil {
+        return
+    }
+    if err := im.store.Save(sortedInstallations(im.installations)); err != nil {
+        log.Printf("installations: save records: %v", err)
+    }
+}
+
 // StartInstallation 开始安装
 func (im *InstallationManager) StartInstallation(pluginID string) error {
+    im.mu.Lock()
+    defer im.mu.Unlock()
+
     // 检查是否超过最大并发数
     activeCount := 0
     for _, ctx := range im.installations {
@@ -348,12 +672,30 @@ func (im *InstallationManager) StartInstallation(pluginID string) error {
         Status:    "installing",
         StartTime: time.Now(),
     }
+    im.persistLocked()
 
     return nil
 }
 
+// ActiveCount 返回正在进行的安装数
+func (im *InstallationManager) ActiveCount() int {
+    im.mu.Lock()
+    defer im.mu.Unlock()
+
+    count := 0
+    for _, ctx := range im.installations {
+        if ctx.Status == "installing" {
+            count++
+        }
+    }
+    return count
+}
+
 // CompleteInstallation 完成安装
 func (im *InstallationManager) CompleteInstallation(pluginID string, err error) {
+    im.mu.Lock()
+    defer im.mu.Unlock()
+
     ctx, exists := im.installations[pluginID]
     if !exists {
         return
@@ -362,22 +704,41 @@ func (im *InstallationManager) CompleteInstallation(pluginID string, err error)
     if err != nil {
         ctx.Status = "failed"
         ctx.Error = err.Error()
+        ctx.ErrorCode = installErrorCode(err)
     } else {
         ctx.Status = "completed"
     }
+    im.persistLocked()
 }
 
 // GetInstallationStatus 获取安装状态
+// 返回副本，避免调用方与进行中的安装并发读写同一记录
 func (im *InstallationManager) GetInstallationStatus(pluginID string) *InstallationContext {
-    return im.installations[pluginID]
+    im.mu.Lock()
+    defer im.mu.Unlock()
+
+    ctx, exists := im.installations[pluginID]
+    if !exists {
+        return nil
+    }
+    snapshot := *ctx
+    return &snapshot
 }
 
 // CleanupOldInstallations 清理旧的安装记录
 func (im *InstallationManager) CleanupOldInstallations(maxAge time.Duration) {
+    im.mu.Lock()
+    defer im.mu.Unlock()
+
     cutoff := time.Now().Add(-maxAge)
+    removed := false
     for id, ctx := range im.installations {
         if ctx.StartTime.Before(cutoff) && ctx.Status != "installing" {
             delete(im.installations, id)
+            removed = true
         }
     }
+    if removed {
+        im.persistLocked()
+    }
 }
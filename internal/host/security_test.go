@@ -0,0 +1,59 @@
+package host
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatePluginAllowed(t *testing.T) {
+	cases := []struct {
+		name             string
+		allowed, blocked []string
+		id               string
+		ok               bool
+	}{
+		{"neutral", nil, nil, "demo", true},
+		{"allowlisted", []string{"demo"}, nil, "demo", true},
+		{"not allowlisted", []string{"other"}, nil, "demo", false},
+		{"blocked", nil, []string{"demo"}, "demo", false},
+		{"blocked wins over allowlist", []string{"demo"}, []string{"demo"}, "demo", false},
+		{"other id blocked", nil, []string{"other"}, "demo", true},
+	}
+	for _, tc := range cases {
+		cfg := DefaultSecurityConfig()
+		cfg.AllowedPluginIDs = tc.allowed
+		cfg.BlockedPluginIDs = tc.blocked
+		verr := NewPluginValidator(cfg).validatePluginAllowed(tc.id)
+		if (verr == nil) != tc.ok {
+			t.Errorf("%s: got %v, want ok=%v", tc.name, verr, tc.ok)
+			continue
+		}
+		if verr != nil && verr.Code != "PLUGIN_NOT_ALLOWED" {
+			t.Errorf("%s: code = %s, want PLUGIN_NOT_ALLOWED", tc.name, verr.Code)
+		}
+	}
+}
+
+func TestInstallRejectsBlockedPluginBeforeDownload(t *testing.T) {
+	cfg := DefaultSecurityConfig()
+	cfg.BlockedPluginIDs = []string{"demo"}
+	h := newTestHost(t, Config{Security: &cfg})
+	url := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+
+	err := h.installPluginFromURL("demo", url, "", "", nil)
+	var vf *ValidationFailedError
+	if !errors.As(err, &vf) || len(vf.Errors) != 1 || vf.Errors[0].Code != "PLUGIN_NOT_ALLOWED" {
+		t.Fatalf("install error = %v, want PLUGIN_NOT_ALLOWED", err)
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "demo")); !os.IsNotExist(err) {
+		t.Fatalf("blocked plugin directory should not exist, stat err = %v", err)
+	}
+
+	cfg.BlockedPluginIDs = nil
+	cfg.AllowedPluginIDs = []string{"demo"}
+	if err := h.installPluginFromURL("demo", url, "", "", nil); err != nil {
+		t.Fatalf("allowlisted install: %v", err)
+	}
+}
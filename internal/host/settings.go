@@ -0,0 +1,142 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 配置字段支持的类型
+const (
+	ConfigTypeString  = "string"
+	ConfigTypeNumber  = "number"
+	ConfigTypeBoolean = "boolean"
+	ConfigTypeArray   = "array"
+	ConfigTypeObject  = "object"
+)
+
+// ConfigField 清单中声明的单个配置项，用于生成设置表单
+type ConfigField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Default     any    `json:"default,omitempty"`
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// settingsPath 返回插件设置的保存位置
+func (h *PluginHost) settingsPath(pluginID string) string {
+	return filepath.Join(h.config.RootDir, "settings", pluginID+".json")
+}
+
+// getPluginSettings 读取插件设置，未保存的字段使用清单中的默认值
+func (h *PluginHost) getPluginSettings(pluginID string) (map[string]any, error) {
+	p, ok := h.getPlugin(pluginID)
+	if !ok {
+		return nil, fmt.Errorf("plugin not found: %s", pluginID)
+	}
+
+	values := make(map[string]any)
+	for _, f := range p.Manifest.ConfigSchema {
+		if f.Default != nil {
+			values[f.Name] = f.Default
+		}
+	}
+
+	data, err := os.ReadFile(h.settingsPath(pluginID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return values, nil
+		}
+		return nil, err
+	}
+	var saved map[string]any
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid settings file: %w", err)
+	}
+	for k, v := range saved {
+		values[k] = v
+	}
+	return values, nil
+}
+
+// setPluginSettings 按清单声明的结构校验并保存插件设置
+func (h *PluginHost) setPluginSettings(pluginID string, values map[string]any) error {
+	p, ok := h.getPlugin(pluginID)
+	if !ok {
+		return fmt.Errorf("plugin not found: %s", pluginID)
+	}
+	if err := validateSettings(p.Manifest.ConfigSchema, values); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := h.settingsPath(pluginID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+
+	h.Broadcast(Event{Type: "plugin.settings.changed", Data: map[string]string{"pluginId": pluginID}})
+	return nil
+}
+
+// validateSettings 校验设置值与字段声明的类型一致，拒绝未声明的字段
+func validateSettings(schema []ConfigField, values map[string]any) error {
+	fields := make(map[string]ConfigField, len(schema))
+	for _, f := range schema {
+		fields[f.Name] = f
+	}
+	for name, v := range values {
+		f, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unknown setting: %s", name)
+		}
+		if v == nil {
+			continue
+		}
+		if !matchesConfigType(f.Type, v) {
+			return fmt.Errorf("setting %s must be of type %s", name, f.Type)
+		}
+	}
+	return nil
+}
+
+// isConfigType 判断是否为支持的配置字段类型
+func isConfigType(typ string) bool {
+	switch typ {
+	case ConfigTypeString, ConfigTypeNumber, ConfigTypeBoolean, ConfigTypeArray, ConfigTypeObject:
+		return true
+	default:
+		return false
+	}
+}
+
+// matchesConfigType 判断JSON解码后的值是否符合声明的类型
+func matchesConfigType(typ string, v any) bool {
+	switch typ {
+	case ConfigTypeString:
+		_, ok := v.(string)
+		return ok
+	case ConfigTypeNumber:
+		_, ok := v.(float64)
+		return ok
+	case ConfigTypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case ConfigTypeArray:
+		_, ok := v.([]any)
+		return ok
+	case ConfigTypeObject:
+		_, ok := v.(map[string]any)
+		return ok
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package host
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPluginSettingsRequireCallerOrAdmin(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret", PluginKeys: map[string]string{"alpha": "key-a", "beta": "key-b"}})
+	addTestPlugin(t, h, "alpha")
+	addTestPlugin(t, h, "beta")
+	h.pluginsMu.Lock()
+	h.plugins["beta"].Manifest.ConfigSchema = []ConfigField{{Name: "color", Type: "string", Default: "red"}}
+	h.pluginsMu.Unlock()
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	alpha := http.Header{pluginKeyHeader: {"key-a"}}
+	beta := http.Header{pluginKeyHeader: {"key-b"}}
+
+	get := map[string]any{"pluginId": "beta"}
+	set := map[string]any{"pluginId": "beta", "values": map[string]any{"color": "blue"}}
+	for _, c := range []struct {
+		method string
+		params map[string]any
+	}{{"host.getPluginSettings", get}, {"host.setPluginSettings", set}} {
+		if code, _ := callRPCWithHeader(t, h, alpha, "alpha", c.method, c.params); code != 403 {
+			t.Errorf("%s on another plugin: got %d, want 403", c.method, code)
+		}
+		if code, _ := callRPC(t, h, "", c.method, c.params); code != 403 {
+			t.Errorf("%s anonymously: got %d, want 403", c.method, code)
+		}
+		if code, resp := callRPCWithHeader(t, h, beta, "beta", c.method, c.params); code != 200 {
+			t.Errorf("%s on itself: got %d %+v", c.method, code, resp.Error)
+		}
+		if code, resp := callRPCWithHeader(t, h, admin, "", c.method, c.params); code != 200 {
+			t.Errorf("%s as admin: got %d %+v", c.method, code, resp.Error)
+		}
+	}
+
+	values, err := h.getPluginSettings("beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["color"] != "blue" {
+		t.Errorf("color = %v, want blue", values["color"])
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	schema := []ConfigField{
+		{Name: "title", Type: ConfigTypeString},
+		{Name: "limit", Type: ConfigTypeNumber},
+		{Name: "enabled", Type: ConfigTypeBoolean},
+		{Name: "tags", Type: ConfigTypeArray},
+		{Name: "extra", Type: ConfigTypeObject},
+	}
+	good := map[string]any{
+		"title":   "notes",
+		"limit":   float64(10),
+		"enabled": true,
+		"tags":    []any{"a", "b"},
+		"extra":   map[string]any{"k": "v"},
+	}
+	if err := validateSettings(schema, good); err != nil {
+		t.Fatalf("valid settings rejected: %v", err)
+	}
+	if err := validateSettings(schema, map[string]any{"title": nil}); err != nil {
+		t.Errorf("null value rejected: %v", err)
+	}
+
+	for name, values := range map[string]map[string]any{
+		"string as number":  {"limit": "10"},
+		"number as boolean": {"enabled": float64(1)},
+		"object as array":   {"tags": map[string]any{}},
+		"unknown field":     {"colour": "red"},
+	} {
+		if err := validateSettings(schema, values); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSetPluginSettingsRejectsTypeMismatch(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "alpha")
+	h.pluginsMu.Lock()
+	h.plugins["alpha"].Manifest.ConfigSchema = []ConfigField{{Name: "limit", Type: ConfigTypeNumber, Default: float64(5)}}
+	h.pluginsMu.Unlock()
+
+	if err := h.setPluginSettings("alpha", map[string]any{"limit": "lots"}); err == nil {
+		t.Fatal("type mismatch should be rejected")
+	}
+	values, err := h.getPluginSettings("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["limit"] != float64(5) {
+		t.Errorf("rejected write changed settings: limit = %v", values["limit"])
+	}
+
+	if err := h.setPluginSettings("alpha", map[string]any{"limit": float64(20)}); err != nil {
+		t.Fatalf("valid value rejected: %v", err)
+	}
+	if values, _ = h.getPluginSettings("alpha"); values["limit"] != float64(20) {
+		t.Errorf("limit = %v, want 20", values["limit"])
+	}
+}
@@ -0,0 +1,105 @@
+package host
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// brokenSSEWriter 在第一次写入之后的所有写入都失败，模拟已断开的客户端
+type brokenSSEWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *brokenSSEWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes > 1 {
+		return 0, errors.New("broken pipe")
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestSSEKeepAliveInterval(t *testing.T) {
+	for _, tc := range []struct {
+		cfg  time.Duration
+		want time.Duration
+	}{
+		{0, defaultSSEKeepAlive},
+		{time.Second, time.Second},
+		{-1, -1},
+	} {
+		h := newTestHost(t, Config{SSEKeepAlive: tc.cfg})
+		if got := h.sseKeepAlive(); got != tc.want {
+			t.Errorf("SSEKeepAlive %v: interval = %v, want %v", tc.cfg, got, tc.want)
+		}
+	}
+	if defaultSSEKeepAlive != 15*time.Second {
+		t.Errorf("default keepalive = %v", defaultSSEKeepAlive)
+	}
+}
+
+func TestSSEWritesPingWhenIdle(t *testing.T) {
+	h := newTestHost(t, Config{SSEKeepAlive: 20 * time.Millisecond})
+	srv := httptest.NewServer(http.HandlerFunc(h.handleSSE))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// 没有任何事件时也应陆续收到 ping 注释
+	sc := bufio.NewScanner(resp.Body)
+	pings := 0
+	for pings < 2 && sc.Scan() {
+		if sc.Text() == ": ping" {
+			pings++
+		}
+	}
+	if pings < 2 {
+		t.Fatalf("got %d pings before stream ended: %v", pings, sc.Err())
+	}
+}
+
+func TestSSEKeepAliveDisabled(t *testing.T) {
+	h := newTestHost(t, Config{SSEKeepAlive: -1})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	h.handleSSE(w, httptest.NewRequest("GET", "/events", nil).WithContext(ctx))
+	if strings.Contains(w.Body.String(), "ping") {
+		t.Fatalf("ping written with keepalive disabled: %q", w.Body.String())
+	}
+}
+
+func TestSSEPingFailureRemovesClient(t *testing.T) {
+	h := newTestHost(t, Config{SSEKeepAlive: 10 * time.Millisecond})
+	w := &brokenSSEWriter{ResponseRecorder: httptest.NewRecorder()}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.handleSSE(w, httptest.NewRequest("GET", "/events", nil))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler kept running after ping write failed")
+	}
+	if n := h.eventHub.clientCount(); n != 0 {
+		t.Fatalf("clients = %d, want dead client removed", n)
+	}
+}
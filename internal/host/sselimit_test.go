@@ -0,0 +1,88 @@
+package host
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// openSSE 连接事件流，状态为 200 时读到开流注释后返回
+func openSSE(t *testing.T, ctx context.Context, url string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode == http.StatusOK {
+		if line, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil || line != ":ok\n" {
+			t.Fatalf("stream start = %q, %v", line, err)
+		}
+	}
+	return resp
+}
+
+func TestSSERejectsClientsOverLimit(t *testing.T) {
+	h := newTestHost(t, Config{MaxSSEClients: 2, SSEKeepAlive: -1})
+	srv := httptest.NewServer(http.HandlerFunc(h.handleSSE))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	first, cancelFirst := context.WithCancel(ctx)
+	defer cancelFirst()
+	for _, c := range []context.Context{first, ctx} {
+		if resp := openSSE(t, c, srv.URL); resp.StatusCode != http.StatusOK {
+			t.Fatalf("client within the limit: got %d", resp.StatusCode)
+		}
+	}
+
+	resp := openSSE(t, ctx, srv.URL)
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != sseRetryAfter {
+		t.Fatalf("third client: got %d Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if n := h.eventHub.clientCount(); n != 2 {
+		t.Fatalf("clients = %d, want rejected client not counted", n)
+	}
+
+	// 断开一个客户端后腾出名额
+	cancelFirst()
+	deadline := time.Now().Add(5 * time.Second)
+	for h.eventHub.clientCount() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("disconnected client not removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if resp := openSSE(t, ctx, srv.URL); resp.StatusCode != http.StatusOK {
+		t.Fatalf("client after a disconnect: got %d", resp.StatusCode)
+	}
+}
+
+func TestEventHubAddClientLimit(t *testing.T) {
+	h := newTestHost(t, Config{})
+	newClient := func() *sseClient { return &sseClient{ch: make(chan []byte, 1), done: make(chan struct{})} }
+	a, b := newClient(), newClient()
+	if !h.eventHub.addClient(a, 1) {
+		t.Fatal("first client rejected")
+	}
+	if h.eventHub.addClient(b, 1) {
+		t.Fatal("client over the limit accepted")
+	}
+	// 0 表示不限制
+	if !h.eventHub.addClient(b, 0) {
+		t.Fatal("unlimited hub rejected a client")
+	}
+	if n := h.eventHub.clientCount(); n != 2 {
+		t.Fatalf("clients = %d, want 2", n)
+	}
+	h.eventHub.removeClient(a)
+	h.eventHub.removeClient(b)
+}
@@ -0,0 +1,85 @@
+package host
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// stagingDir 返回安装时组装插件的暂存目录，未配置时使用 RootDir/staging
+func (h *PluginHost) stagingDir() string {
+	if h.config.StagingDir != "" {
+		return h.config.StagingDir
+	}
+	return filepath.Join(h.config.RootDir, "staging")
+}
+
+// placePluginManifest 先在暂存目录写好名为 name 的清单，再原子地放入插件目录：新插件整个目录一次性移入，
+// 升级时只替换清单文件并删除其他格式的旧清单，LoadPlugins 和文件服务不会看到写了一半的插件
+func (h *PluginHost) placePluginManifest(dir, name string, manifest []byte, newDir bool) error {
+	if err := os.MkdirAll(h.stagingDir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	stage, err := os.MkdirTemp(h.stagingDir(), filepath.Base(dir)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stage)
+
+	staged := filepath.Join(stage, name)
+	if err := os.WriteFile(staged, manifest, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest file: %w", err)
+	}
+
+	if !newDir {
+		if err := os.Rename(staged, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("failed to replace manifest file: %w", err)
+		}
+		for _, other := range h.manifestNames() {
+			if other != name {
+				os.Remove(filepath.Join(dir, other))
+			}
+		}
+		return nil
+	}
+	if err := os.Chmod(stage, 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(stage, dir); err != nil {
+		return fmt.Errorf("failed to move plugin directory into place: %w", err)
+	}
+	return nil
+}
+
+// snapshotManifests 读取插件目录中现有的各格式清单，升级失败时由 undoPluginManifest 写回
+func (h *PluginHost) snapshotManifests(dir string) map[string][]byte {
+	previous := make(map[string][]byte)
+	for _, name := range h.manifestNames() {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			previous[name] = data
+		}
+	}
+	return previous
+}
+
+// undoPluginManifest 撤销 placePluginManifest：新插件删除整个目录，升级时写回之前的清单并删除新增的清单
+func (h *PluginHost) undoPluginManifest(dir string, newDir bool, previous map[string][]byte) {
+	if newDir {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("remove plugin directory %s: %v", dir, err)
+		}
+		return
+	}
+	for _, name := range h.manifestNames() {
+		path := filepath.Join(dir, name)
+		data, ok := previous[name]
+		if !ok {
+			os.Remove(path)
+			continue
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			log.Printf("restore manifest %s: %v", path, err)
+		}
+	}
+}
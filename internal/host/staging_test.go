@@ -0,0 +1,150 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// stagingEntries 返回暂存目录中残留的条目
+func stagingEntries(t *testing.T, h *PluginHost) []string {
+	t.Helper()
+	entries, err := os.ReadDir(h.stagingDir())
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestInstallUsesConfiguredStagingDir(t *testing.T) {
+	staging := filepath.Join(t.TempDir(), "stage")
+	h := newTestHost(t, Config{StagingDir: staging})
+	if h.stagingDir() != staging {
+		t.Fatalf("stagingDir = %s, want %s", h.stagingDir(), staging)
+	}
+	if err := h.installPluginFromURL("demo", serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"}), "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "demo", "manifest.json")); err != nil {
+		t.Errorf("manifest not moved into place: %v", err)
+	}
+	if left := stagingEntries(t, h); len(left) != 0 {
+		t.Errorf("staging dir not cleaned up: %v", left)
+	}
+}
+
+func TestLoadPluginsIgnoresStagedPlugins(t *testing.T) {
+	h := newTestHost(t, Config{})
+	// 暂存目录中组装了一半的插件不会被加载
+	stage := filepath.Join(h.stagingDir(), "demo-123")
+	data, _ := json.Marshal(Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if err := os.MkdirAll(stage, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(stage, "manifest.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.LoadPlugins(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.getPlugin("demo"); ok {
+		t.Error("LoadPlugins loaded a plugin from the staging directory")
+	}
+}
+
+func TestPluginDirNeverVisibleHalfWritten(t *testing.T) {
+	h := newTestHost(t, Config{})
+	const n = 8
+	urls := make([]string, n)
+	for i := range urls {
+		id := fmt.Sprintf("p%d", i)
+		urls[i] = serveManifest(t, Manifest{ID: id, Name: id, Version: "1.0.0", Description: string(make([]byte, 4096))})
+	}
+
+	// 安装过程中反复扫描插件目录，出现的每个插件目录都必须已有完整的清单
+	var stop atomic.Bool
+	var partial atomic.Int32
+	var scans sync.WaitGroup
+	scans.Add(1)
+	go func() {
+		defer scans.Done()
+		for !stop.Load() {
+			entries, _ := os.ReadDir(h.config.PluginsDir)
+			for _, e := range entries {
+				if _, _, err := h.readPluginManifest(filepath.Join(h.config.PluginsDir, e.Name())); err != nil {
+					if _, statErr := os.Stat(filepath.Join(h.config.PluginsDir, e.Name())); statErr == nil {
+						partial.Add(1)
+					}
+				}
+			}
+		}
+	}()
+
+	for i, url := range urls {
+		if err := h.installPluginFromURL(fmt.Sprintf("p%d", i), url, "", "", nil); err != nil {
+			t.Errorf("install p%d: %v", i, err)
+		}
+	}
+	stop.Store(true)
+	scans.Wait()
+
+	if c := partial.Load(); c != 0 {
+		t.Errorf("saw %d plugin directories without a complete manifest", c)
+	}
+	if got := h.CountPlugins(); got != n {
+		t.Errorf("installed %d plugins, want %d", got, n)
+	}
+	if left := stagingEntries(t, h); len(left) != 0 {
+		t.Errorf("staging dir not cleaned up: %v", left)
+	}
+}
+
+func TestFailedInstallLeavesNoStagedFiles(t *testing.T) {
+	h := newTestHost(t, Config{Security: &SecurityConfig{MaxPlugins: 1}})
+	addTestPlugin(t, h, "first")
+	if err := h.installPluginFromURL("demo", serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"}), "", "", nil); err == nil {
+		t.Fatal("install over the plugin limit: expected error")
+	}
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "demo")); !os.IsNotExist(err) {
+		t.Errorf("rejected plugin directory left in place: %v", err)
+	}
+	if left := stagingEntries(t, h); len(left) != 0 {
+		t.Errorf("staging dir not cleaned up: %v", left)
+	}
+}
+
+func TestPlacePluginManifestUpgradeReplacesOtherFormats(t *testing.T) {
+	h := newTestHost(t, Config{})
+	dir := filepath.Join(h.config.PluginsDir, "demo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte("id: demo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previous := h.snapshotManifests(dir)
+
+	if err := h.placePluginManifest(dir, "manifest.json", []byte(`{"id":"demo"}`), false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.yaml")); !os.IsNotExist(err) {
+		t.Errorf("old YAML manifest kept next to the new one: %v", err)
+	}
+
+	// 撤销时写回原有清单并删除新增的清单
+	h.undoPluginManifest(dir, false, previous)
+	if data, err := os.ReadFile(filepath.Join(dir, "manifest.yaml")); err != nil || string(data) != "id: demo\n" {
+		t.Errorf("manifest.yaml after undo = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); !os.IsNotExist(err) {
+		t.Errorf("new manifest kept after undo: %v", err)
+	}
+}
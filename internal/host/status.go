@@ -0,0 +1,43 @@
+package host
+
+import "time"
+
+// HostStatus 宿主运行状态汇总，供运维面板一次获取。所有字段来自内存中的计数，不遍历目录
+type HostStatus struct {
+	PluginCount    int `json:"pluginCount"`
+	EnabledCount   int `json:"enabledCount"`
+	ActiveInstalls int `json:"activeInstalls"`
+	SSEClients     int `json:"sseClients"`
+	// VaultFileCount 最近一次磁盘占用统计时的存储库文件数，尚未统计过时为 null
+	VaultFileCount *int `json:"vaultFileCount"`
+	// Uptime 宿主启动以来的秒数
+	Uptime   int64 `json:"uptime"`
+	ReadOnly bool  `json:"readOnly"`
+}
+
+// status 汇总宿主当前状态
+func (h *PluginHost) status() HostStatus {
+	s := HostStatus{
+		ActiveInstalls: h.installManager.ActiveCount(),
+		SSEClients:     h.eventHub.clientCount(),
+		Uptime:         int64(time.Since(h.startedAt).Seconds()),
+		ReadOnly:       h.IsReadOnly(),
+	}
+
+	h.pluginsMu.RLock()
+	s.PluginCount = len(h.plugins)
+	for _, p := range h.plugins {
+		if p.Enabled {
+			s.EnabledCount++
+		}
+	}
+	h.pluginsMu.RUnlock()
+
+	h.usageMu.Lock()
+	if h.usage != nil {
+		count := h.usage.VaultFiles
+		s.VaultFileCount = &count
+	}
+	h.usageMu.Unlock()
+	return s
+}
@@ -0,0 +1,83 @@
+package host
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetStatus(t *testing.T) {
+	h := newTestHost(t, Config{})
+	for _, id := range []string{"a", "b", "c"} {
+		addTestPlugin(t, h, id)
+	}
+	if err := h.disablePlugin("c"); err != nil {
+		t.Fatal(err)
+	}
+	subscribeEvents(t, h)
+	subscribeEvents(t, h)
+	if err := h.installManager.StartInstallation("pending"); err != nil {
+		t.Fatal(err)
+	}
+	h.startedAt = time.Now().Add(-90 * time.Second)
+
+	_, resp := callRPC(t, h, "", "host.getStatus", nil)
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	status := resp.Result.(map[string]any)
+	want := map[string]any{
+		"pluginCount":    float64(3),
+		"enabledCount":   float64(2),
+		"activeInstalls": float64(1),
+		"sseClients":     float64(2),
+		"readOnly":       false,
+	}
+	for key, value := range want {
+		if status[key] != value {
+			t.Errorf("%s = %v, want %v", key, status[key], value)
+		}
+	}
+	if uptime := status["uptime"].(float64); uptime < 90 || uptime > 100 {
+		t.Errorf("uptime = %v, want about 90", uptime)
+	}
+	// 尚未统计磁盘占用时不遍历存储库
+	if v, ok := status["vaultFileCount"]; !ok || v != nil {
+		t.Errorf("vaultFileCount = %v, want null", v)
+	}
+}
+
+func TestStatusReflectsCachedUsage(t *testing.T) {
+	h := newTestHost(t, Config{})
+	writeSizedFile(t, filepath.Join(h.config.VaultDir, "a.md"), 1)
+	writeSizedFile(t, filepath.Join(h.config.VaultDir, "notes", "b.md"), 1)
+	if _, err := h.getDiskUsage(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 之后新增的文件不计入，使用缓存的计数
+	writeSizedFile(t, filepath.Join(h.config.VaultDir, "c.md"), 1)
+	h.SetReadOnly(true)
+	h.installManager.CompleteInstallation("none", nil)
+
+	s := h.status()
+	if s.VaultFileCount == nil || *s.VaultFileCount != 2 {
+		t.Fatalf("vaultFileCount = %v, want 2", s.VaultFileCount)
+	}
+	if !s.ReadOnly || s.PluginCount != 0 || s.ActiveInstalls != 0 {
+		t.Errorf("status = %+v", s)
+	}
+}
+
+func TestActiveInstallCount(t *testing.T) {
+	im := NewInstallationManager(3)
+	for _, id := range []string{"a", "b"} {
+		if err := im.StartInstallation(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	im.CompleteInstallation("a", nil)
+	if n := im.ActiveCount(); n != 1 {
+		t.Errorf("active installs = %d, want 1", n)
+	}
+}
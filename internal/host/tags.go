@@ -0,0 +1,73 @@
+package host
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	maxTags      = 10
+	maxTagLength = 32
+)
+
+// normalizeTags 将标签转为小写并去重，丢弃空标签和超长标签，最多保留 maxTags 个
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || len(t) > maxTagLength || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+		if len(out) == maxTags {
+			break
+		}
+	}
+	return out
+}
+
+// hasTag 判断标签列表中是否包含指定标签（忽略大小写）
+func hasTag(tags []string, tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// filterMarketByTag 返回带有指定标签的市场条目，tag 为空时原样返回
+func filterMarketByTag(items []MarketItem, tag string) []MarketItem {
+	if tag == "" {
+		return items
+	}
+	out := make([]MarketItem, 0, len(items))
+	for _, item := range items {
+		if hasTag(item.Tags, tag) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// marketTags 返回市场中出现过的所有标签，按字母排序
+func marketTags(items []MarketItem) []string {
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, item := range items {
+		for _, t := range item.Tags {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,73 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	long := strings.Repeat("x", maxTagLength+1)
+	got := normalizeTags([]string{"Git", " git ", "", long, "Sync"})
+	if want := []string{"git", "sync"}; !slices.Equal(got, want) {
+		t.Errorf("normalizeTags = %v, want %v", got, want)
+	}
+
+	many := make([]string, maxTags+5)
+	for i := range many {
+		many[i] = strings.Repeat("t", i+1)
+	}
+	if got := normalizeTags(many); len(got) != maxTags {
+		t.Errorf("got %d tags, want at most %d", len(got), maxTags)
+	}
+	if got := normalizeTags(nil); got != nil {
+		t.Errorf("normalizeTags(nil) = %v", got)
+	}
+}
+
+func TestMarketTagFilter(t *testing.T) {
+	index := serveMarketIndex(t, []MarketItem{
+		{ID: "a", Name: "A", Version: "1.0.0", Tags: []string{"Git", "sync"}},
+		{ID: "b", Name: "B", Version: "1.0.0", Tags: []string{"notes"}},
+		{ID: "c", Name: "C", Version: "1.0.0"},
+	})
+	h := newTestHost(t, Config{MarketIndex: index})
+
+	get := func(query string) []MarketItem {
+		w := httptest.NewRecorder()
+		h.handleMarket(w, httptest.NewRequest(http.MethodGet, "/market"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /market%s: got %d %s", query, w.Code, w.Body.String())
+		}
+		var items []MarketItem
+		if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+			t.Fatal(err)
+		}
+		return items
+	}
+	if items := get("?tag=GIT"); len(items) != 1 || items[0].ID != "a" || !slices.Equal(items[0].Tags, []string{"git", "sync"}) {
+		t.Errorf("tag=GIT returned %+v", items)
+	}
+	if items := get("?tag=unknown"); len(items) != 0 {
+		t.Errorf("tag=unknown returned %d items", len(items))
+	}
+	if items := get(""); len(items) != 3 {
+		t.Errorf("unfiltered returned %d items, want 3", len(items))
+	}
+
+	code, resp := callRPC(t, h, "", "host.getMarketTags", nil)
+	if code != 200 {
+		t.Fatalf("host.getMarketTags: got %d %+v", code, resp.Error)
+	}
+	data, _ := json.Marshal(resp.Result)
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"git", "notes", "sync"}; !slices.Equal(tags, want) {
+		t.Errorf("host.getMarketTags = %v, want %v", tags, want)
+	}
+}
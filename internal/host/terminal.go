@@ -0,0 +1,48 @@
+package host
+
+import "time"
+
+// terminalEventTTL 安装终态事件保留补发的时长
+const terminalEventTTL = 5 * time.Minute
+
+// terminalEvent 最近的安装终态事件
+type terminalEvent struct {
+	pluginID string
+	event    Event
+	at       time.Time
+}
+
+// broadcastTerminal 广播安装终态事件，并保留每个插件最近一次的终态供新连接补发
+func (h *PluginHost) broadcastTerminal(ev Event) {
+	pluginID := ""
+	if p, ok := ev.Data.(InstallProgress); ok {
+		pluginID = p.PluginID
+	}
+
+	h.terminalMu.Lock()
+	now := time.Now()
+	kept := h.terminal[:0]
+	for _, t := range h.terminal {
+		if t.pluginID != pluginID && now.Sub(t.at) < terminalEventTTL {
+			kept = append(kept, t)
+		}
+	}
+	h.terminal = append(kept, terminalEvent{pluginID: pluginID, event: ev, at: now})
+	h.terminalMu.Unlock()
+
+	h.Broadcast(ev)
+}
+
+// recentTerminalEvents 返回保留期内各插件最近一次的安装终态事件
+func (h *PluginHost) recentTerminalEvents() []Event {
+	h.terminalMu.Lock()
+	defer h.terminalMu.Unlock()
+	now := time.Now()
+	events := make([]Event, 0, len(h.terminal))
+	for _, t := range h.terminal {
+		if now.Sub(t.at) < terminalEventTTL {
+			events = append(events, t.event)
+		}
+	}
+	return events
+}
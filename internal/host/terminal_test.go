@@ -0,0 +1,74 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// connectSSE 连接事件流并在读取首批消息后断开，返回收到的事件
+func connectSSE(t *testing.T, h *PluginHost) []Event {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	h.handleSSE(w, httptest.NewRequest("GET", "/events", nil).WithContext(ctx))
+	var events []Event
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			t.Fatalf("decode event %q: %v", line, err)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestSubscriberAfterInstallReceivesTerminalStatus(t *testing.T) {
+	h := newTestHost(t, Config{})
+
+	if err := h.installPluginFromURL("good", serveManifest(t, Manifest{ID: "good", Name: "Good", Version: "1.0.0"}), "", "", nil); err != nil {
+		t.Fatalf("install good: %v", err)
+	}
+	bad := serveManifest(t, Manifest{ID: "bad", Name: "Bad", Version: "1.0.0", Engines: &Engines{SDK: ">=2.0.0"}})
+	if err := h.installPluginFromURL("bad", bad, "", "", nil); err == nil {
+		t.Fatal("install bad: expected error")
+	}
+
+	// 安装结束后才连接的客户端仍能收到每个插件的终态
+	terminal := map[string]Event{}
+	for _, ev := range connectSSE(t, h) {
+		data, _ := ev.Data.(map[string]any)
+		if data["terminal"] != true {
+			t.Errorf("replayed non-terminal event %s", ev.Type)
+			continue
+		}
+		terminal[data["pluginId"].(string)] = ev
+	}
+	if len(terminal) != 2 {
+		t.Fatalf("replayed terminal events for %d plugins, want 2", len(terminal))
+	}
+	if ev := terminal["good"]; ev.Type != "plugin.installation.done" || ev.Data.(map[string]any)["status"] != "completed" {
+		t.Errorf("good: %s %v", ev.Type, ev.Data)
+	}
+	if ev := terminal["bad"]; ev.Type != "plugin.installation.failed" || ev.Data.(map[string]any)["code"] != InstallErrIncompatible {
+		t.Errorf("bad: %s %v", ev.Type, ev.Data)
+	}
+}
+
+func TestTerminalStatusKeepsLatestPerPlugin(t *testing.T) {
+	h := newTestHost(t, Config{})
+
+	h.broadcastTerminal(Event{Type: "plugin.installation.failed", Data: InstallProgress{PluginID: "p", Status: "failed", Terminal: true}})
+	h.broadcastTerminal(Event{Type: "plugin.installation.done", Data: InstallProgress{PluginID: "p", Status: "completed", Terminal: true}})
+
+	events := connectSSE(t, h)
+	if len(events) != 1 || events[0].Type != "plugin.installation.done" {
+		t.Errorf("replayed %v, want only the latest terminal event", eventTypes(events))
+	}
+}
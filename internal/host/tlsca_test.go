@@ -0,0 +1,106 @@
+package host
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// serveManifestTLS 在自签名证书的TLS服务器上提供清单，返回下载地址和服务器CA证书（PEM）
+func serveManifestTLS(t *testing.T, m Manifest) (string, string) {
+	t.Helper()
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	return srv.URL + "/plugin.json", string(caPEM)
+}
+
+func TestInstallTLSCustomCA(t *testing.T) {
+	manifest := Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"}
+	url, caPEM := serveManifestTLS(t, manifest)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte(caPEM), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 默认只信任系统根证书，自签名证书被拒绝
+	h := newTestHost(t, Config{})
+	err := h.installPluginFromURL("demo", url, "", "", nil)
+	if got := installErrorCode(err); got != InstallErrDownload || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("untrusted certificate: code = %q (%v), want %s", got, err, InstallErrDownload)
+	}
+
+	for name, cfg := range map[string]Config{
+		"CACertPEM":             {CACertPEM: caPEM},
+		"CACertFile":            {CACertFile: caFile},
+		"InsecureSkipTLSVerify": {InsecureSkipTLSVerify: true},
+	} {
+		h := newTestHost(t, cfg)
+		if err := h.installPluginFromURL("demo", url, "", "", nil); err != nil {
+			t.Errorf("%s: install: %v", name, err)
+			continue
+		}
+		if _, ok := h.getPlugin("demo"); !ok {
+			t.Errorf("%s: plugin not registered", name)
+		}
+	}
+}
+
+func TestMarketIndexTLSCustomCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]MarketItem{{ID: "demo", Name: "Demo", Version: "1.0.0"}})
+	}))
+	t.Cleanup(srv.Close)
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	if _, err := newTestHost(t, Config{MarketIndex: srv.URL}).fetchMarketIndex(); err == nil {
+		t.Fatal("market index with an untrusted certificate fetched")
+	}
+	items, err := newTestHost(t, Config{MarketIndex: srv.URL, CACertPEM: caPEM}).fetchMarketIndex()
+	if err != nil || len(items) != 1 || items[0].ID != "demo" {
+		t.Fatalf("items = %+v, %v", items, err)
+	}
+}
+
+func TestOutboundTLSConfig(t *testing.T) {
+	if cfg, err := newTestHost(t, Config{}).outboundTLSConfig(); cfg != nil || err != nil {
+		t.Fatalf("default TLS config = %+v, %v, want nil", cfg, err)
+	}
+	cfg, err := newTestHost(t, Config{InsecureSkipTLSVerify: true}).outboundTLSConfig()
+	if err != nil || cfg == nil || !cfg.InsecureSkipVerify || cfg.RootCAs != nil {
+		t.Fatalf("insecure TLS config = %+v, %v", cfg, err)
+	}
+
+	noCerts := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(noCerts, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range map[string]Config{
+		"missing file": {CACertFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"empty file":   {CACertFile: noCerts},
+		"invalid PEM":  {CACertPEM: "not a certificate"},
+	} {
+		if _, err := newTestHost(t, c).outboundTLSConfig(); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+
+	// 证书配置错误时安装以下载失败结束
+	h := newTestHost(t, Config{CACertPEM: "not a certificate"})
+	url, _ := serveManifestTLS(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0"})
+	if got := installErrorCode(h.installPluginFromURL("demo", url, "", "", nil)); got != InstallErrDownload {
+		t.Errorf("install with an invalid CA: code = %q, want %s", got, InstallErrDownload)
+	}
+}
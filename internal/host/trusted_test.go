@@ -0,0 +1,65 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrustedPluginWritesVaultWithoutPermission(t *testing.T) {
+	h := newTestHost(t, Config{TrustedPlugins: []string{"first-party"}})
+	addTestPlugin(t, h, "first-party")
+	addTestPlugin(t, h, "third-party")
+
+	if code, _ := callRPC(t, h, "third-party", "vault.write", map[string]any{"path": "a.md", "content": "x"}); code != http.StatusForbidden {
+		t.Errorf("untrusted vault.write: status = %d, want 403", code)
+	}
+	code, resp := callRPC(t, h, "first-party", "vault.write", map[string]any{"path": "a.md", "content": "hello"})
+	if code != http.StatusOK {
+		t.Fatalf("trusted vault.write: got %d %+v", code, resp.Error)
+	}
+	if data, err := os.ReadFile(filepath.Join(h.config.VaultDir, "a.md")); err != nil || string(data) != "hello" {
+		t.Errorf("vault file = %q, %v", data, err)
+	}
+
+	// 受信任插件的操作照常写入审计日志，并带有 trusted 标记
+	entries, err := h.queryAudit("vault.write", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "plugin:first-party" || entries[0].Meta["trusted"] != true {
+		t.Errorf("audit entries = %+v", entries)
+	}
+}
+
+func TestGetPluginsMarksTrusted(t *testing.T) {
+	h := newTestHost(t, Config{TrustedPlugins: []string{"first-party"}})
+	addTestPlugin(t, h, "first-party")
+	addTestPlugin(t, h, "third-party")
+
+	code, resp := callRPC(t, h, "third-party", "host.getPlugins", nil)
+	if code != http.StatusOK {
+		t.Fatalf("host.getPlugins: got %d %+v", code, resp.Error)
+	}
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var infos []struct {
+		ID      string `json:"id"`
+		Trusted bool   `json:"trusted"`
+	}
+	if err := json.Unmarshal(data, &infos); err != nil {
+		t.Fatal(err)
+	}
+	trusted := map[string]bool{}
+	for _, p := range infos {
+		trusted[p.ID] = p.Trusted
+	}
+	if len(trusted) != 2 || !trusted["first-party"] || trusted["third-party"] {
+		t.Errorf("trusted = %v, want only first-party", trusted)
+	}
+}
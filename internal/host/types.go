@@ -1,37 +1,273 @@
 package host
 
+import (
+	"os"
+	"time"
+)
+
 type Config struct {
 	RootDir    string
 	PluginsDir string
 	VaultDir   string
     MarketIndex string
+	// VaultQuotaBytes 存储库总容量上限，0表示不限制
+	VaultQuotaBytes int64
+	// AdminToken 管理接口（如 /audit）使用的 Bearer 令牌，为空时禁用这些接口
+	AdminToken string
+	// Security 安装相关的安全配置，为 nil 时使用 DefaultSecurityConfig
+	Security *SecurityConfig
+	// PinnedKeys 插件ID到发布者公钥（base64编码的ed25519公钥）的映射，
+	// 设置后该插件的安装包必须带有可被此公钥验证的签名
+	PinnedKeys map[string]string
+	// PluginKeys 插件ID到访问凭据的映射。配置后RPC与 /vault/raw 以请求头
+	// X-Plugin-Key 确定插件身份，不再信任请求中声明的 pluginId
+	PluginKeys map[string]string
+	// VaultWritePolicy 存储库写入前调用的策略，为 nil 时允许所有写入
+	VaultWritePolicy VaultWritePolicy
+	// AllowedVaultExtensions 允许写入存储库的扩展名，如 ".md"，为空时不限制；导入同样适用
+	AllowedVaultExtensions []string
+	// BlockedVaultExtensions 禁止写入存储库的扩展名，优先于允许列表
+	BlockedVaultExtensions []string
+	// ProbeTimeout 插件后端健康探测的单次超时，0 表示使用默认值
+	ProbeTimeout time.Duration
+	// TempDir 插件下载的暂存目录，为空时使用系统临时目录
+	TempDir string
+	// AllowBackendProcesses 是否启动插件清单 entrypoints.process 声明的后端进程，默认关闭
+	AllowBackendProcesses bool
+	// ManifestNames 插件目录中接受的清单文件名，按顺序查找，.yaml/.yml 按 YAML 解析，
+	// 为空时使用 DefaultManifestNames
+	ManifestNames []string
+	// MaxManifestBytes 清单文件的最大字节数，0 表示使用 DefaultMaxManifestBytes
+	MaxManifestBytes int64
+	// MaxManifestDepth 清单中对象和数组的最大嵌套层数，0 表示使用 DefaultMaxManifestDepth
+	MaxManifestDepth int
+	// StagingDir 安装时组装插件的暂存目录，完成后原子地移入 PluginsDir，
+	// 须与 PluginsDir 位于同一文件系统且不在其中，为空时使用 RootDir/staging
+	StagingDir string
+	// BackupMode 备份模式，BackupModeFull（默认）或 BackupModeDiff
+	BackupMode string
+	// GitHubAPIURL 从 Git 源安装时使用的 GitHub API 地址，为空时使用 https://api.github.com
+	GitHubAPIURL string
+	// VerifyGitHubDigest 安装 GitHub 发布资源且未提供SHA256时，查询发布版本公布的摘要用于完整性校验
+	VerifyGitHubDigest bool
+	// Webhooks 接收事件通知的外部地址
+	Webhooks []WebhookConfig
+	// EventRetention 事件历史的保留时长，0 表示使用 DefaultEventRetention
+	EventRetention time.Duration
+	// SSEKeepAlive 事件流空闲时发送 ping 的间隔，0 表示使用默认的 15 秒，负数表示不发送
+	SSEKeepAlive time.Duration
+	// MaxSSEClients 同时连接 /events 的客户端上限，超出时返回 503 并带 Retry-After，0 表示不限制
+	MaxSSEClients int
+	// HTTPProxy 下载插件和拉取市场索引使用的代理地址，如 http://proxy:3128，
+	// 为空时按 HTTP_PROXY/HTTPS_PROXY/NO_PROXY 环境变量决定
+	HTTPProxy string
+	// InstallationStore 安装记录的持久化存储，为 nil 时保存到 RootDir/installations.json
+	InstallationStore InstallationStore
+	// CACertFile、CACertPEM 下载插件和拉取市场索引时在系统根证书之外信任的CA证书（PEM），
+	// 用于自签名的私有插件仓库或企业内部的TLS代理
+	CACertFile string
+	CACertPEM  string
+	// InsecureSkipTLSVerify 下载插件和拉取市场索引时不校验服务端证书，仅用于排查问题，启动时会记录警告
+	InsecureSkipTLSVerify bool
+	// EnableOnInstall 新安装的插件是否立即启用，为 nil 时启用；安装请求的 autoEnable 可覆盖
+	EnableOnInstall *bool
+	// TrustedPlugins 受信任的插件ID，这些插件无需在清单中声明即拥有全部权限，
+	// 其操作仍照常写入审计日志
+	TrustedPlugins []string
+	// DefaultPermissions 所有已安装插件无需在清单中声明即拥有的权限，如 ui.show、notifications.send
+	DefaultPermissions []string
+	// DisabledMethods 完全禁用的RPC方法，如 vault.write、host.batchUninstall，
+	// 无论调用方是否为管理员或拥有权限都返回 403
+	DisabledMethods []string
+	// RPCTimeout 单个RPC请求的默认超时，0 表示使用 DefaultRPCTimeout。
+	// 超时只限制响应时间，不中止已开始的处理；会修改数据的方法不受超时限制
+	RPCTimeout time.Duration
+	// RPCMethodTimeouts 按方法名覆盖RPC超时，如 {"vault.list": 5 * time.Minute}
+	RPCMethodTimeouts map[string]time.Duration
+	// SlowRPCThreshold 耗时超过该值的RPC请求写入日志，0 表示使用 DefaultSlowRPCThreshold
+	SlowRPCThreshold time.Duration
+	// MaxRPCRequestBytes 单个RPC请求体的最大字节数，0 表示使用 DefaultMaxRPCRequestBytes。
+	// vault.write 的内容也受此限制，更大的文件可通过 PUT /vault/raw 流式写入
+	MaxRPCRequestBytes int64
+	// MaxRPCBatchSize RPC参数中单个数组的最大元素数，0 表示使用 DefaultMaxRPCBatchSize
+	MaxRPCBatchSize int
+	// MaxRPCParamsDepth RPC参数的最大嵌套层数，0 表示使用 DefaultMaxRPCParamsDepth
+	MaxRPCParamsDepth int
+	// PluginRPCRateLimit 每个插件每分钟最多的RPC调用数，超出时返回 429，0 表示不限制
+	PluginRPCRateLimit int
+	// VaultStore 存储库的存储后端，为 nil 时使用以 VaultDir 为根目录的 FSVaultStore
+	VaultStore VaultStore
+	// FileMode 默认存储库写入文件的权限，0 表示 DefaultFileMode；配置了 VaultStore 时不生效
+	FileMode os.FileMode
+	// DirMode 默认存储库写入时创建目录的权限，0 表示 DefaultDirMode；配置了 VaultStore 时不生效
+	DirMode os.FileMode
+	// VaultWatchInterval 有 /vault/watch 订阅时轮询存储库变更的间隔，0 表示使用默认的 2 秒
+	VaultWatchInterval time.Duration
+	// ReadOnly 启动时进入只读模式，拒绝安装、存储库写入、启用/禁用等写操作，
+	// 运行时可通过 host.setReadOnly 切换
+	ReadOnly bool
+	// PreInstall 清单校验通过、写入插件目录之前同步调用，返回错误时中止安装（包括更新）
+	PreInstall PluginHook
+	// OnInstall 插件安装或更新完成后同步调用，返回的错误只记录日志
+	OnInstall PluginHook
+	// OnUninstall 插件卸载完成后同步调用，返回的错误只记录日志
+	OnUninstall PluginHook
 }
 
+// PluginHook 宿主在插件安装、卸载流程中调用的回调
+type PluginHook func(pluginID string, manifest Manifest) error
+
 type Manifest struct {
-	ID            string       `json:"id"`
-	Name          string       `json:"name"`
-	Version       string       `json:"version"`
-	MinAppVersion string       `json:"minAppVersion,omitempty"`
-	Author        string       `json:"author,omitempty"`
-	Description   string       `json:"description,omitempty"`
-	Entrypoints   *Entrypoints `json:"entrypoints,omitempty"`
-	Permissions   []string     `json:"permissions,omitempty"`
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	Version       string        `json:"version"`
+	MinAppVersion string        `json:"minAppVersion,omitempty"`
+	Author        string        `json:"author,omitempty"`
+	Description   string        `json:"description,omitempty"`
+	Entrypoints   *Entrypoints  `json:"entrypoints,omitempty"`
+	Permissions   []string      `json:"permissions,omitempty"`
+	ConfigSchema  []ConfigField `json:"configSchema,omitempty"`
+	Tags          []string      `json:"tags,omitempty"`
+	// Commands 插件加载或安装时自动注册的命令
+	Commands []ManifestCommand `json:"commands,omitempty"`
+	// Sandbox 声明后插件只能访问沙箱内的存储库路径
+	Sandbox *Sandbox `json:"sandbox,omitempty"`
+	// UpdateURL 自托管的版本清单地址，内容格式与市场索引中的单个条目相同，
+	// 检查更新时与市场索引一起比较
+	UpdateURL string `json:"updateUrl,omitempty"`
+	// RequiresFeatures 插件依赖的宿主特性（见 host.getInfo 返回的 features），
+	// 缺少任一特性时插件不能启用
+	RequiresFeatures []string `json:"requiresFeatures,omitempty"`
+	// Icon 插件包内图标文件的相对路径，通过 /plugins/<id>/_host/icon 访问，未声明时返回默认图标
+	Icon string `json:"icon,omitempty"`
+	// I18n 按语言标签提供的名称和描述，host.getPlugins 按 Accept-Language 选择，缺少时使用 Name/Description
+	I18n *ManifestI18n `json:"i18n,omitempty"`
+	// Engines 插件依赖的运行环境版本
+	Engines *Engines `json:"engines,omitempty"`
+}
+
+// Engines 清单中声明的运行环境版本范围，语法同 npm，如 ^1.2.0、>=1.0 <2
+type Engines struct {
+	// SDK 兼容的 JS SDK 版本范围（见 host.getInfo 返回的 sdkVersion），不满足时插件不能启用
+	SDK string `json:"sdk,omitempty"`
+}
+
+// ManifestI18n 清单中的本地化文本，键为语言标签，如 zh、zh-TW、ja
+type ManifestI18n struct {
+	Name        map[string]string `json:"name,omitempty"`
+	Description map[string]string `json:"description,omitempty"`
+}
+
+// Sandbox 插件的隔离策略
+type Sandbox struct {
+	// VaultRoot 插件可访问的存储库子目录，为空时为 plugins/<id>/data
+	VaultRoot string `json:"vaultRoot,omitempty"`
+}
+
+// ManifestCommand 清单中声明的命令
+type ManifestCommand struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Category string `json:"category,omitempty"`
+	Hotkey   string `json:"hotkey,omitempty"`
+	// InvokePermission 其他插件调用该命令需要的权限，为空时不限制
+	InvokePermission string `json:"invokePermission,omitempty"`
 }
 
 type Entrypoints struct {
 	Frontend string `json:"frontend,omitempty"`
 	Backend  string `json:"backend,omitempty"`
+	// Health 后端健康检查地址，可为相对 backend 的路径，默认为 /healthz
+	Health string `json:"health,omitempty"`
+	// Process 由宿主启动的后端进程，需开启 Config.AllowBackendProcesses
+	Process *BackendProcess `json:"process,omitempty"`
+}
+
+// BackendProcess 插件启用时由宿主启动、禁用或卸载时停止的后端进程
+type BackendProcess struct {
+	// Command 插件目录内可执行文件或脚本的相对路径
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	// Env 额外的环境变量，形如 KEY=VALUE
+	Env []string `json:"env,omitempty"`
 }
 
 type Plugin struct {
 	Manifest Manifest
 	Enabled  bool   `json:"enabled"`
 	BackupPath string `json:"backupPath,omitempty"`
+	// PendingPermissions 升级时新增、尚未通过 host.approvePermissions 批准的权限，批准前不生效
+	PendingPermissions []string `json:"pendingPermissions,omitempty"`
+}
+
+// BatchResult 批量操作中单个插件的处理结果
+type BatchResult struct {
+	PluginID string `json:"pluginId"`
+	Ok       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+}
+
+// InstallProgress 插件安装进度，用于 plugin.installation.progress、plugin.installation.failed
+// 和 plugin.installation.done 事件，字段与 gin 服务一致
+type InstallProgress struct {
+	PluginID string `json:"pluginId"`
+	// Status 下载、校验等阶段时同 Phase，结束时为 failed 或 completed
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Message  string `json:"message,omitempty"`
+	// Phase 当前或失败时所处的阶段：downloading、verifying、configuring
+	Phase string `json:"phase,omitempty"`
+	// BytesDownloaded 下载阶段已下载的字节数
+	BytesDownloaded int64 `json:"bytesDownloaded,omitempty"`
+	// TotalBytes 安装包总字节数，服务端未返回 Content-Length 时省略
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+	// BytesPerSecond 从开始下载到现在的平均速率
+	BytesPerSecond int64 `json:"bytesPerSecond,omitempty"`
+	// ETASeconds 按平均速率估算的剩余秒数，总字节数未知时省略
+	ETASeconds int64 `json:"etaSeconds,omitempty"`
+	// Code 失败时的安装错误码，见 InstallErr* 常量
+	Code string `json:"code,omitempty"`
+	// Error 失败时的原始错误
+	Error    string `json:"error,omitempty"`
+	Terminal bool   `json:"terminal"`
+	// RequiresApproval 升级新增了权限，需批准后才生效
+	RequiresApproval bool `json:"requiresApproval,omitempty"`
+}
+
+// HostInfo 宿主版本与能力信息，供客户端做特性检测
+type HostInfo struct {
+	Version     string     `json:"version"`
+	APIVersion  string     `json:"apiVersion"`
+	SDKVersion  string     `json:"sdkVersion"`
+	Methods     []string   `json:"methods"`
+	Permissions []string   `json:"permissions"`
+	Features    []string   `json:"features"`
+	ReadOnly    bool       `json:"readOnly"`
+	Limits      HostLimits `json:"limits"`
+}
+
+// HostLimits 宿主对插件和请求的大小限制
+type HostLimits struct {
+	MaxPluginSize   int64 `json:"maxPluginSize"`
+	MaxRequestBytes int64 `json:"maxRequestBytes"`
+	MaxBatchSize    int   `json:"maxBatchSize"`
+	MaxParamsDepth  int   `json:"maxParamsDepth"`
 }
 
 type Command struct {
 	ID       string `json:"id"`
 	Title    string `json:"title"`
 	PluginID string `json:"pluginId"`
+	Category string `json:"category,omitempty"`
+	Hotkey   string `json:"hotkey,omitempty"`
+	// Source 命令来源：manifest 为清单声明，runtime 为运行时注册
+	Source string `json:"source"`
+	// InvokePermission 其他插件调用该命令需要的权限，为空时任何调用方都可调用
+	InvokePermission string `json:"invokePermission,omitempty"`
 }
 
+// 命令来源
+const (
+	CommandSourceManifest = "manifest"
+	CommandSourceRuntime  = "runtime"
+)
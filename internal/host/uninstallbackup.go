@@ -0,0 +1,51 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidBackupDir 卸载时指定的备份目录不可用
+var ErrInvalidBackupDir = errors.New("invalid backup directory")
+
+// UninstallOptions 卸载选项，零值表示卸载前备份到 RootDir/backups
+type UninstallOptions struct {
+	// SkipBackup 为 true 时卸载前不备份，适用于临时的开发插件
+	SkipBackup bool `json:"skipBackup,omitempty"`
+	// BackupDir 备份文件所在目录，相对路径相对于 RootDir，不能位于 PluginsDir 内
+	BackupDir string `json:"backupDir,omitempty"`
+}
+
+// uninstallBackupDir 返回卸载时备份文件所在的目录，未指定 BackupDir 时为 backupDir()
+func (h *PluginHost) uninstallBackupDir(opts UninstallOptions) (string, error) {
+	if opts.BackupDir == "" {
+		return h.backupDir(), nil
+	}
+	dir := opts.BackupDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(h.config.RootDir, dir)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidBackupDir, err)
+	}
+	pluginsDir, err := filepath.Abs(h.config.PluginsDir)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidBackupDir, err)
+	}
+	// 备份放在插件目录内会在下次加载时被当作插件
+	if rel, err := filepath.Rel(pluginsDir, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is inside the plugins directory", ErrInvalidBackupDir, opts.BackupDir)
+	}
+	return dir, nil
+}
+
+// auditMeta 返回卸载审计记录的附加信息，使用默认选项时为 nil
+func (opts UninstallOptions) auditMeta() map[string]any {
+	if opts == (UninstallOptions{}) {
+		return nil
+	}
+	return map[string]any{"skipBackup": opts.SkipBackup, "backupDir": opts.BackupDir}
+}
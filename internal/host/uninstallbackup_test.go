@@ -0,0 +1,161 @@
+package host
+
+import (
+	"archive/zip"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// uninstalledBackupPath 卸载插件并返回 plugin.uninstalled 事件中的备份路径
+func uninstalledBackupPath(t *testing.T, h *PluginHost, id string, opts UninstallOptions) string {
+	t.Helper()
+	sub := subscribeEvents(t, h)
+	if err := h.uninstallPlugin(id, opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, ev := range receivedEvents(t, sub) {
+		if ev.Type == "plugin.uninstalled" {
+			return ev.Data.(map[string]any)["backupPath"].(string)
+		}
+	}
+	t.Fatal("no plugin.uninstalled event")
+	return ""
+}
+
+// assertBackupHasManifest 校验备份 zip 位于 dir 中且包含插件清单
+func assertBackupHasManifest(t *testing.T, path, dir string) {
+	t.Helper()
+	if filepath.Dir(path) != dir {
+		t.Fatalf("backup %s not in %s", path, dir)
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, "manifest.json") {
+			return
+		}
+	}
+	t.Fatalf("backup %s has no manifest.json", path)
+}
+
+func TestUninstallBacksUpByDefault(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo")
+
+	path := uninstalledBackupPath(t, h, "demo", UninstallOptions{})
+	assertBackupHasManifest(t, path, h.backupDir())
+	if _, err := os.Stat(filepath.Join(h.config.PluginsDir, "demo")); !os.IsNotExist(err) {
+		t.Fatalf("plugin dir kept: %v", err)
+	}
+}
+
+func TestUninstallSkipBackup(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo")
+
+	if path := uninstalledBackupPath(t, h, "demo", UninstallOptions{SkipBackup: true}); path != "" {
+		t.Fatalf("backupPath = %q, want none", path)
+	}
+	if entries, _ := os.ReadDir(h.backupDir()); len(entries) != 0 {
+		t.Fatalf("backups written: %v", entries)
+	}
+	if _, ok := h.getPlugin("demo"); ok {
+		t.Fatal("plugin still installed")
+	}
+}
+
+func TestUninstallCustomBackupDir(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "a")
+	addTestPlugin(t, h, "b")
+
+	// 相对路径相对于 RootDir
+	path := uninstalledBackupPath(t, h, "a", UninstallOptions{BackupDir: "archive"})
+	assertBackupHasManifest(t, path, filepath.Join(h.config.RootDir, "archive"))
+
+	abs := filepath.Join(t.TempDir(), "elsewhere")
+	path = uninstalledBackupPath(t, h, "b", UninstallOptions{BackupDir: abs})
+	assertBackupHasManifest(t, path, abs)
+}
+
+func TestUninstallRejectsBackupDirInsidePlugins(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo")
+
+	for _, dir := range []string{h.config.PluginsDir, filepath.Join(h.config.PluginsDir, "backups"), "plugins/x"} {
+		if err := h.uninstallPlugin("demo", UninstallOptions{BackupDir: dir}); !errors.Is(err, ErrInvalidBackupDir) {
+			t.Errorf("backupDir %s: %v", dir, err)
+		}
+	}
+	if _, ok := h.getPlugin("demo"); !ok {
+		t.Fatal("plugin removed although the backup dir was rejected")
+	}
+	// 与插件目录同名前缀的兄弟目录可以使用
+	if _, err := h.uninstallBackupDir(UninstallOptions{BackupDir: h.config.PluginsDir + "-backups"}); err != nil {
+		t.Errorf("sibling dir rejected: %v", err)
+	}
+}
+
+func TestBatchUninstallBackupOptions(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "a")
+	addTestPlugin(t, h, "b")
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	// 指定备份目录需要管理员令牌
+	_, resp := callRPC(t, h, "", "host.batchUninstall", map[string]any{"pluginIds": []string{"a"}, "backupDir": "archive"})
+	if resp.Error == nil || resp.Error.Code != 403 {
+		t.Fatalf("backupDir without admin: %+v", resp.Error)
+	}
+	_, resp = callRPCWithHeader(t, h, admin, "", "host.batchUninstall", map[string]any{"pluginIds": []string{"a"}, "backupDir": "plugins"})
+	if resp.Error == nil || resp.Error.Code != 400 {
+		t.Fatalf("backupDir inside plugins: %+v", resp.Error)
+	}
+	if _, ok := h.getPlugin("a"); !ok {
+		t.Fatal("a uninstalled by a rejected request")
+	}
+
+	_, resp = callRPCWithHeader(t, h, admin, "", "host.batchUninstall", map[string]any{"pluginIds": []string{"a", "b"}, "backupDir": "archive"})
+	if resp.Error != nil {
+		t.Fatal(resp.Error)
+	}
+	entries, err := os.ReadDir(filepath.Join(h.config.RootDir, "archive"))
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("archive = %v, %v", entries, err)
+	}
+	logs, _ := h.queryAudit("plugin.uninstall", time.Time{}, time.Time{})
+	if len(logs) != 2 {
+		t.Fatalf("audit = %+v", logs)
+	}
+	for _, log := range logs {
+		if log.Meta["backupDir"] != "archive" || log.Meta["skipBackup"] != false {
+			t.Errorf("audit meta = %v", log.Meta)
+		}
+	}
+}
+
+func TestMarketDeleteBackupOptions(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "demo")
+
+	if w := serveMarket(h, http.MethodDelete, "/market?id=demo&backupDir=archive", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("backupDir without admin: %d %s", w.Code, w.Body.String())
+	}
+	if _, ok := h.getPlugin("demo"); !ok {
+		t.Fatal("plugin removed by a forbidden request")
+	}
+	if w := serveMarket(h, http.MethodDelete, "/market?id=demo&skipBackup=1", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("skipBackup: %d %s", w.Code, w.Body.String())
+	}
+	if entries, _ := os.ReadDir(h.backupDir()); len(entries) != 0 {
+		t.Fatalf("backup written despite skipBackup: %v", entries)
+	}
+}
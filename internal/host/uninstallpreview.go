@@ -0,0 +1,52 @@
+package host
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// UninstallPreview 卸载插件前的预览，列出卸载会删除的内容，不做任何修改
+type UninstallPreview struct {
+	PluginID string `json:"pluginId"`
+	// DirectorySize 插件目录的大小（字节）
+	DirectorySize int64 `json:"directorySize"`
+	// CommandCount 将被移除的命令数
+	CommandCount int `json:"commandCount"`
+	// BackupWillBeCreated 卸载前是否会先备份插件目录
+	BackupWillBeCreated bool `json:"backupWillBeCreated"`
+	// SettingsSize 插件设置文件的大小，卸载后保留，重新安装时仍然生效
+	SettingsSize int64 `json:"settingsSize"`
+}
+
+// previewUninstall 统计卸载插件会删除的内容
+func (h *PluginHost) previewUninstall(pluginID string) (*UninstallPreview, error) {
+	if _, ok := h.getPlugin(pluginID); !ok {
+		return nil, fmt.Errorf("plugin not found: %s", pluginID)
+	}
+
+	dir := filepath.Join(h.config.PluginsDir, pluginID)
+	size, err := dirSize(dir)
+	if err != nil {
+		return nil, err
+	}
+	preview := &UninstallPreview{PluginID: pluginID, DirectorySize: size}
+
+	// 插件目录存在时卸载流程会先备份
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		preview.BackupWillBeCreated = true
+	}
+
+	h.commandsMu.RLock()
+	for _, c := range h.commands {
+		if c.PluginID == pluginID {
+			preview.CommandCount++
+		}
+	}
+	h.commandsMu.RUnlock()
+
+	if info, err := os.Stat(h.settingsPath(pluginID)); err == nil {
+		preview.SettingsSize = info.Size()
+	}
+	return preview, nil
+}
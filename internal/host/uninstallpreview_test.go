@@ -0,0 +1,93 @@
+package host
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreviewUninstall(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "demo")
+	dir := filepath.Join(h.config.PluginsDir, "demo")
+	writePluginFixture(t, dir, map[string]string{
+		"main.js":         "console.log(1)",
+		"assets/logo.svg": "<svg/>",
+	})
+	h.pluginsMu.Lock()
+	m := h.plugins["demo"].Manifest
+	h.pluginsMu.Unlock()
+	m.Commands = []ManifestCommand{{ID: "demo.run", Title: "Run"}, {ID: "demo.stop", Title: "Stop"}}
+	h.syncManifestCommands(m)
+	if err := os.MkdirAll(filepath.Dir(h.settingsPath("demo")), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(h.settingsPath("demo"), []byte(`{"color":"blue"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wantSize, err := dirSize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+	code, resp := callRPCWithHeader(t, h, admin, "", "host.previewUninstall", map[string]any{"pluginId": "demo"})
+	if code != http.StatusOK {
+		t.Fatalf("host.previewUninstall: got %d %+v", code, resp.Error)
+	}
+	preview := resp.Result.(map[string]any)
+	want := map[string]any{
+		"pluginId":            "demo",
+		"directorySize":       float64(wantSize),
+		"commandCount":        float64(2),
+		"backupWillBeCreated": true,
+		"settingsSize":        float64(len(`{"color":"blue"}`)),
+	}
+	for key, v := range want {
+		if preview[key] != v {
+			t.Errorf("%s = %v, want %v", key, preview[key], v)
+		}
+	}
+	if wantSize <= int64(len("console.log(1)")+len("<svg/>")) {
+		t.Errorf("directory size %d does not include the manifest", wantSize)
+	}
+
+	// 预览不删除任何内容
+	for _, name := range []string{"manifest.json", "main.js", "assets/logo.svg"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s removed by preview: %v", name, err)
+		}
+	}
+	if _, ok := h.getPlugin("demo"); !ok {
+		t.Error("plugin unregistered by preview")
+	}
+	if _, err := os.Stat(h.settingsPath("demo")); err != nil {
+		t.Errorf("settings removed by preview: %v", err)
+	}
+}
+
+func TestPreviewUninstallErrors(t *testing.T) {
+	h := newTestHost(t, Config{AdminToken: "secret"})
+	addTestPlugin(t, h, "demo")
+	admin := http.Header{"Authorization": {"Bearer secret"}}
+
+	if code, _ := callRPC(t, h, "demo", "host.previewUninstall", map[string]any{"pluginId": "demo"}); code != http.StatusForbidden {
+		t.Errorf("non-admin preview: status = %d, want 403", code)
+	}
+	if code, _ := callRPCWithHeader(t, h, admin, "", "host.previewUninstall", map[string]any{"pluginId": "missing"}); code != http.StatusNotFound {
+		t.Errorf("preview of unknown plugin: status = %d, want 404", code)
+	}
+
+	// 插件目录已不存在时不会备份
+	if err := os.RemoveAll(filepath.Join(h.config.PluginsDir, "demo")); err != nil {
+		t.Fatal(err)
+	}
+	preview, err := h.previewUninstall("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.BackupWillBeCreated || preview.DirectorySize != 0 || preview.CommandCount != 0 || preview.SettingsSize != 0 {
+		t.Errorf("preview without a directory = %+v", preview)
+	}
+}
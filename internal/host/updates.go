@@ -0,0 +1,206 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxVersionManifestBytes 自托管版本清单的大小上限
+const maxVersionManifestBytes = 64 << 10
+
+// PluginUpdate 已安装插件在市场中的可用更新
+type PluginUpdate struct {
+	PluginID string `json:"pluginId"`
+	Current  string `json:"current"`
+	Latest   string `json:"latest"`
+	// RequiresApproval 新版本增加了权限，需通过 host.approvePermissions 批准后生效
+	RequiresApproval bool `json:"requiresApproval,omitempty"`
+}
+
+// StartUpdateChecker 按固定间隔比较已安装插件与市场索引中的版本，直到 ctx 结束
+func (h *PluginHost) StartUpdateChecker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := h.checkUpdates(); err != nil {
+				log.Printf("update check failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// latestMarketVersions 返回市场索引中每个插件的最新版本
+func (h *PluginHost) latestMarketVersions() (map[string]MarketItem, error) {
+	items, err := h.fetchMarketIndex()
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]MarketItem, len(items))
+	for _, item := range items {
+		if cur, ok := latest[item.ID]; !ok || compareVersions(item.Version, cur.Version) > 0 {
+			latest[item.ID] = item
+		}
+	}
+	return latest, nil
+}
+
+// fetchVersionManifest 读取插件清单 updateUrl 指向的版本清单，地址须通过下载地址校验
+func (h *PluginHost) fetchVersionManifest(pluginID, updateURL string) (*MarketItem, error) {
+	validator := NewPluginValidator(h.securityConfig())
+	if verr := validator.validateDownloadURL(updateURL); verr != nil {
+		return nil, verr
+	}
+	transport, err := h.outboundTransport()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newDownloadClient(validator, transport).Get(updateURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var item MarketItem
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxVersionManifestBytes)).Decode(&item); err != nil {
+		return nil, fmt.Errorf("invalid version manifest: %w", err)
+	}
+	if item.ID != pluginID {
+		return nil, fmt.Errorf("version manifest id %q does not match %s", item.ID, pluginID)
+	}
+	return &item, nil
+}
+
+// mergeSelfHostedVersion 用插件自托管的版本清单更新 latest，版本更新时替换市场中的条目；
+// 读取失败只记录日志
+func (h *PluginHost) mergeSelfHostedVersion(latest map[string]MarketItem, pluginID, updateURL string) {
+	if updateURL == "" {
+		return
+	}
+	item, err := h.fetchVersionManifest(pluginID, updateURL)
+	if err != nil {
+		log.Printf("update check for %s via %s failed: %v", pluginID, updateURL, err)
+		return
+	}
+	if cur, ok := latest[pluginID]; !ok || compareVersions(item.Version, cur.Version) > 0 {
+		latest[pluginID] = *item
+	}
+}
+
+// checkUpdates 拉取市场索引和插件自托管的版本清单并刷新可用更新列表，
+// 新发现的更新会广播 plugin.update.available
+func (h *PluginHost) checkUpdates() ([]PluginUpdate, error) {
+	latest, err := h.latestMarketVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	h.pluginsMu.RLock()
+	selfHosted := make(map[string]string)
+	for id, p := range h.plugins {
+		if p.Manifest.UpdateURL != "" {
+			selfHosted[id] = p.Manifest.UpdateURL
+		}
+	}
+	h.pluginsMu.RUnlock()
+	for id, u := range selfHosted {
+		h.mergeSelfHostedVersion(latest, id, u)
+	}
+
+	h.pluginsMu.RLock()
+	updates := []PluginUpdate{}
+	for id, p := range h.plugins {
+		item, ok := latest[id]
+		if !ok || compareVersions(item.Version, p.Manifest.Version) <= 0 {
+			continue
+		}
+		updates = append(updates, PluginUpdate{PluginID: id, Current: p.Manifest.Version, Latest: item.Version})
+	}
+	h.pluginsMu.RUnlock()
+
+	h.updatesMu.Lock()
+	previous := h.updates
+	h.updates = updates
+	h.updatesMu.Unlock()
+
+	notified := make(map[string]string, len(previous))
+	for _, u := range previous {
+		notified[u.PluginID] = u.Latest
+	}
+	for _, u := range updates {
+		if notified[u.PluginID] == u.Latest {
+			continue
+		}
+		h.Broadcast(Event{Type: "plugin.update.available", Data: u})
+	}
+	return updates, nil
+}
+
+// getUpdates 返回最近一次检查得到的可用更新，尚未检查过时立即检查
+func (h *PluginHost) getUpdates() ([]PluginUpdate, error) {
+	h.updatesMu.RLock()
+	updates := h.updates
+	h.updatesMu.RUnlock()
+	if updates != nil {
+		return updates, nil
+	}
+	return h.checkUpdates()
+}
+
+// updatePlugin 使用市场或自托管版本清单中的最新版本通过常规安装流程升级插件，保留启用状态
+func (h *PluginHost) updatePlugin(pluginID string) (*PluginUpdate, error) {
+	unlock := h.pluginLocks.Lock(pluginID)
+	defer unlock()
+
+	p, ok := h.getPlugin(pluginID)
+	if !ok {
+		return nil, fmt.Errorf("plugin not found: %s", pluginID)
+	}
+	h.pluginsMu.RLock()
+	current, updateURL := p.Manifest.Version, p.Manifest.UpdateURL
+	h.pluginsMu.RUnlock()
+
+	latest, err := h.latestMarketVersions()
+	if err != nil {
+		return nil, err
+	}
+	h.mergeSelfHostedVersion(latest, pluginID, updateURL)
+	target, ok := latest[pluginID]
+	if !ok || compareVersions(target.Version, current) <= 0 {
+		return nil, fmt.Errorf("no update available for %s", pluginID)
+	}
+
+	if err := h.installPluginLocked(pluginID, target.URL, target.SHA256, target.Signature, nil); err != nil {
+		return nil, err
+	}
+
+	h.updatesMu.Lock()
+	for i, u := range h.updates {
+		if u.PluginID == pluginID {
+			h.updates = append(h.updates[:i:i], h.updates[i+1:]...)
+			break
+		}
+	}
+	h.updatesMu.Unlock()
+
+	update := &PluginUpdate{PluginID: pluginID, Current: current, Latest: target.Version}
+	if p, ok := h.getPlugin(pluginID); ok {
+		h.pluginsMu.RLock()
+		update.RequiresApproval = len(p.PendingPermissions) > 0
+		h.pluginsMu.RUnlock()
+	}
+	h.Broadcast(Event{Type: "plugin.updated", Data: update})
+	return update, nil
+}
@@ -0,0 +1,97 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// serveMarketIndex 在本地服务器上提供市场索引，返回索引地址
+func serveMarketIndex(t *testing.T, items []MarketItem) string {
+	t.Helper()
+	data, err := json.Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/index.json"
+}
+
+func TestCheckUpdatesAndUpdatePlugin(t *testing.T) {
+	v19 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.9.0"})
+	v110 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.10.0"})
+	index := serveMarketIndex(t, []MarketItem{
+		{ID: "demo", Name: "Demo", Version: "1.10.0", URL: v110},
+		{ID: "demo", Name: "Demo", Version: "1.2.0", URL: v19},
+		{ID: "uninstalled", Name: "Other", Version: "3.0.0"},
+	})
+	h := newTestHost(t, Config{MarketIndex: index})
+	if err := h.installPluginFromURL("demo", v19, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	events := subscribeEvents(t, h)
+
+	updates, err := h.checkUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PluginUpdate{PluginID: "demo", Current: "1.9.0", Latest: "1.10.0"}
+	if len(updates) != 1 || updates[0] != want {
+		t.Fatalf("updates = %+v, want [%+v]", updates, want)
+	}
+	if types := eventTypes(receivedEvents(t, events)); !slices.Equal(types, []string{"plugin.update.available"}) {
+		t.Fatalf("events = %v, want one plugin.update.available", types)
+	}
+	// 同一版本的更新只通知一次
+	if _, err := h.checkUpdates(); err != nil {
+		t.Fatal(err)
+	}
+	if types := eventTypes(receivedEvents(t, events)); slices.Contains(types, "plugin.update.available") {
+		t.Errorf("repeated check re-announced the update: %v", types)
+	}
+
+	update, err := h.updatePlugin("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Current != "1.9.0" || update.Latest != "1.10.0" {
+		t.Errorf("update = %+v", update)
+	}
+	if p, _ := h.getPlugin("demo"); p.Manifest.Version != "1.10.0" || !p.Enabled {
+		t.Errorf("plugin after update = %+v", p)
+	}
+	if updates, _ := h.getUpdates(); len(updates) != 0 {
+		t.Errorf("applied update still listed: %+v", updates)
+	}
+	if !slices.Contains(eventTypes(receivedEvents(t, events)), "plugin.updated") {
+		t.Error("update should broadcast plugin.updated")
+	}
+	if _, err := h.updatePlugin("demo"); err == nil {
+		t.Error("updating to the same version should fail")
+	}
+}
+
+func TestGetUpdatesRPC(t *testing.T) {
+	index := serveMarketIndex(t, []MarketItem{{ID: "demo", Name: "Demo", Version: "2.0.0"}})
+	h := newTestHost(t, Config{MarketIndex: index})
+	addTestPlugin(t, h, "demo")
+
+	code, resp := callRPC(t, h, "demo", "host.getUpdates", nil)
+	if code != 200 {
+		t.Fatalf("host.getUpdates: got %d %+v", code, resp.Error)
+	}
+	data, _ := json.Marshal(resp.Result)
+	var updates []PluginUpdate
+	if err := json.Unmarshal(data, &updates); err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].Latest != "2.0.0" || updates[0].Current != "1.0.0" {
+		t.Errorf("updates = %+v", updates)
+	}
+}
@@ -0,0 +1,99 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// serveVersionManifest 在本地服务器上提供自托管的版本清单，返回清单地址
+func serveVersionManifest(t *testing.T, item MarketItem) string {
+	t.Helper()
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/version.json"
+}
+
+func TestCheckUpdatesConsultsUpdateURL(t *testing.T) {
+	v2 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "2.0.0"})
+	updateURL := serveVersionManifest(t, MarketItem{ID: "demo", Name: "Demo", Version: "2.0.0", URL: v2})
+	// 市场索引中只有旧版本
+	index := serveMarketIndex(t, []MarketItem{{ID: "demo", Name: "Demo", Version: "1.1.0"}})
+	h := newTestHost(t, Config{MarketIndex: index})
+	v1 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", UpdateURL: updateURL})
+	if err := h.installPluginFromURL("demo", v1, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	events := subscribeEvents(t, h)
+
+	updates, err := h.checkUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PluginUpdate{PluginID: "demo", Current: "1.0.0", Latest: "2.0.0"}
+	if len(updates) != 1 || updates[0] != want {
+		t.Fatalf("updates = %+v, want [%+v]", updates, want)
+	}
+	if types := eventTypes(receivedEvents(t, events)); !slices.Equal(types, []string{"plugin.update.available"}) {
+		t.Fatalf("events = %v, want one plugin.update.available", types)
+	}
+
+	if _, err := h.updatePlugin("demo"); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := h.getPlugin("demo"); p.Manifest.Version != "2.0.0" {
+		t.Errorf("version after update = %s, want 2.0.0", p.Manifest.Version)
+	}
+}
+
+func TestCheckUpdatesIgnoresMismatchedVersionManifest(t *testing.T) {
+	updateURL := serveVersionManifest(t, MarketItem{ID: "other", Name: "Other", Version: "9.0.0"})
+	h := newTestHost(t, Config{MarketIndex: serveMarketIndex(t, nil)})
+	v1 := serveManifest(t, Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", UpdateURL: updateURL})
+	if err := h.installPluginFromURL("demo", v1, "", "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	updates, err := h.checkUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 0 {
+		t.Errorf("version manifest for another plugin produced updates: %+v", updates)
+	}
+}
+
+func TestValidateManifestUpdateURL(t *testing.T) {
+	v := NewPluginValidator(DefaultSecurityConfig())
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"", true},
+		{"https://github.com/acme/demo/releases/latest/download/version.json", true},
+		{"http://github.com/acme/demo/version.json", false},
+		{"https://evil.example.com/version.json", false},
+	}
+	for _, tc := range cases {
+		m := &Manifest{ID: "demo", Name: "Demo", Version: "1.0.0", UpdateURL: tc.url}
+		result := v.ValidateManifest(m)
+		var rejected bool
+		for _, e := range result.Errors {
+			if e.Field == "manifest.updateUrl" && e.Code == "INVALID_UPDATE_URL" {
+				rejected = true
+			}
+		}
+		if rejected == tc.want {
+			t.Errorf("%q: rejected = %v, want valid = %v (errors %+v)", tc.url, rejected, tc.want, result.Errors)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package host
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// diskUsageTTL 磁盘占用统计的缓存时间，避免频繁遍历目录
+const diskUsageTTL = 30 * time.Second
+
+// DiskUsage 插件、存储库和备份的磁盘占用（字节）
+type DiskUsage struct {
+	Plugins      map[string]int64 `json:"plugins"`
+	PluginsTotal int64            `json:"pluginsTotal"`
+	Vault        int64            `json:"vault"`
+	VaultFiles   int              `json:"vaultFiles"`
+	Backups      int64            `json:"backups"`
+	Total        int64            `json:"total"`
+	GeneratedAt  time.Time        `json:"generatedAt"`
+}
+
+// getDiskUsage 返回磁盘占用统计，在缓存有效期内复用上次结果，ctx 取消时中止遍历存储库
+func (h *PluginHost) getDiskUsage(ctx context.Context) (*DiskUsage, error) {
+	h.usageMu.Lock()
+	defer h.usageMu.Unlock()
+
+	if h.usage != nil && time.Since(h.usage.GeneratedAt) < diskUsageTTL {
+		return h.usage, nil
+	}
+
+	h.pluginsMu.RLock()
+	ids := make([]string, 0, len(h.plugins))
+	for id := range h.plugins {
+		ids = append(ids, id)
+	}
+	h.pluginsMu.RUnlock()
+
+	usage := &DiskUsage{Plugins: make(map[string]int64, len(ids))}
+	for _, id := range ids {
+		size, err := dirSize(filepath.Join(h.config.PluginsDir, id))
+		if err != nil {
+			return nil, err
+		}
+		usage.Plugins[id] = size
+		usage.PluginsTotal += size
+	}
+
+	var err error
+	if usage.Vault, err = h.vaultUsage(ctx); err != nil {
+		return nil, err
+	}
+	paths, err := h.vault.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage.VaultFiles = len(paths)
+	if usage.Backups, err = dirSize(h.backupDir()); err != nil {
+		return nil, err
+	}
+	usage.Total = usage.PluginsTotal + usage.Vault + usage.Backups
+	usage.GeneratedAt = time.Now()
+
+	h.usage = usage
+	return usage, nil
+}
+
+// dirSize 计算目录下所有常规文件的大小之和，目录不存在时返回0
+func dirSize(root string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return total, err
+}
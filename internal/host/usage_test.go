@@ -0,0 +1,65 @@
+package host
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSizedFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetDiskUsage(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "demo")
+	manifest, err := os.Stat(filepath.Join(h.config.PluginsDir, "demo", "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSizedFile(t, filepath.Join(h.config.PluginsDir, "demo", "assets", "app.js"), 1000)
+	writeSizedFile(t, filepath.Join(h.config.VaultDir, "a.md"), 10)
+	writeSizedFile(t, filepath.Join(h.config.VaultDir, "notes", "b.md"), 20)
+	writeSizedFile(t, filepath.Join(h.backupDir(), "demo.zip"), 7)
+
+	usage, err := h.getDiskUsage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPlugin := manifest.Size() + 1000
+	if usage.Plugins["demo"] != wantPlugin || usage.PluginsTotal != wantPlugin {
+		t.Errorf("plugin usage = %d (total %d), want %d", usage.Plugins["demo"], usage.PluginsTotal, wantPlugin)
+	}
+	if usage.Vault != 30 || usage.VaultFiles != 2 {
+		t.Errorf("vault usage = %d bytes in %d files, want 30 in 2", usage.Vault, usage.VaultFiles)
+	}
+	if usage.Backups != 7 {
+		t.Errorf("backups = %d, want 7", usage.Backups)
+	}
+	if usage.Total != wantPlugin+30+7 {
+		t.Errorf("total = %d, want %d", usage.Total, wantPlugin+37)
+	}
+
+	// 缓存有效期内不重新遍历
+	writeSizedFile(t, filepath.Join(h.config.VaultDir, "c.md"), 5)
+	again, err := h.getDiskUsage(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != usage {
+		t.Error("second call within the TTL should reuse the cached report")
+	}
+}
+
+func TestDirSizeMissingDir(t *testing.T) {
+	if size, err := dirSize(filepath.Join(t.TempDir(), "missing")); err != nil || size != 0 {
+		t.Fatalf("dirSize(missing) = %d, %v", size, err)
+	}
+}
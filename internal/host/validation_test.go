@@ -0,0 +1,66 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// postMarketInstall 向 /market 提交安装请求，返回状态码和解码后的校验错误
+func postMarketInstall(t *testing.T, h *PluginHost, body string) (int, []ValidationError) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.handleMarket(w, httptest.NewRequest(http.MethodPost, "/market", strings.NewReader(body)))
+	var resp struct {
+		Errors []ValidationError `json:"errors"`
+	}
+	if w.Code >= http.StatusBadRequest {
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("status %d: Content-Type = %q, body %q", w.Code, ct, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode %q: %v", w.Body.String(), err)
+		}
+	}
+	return w.Code, resp.Errors
+}
+
+func TestMarketInstallStructuredValidationErrors(t *testing.T) {
+	h := newTestHost(t, Config{})
+
+	code, errs := postMarketInstall(t, h, `{"id":"bad id!","url":"ftp://github.com/p.zip"}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", code)
+	}
+	byField := map[string]string{}
+	for _, e := range errs {
+		if e.Message == "" {
+			t.Errorf("%s: empty message", e.Field)
+		}
+		byField[e.Field] = e.Code
+	}
+	if byField["id"] != "INVALID_ID_FORMAT" || byField["url"] != "INSECURE_PROTOCOL" || len(byField) != 2 {
+		t.Errorf("errors = %+v, want id INVALID_ID_FORMAT and url INSECURE_PROTOCOL", errs)
+	}
+
+	code, errs = postMarketInstall(t, h, `{"id":"demo"}`)
+	if code != http.StatusBadRequest || len(errs) != 1 || errs[0].Field != "url" || errs[0].Code != "EMPTY_URL" {
+		t.Errorf("missing url: %d %+v", code, errs)
+	}
+}
+
+func TestMarketInstallBlockedPluginIs403(t *testing.T) {
+	cfg := DefaultSecurityConfig()
+	cfg.BlockedPluginIDs = []string{"demo"}
+	h := newTestHost(t, Config{Security: &cfg})
+
+	code, errs := postMarketInstall(t, h, `{"id":"demo","url":"https://github.com/demo.zip"}`)
+	if code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", code)
+	}
+	if len(errs) != 1 || errs[0].Field != "id" || errs[0].Code != "PLUGIN_NOT_ALLOWED" {
+		t.Errorf("errors = %+v", errs)
+	}
+}
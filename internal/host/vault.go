@@ -0,0 +1,89 @@
+package host
+
+import (
+	"errors"
+	"net/http"
+	"path"
+)
+
+// handleVaultRaw 以流的方式读写存储库文件，避免大文件经过JSON编码
+//
+//	GET /vault/raw?pluginId=&path=  读取文件，支持Range请求
+//	PUT /vault/raw?pluginId=&path=  以请求体覆盖写入文件
+func (h *PluginHost) handleVaultRaw(w http.ResponseWriter, r *http.Request) {
+	pluginID, err := h.resolvePluginID(r, r.URL.Query().Get("pluginId"))
+	if err != nil {
+		http.Error(w, err.Error(), pluginAuthStatus(err))
+		return
+	}
+	relPath := r.URL.Query().Get("path")
+	if relPath == "" {
+		http.Error(w, "missing path", http.StatusBadRequest)
+		return
+	}
+	if err := h.checkVaultSandbox(pluginID, relPath); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !h.hasPermission(pluginID, "vault.read") {
+			http.Error(w, "missing permission: vault.read", http.StatusForbidden)
+			return
+		}
+		f, err := h.vault.Read(relPath)
+		if err != nil {
+			http.Error(w, err.Error(), vaultReadStatus(err))
+			return
+		}
+		defer f.Close()
+		info, err := h.vault.Stat(relPath)
+		if err != nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, path.Base(info.Path), info.ModTime, f)
+	case http.MethodPut:
+		if h.rejectReadOnly(w) {
+			return
+		}
+		if !h.hasPermission(pluginID, "vault.write") {
+			http.Error(w, "missing permission: vault.write", http.StatusForbidden)
+			return
+		}
+		if err := h.writeVaultStream(relPath, r.Body, r.ContentLength); err != nil {
+			if errors.Is(err, ErrInvalidVaultPath) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, ErrVaultQuotaExceeded) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if errors.Is(err, ErrVaultWriteRejected) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.audit("vault.write", requestActor(pluginID, r), relPath, map[string]any{"size": r.ContentLength})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// vaultReadStatus 把读取存储库的错误映射为状态码：文件不存在为 404，路径是目录为 400，其他错误为 500
+func vaultReadStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrVaultFileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrVaultIsDir):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
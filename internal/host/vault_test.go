@@ -0,0 +1,72 @@
+package host
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveVaultRaw 以插件身份请求 /vault/raw
+func serveVaultRaw(h *PluginHost, method, pluginID, path string, body []byte, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/vault/raw?pluginId="+pluginID+"&path="+path, bytes.NewReader(body))
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	h.handleVaultRaw(w, r)
+	return w
+}
+
+func TestVaultRawStreamsLargeFile(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 3<<16) // 3 MiB
+	if w := serveVaultRaw(h, http.MethodPut, "rw", "big.bin", content, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("PUT: got %d %s", w.Code, w.Body.String())
+	}
+	w := serveVaultRaw(h, http.MethodGet, "rw", "big.bin", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: got %d %s", w.Code, w.Body.String())
+	}
+	if !bytes.Equal(w.Body.Bytes(), content) {
+		t.Fatalf("GET returned %d bytes, want %d identical bytes", w.Body.Len(), len(content))
+	}
+}
+
+func TestVaultRawRangeRead(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+
+	content := []byte("hello, streaming vault")
+	if w := serveVaultRaw(h, http.MethodPut, "rw", "notes/a.txt", content, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("PUT: got %d %s", w.Code, w.Body.String())
+	}
+	w := serveVaultRaw(h, http.MethodGet, "rw", "notes/a.txt", nil, http.Header{"Range": {"bytes=7-15"}})
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("range GET: got %d", w.Code)
+	}
+	if got := w.Body.String(); got != "streaming" {
+		t.Errorf("range body = %q, want %q", got, "streaming")
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes 7-15/22" {
+		t.Errorf("Content-Range = %q", got)
+	}
+}
+
+func TestVaultRawRequiresPermission(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "reader", "vault.read")
+
+	if w := serveVaultRaw(h, http.MethodPut, "reader", "a.txt", []byte("x"), nil); w.Code != http.StatusForbidden {
+		t.Errorf("PUT without vault.write: got %d, want 403", w.Code)
+	}
+	if w := serveVaultRaw(h, http.MethodGet, "reader", "missing.txt", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET missing file: got %d, want 404", w.Code)
+	}
+	addTestPlugin(t, h, "none")
+	if w := serveVaultRaw(h, http.MethodGet, "none", "a.txt", nil, nil); w.Code != http.StatusForbidden {
+		t.Errorf("GET without vault.read: got %d, want 403", w.Code)
+	}
+}
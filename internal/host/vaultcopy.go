@@ -0,0 +1,50 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrVaultFileNotFound 存储库中不存在该文件
+	ErrVaultFileNotFound = errors.New("vault file not found")
+	// ErrVaultIsDir 读取的路径是存储库中的目录而不是文件
+	ErrVaultIsDir = errors.New("vault path is a directory")
+	// ErrVaultFileExists 目标文件已存在且未要求覆盖
+	ErrVaultFileExists = errors.New("vault file already exists")
+)
+
+// copyVaultFile 把存储库文件复制到 to，后端支持时保留修改时间，目标目录不存在时自动创建。
+// 目标已存在且 overwrite 为 false 时返回 ErrVaultFileExists；写入同样经过配额和写入策略检查
+func (h *PluginHost) copyVaultFile(from, to string, overwrite bool) error {
+	info, err := h.vault.Stat(from)
+	if err != nil {
+		return err
+	}
+	if cleanVaultPath(from) == cleanVaultPath(to) {
+		if overwrite {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", ErrVaultFileExists, to)
+	}
+	if !overwrite {
+		if _, err := h.vault.Stat(to); err == nil {
+			return fmt.Errorf("%w: %s", ErrVaultFileExists, to)
+		} else if !errors.Is(err, ErrVaultFileNotFound) {
+			return err
+		}
+	}
+
+	f, err := h.vault.Read(from)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := h.writeVaultStream(to, f, info.Size); err != nil {
+		return err
+	}
+	if setter, ok := h.vault.(vaultModTimeSetter); ok {
+		return setter.SetModTime(to, info.ModTime)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package host
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeVaultFixture 直接在存储库目录中写入文件并设置修改时间
+func writeVaultFixture(t *testing.T, h *PluginHost, rel, content string, modTime time.Time) {
+	t.Helper()
+	p := filepath.Join(h.config.VaultDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVaultCopy(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	writeVaultFixture(t, h, "templates/daily.md", "# Daily", modTime)
+
+	code, resp := callRPC(t, h, "rw", "vault.copy", map[string]any{"from": "templates/daily.md", "to": "journal/2024/today.md"})
+	if code != http.StatusOK {
+		t.Fatalf("copy: got %d %+v", code, resp.Error)
+	}
+	dst := filepath.Join(h.config.VaultDir, "journal", "2024", "today.md")
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "# Daily" {
+		t.Fatalf("copied file = %q, %v", data, err)
+	}
+	if info, err := os.Stat(dst); err != nil || !info.ModTime().Equal(modTime) {
+		t.Errorf("copied mtime = %v, want %v", info.ModTime(), modTime)
+	}
+	if data, _ := os.ReadFile(filepath.Join(h.config.VaultDir, "templates", "daily.md")); string(data) != "# Daily" {
+		t.Errorf("source changed to %q", data)
+	}
+}
+
+func TestVaultCopyOverwrite(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+	now := time.Now()
+	writeVaultFixture(t, h, "a.md", "new", now)
+	writeVaultFixture(t, h, "b.md", "old", now)
+
+	if code, _ := callRPC(t, h, "rw", "vault.copy", map[string]any{"from": "a.md", "to": "b.md"}); code != http.StatusConflict {
+		t.Errorf("copy onto existing file: status = %d, want 409", code)
+	}
+	if data, _ := os.ReadFile(filepath.Join(h.config.VaultDir, "b.md")); string(data) != "old" {
+		t.Fatalf("refused copy changed the destination to %q", data)
+	}
+	if code, resp := callRPC(t, h, "rw", "vault.copy", map[string]any{"from": "a.md", "to": "b.md", "overwrite": true}); code != http.StatusOK {
+		t.Fatalf("copy with overwrite: got %d %+v", code, resp.Error)
+	}
+	if data, _ := os.ReadFile(filepath.Join(h.config.VaultDir, "b.md")); string(data) != "new" {
+		t.Errorf("destination after overwrite = %q, want new", data)
+	}
+}
+
+func TestVaultCopyErrors(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+	addTestPlugin(t, h, "reader", "vault.read")
+	addTestPlugin(t, h, "writer", "vault.write")
+	writeVaultFixture(t, h, "a.md", "x", time.Now())
+
+	cases := []struct {
+		pluginID string
+		from, to string
+		want     int
+	}{
+		{"rw", "missing.md", "b.md", http.StatusNotFound},
+		{"rw", "a.md", "bad\x01.md", http.StatusBadRequest},
+		{"reader", "a.md", "b.md", http.StatusForbidden},
+		{"writer", "a.md", "b.md", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if code, _ := callRPC(t, h, tc.pluginID, "vault.copy", map[string]any{"from": tc.from, "to": tc.to}); code != tc.want {
+			t.Errorf("%s copy %s -> %s: status = %d, want %d", tc.pluginID, tc.from, tc.to, code, tc.want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(h.config.VaultDir, "b.md")); !os.IsNotExist(err) {
+		t.Error("failed copy created the destination")
+	}
+}
@@ -0,0 +1,104 @@
+package host
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+)
+
+// exportVaultZip 把存储库 prefix 目录下的文件按相对路径写入 zip，prefix 为空时导出全部，ctx 取消时中止导出
+func (h *PluginHost) exportVaultZip(ctx context.Context, w io.Writer, prefix string) error {
+	paths, err := h.vault.List(ctx)
+	if err != nil {
+		return err
+	}
+	root := cleanVaultPath(prefix)
+	zw := zip.NewWriter(w)
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !inVaultRoot(root, p) {
+			continue
+		}
+		if err := h.exportVaultFile(zw, p); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// exportVaultFile 把单个存储库文件写入 zip，列出后已被删除的文件跳过
+func (h *PluginHost) exportVaultFile(zw *zip.Writer, p string) error {
+	info, err := h.vault.Stat(p)
+	if errors.Is(err, ErrVaultFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	f, err := h.vault.Read(p)
+	if errors.Is(err, ErrVaultFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	header := &zip.FileHeader{Name: p, Method: zip.Deflate, Modified: info.ModTime}
+	header.SetMode(0o644)
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+// handleVaultExport 以 zip 流的形式导出存储库
+//
+//	GET /vault/export?pluginId=&prefix=
+func (h *PluginHost) handleVaultExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	pluginID, err := h.resolvePluginID(r, r.URL.Query().Get("pluginId"))
+	if err != nil {
+		http.Error(w, err.Error(), pluginAuthStatus(err))
+		return
+	}
+	if !h.hasPermission(pluginID, "vault.read") {
+		http.Error(w, "missing permission: vault.read", http.StatusForbidden)
+		return
+	}
+	prefix := r.URL.Query().Get("prefix")
+	if root, ok := h.vaultSandboxRoot(pluginID); ok && prefix == "" {
+		prefix = root
+	}
+	if err := h.checkVaultSandbox(pluginID, prefix); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if prefix != "" {
+		cleaned, ok := vaultImportPath(prefix, "x")
+		if !ok {
+			http.Error(w, "invalid prefix", http.StatusBadRequest)
+			return
+		}
+		prefix = filepath.Dir(cleaned)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="vault.zip"`)
+	if err := h.exportVaultZip(r.Context(), w, prefix); err != nil {
+		// 响应头已发出，只能中断流并记录
+		log.Printf("vault export failed: %v", err)
+		return
+	}
+	h.audit("vault.export", requestActor(pluginID, r), prefix, nil)
+}
@@ -0,0 +1,90 @@
+package host
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// exportedFiles 请求 /vault/export 并解出 zip 中的文件内容
+func exportedFiles(t *testing.T, h *PluginHost, pluginID, prefix string) map[string]string {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/vault/export?pluginId="+pluginID+"&prefix="+prefix, nil)
+	w := httptest.NewRecorder()
+	h.handleVaultExport(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: got %d %s", w.Code, w.Body.String())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("export is not a zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+func TestVaultExportZipContents(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "reader", "vault.read")
+
+	fixture := map[string]string{
+		"index.md":         "# Index",
+		"notes/a.md":       "alpha",
+		"notes/deep/b.md":  "beta",
+		"notesextra/c.md":  "not under notes/",
+		"attachments/x.md": "x",
+	}
+	for p, content := range fixture {
+		full := filepath.Join(h.config.VaultDir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all := exportedFiles(t, h, "reader", "")
+	if len(all) != len(fixture) {
+		t.Errorf("full export has %d files, want %d: %v", len(all), len(fixture), all)
+	}
+	for p, content := range fixture {
+		if all[p] != content {
+			t.Errorf("%s = %q, want %q", p, all[p], content)
+		}
+	}
+
+	notes := exportedFiles(t, h, "reader", "notes")
+	if len(notes) != 2 || notes["notes/a.md"] != "alpha" || notes["notes/deep/b.md"] != "beta" {
+		t.Errorf("prefix export = %v", notes)
+	}
+}
+
+func TestVaultExportRequiresReadPermission(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "none")
+
+	r := httptest.NewRequest(http.MethodGet, "/vault/export?pluginId=none", nil)
+	w := httptest.NewRecorder()
+	h.handleVaultExport(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("export without vault.read: got %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,203 @@
+package host
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxVaultImportBytes 导入的压缩包及解压后内容的总大小上限
+const maxVaultImportBytes = 100 << 20
+
+// VaultImportSkip 导入时被跳过的条目
+type VaultImportSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// VaultImportResult 批量导入的结果汇总
+type VaultImportResult struct {
+	Created []string          `json:"created"`
+	Updated []string          `json:"updated"`
+	Skipped []VaultImportSkip `json:"skipped"`
+}
+
+// vaultImportPath 把压缩包条目名映射到 prefix 下的存储库相对路径，
+// 绝对路径、包含 .. 或反斜杠的条目返回 false
+func vaultImportPath(prefix, name string) (string, bool) {
+	clean := func(p string) (string, bool) {
+		if strings.Contains(p, `\`) || path.IsAbs(p) {
+			return "", false
+		}
+		p = path.Clean(p)
+		if p == ".." || strings.HasPrefix(p, "../") {
+			return "", false
+		}
+		return p, true
+	}
+	pre, ok := clean(prefix)
+	if !ok {
+		return "", false
+	}
+	rel, ok := clean(name)
+	if !ok || rel == "." {
+		return "", false
+	}
+	return filepath.FromSlash(path.Join(pre, rel)), true
+}
+
+// importVaultZip 把压缩包中的文件写入存储库 prefix 目录下，逐个检查容量和写入策略，
+// 不符合要求的条目跳过并记录原因；疑似压缩炸弹时中止导入并返回 ErrZipBomb
+func (h *PluginHost) importVaultZip(zr *zip.Reader, prefix string) (*VaultImportResult, error) {
+	result := &VaultImportResult{Created: []string{}, Updated: []string{}, Skipped: []VaultImportSkip{}}
+	if err := checkZipEntries(zr); err != nil {
+		return result, err
+	}
+	guard := &zipGuard{}
+	var total int64
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rel, ok := vaultImportPath(prefix, f.Name)
+		if !ok {
+			result.Skipped = append(result.Skipped, VaultImportSkip{Path: f.Name, Reason: "invalid path"})
+			continue
+		}
+		if err := checkZipEntryType(f); err != nil {
+			result.Skipped = append(result.Skipped, VaultImportSkip{Path: f.Name, Reason: "unsupported entry type"})
+			continue
+		}
+		if total+int64(f.UncompressedSize64) > maxVaultImportBytes {
+			result.Skipped = append(result.Skipped, VaultImportSkip{Path: f.Name, Reason: "import size limit exceeded"})
+			continue
+		}
+
+		rc, err := guard.open(f)
+		if err != nil {
+			result.Skipped = append(result.Skipped, VaultImportSkip{Path: f.Name, Reason: err.Error()})
+			continue
+		}
+		// 不信任条目头中声明的大小
+		data, err := io.ReadAll(io.LimitReader(rc, maxVaultImportBytes-total+1))
+		rc.Close()
+		if errors.Is(err, ErrZipBomb) {
+			return result, err
+		}
+		if err != nil {
+			result.Skipped = append(result.Skipped, VaultImportSkip{Path: f.Name, Reason: err.Error()})
+			continue
+		}
+		if total+int64(len(data)) > maxVaultImportBytes {
+			result.Skipped = append(result.Skipped, VaultImportSkip{Path: f.Name, Reason: "import size limit exceeded"})
+			continue
+		}
+
+		_, statErr := h.vault.Stat(rel)
+		if err := h.writeVaultFile(rel, data); err != nil {
+			if errors.Is(err, ErrVaultQuotaExceeded) || errors.Is(err, ErrVaultWriteRejected) || errors.Is(err, ErrInvalidVaultPath) {
+				result.Skipped = append(result.Skipped, VaultImportSkip{Path: f.Name, Reason: err.Error()})
+				continue
+			}
+			return result, err
+		}
+		total += int64(len(data))
+
+		slashed := filepath.ToSlash(rel)
+		if statErr == nil {
+			result.Updated = append(result.Updated, slashed)
+		} else {
+			result.Created = append(result.Created, slashed)
+		}
+	}
+	return result, nil
+}
+
+// handleVaultImport 导入 zip 压缩包中的笔记
+//
+//	POST /vault/import?pluginId=&prefix=  请求体为 zip 文件
+func (h *PluginHost) handleVaultImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if h.rejectReadOnly(w) {
+		return
+	}
+	pluginID, err := h.resolvePluginID(r, r.URL.Query().Get("pluginId"))
+	if err != nil {
+		http.Error(w, err.Error(), pluginAuthStatus(err))
+		return
+	}
+	if !h.hasPermission(pluginID, "vault.write") {
+		http.Error(w, "missing permission: vault.write", http.StatusForbidden)
+		return
+	}
+	prefix := r.URL.Query().Get("prefix")
+	if root, ok := h.vaultSandboxRoot(pluginID); ok && prefix == "" {
+		prefix = root
+	}
+	if _, ok := vaultImportPath(prefix, "x"); !ok {
+		http.Error(w, "invalid prefix", http.StatusBadRequest)
+		return
+	}
+	if err := h.checkVaultSandbox(pluginID, prefix); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// zip 需要随机访问，先落盘
+	tmp, err := os.CreateTemp("", "vault-import-*.zip")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, http.MaxBytesReader(w, r.Body, maxVaultImportBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, fmt.Sprintf("import exceeds %d bytes", maxVaultImportBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		http.Error(w, "invalid zip: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.importVaultZip(zr, prefix)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrZipBomb) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	h.Broadcast(Event{Type: "vault.imported", Data: map[string]any{
+		"prefix":  prefix,
+		"created": len(result.Created),
+		"updated": len(result.Updated),
+		"skipped": len(result.Skipped),
+	}})
+	h.audit("vault.import", requestActor(pluginID, r), prefix, map[string]any{
+		"created": len(result.Created),
+		"updated": len(result.Updated),
+		"skipped": len(result.Skipped),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,109 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// importTestZip 以插件身份向 /vault/import 上传 zip
+func importTestZip(t *testing.T, h *PluginHost, pluginID, prefix string, entries map[string][]byte) *VaultImportResult {
+	t.Helper()
+	zipPath := filepath.Join(t.TempDir(), "import.zip")
+	writeTestZip(t, zipPath, entries)
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/vault/import?pluginId="+pluginID+"&prefix="+prefix, bytes.NewReader(data))
+	w := httptest.NewRecorder()
+	h.handleVaultImport(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: got %d %s", w.Code, w.Body.String())
+	}
+	var result VaultImportResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode result %q: %v", w.Body.String(), err)
+	}
+	return &result
+}
+
+func TestVaultImportSmallZip(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+	events := subscribeEvents(t, h)
+
+	result := importTestZip(t, h, "rw", "imported", map[string][]byte{
+		"a.md":     []byte("# A"),
+		"sub/b.md": []byte("# B"),
+	})
+	sort.Strings(result.Created)
+	if len(result.Created) != 2 || result.Created[0] != "imported/a.md" || result.Created[1] != "imported/sub/b.md" {
+		t.Errorf("created = %v", result.Created)
+	}
+	if len(result.Updated) != 0 || len(result.Skipped) != 0 {
+		t.Errorf("updated = %v, skipped = %v", result.Updated, result.Skipped)
+	}
+	data, err := os.ReadFile(filepath.Join(h.config.VaultDir, "imported", "sub", "b.md"))
+	if err != nil || string(data) != "# B" {
+		t.Errorf("imported file = %q, %v", data, err)
+	}
+	if got := eventTypes(receivedEvents(t, events)); len(got) != 1 || got[0] != "vault.imported" {
+		t.Errorf("events = %v, want [vault.imported]", got)
+	}
+
+	// 再次导入同名文件记为更新
+	result = importTestZip(t, h, "rw", "imported", map[string][]byte{"a.md": []byte("# A2")})
+	if len(result.Created) != 0 || len(result.Updated) != 1 || result.Updated[0] != "imported/a.md" {
+		t.Errorf("re-import: created = %v, updated = %v", result.Created, result.Updated)
+	}
+}
+
+func TestVaultImportSkipsTraversal(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+
+	result := importTestZip(t, h, "rw", "imported", map[string][]byte{
+		"ok.md":            []byte("ok"),
+		"../evil.md":       []byte("evil"),
+		"../../escape.md":  []byte("evil"),
+		"/etc/absolute.md": []byte("evil"),
+	})
+	if len(result.Created) != 1 || result.Created[0] != "imported/ok.md" {
+		t.Errorf("created = %v", result.Created)
+	}
+	if len(result.Skipped) != 3 {
+		t.Fatalf("skipped = %v, want 3 entries", result.Skipped)
+	}
+	for _, s := range result.Skipped {
+		if s.Reason != "invalid path" {
+			t.Errorf("skip %s: reason = %q", s.Path, s.Reason)
+		}
+	}
+	for _, p := range []string{
+		filepath.Join(h.config.VaultDir, "evil.md"),
+		filepath.Join(filepath.Dir(h.config.VaultDir), "evil.md"),
+		filepath.Join(filepath.Dir(h.config.VaultDir), "escape.md"),
+	} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s was written outside the import prefix", p)
+		}
+	}
+}
+
+func TestVaultImportRequiresWritePermission(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "reader", "vault.read")
+
+	r := httptest.NewRequest(http.MethodPost, "/vault/import?pluginId=reader", bytes.NewReader(nil))
+	w := httptest.NewRecorder()
+	h.handleVaultImport(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("import without vault.write: got %d, want 403", w.Code)
+	}
+}
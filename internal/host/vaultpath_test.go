@@ -0,0 +1,116 @@
+package host
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateVaultPath(t *testing.T) {
+	for _, p := range []string{
+		"a.md",
+		"notes/2024/daily.md",
+		"./notes/a.md",
+		"console.md",
+		"CONFIG/readme",
+		"COM10.txt",
+		"notes/.hidden",
+		"中文/笔记.md",
+		strings.Repeat("a", MaxVaultPathLength),
+	} {
+		if err := validateVaultPath(p); err != nil {
+			t.Errorf("validateVaultPath(%q) = %v, want ok", p, err)
+		}
+	}
+	for _, p := range []string{
+		"",
+		".",
+		strings.Repeat("a", MaxVaultPathLength+1),
+		strings.Repeat("a/", MaxVaultPathLength/2) + "b",
+		"a\x00b.md",
+		"notes/a\nb.md",
+		"tab\t.md",
+		"del\x7f.md",
+		"CON",
+		"nul.txt",
+		"notes/Aux/a.md",
+		"com1.tar.gz",
+		"LPT9 .md",
+		"notes./a.md",
+		"a.md.",
+		"notes /a.md",
+		"a.md ",
+	} {
+		if err := validateVaultPath(p); !errors.Is(err, ErrInvalidVaultPath) {
+			t.Errorf("validateVaultPath(%q) = %v, want ErrInvalidVaultPath", p, err)
+		}
+	}
+}
+
+func TestVaultWriteRejectsInvalidPath(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+
+	for _, p := range []string{"CON.md", "a\x01.md", "trailing.", strings.Repeat("x", MaxVaultPathLength+1)} {
+		code, resp := callRPC(t, h, "rw", "vault.write", map[string]any{"path": p, "content": "x"})
+		if code != http.StatusBadRequest || resp.Error == nil || !strings.Contains(resp.Error.Message, "vault.invalidPath") {
+			t.Errorf("vault.write %q: got %d %+v", p, code, resp.Error)
+		}
+	}
+	if entries, err := h.vault.List(context.Background()); err != nil || len(entries) != 0 {
+		t.Fatalf("vault entries = %v, %v", entries, err)
+	}
+
+	// 复制的目标路径同样校验
+	if code, resp := callRPC(t, h, "rw", "vault.write", map[string]any{"path": "a.md", "content": "x"}); code != http.StatusOK {
+		t.Fatalf("vault.write: got %d %+v", code, resp.Error)
+	}
+	if code, resp := callRPC(t, h, "rw", "vault.copy", map[string]any{"from": "a.md", "to": "notes/NUL.md"}); code != http.StatusBadRequest {
+		t.Errorf("vault.copy to a reserved name: got %d %+v", code, resp.Error)
+	}
+	if w := serveVaultRaw(h, http.MethodPut, "rw", "prn.txt", []byte("x"), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("PUT /vault/raw reserved name: got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestImportVaultSkipsInvalidPaths(t *testing.T) {
+	h := newTestHost(t, Config{})
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"ok.md", "aux.md", "notes./a.md"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := h.importVaultZip(zr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Skipped) != 2 {
+		t.Fatalf("skipped = %+v, want aux.md and notes./a.md", result.Skipped)
+	}
+	for _, s := range result.Skipped {
+		if !strings.Contains(s.Reason, "vault.invalidPath") {
+			t.Errorf("skip reason for %s = %q", s.Path, s.Reason)
+		}
+	}
+	if _, err := h.vault.Stat("ok.md"); err != nil {
+		t.Errorf("valid entry not imported: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// failingReadStore 读取时返回固定错误，模拟权限不足等磁盘错误
+type failingReadStore struct {
+	VaultStore
+	err error
+}
+
+func (s failingReadStore) Read(string) (io.ReadSeekCloser, error) {
+	return nil, s.err
+}
+
+func TestVaultReadStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: a.md", ErrVaultFileNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: notes", ErrVaultIsDir), http.StatusBadRequest},
+		{os.ErrPermission, http.StatusInternalServerError},
+		{errors.New("disk on fire"), http.StatusInternalServerError},
+	} {
+		if got := vaultReadStatus(tc.err); got != tc.want {
+			t.Errorf("vaultReadStatus(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestVaultStoreReadDirectory(t *testing.T) {
+	for name, v := range map[string]VaultStore{
+		"fs":     NewFSVaultStore(t.TempDir()),
+		"memory": NewMemoryVaultStore(),
+	} {
+		if err := v.Write("notes/a.md", strings.NewReader("x")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := v.Read("notes"); !errors.Is(err, ErrVaultIsDir) {
+			t.Errorf("%s: read directory: %v, want ErrVaultIsDir", name, err)
+		}
+		// 同名前缀的文件不构成目录
+		if _, err := v.Read("note"); !errors.Is(err, ErrVaultFileNotFound) {
+			t.Errorf("%s: read missing: %v, want ErrVaultFileNotFound", name, err)
+		}
+	}
+}
+
+func TestVaultReadErrorCodes(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+	if err := h.writeVaultFile("notes/a.md", []byte("---\ntitle: A\n---\nbody")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{"vault.read", "vault.readMeta"} {
+		for path, want := range map[string]int{"notes/a.md": 200, "missing.md": 404, "notes": 400} {
+			code, resp := callRPC(t, h, "rw", method, map[string]any{"path": path})
+			if code != want {
+				t.Errorf("%s %s: got %d %+v, want %d", method, path, code, resp.Error, want)
+			}
+		}
+	}
+	for path, want := range map[string]int{"notes/a.md": 200, "missing.md": 404, "notes": 400} {
+		if w := serveVaultRaw(h, http.MethodGet, "rw", path, nil, nil); w.Code != want {
+			t.Errorf("GET /vault/raw %s: got %d %s, want %d", path, w.Code, w.Body.String(), want)
+		}
+	}
+	if _, err := h.readVaultFile("missing.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("readVaultFile missing: %v", err)
+	}
+}
+
+func TestVaultReadBackendError(t *testing.T) {
+	h := newTestHost(t, Config{VaultStore: failingReadStore{VaultStore: NewMemoryVaultStore(), err: os.ErrPermission}})
+	addTestPlugin(t, h, "rw", "vault.read")
+
+	code, resp := callRPC(t, h, "rw", "vault.read", map[string]any{"path": "a.md"})
+	if code != http.StatusInternalServerError || resp.Error == nil || !strings.Contains(resp.Error.Message, "permission denied") {
+		t.Errorf("vault.read: got %d %+v, want 500", code, resp.Error)
+	}
+	if w := serveVaultRaw(h, http.MethodGet, "rw", "a.md", nil, nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("GET /vault/raw: got %d, want 500", w.Code)
+	}
+}
@@ -0,0 +1,180 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// VaultFileInfo 存储库文件的元数据
+type VaultFileInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// VaultStore 存储库的存储后端。路径为以斜杠分隔的相对路径，后端负责把路径限制在存储库内；
+// 文件不存在时 Read、Delete、Stat 返回包装了 ErrVaultFileNotFound 的错误，Read 的路径是目录时
+// 返回包装了 ErrVaultIsDir 的错误。
+// 配额、写入策略和沙箱检查由宿主在调用前完成
+type VaultStore interface {
+	// List 列出全部文件，ctx 取消时中止并返回 ctx.Err()
+	List(ctx context.Context) ([]string, error)
+	// Read 打开文件，调用方负责关闭
+	Read(path string) (io.ReadSeekCloser, error)
+	// Write 以 r 的全部内容创建或覆盖文件，写入失败时不留下部分内容
+	Write(path string, r io.Reader) error
+	Delete(path string) error
+	Stat(path string) (VaultFileInfo, error)
+}
+
+// vaultUsager 可选接口，后端能直接给出占用空间时不必逐个统计文件
+type vaultUsager interface {
+	Usage() (int64, error)
+}
+
+// vaultModTimeSetter 可选接口，支持设置修改时间的后端在复制时保留源文件的修改时间
+type vaultModTimeSetter interface {
+	SetModTime(path string, t time.Time) error
+}
+
+const (
+	// DefaultFileMode 未配置 Config.FileMode 时存储库文件的权限
+	DefaultFileMode os.FileMode = 0o644
+	// DefaultDirMode 未配置 Config.DirMode 时存储库目录的权限
+	DefaultDirMode os.FileMode = 0o755
+)
+
+// FSVaultStore 以本地目录保存存储库，写入先落到同目录的临时文件再原子替换
+type FSVaultStore struct {
+	root string
+	// FileMode 写入文件的权限，0 表示 DefaultFileMode
+	FileMode os.FileMode
+	// DirMode 写入时创建的目录的权限，0 表示 DefaultDirMode，已存在的目录不修改
+	DirMode os.FileMode
+}
+
+// NewFSVaultStore 创建以 root 为根目录的文件系统存储库
+func NewFSVaultStore(root string) *FSVaultStore {
+	return &FSVaultStore{root: root}
+}
+
+func (s *FSVaultStore) abs(p string) string {
+	return filepath.Join(s.root, filepath.FromSlash(cleanVaultPath(p)))
+}
+
+func (s *FSVaultStore) List(ctx context.Context) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return nil
+		}
+		// 跳过写入过程中的临时文件
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".vault-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return nil
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	return paths, err
+}
+
+func (s *FSVaultStore) Read(p string) (io.ReadSeekCloser, error) {
+	f, err := os.Open(s.abs(p))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrVaultFileNotFound, p)
+		}
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("%w: %s", ErrVaultIsDir, p)
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, fmt.Errorf("%w: %s", ErrVaultFileNotFound, p)
+	}
+	return f, nil
+}
+
+func (s *FSVaultStore) Write(p string, r io.Reader) error {
+	fileMode, dirMode := s.FileMode.Perm(), s.DirMode.Perm()
+	if fileMode == 0 {
+		fileMode = DefaultFileMode
+	}
+	if dirMode == 0 {
+		dirMode = DefaultDirMode
+	}
+	target := s.abs(p)
+	if err := os.MkdirAll(filepath.Dir(target), dirMode); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".vault-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), fileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *FSVaultStore) Delete(p string) error {
+	if _, err := s.Stat(p); err != nil {
+		return err
+	}
+	return os.Remove(s.abs(p))
+}
+
+func (s *FSVaultStore) Stat(p string) (VaultFileInfo, error) {
+	info, err := os.Stat(s.abs(p))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return VaultFileInfo{}, fmt.Errorf("%w: %s", ErrVaultFileNotFound, p)
+		}
+		return VaultFileInfo{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return VaultFileInfo{}, fmt.Errorf("%w: %s", ErrVaultFileNotFound, p)
+	}
+	return VaultFileInfo{Path: cleanVaultPath(p), Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Usage 返回目录下所有常规文件的大小之和，包括写入中的临时文件
+func (s *FSVaultStore) Usage() (int64, error) {
+	return dirSize(s.root)
+}
+
+// SetModTime 设置文件的修改时间
+func (s *FSVaultStore) SetModTime(p string, t time.Time) error {
+	return os.Chtimes(s.abs(p), t, t)
+}
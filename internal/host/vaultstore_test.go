@@ -0,0 +1,180 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readVaultStore 读出后端中文件的全部内容
+func readVaultStore(t *testing.T, v VaultStore, p string) (string, error) {
+	t.Helper()
+	r, err := v.Read(p)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+// testVaultStore 对任意后端运行同一组行为检查
+func testVaultStore(t *testing.T, v VaultStore) {
+	t.Helper()
+	for p, content := range map[string]string{"notes/a.md": "v1", "b.md": "b"} {
+		if err := v.Write(p, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.Write("notes/a.md", strings.NewReader("version 2")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := readVaultStore(t, v, "notes/a.md"); err != nil || got != "version 2" {
+		t.Errorf("Read = %q, %v, want the overwritten content", got, err)
+	}
+	// 路径被限制在存储库内
+	if got, err := readVaultStore(t, v, "../notes/./a.md"); err != nil || got != "version 2" {
+		t.Errorf("Read of an escaping path = %q, %v, want the clamped file", got, err)
+	}
+	if info, err := v.Stat("notes/a.md"); err != nil || info.Path != "notes/a.md" || info.Size != int64(len("version 2")) {
+		t.Errorf("Stat = %+v, %v", info, err)
+	}
+	paths, err := v.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(paths)
+	if want := []string{"b.md", "notes/a.md"}; !slices.Equal(paths, want) {
+		t.Errorf("List = %v, want %v", paths, want)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := v.List(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("List with canceled ctx: err = %v, want context.Canceled", err)
+	}
+
+	if _, err := v.Read("notes"); !errors.Is(err, ErrVaultIsDir) {
+		t.Errorf("Read of a directory: err = %v, want ErrVaultIsDir", err)
+	}
+	if err := v.Delete("b.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Read("b.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("Read after Delete: err = %v, want ErrVaultFileNotFound", err)
+	}
+	if err := v.Delete("b.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("second Delete: err = %v, want ErrVaultFileNotFound", err)
+	}
+	if _, err := v.Stat("missing.md"); !errors.Is(err, ErrVaultFileNotFound) {
+		t.Errorf("Stat of missing file: err = %v, want ErrVaultFileNotFound", err)
+	}
+
+	if setter, ok := v.(vaultModTimeSetter); ok {
+		mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		if err := setter.SetModTime("notes/a.md", mtime); err != nil {
+			t.Fatal(err)
+		}
+		if info, _ := v.Stat("notes/a.md"); !info.ModTime.Equal(mtime) {
+			t.Errorf("ModTime = %v, want %v", info.ModTime, mtime)
+		}
+	}
+}
+
+func TestVaultStoreBackends(t *testing.T) {
+	t.Run("fs", func(t *testing.T) {
+		testVaultStore(t, NewFSVaultStore(t.TempDir()))
+	})
+	t.Run("memory", func(t *testing.T) {
+		testVaultStore(t, NewMemoryVaultStore())
+	})
+}
+
+func TestFSVaultStoreWriteLeavesNoTempFiles(t *testing.T) {
+	root := t.TempDir()
+	v := NewFSVaultStore(root)
+	v.FileMode = 0o600
+	if err := v.Write("a.md", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "a.md" {
+		t.Errorf("vault dir entries = %v, want only a.md", entries)
+	}
+	if info, err := os.Stat(filepath.Join(root, "a.md")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	if got, _ := NewFSVaultStore(filepath.Join(root, "missing")).List(context.Background()); len(got) != 0 {
+		t.Errorf("List of a missing root = %v, want empty", got)
+	}
+}
+
+func TestHostUsesConfiguredVaultStore(t *testing.T) {
+	v := NewMemoryVaultStore()
+	h := newTestHost(t, Config{VaultStore: v})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+
+	if code, resp := callRPC(t, h, "rw", "vault.write", map[string]any{"path": "a.md", "content": "hello"}); code != http.StatusOK {
+		t.Fatalf("vault.write: got %d %+v", code, resp.Error)
+	}
+	if got, err := readVaultStore(t, v, "a.md"); err != nil || got != "hello" {
+		t.Errorf("configured store content = %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(h.config.VaultDir, "a.md")); !os.IsNotExist(err) {
+		t.Errorf("write reached VaultDir: %v", err)
+	}
+	if code, resp := callRPC(t, h, "rw", "vault.read", map[string]any{"path": "a.md"}); code != http.StatusOK {
+		t.Errorf("vault.read: got %d %+v", code, resp.Error)
+	}
+}
+
+func TestHostVaultFileModes(t *testing.T) {
+	h := newTestHost(t, Config{FileMode: 0o640, DirMode: 0o750})
+	if err := h.vault.Write("notes/deep/a.md", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]os.FileMode{
+		"notes/deep/a.md": 0o640,
+		"notes/deep":      0o750,
+		"notes":           0o750,
+	} {
+		if info, err := os.Stat(filepath.Join(h.config.VaultDir, path)); err != nil || info.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, %v, want %v", path, info.Mode().Perm(), err, want)
+		}
+	}
+
+	// 覆盖已有文件时同样使用配置的权限
+	if err := os.Chmod(filepath.Join(h.config.VaultDir, "notes/deep/a.md"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.vault.Write("notes/deep/a.md", strings.NewReader("again")); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(filepath.Join(h.config.VaultDir, "notes/deep/a.md")); info.Mode().Perm() != 0o640 {
+		t.Errorf("overwritten file mode = %v, want 0640", info.Mode().Perm())
+	}
+}
+
+func TestHostVaultDefaultFileModes(t *testing.T) {
+	h := newTestHost(t, Config{})
+	if err := h.vault.Write("notes/a.md", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(h.config.VaultDir, "notes/a.md")); err != nil || info.Mode().Perm() != DefaultFileMode {
+		t.Errorf("file mode = %v, %v, want %v", info.Mode().Perm(), err, DefaultFileMode)
+	}
+	// 目录权限受 umask 影响，只检查不超过默认值
+	if info, err := os.Stat(filepath.Join(h.config.VaultDir, "notes")); err != nil || info.Mode().Perm()&^DefaultDirMode != 0 {
+		t.Errorf("dir mode = %v, %v, want at most %v", info.Mode().Perm(), err, DefaultDirMode)
+	}
+}
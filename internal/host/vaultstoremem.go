@@ -0,0 +1,113 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryVaultStore 把存储库保存在内存中，适合测试和临时宿主，重启后内容丢失
+type MemoryVaultStore struct {
+	mu    sync.RWMutex
+	files map[string]memVaultFile
+}
+
+type memVaultFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// memVaultReader 为内存中的文件内容提供 Close
+type memVaultReader struct {
+	*bytes.Reader
+}
+
+func (memVaultReader) Close() error { return nil }
+
+// NewMemoryVaultStore 创建空的内存存储库
+func NewMemoryVaultStore() *MemoryVaultStore {
+	return &MemoryVaultStore{files: make(map[string]memVaultFile)}
+}
+
+func (s *MemoryVaultStore) List(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	paths := make([]string, 0, len(s.files))
+	for p := range s.files {
+		paths = append(paths, p)
+	}
+	s.mu.RUnlock()
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (s *MemoryVaultStore) Read(p string) (io.ReadSeekCloser, error) {
+	key := cleanVaultPath(p)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.files[key]
+	if !ok {
+		// 内存中没有目录，路径下有文件时视为目录
+		for other := range s.files {
+			if inVaultRoot(key, other) {
+				return nil, fmt.Errorf("%w: %s", ErrVaultIsDir, p)
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrVaultFileNotFound, p)
+	}
+	// 写入总是替换整个切片，读取方持有的旧内容不会被修改
+	return memVaultReader{bytes.NewReader(f.data)}, nil
+}
+
+func (s *MemoryVaultStore) Write(p string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.files[cleanVaultPath(p)] = memVaultFile{data: data, modTime: time.Now()}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryVaultStore) Delete(p string) error {
+	key := cleanVaultPath(p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[key]; !ok {
+		return fmt.Errorf("%w: %s", ErrVaultFileNotFound, p)
+	}
+	delete(s.files, key)
+	return nil
+}
+
+func (s *MemoryVaultStore) Stat(p string) (VaultFileInfo, error) {
+	key := cleanVaultPath(p)
+	s.mu.RLock()
+	f, ok := s.files[key]
+	s.mu.RUnlock()
+	if !ok {
+		return VaultFileInfo{}, fmt.Errorf("%w: %s", ErrVaultFileNotFound, p)
+	}
+	return VaultFileInfo{Path: key, Size: int64(len(f.data)), ModTime: f.modTime}, nil
+}
+
+// SetModTime 设置文件的修改时间
+func (s *MemoryVaultStore) SetModTime(p string, t time.Time) error {
+	key := cleanVaultPath(p)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrVaultFileNotFound, p)
+	}
+	f.modTime = t
+	s.files[key] = f
+	return nil
+}
@@ -0,0 +1,212 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// defaultVaultWatchInterval 未配置 Config.VaultWatchInterval 时轮询存储库的间隔
+const defaultVaultWatchInterval = 2 * time.Second
+
+// vaultWatcher 一个存储库变更订阅，只接收 prefix 目录下的事件
+type vaultWatcher struct {
+	pluginID string
+	prefix   string
+	ch       chan []byte
+}
+
+// vaultFileState 轮询时用于判断文件是否变化的元数据
+type vaultFileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// vaultWatchInterval 返回轮询存储库的间隔
+func (h *PluginHost) vaultWatchInterval() time.Duration {
+	if h.config.VaultWatchInterval > 0 {
+		return h.config.VaultWatchInterval
+	}
+	return defaultVaultWatchInterval
+}
+
+// addVaultWatcher 注册订阅。第一个订阅出现时记录当前快照并开始轮询，没有订阅时不轮询存储库。
+// ctx 为订阅请求的上下文，客户端在首次快照期间断开时中止遍历
+func (h *PluginHost) addVaultWatcher(ctx context.Context, w *vaultWatcher) error {
+	h.vaultWatchMu.Lock()
+	defer h.vaultWatchMu.Unlock()
+	if h.vaultWatchStop == nil {
+		snapshot, err := h.vaultSnapshot(ctx)
+		if err != nil {
+			return err
+		}
+		h.vaultWatchStop = make(chan struct{})
+		go h.pollVault(h.vaultWatchStop, snapshot)
+	}
+	if h.vaultWatchers == nil {
+		h.vaultWatchers = make(map[*vaultWatcher]struct{})
+	}
+	h.vaultWatchers[w] = struct{}{}
+	return nil
+}
+
+// removeVaultWatcher 注销订阅，最后一个订阅离开时停止轮询
+func (h *PluginHost) removeVaultWatcher(w *vaultWatcher) {
+	h.vaultWatchMu.Lock()
+	defer h.vaultWatchMu.Unlock()
+	delete(h.vaultWatchers, w)
+	if len(h.vaultWatchers) == 0 && h.vaultWatchStop != nil {
+		close(h.vaultWatchStop)
+		h.vaultWatchStop = nil
+	}
+}
+
+// vaultSnapshot 列出存储库全部文件的大小和修改时间，列出后已被删除的文件跳过，ctx 取消时中止遍历
+func (h *PluginHost) vaultSnapshot(ctx context.Context) (map[string]vaultFileState, error) {
+	paths, err := h.vault.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(map[string]vaultFileState, len(paths))
+	for _, p := range paths {
+		info, err := h.vault.Stat(p)
+		if errors.Is(err, ErrVaultFileNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		snapshot[p] = vaultFileState{size: info.Size, modTime: info.ModTime}
+	}
+	return snapshot, nil
+}
+
+// pollVault 按间隔比较存储库快照并把差异分发给订阅，直到 stop 关闭。
+// 轮询不区分变更来源，绕过宿主直接修改存储库目录的文件也会产生事件
+func (h *PluginHost) pollVault(stop <-chan struct{}, prev map[string]vaultFileState) {
+	// 停止轮询时中止进行中的遍历
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(h.vaultWatchInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		cur, err := h.vaultSnapshot(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("vault watch: %v", err)
+			continue
+		}
+		for _, ev := range diffVaultSnapshots(prev, cur) {
+			h.dispatchVaultEvent(ev)
+		}
+		prev = cur
+	}
+}
+
+// diffVaultSnapshots 返回两次快照之间的 vault.created、vault.updated 和 vault.deleted 事件，按路径排序
+func diffVaultSnapshots(prev, cur map[string]vaultFileState) []Event {
+	var events []Event
+	for p, state := range cur {
+		old, ok := prev[p]
+		switch {
+		case !ok:
+			events = append(events, vaultChangeEvent("vault.created", p, state))
+		case old.size != state.size || !old.modTime.Equal(state.modTime):
+			events = append(events, vaultChangeEvent("vault.updated", p, state))
+		}
+	}
+	for p := range prev {
+		if _, ok := cur[p]; !ok {
+			events = append(events, Event{Type: "vault.deleted", Data: map[string]any{"path": p}})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Data.(map[string]any)["path"].(string) < events[j].Data.(map[string]any)["path"].(string)
+	})
+	return events
+}
+
+func vaultChangeEvent(typ, p string, state vaultFileState) Event {
+	return Event{Type: typ, Data: map[string]any{
+		"path":    p,
+		"size":    state.size,
+		"modTime": state.modTime,
+	}}
+}
+
+// dispatchVaultEvent 把事件发给前缀匹配的订阅。每次分发时重新检查读取权限和沙箱，
+// 订阅期间被撤销权限的插件不再收到事件；订阅方处理不及时时丢弃事件
+func (h *PluginHost) dispatchVaultEvent(ev Event) {
+	p := ev.Data.(map[string]any)["path"].(string)
+	msg := encodeSSE(ev)
+	h.vaultWatchMu.Lock()
+	defer h.vaultWatchMu.Unlock()
+	for w := range h.vaultWatchers {
+		if !inVaultRoot(w.prefix, p) || !h.hasPermission(w.pluginID, "vault.read") || h.checkVaultSandbox(w.pluginID, p) != nil {
+			continue
+		}
+		select {
+		case w.ch <- msg:
+		default:
+		}
+	}
+}
+
+// handleVaultWatch 以 SSE 推送存储库 prefix 目录下的文件变更，事件类型为 vault.created、
+// vault.updated 和 vault.deleted。需要 vault.read 权限，声明了沙箱的插件只能订阅沙箱内的目录
+//
+//	GET /vault/watch?pluginId=&prefix=
+func (h *PluginHost) handleVaultWatch(w http.ResponseWriter, r *http.Request) {
+	pluginID, err := h.resolvePluginID(r, r.URL.Query().Get("pluginId"))
+	if err != nil {
+		http.Error(w, err.Error(), pluginAuthStatus(err))
+		return
+	}
+	if !h.hasPermission(pluginID, "vault.read") {
+		http.Error(w, "missing permission: vault.read", http.StatusForbidden)
+		return
+	}
+	prefix := r.URL.Query().Get("prefix")
+	if root, ok := h.vaultSandboxRoot(pluginID); ok && prefix == "" {
+		prefix = root
+	}
+	if err := h.checkVaultSandbox(pluginID, prefix); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	watcher := &vaultWatcher{pluginID: pluginID, prefix: cleanVaultPath(prefix), ch: make(chan []byte, 64)}
+	if err := h.addVaultWatcher(r.Context(), watcher); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer h.removeVaultWatcher(watcher)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	_, _ = w.Write([]byte(":ok\n\n"))
+	flusher.Flush()
+
+	h.streamSSE(w, flusher, r, watcher.ch)
+}
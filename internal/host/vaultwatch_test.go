@@ -0,0 +1,96 @@
+package host
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffVaultSnapshots(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	t1 := time.Unix(2000, 0)
+	prev := map[string]vaultFileState{
+		"same.md":    {size: 1, modTime: t0},
+		"resized.md": {size: 1, modTime: t0},
+		"touched.md": {size: 1, modTime: t0},
+		"gone.md":    {size: 1, modTime: t0},
+	}
+	cur := map[string]vaultFileState{
+		"same.md":    {size: 1, modTime: t0},
+		"resized.md": {size: 2, modTime: t0},
+		"touched.md": {size: 1, modTime: t1},
+		"added.md":   {size: 3, modTime: t1},
+	}
+
+	var got [][2]string
+	for _, ev := range diffVaultSnapshots(prev, cur) {
+		got = append(got, [2]string{ev.Type, ev.Data.(map[string]any)["path"].(string)})
+	}
+	want := [][2]string{
+		{"vault.created", "added.md"},
+		{"vault.deleted", "gone.md"},
+		{"vault.updated", "resized.md"},
+		{"vault.updated", "touched.md"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diff = %v, want %v", got, want)
+	}
+	if evs := diffVaultSnapshots(cur, cur); len(evs) != 0 {
+		t.Errorf("identical snapshots produced %d events", len(evs))
+	}
+}
+
+func TestVaultSnapshot(t *testing.T) {
+	h := newTestHost(t, Config{})
+	if err := os.MkdirAll(filepath.Join(h.config.VaultDir, "notes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(h.config.VaultDir, "notes", "a.md"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := h.vaultSnapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, ok := snapshot["notes/a.md"]; !ok || state.size != 5 {
+		t.Fatalf("snapshot = %+v, want notes/a.md with size 5", snapshot)
+	}
+}
+
+func TestDispatchVaultEventFiltersWatchers(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "reader", "vault.read")
+	addTestPlugin(t, h, "blind")
+	addTestPlugin(t, h, "boxed", "vault.read")
+	h.plugins["boxed"].Manifest.Sandbox = &Sandbox{VaultRoot: "private"}
+
+	newWatcher := func(pluginID, prefix string) *vaultWatcher {
+		return &vaultWatcher{pluginID: pluginID, prefix: cleanVaultPath(prefix), ch: make(chan []byte, 4)}
+	}
+	all := newWatcher("reader", "")
+	notes := newWatcher("reader", "notes")
+	other := newWatcher("reader", "other")
+	blind := newWatcher("blind", "")
+	boxed := newWatcher("boxed", "")
+	h.vaultWatchers = map[*vaultWatcher]struct{}{all: {}, notes: {}, other: {}, blind: {}, boxed: {}}
+
+	h.dispatchVaultEvent(vaultChangeEvent("vault.created", "notes/a.md", vaultFileState{size: 1}))
+
+	for name, c := range map[string]struct {
+		w    *vaultWatcher
+		want int
+	}{
+		"no prefix":       {all, 1},
+		"matching prefix": {notes, 1},
+		"other prefix":    {other, 0},
+		"no permission":   {blind, 0},
+		"outside sandbox": {boxed, 0},
+	} {
+		if got := len(c.w.ch); got != c.want {
+			t.Errorf("%s: got %d events, want %d", name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package host
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// compareVersions 按语义化版本比较 a 和 b，返回 -1、0 或 1
+// 数字段逐段按数值比较（1.10.0 > 1.9.0），带预发布后缀的版本低于对应的正式版本，
+// 预发布后缀之间按 comparePrerelease 比较
+func compareVersions(a, b string) int {
+	aCore, aPre := splitVersion(a)
+	bCore, bPre := splitVersion(b)
+
+	for i := 0; i < len(aCore) || i < len(bCore); i++ {
+		var x, y int
+		if i < len(aCore) {
+			x = aCore[i]
+		}
+		if i < len(bCore) {
+			y = bCore[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	default:
+		return comparePrerelease(aPre, bPre)
+	}
+}
+
+// comparePrerelease 按 SemVer 规则比较预发布后缀：以点分隔逐个标识符比较，纯数字标识符按数值比较
+// （rc.10 > rc.2）且低于非数字标识符，前面的标识符都相同时标识符较少的一方较低（alpha < alpha.1）
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, y := as[i], bs[i]
+		xNum, yNum := isNumericIdentifier(x), isNumericIdentifier(y)
+		switch {
+		case xNum && yNum:
+			// 去掉前导零后先比长度再比字典序，避免超长数字溢出
+			x, y = strings.TrimLeft(x, "0"), strings.TrimLeft(y, "0")
+			if len(x) != len(y) {
+				if len(x) < len(y) {
+					return -1
+				}
+				return 1
+			}
+		case xNum:
+			return -1
+		case yNum:
+			return 1
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// isNumericIdentifier 判断预发布标识符是否只由数字组成
+func isNumericIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// splitVersion 拆分出数字段和预发布后缀，忽略前缀 v 和构建元数据
+func splitVersion(v string) ([]int, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var pre string
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		nums[i], _ = strconv.Atoi(p)
+	}
+	return nums, pre
+}
+
+// versionComparator 版本范围中的单个比较条件，op 为 =、>、>=、<、<=
+type versionComparator struct {
+	op      string
+	version string
+}
+
+// versionInRange 判断 version 是否满足 npm 风格的版本范围。支持 =、>、>=、<、<=、^、~、
+// x/* 通配和省略的段（1.2 等同于 1.2.x），空格连接的条件须同时满足，|| 连接的条件满足其一即可
+func versionInRange(version, rng string) (bool, error) {
+	sets, err := parseVersionRange(rng)
+	if err != nil {
+		return false, err
+	}
+	for _, set := range sets {
+		ok := allowsPrerelease(version, set)
+		for _, c := range set {
+			if !c.match(version) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// allowsPrerelease 与 npm 一致，预发布版本只被含有相同 x.y.z 预发布条件的条件组接受，
+// 避免 ^1.2.0 匹配 2.0.0-rc.1
+func allowsPrerelease(version string, set []versionComparator) bool {
+	core, pre := splitVersion(version)
+	if pre == "" {
+		return true
+	}
+	for _, c := range set {
+		if cCore, cPre := splitVersion(c.version); cPre != "" && slices.Equal(core, cCore) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c versionComparator) match(version string) bool {
+	cmp := compareVersions(version, c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// parseVersionRange 把版本范围解析为条件组，任一组的条件全部满足即匹配，空组匹配任意版本
+func parseVersionRange(rng string) ([][]versionComparator, error) {
+	var sets [][]versionComparator
+	for _, group := range strings.Split(rng, "||") {
+		set := []versionComparator{}
+		for _, term := range strings.Fields(group) {
+			cs, err := parseVersionTerm(term)
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, cs...)
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// parseVersionTerm 把 ^1.2、~1.2.3、>=1.0、1.x 等单个条件展开为比较条件
+func parseVersionTerm(term string) ([]versionComparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op, term = prefix, term[len(prefix):]
+			break
+		}
+	}
+	parts, pre, err := parsePartialVersion(term)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		// * 和 x 不限制版本
+		return nil, nil
+	}
+	lower := joinVersion(parts, pre)
+	exact := len(parts) == 3
+	switch op {
+	case "", "=":
+		if exact {
+			return []versionComparator{{"=", lower}}, nil
+		}
+		return []versionComparator{{">=", lower}, {"<", bumpVersion(parts, len(parts)-1)}}, nil
+	case "^":
+		// 第一个非零段之前的段保持不变，全为零时锁定最后一段
+		i := len(parts) - 1
+		for j, p := range parts {
+			if p != 0 {
+				i = j
+				break
+			}
+		}
+		return []versionComparator{{">=", lower}, {"<", bumpVersion(parts, i)}}, nil
+	case "~":
+		return []versionComparator{{">=", lower}, {"<", bumpVersion(parts, min(len(parts)-1, 1))}}, nil
+	case ">":
+		if exact {
+			return []versionComparator{{">", lower}}, nil
+		}
+		return []versionComparator{{">=", bumpVersion(parts, len(parts)-1)}}, nil
+	case "<=":
+		if exact {
+			return []versionComparator{{"<=", lower}}, nil
+		}
+		return []versionComparator{{"<", bumpVersion(parts, len(parts)-1)}}, nil
+	default:
+		return []versionComparator{{op, lower}}, nil
+	}
+}
+
+// parsePartialVersion 解析可能省略段或使用 x/* 通配的版本，只返回通配之前给出的数字段
+func parsePartialVersion(v string) ([]int, string, error) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var pre string
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+	if v == "" {
+		return nil, "", fmt.Errorf("invalid version %q", v)
+	}
+	segs := strings.Split(v, ".")
+	if len(segs) > 3 {
+		return nil, "", fmt.Errorf("invalid version %q", v)
+	}
+	var parts []int
+	for _, s := range segs {
+		if s == "x" || s == "X" || s == "*" {
+			break
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, "", fmt.Errorf("invalid version %q", v)
+		}
+		parts = append(parts, n)
+	}
+	if len(parts) < 3 {
+		pre = ""
+	}
+	return parts, pre, nil
+}
+
+// joinVersion 把给出的数字段补齐为 x.y.z
+func joinVersion(parts []int, pre string) string {
+	segs := []string{"0", "0", "0"}
+	for i, p := range parts {
+		segs[i] = strconv.Itoa(p)
+	}
+	v := strings.Join(segs, ".")
+	if pre != "" {
+		v += "-" + pre
+	}
+	return v
+}
+
+// bumpVersion 返回第 i 段加一、之后各段归零的版本，作为范围的上界
+func bumpVersion(parts []int, i int) string {
+	bumped := make([]int, i+1)
+	copy(bumped, parts[:i+1])
+	bumped[i]++
+	return joinVersion(bumped, "")
+}
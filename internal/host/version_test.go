@@ -0,0 +1,94 @@
+package host
+
+import "testing"
+
+func TestVersionInRange(t *testing.T) {
+	cases := []struct {
+		version, rng string
+		want         bool
+	}{
+		{"1.0.0", "", true},
+		{"1.0.0", "*", true},
+		{"1.0.0", "1.0.0", true},
+		{"1.0.1", "=1.0.0", false},
+		{"1.4.2", "1.x", true},
+		{"2.0.0", "1.x", false},
+		{"1.4.2", "1.4", true},
+		{"1.5.0", ">=1.0.0 <2.0.0", true},
+		{"2.0.0", ">=1.0.0 <2.0.0", false},
+		{"0.9.0", ">=1.0.0 <2.0.0", false},
+		{"1.3.0", ">1.2", true},
+		{"1.2.9", ">1.2", false},
+		{"1.2.9", "<=1.2", true},
+		{"1.3.0", "<=1.2", false},
+		// ||
+		{"3.1.0", "^1.0.0 || ^3.0.0", true},
+		{"2.1.0", "^1.0.0 || ^3.0.0", false},
+		{"1.0.0", "<0.5.0 || >=1.0.0", true},
+		// ^
+		{"1.9.9", "^1.2.3", true},
+		{"1.2.2", "^1.2.3", false},
+		{"2.0.0", "^1.2.3", false},
+		{"0.2.9", "^0.2.3", true},
+		{"0.3.0", "^0.2.3", false},
+		{"0.0.3", "^0.0.3", true},
+		{"0.0.4", "^0.0.3", false},
+		// ~
+		{"1.2.9", "~1.2.3", true},
+		{"1.3.0", "~1.2.3", false},
+		{"1.9.0", "~1", true},
+		{"2.0.0", "~1", false},
+		// 预发布版本
+		{"1.0.0-beta", "^1.0.0", false},
+		{"2.0.0-rc.1", "^1.2.0", false},
+		{"2.0.0-rc.1", ">=2.0.0-rc.0", true},
+		{"2.0.0-rc.1", ">=2.0.0-rc.2", false},
+		{"2.0.0", ">=2.0.0-rc.1", true},
+		{"1.3.0-beta", ">=1.2.0-beta <2.0.0", false},
+		{"v1.2.3", "^1.0.0", true},
+	}
+	for _, c := range cases {
+		got, err := versionInRange(c.version, c.rng)
+		if err != nil {
+			t.Errorf("versionInRange(%q, %q): %v", c.version, c.rng, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("versionInRange(%q, %q) = %v, want %v", c.version, c.rng, got, c.want)
+		}
+	}
+}
+
+func TestVersionInRangeInvalid(t *testing.T) {
+	for _, rng := range []string{">=abc", "1.2.3.4", "^", "1.-2"} {
+		if _, err := versionInRange("1.0.0", rng); err == nil {
+			t.Errorf("versionInRange(%q) should fail", rng)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.0", "1.9.0", 1},
+		{"1.0.0", "1.0.0", 0},
+		{"v1.0.0", "1.0.0", 0},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"1.0.0-rc.10", "1.0.0-rc.2", 1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-rc.1", "1.0.0-rc.1", 0},
+		{"1.0.0-rc.99999999999999999999", "1.0.0-rc.100", 1},
+	}
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
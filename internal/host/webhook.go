@@ -0,0 +1,175 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Webhook 投递参数
+const (
+	// webhookQueueSize 每个 Webhook 待投递事件的缓冲数量，队列满时丢弃新事件
+	webhookQueueSize = 64
+	// webhookMaxAttempts 单个事件的最大投递次数
+	webhookMaxAttempts = 3
+	// webhookRetryDelay 首次重试前的等待时间，之后按次数线性增加
+	webhookRetryDelay = time.Second
+	// webhookTimeout 单次请求的超时时间
+	webhookTimeout = 10 * time.Second
+	// webhookBreakerThreshold 连续投递失败达到该次数后熔断
+	webhookBreakerThreshold = 5
+	// webhookBreakerCooldown 熔断持续时间，期间的事件直接丢弃
+	webhookBreakerCooldown = time.Minute
+)
+
+// WebhookConfig 事件通知的外部地址
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// Events 需要投递的事件类型，支持 "*" 和 "plugin.*" 形式的前缀匹配，为空时投递全部事件
+	Events []string `json:"events,omitempty"`
+	// Secret 不为空时用 HMAC-SHA256 对请求体签名，写入 X-Signature 请求头
+	Secret string `json:"secret,omitempty"`
+}
+
+// matches 判断事件类型是否在过滤列表中
+func (c WebhookConfig) matches(eventType string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == "*" || e == eventType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(e, "*"); ok && strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookPayload 投递给 Webhook 的请求体
+type webhookPayload struct {
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// webhook 单个 Webhook 的投递队列和熔断状态，状态只由投递协程访问
+type webhook struct {
+	cfg       WebhookConfig
+	client    *http.Client
+	queue     chan webhookDelivery
+	failures  int
+	openUntil time.Time
+}
+
+type webhookDelivery struct {
+	eventType string
+	body      []byte
+}
+
+// newWebhooks 为每个配置创建投递队列并启动投递协程
+func newWebhooks(cfgs []WebhookConfig) []*webhook {
+	hooks := make([]*webhook, 0, len(cfgs))
+	for _, c := range cfgs {
+		wh := &webhook{
+			cfg:    c,
+			client: &http.Client{Timeout: webhookTimeout},
+			queue:  make(chan webhookDelivery, webhookQueueSize),
+		}
+		go wh.run()
+		hooks = append(hooks, wh)
+	}
+	return hooks
+}
+
+// dispatchWebhooks 把事件放入匹配的 Webhook 队列，不会阻塞广播
+func (h *PluginHost) dispatchWebhooks(ev Event) {
+	if len(h.webhooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(webhookPayload{Type: ev.Type, Data: ev.Data, Timestamp: time.Now().UTC()})
+	if err != nil {
+		log.Printf("webhook: encode %s: %v", ev.Type, err)
+		return
+	}
+	for _, wh := range h.webhooks {
+		if !wh.cfg.matches(ev.Type) {
+			continue
+		}
+		select {
+		case wh.queue <- webhookDelivery{eventType: ev.Type, body: body}:
+		default:
+			log.Printf("webhook %s: queue full, dropping %s", wh.cfg.URL, ev.Type)
+		}
+	}
+}
+
+func (wh *webhook) run() {
+	for d := range wh.queue {
+		if time.Now().Before(wh.openUntil) {
+			continue
+		}
+		var err error
+		for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+			if err = wh.post(d); err == nil {
+				break
+			}
+			if attempt < webhookMaxAttempts {
+				time.Sleep(time.Duration(attempt) * webhookRetryDelay)
+			}
+		}
+		if err == nil {
+			wh.failures = 0
+			continue
+		}
+		wh.failures++
+		log.Printf("webhook %s: deliver %s: %v", wh.cfg.URL, d.eventType, err)
+		if wh.failures >= webhookBreakerThreshold {
+			wh.openUntil = time.Now().Add(webhookBreakerCooldown)
+			wh.failures = 0
+			log.Printf("webhook %s: circuit open for %s", wh.cfg.URL, webhookBreakerCooldown)
+		}
+	}
+}
+
+// post 发送一次请求，2xx 视为成功
+func (wh *webhook) post(d webhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.cfg.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", d.eventType)
+	if wh.cfg.Secret != "" {
+		req.Header.Set("X-Signature", signWebhook(wh.cfg.Secret, d.body))
+	}
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signWebhook 返回 "sha256=<hex>" 格式的 HMAC-SHA256 签名
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
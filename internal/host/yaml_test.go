@@ -0,0 +1,97 @@
+package host
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAMLDocument(t *testing.T) {
+	doc, err := parseYAMLDocument(strings.Join([]string{
+		"---",
+		"id: notes # comment",
+		"version: \"1.0\"",
+		"count: 3",
+		"enabled: true",
+		"homepage: https://example.com/a",
+		"tags: [a, b]",
+		"permissions:",
+		"  - vault.read",
+		"  - vault.write",
+		"entrypoints:",
+		"  backend: http://localhost:3000",
+		"commands:",
+		"- id: open",
+		"  title: Open",
+	}, "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"id":          "notes",
+		"version":     "1.0",
+		"count":       int64(3),
+		"enabled":     true,
+		"homepage":    "https://example.com/a",
+		"tags":        []any{"a", "b"},
+		"permissions": []any{"vault.read", "vault.write"},
+		"entrypoints": map[string]any{"backend": "http://localhost:3000"},
+		"commands":    []any{map[string]any{"id": "open", "title": "Open"}},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Fatalf("parsed = %#v\nwant %#v", doc, want)
+	}
+}
+
+func TestParseYAMLDocumentErrors(t *testing.T) {
+	cases := map[string]string{
+		"a: 1\na: 2":        "duplicate key",
+		"a: |\n  text":      "multi-line",
+		"a: &x 1":           "anchors",
+		"a: {b: 1}":         "inline mappings",
+		"a: 1\n\tb: 2":      "tabs",
+		"- item":            "unexpected list item",
+		"a: [1, 2":          "unterminated list",
+		"a:\n  b: 1\n c: 2": "unexpected indentation",
+		"just text":         "expected key: value",
+	}
+	for input, want := range cases {
+		_, err := parseYAMLDocument(input)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseYAMLDocument(%q) error = %v, want %q", input, err, want)
+		}
+	}
+}
+
+func TestReadVaultMeta(t *testing.T) {
+	h := newTestHost(t, Config{})
+	content := "---\ntitle: Hello\ntags:\n  - a\n  - b\nauthor:\n  name: Ann\n---\nBody text"
+	if err := h.writeVaultFile("note.md", []byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := h.readVaultMeta("note.md", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Error != "" {
+		t.Fatalf("unexpected error: %s", meta.Error)
+	}
+	want := map[string]any{"title": "Hello", "tags": []any{"a", "b"}, "author": map[string]any{"name": "Ann"}}
+	if !reflect.DeepEqual(meta.Meta, want) {
+		t.Fatalf("meta = %#v", meta.Meta)
+	}
+	if meta.Preview != "Body text" || meta.BodyOffset != int64(len(content)-len("Body text")) {
+		t.Fatalf("preview = %q offset = %d", meta.Preview, meta.BodyOffset)
+	}
+
+	if err := h.writeVaultFile("bad.md", []byte("---\ntitle: a\ntitle: b\n---\n")); err != nil {
+		t.Fatal(err)
+	}
+	meta, err = h.readVaultMeta("bad.md", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(meta.Error, "line 3") {
+		t.Fatalf("error should report the file line number, got %q", meta.Error)
+	}
+}
@@ -0,0 +1,105 @@
+package host
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 解压限制
+const (
+	// maxZipEntries 压缩包允许的最多条目数
+	maxZipEntries = 10000
+	// maxZipRatio 单个条目解压后与压缩后大小之比的上限
+	maxZipRatio = 100
+	// zipRatioMinBytes 条目解压超过该大小后才检查压缩比，避免误判很小的高压缩率文件
+	zipRatioMinBytes = 1 << 20
+)
+
+var (
+	// ErrZipBomb 压缩包条目过多、解压后过大或压缩比异常
+	ErrZipBomb = errors.New("suspicious archive")
+	// ErrUnsafeZipEntry 压缩包中含符号链接、设备文件等非普通文件条目，或解压目标经过符号链接
+	ErrUnsafeZipEntry = errors.New("unsafe archive entry")
+)
+
+// checkZipEntries 检查压缩包的条目数
+func checkZipEntries(zr *zip.Reader) error {
+	if n := len(zr.File); n > maxZipEntries {
+		return fmt.Errorf("%w: %d entries exceeds limit %d", ErrZipBomb, n, maxZipEntries)
+	}
+	return nil
+}
+
+// checkZipEntryType 只允许普通文件和目录条目
+func checkZipEntryType(f *zip.File) error {
+	if mode := f.Mode(); !mode.IsRegular() && !mode.IsDir() {
+		return fmt.Errorf("%w: %s has type %s", ErrUnsafeZipEntry, f.Name, mode.Type())
+	}
+	return nil
+}
+
+// checkNoSymlink 检查 root 到 target 之间已存在的路径都不是符号链接，
+// 避免写入时跟随链接写到 root 之外
+func checkNoSymlink(root, target string) error {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return err
+	}
+	cur := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		cur = filepath.Join(cur, part)
+		info, err := os.Lstat(cur)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s is a symlink", ErrUnsafeZipEntry, cur)
+		}
+	}
+	return nil
+}
+
+// zipGuard 统计一次解压的总字节数，超过 maxTotal（0 表示不限制）
+// 或单个条目压缩比过高时读取返回 ErrZipBomb
+type zipGuard struct {
+	maxTotal int64
+	total    int64
+}
+
+// open 打开条目，返回的读取器按实际解压的字节数检查限制，不信任条目头中声明的大小
+func (g *zipGuard) open(f *zip.File) (io.ReadCloser, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &guardedEntry{ReadCloser: rc, guard: g, name: f.Name, compressed: int64(f.CompressedSize64)}, nil
+}
+
+type guardedEntry struct {
+	io.ReadCloser
+	guard      *zipGuard
+	name       string
+	compressed int64
+	read       int64
+}
+
+func (e *guardedEntry) Read(p []byte) (int, error) {
+	n, err := e.ReadCloser.Read(p)
+	e.read += int64(n)
+	e.guard.total += int64(n)
+	if g := e.guard; g.maxTotal > 0 && g.total > g.maxTotal {
+		return n, fmt.Errorf("%w: uncompressed size exceeds %d bytes", ErrZipBomb, g.maxTotal)
+	}
+	if e.read > zipRatioMinBytes && e.read > e.compressed*maxZipRatio {
+		return n, fmt.Errorf("%w: %s compression ratio exceeds %d", ErrZipBomb, e.name, maxZipRatio)
+	}
+	return n, err
+}
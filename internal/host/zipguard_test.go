@@ -0,0 +1,93 @@
+package host
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSymlinkZip 写入一个普通文件和一个指向 /etc/passwd 的符号链接条目
+func writeSymlinkZip(t *testing.T, path string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	hdr := &zip.FileHeader{Name: "passwd", Method: zip.Store}
+	hdr.SetMode(os.ModeSymlink | 0o777)
+	if w, err = zw.CreateHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("/etc/passwd")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreBackupRejectsSymlinkEntry(t *testing.T) {
+	backup := filepath.Join(t.TempDir(), "evil.zip")
+	writeSymlinkZip(t, backup)
+	dest := t.TempDir()
+
+	if err := RestoreBackup(backup, dest); !errors.Is(err, ErrUnsafeZipEntry) {
+		t.Fatalf("got %v, want ErrUnsafeZipEntry", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "passwd")); !os.IsNotExist(err) {
+		t.Errorf("symlink entry was written: %v", err)
+	}
+}
+
+func TestRestoreBackupDoesNotFollowSymlinkedDir(t *testing.T) {
+	backup := filepath.Join(t.TempDir(), "backup.zip")
+	writeTestZip(t, backup, map[string][]byte{"sub/a.txt": []byte("x")})
+	dest, outside := t.TempDir(), t.TempDir()
+	// 目标目录中已有指向外部的符号链接，写入时不能跟随
+	if err := os.Symlink(outside, filepath.Join(dest, "sub")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if err := RestoreBackup(backup, dest); !errors.Is(err, ErrUnsafeZipEntry) {
+		t.Fatalf("got %v, want ErrUnsafeZipEntry", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "a.txt")); !os.IsNotExist(err) {
+		t.Error("file was written through the symlink")
+	}
+}
+
+func TestVaultImportSkipsSymlinkEntry(t *testing.T) {
+	h := newTestHost(t, Config{})
+	addTestPlugin(t, h, "rw", "vault.read", "vault.write")
+	zipPath := filepath.Join(t.TempDir(), "import.zip")
+	writeSymlinkZip(t, zipPath)
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.handleVaultImport(w, httptest.NewRequest(http.MethodPost, "/vault/import?pluginId=rw", bytes.NewReader(data)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("import: got %d %s", w.Code, w.Body.String())
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`"unsupported entry type"`)) {
+		t.Errorf("symlink entry not reported as skipped: %s", w.Body.String())
+	}
+	if _, err := os.Lstat(filepath.Join(h.config.VaultDir, "passwd")); !os.IsNotExist(err) {
+		t.Errorf("symlink entry was imported: %v", err)
+	}
+}